@@ -1,3 +1,5 @@
 module github.com/kebaren/gapbuffer
 
 go 1.24.0
+
+require golang.org/x/text v0.34.0
@@ -0,0 +1,109 @@
+package buffer
+
+import (
+	"context"
+	"io"
+)
+
+// ActorEditor applies edits to a buffer on behalf of an actor (a user, a
+// plugin, a remote peer). The actor is recorded with every resulting
+// operation and reported by Operations and BlameRange.
+type ActorEditor struct {
+	gb    *GapBuffer
+	actor string
+}
+
+// WithActor returns an editor whose edits are attributed to actor
+func (gb *GapBuffer) WithActor(actor string) *ActorEditor {
+	return &ActorEditor{gb: gb, actor: actor}
+}
+
+// Actor returns the identity the editor attributes edits to
+func (a *ActorEditor) Actor() string {
+	return a.actor
+}
+
+// run calls fn with the buffer's current actor set to a.actor
+func (a *ActorEditor) run(fn func() error) error {
+	prev := a.gb.actor
+	a.gb.actor = a.actor
+	defer func() { a.gb.actor = prev }()
+	return fn()
+}
+
+// InsertAt inserts text at the specified position
+func (a *ActorEditor) InsertAt(pos int, text string) error {
+	return a.run(func() error { return a.gb.InsertAt(pos, text) })
+}
+
+// InsertRuneAt 在指定的Unicode字符位置插入文本
+func (a *ActorEditor) InsertRuneAt(runePos int, text string) error {
+	return a.run(func() error { return a.gb.InsertRuneAt(runePos, text) })
+}
+
+// DeleteAt deletes text at the specified position
+func (a *ActorEditor) DeleteAt(pos int, count int) error {
+	return a.run(func() error { return a.gb.DeleteAt(pos, count) })
+}
+
+// DeleteRuneAt 删除从指定Unicode字符位置开始的指定数量的Unicode字符
+func (a *ActorEditor) DeleteRuneAt(runePos int, runeCount int) error {
+	return a.run(func() error { return a.gb.DeleteRuneAt(runePos, runeCount) })
+}
+
+// Replace replaces the text in the specified range
+func (a *ActorEditor) Replace(start int, end int, text string) error {
+	return a.run(func() error { return a.gb.Replace(start, end, text) })
+}
+
+// ReplaceRune 替换指定Unicode字符范围的文本
+func (a *ActorEditor) ReplaceRune(runeStart int, runeEnd int, text string) error {
+	return a.run(func() error { return a.gb.ReplaceRune(runeStart, runeEnd, text) })
+}
+
+// AcceptSuggestion applies a pending suggestion on behalf of the actor
+func (a *ActorEditor) AcceptSuggestion(id int) error {
+	return a.run(func() error { return a.gb.AcceptSuggestion(id) })
+}
+
+// ApplyEdits applies a set of edits on behalf of the actor, see
+// GapBuffer.ApplyEdits
+func (a *ActorEditor) ApplyEdits(edits []Edit, policy OverlapPolicy) (applied []AppliedEdit, err error) {
+	err = a.run(func() error {
+		applied, err = a.gb.ApplyEdits(edits, policy)
+		return err
+	})
+	return applied, err
+}
+
+// ApplyContentChanges applies LSP content changes on behalf of the actor
+func (a *ActorEditor) ApplyContentChanges(changes []ContentChange) error {
+	return a.run(func() error { return a.gb.ApplyContentChanges(changes) })
+}
+
+// ReadFrom appends everything r produces on behalf of the actor, see
+// GapBuffer.ReadFrom
+func (a *ActorEditor) ReadFrom(r io.Reader) (n int64, err error) {
+	err = a.run(func() error {
+		n, err = a.gb.ReadFrom(r)
+		return err
+	})
+	return n, err
+}
+
+// PipeThroughCommand replaces the text in r with the output of the command
+// on behalf of the actor, see GapBuffer.PipeThroughCommand
+func (a *ActorEditor) PipeThroughCommand(ctx context.Context, name string, args []string, r *Range) (applied []AppliedEdit, err error) {
+	err = a.run(func() error {
+		applied, err = a.gb.PipeThroughCommand(ctx, name, args, r)
+		return err
+	})
+	return applied, err
+}
+
+// Transaction runs fn in a transaction as GapBuffer.Transaction does. Every
+// edit fn makes on the buffer, directly or through another method, is
+// attributed to the actor unless it goes through another ActorEditor.
+func (a *ActorEditor) Transaction(fn func() error) error {
+	return a.run(func() error { return a.gb.Transaction(fn) })
+}
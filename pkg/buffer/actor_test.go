@@ -0,0 +1,75 @@
+package buffer_test
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestActorEditor checks that every kind of edit made through an editor,
+// a transaction's included, is recorded with its actor and that direct
+// edits keep the buffer's own
+func TestActorEditor(t *testing.T) {
+	gb := newBuffer(t, "hello\n")
+	alice := gb.WithActor("alice")
+
+	edits := []struct {
+		name string
+		edit func() error
+	}{
+		{"InsertAt", func() error { return alice.InsertAt(0, "> ") }},
+		{"ApplyEdits", func() error {
+			_, err := alice.ApplyEdits([]buffer.Edit{{Start: 0, End: 1, Text: "|"}}, buffer.OverlapError)
+			return err
+		}},
+		{"ApplyContentChanges", func() error {
+			return alice.ApplyContentChanges([]buffer.ContentChange{{Text: "world\n"}})
+		}},
+		{"ReadFrom", func() error {
+			_, err := alice.ReadFrom(strings.NewReader("more\n"))
+			return err
+		}},
+		{"Transaction", func() error {
+			return alice.Transaction(func() error {
+				if err := gb.InsertAt(0, "a"); err != nil {
+					return err
+				}
+				return gb.DeleteAt(0, 1)
+			})
+		}},
+	}
+	if _, err := exec.LookPath("sed"); err == nil {
+		edits = append(edits, struct {
+			name string
+			edit func() error
+		}{"PipeThroughCommand", func() error {
+			_, err := alice.PipeThroughCommand(context.Background(), "sed", []string{"s/more/less/"}, nil)
+			return err
+		}})
+	}
+	for _, tt := range edits {
+		since := gb.Version()
+		if err := tt.edit(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		ops, err := gb.Operations(since)
+		if err != nil || len(ops) == 0 {
+			t.Fatalf("%s: got %+v, %v", tt.name, ops, err)
+		}
+		for _, op := range ops {
+			if op.Actor != "alice" {
+				t.Errorf("%s: operation %d by %q, want alice", tt.name, op.Version, op.Actor)
+			}
+		}
+	}
+
+	since := gb.Version()
+	gb.InsertAt(0, "!")
+	ops, _ := gb.Operations(since)
+	if len(ops) != 1 || ops[0].Actor != "" {
+		t.Errorf("direct edit: got %+v, want no actor", ops)
+	}
+}
@@ -0,0 +1,123 @@
+package buffer
+
+import (
+	"context"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Analyzer computes statistics over the text of a buffer in a pass of
+// Analyze
+type Analyzer interface {
+	// AnalyzeChunk is called with the text in order, one piece at a time,
+	// with the position of its first byte. Pieces end between runes, except
+	// for invalid UTF-8 at the end of the text. An error stops the pass.
+	AnalyzeChunk(pos int, text string) error
+}
+
+// AnalyzerFunc adapts a function to the Analyzer interface
+type AnalyzerFunc func(pos int, text string) error
+
+// AnalyzeChunk calls f
+func (f AnalyzerFunc) AnalyzeChunk(pos int, text string) error {
+	return f(pos, text)
+}
+
+// Analyze streams the text through all analyzers in a single pass over the
+// chunks, so features computing their own statistics share one traversal
+// instead of flattening or walking the buffer each. The buffer is frozen
+// meanwhile, as by View. The pass stops with ctx.Err() once ctx is done,
+// checked between pieces, or with the first error an analyzer returns.
+func (gb *GapBuffer) Analyze(ctx context.Context, analyzers ...Analyzer) (err error) {
+	defer guard("Analyze", noPos, gb.length, &err)
+
+	gb.views++
+	defer func() { gb.views-- }()
+
+	carry := "" // incomplete rune held back from the previous piece
+	gb.forEachChunk(0, gb.length, func(pos int, text string) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+		if carry != "" {
+			pos -= len(carry)
+			text = carry + text
+			carry = ""
+		}
+		if pos+len(text) < gb.length {
+			cut := incompleteRuneStart(text)
+			text, carry = text[:cut], text[cut:]
+		}
+		if text == "" {
+			return true
+		}
+		for _, a := range analyzers {
+			if err = a.AnalyzeChunk(pos, text); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+	return err
+}
+
+// incompleteRuneStart returns the offset of the UTF-8 sequence text ends
+// in the middle of, or the length of text if it does not
+func incompleteRuneStart(text string) int {
+	for start := len(text) - 1; start >= max(len(text)-utf8.UTFMax, 0); start-- {
+		if utf8.RuneStart(text[start]) {
+			if !utf8.FullRuneInString(text[start:]) {
+				return start
+			}
+			break
+		}
+	}
+	return len(text)
+}
+
+// WordCount is an Analyzer counting like wc: newlines, words separated by
+// Unicode white space, runes and bytes. Invalid bytes count as one rune
+// each, as when decoding a string.
+type WordCount struct {
+	Lines int
+	Words int
+	Runes int
+	Bytes int
+
+	inWord bool
+}
+
+// AnalyzeChunk implements Analyzer
+func (c *WordCount) AnalyzeChunk(_ int, text string) error {
+	c.Bytes += len(text)
+	for _, r := range text {
+		c.Runes++
+		if r == '\n' {
+			c.Lines++
+		}
+		if unicode.IsSpace(r) {
+			c.inWord = false
+		} else if !c.inWord {
+			c.inWord = true
+			c.Words++
+		}
+	}
+	return nil
+}
+
+// RuneHistogram is an Analyzer counting how often every rune occurs.
+// Invalid bytes are counted as utf8.RuneError.
+type RuneHistogram struct {
+	Counts map[rune]int
+}
+
+// AnalyzeChunk implements Analyzer
+func (h *RuneHistogram) AnalyzeChunk(_ int, text string) error {
+	if h.Counts == nil {
+		h.Counts = make(map[rune]int)
+	}
+	for _, r := range text {
+		h.Counts[r]++
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package buffer_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestAnalyze runs the built-in analyzers and one checking the pieces in
+// a single pass over small chunks with runes split between them
+func TestAnalyze(t *testing.T) {
+	gb := buffer.NewWithChunkSize(4)
+	gb.InsertAt(0, "hé中中 words\n\tand  more\nlast")
+	gb.RandomizeLayout(3)
+	text := gb.GetText()
+
+	var wc buffer.WordCount
+	var hist buffer.RuneHistogram
+	var sb strings.Builder
+	pieces := buffer.AnalyzerFunc(func(pos int, piece string) error {
+		if pos != sb.Len() {
+			t.Errorf("piece at %d, want %d", pos, sb.Len())
+		}
+		if !utf8.ValidString(piece) {
+			t.Errorf("piece %q splits a rune", piece)
+		}
+		sb.WriteString(piece)
+		return nil
+	})
+	if err := gb.Analyze(context.Background(), &wc, &hist, pieces); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != text {
+		t.Errorf("pieces make up %q", sb.String())
+	}
+	want := buffer.WordCount{Lines: 2, Words: 5, Runes: utf8.RuneCountInString(text), Bytes: len(text)}
+	if wc.Lines != want.Lines || wc.Words != want.Words || wc.Runes != want.Runes || wc.Bytes != want.Bytes {
+		t.Errorf("got %+v, want %+v", wc, want)
+	}
+	if hist.Counts['中'] != 2 || hist.Counts['é'] != 1 || hist.Counts[' '] != 3 {
+		t.Errorf("got histogram %v", hist.Counts)
+	}
+}
+
+// TestAnalyzeStops checks that a pass stops on a cancelled context or an
+// analyzer error and that the buffer is frozen during it
+func TestAnalyzeStops(t *testing.T) {
+	gb := buffer.NewWithChunkSize(4)
+	gb.InsertAt(0, strings.Repeat("stop ", 20))
+
+	errStop := errors.New("stop")
+	calls := 0
+	stop := buffer.AnalyzerFunc(func(pos int, text string) error {
+		if err := gb.InsertAt(0, "x"); !errors.Is(err, buffer.ErrViewActive) {
+			t.Errorf("edit during a pass: got %v", err)
+		}
+		if calls++; calls == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if err := gb.Analyze(context.Background(), stop); !errors.Is(err, errStop) || calls != 3 {
+		t.Errorf("got %v after %d pieces", err, calls)
+	}
+	if err := gb.InsertAt(0, "x"); err != nil {
+		t.Errorf("edit after a pass: got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = 0
+	cancelling := buffer.AnalyzerFunc(func(int, string) error {
+		calls++
+		cancel()
+		return nil
+	})
+	if err := gb.Analyze(ctx, cancelling); !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("got %v after %d pieces", err, calls)
+	}
+}
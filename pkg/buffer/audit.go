@@ -0,0 +1,77 @@
+package buffer
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// AuditRedactor rewrites the content of an operation before it is exported.
+// It is called for the deleted and the inserted text separately; returning
+// an empty string drops the content and keeps only positions and lengths.
+type AuditRedactor func(op Operation, text string) string
+
+// RedactContent is an AuditRedactor that drops all content
+func RedactContent(op Operation, text string) string {
+	return ""
+}
+
+// operationRecord is the JSON form of an Operation, used by the audit log
+// and the change feed
+type operationRecord struct {
+	Version      int       `json:"version"`
+	Time         time.Time `json:"time"`
+	Actor        string    `json:"actor,omitempty"`
+	Metadata     Metadata  `json:"metadata,omitempty"`
+	Pos          int       `json:"pos"`
+	Deleted      int       `json:"deleted"`
+	Inserted     int       `json:"inserted"`
+	DeletedText  string    `json:"deleted_text,omitempty"`
+	InsertedText string    `json:"inserted_text,omitempty"`
+}
+
+// newOperationRecord converts an operation into its JSON form
+func newOperationRecord(op Operation) operationRecord {
+	return operationRecord{
+		Version:      op.Version,
+		Time:         op.Time,
+		Actor:        op.Actor,
+		Metadata:     op.Metadata,
+		Pos:          op.Pos,
+		Deleted:      op.Deleted,
+		Inserted:     op.Inserted,
+		DeletedText:  op.DeletedText,
+		InsertedText: op.InsertedText,
+	}
+}
+
+// ExportAuditLog writes the operations applied after version since to w as
+// JSON lines, oldest first. If redact is not nil it is applied to all content.
+func (gb *GapBuffer) ExportAuditLog(w io.Writer, since int, redact AuditRedactor) (err error) {
+	defer guard("ExportAuditLog", noPos, gb.length, &err)
+
+	ops, err := gb.oplog.since(since, gb.version)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, op := range ops {
+		if op, err = gb.loadOperation(op); err != nil {
+			return err
+		}
+		record := newOperationRecord(op)
+		if redact != nil && record.DeletedText != "" {
+			record.DeletedText = redact(op, record.DeletedText)
+		}
+		if redact != nil && record.InsertedText != "" {
+			record.InsertedText = redact(op, record.InsertedText)
+		}
+		if err := enc.Encode(&record); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,79 @@
+package buffer_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// auditRecord is the part of an audit log line the tests look at
+type auditRecord struct {
+	Version      int    `json:"version"`
+	Actor        string `json:"actor"`
+	Pos          int    `json:"pos"`
+	Deleted      int    `json:"deleted"`
+	Inserted     int    `json:"inserted"`
+	DeletedText  string `json:"deleted_text"`
+	InsertedText string `json:"inserted_text"`
+}
+
+// exportAudit returns the records ExportAuditLog writes
+func exportAudit(t *testing.T, gb *buffer.GapBuffer, since int, redact buffer.AuditRedactor) []auditRecord {
+	t.Helper()
+	var sb strings.Builder
+	if err := gb.ExportAuditLog(&sb, since, redact); err != nil {
+		t.Fatal(err)
+	}
+	var records []auditRecord
+	sc := bufio.NewScanner(strings.NewReader(sb.String()))
+	for sc.Scan() {
+		var r auditRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+// TestExportAuditLog checks the records of the log and that redaction
+// keeps positions and lengths while rewriting or dropping the content
+func TestExportAuditLog(t *testing.T) {
+	gb := buffer.New()
+	gb.InsertAt(0, "user=alice password=hunter2")
+	gb.WithActor("admin").Replace(5, 10, "bob")
+
+	got := exportAudit(t, gb, 0, nil)
+	want := []auditRecord{
+		{Version: 1, Inserted: 27, InsertedText: "user=alice password=hunter2"},
+		{Version: 2, Actor: "admin", Pos: 5, Deleted: 5, Inserted: 3, DeletedText: "alice", InsertedText: "bob"},
+	}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	mask := func(op buffer.Operation, text string) string {
+		if i := strings.Index(text, "password="); i >= 0 {
+			return text[:i] + "password=***"
+		}
+		return text
+	}
+	got = exportAudit(t, gb, 0, mask)
+	if got[0].InsertedText != "user=alice password=***" || got[1].DeletedText != "alice" {
+		t.Errorf("masked: got %+v", got)
+	}
+
+	got = exportAudit(t, gb, 1, buffer.RedactContent)
+	want = []auditRecord{{Version: 2, Actor: "admin", Pos: 5, Deleted: 5, Inserted: 3}}
+	if len(got) != 1 || got[0] != want[0] {
+		t.Errorf("redacted: got %+v, want %+v", got, want)
+	}
+
+	if err := gb.ExportAuditLog(&strings.Builder{}, 3, nil); !errors.Is(err, buffer.ErrVersionUnavailable) {
+		t.Errorf("future version: got %v, want ErrVersionUnavailable", err)
+	}
+}
@@ -0,0 +1,211 @@
+package buffer_test
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// backend makes buffers of one implementation for the workload suite
+type backend struct {
+	name  string
+	new   func(text string) buffer.Buffer
+	clone func(b buffer.Buffer) buffer.Buffer
+}
+
+var backends = []backend{
+	{
+		name: "GapBuffer",
+		new: func(text string) buffer.Buffer {
+			gb := buffer.New()
+			gb.InsertAt(0, text)
+			return gb
+		},
+		clone: func(b buffer.Buffer) buffer.Buffer { return b.(*buffer.GapBuffer).Clone() },
+	},
+	{
+		name:  "Rope",
+		new:   func(text string) buffer.Buffer { return buffer.NewRopeFromString(text) },
+		clone: func(b buffer.Buffer) buffer.Buffer { return b.(*buffer.Rope).Clone() },
+	},
+	{
+		name: "FlatBuffer",
+		new:  func(text string) buffer.Buffer { return buffer.NewFlatBufferFromString(text) },
+		clone: func(b buffer.Buffer) buffer.Buffer {
+			return buffer.NewFlatBufferFromString(b.GetText())
+		},
+	},
+	{
+		name: "PieceTable",
+		new:  func(text string) buffer.Buffer { return buffer.NewPieceTableFromString(text) },
+		clone: func(b buffer.Buffer) buffer.Buffer {
+			return buffer.NewPieceTableFromString(b.GetText())
+		},
+	},
+}
+
+// workload is one benchmark of the standard suite, run on a buffer holding
+// a document of the given size
+type workload struct {
+	name string
+	run  func(b *testing.B, be backend, doc string)
+}
+
+var workloads = []workload{
+	{"Typing", benchTyping},
+	{"RandomEdits", benchRandomEdits},
+	{"Append", benchAppend},
+	{"RandomReads", benchRandomReads},
+	{"Snapshots", benchSnapshots},
+}
+
+// document returns size bytes of source-like text with line breaks
+func document(size int) string {
+	line := "\tfor i := 0; i < n; i++ { sum += values[i] * weight }\n"
+	return strings.Repeat(line, size/len(line)+1)[:size]
+}
+
+// benchTyping inserts one character at a time in the middle of the text,
+// the way a user types
+func benchTyping(b *testing.B, be backend, doc string) {
+	buf := be.new(doc)
+	pos := len(doc) / 2
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.InsertAt(pos, "x")
+		pos++
+	}
+}
+
+// benchRandomEdits replaces a few bytes at random positions
+func benchRandomEdits(b *testing.B, be backend, doc string) {
+	buf := be.new(doc)
+	rng := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pos := rng.Intn(buf.Length() - 8)
+		buf.Replace(pos, pos+4, "edit")
+	}
+}
+
+// benchAppend adds lines at the end, like a log being written
+func benchAppend(b *testing.B, be backend, doc string) {
+	buf := be.new(doc)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.InsertAt(buf.Length(), "appended line\n")
+	}
+}
+
+// benchRandomReads reads a line's worth of text at random positions
+func benchRandomReads(b *testing.B, be backend, doc string) {
+	buf := be.new(doc)
+	rng := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pos := rng.Intn(buf.Length() - 64)
+		buf.GetTextRange(pos, pos+64)
+	}
+}
+
+// benchSnapshots keeps a version after every edit, as a collaboration
+// server does, and reads back an older one now and then
+func benchSnapshots(b *testing.B, be backend, doc string) {
+	buf := be.new(doc)
+	rng := rand.New(rand.NewSource(1))
+	versions := make([]buffer.Buffer, 0, 64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pos := rng.Intn(buf.Length())
+		buf.InsertAt(pos, "v")
+		if len(versions) == cap(versions) {
+			versions = versions[:0]
+		}
+		versions = append(versions, be.clone(buf))
+		if i%16 == 0 {
+			old := versions[rng.Intn(len(versions))]
+			old.GetTextRange(0, min(old.Length(), 64))
+		}
+	}
+}
+
+// BenchmarkWorkloads runs the standard workload suite on every backend, on
+// a small file and a large one
+func BenchmarkWorkloads(b *testing.B) {
+	for _, size := range []int{64 << 10, 16 << 20} {
+		doc := document(size)
+		for _, w := range workloads {
+			for _, be := range backends {
+				b.Run(fmt.Sprintf("%s/%dKB/%s", w.name, size>>10, be.name), func(b *testing.B) {
+					w.run(b, be, doc)
+				})
+			}
+		}
+	}
+}
+
+// BenchmarkTreeVsFlat compares the chunk tree of GapBuffer with the
+// contiguous FlatBuffer as the file grows: the flat buffer wins on small
+// files, where moving the gap is a short copy, and loses once edits far
+// apart move it across megabytes
+func BenchmarkTreeVsFlat(b *testing.B) {
+	tree, flat := backends[0], backends[2]
+	for _, size := range []int{1 << 10, 16 << 10, 256 << 10, 4 << 20, 64 << 20} {
+		doc := document(size)
+		for _, w := range workloads[:4] {
+			for _, be := range []backend{tree, flat} {
+				b.Run(fmt.Sprintf("%s/%dKB/%s", w.name, size>>10, be.name), func(b *testing.B) {
+					w.run(b, be, doc)
+				})
+			}
+		}
+	}
+}
+
+// BenchmarkSnapshotEdit types in the middle of the text taking a snapshot
+// before every keystroke, as a linter run on every change does. Forking
+// copies only the nodes on the path of an edit, so the time per keystroke
+// grows with log n, not with the size of the text.
+func BenchmarkSnapshotEdit(b *testing.B) {
+	for _, size := range []int{64 << 10, 16 << 20} {
+		doc := document(size)
+		b.Run(fmt.Sprintf("%dKB", size>>10), func(b *testing.B) {
+			gb := buffer.New()
+			gb.InsertAt(0, doc)
+			pos := len(doc) / 2
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				gb.Snapshot()
+				gb.InsertAt(pos, "x")
+				pos++
+			}
+		})
+	}
+}
+
+// BenchmarkMarkers types in the middle of a text holding markers spread
+// over it, as diagnostics are. Only the markers at the edit are touched, so
+// the time per keystroke barely depends on how many there are.
+func BenchmarkMarkers(b *testing.B) {
+	doc := document(1 << 20)
+	for _, n := range []int{1000, 100000} {
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			gb := buffer.New()
+			gb.InsertAt(0, doc)
+			specs := make([]buffer.MarkerSpec, n)
+			for i := range specs {
+				specs[i] = buffer.MarkerSpec{Pos: i * len(doc) / n}
+			}
+			gb.CreateMarkers(specs)
+			pos := len(doc) / 2
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				gb.InsertAt(pos, "x")
+				pos++
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package buffer
+
+// SetBinary makes GetText, GetTextRange and GetRuneTextRange return the
+// stored bytes exactly instead of dropping invalid UTF-8, for buffers that
+// hold arbitrary data, as in a hex editor or for binary patching. Edits
+// always store the bytes they are given; Bytes, GetBytesRange, Reader and
+// WriteTo never convert. Rune and line positions still count as if the
+// bytes were UTF-8.
+func (gb *GapBuffer) SetBinary(on bool) {
+	gb.binary = on
+	gb.rangeCache.entries = gb.rangeCache.entries[:0]
+}
+
+// Binary reports whether the buffer is in binary mode, see SetBinary
+func (gb *GapBuffer) Binary() bool {
+	return gb.binary
+}
+
+// Bytes returns a copy of the content of the buffer exactly as stored
+func (gb *GapBuffer) Bytes() []byte {
+	return gb.appendBytes(make([]byte, 0, gb.length), 0, gb.length)
+}
+
+// GetBytesRange returns a copy of the bytes in [start, end) exactly as
+// stored
+func (gb *GapBuffer) GetBytesRange(start int, end int) (b []byte, err error) {
+	defer guard("GetBytesRange", start, gb.length, &err)
+
+	if start < 0 || end > gb.length || start > end {
+		return nil, ErrInvalidRange
+	}
+	return gb.appendBytes(make([]byte, 0, end-start), start, end), nil
+}
+
+// appendBytes appends the bytes in [start, end) to dst
+func (gb *GapBuffer) appendBytes(dst []byte, start int, end int) []byte {
+	gb.forEachChunk(start, end, func(_ int, text string) bool {
+		dst = append(dst, text...)
+		return true
+	})
+	return dst
+}
@@ -0,0 +1,69 @@
+package buffer_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestBinary checks that binary mode returns the stored bytes exactly and
+// that switching modes is not hidden by the range cache
+func TestBinary(t *testing.T) {
+	data := "\x00\xffab\xc3\x28\xe2\x82"
+	gb := buffer.NewWithChunkSize(4)
+	gb.InsertAt(0, data)
+
+	if got := gb.GetText(); got != "\x00ab(" {
+		t.Errorf("text mode: got %q", got)
+	}
+	text, _ := gb.GetTextRange(1, 6)
+	if text != "ab(" {
+		t.Errorf("text mode range: got %q", text)
+	}
+
+	gb.SetBinary(true)
+	if !gb.Binary() {
+		t.Errorf("binary mode is off")
+	}
+	if got := gb.GetText(); got != data {
+		t.Errorf("binary mode: got %q", got)
+	}
+	if got, _ := gb.GetTextRange(1, 6); got != data[1:6] {
+		t.Errorf("binary mode range: got %q", got)
+	}
+	if got, _ := gb.GetRuneTextRange(0, 3); got != data[:3] {
+		t.Errorf("binary mode rune range: got %q", got)
+	}
+
+	gb.SetBinary(false)
+	if got, _ := gb.GetTextRange(1, 6); got != "ab(" {
+		t.Errorf("back in text mode: got %q", got)
+	}
+}
+
+// TestBytes checks that the byte accessors copy the stored bytes in both
+// modes
+func TestBytes(t *testing.T) {
+	data := []byte("\xfe\xff\x00\x01 binary \x80")
+	gb := buffer.NewWithChunkSize(4)
+	gb.InsertAt(0, string(data))
+	for _, binary := range []bool{false, true} {
+		gb.SetBinary(binary)
+		if got := gb.Bytes(); !bytes.Equal(got, data) {
+			t.Errorf("binary %v: got %q", binary, got)
+		}
+		got, err := gb.GetBytesRange(1, 12)
+		if err != nil || !bytes.Equal(got, data[1:12]) {
+			t.Errorf("binary %v: got %q, %v", binary, got, err)
+		}
+		got[0] = 'x'
+		if gb.Bytes()[1] != data[1] {
+			t.Errorf("binary %v: the range shares the buffer's memory", binary)
+		}
+	}
+	if _, err := gb.GetBytesRange(3, 99); !errors.Is(err, buffer.ErrInvalidRange) {
+		t.Errorf("range past the end: got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package buffer
+
+// BlameSpan attributes a range of the text to the operation that last wrote it.
+// Text older than the retained operation log has a zero Operation.
+type BlameSpan struct {
+	Start     int
+	End       int
+	Operation Operation
+}
+
+// blameSegment is a run of text written by the same operation
+type blameSegment struct {
+	length  int
+	version int // version the operation produced, 0 when unknown
+}
+
+// blameCache keeps the segments of the text at a version, so that
+// BlameRange only replays the operations applied since
+type blameCache struct {
+	valid    bool
+	version  int
+	segments []blameSegment
+}
+
+// BlameRange attributes every byte in [start, end) to the operation that last
+// wrote it, by replaying the retained operation log. Adjacent bytes written by
+// the same operation are reported as one span.
+func (gb *GapBuffer) BlameRange(start int, end int) (spans []BlameSpan, err error) {
+	defer guard("BlameRange", start, gb.length, &err)
+
+	if start < 0 || end > gb.length || start > end {
+		return nil, ErrInvalidRange
+	}
+	if start == end {
+		return nil, nil
+	}
+
+	segments := gb.blameSegments()
+	ops := gb.oplog.ops
+	first := gb.version - len(ops) + 1
+
+	// Collect the segments overlapping the requested range
+	pos := 0
+	for _, seg := range segments {
+		segStart, segEnd := pos, pos+seg.length
+		pos = segEnd
+		if segEnd <= start || segStart >= end {
+			continue
+		}
+		if segStart < start {
+			segStart = start
+		}
+		if segEnd > end {
+			segEnd = end
+		}
+
+		// Operations dropped from the log since are unknown as well
+		var op Operation
+		if seg.version >= first && seg.version > 0 {
+			op = ops[seg.version-first]
+		}
+		if n := len(spans); n > 0 && spans[n-1].End == segStart && spans[n-1].Operation.Version == op.Version {
+			spans[n-1].End = segEnd
+			continue
+		}
+		spans = append(spans, BlameSpan{Start: segStart, End: segEnd, Operation: op})
+	}
+	return spans, nil
+}
+
+// blameSegments returns the segments of the current text. The cached
+// segments are brought up to date with the operations applied since they
+// were computed, or computed afresh from the oldest retained operation
+// when the log no longer reaches back to them.
+func (gb *GapBuffer) blameSegments() []blameSegment {
+	c := &gb.blame
+	newer, err := gb.oplog.since(c.version, gb.version)
+	if !c.valid || err != nil {
+		newer = gb.oplog.ops
+
+		// Length of the text before the oldest retained operation
+		baseLength := gb.length
+		for _, op := range newer {
+			baseLength -= op.Inserted - op.Deleted
+		}
+		c.segments = nil
+		if baseLength > 0 {
+			c.segments = append(c.segments, blameSegment{length: baseLength})
+		}
+	}
+	for _, op := range newer {
+		c.segments = replaySegments(c.segments, op.Pos, op.Deleted, blameSegment{length: op.Inserted, version: op.Version})
+	}
+	c.valid, c.version = true, gb.version
+	return c.segments
+}
+
+// replaySegments applies one operation to the segment list: deleted bytes at
+// pos are removed and the inserted segment takes their place
+func replaySegments(segments []blameSegment, pos int, deleted int, inserted blameSegment) []blameSegment {
+	result := make([]blameSegment, 0, len(segments)+2)
+	offset := 0
+	placed := false
+	for _, seg := range segments {
+		segStart, segEnd := offset, offset+seg.length
+		offset = segEnd
+
+		// Part of the segment before the edit
+		if segStart < pos {
+			keep := seg.length
+			if segEnd > pos {
+				keep = pos - segStart
+			}
+			result = append(result, blameSegment{length: keep, version: seg.version})
+		}
+
+		if !placed && segEnd >= pos {
+			if inserted.length > 0 {
+				result = append(result, inserted)
+			}
+			placed = true
+		}
+
+		// Part of the segment after the deleted range
+		if segEnd > pos+deleted {
+			from := segStart
+			if from < pos+deleted {
+				from = pos + deleted
+			}
+			result = append(result, blameSegment{length: segEnd - from, version: seg.version})
+		}
+	}
+	if !placed && inserted.length > 0 {
+		result = append(result, inserted)
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+package buffer_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// blameVersions returns the spans as start, end and version triples
+func blameVersions(t *testing.T, gb *buffer.GapBuffer) [][3]int {
+	t.Helper()
+	spans, err := gb.BlameRange(0, gb.Length())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result [][3]int
+	for _, s := range spans {
+		result = append(result, [3]int{s.Start, s.End, s.Operation.Version})
+	}
+	return result
+}
+
+// TestBlameRange checks that every byte is attributed to the edit that
+// last wrote it, and text older than the log to no operation
+func TestBlameRange(t *testing.T) {
+	gb := buffer.New()
+	gb.InsertAt(0, "hello world") // 1
+	gb.Replace(6, 11, "there")    // 2
+	gb.InsertAt(5, ",")           // 3
+	gb.DeleteAt(0, 1)             // 4
+
+	want := [][3]int{{0, 4, 1}, {4, 5, 3}, {5, 6, 1}, {6, 11, 2}}
+	if got := blameVersions(t, gb); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	spans, err := gb.BlameRange(5, 9)
+	if err != nil || len(spans) != 2 || spans[1].Operation.InsertedText != "there" {
+		t.Fatalf("partial range: got %+v, %v", spans, err)
+	}
+	if _, err := gb.BlameRange(3, 20); err == nil {
+		t.Error("range past the end: got no error")
+	}
+
+	// Undoing the deletion writes the byte back as a new operation
+	gb.Undo() // 5
+	want = [][3]int{{0, 1, 5}, {1, 5, 1}, {5, 6, 3}, {6, 7, 1}, {7, 12, 2}}
+	if got := blameVersions(t, gb); !reflect.DeepEqual(got, want) {
+		t.Fatalf("after undo: got %v, want %v", got, want)
+	}
+
+	// Once the log forgets the first edits, their text has no operation
+	for i := 0; i < 4; i++ {
+		gb.InsertAt(gb.Length(), "!")
+	}
+	gb.SetOperationLogLimit(2)
+	want = [][3]int{{0, 14, 0}, {14, 15, 8}, {15, 16, 9}}
+	if got := blameVersions(t, gb); !reflect.DeepEqual(got, want) {
+		t.Fatalf("after trimming the log: got %v, want %v", got, want)
+	}
+}
+
+// TestBlameRangeCached checks that blame brought up to date between edits
+// matches blame computed once at the end
+func TestBlameRangeCached(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	queried, fresh := buffer.New(), buffer.New()
+	for i := 0; i < 500; i++ {
+		start := rng.Intn(queried.Length() + 1)
+		end := start + rng.Intn(min(5, queried.Length()-start)+1)
+		text := "abcdef"[:rng.Intn(4)]
+		for _, gb := range []*buffer.GapBuffer{queried, fresh} {
+			if err := gb.Replace(start, end, text); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if i%7 == 0 {
+			blameVersions(t, queried)
+		}
+	}
+	if got, want := blameVersions(t, queried), blameVersions(t, fresh); !reflect.DeepEqual(got, want) {
+		t.Errorf("cached blame: got %v, want %v", got, want)
+	}
+}
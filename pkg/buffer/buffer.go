@@ -0,0 +1,19 @@
+package buffer
+
+// Buffer is the editing interface shared by the text storage backends
+type Buffer interface {
+	InsertAt(pos int, text string) error
+	DeleteAt(pos int, count int) error
+	Replace(start int, end int, text string) error
+	GetText() string
+	GetTextRange(start int, end int) (string, error)
+	Length() int
+}
+
+var (
+	_ Buffer = (*GapBuffer)(nil)
+	_ Buffer = (*Rope)(nil)
+	_ Buffer = (*FlatBuffer)(nil)
+	_ Buffer = (*PieceTable)(nil)
+	_ Buffer = (*SyncBuffer)(nil)
+)
@@ -0,0 +1,249 @@
+// Package buffertest checks that implementations of buffer.Buffer behave
+// like the ones in package buffer, in the spirit of testing/fstest. Any
+// backend can run it from its own tests:
+//
+//	if err := buffertest.TestBuffer(func() buffer.Buffer { return mybackend.New() }); err != nil {
+//		t.Fatal(err)
+//	}
+package buffertest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"unicode/utf8"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// Historian is implemented by buffers with undo and redo. TestBuffer checks
+// their history semantics too.
+type Historian interface {
+	Undo() error
+	Redo() error
+}
+
+// Samples are texts whose bytes every buffer must return unchanged: line
+// ending styles, a byte order mark, NUL bytes and multi-byte runes.
+var Samples = []string{
+	"",
+	"plain ascii text",
+	"unix\nline\nendings\n",
+	"windows\r\nline\r\nendings\r\n",
+	"old mac\rline\rendings\r",
+	"mixed\r\nline\nendings\r",
+	"\ufeffbyte order mark\n",
+	"nul\x00bytes\x00",
+	"héllo wörld, 日本語, emoji 😀👍🏽, combining é",
+	"\t indented\n\n\n  blank lines \n",
+}
+
+// TestBuffer runs the conformance checks on buffers made by newBuffer, which
+// must return a new empty buffer on every call. It returns an error listing
+// every failure, or nil if the buffers conform.
+func TestBuffer(newBuffer func() buffer.Buffer) error {
+	t := &tester{newBuffer: newBuffer}
+	t.testRoundTrip()
+	t.testInvalidPositions()
+	t.testEdits(1)
+	t.testHistory()
+	return errors.Join(t.errs...)
+}
+
+// tester collects the failures of one TestBuffer run
+type tester struct {
+	newBuffer func() buffer.Buffer
+	errs      []error
+}
+
+// errorf records a failure
+func (t *tester) errorf(format string, args ...interface{}) {
+	t.errs = append(t.errs, fmt.Errorf(format, args...))
+}
+
+// check compares the whole content of b with want
+func (t *tester) check(b buffer.Buffer, want string, context string) bool {
+	if got := b.GetText(); got != want {
+		t.errorf("%s: GetText() = %q, want %q", context, got, want)
+		return false
+	}
+	if got := b.Length(); got != len(want) {
+		t.errorf("%s: Length() = %d, want %d", context, got, len(want))
+		return false
+	}
+	return true
+}
+
+// testRoundTrip checks that samples come back byte for byte, whole and in
+// ranges, however they were inserted
+func (t *tester) testRoundTrip() {
+	for _, sample := range Samples {
+		b := t.newBuffer()
+		if err := b.InsertAt(0, sample); err != nil {
+			t.errorf("InsertAt(0, %q): %v", sample, err)
+			continue
+		}
+		if !t.check(b, sample, fmt.Sprintf("after inserting %q", sample)) {
+			continue
+		}
+
+		// Every range between rune boundaries
+		bounds := runeBounds(sample)
+		for _, start := range bounds {
+			for _, end := range bounds {
+				if end < start {
+					continue
+				}
+				if got, err := b.GetTextRange(start, end); err != nil || got != sample[start:end] {
+					t.errorf("GetTextRange(%d, %d) of %q = %q, %v, want %q", start, end, sample, got, err, sample[start:end])
+				}
+			}
+		}
+
+		// Rune by rune, appending and prepending
+		appended, prepended := t.newBuffer(), t.newBuffer()
+		for i := 0; i+1 < len(bounds); i++ {
+			appended.InsertAt(appended.Length(), sample[bounds[i]:bounds[i+1]])
+			j := len(bounds) - 1 - i
+			prepended.InsertAt(0, sample[bounds[j-1]:bounds[j]])
+		}
+		t.check(appended, sample, fmt.Sprintf("after appending %q rune by rune", sample))
+		t.check(prepended, sample, fmt.Sprintf("after prepending %q rune by rune", sample))
+	}
+}
+
+// testInvalidPositions checks that out-of-range edits and reads fail and
+// leave the text alone
+func (t *tester) testInvalidPositions() {
+	const text = "abc\r\ndéf"
+	b := t.newBuffer()
+	b.InsertAt(0, text)
+	n := len(text)
+
+	for _, c := range []struct {
+		name string
+		call func() error
+	}{
+		{"InsertAt(-1)", func() error { return b.InsertAt(-1, "x") }},
+		{"InsertAt(len+1)", func() error { return b.InsertAt(n+1, "x") }},
+		{"DeleteAt(-1, 1)", func() error { return b.DeleteAt(-1, 1) }},
+		{"DeleteAt(0, -1)", func() error { return b.DeleteAt(0, -1) }},
+		{"DeleteAt(len, 1)", func() error { return b.DeleteAt(n, 1) }},
+		{"Replace(2, 1)", func() error { return b.Replace(2, 1, "x") }},
+		{"Replace(0, len+1)", func() error { return b.Replace(0, n+1, "x") }},
+		{"GetTextRange(2, 1)", func() error { _, err := b.GetTextRange(2, 1); return err }},
+		{"GetTextRange(0, len+1)", func() error { _, err := b.GetTextRange(0, n+1); return err }},
+	} {
+		if err := c.call(); err == nil {
+			t.errorf("%s on %q succeeded, want an error", c.name, text)
+		}
+		t.check(b, text, "after "+c.name)
+	}
+}
+
+// testEdits applies random edits at rune boundaries and compares the
+// buffer with a plain string after each of them
+func (t *tester) testEdits(seed int64) {
+	r := rand.New(rand.NewSource(seed))
+	pieces := []string{"a", "bc", "\n", "\r\n", "é", "日本", "😀", " ", "line\n"}
+
+	b := t.newBuffer()
+	model := ""
+	for i := 0; i < 500; i++ {
+		bounds := runeBounds(model)
+		start := bounds[r.Intn(len(bounds))]
+		end := start
+		if r.Intn(2) == 0 {
+			end = bounds[r.Intn(len(bounds))]
+			start, end = min(start, end), max(start, end)
+		}
+		text := ""
+		for k := r.Intn(4); k > 0; k-- {
+			text += pieces[r.Intn(len(pieces))]
+		}
+
+		var err error
+		var name string
+		switch {
+		case start == end:
+			name = fmt.Sprintf("InsertAt(%d, %q)", start, text)
+			err = b.InsertAt(start, text)
+		case text == "":
+			name = fmt.Sprintf("DeleteAt(%d, %d)", start, end-start)
+			err = b.DeleteAt(start, end-start)
+		default:
+			name = fmt.Sprintf("Replace(%d, %d, %q)", start, end, text)
+			err = b.Replace(start, end, text)
+		}
+		if err != nil {
+			t.errorf("edit %d: %s: %v", i, name, err)
+			return
+		}
+		model = model[:start] + text + model[end:]
+		if !t.check(b, model, fmt.Sprintf("edit %d: after %s", i, name)) {
+			return
+		}
+	}
+}
+
+// testHistory checks that undoing every edit and redoing them goes through
+// the same texts in reverse and back, for buffers with history
+func (t *tester) testHistory() {
+	b := t.newBuffer()
+	h, ok := b.(Historian)
+	if !ok {
+		return
+	}
+
+	states := []string{""}
+	for _, e := range []struct {
+		start, end int
+		text       string
+	}{
+		{0, 0, "hello\r\nworld"},
+		{5, 7, "\n"},
+		{0, 1, "J"},
+		{6, 6, "wide 日本 "},
+		{0, 2, ""},
+	} {
+		if err := b.Replace(e.start, e.end, e.text); err != nil {
+			t.errorf("history: Replace(%d, %d, %q): %v", e.start, e.end, e.text, err)
+			return
+		}
+		states = append(states, b.GetText())
+	}
+
+	for i := len(states) - 2; i >= 0; i-- {
+		if err := h.Undo(); err != nil {
+			t.errorf("history: Undo back to state %d: %v", i, err)
+			return
+		}
+		if !t.check(b, states[i], fmt.Sprintf("history: after undoing to state %d", i)) {
+			return
+		}
+	}
+	if err := h.Undo(); err == nil {
+		t.errorf("history: Undo with nothing to undo succeeded")
+	}
+	for i := 1; i < len(states); i++ {
+		if err := h.Redo(); err != nil {
+			t.errorf("history: Redo to state %d: %v", i, err)
+			return
+		}
+		if !t.check(b, states[i], fmt.Sprintf("history: after redoing to state %d", i)) {
+			return
+		}
+	}
+	if err := h.Redo(); err == nil {
+		t.errorf("history: Redo with nothing to redo succeeded")
+	}
+}
+
+// runeBounds returns the positions of the rune boundaries of s, both ends included
+func runeBounds(s string) []int {
+	bounds := make([]int, 0, utf8.RuneCountInString(s)+1)
+	for i := range s {
+		bounds = append(bounds, i)
+	}
+	return append(bounds, len(s))
+}
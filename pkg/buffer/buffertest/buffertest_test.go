@@ -0,0 +1,35 @@
+package buffertest_test
+
+import (
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+	"github.com/kebaren/gapbuffer/pkg/buffer/buffertest"
+)
+
+// TestBackends runs the conformance checks on the backends of package
+// buffer, the chunk tree in both layouts and with tiny chunks among them
+func TestBackends(t *testing.T) {
+	backends := []struct {
+		name      string
+		newBuffer func() buffer.Buffer
+	}{
+		{"GapBuffer", func() buffer.Buffer { return buffer.New() }},
+		{"GapBufferCompact", func() buffer.Buffer {
+			gb := buffer.New()
+			gb.SetTreeLayout(buffer.LayoutCompact)
+			return gb
+		}},
+		{"GapBufferSmallChunks", func() buffer.Buffer { return buffer.NewWithChunkSize(4) }},
+		{"Rope", func() buffer.Buffer { return buffer.NewRope() }},
+		{"FlatBuffer", func() buffer.Buffer { return buffer.NewFlatBuffer() }},
+		{"PieceTable", func() buffer.Buffer { return buffer.NewPieceTable() }},
+	}
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			if err := buffertest.TestBuffer(b.newBuffer); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package buffer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+)
+
+// maxDecodeErrorOffsets caps the offsets a DecodeError lists
+const maxDecodeErrorOffsets = 1000
+
+// DecodeError reports input that is not valid in the encoding it was read
+// in. Each undecodable sequence was replaced with U+FFFD.
+type DecodeError struct {
+	Offsets []int64 // Input offsets of the first undecodable sequences, in order
+	Count   int     // Number of undecodable sequences, which may exceed len(Offsets)
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%d undecodable byte sequences, the first at offset %d", e.Count, e.Offsets[0])
+}
+
+// EncodeError reports a rune of the buffer that the encoding a file is
+// written in cannot represent, or invalid UTF-8
+type EncodeError struct {
+	Pos  int  // Byte position of the rune in the buffer
+	Rune rune // The rune, utf8.RuneError for invalid UTF-8
+}
+
+func (e *EncodeError) Error() string {
+	return fmt.Sprintf("cannot encode %U at position %d", e.Rune, e.Pos)
+}
+
+// replacement is U+FFFD in UTF-8, what decoders produce for bad input
+var replacement = []byte(string(utf8.RuneError))
+
+// NewFromReaderEncoding is like NewFromReader for input in enc, such as
+// charmap.ISO8859_1, simplifiedchinese.GBK or japanese.ShiftJIS. The text
+// is converted to UTF-8 as it is read. If some input could not be decoded,
+// the buffer is returned along with a *DecodeError giving its offsets, and
+// holds U+FFFD in its place. OriginalOffset maps positions of the buffer
+// back to offsets in the input.
+func NewFromReaderEncoding(r io.Reader, enc encoding.Encoding) (gb *GapBuffer, err error) {
+	defer guard("NewFromReaderEncoding", noPos, noPos, &err)
+
+	gb = New()
+	gb.origins = newOriginTable(enc)
+	units := enc.NewDecoder()
+	derr, err := decodeBlocks(r, enc, gb.chunkSize, func(text string, src []byte, atEOF bool) {
+		gb.rawInsert(gb.length, text)
+		gb.origins.addDecoded(units, text, src, atEOF)
+	})
+	if err != nil {
+		return nil, err
+	}
+	gb.origins.finish()
+	if derr != nil {
+		return gb, derr
+	}
+	return gb, nil
+}
+
+// decodeBlocks reads r until io.EOF, decodes it from enc and calls fn with
+// the UTF-8 text in blocks of complete runes, along with the input decoded
+// into it and whether that input ends the input
+func decodeBlocks(r io.Reader, enc encoding.Encoding, size int, fn func(text string, src []byte, atEOF bool)) (*DecodeError, error) {
+	dec := enc.NewDecoder()
+	var derr *DecodeError
+	buf := make([]byte, size)
+	dst := make([]byte, 4*size)
+	var src []byte
+	var offset int64 // of src[0] in the input
+	eof := false
+	for !eof {
+		n, err := r.Read(buf)
+		src = append(src, buf[:n]...)
+		switch {
+		case err == io.EOF:
+			eof = true
+		case err != nil:
+			return nil, err
+		}
+
+		for {
+			nDst, nSrc, err := dec.Transform(dst, src, eof)
+			atEOF := eof && nSrc == len(src)
+			if bytes.Contains(dst[:nDst], replacement) {
+				derr = locateUndecodable(derr, enc, src[:nSrc], offset, atEOF)
+			}
+			if nDst > 0 || nSrc > 0 {
+				fn(string(dst[:nDst]), src[:nSrc], atEOF)
+			}
+			src = src[:copy(src, src[nSrc:])]
+			offset += int64(nSrc)
+			if errors.Is(err, transform.ErrShortDst) {
+				continue
+			}
+			if err != nil && !errors.Is(err, transform.ErrShortSrc) {
+				return nil, err
+			}
+			break
+		}
+	}
+	return derr, nil
+}
+
+// locateUndecodable adds the offsets of the undecodable sequences of src,
+// which holds complete characters unless it ends the input, to derr. The
+// characters are decoded one at a time, from the smallest prefix the
+// decoder accepts; one decoding to U+FFFD is undecodable unless it is how
+// enc encodes U+FFFD. A stateful encoding is decoded from its initial
+// state.
+func locateUndecodable(derr *DecodeError, enc encoding.Encoding, src []byte, offset int64, atEOF bool) *DecodeError {
+	legit, _ := enc.NewEncoder().Bytes(replacement)
+	dec := enc.NewDecoder()
+	var dst [2 * utf8.UTFMax]byte
+	for i := 0; i < len(src); {
+		nDst, nSrc := 0, 0
+		for k := 1; k <= len(src)-i && nSrc == 0; k++ {
+			var err error
+			nDst, nSrc, err = dec.Transform(dst[:], src[i:i+k], atEOF && i+k == len(src))
+			if err != nil && !errors.Is(err, transform.ErrShortSrc) {
+				break
+			}
+		}
+		if nSrc == 0 {
+			return derr // cannot happen for complete characters
+		}
+		if bytes.Equal(dst[:nDst], replacement) && !bytes.Equal(src[i:i+nSrc], legit) {
+			if derr == nil {
+				derr = &DecodeError{}
+			}
+			if derr.Count < maxDecodeErrorOffsets {
+				derr.Offsets = append(derr.Offsets, offset+int64(i))
+			}
+			derr.Count++
+		}
+		i += nSrc
+	}
+	return derr
+}
+
+// writeEncoded writes the content like writeContent, converted from UTF-8
+// to enc, and fails with an *EncodeError at the first rune enc cannot
+// represent
+func (gb *GapBuffer) writeEncoded(w io.Writer, enc encoding.Encoding) error {
+	e := enc.NewEncoder()
+	dst := make([]byte, 32<<10)
+	var src []byte
+	pos := 0 // of src[0] in the buffer
+
+	// transform encodes src as far as it can, keeping an incomplete rune at
+	// its end unless atEOF
+	transformSrc := func(atEOF bool) error {
+		for {
+			nDst, nSrc, err := e.Transform(dst, src, atEOF)
+			if _, werr := w.Write(dst[:nDst]); werr != nil {
+				return werr
+			}
+			src = src[:copy(src, src[nSrc:])]
+			pos += nSrc
+			switch {
+			case errors.Is(err, transform.ErrShortDst):
+				continue
+			case err == nil, errors.Is(err, transform.ErrShortSrc):
+				return nil
+			}
+			r, _ := utf8.DecodeRune(src)
+			return &EncodeError{Pos: pos, Rune: r}
+		}
+	}
+
+	var err error
+	gb.forEachChunk(0, gb.length, func(_ int, text string) bool {
+		src = append(src, text...)
+		err = transformSrc(false)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if gb.hasVirtualNewline() {
+		src = append(src, '\n')
+	}
+	return transformSrc(true)
+}
@@ -0,0 +1,63 @@
+package buffer_test
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestNewFromReaderEncoding checks decoding, with characters split between
+// reads, and the offsets of undecodable input
+func TestNewFromReaderEncoding(t *testing.T) {
+	gb, err := buffer.NewFromReaderEncoding(strings.NewReader("caf\xe9 \xa4"), charmap.ISO8859_1)
+	if err != nil || gb.GetText() != "café ¤" {
+		t.Errorf("Latin-1: got %q, %v", gb.GetText(), err)
+	}
+
+	gbk := strings.Repeat("\xc4\xe3\xba\xc3\n", 1000)
+	gb, err = buffer.NewFromReaderEncoding(iotest.OneByteReader(strings.NewReader(gbk)), simplifiedchinese.GBK)
+	if err != nil || gb.GetText() != strings.Repeat("你好\n", 1000) {
+		t.Errorf("GBK: got %d bytes, %v", gb.Length(), err)
+	}
+
+	gb, err = buffer.NewFromReaderEncoding(strings.NewReader("a\xff\xc4\xe3b\xff"), simplifiedchinese.GBK)
+	var derr *buffer.DecodeError
+	if !errors.As(err, &derr) || derr.Count != 2 || !reflect.DeepEqual(derr.Offsets, []int64{1, 5}) {
+		t.Fatalf("got %v", err)
+	}
+	if gb == nil || gb.GetText() != "a\uFFFD你b\uFFFD" {
+		t.Errorf("undecodable input: got %v", gb)
+	}
+}
+
+// TestSaveFileEncoding checks that SaveFile encodes the text and fails on
+// a rune the encoding cannot represent, leaving the file untouched
+func TestSaveFileEncoding(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "latin1.txt")
+	gb := newBuffer(t, "café\n")
+	if err := gb.SaveFile(path, buffer.SaveOptions{Encoding: charmap.ISO8859_1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path); got != "caf\xe9\n" {
+		t.Errorf("saved %q", got)
+	}
+
+	gb.InsertAt(5, "中")
+	err := gb.SaveFile(path, buffer.SaveOptions{Encoding: charmap.ISO8859_1})
+	var eerr *buffer.EncodeError
+	if !errors.As(err, &eerr) || eerr.Pos != 5 || eerr.Rune != '中' {
+		t.Errorf("got %v", err)
+	}
+	if got := readFile(t, path); got != "caf\xe9\n" {
+		t.Errorf("the file changed to %q", got)
+	}
+	checkNoTemp(t, filepath.Dir(path), 1)
+}
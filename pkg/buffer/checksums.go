@@ -0,0 +1,85 @@
+package buffer
+
+// SetLineChecksums turns per-line checksums on or off. The checksum of a
+// line is the 64-bit FNV-1a hash of its bytes, its newline included, so a
+// remote copy of the buffer can find the lines that differ by comparing
+// checksums, without either side sending or hashing the whole text. The
+// lines are hashed once; afterwards an edit only rehashes the lines it
+// touches.
+func (gb *GapBuffer) SetLineChecksums(on bool) {
+	if !on {
+		gb.lineSums = nil
+		return
+	}
+	keys := gb.lineKeys()
+	gb.lineSums = make([]uint64, len(keys))
+	for i, key := range keys {
+		gb.lineSums[i] = key.hash
+	}
+}
+
+// LineChecksum returns the checksum of the zero-based line, computing it if
+// line checksums are off
+func (gb *GapBuffer) LineChecksum(line int) (sum uint64, err error) {
+	defer guard("LineChecksum", line, gb.length, &err)
+
+	if line < 0 || line >= gb.lineCount() {
+		return 0, ErrLineOutOfRange
+	}
+	if gb.lineSums != nil {
+		return gb.lineSums[line], nil
+	}
+	return gb.lineHash(line), nil
+}
+
+// LineChecksums returns the checksums of the lines [start, end), computing
+// them if line checksums are off
+func (gb *GapBuffer) LineChecksums(start int, end int) (sums []uint64, err error) {
+	defer guard("LineChecksums", start, gb.length, &err)
+
+	if start < 0 || end > gb.lineCount() || start > end {
+		return nil, ErrLineOutOfRange
+	}
+	if gb.lineSums != nil {
+		return append([]uint64(nil), gb.lineSums[start:end]...), nil
+	}
+	sums = make([]uint64, end-start)
+	for i := range sums {
+		sums[i] = gb.lineHash(start + i)
+	}
+	return sums, nil
+}
+
+// updateLineSums rehashes the lines op touched and moves the checksums of
+// the lines after them
+func (gb *GapBuffer) updateLineSums(op Operation) {
+	if gb.lineSums == nil {
+		return
+	}
+	first := gb.lineAt(op.Pos)
+	added := gb.lineAt(op.Pos+op.Inserted) - first
+	removed := added + len(gb.lineSums) - gb.lineCount()
+
+	sums := make([]uint64, added+1)
+	for i := range sums {
+		sums[i] = gb.lineHash(first + i)
+	}
+	tail := gb.lineSums[first+removed+1:]
+	gb.lineSums = append(append(gb.lineSums[:first:first], sums...), tail...)
+}
+
+// lineHash returns the FNV-1a hash of the zero-based line, which must exist
+func (gb *GapBuffer) lineHash(line int) uint64 {
+	end := gb.length
+	if line+1 < gb.lineCount() {
+		end = gb.lineStartOf(line + 1)
+	}
+	hash := fnvOffset
+	gb.forEachChunk(gb.lineStartOf(line), end, func(_ int, text string) bool {
+		for i := 0; i < len(text); i++ {
+			hash = (hash ^ uint64(text[i])) * fnvPrime
+		}
+		return true
+	})
+	return hash
+}
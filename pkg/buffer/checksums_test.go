@@ -0,0 +1,69 @@
+package buffer_test
+
+import (
+	"errors"
+	"hash/fnv"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// fnv64a returns the 64-bit FNV-1a hash of s
+func fnv64a(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// TestLineChecksums checks the checksums against FNV-1a of each line,
+// newline included
+func TestLineChecksums(t *testing.T) {
+	gb := newBuffer(t, "one\ntwo\r\n\nlast")
+	want := []uint64{fnv64a("one\n"), fnv64a("two\r\n"), fnv64a("\n"), fnv64a("last")}
+	for _, on := range []bool{false, true} {
+		gb.SetLineChecksums(on)
+		sums, err := gb.LineChecksums(0, gb.LineCount())
+		if err != nil || !slices.Equal(sums, want) {
+			t.Errorf("index %v: got %x, %v, want %x", on, sums, err, want)
+		}
+		if sum, err := gb.LineChecksum(2); err != nil || sum != want[2] {
+			t.Errorf("index %v: line 2 has %x, %v", on, sum, err)
+		}
+		if _, err := gb.LineChecksum(4); !errors.Is(err, buffer.ErrLineOutOfRange) {
+			t.Errorf("index %v: line past the end: %v", on, err)
+		}
+		if _, err := gb.LineChecksums(2, 1); !errors.Is(err, buffer.ErrLineOutOfRange) {
+			t.Errorf("index %v: reversed range: %v", on, err)
+		}
+	}
+}
+
+// TestLineChecksumsEdits checks the maintained checksums against ones
+// computed from scratch after random edits
+func TestLineChecksumsEdits(t *testing.T) {
+	gb := buffer.NewWithChunkSize(16)
+	gb.InsertAt(0, strings.Repeat("line\n", 20))
+	gb.SetLineChecksums(true)
+	rng := rand.New(rand.NewSource(4))
+	inserts := []string{"x", "\n", "\r", "ab\ncd", "\n\n"}
+
+	for i := 0; i < 300; i++ {
+		pos := rng.Intn(gb.Length() + 1)
+		if rng.Intn(3) == 0 && pos < gb.Length() {
+			gb.DeleteAt(pos, min(rng.Intn(6)+1, gb.Length()-pos))
+		} else {
+			gb.InsertAt(pos, inserts[rng.Intn(len(inserts))])
+		}
+
+		var want []uint64
+		for _, line := range strings.SplitAfter(string(gb.Bytes()), "\n") {
+			want = append(want, fnv64a(line))
+		}
+		if got, _ := gb.LineChecksums(0, gb.LineCount()); !slices.Equal(got, want) {
+			t.Fatalf("edit %d: got %x, want %x", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package buffer
+
+import "math"
+
+// measures are the counts the tree keeps of a chunk and of every subtree:
+// size, number of lines, number of non-whitespace bytes, number of UTF-16
+// code units and number of runes. Totals allow finding a chunk by position,
+// line or rune in O(log n) without relying on keys.
+type measures struct {
+	size  int
+	lines int
+	ink   int
+	units int
+	runes int
+}
+
+// plus returns the sum of m and o
+func (m measures) plus(o measures) measures {
+	return measures{m.size + o.size, m.lines + o.lines, m.ink + o.ink, m.units + o.units, m.runes + o.runes}
+}
+
+// minus returns the difference of m and o
+func (m measures) minus(o measures) measures {
+	return measures{m.size - o.size, m.lines - o.lines, m.ink - o.ink, m.units - o.units, m.runes - o.runes}
+}
+
+// chunkTree holds the chunks of a buffer ordered by key, their physical
+// position, with the measures of every subtree. RBTree implements it with
+// pointer-linked nodes, compactTree with an index-based arena.
+type chunkTree interface {
+	// add inserts a chunk at key, measuring it
+	add(key int, value payload)
+	// insertMeasured adds a chunk at key whose measures are already known
+	insertMeasured(key int, value payload, m measures)
+	// Delete removes the chunk at key, if there is one
+	Delete(key int)
+	// set replaces the chunk at key, which must exist, and remeasures it
+	set(key int, value payload)
+	// floor returns the chunk with the largest key less than or equal to key
+	floor(key int) (at int, value *payload, ok bool)
+	// ascend calls fn with the chunks with a key in [from, to) and their
+	// measures in key order until fn returns false. fn must not change the
+	// tree.
+	ascend(from int, to int, fn func(key int, value *payload, own measures) bool)
+	// descend calls fn with the chunks with a key at most from in reverse
+	// key order until fn returns false. fn must not change the tree.
+	descend(from int, fn func(key int, value *payload) bool)
+	// shift adds delta to the keys in [from, to); callers guarantee that
+	// the key order does not change
+	shift(from int, to int, delta int)
+	// total returns the measures of all chunks
+	total() measures
+	// seek descends to the chunk in which the measure a caller looks for
+	// falls. in reports whether it falls in measures m following measures
+	// before, and must be monotonic. It returns the chunk with the measures
+	// of the chunks before it and its own, or ok false with the measures of
+	// all chunks if it falls in none. owned reports whether the chunk is
+	// the tree's alone, so that its rune marks can be kept.
+	seek(in func(before measures, m measures) bool) (value *payload, before measures, own measures, ok bool, owned bool)
+	// Depth returns the number of nodes on the longest path from the root
+	Depth() int
+	// count returns the number of chunks
+	count() int
+	// rebuilt returns a balanced tree of the same layout holding the chunks
+	rebuilt() chunkTree
+	// forked returns a tree holding the chunks, which can change while t
+	// is read, as by a snapshot, from other goroutines. It is a compact
+	// tree whatever the layout of t.
+	forked() chunkTree
+}
+
+// TreeLayout selects how the tree holding the chunks of a buffer is stored
+type TreeLayout int
+
+const (
+	// LayoutPointer links separately allocated nodes with child and parent
+	// pointers. Such nodes cannot be shared with a snapshot, so the first
+	// edit after a snapshot moves the buffer to LayoutCompact.
+	LayoutPointer TreeLayout = iota
+	// LayoutCompact keeps the nodes in one arena linked by 32-bit indices,
+	// without parent pointers, iterating with an explicit stack. Nodes take
+	// about a third less memory and sit next to each other, which suits
+	// very large documents.
+	LayoutCompact
+)
+
+// SetTreeLayout stores the chunks in a tree of the given layout, moving
+// the existing chunks over. The text, the gap and the chunk boundaries are
+// unchanged, as are the results of every read.
+func (gb *GapBuffer) SetTreeLayout(layout TreeLayout) {
+	if layout == gb.TreeLayout() {
+		return
+	}
+	tree := newChunkTree(layout)
+	gb.tree.ascend(math.MinInt, math.MaxInt, func(key int, value *payload, own measures) bool {
+		tree.insertMeasured(key, *value, own)
+		return true
+	})
+	gb.tree = tree
+	gb.treeShared = false
+}
+
+// TreeLayout returns the layout of the tree holding the chunks
+func (gb *GapBuffer) TreeLayout() TreeLayout {
+	if _, ok := gb.tree.(*compactTree); ok {
+		return LayoutCompact
+	}
+	return LayoutPointer
+}
+
+// newChunkTree returns an empty tree of the given layout
+func newChunkTree(layout TreeLayout) chunkTree {
+	if layout == LayoutCompact {
+		return newCompactTree()
+	}
+	return NewRBTree()
+}
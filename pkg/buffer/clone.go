@@ -0,0 +1,45 @@
+package buffer
+
+import (
+	"math"
+	"strings"
+)
+
+// Clone returns an independent copy of the buffer, for handing a stable
+// text to a background worker, such as a formatter or linter, while the
+// buffer keeps being edited. The tree is duplicated node for node, so the
+// copy has the same chunks, gap and tree layout and nothing is rescanned;
+// the chunks share their text, which is never modified in place. Text
+// still pointing into a file mapped by NewFromFile is copied, so the clone
+// outlives Close of the buffer.
+//
+// The clone starts at the same version with the same chunk size, binary
+// mode, final newline and range cache size. History, markers, listeners,
+// indexes, limits and quota stay with the buffer. Cloning takes time
+// linear in the number of chunks and must not run concurrently with edits
+// of the buffer; afterwards the two can be used from different goroutines.
+func (gb *GapBuffer) Clone() *GapBuffer {
+	tree := newChunkTree(gb.TreeLayout())
+	gb.tree.ascend(math.MinInt, math.MaxInt, func(key int, value *payload, own measures) bool {
+		chunk := *value
+		if gb.inMapping(chunk.Text) {
+			chunk.Text = strings.Clone(chunk.Text)
+		}
+		tree.insertMeasured(key, chunk, own)
+		return true
+	})
+
+	return &GapBuffer{
+		tree:         tree,
+		gapStart:     gb.gapStart,
+		gapEnd:       gb.gapEnd,
+		length:       gb.length,
+		chunkSize:    gb.chunkSize,
+		version:      gb.version,
+		finalNewline: gb.finalNewline,
+		frozen:       gb.frozen,
+		treeChecks:   gb.treeChecks,
+		binary:       gb.binary,
+		rangeCache:   rangeCache{size: gb.rangeCache.size},
+	}
+}
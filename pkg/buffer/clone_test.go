@@ -0,0 +1,87 @@
+package buffer_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestClone checks what a clone takes over and that edits on either side
+// leave the other alone
+func TestClone(t *testing.T) {
+	gb := edited()
+	gb.SetTreeLayout(buffer.LayoutCompact)
+	gb.SetBinary(true)
+	m, _ := gb.CreateMarker(10, buffer.GravityLeft)
+	text := string(gb.Bytes())
+
+	c := gb.Clone()
+	if !reflect.DeepEqual(checkChunks(t, c), checkChunks(t, gb)) {
+		t.Error("the clone has other chunks")
+	}
+	if c.Version() != gb.Version() || c.TreeLayout() != buffer.LayoutCompact || !c.Binary() {
+		t.Errorf("clone at version %d, layout %v, binary %v", c.Version(), c.TreeLayout(), c.Binary())
+	}
+	if c.CanUndo() {
+		t.Error("the clone took over the history")
+	}
+
+	c.InsertAt(0, "clone ")
+	gb.DeleteAt(0, 4)
+	if string(c.Bytes()) != "clone "+text || string(gb.Bytes()) != text[4:] {
+		t.Error("edits leaked between the buffer and its clone")
+	}
+	if m.Pos() != 6 {
+		t.Errorf("the buffer's marker moved to %d", m.Pos())
+	}
+	checkChunks(t, c)
+}
+
+// TestCloneConcurrent reads a clone in another goroutine while the buffer
+// is edited, which the race detector checks
+func TestCloneConcurrent(t *testing.T) {
+	gb := buffer.NewWithChunkSize(16)
+	gb.InsertAt(0, strings.Repeat("shared chunk text\n", 200))
+	c := gb.Clone()
+	want := c.GetText()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			if c.GetText() != want || c.LineCount() != 201 {
+				t.Error("the clone changed")
+				return
+			}
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		gb.Replace(i*5, i*5+3, "edit")
+	}
+	wg.Wait()
+}
+
+// TestCloneMapped checks that a clone of a buffer opened with NewFromFile
+// outlives Close of the buffer
+func TestCloneMapped(t *testing.T) {
+	text := strings.Repeat("mapped line\n", 5000)
+	path := filepath.Join(t.TempDir(), "mapped.txt")
+	os.WriteFile(path, []byte(text), 0o644)
+	gb, err := buffer.NewFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := gb.Clone()
+	if err := gb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if c.GetText() != text {
+		t.Error("the clone lost its text when the buffer was closed")
+	}
+}
@@ -0,0 +1,141 @@
+package buffer
+
+import "unicode"
+
+// VisualColumn returns the column at which offset is displayed in its line
+// by a terminal: tabs advance to the next multiple of tabWidth, East Asian
+// wide characters and emoji take two columns and combining marks none. A
+// grapheme cluster takes the width of its first rune, so an offset inside
+// a cluster gets the column after it.
+func (gb *GapBuffer) VisualColumn(offset int, tabWidth int) (col int, err error) {
+	defer guard("VisualColumn", offset, gb.length, &err)
+
+	if offset < 0 || offset > gb.length {
+		return 0, ErrPositionOutOfRange
+	}
+	offset = gb.snapEOL(offset, false)
+	gb.visualColumns(gb.lineStart(offset), gb.lineEnd(offset), tabWidth, func(at int, _ int, end int) bool {
+		if at >= offset {
+			return false
+		}
+		col = end
+		return true
+	})
+	return col, nil
+}
+
+// OffsetAtVisualColumn returns the position of the grapheme cluster
+// displayed at column col of the zero-based line, which is the cluster
+// covering the column for wide characters and tabs. Columns past the end
+// of the line give the end of the line.
+func (gb *GapBuffer) OffsetAtVisualColumn(line int, col int, tabWidth int) (pos int, err error) {
+	defer guard("OffsetAtVisualColumn", line, gb.length, &err)
+
+	if col < 0 {
+		return 0, ErrPositionOutOfRange
+	}
+	r, err := gb.lineRange(line)
+	if err != nil {
+		return 0, err
+	}
+	pos = r.End
+	gb.visualColumns(r.Start, r.End, tabWidth, func(at int, start int, end int) bool {
+		if col < end {
+			pos = at
+			return false
+		}
+		return true
+	})
+	return pos, nil
+}
+
+// visualColumns lays out [start, end), which must start a line, calling fn
+// with the position of every grapheme cluster and the columns it spans
+// until fn returns false
+func (gb *GapBuffer) visualColumns(start int, end int, tabWidth int, fn func(pos int, start int, end int) bool) {
+	if tabWidth <= 0 {
+		tabWidth = 1
+	}
+
+	var g graphemeBreaker
+	col, width := 0, 0 // start and width of the current cluster
+	clusterPos := -1
+	stopped := false
+	gb.scanRunes(start, end, func(pos int, r rune, _ int) bool {
+		if !g.next(r) {
+			if r == 0xFE0F && width == 1 {
+				width = 2 // emoji presentation selector
+			}
+			return true
+		}
+		if clusterPos >= 0 && !fn(clusterPos, col, col+width) {
+			stopped = true
+			return false
+		}
+		col += width
+		clusterPos = pos
+		if r == '\t' {
+			width = tabWidth - col%tabWidth
+		} else {
+			width = runeWidth(r)
+		}
+		return true
+	})
+	if clusterPos >= 0 && !stopped {
+		fn(clusterPos, col, col+width)
+	}
+}
+
+// runeWidth returns the number of terminal columns r takes: 0 for
+// combining marks and format and control characters, 2 for East Asian wide
+// and fullwidth characters and emoji, 1 otherwise
+func runeWidth(r rune) int {
+	switch {
+	case r >= 0x20 && r < 0x7F:
+		return 1
+	case r < 0x20, r >= 0x7F && r < 0xA0, r == 0x200D, r >= 0x1160 && r <= 0x11FF,
+		unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// wideRanges are the East Asian Wide and Fullwidth blocks and the emoji
+// displayed wide by default, sorted
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+	{0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x17000, 0x18AFF}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F1E6, 0x1F1FF}, {0x1F200, 0x1F251},
+	{0x1F300, 0x1F64F}, {0x1F680, 0x1F6FF}, {0x1F7E0, 0x1F7EB}, {0x1F900, 0x1F9FF},
+	{0x1FA70, 0x1FAFF}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+// isWide reports whether r is in wideRanges
+func isWide(r rune) bool {
+	lo, hi := 0, len(wideRanges)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch {
+		case r < wideRanges[mid][0]:
+			hi = mid
+		case r > wideRanges[mid][1]:
+			lo = mid + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
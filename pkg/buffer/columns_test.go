@@ -0,0 +1,58 @@
+package buffer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestVisualColumn checks columns of tabs, wide characters and clusters
+func TestVisualColumn(t *testing.T) {
+	gb := newBuffer(t, "a\tb\n中x\ne\u0301x\n\U0001F600!")
+	tests := []struct {
+		offset int
+		want   int
+	}{
+		{0, 0}, {1, 1}, {2, 4}, {3, 5},
+		{4, 0}, {7, 2}, {8, 3},
+		{9, 0}, {10, 1}, {12, 1}, {13, 2},
+		{14, 0}, {18, 2},
+	}
+	for _, tt := range tests {
+		if got, err := gb.VisualColumn(tt.offset, 4); err != nil || got != tt.want {
+			t.Errorf("VisualColumn(%d) = %d, %v, want %d", tt.offset, got, err, tt.want)
+		}
+	}
+	if got, _ := gb.VisualColumn(2, 8); got != 8 {
+		t.Errorf("tab width 8: got column %d", got)
+	}
+	if _, err := gb.VisualColumn(gb.Length()+1, 4); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("offset past the end: %v", err)
+	}
+}
+
+// TestOffsetAtVisualColumn checks that columns map back to the cluster
+// covering them
+func TestOffsetAtVisualColumn(t *testing.T) {
+	gb := newBuffer(t, "a\tb\n中x\ne\u0301x")
+	tests := []struct {
+		line, col int
+		want      int
+	}{
+		{0, 0, 0}, {0, 1, 1}, {0, 3, 1}, {0, 4, 2}, {0, 9, 3},
+		{1, 1, 4}, {1, 2, 7}, {1, 3, 8},
+		{2, 0, 9}, {2, 1, 12},
+	}
+	for _, tt := range tests {
+		if got, err := gb.OffsetAtVisualColumn(tt.line, tt.col, 4); err != nil || got != tt.want {
+			t.Errorf("OffsetAtVisualColumn(%d, %d) = %d, %v, want %d", tt.line, tt.col, got, err, tt.want)
+		}
+	}
+	if _, err := gb.OffsetAtVisualColumn(0, -1, 4); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("negative column: %v", err)
+	}
+	if _, err := gb.OffsetAtVisualColumn(3, 0, 4); err == nil {
+		t.Errorf("line past the end: no error")
+	}
+}
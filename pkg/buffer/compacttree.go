@@ -0,0 +1,493 @@
+package buffer
+
+import "math"
+
+// compactNode is a node of a compactTree. Its own measures are not stored:
+// they are the measures of its subtree minus those of its children.
+type compactNode struct {
+	key         int
+	value       payload
+	sum         measures
+	left, right int32 // indexes in the arena, 0 for none
+	red         bool
+	gen         uint32 // generation of the tree that made the node
+}
+
+// compactTree is a left-leaning red-black tree whose nodes live in one
+// arena and refer to each other by 32-bit indexes. There are no parent
+// pointers: updates recurse from the root and fix the measures on the way
+// back up, and iteration keeps an explicit stack.
+//
+// A forked tree shares the arena and the nodes of the tree it was forked
+// from. Nodes of an older generation belong to both and are never written:
+// an update copies the nodes on its path from the root instead, so the
+// original tree keeps reading the text it had.
+type compactTree struct {
+	nodes []compactNode // nodes[0] stands for the missing child
+	root  int32
+	free  int32 // released slots, chained through left
+	n     int
+	gen   uint32
+}
+
+// newCompactTree creates an empty compact tree
+func newCompactTree() *compactTree {
+	return &compactTree{nodes: make([]compactNode, 1)}
+}
+
+// add inserts a node with the given key and value, measuring the value
+func (t *compactTree) add(key int, value payload) {
+	t.insertMeasured(key, value, value.measure())
+}
+
+// insertMeasured is like Insert but takes the measure of value
+func (t *compactTree) insertMeasured(key int, value payload, m measures) {
+	t.root = t.put(t.root, key, value, m)
+	t.nodes[t.root].red = false
+	t.n++
+}
+
+// put inserts into the subtree rooted at h and returns its new root
+func (t *compactTree) put(h int32, key int, value payload, m measures) int32 {
+	if h == 0 {
+		return t.alloc(key, value, m)
+	}
+	h = t.mutable(h)
+
+	// The arena may grow in the recursive call, so nodes are addressed
+	// through t.nodes again after it
+	own := t.own(h)
+	if key < t.nodes[h].key {
+		left := t.put(t.nodes[h].left, key, value, m)
+		t.nodes[h].left = left
+	} else {
+		right := t.put(t.nodes[h].right, key, value, m)
+		t.nodes[h].right = right
+	}
+	t.pull(h, own)
+	return t.balance(h)
+}
+
+// Delete removes the node with the given key, if there is one
+func (t *compactTree) Delete(key int) {
+	if t.find(key) == 0 {
+		return
+	}
+	t.root = t.mutable(t.root)
+	root := &t.nodes[t.root]
+	if !t.isRed(root.left) && !t.isRed(root.right) {
+		root.red = true
+	}
+	t.root = t.del(t.root, key)
+	if t.root != 0 {
+		t.nodes[t.root].red = false
+	}
+	t.n--
+}
+
+// del removes key, which must be there, from the subtree rooted at h and
+// returns its new root
+func (t *compactTree) del(h int32, key int) int32 {
+	h = t.mutable(h)
+	if key < t.nodes[h].key {
+		if !t.isRed(t.nodes[h].left) && !t.isRed(t.nodes[t.nodes[h].left].left) {
+			h = t.moveRedLeft(h)
+		}
+		own := t.own(h)
+		left := t.del(t.nodes[h].left, key)
+		t.nodes[h].left = left
+		t.pull(h, own)
+		return t.balance(h)
+	}
+
+	if t.isRed(t.nodes[h].left) {
+		h = t.rotateRight(h)
+	}
+	if key == t.nodes[h].key && t.nodes[h].right == 0 {
+		t.release(h)
+		return 0
+	}
+	if !t.isRed(t.nodes[h].right) && !t.isRed(t.nodes[t.nodes[h].right].left) {
+		h = t.moveRedRight(h)
+	}
+	own := t.own(h)
+	if key == t.nodes[h].key {
+		// Take the place of the smallest node on the right
+		x := t.nodes[h].right
+		for t.nodes[x].left != 0 {
+			x = t.nodes[x].left
+		}
+		t.nodes[h].key, t.nodes[h].value = t.nodes[x].key, t.nodes[x].value
+		own = t.own(x)
+		right := t.deleteMin(t.nodes[h].right)
+		t.nodes[h].right = right
+	} else {
+		right := t.del(t.nodes[h].right, key)
+		t.nodes[h].right = right
+	}
+	t.pull(h, own)
+	return t.balance(h)
+}
+
+// deleteMin removes the smallest node of the subtree rooted at h and
+// returns its new root
+func (t *compactTree) deleteMin(h int32) int32 {
+	if t.nodes[h].left == 0 {
+		t.release(h)
+		return 0
+	}
+	h = t.mutable(h)
+	if !t.isRed(t.nodes[h].left) && !t.isRed(t.nodes[t.nodes[h].left].left) {
+		h = t.moveRedLeft(h)
+	}
+	own := t.own(h)
+	left := t.deleteMin(t.nodes[h].left)
+	t.nodes[h].left = left
+	t.pull(h, own)
+	return t.balance(h)
+}
+
+// set replaces the value of the node at key, which must exist
+func (t *compactTree) set(key int, value payload) {
+	t.root = t.setIn(t.root, key, value)
+}
+
+// setIn replaces the value at key in the subtree rooted at h and returns
+// its new root
+func (t *compactTree) setIn(h int32, key int, value payload) int32 {
+	if h == 0 {
+		return 0
+	}
+	h = t.mutable(h)
+	own := t.own(h)
+	switch {
+	case key < t.nodes[h].key:
+		left := t.setIn(t.nodes[h].left, key, value)
+		t.nodes[h].left = left
+	case key > t.nodes[h].key:
+		right := t.setIn(t.nodes[h].right, key, value)
+		t.nodes[h].right = right
+	default:
+		t.nodes[h].value = value
+		own = t.nodes[h].value.measure()
+	}
+	t.pull(h, own)
+	return h
+}
+
+// find returns the node with the given key, or 0
+func (t *compactTree) find(key int) int32 {
+	h := t.root
+	for h != 0 && t.nodes[h].key != key {
+		if key < t.nodes[h].key {
+			h = t.nodes[h].left
+		} else {
+			h = t.nodes[h].right
+		}
+	}
+	return h
+}
+
+// floor returns the node with the largest key less than or equal to key
+func (t *compactTree) floor(key int) (int, *payload, bool) {
+	var result int32
+	for h := t.root; h != 0; {
+		if t.nodes[h].key <= key {
+			result = h
+			h = t.nodes[h].right
+		} else {
+			h = t.nodes[h].left
+		}
+	}
+	if result == 0 {
+		return 0, nil, false
+	}
+	return t.nodes[result].key, &t.nodes[result].value, true
+}
+
+// ascend calls fn with the nodes with a key in [from, to) in key order
+func (t *compactTree) ascend(from int, to int, fn func(key int, value *payload, own measures) bool) {
+	t.each(from, to, func(h int32) bool {
+		return fn(t.nodes[h].key, &t.nodes[h].value, t.own(h))
+	})
+}
+
+// each calls fn with the nodes with a key in [from, to) in key order until
+// fn returns false
+func (t *compactTree) each(from int, to int, fn func(h int32) bool) {
+	var buf [64]int32
+	stack := buf[:0]
+	for h := t.root; h != 0; {
+		if t.nodes[h].key >= from {
+			stack = append(stack, h)
+			h = t.nodes[h].left
+		} else {
+			h = t.nodes[h].right
+		}
+	}
+	for len(stack) > 0 {
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if t.nodes[h].key >= to || !fn(h) {
+			return
+		}
+		for h = t.nodes[h].right; h != 0; h = t.nodes[h].left {
+			stack = append(stack, h)
+		}
+	}
+}
+
+// descend calls fn with the nodes with a key at most from in reverse key
+// order
+func (t *compactTree) descend(from int, fn func(key int, value *payload) bool) {
+	var buf [64]int32
+	stack := buf[:0]
+	for h := t.root; h != 0; {
+		if t.nodes[h].key <= from {
+			stack = append(stack, h)
+			h = t.nodes[h].right
+		} else {
+			h = t.nodes[h].left
+		}
+	}
+	for len(stack) > 0 {
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !fn(t.nodes[h].key, &t.nodes[h].value) {
+			return
+		}
+		for h = t.nodes[h].left; h != 0; h = t.nodes[h].right {
+			stack = append(stack, h)
+		}
+	}
+}
+
+// shift moves the nodes with a key in [from, to) by delta
+func (t *compactTree) shift(from int, to int, delta int) {
+	t.root = t.shiftIn(t.root, from, to, delta)
+}
+
+// shiftIn moves the nodes with a key in [from, to) of the subtree rooted
+// at h by delta and returns its new root
+func (t *compactTree) shiftIn(h int32, from int, to int, delta int) int32 {
+	if h == 0 {
+		return 0
+	}
+	key, left, right := t.nodes[h].key, t.nodes[h].left, t.nodes[h].right
+	if key > from {
+		left = t.shiftIn(left, from, to, delta)
+	}
+	if key < to {
+		right = t.shiftIn(right, from, to, delta)
+	}
+	moved := key >= from && key < to
+	if !moved && left == t.nodes[h].left && right == t.nodes[h].right {
+		return h
+	}
+	h = t.mutable(h)
+	t.nodes[h].left, t.nodes[h].right = left, right
+	if moved {
+		t.nodes[h].key += delta
+	}
+	return h
+}
+
+// total returns the measures of the whole tree
+func (t *compactTree) total() measures {
+	return t.nodes[t.root].sum
+}
+
+// seek descends from the root to the node in which in first holds
+func (t *compactTree) seek(in func(before measures, m measures) bool) (*payload, measures, measures, bool, bool) {
+	var before measures
+	for h := t.root; h != 0; {
+		left := t.nodes[t.nodes[h].left].sum
+		if in(before, left) {
+			h = t.nodes[h].left
+			continue
+		}
+		before = before.plus(left)
+		own := t.own(h)
+		if in(before, own) {
+			return &t.nodes[h].value, before, own, true, t.nodes[h].gen == t.gen
+		}
+		before = before.plus(own)
+		h = t.nodes[h].right
+	}
+	return nil, before, measures{}, false, false
+}
+
+// Depth returns the number of nodes on the longest path from the root
+func (t *compactTree) Depth() int {
+	var depth func(h int32) int
+	depth = func(h int32) int {
+		if h == 0 {
+			return 0
+		}
+		return 1 + max(depth(t.nodes[h].left), depth(t.nodes[h].right))
+	}
+	return depth(t.root)
+}
+
+// count returns the number of nodes
+func (t *compactTree) count() int {
+	return t.n
+}
+
+// forked returns a tree sharing the nodes of t, which copies them before
+// they change. The copies leave nodes behind in the arena; once they are
+// more than the live ones, it returns a rebuilt tree instead.
+func (t *compactTree) forked() chunkTree {
+	if len(t.nodes) > 2*(t.n+1) {
+		return t.rebuilt()
+	}
+	fork := *t
+	fork.gen++
+	return &fork
+}
+
+// rebuilt returns a compact tree holding the same keys and values, with
+// its arena packed
+func (t *compactTree) rebuilt() chunkTree {
+	fresh := newCompactTree()
+	fresh.nodes = make([]compactNode, 1, t.n+1)
+	t.ascend(math.MinInt, math.MaxInt, func(key int, value *payload, own measures) bool {
+		fresh.insertMeasured(key, *value, own)
+		return true
+	})
+	return fresh
+}
+
+// own returns the measures of the value of h
+func (t *compactTree) own(h int32) measures {
+	n := &t.nodes[h]
+	return n.sum.minus(t.nodes[n.left].sum).minus(t.nodes[n.right].sum)
+}
+
+// pull recomputes the measures of h from its children and its own
+func (t *compactTree) pull(h int32, own measures) {
+	n := &t.nodes[h]
+	n.sum = t.nodes[n.left].sum.plus(own).plus(t.nodes[n.right].sum)
+}
+
+// isRed reports whether h is a red node
+func (t *compactTree) isRed(h int32) bool {
+	return h != 0 && t.nodes[h].red
+}
+
+// rotateLeft makes the right child of h the root of its subtree
+func (t *compactTree) rotateLeft(h int32) int32 {
+	h = t.mutable(h)
+	own := t.own(h)
+	x := t.mutable(t.nodes[h].right)
+	t.nodes[h].right = t.nodes[x].left
+	t.nodes[x].left = h
+	t.nodes[x].red, t.nodes[h].red = t.nodes[h].red, true
+	t.nodes[x].sum = t.nodes[h].sum
+	t.pull(h, own)
+	return x
+}
+
+// rotateRight makes the left child of h the root of its subtree
+func (t *compactTree) rotateRight(h int32) int32 {
+	h = t.mutable(h)
+	own := t.own(h)
+	x := t.mutable(t.nodes[h].left)
+	t.nodes[h].left = t.nodes[x].right
+	t.nodes[x].right = h
+	t.nodes[x].red, t.nodes[h].red = t.nodes[h].red, true
+	t.nodes[x].sum = t.nodes[h].sum
+	t.pull(h, own)
+	return x
+}
+
+// flip inverts the colors of h, which must be of the current generation,
+// and its children
+func (t *compactTree) flip(h int32) {
+	t.nodes[h].red = !t.nodes[h].red
+	if left := t.nodes[h].left; left != 0 {
+		left = t.mutable(left)
+		t.nodes[h].left = left
+		t.nodes[left].red = !t.nodes[left].red
+	}
+	if right := t.nodes[h].right; right != 0 {
+		right = t.mutable(right)
+		t.nodes[h].right = right
+		t.nodes[right].red = !t.nodes[right].red
+	}
+}
+
+// balance restores the left-leaning invariants at h on the way up
+func (t *compactTree) balance(h int32) int32 {
+	if t.isRed(t.nodes[h].right) && !t.isRed(t.nodes[h].left) {
+		h = t.rotateLeft(h)
+	}
+	if t.isRed(t.nodes[h].left) && t.isRed(t.nodes[t.nodes[h].left].left) {
+		h = t.rotateRight(h)
+	}
+	if t.isRed(t.nodes[h].left) && t.isRed(t.nodes[h].right) {
+		t.flip(h)
+	}
+	return h
+}
+
+// moveRedLeft makes the left child of h or one of its children red,
+// assuming h is red and both its children black
+func (t *compactTree) moveRedLeft(h int32) int32 {
+	t.flip(h)
+	if t.isRed(t.nodes[t.nodes[h].right].left) {
+		right := t.rotateRight(t.nodes[h].right)
+		t.nodes[h].right = right
+		h = t.rotateLeft(h)
+		t.flip(h)
+	}
+	return h
+}
+
+// moveRedRight makes the right child of h or one of its children red,
+// assuming h is red and both its children black
+func (t *compactTree) moveRedRight(h int32) int32 {
+	t.flip(h)
+	if t.isRed(t.nodes[t.nodes[h].left].left) {
+		h = t.rotateRight(h)
+		t.flip(h)
+	}
+	return h
+}
+
+// alloc returns a new red node
+func (t *compactTree) alloc(key int, value payload, m measures) int32 {
+	return t.place(compactNode{key: key, value: value, sum: m, red: true})
+}
+
+// mutable returns h if its node is of the current generation, and
+// otherwise a copy of it that is. The caller links the copy in place of h.
+func (t *compactTree) mutable(h int32) int32 {
+	if t.nodes[h].gen == t.gen {
+		return h
+	}
+	return t.place(t.nodes[h])
+}
+
+// place stores node in the arena as a node of the current generation,
+// reusing a released slot if there is one, and returns its index
+func (t *compactTree) place(node compactNode) int32 {
+	node.gen = t.gen
+	if h := t.free; h != 0 {
+		t.free = t.nodes[h].left
+		t.nodes[h] = node
+		return h
+	}
+	t.nodes = append(t.nodes, node)
+	return int32(len(t.nodes) - 1)
+}
+
+// release returns the slot of h to the free list, dropping its text. The
+// node of an older generation is left to the trees sharing it.
+func (t *compactTree) release(h int32) {
+	if t.nodes[h].gen != t.gen {
+		return
+	}
+	t.nodes[h] = compactNode{left: t.free}
+	t.free = h
+}
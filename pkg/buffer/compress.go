@@ -0,0 +1,110 @@
+package buffer
+
+import (
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Compressor wraps w in a writer that compresses everything written to it.
+// Closing the returned writer must flush the compressed stream but not close w.
+type Compressor func(w io.Writer) (io.WriteCloser, error)
+
+// GzipCompressor returns a Compressor producing gzip output at the given level
+func GzipCompressor(level int) Compressor {
+	return func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, level)
+	}
+}
+
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[string]Compressor{
+		".gz": GzipCompressor(gzip.DefaultCompression),
+	}
+)
+
+// RegisterCompressor makes c the compressor used for file names ending in ext,
+// e.g. ".zst" backed by a zstd encoder
+func RegisterCompressor(ext string, c Compressor) {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+	compressors[strings.ToLower(ext)] = c
+}
+
+// CompressorFor returns the compressor registered for the extension of name,
+// or nil if the file should be written uncompressed
+func CompressorFor(name string) Compressor {
+	compressorsMu.RLock()
+	defer compressorsMu.RUnlock()
+	return compressors[strings.ToLower(filepath.Ext(name))]
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// WriteTo implements io.WriterTo: it writes the chunks straight into w in
+// order, followed by the final newline if one is synthesized, so saving
+// never materializes the text. It returns the number of bytes written.
+func (gb *GapBuffer) WriteTo(w io.Writer) (n int64, err error) {
+	defer guard("WriteTo", noPos, gb.length, &err)
+
+	cw := &countingWriter{w: w}
+	err = gb.writeContent(cw)
+	return cw.n, err
+}
+
+// WriteCompressed streams the buffer content through c into w without
+// materializing the text. A nil c writes the content uncompressed.
+// It returns the number of bytes written to w.
+func (gb *GapBuffer) WriteCompressed(w io.Writer, c Compressor) (n int64, err error) {
+	defer guard("WriteCompressed", noPos, gb.length, &err)
+	return writeCompressed(w, c, gb.writeContent)
+}
+
+// writeCompressed calls write with a writer compressing into w through c,
+// or with w itself if c is nil, and returns the number of bytes written to
+// w
+func writeCompressed(w io.Writer, c Compressor, write func(io.Writer) error) (int64, error) {
+	cw := &countingWriter{w: w}
+	if c == nil {
+		err := write(cw)
+		return cw.n, err
+	}
+
+	zw, err := c(cw)
+	if err != nil {
+		return 0, err
+	}
+	if err := write(zw); err != nil {
+		zw.Close()
+		return cw.n, err
+	}
+	err = zw.Close()
+	return cw.n, err
+}
+
+// writeContent writes the stored bytes chunk by chunk, followed by the
+// synthesized final newline if any
+func (gb *GapBuffer) writeContent(w io.Writer) error {
+	var err error
+	gb.forEachChunk(0, gb.length, func(pos int, text string) bool {
+		_, err = io.WriteString(w, text)
+		return err == nil
+	})
+	if err == nil && gb.hasVirtualNewline() {
+		_, err = io.WriteString(w, "\n")
+	}
+	return err
+}
@@ -0,0 +1,137 @@
+package buffer_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// gunzip returns the content of a gzip stream
+func gunzip(t *testing.T, data []byte) string {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(text)
+}
+
+// upper is a Compressor upper-casing what is written, standing in for a
+// registered format
+func upper(w io.Writer) (io.WriteCloser, error) {
+	return upperWriter{w}, nil
+}
+
+type upperWriter struct{ w io.Writer }
+
+func (u upperWriter) Write(p []byte) (int, error) {
+	return u.w.Write(bytes.ToUpper(p))
+}
+
+func (u upperWriter) Close() error { return nil }
+
+// TestSaveFileCompressed checks that SaveFile picks the compressor by
+// extension, that opts.Compressor overrides it, and WriteCompressed
+func TestSaveFileCompressed(t *testing.T) {
+	text := strings.Repeat("compressible line\n", 100)
+	gb := newBuffer(t, text)
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "doc.txt.GZ")
+	if err := gb.SaveFile(path, buffer.SaveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := gunzip(t, data); got != text {
+		t.Errorf("gzip file: got %d bytes, want %d", len(got), len(text))
+	}
+	if len(data) >= len(text) {
+		t.Errorf("gzip file of %d bytes is not smaller than the text", len(data))
+	}
+
+	buffer.RegisterCompressor(".up", upper)
+	path = filepath.Join(dir, "doc.up")
+	if err := gb.SaveFile(path, buffer.SaveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != strings.ToUpper(text) {
+		t.Errorf("registered compressor: got %.20q...", data)
+	}
+
+	path = filepath.Join(dir, "doc.txt")
+	if err := gb.SaveFile(path, buffer.SaveOptions{Compressor: buffer.GzipCompressor(gzip.BestSpeed)}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); gunzip(t, data) != text {
+		t.Error("explicit compressor: content differs")
+	}
+
+	var out bytes.Buffer
+	n, err := gb.WriteCompressed(&out, nil)
+	if err != nil || n != int64(len(text)) || out.String() != text {
+		t.Errorf("uncompressed: wrote %d bytes, %v", n, err)
+	}
+	out.Reset()
+	n, err = gb.WriteCompressed(&out, buffer.CompressorFor("x.gz"))
+	if err != nil || n != int64(out.Len()) || gunzip(t, out.Bytes()) != text {
+		t.Errorf("compressed: wrote %d of %d bytes, %v", n, out.Len(), err)
+	}
+	if buffer.CompressorFor("x.txt") != nil {
+		t.Error("CompressorFor of a plain file is not nil")
+	}
+}
+
+// failingWriter accepts up to n bytes and then fails
+type failingWriter struct {
+	n   int
+	buf bytes.Buffer
+}
+
+// errWriteFailed is the error of a failingWriter
+var errWriteFailed = errors.New("write failed")
+
+// Write implements io.Writer
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		w.buf.Write(p[:w.n])
+		n := w.n
+		w.n = 0
+		return n, errWriteFailed
+	}
+	w.n -= len(p)
+	return w.buf.Write(p)
+}
+
+// TestWriteTo checks that WriteTo writes the stored bytes chunk by chunk
+// and returns the writer's error with the count written before it
+func TestWriteTo(t *testing.T) {
+	text := strings.Repeat("write\xfe to\n", 40)
+	gb := buffer.NewWithChunkSize(16)
+	gb.InsertAt(0, text)
+	gb.RandomizeLayout(2)
+
+	var sb strings.Builder
+	if n, err := gb.WriteTo(&sb); err != nil || n != int64(len(text)) || sb.String() != text {
+		t.Errorf("wrote %d bytes, %v; equal %v", n, err, sb.String() == text)
+	}
+
+	w := &failingWriter{n: 100}
+	n, err := gb.WriteTo(w)
+	if !errors.Is(err, errWriteFailed) || n != 100 || w.buf.String() != text[:100] {
+		t.Errorf("failing writer: wrote %d bytes, %v", n, err)
+	}
+}
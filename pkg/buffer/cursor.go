@@ -0,0 +1,203 @@
+package buffer
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Unit is the step a cursor moves by
+type Unit int
+
+const (
+	UnitByte Unit = iota
+	UnitRune
+	UnitGrapheme // User-perceived characters, see GraphemeCount
+	UnitWord     // Unicode words, see WordBoundaryAfter, skipping what lies between them
+	UnitLine     // Keeps the column of the first line move, clamped to shorter lines
+)
+
+// Cursor is an editing position in a buffer that follows edits made through
+// it or anywhere else. Text inserted at the cursor ends up before it. A
+// cursor never rests between the \r and \n of a \r\n: moves step over
+// the pair as one, and positions inside it are taken to be before it.
+type Cursor struct {
+	gb   *GapBuffer
+	mark *Marker
+
+	goal    int // byte column line moves aim for
+	goalPos int // position the goal column was last valid at, -1 for none
+}
+
+// NewCursor returns a cursor at pos. Close it when it is no longer needed.
+func (gb *GapBuffer) NewCursor(pos int) (c *Cursor, err error) {
+	defer guard("NewCursor", pos, gb.length, &err)
+
+	mark, err := gb.CreateMarker(pos, GravityRight)
+	if err != nil {
+		return nil, err
+	}
+	return &Cursor{gb: gb, mark: mark, goalPos: -1}, nil
+}
+
+// Close stops the buffer from updating the cursor
+func (c *Cursor) Close() {
+	c.mark.Remove()
+}
+
+// Pos returns the position of the cursor
+func (c *Cursor) Pos() int {
+	return c.gb.snapEOL(c.mark.Pos(), false)
+}
+
+// SetPos moves the cursor to pos
+func (c *Cursor) SetPos(pos int) error {
+	c.goalPos = -1
+	return c.mark.SetPos(c.gb.snapEOL(pos, false))
+}
+
+// SetCollapse changes where the cursor goes when an edit replaces the text
+// around it, CollapseGravity (the end of the replacement) by default
+func (c *Cursor) SetCollapse(mode Collapse) {
+	c.mark.SetCollapse(mode)
+}
+
+// LineCol returns the zero-based line of the cursor and its byte offset in it
+func (c *Cursor) LineCol() (line int, col int) {
+	line = c.gb.lineAt(c.Pos())
+	return line, c.Pos() - c.gb.lineStartOf(line)
+}
+
+// AtStart reports whether the cursor is at the start of the buffer
+func (c *Cursor) AtStart() bool {
+	return c.Pos() == 0
+}
+
+// AtEnd reports whether the cursor is at the end of the buffer
+func (c *Cursor) AtEnd() bool {
+	return c.Pos() == c.gb.length
+}
+
+// MoveForward moves the cursor n units towards the end of the buffer,
+// stopping there, and returns the new position
+func (c *Cursor) MoveForward(unit Unit, n int) int {
+	return c.move(unit, n)
+}
+
+// MoveBackward moves the cursor n units towards the start of the buffer,
+// stopping there, and returns the new position
+func (c *Cursor) MoveBackward(unit Unit, n int) int {
+	return c.move(unit, -n)
+}
+
+// move moves the cursor n units, backwards if n is negative
+func (c *Cursor) move(unit Unit, n int) int {
+	gb := c.gb
+	pos := c.Pos()
+
+	switch unit {
+	case UnitByte:
+		pos = gb.snapEOL(min(max(pos+n, 0), gb.length), n > 0)
+	case UnitRune:
+		for ; n > 0 && pos < gb.length; n-- {
+			pos = gb.nextRune(pos)
+		}
+		for ; n < 0 && pos > 0; n++ {
+			pos = gb.prevRune(pos)
+		}
+	case UnitGrapheme:
+		for ; n > 0 && pos < gb.length; n-- {
+			pos = gb.nextGrapheme(pos)
+		}
+		for ; n < 0 && pos > 0; n++ {
+			pos = gb.prevGrapheme(pos)
+		}
+	case UnitWord:
+		for ; n > 0 && pos < gb.length; n-- {
+			pos = gb.WordBoundaryAfter(pos)
+		}
+		for ; n < 0 && pos > 0; n++ {
+			pos = gb.WordBoundaryBefore(pos)
+		}
+	case UnitLine:
+		line, col := c.LineCol()
+		if c.goalPos != pos {
+			c.goal = col
+		}
+		line = min(max(line+n, 0), gb.lineCount()-1)
+		r, _ := gb.lineRange(line)
+		pos = r.Start + min(c.goal, r.Len())
+		for pos > r.Start && !gb.runeStart(pos) {
+			pos--
+		}
+		c.mark.moveTo(pos)
+		c.goalPos = pos
+		return pos
+	}
+
+	c.mark.moveTo(pos)
+	c.goalPos = -1
+	return pos
+}
+
+// InsertHere inserts text at the cursor and moves the cursor after it
+func (c *Cursor) InsertHere(text string) error {
+	c.goalPos = -1
+	return c.gb.InsertAt(c.Pos(), text)
+}
+
+// DeleteForward deletes up to n runes after the cursor, a \r\n counting
+// as one
+func (c *Cursor) DeleteForward(n int) error {
+	end := c.Pos()
+	for ; n > 0 && end < c.gb.length; n-- {
+		end = c.gb.nextRune(end)
+	}
+	c.goalPos = -1
+	return c.gb.DeleteAt(c.Pos(), end-c.Pos())
+}
+
+// DeleteBackward deletes up to n runes before the cursor, a \r\n
+// counting as one
+func (c *Cursor) DeleteBackward(n int) error {
+	start := c.Pos()
+	for ; n > 0 && start > 0; n-- {
+		start = c.gb.prevRune(start)
+	}
+	c.goalPos = -1
+	return c.gb.DeleteAt(start, c.Pos()-start)
+}
+
+// nextRune returns the position after the rune at pos, or after the whole
+// \r\n if the rune is its \r
+func (gb *GapBuffer) nextRune(pos int) int {
+	_, size := gb.runeAfter(pos)
+	return gb.snapEOL(pos+size, true)
+}
+
+// prevRune returns the position of the rune before pos, or of the \r of
+// a \r\n ending at pos
+func (gb *GapBuffer) prevRune(pos int) int {
+	_, size := gb.runeBefore(pos)
+	return gb.snapEOL(pos-size, false)
+}
+
+// runeAfter decodes the rune starting at pos, which must be before the end.
+// Invalid bytes are returned as utf8.RuneError of size 1.
+func (gb *GapBuffer) runeAfter(pos int) (rune, int) {
+	return utf8.DecodeRuneInString(gb.rawText(pos, min(pos+utf8.UTFMax, gb.length)))
+}
+
+// runeBefore decodes the rune ending at pos, which must be after the start
+func (gb *GapBuffer) runeBefore(pos int) (rune, int) {
+	return utf8.DecodeLastRuneInString(gb.rawText(max(pos-utf8.UTFMax, 0), pos))
+}
+
+// runeStart reports whether the byte at pos starts a rune
+func (gb *GapBuffer) runeStart(pos int) bool {
+	return pos >= gb.length || utf8.RuneStart(gb.rawText(pos, pos+1)[0])
+}
+
+// isWordRune reports whether r is part of a word for cursor movement
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
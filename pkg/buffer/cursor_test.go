@@ -0,0 +1,91 @@
+package buffer_test
+
+import (
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestCursorMoves checks moving by each unit, including over a \r\n and
+// multi-byte runes, and stopping at either end
+func TestCursorMoves(t *testing.T) {
+	gb := newBuffer(t, "héllo wörld\r\nab\r\nlast line")
+	c, err := gb.NewCursor(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	steps := []struct {
+		forward bool
+		unit    buffer.Unit
+		n       int
+		want    int
+	}{
+		{true, buffer.UnitRune, 2, 3},
+		{true, buffer.UnitByte, 1, 4},
+		{true, buffer.UnitWord, 1, 6},
+		{true, buffer.UnitWord, 1, 13},
+		{true, buffer.UnitRune, 1, 15},  // over the \r\n
+		{false, buffer.UnitByte, 1, 13}, // not between \r and \n
+		{false, buffer.UnitWord, 1, 7},
+		{true, buffer.UnitLine, 1, 17}, // column 7 clamped to "ab"
+		{true, buffer.UnitLine, 1, 26}, // back to column 7
+		{false, buffer.UnitLine, 2, 7}, // column 7 kept through both moves
+		{false, buffer.UnitRune, 100, 0},
+		{true, buffer.UnitGrapheme, 100, 28},
+	}
+	for i, s := range steps {
+		var got int
+		if s.forward {
+			got = c.MoveForward(s.unit, s.n)
+		} else {
+			got = c.MoveBackward(s.unit, s.n)
+		}
+		if got != s.want || c.Pos() != s.want {
+			t.Fatalf("step %d: got %d, want %d", i, got, s.want)
+		}
+	}
+	if !c.AtEnd() || c.AtStart() {
+		t.Error("cursor not reported at the end")
+	}
+	if line, col := c.LineCol(); line != 2 || col != 9 {
+		t.Errorf("got %d:%d, want 2:9", line, col)
+	}
+	if c.SetPos(14); c.Pos() != 13 {
+		t.Errorf("SetPos inside a \\r\\n: got %d, want 13", c.Pos())
+	}
+}
+
+// TestCursorEdits checks editing at the cursor and following edits made
+// elsewhere
+func TestCursorEdits(t *testing.T) {
+	gb := newBuffer(t, "a\r\nbé")
+	c, _ := gb.NewCursor(3)
+	if err := c.InsertHere("xy"); err != nil || c.Pos() != 5 {
+		t.Fatalf("insert: at %d, %v", c.Pos(), err)
+	}
+	if err := c.DeleteForward(2); err != nil || gb.GetText() != "a\r\nxy" {
+		t.Fatalf("delete forward: got %q, %v", gb.GetText(), err)
+	}
+	if err := c.DeleteBackward(3); err != nil || gb.GetText() != "a" || c.Pos() != 1 {
+		t.Fatalf("delete backward: got %q, %v, at %d", gb.GetText(), err, c.Pos())
+	}
+	gb.InsertAt(0, ">> ")
+	if c.Pos() != 4 {
+		t.Errorf("after an insert before: got %d, want 4", c.Pos())
+	}
+	gb.InsertAt(4, "!")
+	if c.Pos() != 5 {
+		t.Errorf("after an insert at the cursor: got %d, want 5", c.Pos())
+	}
+
+	c.Close()
+	gb.InsertAt(0, "x")
+	if c.Pos() != 5 {
+		t.Errorf("closed cursor moved to %d", c.Pos())
+	}
+	if _, err := gb.NewCursor(gb.Length() + 1); err == nil {
+		t.Error("cursor past the end created")
+	}
+}
@@ -0,0 +1,75 @@
+package buffer
+
+// DeltaOp is one step of a Delta. Exactly one field is set: Retain skips
+// that many bytes, Insert inserts text at the current position and Delete
+// removes that many bytes there.
+type DeltaOp struct {
+	Retain int    `json:"retain,omitempty"`
+	Insert string `json:"insert,omitempty"`
+	Delete int    `json:"delete,omitempty"`
+}
+
+// Delta is an edit in a serializable form that walks the text from its
+// start, such as for shipping changes to a renderer or a remote peer.
+// Bytes after the last step are kept.
+type Delta struct {
+	Revision int       `json:"revision"` // Version of the buffer after the edit
+	Ops      []DeltaOp `json:"ops"`
+}
+
+// Delta returns the operation as a delta
+func (op Operation) Delta() Delta {
+	d := Delta{Revision: op.Version}
+	if op.Pos > 0 {
+		d.Ops = append(d.Ops, DeltaOp{Retain: op.Pos})
+	}
+	if op.InsertedText != "" {
+		d.Ops = append(d.Ops, DeltaOp{Insert: op.InsertedText})
+	}
+	if op.Deleted > 0 {
+		d.Ops = append(d.Ops, DeltaOp{Delete: op.Deleted})
+	}
+	return d
+}
+
+// Apply applies the delta to b, which must hold the text the delta was
+// made against
+func (d Delta) Apply(b Buffer) error {
+	pos := 0
+	for _, op := range d.Ops {
+		switch {
+		case op.Retain > 0:
+			pos += op.Retain
+			if pos > b.Length() {
+				return ErrPositionOutOfRange
+			}
+		case op.Insert != "":
+			if err := b.InsertAt(pos, op.Insert); err != nil {
+				return err
+			}
+			pos += len(op.Insert)
+		case op.Delete > 0:
+			if err := b.DeleteAt(pos, op.Delete); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Changes returns the edits applied after the given version as deltas,
+// oldest first. Applying them in order to the text of that version yields
+// the current text.
+func (gb *GapBuffer) Changes(since int) (deltas []Delta, err error) {
+	defer guard("Changes", noPos, gb.length, &err)
+
+	ops, err := gb.oplog.since(since, gb.version)
+	if err != nil {
+		return nil, err
+	}
+	deltas = make([]Delta, len(ops))
+	for i, op := range ops {
+		deltas[i] = op.Delta()
+	}
+	return deltas, nil
+}
@@ -0,0 +1,76 @@
+package buffer_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestChanges checks that the deltas since a version, sent through JSON,
+// bring a replica of that version up to date
+func TestChanges(t *testing.T) {
+	gb := newBuffer(t, "shared text")
+	replica := newBuffer(t, gb.GetText())
+	since := gb.Version()
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		pos := rng.Intn(gb.Length() + 1)
+		gb.Replace(pos, pos+rng.Intn(min(4, gb.Length()-pos)+1), "xyz"[:rng.Intn(4)])
+		if i%10 == 0 {
+			gb.Undo()
+		}
+	}
+
+	deltas, err := gb.Changes(since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(deltas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received []buffer.Delta
+	if err := json.Unmarshal(data, &received); err != nil || !reflect.DeepEqual(received, deltas) {
+		t.Fatalf("JSON round trip: %v", err)
+	}
+	for _, d := range received {
+		if err := d.Apply(replica); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if replica.GetText() != gb.GetText() || received[len(received)-1].Revision != gb.Version() {
+		t.Errorf("replica has %q, want %q", replica.GetText(), gb.GetText())
+	}
+
+	if _, err := gb.Changes(gb.Version() + 1); !errors.Is(err, buffer.ErrVersionUnavailable) {
+		t.Errorf("changes since a future version: %v", err)
+	}
+}
+
+// TestDelta checks the steps of a delta and applying one to a text it was
+// not made against
+func TestDelta(t *testing.T) {
+	gb := newBuffer(t, "hello world")
+	since := gb.Version()
+	gb.Replace(6, 11, "there")
+	deltas, _ := gb.Changes(since)
+	want := buffer.Delta{Revision: since + 1, Ops: []buffer.DeltaOp{{Retain: 6}, {Insert: "there"}, {Delete: 5}}}
+	if len(deltas) != 1 || !reflect.DeepEqual(deltas[0], want) {
+		t.Fatalf("got %+v, want %+v", deltas, want)
+	}
+	if data, _ := json.Marshal(want.Ops); string(data) != `[{"retain":6},{"insert":"there"},{"delete":5}]` {
+		t.Errorf("got JSON %s", data)
+	}
+
+	if err := want.Apply(newBuffer(t, "hi")); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("retain past the end: %v", err)
+	}
+	if err := want.Apply(newBuffer(t, "hello wor")); !errors.Is(err, buffer.ErrInvalidRange) {
+		t.Errorf("delete past the end: %v", err)
+	}
+}
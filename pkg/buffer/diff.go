@@ -0,0 +1,369 @@
+package buffer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// diffKind tells whether a line is kept, removed or added
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffDelete
+	diffInsert
+)
+
+// diffLine is one step of a line diff. A and B are the indexes of the line in
+// the old and new sequence; only the one matching the kind is meaningful for
+// deletions and insertions.
+type diffLine struct {
+	kind diffKind
+	a    int
+	b    int
+}
+
+// splitLines splits text into lines that keep their newline
+func splitLines(text string) []string {
+	var lines []string
+	for len(text) > 0 {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			lines = append(lines, text)
+			break
+		}
+		lines = append(lines, text[:i+1])
+		text = text[i+1:]
+	}
+	return lines
+}
+
+// diffLines computes a shortest line diff between a and b using Myers'
+// algorithm, after stripping the common prefix and suffix. Lines are
+// compared as values of T, such as their text or a hash of it.
+func diffLines[T comparable](a, b []T) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var result []diffLine
+	for i := 0; i < prefix; i++ {
+		result = append(result, diffLine{kind: diffEqual, a: i, b: i})
+	}
+	result = append(result, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix, prefix)...)
+	for i := 0; i < suffix; i++ {
+		result = append(result, diffLine{kind: diffEqual, a: len(a) - suffix + i, b: len(b) - suffix + i})
+	}
+	return result
+}
+
+// myers runs the O(ND) diff algorithm; offA and offB are added to the reported indexes
+func myers[T comparable](a, b []T, offA, offB int) []diffLine {
+	n, m := len(a), len(b)
+	maxD := n + m
+	if maxD == 0 {
+		return nil
+	}
+
+	// v[k+maxD+1] is the furthest x reached on diagonal k. trace keeps the
+	// diagonals -d-1..d+1 of v before each step, which is all backtracking needs.
+	v := make([]int, 2*maxD+3)
+	var trace [][]int
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v[maxD-d:maxD+d+3]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k+maxD] < v[k+maxD+2]) {
+				x = v[k+maxD+2]
+			} else {
+				x = v[k+maxD] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+maxD+1] = x
+			if x >= n && y >= m {
+				return myersBacktrack(trace, d, n, m, offA, offB)
+			}
+		}
+	}
+	return nil
+}
+
+// myersBacktrack walks the recorded steps back from (n, m) to build the diff
+func myersBacktrack(trace [][]int, d int, n int, m int, offA int, offB int) []diffLine {
+	var reversed []diffLine
+	x, y := n, m
+	for ; d > 0; d-- {
+		// trace[d][i] holds diagonal i-d-1
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[k+d] < v[k+d+2]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+d+1]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, diffLine{kind: diffEqual, a: x + offA, b: y + offB})
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, diffLine{kind: diffInsert, a: x + offA, b: y + offB})
+		} else {
+			x--
+			reversed = append(reversed, diffLine{kind: diffDelete, a: x + offA, b: y + offB})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		reversed = append(reversed, diffLine{kind: diffEqual, a: x + offA, b: y + offB})
+	}
+
+	result := make([]diffLine, len(reversed))
+	for i, l := range reversed {
+		result[len(reversed)-1-i] = l
+	}
+	return result
+}
+
+// unifiedDiff renders the diff of a and b in unified format with the given
+// number of context lines. It returns an empty string when nothing changed.
+func unifiedDiff(a, b []string, oldName string, newName string, context int) string {
+	lines := diffLines(a, b)
+
+	var sb strings.Builder
+	eachHunk(lines, context, func(start int, end int) {
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		writeHunk(&sb, lines[start:end], a, b)
+	})
+	return sb.String()
+}
+
+// eachHunk calls fn with the bounds of every hunk of lines: a run of
+// changes with up to context equal lines around it, extended while changes
+// are separated by at most 2*context equal lines
+func eachHunk(lines []diffLine, context int, fn func(start int, end int)) {
+	for i := 0; i < len(lines); {
+		if lines[i].kind == diffEqual {
+			i++
+			continue
+		}
+
+		start := max(i-context, 0)
+		end := i
+		for end < len(lines) {
+			if lines[end].kind != diffEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(lines) && lines[run].kind == diffEqual {
+				run++
+			}
+			if run == len(lines) || run-end > 2*context {
+				end = min(end+context, len(lines))
+				break
+			}
+			end = run
+		}
+
+		fn(start, end)
+		i = end
+	}
+}
+
+// writeHunk writes one hunk header and its lines
+func writeHunk(sb *strings.Builder, hunk []diffLine, a, b []string) {
+	oldStart, newStart := hunk[0].a, hunk[0].b
+	oldCount, newCount := 0, 0
+	for _, l := range hunk {
+		if l.kind != diffInsert {
+			oldCount++
+		}
+		if l.kind != diffDelete {
+			newCount++
+		}
+	}
+
+	// Empty ranges point at the line before them, as diff(1) does
+	if oldCount > 0 {
+		oldStart++
+	}
+	if newCount > 0 {
+		newStart++
+	}
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+
+	for _, l := range hunk {
+		var prefix, text string
+		switch l.kind {
+		case diffEqual:
+			prefix, text = " ", a[l.a]
+		case diffDelete:
+			prefix, text = "-", a[l.a]
+		case diffInsert:
+			prefix, text = "+", b[l.b]
+		}
+		sb.WriteString(prefix)
+		sb.WriteString(text)
+		if !strings.HasSuffix(text, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// syncLines is the number of equal lines that end a hunk of streamLineEdits
+const syncLines = 3
+
+// streamLineEdits returns the edits turning the text read from old into the
+// text read from new, one per run of changed lines, with positions offset
+// by base. Both are read line by line in step: equal lines are skipped as
+// they come, and only the lines of a hunk, up to syncLines equal lines on
+// both sides, are held while it is diffed, so neither text is ever held
+// whole unless all of it changed.
+func streamLineEdits(old io.Reader, new io.Reader, base int) ([]Edit, error) {
+	a, b := lineSource{r: bufio.NewReader(old)}, lineSource{r: bufio.NewReader(new)}
+	var qa, qb []string // lines read and not yet matched
+	var edits []Edit
+	pos := base
+	for {
+		// Skip the equal lines
+		for {
+			a.fill(&qa, 1)
+			b.fill(&qb, 1)
+			if len(qa) == 0 || len(qb) == 0 || qa[0] != qb[0] {
+				break
+			}
+			pos += len(qa[0])
+			qa, qb = qa[1:], qb[1:]
+		}
+		if a.err != nil {
+			return nil, a.err
+		}
+		if b.err != nil {
+			return nil, b.err
+		}
+		if len(qa) == 0 && len(qb) == 0 {
+			return edits, nil
+		}
+
+		// Read more lines, twice as many each time, until the hunk ends
+		for n := 2; ; n *= 2 {
+			a.fill(&qa, n)
+			b.fill(&qb, n)
+			i, j, ok := hunkEnd(qa, qb)
+			if !ok && a.done && b.done {
+				i, j, ok = len(qa), len(qb), true
+			}
+			if ok {
+				edits = append(edits, hunkEdits(qa[:i], qb[:j], pos)...)
+				for _, line := range qa[:i] {
+					pos += len(line)
+				}
+				qa, qb = qa[i:], qb[j:]
+				break
+			}
+		}
+	}
+}
+
+// lineSource reads lines, each with its newline, until the end or an error
+type lineSource struct {
+	r    *bufio.Reader
+	done bool
+	err  error
+}
+
+// fill reads lines onto q until it holds n or the source is done
+func (s *lineSource) fill(q *[]string, n int) {
+	for len(*q) < n && !s.done {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			s.done = true
+			if err != io.EOF {
+				s.err = err
+			}
+		}
+		if line != "" {
+			*q = append(*q, line)
+		}
+	}
+}
+
+// hunkEnd finds where a hunk starting with a[0] and b[0] ends: the first
+// lines i of a and j of b, with the least i+j, followed by syncLines equal
+// lines on both sides
+func hunkEnd(a []string, b []string) (i int, j int, ok bool) {
+	at := make(map[string][]int)
+	for k := 0; k+syncLines <= len(a); k++ {
+		at[a[k]] = append(at[a[k]], k)
+	}
+	best := -1
+	for j := 0; j+syncLines <= len(b); j++ {
+		if best >= 0 && j >= best {
+			break
+		}
+		for _, ai := range at[b[j]] {
+			if best >= 0 && ai+j >= best {
+				break
+			}
+			if slices.Equal(a[ai:ai+syncLines], b[j:j+syncLines]) {
+				best, i = ai+j, ai
+				break
+			}
+		}
+	}
+	if best < 0 {
+		return 0, 0, false
+	}
+	return i, best - i, true
+}
+
+// hunkEdits returns the edits turning the lines a into the lines b, one per
+// run of changed lines, with positions offset by base
+func hunkEdits(a []string, b []string, base int) []Edit {
+	var edits []Edit
+	pos := base
+	var current *Edit
+	for _, l := range diffLines(a, b) {
+		switch l.kind {
+		case diffEqual:
+			current = nil
+			pos += len(a[l.a])
+			continue
+		case diffDelete:
+			if current == nil {
+				edits = append(edits, Edit{Start: pos, End: pos})
+				current = &edits[len(edits)-1]
+			}
+			pos += len(a[l.a])
+			current.End = pos
+		case diffInsert:
+			if current == nil {
+				edits = append(edits, Edit{Start: pos, End: pos})
+				current = &edits[len(edits)-1]
+			}
+			current.Text += b[l.b]
+		}
+	}
+	return edits
+}
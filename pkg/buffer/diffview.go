@@ -0,0 +1,149 @@
+package buffer
+
+// DiffRow is one row of a side-by-side diff: a line of the old buffer, a
+// line of the new buffer or a pair of them
+type DiffRow struct {
+	OldLine    int     // Zero-based line in the old buffer, -1 if the row only has a new line
+	NewLine    int     // Zero-based line in the new buffer, -1 if the row only has an old line
+	Equal      bool    // Both lines are there and identical
+	OldChanged []Range // Changed parts of the old line, as positions in the old buffer
+	NewChanged []Range // Changed parts of the new line, as positions in the new buffer
+}
+
+// DiffHunk is a run of changed rows with up to context equal rows around it
+type DiffHunk struct {
+	OldStart int // First line of the hunk in the old buffer
+	OldLines int // Number of old lines in the hunk
+	NewStart int // First line of the hunk in the new buffer
+	NewLines int // Number of new lines in the hunk
+	Rows     []DiffRow
+}
+
+// DiffBuffers compares the lines of a and b and returns the hunks of their
+// differences, with context equal lines around them, for side-by-side
+// viewers. Removed lines are paired in order with the lines added in their
+// place, and paired lines are compared word by word. Lines are compared by
+// hashes computed while streaming the chunks of both buffers, so neither
+// text is flattened.
+func DiffBuffers(a, b *GapBuffer, context int) []DiffHunk {
+	lines := diffLines(a.lineKeys(), b.lineKeys())
+
+	var hunks []DiffHunk
+	eachHunk(lines, max(context, 0), func(start int, end int) {
+		h := DiffHunk{OldStart: lines[start].a, NewStart: lines[start].b}
+		for i := start; i < end; {
+			if l := lines[i]; l.kind == diffEqual {
+				h.Rows = append(h.Rows, DiffRow{OldLine: l.a, NewLine: l.b, Equal: true})
+				i++
+				continue
+			}
+
+			var removed, added []int
+			for ; i < end && lines[i].kind != diffEqual; i++ {
+				if lines[i].kind == diffDelete {
+					removed = append(removed, lines[i].a)
+				} else {
+					added = append(added, lines[i].b)
+				}
+			}
+			for j := 0; j < max(len(removed), len(added)); j++ {
+				row := DiffRow{OldLine: -1, NewLine: -1}
+				var oldRange, newRange Range
+				if j < len(removed) {
+					row.OldLine = removed[j]
+					oldRange, _ = a.lineRange(row.OldLine)
+				}
+				if j < len(added) {
+					row.NewLine = added[j]
+					newRange, _ = b.lineRange(row.NewLine)
+				}
+				switch {
+				case row.OldLine >= 0 && row.NewLine >= 0:
+					row.OldChanged, row.NewChanged = wordChanges(a, oldRange, b, newRange)
+				case row.OldLine >= 0 && !oldRange.Empty():
+					row.OldChanged = []Range{oldRange}
+				case row.NewLine >= 0 && !newRange.Empty():
+					row.NewChanged = []Range{newRange}
+				}
+				h.Rows = append(h.Rows, row)
+			}
+		}
+
+		for _, row := range h.Rows {
+			if row.OldLine >= 0 {
+				h.OldLines++
+			}
+			if row.NewLine >= 0 {
+				h.NewLines++
+			}
+		}
+		hunks = append(hunks, h)
+	})
+	return hunks
+}
+
+// wordChanges diffs the words of the line ra of a against those of the
+// line rb of b and returns the ranges of the differing words on each side
+func wordChanges(a *GapBuffer, ra Range, b *GapBuffer, rb Range) (oldChanged []Range, newChanged []Range) {
+	oldText, newText := a.rawText(ra.Start, ra.End), b.rawText(rb.Start, rb.End)
+	oldBounds, newBounds := wordBreaks(oldText), wordBreaks(newText)
+	oldWords, newWords := segments(oldText, oldBounds), segments(newText, newBounds)
+
+	for _, l := range diffLines(oldWords, newWords) {
+		switch l.kind {
+		case diffDelete:
+			oldChanged = appendRange(oldChanged, Range{Start: ra.Start + oldBounds[l.a], End: ra.Start + oldBounds[l.a+1]})
+		case diffInsert:
+			newChanged = appendRange(newChanged, Range{Start: rb.Start + newBounds[l.b], End: rb.Start + newBounds[l.b+1]})
+		}
+	}
+	return oldChanged, newChanged
+}
+
+// segments cuts text at bounds, which include 0 and len(text)
+func segments(text string, bounds []int) []string {
+	var parts []string
+	for i := 1; i < len(bounds); i++ {
+		parts = append(parts, text[bounds[i-1]:bounds[i]])
+	}
+	return parts
+}
+
+// appendRange appends r to ranges, extending the last one if r continues it
+func appendRange(ranges []Range, r Range) []Range {
+	if n := len(ranges); n > 0 && ranges[n-1].End == r.Start {
+		ranges[n-1].End = r.End
+		return ranges
+	}
+	return append(ranges, r)
+}
+
+// lineKey identifies the text of a line, newline included, for diffing
+type lineKey struct {
+	hash uint64 // FNV-1a
+	size int
+}
+
+// FNV-1a parameters
+const (
+	fnvOffset uint64 = 14695981039346656037
+	fnvPrime  uint64 = 1099511628211
+)
+
+// lineKeys returns the key of every line, streaming the chunks
+func (gb *GapBuffer) lineKeys() []lineKey {
+	keys := make([]lineKey, 0, gb.lineCount())
+	hash, size := fnvOffset, 0
+	gb.forEachChunk(0, gb.length, func(_ int, text string) bool {
+		for i := 0; i < len(text); i++ {
+			hash = (hash ^ uint64(text[i])) * fnvPrime
+			size++
+			if text[i] == '\n' {
+				keys = append(keys, lineKey{hash: hash, size: size})
+				hash, size = fnvOffset, 0
+			}
+		}
+		return true
+	})
+	return append(keys, lineKey{hash: hash, size: size})
+}
@@ -0,0 +1,63 @@
+package buffer_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestDiffBuffers checks hunks, row pairing and word changes of a
+// side-by-side diff
+func TestDiffBuffers(t *testing.T) {
+	a := newBuffer(t, "one\ntwo\nthe old cat\nfour\nfive\nsix\nseven\n")
+	b := newBuffer(t, "one\ntwo\nthe new cat\nfour\nfive\nsix\nseven\neight\n")
+	b.RandomizeLayout(3)
+
+	want := []buffer.DiffHunk{
+		{OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 3, Rows: []buffer.DiffRow{
+			{OldLine: 1, NewLine: 1, Equal: true},
+			{OldLine: 2, NewLine: 2, OldChanged: []buffer.Range{{Start: 12, End: 15}}, NewChanged: []buffer.Range{{Start: 12, End: 15}}},
+			{OldLine: 3, NewLine: 3, Equal: true},
+		}},
+		{OldStart: 6, OldLines: 2, NewStart: 6, NewLines: 3, Rows: []buffer.DiffRow{
+			{OldLine: 6, NewLine: 6, Equal: true},
+			{OldLine: -1, NewLine: 7, NewChanged: []buffer.Range{{Start: 40, End: 45}}},
+			{OldLine: 7, NewLine: 8, Equal: true},
+		}},
+	}
+	if got := buffer.DiffBuffers(a, b, 1); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+	if got := buffer.DiffBuffers(a, a, 3); len(got) != 0 {
+		t.Errorf("identical buffers: got %d hunks", len(got))
+	}
+}
+
+// TestDiffBuffersPairing checks that removed lines pair in order with the
+// lines added in their place and the rest stand alone
+func TestDiffBuffersPairing(t *testing.T) {
+	a := newBuffer(t, "x\na\nb\nc\ny")
+	b := newBuffer(t, "x\nA\nB\ny")
+
+	hunks := buffer.DiffBuffers(a, b, 0)
+	if len(hunks) != 1 {
+		t.Fatalf("got %d hunks", len(hunks))
+	}
+	var pairs [][2]int
+	for _, row := range hunks[0].Rows {
+		pairs = append(pairs, [2]int{row.OldLine, row.NewLine})
+		if row.Equal {
+			t.Errorf("equal row %+v without context", row)
+		}
+	}
+	if want := [][2]int{{1, 1}, {2, 2}, {3, -1}}; !reflect.DeepEqual(pairs, want) {
+		t.Errorf("got rows %v, want %v", pairs, want)
+	}
+	if h := hunks[0]; h.OldLines != 3 || h.NewLines != 2 {
+		t.Errorf("got %d old and %d new lines", h.OldLines, h.NewLines)
+	}
+	if row := hunks[0].Rows[2]; !reflect.DeepEqual(row.OldChanged, []buffer.Range{{Start: 6, End: 7}}) {
+		t.Errorf("removed line changed %v", row.OldChanged)
+	}
+}
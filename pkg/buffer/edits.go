@@ -0,0 +1,204 @@
+package buffer
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrOverlappingEdits is returned when an edit set contains overlapping edits
+var ErrOverlappingEdits = errors.New("overlapping edits")
+
+// Edit replaces the text in [Start, End) with Text. An insertion has
+// Start == End, a deletion has an empty Text.
+type Edit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// validateEdits checks that edits are in range, sorted and do not overlap
+func validateEdits(edits []Edit, length int) error {
+	prevEnd := 0
+	for i, e := range edits {
+		if e.Start < 0 || e.End > length || e.Start > e.End {
+			return ErrInvalidRange
+		}
+		if i > 0 && e.Start < prevEnd {
+			return ErrOverlappingEdits
+		}
+		prevEnd = e.End
+	}
+	return nil
+}
+
+// rebaseEdit maps an edit through an operation applied after it was made.
+// It reports false if the operation touched the edited range.
+func rebaseEdit(e Edit, op Operation) (Edit, bool) {
+	switch {
+	case op.Pos+op.Deleted <= e.Start:
+		// Operation is before the edit
+		delta := op.Inserted - op.Deleted
+		e.Start += delta
+		e.End += delta
+		return e, true
+	case op.Pos >= e.End:
+		// Operation is after the edit
+		return e, true
+	default:
+		return e, false
+	}
+}
+
+// OverlapPolicy decides what ApplyEdits does with overlapping edits
+type OverlapPolicy int
+
+const (
+	OverlapError    OverlapPolicy = iota // Reject the whole edit set with ErrOverlappingEdits
+	OverlapMerge                         // Merge overlapping edits into one covering their union
+	OverlapLastWins                      // Drop earlier edits overlapped by a later one
+)
+
+// AppliedEdit is an edit of a normalized edit set together with the range
+// its text occupies in the buffer after the whole set was applied
+type AppliedEdit struct {
+	Edit
+	FinalStart int
+	FinalEnd   int
+}
+
+// overlaps reports whether two edits touch the same original text. Edits
+// that only meet at a boundary, such as two insertions at one position,
+// do not overlap.
+func overlaps(a, b Edit) bool {
+	if a.Start == a.End && b.Start == b.End {
+		return false
+	}
+	if a.Start == a.End {
+		return b.Start < a.Start && a.Start < b.End
+	}
+	if b.Start == b.End {
+		return a.Start < b.Start && b.Start < a.End
+	}
+	return a.Start < b.End && b.Start < a.End
+}
+
+// normalizeEdits sorts edits by position and resolves overlaps according to policy.
+// At the same position insertions come before the edits of a range, whose
+// text they precede; otherwise edits at the same position keep their input
+// order.
+func normalizeEdits(edits []Edit, policy OverlapPolicy) ([]Edit, error) {
+	var result []Edit
+	if policy == OverlapLastWins {
+		// Later edits evict the earlier ones they overlap
+		for _, e := range edits {
+			kept := result[:0]
+			for _, prev := range result {
+				if !overlaps(prev, e) {
+					kept = append(kept, prev)
+				}
+			}
+			result = append(kept, e)
+		}
+	} else {
+		result = append(result, edits...)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Start != b.Start {
+			return a.Start < b.Start
+		}
+		return a.Start == a.End && b.Start < b.End
+	})
+
+	merged := result[:0]
+	for _, e := range result {
+		n := len(merged)
+		if n == 0 || !overlaps(merged[n-1], e) {
+			merged = append(merged, e)
+			continue
+		}
+		if policy != OverlapMerge {
+			return nil, ErrOverlappingEdits
+		}
+
+		// Replace the union of both ranges with both texts in position order
+		prev := &merged[n-1]
+		if e.End > prev.End {
+			prev.End = e.End
+		}
+		prev.Text += e.Text
+	}
+	return merged, nil
+}
+
+// ApplyEdits applies a set of edits expressed in the coordinates of the
+// current text, in any order, shifting offsets as earlier edits change the
+// text. Overlapping edits are handled according to policy. It returns the
+// normalized edit set that was applied, sorted by position, with the final
+// range of every inserted text. The set is undone as one step.
+func (gb *GapBuffer) ApplyEdits(edits []Edit, policy OverlapPolicy) (applied []AppliedEdit, err error) {
+	defer guard("ApplyEdits", noPos, gb.length, &err)
+
+	if gb.views > 0 {
+		return nil, ErrViewActive
+	}
+	for _, e := range edits {
+		if e.Start < 0 || e.End > gb.length || e.Start > e.End {
+			return nil, ErrInvalidRange
+		}
+	}
+
+	normalized, err := normalizeEdits(edits, policy)
+	if err != nil {
+		return nil, err
+	}
+	for i := range normalized {
+		normalized[i].Text = normalizeString(gb.inputNorm, normalized[i].Text)
+	}
+	// Check the whole set up front so that it is applied entirely or not at all
+	if err := gb.checkLimits(normalized); err != nil {
+		return nil, err
+	}
+	if !gb.suggesting {
+		if err := gb.checkHooks(normalized); err != nil {
+			return nil, err
+		}
+	}
+	limits, quota, hooks, inputNorm := gb.limits, gb.quota, gb.changeHooks, gb.inputNorm
+	if !gb.suggesting {
+		delta := 0
+		for _, e := range normalized {
+			delta += len(e.Text) - (e.End - e.Start)
+		}
+		if err := gb.chargeQuota(delta); err != nil {
+			return nil, err
+		}
+	}
+	// Widening an edit to the runes it combines with could reach into the
+	// range of the edit before it, so each text was normalized on its own
+	gb.limits, gb.quota, gb.changeHooks, gb.inputNorm = Limits{}, nil, nil, NormNone
+	defer func() { gb.limits, gb.quota, gb.changeHooks, gb.inputNorm = limits, quota, hooks, inputNorm }()
+	gb.BeginGroup()
+	defer gb.EndGroup()
+
+	// Apply from the end so that earlier offsets stay valid
+	for i := len(normalized) - 1; i >= 0; i-- {
+		e := normalized[i]
+		if e.Start == e.End && e.Text == "" {
+			continue
+		}
+		if err := gb.replace(e.Start, e.End, e.Text); err != nil {
+			return nil, err
+		}
+	}
+
+	applied = make([]AppliedEdit, len(normalized))
+	delta := 0
+	for i, e := range normalized {
+		start := e.Start + delta
+		applied[i] = AppliedEdit{Edit: e, FinalStart: start, FinalEnd: start + len(e.Text)}
+		delta += len(e.Text) - (e.End - e.Start)
+	}
+	return applied, nil
+}
@@ -0,0 +1,100 @@
+package buffer_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestApplyEdits checks each overlap policy on the same edit sets, the
+// final ranges reported, and that the set is undone as one step
+func TestApplyEdits(t *testing.T) {
+	const text = "0123456789"
+	tests := []struct {
+		name   string
+		edits  []buffer.Edit
+		policy buffer.OverlapPolicy
+		want   string
+		err    error
+	}{
+		{"disjoint", []buffer.Edit{{Start: 8, End: 9, Text: "E"}, {Start: 1, End: 3, Text: "ab"}}, buffer.OverlapError, "0ab34567E9", nil},
+		{"inserts at one position", []buffer.Edit{{Start: 5, End: 5, Text: "x"}, {Start: 5, End: 5, Text: "y"}}, buffer.OverlapError, "01234xy56789", nil},
+		{"insert at a deletion's end", []buffer.Edit{{Start: 2, End: 5}, {Start: 5, End: 5, Text: "x"}}, buffer.OverlapError, "01x56789", nil},
+		{"insert at a deletion's start", []buffer.Edit{{Start: 5, End: 8}, {Start: 5, End: 5, Text: "x"}}, buffer.OverlapError, "01234x89", nil},
+		{"insert at a replacement's start", []buffer.Edit{{Start: 5, End: 8, Text: "R"}, {Start: 5, End: 5, Text: "x"}}, buffer.OverlapMerge, "01234xR89", nil},
+		{"overlap rejected", []buffer.Edit{{Start: 1, End: 4, Text: "a"}, {Start: 3, End: 6, Text: "b"}}, buffer.OverlapError, text, buffer.ErrOverlappingEdits},
+		{"insert inside rejected", []buffer.Edit{{Start: 1, End: 4}, {Start: 2, End: 2, Text: "x"}}, buffer.OverlapError, text, buffer.ErrOverlappingEdits},
+		{"overlap merged", []buffer.Edit{{Start: 3, End: 6, Text: "b"}, {Start: 1, End: 4, Text: "a"}}, buffer.OverlapMerge, "0ab6789", nil},
+		{"chain merged", []buffer.Edit{{Start: 1, End: 3, Text: "a"}, {Start: 2, End: 5, Text: "b"}, {Start: 4, End: 7, Text: "c"}}, buffer.OverlapMerge, "0abc789", nil},
+		{"last wins", []buffer.Edit{{Start: 1, End: 4, Text: "a"}, {Start: 8, End: 9, Text: "E"}, {Start: 3, End: 6, Text: "b"}}, buffer.OverlapLastWins, "012b67E9", nil},
+		{"out of range", []buffer.Edit{{Start: 8, End: 11}}, buffer.OverlapMerge, text, buffer.ErrInvalidRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gb := newBuffer(t, text)
+			applied, err := gb.ApplyEdits(tt.edits, tt.policy)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if gb.GetText() != tt.want {
+				t.Fatalf("got %q, want %q", gb.GetText(), tt.want)
+			}
+			if err != nil {
+				return
+			}
+			for _, a := range applied {
+				if got, _ := gb.GetTextRange(a.FinalStart, a.FinalEnd); got != a.Text {
+					t.Errorf("final range of %+v holds %q", a, got)
+				}
+			}
+			if err := gb.Undo(); err != nil || gb.GetText() != text {
+				t.Errorf("undo: got %q, %v", gb.GetText(), err)
+			}
+		})
+	}
+}
+
+// TestApplyEditsFinalRanges checks the normalized set returned for edits
+// given out of order
+func TestApplyEditsFinalRanges(t *testing.T) {
+	gb := newBuffer(t, "aaa bbb ccc")
+	applied, err := gb.ApplyEdits([]buffer.Edit{
+		{Start: 8, End: 11, Text: "C"},
+		{Start: 0, End: 3, Text: "AAAA"},
+	}, buffer.OverlapError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []buffer.AppliedEdit{
+		{Edit: buffer.Edit{Start: 0, End: 3, Text: "AAAA"}, FinalStart: 0, FinalEnd: 4},
+		{Edit: buffer.Edit{Start: 8, End: 11, Text: "C"}, FinalStart: 9, FinalEnd: 10},
+	}
+	if !reflect.DeepEqual(applied, want) {
+		t.Errorf("got %+v, want %+v", applied, want)
+	}
+}
+
+// TestApplyEditsInsertAtRangeStart checks that an insertion at the start of
+// a deleted range lands before it rather than being deleted with it
+func TestApplyEditsInsertAtRangeStart(t *testing.T) {
+	gb := newBuffer(t, "0123456789")
+	applied, err := gb.ApplyEdits([]buffer.Edit{
+		{Start: 5, End: 8},
+		{Start: 5, End: 5, Text: "x"},
+	}, buffer.OverlapError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := gb.GetText(); got != "01234x89" {
+		t.Errorf("got %q, want %q", got, "01234x89")
+	}
+	want := []buffer.AppliedEdit{
+		{Edit: buffer.Edit{Start: 5, End: 5, Text: "x"}, FinalStart: 5, FinalEnd: 6},
+		{Edit: buffer.Edit{Start: 5, End: 8}, FinalStart: 6, FinalEnd: 6},
+	}
+	if !reflect.DeepEqual(applied, want) {
+		t.Errorf("got %+v, want %+v", applied, want)
+	}
+}
@@ -0,0 +1,60 @@
+package buffer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestDeleteAtEnd checks that every backend refuses deletions starting at
+// the end of the buffer and allows empty ones there
+func TestDeleteAtEnd(t *testing.T) {
+	backends := map[string]buffer.Buffer{
+		"GapBuffer":  newBuffer(t, "text\n"),
+		"FlatBuffer": buffer.NewFlatBufferFromString("text\n"),
+		"Rope":       buffer.NewRopeFromString("text\n"),
+		"PieceTable": buffer.NewPieceTableFromString("text\n"),
+	}
+	for name, b := range backends {
+		if err := b.DeleteAt(5, 1); !errors.Is(err, buffer.ErrDeleteAtEnd) {
+			t.Errorf("%s: DeleteAt at the end: got %v", name, err)
+		}
+		if err := b.DeleteAt(5, 0); err != nil {
+			t.Errorf("%s: empty DeleteAt at the end: got %v", name, err)
+		}
+		if err := b.DeleteAt(6, 0); !errors.Is(err, buffer.ErrInvalidRange) {
+			t.Errorf("%s: DeleteAt past the end: got %v", name, err)
+		}
+		if err := b.InsertAt(5, "!"); err != nil || b.GetText() != "text\n!" {
+			t.Errorf("%s: InsertAt at the end: got %q, %v", name, b.GetText(), err)
+		}
+	}
+}
+
+// TestEndPosition checks the end of the buffer as a position of the rune,
+// grapheme, line and marker APIs
+func TestEndPosition(t *testing.T) {
+	gb := newBuffer(t, "é\n")
+	if err := gb.DeleteRuneAt(2, 1); !errors.Is(err, buffer.ErrDeleteAtEnd) {
+		t.Errorf("DeleteRuneAt at the end: got %v", err)
+	}
+	if err := gb.DeleteRuneAt(3, 1); !errors.Is(err, buffer.ErrRunePositionOutOfRange) {
+		t.Errorf("DeleteRuneAt past the end: got %v", err)
+	}
+	if err := gb.DeleteGraphemeAt(2, 1); !errors.Is(err, buffer.ErrDeleteAtEnd) {
+		t.Errorf("DeleteGraphemeAt at the end: got %v", err)
+	}
+
+	if line, col, err := gb.OffsetToLineCol(gb.Length()); err != nil || line != 1 || col != 0 {
+		t.Errorf("end position at line %d, column %d, %v", line, col, err)
+	}
+	m, err := gb.CreateMarker(gb.Length(), buffer.GravityRight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gb.InsertRuneAt(2, "x")
+	if m.Pos() != gb.Length() {
+		t.Errorf("marker at the end moved to %d of %d", m.Pos(), gb.Length())
+	}
+}
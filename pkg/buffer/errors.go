@@ -0,0 +1,87 @@
+package buffer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Errors returned by the public API, wrapped in an *OpError. Callers can
+// match them with errors.Is.
+var (
+	ErrPositionOutOfRange     = errors.New("position out of range")
+	ErrInvalidRange           = errors.New("invalid range")
+	ErrRunePositionOutOfRange = errors.New("rune position out of range")
+	ErrInvalidRuneRange       = errors.New("invalid rune range")
+	ErrInvalidRuneCount       = errors.New("rune count must be positive")
+	ErrLineOutOfRange         = errors.New("line out of range")
+	ErrDeleteAtEnd            = errors.New("nothing to delete at the end of the buffer")
+	ErrInternal               = errors.New("internal error")
+)
+
+// InternalError reports a panic that was recovered inside a public call.
+// It always wraps ErrInternal; the buffer should be considered suspect afterwards.
+type InternalError struct {
+	Op    string      // Public method that panicked
+	Panic interface{} // Value passed to panic
+}
+
+func (e *InternalError) Error() string {
+	return fmt.Sprintf("internal error in %s: %v", e.Op, e.Panic)
+}
+
+func (e *InternalError) Unwrap() error {
+	return ErrInternal
+}
+
+// noPos is the position of an OpError for a call that takes none, and its
+// length for a call made before there was a buffer
+const noPos = -1
+
+// OpError is the error returned by a failed public call. It wraps the cause,
+// usually one of the sentinels above, so errors.Is and errors.As see
+// through it, and adds the context worth logging: the operation, the
+// position it was called with and the length of the buffer at the call.
+// When public calls nest, the error carries the context of the innermost.
+type OpError struct {
+	Op     string // Public method that failed
+	Pos    int    // Position or line passed to the call, in its unit; -1 if it takes none
+	Length int    // Length of the buffer in bytes at the call; -1 if there was none yet
+	Err    error  // Underlying error
+}
+
+func (e *OpError) Error() string {
+	s := e.Op
+	if e.Pos != noPos {
+		s += fmt.Sprintf(" at %d", e.Pos)
+	}
+	if e.Length != noPos {
+		s += fmt.Sprintf(" (length %d)", e.Length)
+	}
+	return s + ": " + e.Err.Error()
+}
+
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// guard converts a panic in a public call into an *InternalError and wraps
+// the error the call returns in an *OpError. It must be deferred directly
+// by the public method, with the length evaluated at the call.
+func guard(op string, pos int, length int, err *error) {
+	if r := recover(); r != nil {
+		*err = &InternalError{Op: op, Panic: r}
+	}
+	*err = wrapOp(op, pos, length, *err)
+}
+
+// wrapOp wraps err in an *OpError. io.EOF, which readers must return as
+// is, and errors that already carry the context of a nested call are left
+// alone.
+func wrapOp(op string, pos int, length int, err error) error {
+	var opErr *OpError
+	if err == nil || err == io.EOF || errors.As(err, &opErr) {
+		return err
+	}
+	return &OpError{Op: op, Pos: pos, Length: length, Err: err}
+}
@@ -0,0 +1,67 @@
+package buffer_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestOpError checks the context failed calls add to their errors
+func TestOpError(t *testing.T) {
+	gb := newBuffer(t, "one\ntwo")
+	tests := []struct {
+		op   string
+		call func() error
+		pos  int
+		want error
+	}{
+		{"InsertAt", func() error { return gb.InsertAt(8, "x") }, 8, buffer.ErrPositionOutOfRange},
+		{"DeleteAt", func() error { return gb.DeleteAt(7, 1) }, 7, buffer.ErrDeleteAtEnd},
+		{"Replace", func() error { return gb.Replace(5, 3, "x") }, 5, buffer.ErrInvalidRange},
+		{"LineRange", func() error { _, err := gb.LineRange(2); return err }, 2, buffer.ErrLineOutOfRange},
+		{"Redo", gb.Redo, -1, buffer.ErrNothingToRedo},
+	}
+	for _, tt := range tests {
+		err := tt.call()
+		var opErr *buffer.OpError
+		if !errors.As(err, &opErr) {
+			t.Errorf("%s: got %T %v", tt.op, err, err)
+			continue
+		}
+		want := buffer.OpError{Op: tt.op, Pos: tt.pos, Length: 7, Err: tt.want}
+		if *opErr != want {
+			t.Errorf("got %+v, want %+v", *opErr, want)
+		}
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: errors.Is does not see %v", tt.op, tt.want)
+		}
+	}
+
+	err := &buffer.OpError{Op: "DeleteAt", Pos: 7, Length: 7, Err: buffer.ErrDeleteAtEnd}
+	if got := err.Error(); got != "DeleteAt at 7 (length 7): nothing to delete at the end of the buffer" {
+		t.Errorf("got %q", got)
+	}
+	err = &buffer.OpError{Op: "NewFromFile", Pos: -1, Length: -1, Err: io.ErrUnexpectedEOF}
+	if got := err.Error(); got != "NewFromFile: unexpected EOF" {
+		t.Errorf("got %q", got)
+	}
+}
+
+// TestOpErrorPassThrough checks that io.EOF and errors already carrying
+// context are returned as they are
+func TestOpErrorPassThrough(t *testing.T) {
+	gb := newBuffer(t, "ab")
+	r := gb.Reader()
+	io.ReadAll(r)
+	if _, err := r.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("reader at the end: got %v", err)
+	}
+
+	err := gb.Transaction(func() error { return gb.InsertAt(9, "x") })
+	var opErr *buffer.OpError
+	if !errors.As(err, &opErr) || opErr.Op != "InsertAt" || opErr.Pos != 9 {
+		t.Errorf("nested call: got %v", err)
+	}
+}
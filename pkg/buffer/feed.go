@@ -0,0 +1,191 @@
+package buffer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// DEFAULT_FEED_PENDING is the default number of undelivered operations a change feed holds
+const DEFAULT_FEED_PENDING = 1024
+
+// ErrFeedClosed is returned by a change feed after Close
+var ErrFeedClosed = errors.New("change feed closed")
+
+// FeedBatch is what a change feed delivers: the operations applied since the
+// previous batch, or a resync request when the consumer fell too far behind
+type FeedBatch struct {
+	Ops     []Operation // Coalesced operations, oldest first
+	Resync  bool        // Operations were dropped, reload the full text
+	Version int         // Buffer version after the last operation
+}
+
+// ChangeFeed mirrors buffer edits to a consumer on another goroutine. Edits
+// never block on the consumer: operations are queued and coalesced (runs of
+// typing or backspacing become one operation) and when the queue is full
+// the feed drops it and asks the consumer to resync.
+type ChangeFeed struct {
+	gb         *GapBuffer
+	maxPending int
+
+	mu      sync.Mutex
+	pending []Operation
+	resync  bool
+	version int
+	closed  bool
+	notify  chan struct{} // signalled when something is pending
+}
+
+// NewChangeFeed creates a feed of the buffer's edits holding at most
+// maxPending undelivered operations. maxPending <= 0 uses DEFAULT_FEED_PENDING.
+func (gb *GapBuffer) NewChangeFeed(maxPending int) *ChangeFeed {
+	if maxPending <= 0 {
+		maxPending = DEFAULT_FEED_PENDING
+	}
+	f := &ChangeFeed{
+		gb:         gb,
+		maxPending: maxPending,
+		version:    gb.version,
+		notify:     make(chan struct{}, 1),
+	}
+	gb.feeds = append(gb.feeds, f)
+	return f
+}
+
+// publishFeeds hands op to every open change feed
+func (gb *GapBuffer) publishFeeds(op Operation) {
+	for _, f := range gb.feeds {
+		f.publish(op)
+	}
+}
+
+// publish queues op, coalescing it with the previous one when possible
+func (f *ChangeFeed) publish(op Operation) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return
+	}
+	f.version = op.Version
+	if !f.resync {
+		if n := len(f.pending); n > 0 && coalesce(&f.pending[n-1], op) {
+			// Merged into the previous operation
+		} else if n >= f.maxPending {
+			f.pending = nil
+			f.resync = true
+		} else {
+			f.pending = append(f.pending, op)
+		}
+	}
+
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+}
+
+// coalesce merges next into prev when next continues it: typing right after
+// the text prev inserted, or deleting right before the text prev deleted.
+// Operations by different actors or carrying metadata are never merged, so
+// none of it is lost.
+func coalesce(prev *Operation, next Operation) bool {
+	switch {
+	case prev.Actor != next.Actor || len(prev.Metadata) > 0 || len(next.Metadata) > 0:
+		return false
+	case next.Deleted == 0 && next.Pos == prev.Pos+prev.Inserted:
+		prev.InsertedText += next.InsertedText
+		prev.Inserted += next.Inserted
+	case prev.Inserted == 0 && next.Inserted == 0 && next.Pos+next.Deleted == prev.Pos:
+		prev.Pos = next.Pos
+		prev.DeletedText = next.DeletedText + prev.DeletedText
+		prev.Deleted += next.Deleted
+	default:
+		return false
+	}
+	prev.Version = next.Version
+	prev.Time = next.Time
+	return true
+}
+
+// Next blocks until operations are pending and returns them as one batch
+func (f *ChangeFeed) Next(ctx context.Context) (FeedBatch, error) {
+	for {
+		f.mu.Lock()
+		if f.closed {
+			f.mu.Unlock()
+			return FeedBatch{}, ErrFeedClosed
+		}
+		if len(f.pending) > 0 || f.resync {
+			batch := FeedBatch{Ops: f.pending, Resync: f.resync, Version: f.version}
+			f.pending = nil
+			f.resync = false
+			f.mu.Unlock()
+			return batch, nil
+		}
+		f.mu.Unlock()
+
+		select {
+		case <-f.notify:
+		case <-ctx.Done():
+			return FeedBatch{}, ctx.Err()
+		}
+	}
+}
+
+// Close detaches the feed from the buffer and wakes up a blocked Next.
+// It must be called from the goroutine that edits the buffer.
+func (f *ChangeFeed) Close() {
+	for i, feed := range f.gb.feeds {
+		if feed == f {
+			f.gb.feeds = append(f.gb.feeds[:i], f.gb.feeds[i+1:]...)
+			break
+		}
+	}
+
+	f.mu.Lock()
+	f.closed = true
+	f.pending = nil
+	f.mu.Unlock()
+
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+}
+
+// ServeHTTP streams the feed as server-sent events: a "change" event with the
+// JSON form of each operation, or a "resync" event carrying the version when
+// operations were dropped. The stream ends when the request or the feed ends.
+func (f *ChangeFeed) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+
+	for {
+		batch, err := f.Next(req.Context())
+		if err != nil {
+			return
+		}
+
+		if batch.Resync {
+			fmt.Fprintf(w, "event: resync\ndata: {\"version\":%d}\n\n", batch.Version)
+		}
+		for _, op := range batch.Ops {
+			data, err := json.Marshal(newOperationRecord(op))
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "event: change\nid: %d\ndata: %s\n\n", op.Version, data)
+		}
+		flusher.Flush()
+	}
+}
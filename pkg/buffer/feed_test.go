@@ -0,0 +1,97 @@
+package buffer_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestChangeFeed checks that typing and backspacing are coalesced unless
+// actors differ, that an overflowing feed asks for a resync, and Close
+func TestChangeFeed(t *testing.T) {
+	gb := buffer.New()
+	f := gb.NewChangeFeed(3)
+	for i, c := range "hello" {
+		gb.InsertAt(i, string(c))
+	}
+	gb.DeleteAt(4, 1)
+	gb.DeleteAt(3, 1)
+	gb.WithActor("bob").InsertAt(3, "p")
+
+	batch, err := f.Next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Ops) != 3 || batch.Resync || batch.Version != 8 {
+		t.Fatalf("got %+v", batch)
+	}
+	if op := batch.Ops[0]; op.InsertedText != "hello" || op.Version != 5 {
+		t.Errorf("typing: got %+v", op)
+	}
+	if op := batch.Ops[1]; op.Pos != 3 || op.DeletedText != "lo" {
+		t.Errorf("backspacing: got %+v", op)
+	}
+	if op := batch.Ops[2]; op.Actor != "bob" || op.InsertedText != "p" {
+		t.Errorf("other actor: got %+v", op)
+	}
+
+	for i := 0; i < 4; i++ {
+		gb.InsertAt(0, "x")
+	}
+	batch, err = f.Next(context.Background())
+	if err != nil || !batch.Resync || len(batch.Ops) != 0 || batch.Version != gb.Version() {
+		t.Fatalf("overflow: got %+v, %v", batch, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := f.Next(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("nothing pending: got %v, want DeadlineExceeded", err)
+	}
+	f.Close()
+	gb.InsertAt(0, "x")
+	if _, err := f.Next(context.Background()); !errors.Is(err, buffer.ErrFeedClosed) {
+		t.Errorf("closed: got %v, want ErrFeedClosed", err)
+	}
+}
+
+// TestChangeFeedServeHTTP reads the server-sent events of a feed as they
+// are streamed
+func TestChangeFeedServeHTTP(t *testing.T) {
+	gb := buffer.New()
+	f := gb.NewChangeFeed(0)
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type %q", ct)
+	}
+
+	gb.InsertAt(0, "hi")
+	sc := bufio.NewScanner(res.Body)
+	var lines []string
+	for sc.Scan() && sc.Text() != "" {
+		lines = append(lines, sc.Text())
+	}
+	if len(lines) != 3 || lines[0] != "event: change" || lines[1] != "id: 1" ||
+		!strings.Contains(lines[2], `"inserted_text":"hi"`) {
+		t.Fatalf("got %q", lines)
+	}
+
+	f.Close()
+	if sc.Scan() {
+		t.Errorf("stream goes on after Close: %q", sc.Text())
+	}
+}
@@ -0,0 +1,19 @@
+package buffer
+
+// SetFinalNewline makes the buffer present a trailing newline to writers and
+// to LineCount, LineRange and LineColToOffset when its content is not empty
+// and does not already end with one. The newline is never stored: Length,
+// GetText and the edit APIs are unaffected.
+func (gb *GapBuffer) SetFinalNewline(on bool) {
+	gb.finalNewline = on
+}
+
+// FinalNewline reports whether a trailing newline is synthesized
+func (gb *GapBuffer) FinalNewline() bool {
+	return gb.finalNewline
+}
+
+// hasVirtualNewline reports whether a newline is currently synthesized after the content
+func (gb *GapBuffer) hasVirtualNewline() bool {
+	return gb.finalNewline && gb.length > 0 && gb.rawText(gb.length-1, gb.length) != "\n"
+}
@@ -0,0 +1,69 @@
+package buffer_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestFinalNewline checks that the synthesized newline is seen by writers
+// and line math but never stored
+func TestFinalNewline(t *testing.T) {
+	tests := []struct {
+		text    string
+		written string
+		lines   int
+	}{
+		{"", "", 1},
+		{"a\nb", "a\nb\n", 3},
+		{"a\nb\n", "a\nb\n", 3},
+		{"a\r\nb", "a\r\nb\n", 3},
+	}
+	for _, tt := range tests {
+		gb := newBuffer(t, tt.text)
+		gb.SetFinalNewline(true)
+
+		var sb strings.Builder
+		if _, err := gb.WriteTo(&sb); err != nil || sb.String() != tt.written {
+			t.Errorf("%q: wrote %q, %v, want %q", tt.text, sb.String(), err, tt.written)
+		}
+		if gb.LineCount() != tt.lines {
+			t.Errorf("%q: got %d lines, want %d", tt.text, gb.LineCount(), tt.lines)
+		}
+		if gb.Length() != len(tt.text) || gb.GetText() != tt.text {
+			t.Errorf("%q: stored %q", tt.text, gb.GetText())
+		}
+	}
+
+	gb := newBuffer(t, "a\nb")
+	gb.SetFinalNewline(true)
+	if r, err := gb.LineRange(2); err != nil || r != (buffer.Range{Start: 3, End: 3}) {
+		t.Errorf("last line: got %+v, %v", r, err)
+	}
+	if pos, err := gb.LineColToOffset(2, 0); err != nil || pos != 3 {
+		t.Errorf("start of last line: got %d, %v", pos, err)
+	}
+	if line, col, err := gb.OffsetToLineCol(3); err != nil || line != 1 || col != 1 {
+		t.Errorf("end of buffer: got %d:%d, %v", line, col, err)
+	}
+	if _, err := gb.LineRange(3); !errors.Is(err, buffer.ErrLineOutOfRange) {
+		t.Errorf("past the last line: got %v, want ErrLineOutOfRange", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "posix.txt")
+	if err := gb.SaveFile(path, buffer.SaveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "a\nb\n" {
+		t.Errorf("saved %q", data)
+	}
+
+	gb.SetFinalNewline(false)
+	if gb.LineCount() != 2 {
+		t.Errorf("turned off: got %d lines, want 2", gb.LineCount())
+	}
+}
@@ -0,0 +1,100 @@
+package buffer
+
+import (
+	"iter"
+	"strings"
+)
+
+// Find returns the position of the first occurrence of needle at or after
+// from, or -1 if there is none. It searches chunk by chunk, so the text is
+// never flattened. An empty needle is found at from.
+func (gb *GapBuffer) Find(needle string, from int) int {
+	from = max(from, 0)
+	if from > gb.length {
+		return -1
+	}
+	if needle == "" {
+		return from
+	}
+
+	found := -1
+	gb.findLiteral(needle, from, func(pos int) bool {
+		found = pos
+		return false
+	})
+	return found
+}
+
+// FindAll returns an iterator over the positions of the non-overlapping
+// occurrences of needle at or after from, found lazily as the iteration
+// goes on. An empty needle occurs at every position. Editing the buffer
+// ends the iteration.
+func (gb *GapBuffer) FindAll(needle string, from int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		from := max(from, 0)
+		version := gb.version
+		if needle == "" {
+			for pos := from; pos <= gb.length && gb.version == version; pos++ {
+				if !yield(pos) {
+					return
+				}
+			}
+			return
+		}
+
+		next := from // occurrences before this overlap the previous one
+		gb.findLiteral(needle, from, func(pos int) bool {
+			if pos < next {
+				return true
+			}
+			next = pos + len(needle)
+			return yield(pos) && gb.version == version
+		})
+	}
+}
+
+// findLiteral calls fn with the position of every occurrence of needle,
+// which must not be empty, at or after from, overlapping ones included, in
+// order until fn returns false. Occurrences spanning chunks are found by
+// keeping the last len(needle)-1 bytes seen in pending.
+func (gb *GapBuffer) findLiteral(needle string, from int, fn func(pos int) bool) {
+	keep := len(needle) - 1
+	pending := ""
+	pendingPos := from
+
+	gb.forEachChunk(from, gb.length, func(pos int, text string) bool {
+		// Occurrences starting in pending and ending in this piece
+		window := pending + text[:min(len(text), keep)]
+		for i := 0; i < len(pending); i++ {
+			j := strings.Index(window[i:], needle)
+			if j < 0 || i+j >= len(pending) {
+				break
+			}
+			i += j
+			if !fn(pendingPos + i) {
+				return false
+			}
+		}
+
+		if len(text) < keep {
+			// Too short to hold an occurrence; carry it over with pending
+			pending = window[max(len(window)-keep, 0):]
+			pendingPos = pos + len(text) - len(pending)
+			return true
+		}
+
+		for i := 0; ; i++ {
+			j := strings.Index(text[i:], needle)
+			if j < 0 {
+				break
+			}
+			i += j
+			if !fn(pos + i) {
+				return false
+			}
+		}
+		pending = text[len(text)-keep:]
+		pendingPos = pos + len(text) - keep
+		return true
+	})
+}
@@ -0,0 +1,87 @@
+package buffer_test
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestFind checks Find and FindAll against the strings package on random
+// texts and layouts, with needles longer than the chunks
+func TestFind(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		text := ""
+		for n := rng.Intn(60); n > 0; n-- {
+			text += string("aab"[rng.Intn(3)])
+		}
+		needle := ""
+		for n := rng.Intn(7) + 1; n > 0; n-- {
+			needle += string("ab"[rng.Intn(2)])
+		}
+		from := rng.Intn(len(text)+2) - 1
+
+		gb := buffer.NewWithChunkSize(3)
+		gb.InsertAt(0, text)
+		gb.RandomizeLayout(int64(i))
+
+		var want []int
+		for pos := max(from, 0); pos <= len(text); {
+			j := strings.Index(text[pos:], needle)
+			if j < 0 {
+				break
+			}
+			want = append(want, pos+j)
+			pos += j + len(needle)
+		}
+		first := -1
+		if len(want) > 0 {
+			first = want[0]
+		}
+		if got := gb.Find(needle, from); got != first {
+			t.Fatalf("%q in %q from %d: got %d, want %d", needle, text, from, got, first)
+		}
+		if got := slices.Collect(gb.FindAll(needle, from)); !reflect.DeepEqual(got, want) {
+			t.Fatalf("all %q in %q from %d: got %v, want %v", needle, text, from, got, want)
+		}
+	}
+}
+
+// TestFindAllLazy checks empty needles, stopping early and that an edit
+// ends the iteration
+func TestFindAllLazy(t *testing.T) {
+	gb := newBuffer(t, "a-a-a-a")
+	if got := gb.Find("", 3); got != 3 {
+		t.Errorf("empty needle: got %d", got)
+	}
+	if got := gb.Find("a", 8); got != -1 {
+		t.Errorf("from past the end: got %d", got)
+	}
+	if got := slices.Collect(gb.FindAll("", 5)); !reflect.DeepEqual(got, []int{5, 6, 7}) {
+		t.Errorf("empty needle: got %v", got)
+	}
+
+	var got []int
+	for pos := range gb.FindAll("a", 0) {
+		got = append(got, pos)
+		if len(got) == 2 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(got, []int{0, 2}) {
+		t.Errorf("stopping early: got %v", got)
+	}
+
+	got = nil
+	for pos := range gb.FindAll("a", 0) {
+		got = append(got, pos)
+		gb.InsertAt(0, "x")
+	}
+	if len(got) != 1 {
+		t.Errorf("edit did not end the iteration: got %v", got)
+	}
+}
@@ -0,0 +1,127 @@
+package buffer
+
+// DEFAULT_FLAT_GAP_SIZE is the gap a flat buffer opens when it runs out of room
+const DEFAULT_FLAT_GAP_SIZE = 4096
+
+// FlatBuffer is the classic gap buffer: one contiguous byte slice with a gap
+// at the last edit. Edits near each other are cheap, but moving the gap
+// far away copies everything in between and growing copies the whole text,
+// so it suits small files better than the chunk tree of GapBuffer.
+type FlatBuffer struct {
+	data     []byte
+	gapStart int
+	gapEnd   int
+}
+
+// NewFlatBuffer creates an empty flat buffer
+func NewFlatBuffer() *FlatBuffer {
+	return &FlatBuffer{}
+}
+
+// NewFlatBufferFromString creates a flat buffer holding text
+func NewFlatBufferFromString(text string) *FlatBuffer {
+	data := make([]byte, len(text)+DEFAULT_FLAT_GAP_SIZE)
+	copy(data, text)
+	return &FlatBuffer{data: data, gapStart: len(text), gapEnd: len(data)}
+}
+
+// Length returns the length of the text in bytes
+func (f *FlatBuffer) Length() int {
+	return len(f.data) - (f.gapEnd - f.gapStart)
+}
+
+// InsertAt inserts text at the specified position
+func (f *FlatBuffer) InsertAt(pos int, text string) (err error) {
+	defer guard("InsertAt", pos, f.Length(), &err)
+
+	if pos < 0 || pos > f.Length() {
+		return ErrPositionOutOfRange
+	}
+	return f.Replace(pos, pos, text)
+}
+
+// DeleteAt deletes text at the specified position
+func (f *FlatBuffer) DeleteAt(pos int, count int) (err error) {
+	defer guard("DeleteAt", pos, f.Length(), &err)
+
+	if pos == f.Length() && count > 0 {
+		return ErrDeleteAtEnd
+	}
+	if pos < 0 || count < 0 || pos > f.Length() || count > f.Length()-pos {
+		return ErrInvalidRange
+	}
+	return f.Replace(pos, pos+count, "")
+}
+
+// Replace replaces the text in the specified range
+func (f *FlatBuffer) Replace(start int, end int, text string) (err error) {
+	defer guard("Replace", start, f.Length(), &err)
+
+	if start < 0 || end > f.Length() || start > end {
+		return ErrInvalidRange
+	}
+	f.moveGap(end)
+	f.gapStart = start
+	if f.gapEnd-f.gapStart < len(text) {
+		f.grow(len(text))
+	}
+	copy(f.data[f.gapStart:], text)
+	f.gapStart += len(text)
+	return nil
+}
+
+// GetText returns the text in the buffer
+func (f *FlatBuffer) GetText() string {
+	return EnsureValidUTF8(string(f.data[:f.gapStart]) + string(f.data[f.gapEnd:]))
+}
+
+// GetTextRange returns the text in the specified range
+func (f *FlatBuffer) GetTextRange(start int, end int) (text string, err error) {
+	defer guard("GetTextRange", start, f.Length(), &err)
+
+	if start < 0 || end > f.Length() || start > end {
+		return "", ErrInvalidRange
+	}
+	return EnsureValidUTF8(f.text(start, end)), nil
+}
+
+// text returns the bytes in [start, end) as they are stored
+func (f *FlatBuffer) text(start int, end int) string {
+	result := make([]byte, 0, end-start)
+	if start < f.gapStart {
+		result = append(result, f.data[start:min(end, f.gapStart)]...)
+	}
+	if end > f.gapStart {
+		gap := f.gapEnd - f.gapStart
+		result = append(result, f.data[max(start, f.gapStart)+gap:end+gap]...)
+	}
+	return string(result)
+}
+
+// moveGap moves the gap to pos, copying the text between
+func (f *FlatBuffer) moveGap(pos int) {
+	switch {
+	case pos < f.gapStart:
+		n := f.gapStart - pos
+		copy(f.data[f.gapEnd-n:f.gapEnd], f.data[pos:f.gapStart])
+		f.gapStart -= n
+		f.gapEnd -= n
+	case pos > f.gapStart:
+		n := pos - f.gapStart
+		copy(f.data[f.gapStart:], f.data[f.gapEnd:f.gapEnd+n])
+		f.gapStart += n
+		f.gapEnd += n
+	}
+}
+
+// grow reallocates the data so the gap holds at least need bytes, doubling
+// the capacity to keep repeated inserts amortized
+func (f *FlatBuffer) grow(need int) {
+	size := max(2*len(f.data), f.Length()+need+DEFAULT_FLAT_GAP_SIZE)
+	data := make([]byte, size)
+	copy(data, f.data[:f.gapStart])
+	tail := len(f.data) - f.gapEnd
+	copy(data[size-tail:], f.data[f.gapEnd:])
+	f.data = data
+	f.gapEnd = size - tail
+}
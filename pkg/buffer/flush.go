@@ -0,0 +1,95 @@
+package buffer
+
+import (
+	"context"
+	"sync"
+)
+
+// backgroundTasks tracks work the buffer runs off the caller's goroutine
+type backgroundTasks struct {
+	mu      sync.Mutex
+	pending int
+	idle    chan struct{} // closed when pending drops back to zero
+}
+
+// start registers a new background task
+func (b *backgroundTasks) start() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.pending == 0 {
+		b.idle = make(chan struct{})
+	}
+	b.pending++
+}
+
+// done marks a background task as finished
+func (b *backgroundTasks) done() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pending--
+	if b.pending == 0 {
+		close(b.idle)
+	}
+}
+
+// wait blocks until no background task is pending or ctx is done
+func (b *backgroundTasks) wait(ctx context.Context) error {
+	b.mu.Lock()
+	if b.pending == 0 {
+		b.mu.Unlock()
+		return nil
+	}
+	idle := b.idle
+	b.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run runs fn on its own goroutine and tracks it until it returns
+func (b *backgroundTasks) run(fn func()) {
+	b.start()
+	go func() {
+		defer b.done()
+		fn()
+	}()
+}
+
+// goBackground runs fn on its own goroutine and tracks it until it returns
+func (gb *GapBuffer) goBackground(fn func()) {
+	gb.bg.run(fn)
+}
+
+// Flush brings the buffer to rest: it runs the tasks scheduled with OnIdle,
+// such as ScheduleCompaction or ScheduleGuideBackfill, until they are done
+// or cancelled, and waits until the background work started by the buffer
+// has finished, such as the writes and deletes of the streams of
+// SetSpillStorage. Work started while
+// Flush is waiting is waited for as well. It returns ctx.Err() if the
+// context is done first. Idle tasks run on the calling goroutine, so it
+// must be the one editing the buffer.
+func (gb *GapBuffer) Flush(ctx context.Context) error {
+	for len(gb.idle.tasks) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		gb.RunIdle()
+	}
+	for {
+		if err := gb.bg.wait(ctx); err != nil {
+			return err
+		}
+		gb.bg.mu.Lock()
+		pending := gb.bg.pending
+		gb.bg.mu.Unlock()
+		if pending == 0 {
+			return nil
+		}
+	}
+}
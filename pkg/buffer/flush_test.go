@@ -0,0 +1,97 @@
+package buffer_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestFlush checks that Flush runs idle tasks to completion, however many
+// slices they take, and stops when the context is done
+func TestFlush(t *testing.T) {
+	gb := buffer.New()
+	if err := gb.Flush(context.Background()); err != nil {
+		t.Fatalf("flush with nothing to do: %v", err)
+	}
+
+	slices := 0
+	task := func(yield func() bool) bool {
+		slices++
+		return slices == 3
+	}
+
+	h := gb.OnIdle(task, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := gb.Flush(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled flush: got %v, want context.Canceled", err)
+	}
+	if h.Done() || slices != 0 {
+		t.Fatalf("cancelled flush ran the task: done %v after %d slices", h.Done(), slices)
+	}
+
+	if err := gb.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !h.Done() || slices != 3 {
+		t.Errorf("got done %v after %d slices, want done after 3", h.Done(), slices)
+	}
+	if gb.RunIdle() {
+		t.Error("tasks remain after Flush")
+	}
+}
+
+// gatedStorage is a MemStorage whose Put waits until the gate is opened
+type gatedStorage struct {
+	*buffer.MemStorage
+	gate chan struct{}
+}
+
+// Put implements buffer.Storage
+func (s gatedStorage) Put(ctx context.Context, name string, r io.Reader) error {
+	<-s.gate
+	return s.MemStorage.Put(ctx, name, r)
+}
+
+// TestFlushWaits checks that Flush blocks until the spilled text being put
+// into a slow storage in the background is written, while editing goes on
+func TestFlushWaits(t *testing.T) {
+	st := gatedStorage{buffer.NewMemStorage(), make(chan struct{})}
+	gb := buffer.New()
+	gb.SetSpillThreshold(16)
+	gb.SetSpillStorage(st, "spill/")
+	text := strings.Repeat("spilled in the background ", 10)
+	gb.InsertAt(0, text)
+	gb.DeleteAt(0, len(text))
+	gb.InsertAt(0, "editing goes on")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := gb.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("flush with a write pending: got %v, want context.DeadlineExceeded", err)
+	}
+
+	flushed := make(chan error)
+	go func() { flushed <- gb.Flush(context.Background()) }()
+	select {
+	case err := <-flushed:
+		t.Fatalf("flush returned %v before the write finished", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(st.gate)
+	if err := <-flushed; err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := st.List(context.Background(), "spill/"); len(names) != 1 {
+		t.Fatalf("flushed streams %v", names)
+	}
+	gb.Undo()
+	if err := gb.Undo(); err != nil || gb.GetText() != text {
+		t.Errorf("undo: got %d bytes, %v", gb.Length(), err)
+	}
+}
@@ -0,0 +1,217 @@
+package buffer
+
+import (
+	"iter"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FoldOptions selects the differences a folded search ignores
+type FoldOptions struct {
+	IgnoreCase  bool // Letters match in any case, by Unicode simple case folding
+	Canonical   bool // Canonically equivalent text matches, e.g. "\u00e9" and "e\u0301"
+	IgnoreMarks bool // Combining marks are ignored, so "resume" matches "Résumé"; implies Canonical
+}
+
+// maxFoldSegment caps the runes folded together, as the stream-safe text
+// format of UAX #15 caps runs of combining marks
+const maxFoldSegment = 32
+
+// FindFolded returns the first occurrence of needle at or after from under
+// opts. The occurrence may differ in length from needle, so its range is
+// returned. Matches start and end on the boundaries of the runes the folding
+// looks at together, so "e" does not match the start of "é" unless marks
+// are ignored. The text is folded chunk by chunk as it is searched and is
+// never flattened. A needle folding to nothing is found at from.
+func (gb *GapBuffer) FindFolded(needle string, from int, opts FoldOptions) (match Range, found bool) {
+	from = max(from, 0)
+	if from > gb.length {
+		return Range{}, false
+	}
+	pattern := foldString(needle, opts)
+	if len(pattern) == 0 {
+		return Range{Start: from, End: from}, true
+	}
+
+	gb.findFolded(pattern, from, opts, func(r Range) bool {
+		match, found = r, true
+		return false
+	})
+	return match, found
+}
+
+// FindAllFolded returns an iterator over the non-overlapping occurrences of
+// needle at or after from under opts, found lazily as the iteration goes
+// on. A needle folding to nothing occurs at every position. Editing the
+// buffer ends the iteration.
+func (gb *GapBuffer) FindAllFolded(needle string, from int, opts FoldOptions) iter.Seq[Range] {
+	return func(yield func(Range) bool) {
+		from := max(from, 0)
+		version := gb.version
+		pattern := foldString(needle, opts)
+		if len(pattern) == 0 {
+			for pos := from; pos <= gb.length && gb.version == version; pos++ {
+				if !yield(Range{Start: pos, End: pos}) {
+					return
+				}
+			}
+			return
+		}
+
+		next := from // occurrences before this overlap the previous one
+		gb.findFolded(pattern, from, opts, func(r Range) bool {
+			if r.Start < next {
+				return true
+			}
+			next = r.End
+			return yield(r) && gb.version == version
+		})
+	}
+}
+
+// foldedRune is a rune of the folded text with the range of the source
+// text it was folded from. Runes folded together share the range; first
+// and last mark the runes that begin and end it.
+type foldedRune struct {
+	r           rune
+	start       int
+	end         int
+	first, last bool
+}
+
+// findFolded calls fn with the range of every occurrence of the folded
+// pattern, which must not be empty, at or after from, overlapping ones
+// included, in order until fn returns false. The pattern is matched against
+// the folded text stream with Knuth-Morris-Pratt, keeping the last
+// len(pattern) folded runes to map a match back to the source.
+func (gb *GapBuffer) findFolded(pattern []rune, from int, opts FoldOptions, fn func(Range) bool) {
+	n := len(pattern)
+	fail := make([]int, n) // length of the longest proper border of pattern[:i+1]
+	for i, k := 1, 0; i < n; i++ {
+		for k > 0 && pattern[i] != pattern[k] {
+			k = fail[k-1]
+		}
+		if pattern[i] == pattern[k] {
+			k++
+		}
+		fail[i] = k
+	}
+
+	var window []foldedRune
+	k := 0
+	gb.eachFolded(from, opts, func(f foldedRune) bool {
+		if len(window) >= 2*n {
+			window = append(window[:0], window[len(window)-n+1:]...)
+		}
+		window = append(window, f)
+
+		for k > 0 && pattern[k] != f.r {
+			k = fail[k-1]
+		}
+		if pattern[k] == f.r {
+			k++
+		}
+		if k < n {
+			return true
+		}
+		k = fail[k-1]
+		m := window[len(window)-n:]
+		if !m[0].first || !m[n-1].last {
+			return true
+		}
+		return fn(Range{Start: m[0].start, End: m[n-1].end})
+	})
+}
+
+// eachFolded folds the text from from on and calls fn with every folded
+// rune until fn returns false. The runes combining with each other are
+// folded together: a rune and the combining marks after it when the search
+// is canonical, single runes otherwise.
+func (gb *GapBuffer) eachFolded(from int, opts FoldOptions, fn func(foldedRune) bool) {
+	canonical := opts.Canonical || opts.IgnoreMarks
+	var segment strings.Builder
+	var buf []rune
+	start, count := from, 0
+	stopped := false
+
+	flush := func(end int) bool {
+		if count == 0 {
+			return true
+		}
+		if canonical {
+			buf = normalizeRunes(NFD, segment.String(), buf[:0])
+		} else {
+			buf = append(buf[:0], []rune(segment.String())...)
+		}
+		buf = foldRunes(buf, opts)
+		for i, r := range buf {
+			if !fn(foldedRune{r: r, start: start, end: end, first: i == 0, last: i == len(buf)-1}) {
+				return false
+			}
+		}
+		segment.Reset()
+		start, count = end, 0
+		return true
+	}
+
+	gb.scanRunes(from, gb.length, func(pos int, r rune, size int) bool {
+		invalid := r == utf8.RuneError && size == 1
+		if !canonical || invalid || !combinesBackward(r) || count >= maxFoldSegment {
+			if !flush(pos) {
+				stopped = true
+				return false
+			}
+		}
+		if invalid {
+			// Kept as a rune of its own that only U+FFFD matches
+			r = utf8.RuneError
+		}
+		segment.WriteRune(r)
+		count++
+		return true
+	})
+	if !stopped {
+		flush(gb.length)
+	}
+}
+
+// foldString returns the folded runes of s
+func foldString(s string, opts FoldOptions) []rune {
+	if opts.Canonical || opts.IgnoreMarks {
+		return foldRunes(normalizeRunes(NFD, s, nil), opts)
+	}
+	return foldRunes([]rune(s), opts)
+}
+
+// foldRunes case folds runes and drops their combining marks as opts asks,
+// in place
+func foldRunes(runes []rune, opts FoldOptions) []rune {
+	out := runes[:0]
+	for _, r := range runes {
+		if opts.IgnoreMarks && (combiningClass(r) != 0 || unicode.In(r, unicode.Mn, unicode.Me)) {
+			continue
+		}
+		if opts.IgnoreCase {
+			r = foldCase(r)
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// foldCase returns the smallest rune r is equivalent to under simple case
+// folding, the same for every rune of the orbit
+func foldCase(r rune) rune {
+	if r < utf8.RuneSelf {
+		if 'a' <= r && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return r
+	}
+	folded := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		folded = min(folded, f)
+	}
+	return folded
+}
@@ -0,0 +1,64 @@
+package buffer_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestFindFolded checks matches under each of the fold options
+func TestFindFolded(t *testing.T) {
+	gb := newBuffer(t, "The R\u00C9SUM\u00C9 and the re\u0301sume\u0301 of a resume")
+	gb.RandomizeLayout(5)
+	tests := []struct {
+		name   string
+		needle string
+		from   int
+		opts   buffer.FoldOptions
+		want   buffer.Range
+		found  bool
+	}{
+		{"exact", "resume", 0, buffer.FoldOptions{}, buffer.Range{Start: 37, End: 43}, true},
+		{"case", "the", 1, buffer.FoldOptions{IgnoreCase: true}, buffer.Range{Start: 17, End: 20}, true},
+		{"case and canonical", "r\u00E9sum\u00E9", 0, buffer.FoldOptions{IgnoreCase: true, Canonical: true}, buffer.Range{Start: 4, End: 12}, true},
+		{"canonical", "r\u00E9sum\u00E9", 0, buffer.FoldOptions{Canonical: true}, buffer.Range{Start: 21, End: 31}, true},
+		{"not canonical", "r\u00E9sum\u00E9", 0, buffer.FoldOptions{}, buffer.Range{}, false},
+		{"marks", "resume", 0, buffer.FoldOptions{IgnoreCase: true, IgnoreMarks: true}, buffer.Range{Start: 4, End: 12}, true},
+		{"marks from", "resume", 5, buffer.FoldOptions{IgnoreMarks: true}, buffer.Range{Start: 21, End: 31}, true},
+		{"part of a cluster", "re", 21, buffer.FoldOptions{Canonical: true}, buffer.Range{Start: 37, End: 39}, true},
+		{"empty", "", 7, buffer.FoldOptions{}, buffer.Range{Start: 7, End: 7}, true},
+		{"past the end", "a", 99, buffer.FoldOptions{}, buffer.Range{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := gb.FindFolded(tt.needle, tt.from, tt.opts)
+			if found != tt.found || got != tt.want {
+				t.Errorf("got %+v, %v, want %+v, %v", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+// TestFindAllFolded checks that occurrences do not overlap and that
+// editing ends the iteration
+func TestFindAllFolded(t *testing.T) {
+	gb := newBuffer(t, "AAaa\u00C5a")
+	opts := buffer.FoldOptions{IgnoreCase: true, IgnoreMarks: true}
+
+	got := slices.Collect(gb.FindAllFolded("aa", 0, opts))
+	want := []buffer.Range{{Start: 0, End: 2}, {Start: 2, End: 4}, {Start: 4, End: 7}}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	n := 0
+	for range gb.FindAllFolded("a", 0, opts) {
+		if n++; n == 1 {
+			gb.InsertAt(0, "a")
+		}
+	}
+	if n != 1 {
+		t.Errorf("iteration went on for %d matches after an edit", n)
+	}
+}
@@ -0,0 +1,43 @@
+package buffer
+
+import "log"
+
+// FullReadPolicy guards against reading large buffers whole. GetText on a
+// buffer longer than Threshold calls Report, steering integrators toward
+// the range and streaming APIs such as GetTextRange, Reader and WriteTo.
+// A Report that panics turns such reads into failures, e.g. in tests.
+// FullText reads the whole text without the check, for callers that mean
+// to.
+type FullReadPolicy struct {
+	Threshold int              // Length above which GetText is reported, 0 for no check
+	Report    func(length int) // Called with the length of the buffer; nil logs a warning
+}
+
+// SetFullReadPolicy sets the policy checked by GetText
+func (gb *GapBuffer) SetFullReadPolicy(p FullReadPolicy) {
+	gb.fullRead = p
+}
+
+// FullReadPolicy returns the policy set with SetFullReadPolicy
+func (gb *GapBuffer) FullReadPolicy() FullReadPolicy {
+	return gb.fullRead
+}
+
+// FullText returns the whole text like GetText, without checking the full
+// read policy
+func (gb *GapBuffer) FullText() string {
+	return gb.text()
+}
+
+// checkFullRead reports a whole read of a buffer above the policy threshold
+func (gb *GapBuffer) checkFullRead() {
+	p := gb.fullRead
+	if p.Threshold <= 0 || gb.length <= p.Threshold {
+		return
+	}
+	if p.Report != nil {
+		p.Report(gb.length)
+		return
+	}
+	log.Printf("buffer: GetText read %d bytes, above the full read threshold of %d; use GetTextRange, Reader or WriteTo", gb.length, p.Threshold)
+}
@@ -0,0 +1,58 @@
+package buffer_test
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestFullReadPolicy checks that GetText reports reads above the threshold
+// and FullText does not
+func TestFullReadPolicy(t *testing.T) {
+	gb := newBuffer(t, "0123456789")
+	var reported []int
+	gb.SetFullReadPolicy(buffer.FullReadPolicy{Threshold: 10, Report: func(length int) {
+		reported = append(reported, length)
+	}})
+	if gb.FullReadPolicy().Threshold != 10 {
+		t.Errorf("got policy %+v", gb.FullReadPolicy())
+	}
+
+	gb.GetText()
+	if len(reported) != 0 {
+		t.Errorf("a read at the threshold was reported")
+	}
+	gb.InsertAt(0, "x")
+	gb.GetText()
+	if text := gb.FullText(); text != "x0123456789" {
+		t.Errorf("FullText returned %q", text)
+	}
+	gb.GetTextRange(0, 11)
+	if len(reported) != 1 || reported[0] != 11 {
+		t.Errorf("got reports %v, want [11]", reported)
+	}
+
+	gb.SetFullReadPolicy(buffer.FullReadPolicy{})
+	gb.GetText()
+	if len(reported) != 1 {
+		t.Errorf("reported without a threshold")
+	}
+}
+
+// TestFullReadPolicyLog checks that a policy without Report logs a warning
+func TestFullReadPolicyLog(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	gb := newBuffer(t, "0123456789")
+	gb.SetFullReadPolicy(buffer.FullReadPolicy{Threshold: 4})
+	gb.GetText()
+	if !strings.Contains(out.String(), "GetText read 10 bytes") {
+		t.Errorf("logged %q", out.String())
+	}
+}
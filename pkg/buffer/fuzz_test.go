@@ -0,0 +1,153 @@
+package buffer_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// FuzzEdits drives the public API with arbitrary positions and text, the
+// way offsets coming over the wire would. No call may panic or report an
+// internal error, except for edits whose outline classifier panics on
+// purpose, and those must leave the buffer untouched.
+func FuzzEdits(f *testing.F) {
+	f.Add([]byte("\x00\x00\x05hello\x01\x01\x02\x00\x03\x00\x00\x00\x06\x00\x07"))
+	f.Add([]byte("\x02\xff\x01\x03a\nb\x04\x02\x02\x08\x00\x00\x09\x03\x01"))
+	f.Add([]byte("\x00\x00\x04\xe4\xbd\xa0\x0a\x05\x01\x02\x00\x00\x04boom\x06\x06\x07"))
+	f.Add([]byte("\x10\x03abc\x10\x05#boom\x06\x00\x00\x01x"))
+	f.Add([]byte("\x00\x00\x03\xe4\xb8\x96\x02\x02\x03\x01\x8c"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		gb := buffer.NewWithChunkSize(8)
+		gb.SetTreeChecks(true)
+		gb.SetWordIndex(true)
+		gb.SetOutlineClassifier(func(line string) (string, bool) {
+			if strings.Contains(line, "boom") {
+				panic("boom")
+			}
+			return line, strings.HasPrefix(line, "#")
+		})
+		var model []byte
+
+		next := func() int {
+			if len(data) == 0 {
+				return 0
+			}
+			b := data[0]
+			data = data[1:]
+			// Mostly small positions, sometimes far out of range
+			return int(int8(b))
+		}
+		text := func() string {
+			n := min(next()&15, len(data))
+			s := string(data[:n])
+			data = data[n:]
+			return s
+		}
+
+		for len(data) > 0 {
+			var err error
+			checked := false
+			before := string(gb.Bytes())
+			switch op := (next() & 31) % 17; op {
+			case 0:
+				pos, s := next(), text()
+				if err = gb.InsertAt(pos, s); err == nil {
+					model = append(model[:pos:pos], append([]byte(s), model[pos:]...)...)
+					checked = true
+				}
+			case 1:
+				pos, n := next(), next()
+				if err = gb.DeleteAt(pos, n); err == nil {
+					model = append(model[:pos:pos], model[pos+n:]...)
+					checked = true
+				}
+			case 2:
+				start, end, s := next(), next(), text()
+				if err = gb.Replace(start, end, s); err == nil {
+					model = append(model[:start:start], append([]byte(s), model[end:]...)...)
+					checked = true
+				}
+			case 3:
+				err = gb.InsertRuneAt(next(), text())
+			case 4:
+				err = gb.DeleteRuneAt(next(), next())
+			case 5:
+				err = gb.ReplaceRune(next(), next(), text())
+			case 6:
+				err = gb.Undo()
+			case 7:
+				err = gb.Redo()
+			case 8:
+				_, err = gb.GetTextRange(next(), next())
+			case 9:
+				_, _, err = gb.OffsetToLineCol(next())
+			case 10:
+				_, err = gb.LineColToOffset(next(), next())
+			case 11:
+				_, err = gb.LineRange(next())
+			case 12:
+				_, err = gb.ReadAt(make([]byte, next()&31), int64(next()))
+			case 13:
+				_, err = gb.CreateMarker(next(), buffer.Gravity(next()&1))
+			case 14:
+				_, err = gb.RuneAt(next())
+			case 15:
+				_, err = gb.ByteToRune(next())
+			case 16:
+				s := text()
+				if err = gb.ApplyContentChanges([]buffer.ContentChange{{Text: s}}); err == nil {
+					model = []byte(s)
+					checked = true
+				}
+			}
+
+			if errors.Is(err, buffer.ErrInternal) {
+				var internal *buffer.InternalError
+				if !errors.As(err, &internal) || internal.Panic != "boom" {
+					t.Fatalf("internal error: %v", err)
+				}
+				if got := string(gb.Bytes()); got != before {
+					t.Fatalf("failed edit changed the text from %q to %q", before, got)
+				}
+				if gb.InTransaction() {
+					t.Fatal("failed edit left a transaction open")
+				}
+			}
+			got := string(gb.Bytes())
+			if checked && got != string(model) {
+				t.Fatalf("text %q, want %q", got, model)
+			}
+			// Rune edits, undo and redo are only checked for the invariants
+			// below
+			model = []byte(got)
+
+			if gb.Length() != len(got) {
+				t.Fatalf("Length %d, text has %d bytes", gb.Length(), len(got))
+			}
+			// Runes are counted by their first byte, so stray continuation
+			// bytes count for none
+			runes := 0
+			for i := 0; i < len(got); i++ {
+				if utf8.RuneStart(got[i]) {
+					runes++
+				}
+			}
+			if gb.RuneLength() != runes {
+				t.Fatalf("RuneLength %d, text has %d runes", gb.RuneLength(), runes)
+			}
+
+			// The word index follows edits splitting and joining runes
+			fresh := buffer.New()
+			fresh.InsertAt(0, got)
+			fresh.SetWordIndex(true)
+			if words, want := gb.WordCompletions("", 0), fresh.WordCompletions("", 0); !slices.Equal(words, want) {
+				t.Fatalf("word index %v, text has %v", words, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package buffer
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// FuzzyMatch is an approximate occurrence of a query: the text in
+// [Start, End) differs from it by Errors rune insertions, deletions or
+// substitutions
+type FuzzyMatch struct {
+	Start  int
+	End    int
+	Errors int
+}
+
+// FuzzyFind finds the places where the text matches query with at most
+// maxErrors errors, best first: fewest errors, then earliest position.
+// Occurrences ending at neighbouring positions are reported once, as the
+// best of them. The text is streamed chunk by chunk through Myers'
+// bit-parallel algorithm, or a plain dynamic program for queries longer
+// than 64 runes.
+func (gb *GapBuffer) FuzzyFind(query string, maxErrors int) []FuzzyMatch {
+	pattern := []rune(query)
+	m := len(pattern)
+	if m == 0 || maxErrors < 0 {
+		return nil
+	}
+	maxErrors = min(maxErrors, m-1)
+
+	var matches []FuzzyMatch
+	best := FuzzyMatch{Errors: -1} // best end of the current run of occurrences
+	flush := func() {
+		if best.Errors >= 0 {
+			best.Start = gb.fuzzyStart(pattern, best.End, maxErrors)
+			matches = append(matches, best)
+			best.Errors = -1
+		}
+	}
+	gb.fuzzyScan(pattern, func(end int, errors int) {
+		switch {
+		case errors > maxErrors:
+			flush()
+		case best.Errors < 0 || errors < best.Errors:
+			best = FuzzyMatch{End: end, Errors: errors}
+		}
+	})
+	flush()
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Errors < matches[j].Errors
+	})
+	return matches
+}
+
+// fuzzyScan calls fn after every rune of the text with the position after
+// it and the fewest errors of an occurrence of pattern ending there
+func (gb *GapBuffer) fuzzyScan(pattern []rune, fn func(end int, errors int)) {
+	m := len(pattern)
+	if m > 64 {
+		// Column of the edit distances of the pattern prefixes, with the
+		// occurrence free to start anywhere
+		col := make([]int, m+1)
+		for i := range col {
+			col[i] = i
+		}
+		gb.scanRunes(0, gb.length, func(pos int, c rune, size int) bool {
+			diag := 0
+			for i := 1; i <= m; i++ {
+				cost := diag
+				if pattern[i-1] != c {
+					cost = min(diag, col[i], col[i-1]) + 1
+				}
+				diag, col[i] = col[i], cost
+			}
+			fn(pos+size, col[m])
+			return true
+		})
+		return
+	}
+
+	peq := make(map[rune]uint64)
+	for i, c := range pattern {
+		peq[c] |= 1 << i
+	}
+	mask := ^uint64(0) >> (64 - m)
+	last := uint64(1) << (m - 1)
+
+	pv, mv, score := mask, uint64(0), m
+	gb.scanRunes(0, gb.length, func(pos int, c rune, size int) bool {
+		eq := peq[c]
+		xv := eq | mv
+		xh := (((eq & pv) + pv) ^ pv) | eq
+		ph := mv | ^(xh | pv)
+		mh := pv & xh
+		if ph&last != 0 {
+			score++
+		} else if mh&last != 0 {
+			score--
+		}
+		ph <<= 1
+		mh <<= 1
+		pv = (mh | ^(xv | ph)) & mask
+		mv = ph & xv & mask
+		fn(pos+size, score)
+		return true
+	})
+}
+
+// fuzzyStart returns where the best occurrence of pattern ending at end
+// starts, preferring the length closest to the pattern among equally good
+// ones. It aligns the reversed pattern with the text read backwards.
+func (gb *GapBuffer) fuzzyStart(pattern []rune, end int, maxErrors int) int {
+	m := len(pattern)
+	window := gb.rawText(max(end-(m+maxErrors)*utf8.UTFMax, 0), end)
+
+	// row[j] is the distance between the last i runes of the window and
+	// the last j runes of the pattern
+	row := make([]int, m+1)
+	for j := range row {
+		row[j] = j
+	}
+	start, bestErrors, bestLen := end, row[m], 0
+	pos := end
+	for i := 1; i <= m+maxErrors && len(window) > 0; i++ {
+		c, size := utf8.DecodeLastRuneInString(window)
+		window = window[:len(window)-size]
+		pos -= size
+
+		diag := row[0]
+		row[0] = i
+		for j := 1; j <= m; j++ {
+			cost := diag
+			if pattern[m-j] != c {
+				cost = min(diag, row[j], row[j-1]) + 1
+			}
+			diag, row[j] = row[j], cost
+		}
+		if row[m] < bestErrors || (row[m] == bestErrors && abs(i-m) < abs(bestLen-m)) {
+			start, bestErrors, bestLen = pos, row[m], i
+		}
+	}
+	return start
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,65 @@
+package buffer_test
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestFuzzyFind checks the matches found for a query with typos, best
+// first, and the queries finding nothing
+func TestFuzzyFind(t *testing.T) {
+	gb := newBuffer(t, "the quick brown fox, the quack brawn fix, the quick brown fox")
+	want := []buffer.FuzzyMatch{
+		{Start: 4, End: 15, Errors: 0},
+		{Start: 46, End: 57, Errors: 0},
+		{Start: 25, End: 36, Errors: 2},
+	}
+	if got := gb.FuzzyFind("quick brown", 2); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := gb.FuzzyFind("quick brown", 1); len(got) != 2 {
+		t.Errorf("within 1 error: got %+v", got)
+	}
+	if gb.FuzzyFind("", 3) != nil || gb.FuzzyFind("fox", -1) != nil {
+		t.Error("matches for an empty query or a negative error count")
+	}
+}
+
+// TestFuzzyFindDistance checks that every match is within the reported
+// distance of the query, and ordered, for short and long queries
+func TestFuzzyFindDistance(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, m := range []int{3, 8, 64, 70} {
+		for i := 0; i < 20; i++ {
+			var sb strings.Builder
+			for n := 300; n > 0; n-- {
+				sb.WriteString([]string{"a", "b", "c", "é"}[rng.Intn(4)])
+			}
+			text := sb.String()
+			query := []rune(text)[rng.Intn(200):][:m]
+			query[rng.Intn(m)] = 'x'
+
+			gb := buffer.NewWithChunkSize(16)
+			gb.InsertAt(0, text)
+			maxErrors := 1 + m/8
+			matches := gb.FuzzyFind(string(query), maxErrors)
+			if len(matches) == 0 {
+				t.Fatalf("query of %d runes with a typo not found", m)
+			}
+			if !sort.SliceIsSorted(matches, func(a, b int) bool { return matches[a].Errors < matches[b].Errors }) {
+				t.Errorf("matches out of order: %+v", matches)
+			}
+			for _, match := range matches {
+				d := levenshtein(text[match.Start:match.End], string(query))
+				if match.Errors > maxErrors || d != match.Errors {
+					t.Fatalf("query of %d runes: %+v at distance %d", m, match, d)
+				}
+			}
+		}
+	}
+}
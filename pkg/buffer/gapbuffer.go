@@ -1,7 +1,11 @@
 package buffer
 
 import (
-	"errors"
+	"math"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
 	"unicode/utf8"
 )
 
@@ -11,30 +15,120 @@ const (
 	DEFAULT_GAP_SIZE   = 1024 * 1024 // 1MB initial gap
 )
 
-// Chunk represents a chunk of text in the gap buffer
+// Chunk is a chunk of text of the gap buffer at its logical position, as
+// returned by Chunks
 type Chunk struct {
 	Text string
 	Pos  int
 }
 
+// payload is the chunk of text a tree node holds. It is stored in the node
+// itself, so walking the tree needs no type assertion and no second
+// allocation per chunk; the position of the chunk is the node key and its
+// counts are the node's own measures.
+type payload struct {
+	Text string
+
+	runeMarks []int // byte offset of every runeMarkInterval-th rune, built on demand
+}
+
+// measure returns the measures of the chunk: its size, the number of
+// newlines in it, the number of its bytes that are not whitespace, its
+// length in UTF-16 and its number of runes. Measuring means the text
+// changed, so it drops the rune marks.
+func (c *payload) measure() measures {
+	c.runeMarks = nil
+	return textMeasures(c.Text)
+}
+
+// textMeasures returns the measures of a chunk holding text
+func textMeasures(text string) measures {
+	lines := strings.Count(text, "\n")
+	return measures{size: len(text), lines: lines, ink: textInk(text, lines), units: textUnits(text), runes: textRunes(text)}
+}
+
+// textInk returns the number of bytes of text other than spaces, tabs,
+// carriage returns and newlines, given the number of newlines in it
+func textInk(text string, lines int) int {
+	blank := lines + strings.Count(text, " ") + strings.Count(text, "\t") + strings.Count(text, "\r")
+	return len(text) - blank
+}
+
 // GapBuffer represents a gap buffer implemented using a red-black tree
 type GapBuffer struct {
-	tree      *RBTree
+	tree      chunkTree
 	gapStart  int
 	gapEnd    int
 	length    int
 	chunkSize int
+
+	bg   backgroundTasks // work running off the caller's goroutine
+	idle idleScheduler   // deferred work run between edits, see OnIdle
+
+	suggesting  bool          // record edits as suggestions instead of applying them
+	suggestions suggestionSet // pending suggested edits
+
+	version  int         // incremented by every applied edit
+	oplog    opLog       // most recent applied edits
+	blame    blameCache  // blame segments at a version, see BlameRange
+	history  history     // undo and redo steps
+	txn      transaction // edits of the open transactions
+	actor    string      // identity recorded with edits, see WithActor
+	metadata Metadata    // attached to edits, see WithMetadata
+
+	spill          spillFile     // large history content moved out of memory
+	spillThreshold int           // deleted text larger than this is spilled
+	mapping        []byte        // file mapped by NewFromFile, referenced by chunks
+	mappingPins    *atomic.Int32 // holders of mapping: the buffer and its snapshots
+	mappedFile     *os.File      // open file of the mapping, see SaveFile
+	mappedTime     time.Time     // modification time of mappedFile when opened
+	origins        *originTable  // input offsets of decoded text, see OriginalOffset
+
+	finalNewline bool // present a trailing newline to writers, see SetFinalNewline
+	frozen       bool // compacted for reading until the next edit, see Freeze
+	treeChecks   bool // check the tree depth after edits, see SetTreeChecks
+	views        int  // number of open read views freezing the buffer
+	treeShared   bool // tree shared with snapshots, copied before it changes, see Snapshot
+
+	outline  *outlineIndex // outline items maintained through edits
+	prose    *proseIndex   // prose blocks maintained through edits
+	guides   guideCache    // lazily computed bracket depths per line
+	lineIDs  *lineTable    // stable line identities, see LineID
+	lineSums []uint64      // checksum of every line maintained through edits, see SetLineChecksums
+	words    *wordIndex    // word frequencies maintained through edits, see SetWordIndex
+
+	rangeSets []*RangeSet // range sets shifted through edits
+	markers   markerIndex // positions shifted through edits, see CreateMarker
+
+	versions *versionChain // retained versions, see SetVersionRetention
+	feeds    []*ChangeFeed // consumers mirroring edits
+
+	subscriptions []*subscription // listeners called after edits, see Subscribe
+	changeHooks   []*changeHook   // checked before edits, see BeforeChange
+
+	recordNoOps bool     // apply edits that change nothing, see SetRecordNoOps
+	inputNorm   NormForm // normalize inserted text, see SetInputNormalization
+
+	limits     Limits         // hard limits checked before edits, see SetLimits
+	fullRead   FullReadPolicy // reports whole reads of large buffers, see SetFullReadPolicy
+	rangeCache rangeCache     // recent GetTextRange results, see SetRangeCacheSize
+	regexps    regexpCache    // context regexp of the last regexp search
+	binary     bool           // return text without UTF-8 coercion, see SetBinary
+	quota      *QuotaManager  // accounts the text length to quotaOwner, see SetQuota
+	quotaOwner string
+	identity   uint64 // random identity of the content history, see ETag
 }
 
 // New creates a new gap buffer
 func New() *GapBuffer {
 	tree := NewRBTree()
 	return &GapBuffer{
-		tree:      tree,
-		gapStart:  0,
-		gapEnd:    DEFAULT_GAP_SIZE,
-		length:    0,
-		chunkSize: DEFAULT_CHUNK_SIZE,
+		tree:       tree,
+		gapStart:   0,
+		gapEnd:     DEFAULT_GAP_SIZE,
+		length:     0,
+		chunkSize:  DEFAULT_CHUNK_SIZE,
+		rangeCache: rangeCache{size: DEFAULT_RANGE_CACHE_SIZE},
 	}
 }
 
@@ -45,68 +139,34 @@ func NewWithChunkSize(chunkSize int) *GapBuffer {
 		chunkSize = DEFAULT_CHUNK_SIZE
 	}
 	return &GapBuffer{
-		tree:      tree,
-		gapStart:  0,
-		gapEnd:    DEFAULT_GAP_SIZE,
-		length:    0,
-		chunkSize: chunkSize,
+		tree:       tree,
+		gapStart:   0,
+		gapEnd:     DEFAULT_GAP_SIZE,
+		length:     0,
+		chunkSize:  chunkSize,
+		rangeCache: rangeCache{size: DEFAULT_RANGE_CACHE_SIZE},
 	}
 }
 
 // InsertAt inserts text at the specified position
-func (gb *GapBuffer) InsertAt(pos int, text string) error {
-	if pos < 0 || pos > gb.length {
-		return errors.New("position out of range")
-	}
-
-	// Move gap to insertion point if needed
-	if pos != gb.gapStart {
-		gb.moveGap(pos)
-	}
-
-	// If gap is too small, expand it
-	if len(text) > gb.gapEnd-gb.gapStart {
-		gb.expandGap(len(text))
-	}
-
-	// Insert text into gap in chunks, ensuring we don't break Unicode characters
-	for i := 0; i < len(text); {
-		// Determine end position for this chunk, ensuring we don't break a UTF-8 sequence
-		end := i + gb.chunkSize
-		if end > len(text) {
-			end = len(text)
-		} else {
-			// Ensure we don't break a UTF-8 sequence
-			for !utf8.RuneStart(text[end-1]) && end > i {
-				end--
-			}
-		}
+func (gb *GapBuffer) InsertAt(pos int, text string) (err error) {
+	defer guard("InsertAt", pos, gb.length, &err)
 
-		chunk := &Chunk{
-			Text: text[i:end],
-			Pos:  gb.gapStart,
-		}
-		gb.tree.Insert(gb.gapStart, chunk)
-		gb.gapStart += end - i
-
-		i = end
+	if pos < 0 || pos > gb.length {
+		return ErrPositionOutOfRange
 	}
 
-	// Update length
-	gb.length += len(text)
-
-	return nil
+	return gb.replace(pos, pos, text)
 }
 
 // InsertRuneAt 在指定的Unicode字符位置插入文本
-func (gb *GapBuffer) InsertRuneAt(runePos int, text string) error {
-	// 获取完整文本以计算字节位置
-	fullText := gb.GetText()
+func (gb *GapBuffer) InsertRuneAt(runePos int, text string) (err error) {
+	defer guard("InsertRuneAt", runePos, gb.length, &err)
 
 	// 计算字节位置
-	bytePos := RuneIndex(fullText, runePos)
-	if bytePos < 0 {
-		return errors.New("rune position out of range")
+	bytePos, err := gb.RuneToByte(runePos)
+	if err != nil {
+		return err
 	}
 
 	// 调用字节位置的插入方法
@@ -114,61 +174,54 @@ func (gb *GapBuffer) InsertRuneAt(runePos int, text string) error {
 }
 
 // DeleteAt deletes text at the specified position
-func (gb *GapBuffer) DeleteAt(pos int, count int) error {
-	if pos < 0 || pos+count > gb.length {
-		return errors.New("position or count out of range")
-	}
+func (gb *GapBuffer) DeleteAt(pos int, count int) (err error) {
+	defer guard("DeleteAt", pos, gb.length, &err)
 
-	// Move gap to deletion point if needed
-	if pos != gb.gapStart {
-		gb.moveGap(pos)
+	if pos == gb.length && count > 0 {
+		return ErrDeleteAtEnd
 	}
-
-	// Find all chunks that need to be deleted
-	var keysToDelete []int
-	gb.tree.InOrderTraversal(func(key int, value interface{}) {
-		if key >= gb.gapEnd && key < gb.gapEnd+count {
-			keysToDelete = append(keysToDelete, key)
-		}
-	})
-
-	// Delete the chunks
-	for _, key := range keysToDelete {
-		gb.tree.Delete(key)
+	if pos < 0 || count < 0 || pos > gb.length || count > gb.length-pos {
+		return ErrInvalidRange
 	}
 
-	// Update gap and length
-	gb.gapEnd += count
-	gb.length -= count
-	return nil
+	return gb.replace(pos, pos+count, "")
 }
 
 // DeleteRuneAt 删除从指定Unicode字符位置开始的指定数量的Unicode字符
-func (gb *GapBuffer) DeleteRuneAt(runePos int, runeCount int) error {
+func (gb *GapBuffer) DeleteRuneAt(runePos int, runeCount int) (err error) {
+	defer guard("DeleteRuneAt", runePos, gb.length, &err)
+
 	if runeCount <= 0 {
-		return errors.New("rune count must be positive")
+		return ErrInvalidRuneCount
 	}
 
-	// 获取完整文本以计算字节位置
-	fullText := gb.GetText()
-
 	// 确保runePos有效
-	if runePos < 0 || runePos >= RuneCount(fullText) {
-		return errors.New("rune position out of range")
+	runes := gb.tree.total().runes
+	if runePos == runes {
+		return ErrDeleteAtEnd
+	}
+	if runePos < 0 || runePos > runes {
+		return ErrRunePositionOutOfRange
 	}
 
 	// 计算开始和结束的字节位置
-	byteStart, byteEnd := RuneIndexRange(fullText, runePos, runePos+runeCount)
-	if byteStart < 0 || byteEnd < 0 {
-		return errors.New("invalid rune range")
-	}
+	byteStart := gb.runeOffset(runePos)
+	byteEnd := gb.runeOffset(min(runePos+runeCount, runes))
 
 	// 调用字节位置的删除方法
 	return gb.DeleteAt(byteStart, byteEnd-byteStart)
 }
 
-// GetText returns the text in the buffer
+// GetText returns the text in the buffer, without invalid UTF-8 unless the
+// buffer is in binary mode, see SetBinary. Large buffers are better read
+// in ranges or streamed, see SetFullReadPolicy.
 func (gb *GapBuffer) GetText() string {
+	gb.checkFullRead()
+	return gb.text()
+}
+
+// text returns the text in the buffer
+func (gb *GapBuffer) text() string {
 	// Approximate the buffer size to avoid frequent reallocations
 	resultCapacity := gb.length + 100
 	if resultCapacity > 100*1024*1024 { // Cap at 100MB to avoid excessive allocation
@@ -178,180 +231,383 @@ func (gb *GapBuffer) GetText() string {
 	// Use a byte buffer for better performance with large strings
 	result := make([]byte, 0, resultCapacity)
 
-	gb.tree.InOrderTraversal(func(key int, value interface{}) {
-		chunk := value.(*Chunk)
+	gb.tree.ascend(math.MinInt, math.MaxInt, func(key int, chunk *payload, _ measures) bool {
 		if key < gb.gapStart || key >= gb.gapEnd {
 			result = append(result, chunk.Text...)
 		}
+		return true
 	})
 
+	if gb.binary {
+		return string(result)
+	}
+
 	// 确保返回的是有效的UTF-8字符串
 	return EnsureValidUTF8(string(result))
 }
 
-// GetTextRange returns the text in the specified range
-func (gb *GapBuffer) GetTextRange(start int, end int) (string, error) {
+// GetTextRange returns the text in the specified range. Recent results are
+// cached until the next edit, see SetRangeCacheSize.
+func (gb *GapBuffer) GetTextRange(start int, end int) (text string, err error) {
+	defer guard("GetTextRange", start, gb.length, &err)
+
 	if start < 0 || end > gb.length || start > end {
-		return "", errors.New("invalid range")
+		return "", ErrInvalidRange
+	}
+
+	if text, ok := gb.rangeCache.get(gb.version, start, end); ok {
+		return text, nil
 	}
 
-	length := end - start
-	result := make([]byte, 0, length)
+	// 确保返回的是有效的UTF-8字符串
+	text = gb.rawText(start, end)
+	if !gb.binary {
+		text = EnsureValidUTF8(text)
+	}
+	gb.rangeCache.put(gb.version, start, end, text)
+	return text, nil
+}
 
-	// Convert logical positions to physical positions
-	physicalStart := start
-	physicalEnd := end
-	if physicalStart >= gb.gapStart {
-		physicalStart += (gb.gapEnd - gb.gapStart)
+// rawText returns the bytes in [start, end) exactly as stored
+func (gb *GapBuffer) rawText(start int, end int) string {
+	if start >= end {
+		return ""
 	}
-	if physicalEnd >= gb.gapStart {
-		physicalEnd += (gb.gapEnd - gb.gapStart)
+	result := make([]byte, 0, end-start)
+	gb.forEachChunk(start, end, func(pos int, text string) bool {
+		result = append(result, text...)
+		return true
+	})
+	return string(result)
+}
+
+// forEachChunk calls fn with the logical position and text of every chunk piece
+// overlapping [start, end), trimmed to the range, until fn returns false
+func (gb *GapBuffer) forEachChunk(start int, end int, fn func(pos int, text string) bool) {
+	if start >= end {
+		return
 	}
 
-	// Find all chunks in the range and add their text to the result
-	gb.tree.InOrderTraversal(func(key int, value interface{}) {
-		chunk := value.(*Chunk)
-		adjustedKey := key
-		chunkText := chunk.Text
-		chunkLen := len(chunkText)
+	// Begin with the chunk containing start, which may begin before it
+	from := gb.physical(start)
+	if key, _, ok := gb.tree.floor(from); ok {
+		from = key
+	}
 
-		// Skip the gap
-		if key >= gb.gapStart && key < gb.gapEnd {
-			return
-		}
+	gb.tree.ascend(from, gb.physical(end-1)+1, func(key int, chunk *payload, _ measures) bool {
+		pos := gb.logical(key)
+		text := chunk.Text
 
-		// Adjust key for logical position calculation
-		if key >= gb.gapEnd {
-			adjustedKey = key - (gb.gapEnd - gb.gapStart)
+		// Trim the chunk to the requested range
+		if pos < start {
+			if start-pos >= len(text) {
+				return true
+			}
+			text = text[start-pos:]
+			pos = start
 		}
-
-		// Check if this chunk is in our range
-		if adjustedKey+chunkLen <= start || adjustedKey >= end {
-			return
+		if pos+len(text) > end {
+			text = text[:end-pos]
 		}
+		return fn(pos, text)
+	})
+}
 
-		// Calculate the intersection of the chunk and our range
-		chunkStart := 0
-		if adjustedKey < start {
-			chunkStart = start - adjustedKey
+// forEachChunkReverse is like forEachChunk but visits the pieces from the end
+// of the range towards its start
+func (gb *GapBuffer) forEachChunkReverse(start int, end int, fn func(pos int, text string) bool) {
+	if start >= end {
+		return
+	}
+
+	gb.tree.descend(gb.physical(end-1), func(key int, chunk *payload) bool {
+		pos := gb.logical(key)
+		text := chunk.Text
+		if pos+len(text) <= start {
+			return false
 		}
 
-		chunkEnd := chunkLen
-		if adjustedKey+chunkLen > end {
-			chunkEnd = end - adjustedKey
+		// Trim the chunk to the requested range
+		if pos+len(text) > end {
+			text = text[:end-pos]
 		}
+		if pos < start {
+			text = text[start-pos:]
+			pos = start
+		}
+		return fn(pos, text)
+	})
+}
 
-		// Add the relevant portion of the chunk text to our result
-		if chunkStart < chunkEnd {
-			// Ensure we don't break UTF-8 sequences at the start
-			for chunkStart < chunkEnd && !utf8.RuneStart(chunkText[chunkStart]) {
-				chunkStart++
-			}
+// GetRuneTextRange 获取指定Unicode字符范围的文本
+func (gb *GapBuffer) GetRuneTextRange(runeStart int, runeEnd int) (text string, err error) {
+	defer guard("GetRuneTextRange", runeStart, gb.length, &err)
 
-			// Ensure we don't break UTF-8 sequences at the end
-			tmpEnd := chunkEnd
-			for tmpEnd > chunkStart {
-				runeLen := 1
-				for i := 1; i < utf8.UTFMax && tmpEnd-i >= chunkStart; i++ {
-					if utf8.RuneStart(chunkText[tmpEnd-i]) {
-						runeLen = i
-						break
-					}
-				}
-
-				if tmpEnd-runeLen >= chunkStart {
-					valid := true
-					for i := 0; i < runeLen; i++ {
-						if !utf8.ValidRune(rune(chunkText[tmpEnd-runeLen+i])) {
-							valid = false
-							break
-						}
-					}
-
-					if valid {
-						break
-					}
-				}
-
-				tmpEnd--
-			}
+	byteStart, byteEnd, err := gb.runeRange(runeStart, runeEnd)
+	if err != nil {
+		return "", err
+	}
 
-			chunkEnd = tmpEnd
+	// 调用字节位置的范围获取方法
+	return gb.GetTextRange(byteStart, byteEnd)
+}
 
-			// Add the valid portion to result
-			if chunkStart < chunkEnd {
-				result = append(result, chunkText[chunkStart:chunkEnd]...)
-			}
+// Replace replaces the text in the specified range
+func (gb *GapBuffer) Replace(start int, end int, text string) (err error) {
+	defer guard("Replace", start, gb.length, &err)
+
+	if start < 0 || end > gb.length || start > end {
+		return ErrInvalidRange
+	}
+
+	return gb.replace(start, end, text)
+}
+
+// ReplaceRune 替换指定Unicode字符范围的文本
+func (gb *GapBuffer) ReplaceRune(runeStart int, runeEnd int, text string) (err error) {
+	defer guard("ReplaceRune", runeStart, gb.length, &err)
+
+	byteStart, byteEnd, err := gb.runeRange(runeStart, runeEnd)
+	if err != nil {
+		return err
+	}
+
+	// 调用字节位置的替换方法
+	return gb.Replace(byteStart, byteEnd, text)
+}
+
+// replace is the single path every mutation goes through: it replaces
+// [start, end) with text and lets the position-tracking subsystems catch up.
+// The range has already been validated by the caller.
+func (gb *GapBuffer) replace(start int, end int, text string) error {
+	if gb.inputNorm != NormNone && !gb.history.replaying {
+		start, end, text = gb.normalizeInput(start, end, text)
+	}
+	if !gb.recordNoOps && gb.isNoOp(start, end, text) {
+		return nil
+	}
+	if gb.views > 0 {
+		return ErrViewActive
+	}
+	if err := gb.checkLimits([]Edit{{Start: start, End: end, Text: text}}); err != nil {
+		return err
+	}
+	if gb.suggesting {
+		_, err := gb.addSuggestion(start, end, text)
+		return err
+	}
+	if err := gb.checkHooks([]Edit{{Start: start, End: end, Text: text}}); err != nil {
+		return err
+	}
+	if err := gb.chargeQuota(len(text) - (end - start)); err != nil {
+		return err
+	}
+
+	if gb.frozen {
+		gb.thaw()
+	}
+	deleted := gb.rawText(start, end)
+	gb.rawDelete(start, end-start)
+	gb.rawInsert(start, text)
+	op, logged := gb.recordOperation(start, deleted, text)
+	gb.trackEdit(op)
+	gb.didEdit(op, logged)
+	return nil
+}
+
+// trackEdit lets everything that tracks positions catch up with an applied
+// operation. This runs code of the caller, such as the LineClassifier of
+// the outline, so it may panic; the edit is then taken back before the
+// panic reaches guard, and the buffer is left as it was before the call.
+func (gb *GapBuffer) trackEdit(op Operation) {
+	steps := []func(Operation){
+		gb.adjustSuggestions,
+		gb.updateOutline,
+		gb.updateProse,
+		gb.updateWords,
+		gb.invalidateGuides,
+		gb.updateLineIDs,
+		gb.updateLineSums,
+		gb.shiftRangeSets,
+		gb.shiftMarkers,
+		gb.updateOrigins,
+	}
+	done := 0
+	defer func() {
+		// Only a panic leaves steps to do
+		if done < len(steps) {
+			gb.takeBack(op, steps[:done])
 		}
-	})
+	}()
 
-	// 确保返回的是有效的UTF-8字符串
-	return EnsureValidUTF8(string(result)), nil
+	gb.checkTree()
+	for _, step := range steps {
+		step(op)
+		done++
+	}
 }
 
-// GetRuneTextRange 获取指定Unicode字符范围的文本
-func (gb *GapBuffer) GetRuneTextRange(runeStart int, runeEnd int) (string, error) {
-	if runeStart < 0 || runeStart > runeEnd {
-		return "", errors.New("invalid rune range")
+// takeBack reverts the text, version and quota of an operation that failed
+// in trackEdit and hands its inverse to the steps that had already seen it,
+// as undo would. The step that panicked is expected to have left its state
+// untouched, which holds for every step updating its state last.
+func (gb *GapBuffer) takeBack(op Operation, done []func(Operation)) {
+	gb.rawDelete(op.Pos, op.Inserted)
+	gb.rawInsert(op.Pos, op.DeletedText)
+	gb.chargeQuota(op.Deleted - op.Inserted)
+
+	last := len(gb.oplog.ops) - 1
+	logged := gb.oplog.ops[last]
+	gb.oplog.ops = gb.oplog.ops[:last]
+	gb.releaseOperations([]Operation{logged})
+	gb.version--
+	// Ranges cached during the edit are of the taken back text
+	gb.rangeCache.entries = gb.rangeCache.entries[:0]
+	gb.blame = blameCache{}
+
+	inverse := op
+	inverse.Version = gb.version
+	inverse.Deleted, inverse.Inserted = op.Inserted, op.Deleted
+	inverse.DeletedText, inverse.InsertedText = op.InsertedText, op.DeletedText
+	for _, step := range done {
+		step(inverse)
 	}
+}
 
-	// 获取完整文本以计算字节位置
-	fullText := gb.GetText()
+// didEdit records an applied operation once everything tracks it, and tells
+// the listeners about it. The history keeps the logged copy, so large
+// deleted text is not held in memory twice.
+func (gb *GapBuffer) didEdit(op Operation, logged Operation) {
+	gb.recordVersion(op)
+	gb.recordHistory(logged)
+	gb.recordTransaction(op)
+	gb.publishFeeds(op)
+	gb.notifySubscribers(op)
+	gb.NotifyInput()
+}
 
-	// 确保runeEnd不超出范围
-	runeCount := RuneCount(fullText)
-	if runeEnd > runeCount {
-		runeEnd = runeCount
+// rawInsert stores text at pos without notifying anything about the edit
+func (gb *GapBuffer) rawInsert(pos int, text string) {
+	if len(text) == 0 {
+		return
 	}
+	gb.ownTree()
 
-	// 计算开始和结束的字节位置
-	byteStart, byteEnd := RuneIndexRange(fullText, runeStart, runeEnd)
-	if byteStart < 0 || byteEnd < 0 {
-		return "", errors.New("invalid rune range")
+	// Move gap to insertion point if needed
+	if pos != gb.gapStart {
+		gb.moveGap(pos)
 	}
 
-	// 调用字节位置的范围获取方法
-	return gb.GetTextRange(byteStart, byteEnd)
+	// If gap is too small, expand it
+	if len(text) > gb.gapEnd-gb.gapStart {
+		gb.expandGap(len(text))
+	}
+
+	// Small inserts extend the chunk right before the gap instead of adding a new node
+	if key, chunk, ok := gb.tree.floor(gb.gapStart - 1); ok {
+		if key+len(chunk.Text) == gb.gapStart && len(chunk.Text)+len(text) <= gb.chunkSize {
+			gb.tree.set(key, payload{Text: chunk.Text + text})
+			gb.gapStart += len(text)
+			gb.length += len(text)
+			return
+		}
+	}
+
+	// Insert text into gap in chunks, ensuring we don't break Unicode characters
+	for i := 0; i < len(text); {
+		end := chunkEnd(text, i, gb.chunkSize)
+
+		gb.tree.add(gb.gapStart, payload{Text: text[i:end]})
+		gb.gapStart += end - i
+
+		i = end
+	}
+
+	// Update length
+	gb.length += len(text)
 }
 
-// Replace replaces the text in the specified range
-func (gb *GapBuffer) Replace(start int, end int, text string) error {
-	if start < 0 || end > gb.length || start > end {
-		return errors.New("invalid range")
+// chunkEnd returns the end of the chunk of at most size bytes starting at i,
+// moved back so that it does not split a UTF-8 sequence when possible
+func chunkEnd(text string, i, size int) int {
+	end := i + size
+	if end >= len(text) {
+		return len(text)
 	}
 
-	// Delete the range
-	if err := gb.DeleteAt(start, end-start); err != nil {
-		return err
+	// Ensure we don't break a UTF-8 sequence
+	for end > i && !utf8.RuneStart(text[end]) {
+		end--
 	}
 
-	// Insert the new text
-	return gb.InsertAt(start, text)
+	// No rune start within the chunk, the text is not valid UTF-8 here
+	if end == i {
+		end = i + size
+	}
+	return end
 }
 
-// ReplaceRune 替换指定Unicode字符范围的文本
-func (gb *GapBuffer) ReplaceRune(runeStart int, runeEnd int, text string) error {
-	if runeStart < 0 || runeStart > runeEnd {
-		return errors.New("invalid rune range")
+// rawDelete removes count bytes at pos without notifying anything about the edit
+func (gb *GapBuffer) rawDelete(pos int, count int) {
+	if count == 0 {
+		return
+	}
+	gb.ownTree()
+
+	// Move gap to deletion point if needed
+	if pos != gb.gapStart {
+		gb.moveGap(pos)
 	}
 
-	// 获取完整文本以计算字节位置
-	fullText := gb.GetText()
+	// Make sure no chunk straddles the end of the deleted range
+	gb.splitAt(gb.gapEnd + count)
 
-	// 确保runeEnd不超出范围
-	runeCount := RuneCount(fullText)
-	if runeEnd > runeCount {
-		runeEnd = runeCount
+	// Delete all chunks that now lie entirely in the deleted range
+	var keys []int
+	gb.tree.ascend(gb.gapEnd, gb.gapEnd+count, func(key int, _ *payload, _ measures) bool {
+		keys = append(keys, key)
+		return true
+	})
+	for _, key := range keys {
+		gb.tree.Delete(key)
 	}
 
-	// 计算开始和结束的字节位置
-	byteStart, byteEnd := RuneIndexRange(fullText, runeStart, runeEnd)
-	if byteStart < 0 || byteEnd < 0 {
-		return errors.New("invalid rune range")
+	// Update gap and length
+	gb.gapEnd += count
+	gb.length -= count
+}
+
+// physical converts a logical position into a key in the tree
+func (gb *GapBuffer) physical(pos int) int {
+	if pos < gb.gapStart {
+		return pos
 	}
+	return pos + gb.gapEnd - gb.gapStart
+}
 
-	// 调用字节位置的替换方法
-	return gb.Replace(byteStart, byteEnd, text)
+// logical converts a key in the tree into a logical position
+func (gb *GapBuffer) logical(key int) int {
+	if key < gb.gapEnd {
+		return key
+	}
+	return key - (gb.gapEnd - gb.gapStart)
+}
+
+// splitAt splits the chunk spanning the given key so that a chunk starts there
+func (gb *GapBuffer) splitAt(key int) {
+	at, chunk, ok := gb.tree.floor(key)
+	if !ok || at == key {
+		return
+	}
+
+	text := chunk.Text
+	offset := key - at
+	if offset >= len(text) {
+		return
+	}
+
+	gb.tree.set(at, payload{Text: text[:offset]})
+	gb.tree.add(key, payload{Text: text[offset:]})
 }
 
 // moveGap moves the gap to the specified position
@@ -360,52 +616,21 @@ func (gb *GapBuffer) moveGap(pos int) {
 		return
 	}
 
-	// Collect nodes that need to be moved
-	var nodesToMove []*Node
+	gapSize := gb.gapEnd - gb.gapStart
 
 	if pos < gb.gapStart {
-		// Move gap left
-		gb.tree.InOrderTraversal(func(key int, value interface{}) {
-			if key >= pos && key < gb.gapStart {
-				nodesToMove = append(nodesToMove, gb.tree.Search(key))
-			}
-		})
-
-		// Process nodes from right to left to maintain order
-		for i := len(nodesToMove) - 1; i >= 0; i-- {
-			node := nodesToMove[i]
-			chunk := node.Value.(*Chunk)
-			gb.tree.Delete(node.Key)
-			newKey := gb.gapEnd - len(nodesToMove) + i
-			gb.tree.Insert(newKey, chunk)
-		}
-
-		// Update gap boundaries
-		gapSize := gb.gapEnd - gb.gapStart
-		gb.gapEnd = pos + gapSize
-		gb.gapStart = pos
-
+		// Move gap left: chunks in [pos, gapStart) move to the other side of the gap
+		gb.splitAt(pos)
+		gb.tree.shift(pos, gb.gapStart, gapSize)
 	} else {
-		// Move gap right
-		gb.tree.InOrderTraversal(func(key int, value interface{}) {
-			if key >= gb.gapEnd && key < gb.gapEnd+(pos-gb.gapStart) {
-				nodesToMove = append(nodesToMove, gb.tree.Search(key))
-			}
-		})
-
-		// Process nodes from left to right to maintain order
-		for i, node := range nodesToMove {
-			chunk := node.Value.(*Chunk)
-			gb.tree.Delete(node.Key)
-			newKey := gb.gapStart + i
-			gb.tree.Insert(newKey, chunk)
-		}
-
-		// Update gap boundaries
-		gapSize := gb.gapEnd - gb.gapStart
-		gb.gapStart = pos
-		gb.gapEnd = pos + gapSize
+		// Move gap right: chunks in [gapEnd, pos+gapSize) move to the other side of the gap
+		gb.splitAt(pos + gapSize)
+		gb.tree.shift(gb.gapEnd, pos+gapSize, -gapSize)
 	}
+
+	// Update gap boundaries
+	gb.gapStart = pos
+	gb.gapEnd = pos + gapSize
 }
 
 // expandGap expands the gap to accommodate more text
@@ -415,42 +640,30 @@ func (gb *GapBuffer) expandGap(minSize int) {
 		return
 	}
 
-	// Calculate new gap size (double the current size or requested size, whichever is larger)
-	newGapSize := currentGapSize * 2
-	if newGapSize < minSize {
-		newGapSize = minSize
-	}
-
-	// We need to expand by this much
-	expandBy := newGapSize - currentGapSize
+	// Calculate new gap size (double the current size or requested size,
+	// whichever is larger, and at least the default so that a gap used up
+	// does not grow byte by byte, shifting every key each time)
+	newGapSize := max(currentGapSize*2, minSize, DEFAULT_GAP_SIZE)
 
 	// Shift all nodes after the gap
-	var nodesToMove []*Node
-	gb.tree.InOrderTraversal(func(key int, value interface{}) {
-		if key >= gb.gapEnd {
-			nodesToMove = append(nodesToMove, gb.tree.Search(key))
-		}
-	})
-
-	// Delete and reinsert nodes with new positions
-	for _, node := range nodesToMove {
-		key := node.Key
-		value := node.Value
-		gb.tree.Delete(key)
-		gb.tree.Insert(key+expandBy, value)
-	}
+	expandBy := newGapSize - currentGapSize
+	gb.tree.shift(gb.gapEnd, gb.gapEnd+gb.length-gb.gapStart, expandBy)
 
 	gb.gapEnd += expandBy
 }
 
-// Length returns the length of the text in the buffer
+// Length returns the length of the text in the buffer. The end of the
+// buffer is a position like any other: text can be inserted and markers
+// and cursors placed there, ranges may end there, and it maps to the last
+// line, which is empty when the text ends with a newline. Deletions
+// starting there fail with ErrDeleteAtEnd, since no text follows.
 func (gb *GapBuffer) Length() int {
 	return gb.length
 }
 
 // RuneLength 返回缓冲区中Unicode字符的数量
 func (gb *GapBuffer) RuneLength() int {
-	return RuneCount(gb.GetText())
+	return gb.tree.total().runes
 }
 
 // GapLength returns the current gap length
@@ -0,0 +1,260 @@
+//go:build ignore
+
+// gengrapheme writes graphemetables.go, the Grapheme_Cluster_Break,
+// Extended_Pictographic and Indic_Conjunct_Break data grapheme.go uses.
+// Grapheme_Cluster_Break is derived from the unicode package the way
+// GraphemeBreakProperty.txt is, following UAX #29; the few inputs the
+// unicode package lacks are listed below from the UCD.
+//
+//	go run gengrapheme.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"unicode"
+)
+
+// Grapheme_Cluster_Break classes, as in grapheme.go
+const (
+	gcbOther = iota
+	gcbCR
+	gcbLF
+	gcbControl
+	gcbExtend
+	gcbZWJ
+	gcbRegionalIndicator
+	gcbPrepend
+	gcbSpacingMark
+	gcbL
+	gcbV
+	gcbT
+	gcbLV
+	gcbLVT
+)
+
+var classNames = []string{
+	"gcbOther", "gcbCR", "gcbLF", "gcbControl", "gcbExtend", "gcbZWJ",
+	"gcbRegionalIndicator", "gcbPrepend", "gcbSpacingMark",
+	"gcbL", "gcbV", "gcbT", "gcbLV", "gcbLVT",
+}
+
+// Indic_Conjunct_Break values, as in grapheme.go
+const (
+	incbNone = iota
+	incbConsonant
+	incbLinker
+)
+
+var incbNames = []string{"incbNone", "incbConsonant", "incbLinker"}
+
+// Hangul_Syllable_Type L, V and T, from HangulSyllableType.txt; LV and LVT
+// follow from the syllable arithmetic
+var (
+	hangulL = [][2]rune{{0x1100, 0x115F}, {0xA960, 0xA97C}}
+	hangulV = [][2]rune{{0x1160, 0x11A7}, {0xD7B0, 0xD7C6}}
+	hangulT = [][2]rune{{0x11A8, 0x11FF}, {0xD7CB, 0xD7FB}}
+)
+
+// Indic_Syllabic_Category Consonant_Preceding_Repha and Consonant_Prefixed,
+// from IndicSyllabicCategory.txt, which are Prepend
+var prependISC = [][2]rune{
+	{0x0D4E, 0x0D4E}, {0x111C2, 0x111C3}, {0x1193F, 0x1193F}, {0x11941, 0x11941},
+	{0x11A3A, 0x11A3A}, {0x11A84, 0x11A89}, {0x11D46, 0x11D46}, {0x11F02, 0x11F02},
+	{0x113D1, 0x113D1},
+}
+
+// Spacing marks UAX #29 leaves out of SpacingMark
+var spacingMarkExceptions = [][2]rune{
+	{0x102B, 0x102C}, {0x1038, 0x1038}, {0x1062, 0x1064}, {0x1067, 0x106D},
+	{0x1083, 0x1083}, {0x1087, 0x108C}, {0x108F, 0x108F}, {0x109A, 0x109C},
+	{0x1A61, 0x1A61}, {0x1A63, 0x1A64}, {0xAA7B, 0xAA7B}, {0xAA7D, 0xAA7D},
+	{0x11720, 0x11721},
+}
+
+// Emoji_Modifier, from emoji-data.txt
+var emojiModifier = [][2]rune{{0x1F3FB, 0x1F3FF}}
+
+// Extended_Pictographic, from emoji-data.txt. The property covers reserved
+// code points in the emoji blocks, so it does not change as emoji are added.
+var extendedPictographic = [][2]rune{
+	{0x00A9, 0x00A9}, {0x00AE, 0x00AE}, {0x203C, 0x203C}, {0x2049, 0x2049},
+	{0x2122, 0x2122}, {0x2139, 0x2139}, {0x2194, 0x2199}, {0x21A9, 0x21AA},
+	{0x231A, 0x231B}, {0x2328, 0x2328}, {0x2388, 0x2388}, {0x23CF, 0x23CF},
+	{0x23E9, 0x23F3}, {0x23F8, 0x23FA}, {0x24C2, 0x24C2}, {0x25AA, 0x25AB},
+	{0x25B6, 0x25B6}, {0x25C0, 0x25C0}, {0x25FB, 0x25FE}, {0x2600, 0x2605},
+	{0x2607, 0x2612}, {0x2614, 0x2685}, {0x2690, 0x2705}, {0x2708, 0x2712},
+	{0x2714, 0x2714}, {0x2716, 0x2716}, {0x271D, 0x271D}, {0x2721, 0x2721},
+	{0x2728, 0x2728}, {0x2733, 0x2734}, {0x2744, 0x2744}, {0x2747, 0x2747},
+	{0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755}, {0x2757, 0x2757},
+	{0x2763, 0x2767}, {0x2795, 0x2797}, {0x27A1, 0x27A1}, {0x27B0, 0x27B0},
+	{0x27BF, 0x27BF}, {0x2934, 0x2935}, {0x2B05, 0x2B07}, {0x2B1B, 0x2B1C},
+	{0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x3030, 0x3030}, {0x303D, 0x303D},
+	{0x3297, 0x3297}, {0x3299, 0x3299}, {0x1F000, 0x1F0FF}, {0x1F10D, 0x1F10F},
+	{0x1F12F, 0x1F12F}, {0x1F16C, 0x1F171}, {0x1F17E, 0x1F17F}, {0x1F18E, 0x1F18E},
+	{0x1F191, 0x1F19A}, {0x1F1AD, 0x1F1E5}, {0x1F201, 0x1F20F}, {0x1F21A, 0x1F21A},
+	{0x1F22F, 0x1F22F}, {0x1F232, 0x1F23A}, {0x1F23C, 0x1F23F}, {0x1F249, 0x1F3FA},
+	{0x1F400, 0x1F53D}, {0x1F546, 0x1F64F}, {0x1F680, 0x1F6FF}, {0x1F774, 0x1F77F},
+	{0x1F7D5, 0x1F7FF}, {0x1F80C, 0x1F80F}, {0x1F848, 0x1F84F}, {0x1F85A, 0x1F85F},
+	{0x1F888, 0x1F88F}, {0x1F8AE, 0x1F8FF}, {0x1F90C, 0x1F93A}, {0x1F93C, 0x1F945},
+	{0x1F947, 0x1FAFF}, {0x1FC00, 0x1FFFD},
+}
+
+// Indic_Conjunct_Break Linker and Consonant, from DerivedCoreProperties.txt
+// as introduced with rule GB9c
+var (
+	incbLinkers = [][2]rune{
+		{0x094D, 0x094D}, {0x09CD, 0x09CD}, {0x0ACD, 0x0ACD}, {0x0B4D, 0x0B4D},
+		{0x0C4D, 0x0C4D}, {0x0D4D, 0x0D4D},
+	}
+	incbConsonants = [][2]rune{
+		{0x0915, 0x0939}, {0x0958, 0x095F}, {0x0978, 0x097F}, {0x0995, 0x09A8},
+		{0x09AA, 0x09B0}, {0x09B2, 0x09B2}, {0x09B6, 0x09B9}, {0x09DC, 0x09DD},
+		{0x09DF, 0x09DF}, {0x09F0, 0x09F1}, {0x0A95, 0x0AA8}, {0x0AAA, 0x0AB0},
+		{0x0AB2, 0x0AB3}, {0x0AB5, 0x0AB9}, {0x0AF9, 0x0AF9}, {0x0B15, 0x0B28},
+		{0x0B2A, 0x0B30}, {0x0B32, 0x0B33}, {0x0B35, 0x0B39}, {0x0B5C, 0x0B5D},
+		{0x0B5F, 0x0B5F}, {0x0B71, 0x0B71}, {0x0C15, 0x0C28}, {0x0C2A, 0x0C39},
+		{0x0C58, 0x0C5A}, {0x0D15, 0x0D3A},
+	}
+)
+
+func in(r rune, ranges [][2]rune) bool {
+	for _, rg := range ranges {
+		if r >= rg[0] && r <= rg[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// assigned reports whether r has a general category other than Cn
+func assigned(r rune) bool {
+	for _, t := range unicode.Categories {
+		if unicode.Is(t, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// classOf derives the Grapheme_Cluster_Break of r as UAX #29 defines it
+func classOf(r rune) int {
+	switch {
+	case r == '\r':
+		return gcbCR
+	case r == '\n':
+		return gcbLF
+	case r == 0x200D:
+		return gcbZWJ
+	case unicode.Is(unicode.Regional_Indicator, r):
+		return gcbRegionalIndicator
+	case in(r, hangulL):
+		return gcbL
+	case in(r, hangulV):
+		return gcbV
+	case in(r, hangulT):
+		return gcbT
+	case r >= 0xAC00 && r <= 0xD7A3:
+		if (r-0xAC00)%28 == 0 {
+			return gcbLV
+		}
+		return gcbLVT
+	case unicode.Is(unicode.Prepended_Concatenation_Mark, r), in(r, prependISC):
+		return gcbPrepend
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Other_Grapheme_Extend), in(r, emojiModifier):
+		return gcbExtend
+	case unicode.In(r, unicode.Zl, unicode.Zp, unicode.Cc, unicode.Cf),
+		!assigned(r) && unicode.Is(unicode.Other_Default_Ignorable_Code_Point, r):
+		return gcbControl
+	case unicode.Is(unicode.Mc, r) && !in(r, spacingMarkExceptions), r == 0x0E33, r == 0x0EB3:
+		return gcbSpacingMark
+	}
+	return gcbOther
+}
+
+// ranges returns the runs of runes with the same nonzero value of f
+func ranges(f func(r rune) int) [][3]rune {
+	var out [][3]rune
+	for r := rune(0); r <= unicode.MaxRune; r++ {
+		v := f(r)
+		if v == 0 {
+			continue
+		}
+		if n := len(out); n > 0 && out[n-1][1] == r-1 && out[n-1][2] == rune(v) {
+			out[n-1][1] = r
+			continue
+		}
+		out = append(out, [3]rune{r, r, rune(v)})
+	}
+	return out
+}
+
+// writeTable writes table as a slice of typ, a few ranges to a line
+func writeTable(b *bytes.Buffer, doc string, name string, typ string, table [][3]rune, names []string) {
+	fmt.Fprintf(b, "%s\nvar %s = []%s{", doc, name, typ)
+	for i, rg := range table {
+		if i%3 == 0 {
+			b.WriteString("\n\t")
+		} else {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(b, "{0x%04X, 0x%04X, %s},", rg[0], rg[1], names[rg[2]])
+	}
+	b.WriteString("\n}\n\n")
+}
+
+func main() {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `// Code generated by gengrapheme.go; DO NOT EDIT.
+
+package buffer
+
+// Grapheme cluster data of Unicode %s, derived as UAX #29 describes from
+// the unicode package and the UCD lists in gengrapheme.go
+
+`, unicode.Version)
+
+	writeTable(&b,
+		"// graphemeClasses are the runes whose Grapheme_Cluster_Break is not Other,\n// as first rune, last rune and class, sorted",
+		"graphemeClasses", "graphemeRange", ranges(classOf), classNames)
+
+	pict := ranges(func(r rune) int {
+		if in(r, extendedPictographic) {
+			return 1
+		}
+		return 0
+	})
+	b.WriteString("// pictographicRanges are the Extended_Pictographic runes, as first and\n// last rune, sorted\nvar pictographicRanges = [][2]rune{")
+	for i, rg := range pict {
+		if i%4 == 0 {
+			b.WriteString("\n\t")
+		} else {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "{0x%04X, 0x%04X},", rg[0], rg[1])
+	}
+	b.WriteString("\n}\n\n")
+
+	writeTable(&b,
+		"// conjunctClasses are the Indic_Conjunct_Break Consonant and Linker runes,\n// as first rune, last rune and value, sorted. Extend is every other rune\n// of class gcbExtend or gcbZWJ.",
+		"conjunctClasses", "conjunctRange", ranges(func(r rune) int {
+			switch {
+			case in(r, incbConsonants):
+				return incbConsonant
+			case in(r, incbLinkers):
+				return incbLinker
+			}
+			return incbNone
+		}), incbNames)
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("graphemetables.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
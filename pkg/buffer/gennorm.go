@@ -0,0 +1,147 @@
+//go:build ignore
+
+// gennorm writes normtables.go, the normalization data normalize.go uses,
+// from the tables of golang.org/x/text/unicode/norm. normalize.go does
+// not compose with that package itself: its composition looks pairs up by
+// the low 16 bits of the runes, so it composes a supplementary rune and a
+// mark into an unrelated letter ("\U00010041́" becomes "Á").
+// Composition is only used here on the decompositions of the composites,
+// whose pairs are checked against the decompositions before they are
+// written.
+//
+//	go run gennorm.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Hangul syllables, which normalize.go handles algorithmically
+const (
+	hangulFirst = 0xAC00
+	hangulLast  = 0xD7A3
+)
+
+// ccc returns the canonical combining class of r
+func ccc(r rune) uint8 {
+	return norm.NFD.PropertiesString(string(r)).CCC()
+}
+
+func main() {
+	var classes [][3]rune
+	var decompositions []rune
+	var compositions [][3]rune
+	starters := map[rune]bool{}
+
+	for r := rune(0); r <= utf8.MaxRune; r++ {
+		if !utf8.ValidRune(r) {
+			continue
+		}
+		if c := rune(ccc(r)); c != 0 {
+			if n := len(classes); n > 0 && classes[n-1][1] == r-1 && classes[n-1][2] == c {
+				classes[n-1][1] = r
+			} else {
+				classes = append(classes, [3]rune{r, r, c})
+			}
+		}
+		if r >= hangulFirst && r <= hangulLast {
+			continue
+		}
+		s := string(r)
+		d := norm.NFD.String(s)
+		if d == s {
+			continue
+		}
+		decompositions = append(decompositions, r)
+
+		// A primary composite is its own NFC. It composes from the NFC of
+		// its decomposition but for the last rune, and that rune.
+		if norm.NFC.String(s) != s {
+			continue
+		}
+		runes := []rune(d)
+		last := runes[len(runes)-1]
+		first := []rune(norm.NFC.String(string(runes[:len(runes)-1])))
+		if len(first) != 1 || norm.NFD.String(string(first[0])+string(last)) != d {
+			log.Fatalf("cannot find the pair %U composes from", r)
+		}
+		compositions = append(compositions, [3]rune{first[0], last, r})
+		if ccc(last) == 0 {
+			starters[last] = true
+		}
+	}
+	slices.SortFunc(compositions, func(a, b [3]rune) int {
+		if a[0] != b[0] {
+			return int(a[0] - b[0])
+		}
+		return int(a[1] - b[1])
+	})
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `// Code generated by gennorm.go; DO NOT EDIT.
+
+package buffer
+
+// Normalization data of Unicode %s, from golang.org/x/text/unicode/norm.
+// Hangul syllables are handled algorithmically.
+
+// cccRanges are the runes with a nonzero canonical combining class, as
+// first rune, last rune and class, sorted
+var cccRanges = [][3]rune{`, norm.Version)
+	for i, c := range classes {
+		sep(&b, i, 4)
+		fmt.Fprintf(&b, "{0x%04X, 0x%04X, %d},", c[0], c[1], c[2])
+	}
+
+	b.WriteString("\n}\n\n// decompositions are the full canonical decompositions, sorted\nvar decompositions = []struct {\n\tr rune\n\td string\n}{\n")
+	for _, r := range decompositions {
+		fmt.Fprintf(&b, "\t{0x%04X, \"", r)
+		for _, x := range norm.NFD.String(string(r)) {
+			if x > 0xFFFF {
+				fmt.Fprintf(&b, "\\U%08X", x)
+			} else {
+				fmt.Fprintf(&b, "\\u%04X", x)
+			}
+		}
+		b.WriteString("\"},\n")
+	}
+
+	b.WriteString("}\n\n// compositions are the primary composites by the pair they compose from,\n// sorted\nvar compositions = [][3]rune{")
+	for i, c := range compositions {
+		sep(&b, i, 4)
+		fmt.Fprintf(&b, "{0x%04X, 0x%04X, 0x%04X},", c[0], c[1], c[2])
+	}
+
+	b.WriteString("\n}\n\n// composingStarters are the runes of combining class 0 that compose with\n// the rune before them, sorted\nvar composingStarters = []rune{")
+	for i, r := range slices.Sorted(maps.Keys(starters)) {
+		sep(&b, i, 8)
+		fmt.Fprintf(&b, "0x%04X,", r)
+	}
+	b.WriteString("\n}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("normtables.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// sep starts the i-th entry of a table of n entries to a line
+func sep(b *bytes.Buffer, i int, n int) {
+	if i%n == 0 {
+		b.WriteString("\n\t")
+	} else {
+		b.WriteString(" ")
+	}
+}
@@ -0,0 +1,322 @@
+package buffer
+
+import (
+	"errors"
+	"iter"
+)
+
+//go:generate go run gengrapheme.go
+
+// Errors returned by the grapheme cluster API
+var (
+	ErrGraphemePositionOutOfRange = errors.New("grapheme position out of range")
+	ErrInvalidGraphemeCount       = errors.New("grapheme count must be positive")
+)
+
+// graphemeContext is how far back from a position a scan for cluster
+// boundaries starts when the line started earlier. Clusters longer than
+// this are split.
+const graphemeContext = 4096
+
+// GraphemeCount returns the number of user-perceived characters in the
+// buffer: extended grapheme clusters as defined by Unicode UAX #29, so an
+// emoji ZWJ sequence or a letter with combining marks counts once
+func (gb *GapBuffer) GraphemeCount() int {
+	n := -1 // the end of the text is a boundary too
+	gb.eachGraphemeBoundary(0, func(int) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// GraphemeBoundaries returns an iterator over the positions at or after
+// from where a grapheme cluster starts, followed by the end of the buffer.
+// Editing the buffer ends the iteration.
+func (gb *GapBuffer) GraphemeBoundaries(from int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		version := gb.version
+		gb.eachGraphemeBoundary(max(from, 0), func(pos int) bool {
+			return yield(pos) && gb.version == version
+		})
+	}
+}
+
+// DeleteGraphemeAt deletes count grapheme clusters starting at the
+// graphemePos-th one, or as many as there are
+func (gb *GapBuffer) DeleteGraphemeAt(graphemePos int, count int) (err error) {
+	defer guard("DeleteGraphemeAt", graphemePos, gb.length, &err)
+
+	if count <= 0 {
+		return ErrInvalidGraphemeCount
+	}
+	if graphemePos < 0 {
+		return ErrGraphemePositionOutOfRange
+	}
+
+	start, end, i := -1, gb.length, 0
+	gb.eachGraphemeBoundary(0, func(pos int) bool {
+		switch i {
+		case graphemePos:
+			start = pos
+		case graphemePos + count:
+			end = pos
+			return false
+		}
+		i++
+		return true
+	})
+	if start == gb.length {
+		return ErrDeleteAtEnd
+	}
+	if start < 0 {
+		return ErrGraphemePositionOutOfRange
+	}
+	return gb.replace(start, end, "")
+}
+
+// nextGrapheme returns the first grapheme cluster boundary after pos, or
+// the buffer length
+func (gb *GapBuffer) nextGrapheme(pos int) int {
+	next := gb.length
+	gb.eachGraphemeBoundary(pos+1, func(p int) bool {
+		next = p
+		return false
+	})
+	return next
+}
+
+// prevGrapheme returns the last grapheme cluster boundary before pos, or 0
+func (gb *GapBuffer) prevGrapheme(pos int) int {
+	prev := 0
+	gb.eachGraphemeBoundaryFrom(gb.graphemeScanStart(pos-1), 0, func(p int) bool {
+		if p >= pos {
+			return false
+		}
+		prev = p
+		return true
+	})
+	return prev
+}
+
+// eachGraphemeBoundary calls fn with every grapheme cluster boundary at or
+// after from, the end of the buffer included, until fn returns false
+func (gb *GapBuffer) eachGraphemeBoundary(from int, fn func(pos int) bool) {
+	if from > gb.length {
+		return
+	}
+	gb.eachGraphemeBoundaryFrom(gb.graphemeScanStart(from), from, fn)
+}
+
+// eachGraphemeBoundaryFrom is eachGraphemeBoundary scanning from start,
+// which is taken to be a boundary
+func (gb *GapBuffer) eachGraphemeBoundaryFrom(start int, from int, fn func(pos int) bool) {
+	var g graphemeBreaker
+	stopped := false
+	gb.scanRunes(start, gb.length, func(pos int, r rune, _ int) bool {
+		if g.next(r) && pos >= from && !fn(pos) {
+			stopped = true
+			return false
+		}
+		return true
+	})
+	if !stopped {
+		fn(gb.length)
+	}
+}
+
+// graphemeScanStart returns a position before pos where no cluster
+// continues from earlier text: the start of its line, or at most
+// graphemeContext bytes back for long lines
+func (gb *GapBuffer) graphemeScanStart(pos int) int {
+	pos = min(max(pos, 0), gb.length)
+	start := gb.lineStart(pos)
+	if pos-start > graphemeContext {
+		start = pos - graphemeContext
+		for start > 0 && !gb.runeStart(start) {
+			start--
+		}
+	}
+	return start
+}
+
+// graphemeClass is the Grapheme_Cluster_Break property of a rune
+type graphemeClass uint8
+
+const (
+	gcbOther graphemeClass = iota
+	gcbCR
+	gcbLF
+	gcbControl
+	gcbExtend
+	gcbZWJ
+	gcbRegionalIndicator
+	gcbPrepend
+	gcbSpacingMark
+	gcbL
+	gcbV
+	gcbT
+	gcbLV
+	gcbLVT
+)
+
+// graphemeBreaker applies the UAX #29 rules to a text fed rune by rune
+type graphemeBreaker struct {
+	started bool
+	prev    graphemeClass
+	ri      int  // regional indicators right before the current rune
+	emoji   bool // the runes before are a pictograph followed by extenders
+
+	// The runes before are a conjunct consonant followed by extenders and
+	// linkers, and linked says a linker was among them
+	consonant bool
+	linked    bool
+}
+
+// next reports whether a cluster starts at r, the next rune of the text.
+// The first rune always starts one.
+func (g *graphemeBreaker) next(r rune) bool {
+	c := graphemeClassOf(r)
+	pictograph := isPictographic(r)
+	conjunct := conjunctClassOf(r)
+	brk := !g.started || g.breaks(c, pictograph, conjunct)
+
+	switch {
+	case pictograph:
+		g.emoji = true
+	case c == gcbExtend && g.prev != gcbZWJ, c == gcbZWJ:
+	default:
+		g.emoji = false
+	}
+	switch {
+	case conjunct == incbConsonant:
+		g.consonant, g.linked = true, false
+	case conjunct == incbLinker:
+		g.linked = g.consonant
+	case c != gcbExtend && c != gcbZWJ:
+		g.consonant, g.linked = false, false
+	}
+	if c == gcbRegionalIndicator {
+		g.ri++
+	} else {
+		g.ri = 0
+	}
+	g.started = true
+	g.prev = c
+	return brk
+}
+
+// breaks reports whether the rules put a boundary between the previous rune
+// and one of class c
+func (g *graphemeBreaker) breaks(c graphemeClass, pictograph bool, conjunct conjunctClass) bool {
+	p := g.prev
+	switch {
+	case p == gcbCR && c == gcbLF: // GB3
+		return false
+	case p == gcbCR || p == gcbLF || p == gcbControl: // GB4
+		return true
+	case c == gcbCR || c == gcbLF || c == gcbControl: // GB5
+		return true
+	case p == gcbL && (c == gcbL || c == gcbV || c == gcbLV || c == gcbLVT): // GB6
+		return false
+	case (p == gcbLV || p == gcbV) && (c == gcbV || c == gcbT): // GB7
+		return false
+	case (p == gcbLVT || p == gcbT) && c == gcbT: // GB8
+		return false
+	case c == gcbExtend || c == gcbZWJ || c == gcbSpacingMark: // GB9, GB9a
+		return false
+	case p == gcbPrepend: // GB9b
+		return false
+	case g.linked && conjunct == incbConsonant: // GB9c
+		return false
+	case p == gcbZWJ && g.emoji && pictograph: // GB11
+		return false
+	case p == gcbRegionalIndicator && c == gcbRegionalIndicator: // GB12, GB13
+		return g.ri%2 == 0
+	}
+	return true
+}
+
+// graphemeRange gives the runes first to last a Grapheme_Cluster_Break
+// class
+type graphemeRange struct {
+	first, last rune
+	class       graphemeClass
+}
+
+// conjunctClass is the Indic_Conjunct_Break property of a rune, which rule
+// GB9c uses to keep conjuncts such as क्ष together
+type conjunctClass uint8
+
+const (
+	incbNone conjunctClass = iota
+	incbConsonant
+	incbLinker
+)
+
+// conjunctRange gives the runes first to last an Indic_Conjunct_Break value
+type conjunctRange struct {
+	first, last rune
+	class       conjunctClass
+}
+
+// graphemeClassOf returns the Grapheme_Cluster_Break property of r
+func graphemeClassOf(r rune) graphemeClass {
+	if r < 0x7F && r >= 0x20 {
+		return gcbOther
+	}
+	lo, hi := 0, len(graphemeClasses)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch c := graphemeClasses[mid]; {
+		case r < c.first:
+			hi = mid
+		case r > c.last:
+			lo = mid + 1
+		default:
+			return c.class
+		}
+	}
+	return gcbOther
+}
+
+// conjunctClassOf returns the Indic_Conjunct_Break Consonant or Linker
+// property of r, or incbNone
+func conjunctClassOf(r rune) conjunctClass {
+	if r < 0x900 {
+		return incbNone
+	}
+	lo, hi := 0, len(conjunctClasses)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch c := conjunctClasses[mid]; {
+		case r < c.first:
+			hi = mid
+		case r > c.last:
+			lo = mid + 1
+		default:
+			return c.class
+		}
+	}
+	return incbNone
+}
+
+// isPictographic reports whether r has the Extended_Pictographic property
+func isPictographic(r rune) bool {
+	if r < 0xA9 {
+		return false
+	}
+	lo, hi := 0, len(pictographicRanges)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch c := pictographicRanges[mid]; {
+		case r < c[0]:
+			hi = mid
+		case r > c[1]:
+			lo = mid + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
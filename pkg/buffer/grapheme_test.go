@@ -0,0 +1,81 @@
+package buffer_test
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestGraphemeBoundaries checks clusters made by the rules of UAX #29
+func TestGraphemeBoundaries(t *testing.T) {
+	tests := []struct {
+		name     string
+		clusters []string
+	}{
+		{"ascii", []string{"a", "b", " "}},
+		{"combining mark", []string{"e\u0301", "x"}},
+		{"crlf", []string{"a", "\r\n", "\n", "\r"}},
+		{"zwj sequence", []string{"\U0001F468\u200D\U0001F469\u200D\U0001F467", "!"}},
+		{"skin tone", []string{"\U0001F44D\U0001F3FD"}},
+		{"flags", []string{"\U0001F1FA\U0001F1F8", "\U0001F1EB\U0001F1F7", "\U0001F1EA"}},
+		{"hangul", []string{"\u1100\u1161\u11A8", "\uAC00"}},
+		{"conjunct", []string{"\u0915\u094D\u0937", "a"}},
+		{"zwj after a letter", []string{"a\u200D", "b"}},
+	}
+	for _, tt := range tests {
+		text := ""
+		var want []int
+		for _, c := range tt.clusters {
+			want = append(want, len(text))
+			text += c
+		}
+		want = append(want, len(text))
+
+		gb := newBuffer(t, text)
+		if got := slices.Collect(gb.GraphemeBoundaries(0)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got boundaries %v, want %v", tt.name, got, want)
+		}
+		if got := gb.GraphemeCount(); got != len(tt.clusters) {
+			t.Errorf("%s: got %d clusters, want %d", tt.name, got, len(tt.clusters))
+		}
+	}
+}
+
+// TestDeleteGraphemeAt checks deleting whole clusters and the errors
+func TestDeleteGraphemeAt(t *testing.T) {
+	flag := "\U0001F1FA\U0001F1F8"
+	gb := newBuffer(t, "e\u0301"+flag+"x\r\ny")
+	if err := gb.DeleteGraphemeAt(1, 2); err != nil || gb.GetText() != "e\u0301\r\ny" {
+		t.Fatalf("got %q, %v", gb.GetText(), err)
+	}
+	if err := gb.DeleteGraphemeAt(1, 10); err != nil || gb.GetText() != "e\u0301" {
+		t.Fatalf("past the end: got %q, %v", gb.GetText(), err)
+	}
+
+	if err := gb.DeleteGraphemeAt(1, 1); !errors.Is(err, buffer.ErrDeleteAtEnd) {
+		t.Errorf("at the end: %v", err)
+	}
+	if err := gb.DeleteGraphemeAt(2, 1); !errors.Is(err, buffer.ErrGraphemePositionOutOfRange) {
+		t.Errorf("past the end: %v", err)
+	}
+	if err := gb.DeleteGraphemeAt(-1, 1); !errors.Is(err, buffer.ErrGraphemePositionOutOfRange) {
+		t.Errorf("negative position: %v", err)
+	}
+	if err := gb.DeleteGraphemeAt(0, 0); !errors.Is(err, buffer.ErrInvalidGraphemeCount) {
+		t.Errorf("no count: %v", err)
+	}
+
+	// The iteration ends with an edit
+	gb = newBuffer(t, "abc")
+	var seen []int
+	for pos := range gb.GraphemeBoundaries(1) {
+		seen = append(seen, pos)
+		gb.InsertAt(0, "x")
+	}
+	if !reflect.DeepEqual(seen, []int{1}) {
+		t.Errorf("got %v", seen)
+	}
+}
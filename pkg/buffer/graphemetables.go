@@ -0,0 +1,515 @@
+// Code generated by gengrapheme.go; DO NOT EDIT.
+
+package buffer
+
+// Grapheme cluster data of Unicode 17.0.0, derived as UAX #29 describes from
+// the unicode package and the UCD lists in gengrapheme.go
+
+// graphemeClasses are the runes whose Grapheme_Cluster_Break is not Other,
+// as first rune, last rune and class, sorted
+var graphemeClasses = []graphemeRange{
+	{0x0000, 0x0009, gcbControl}, {0x000A, 0x000A, gcbLF}, {0x000B, 0x000C, gcbControl},
+	{0x000D, 0x000D, gcbCR}, {0x000E, 0x001F, gcbControl}, {0x007F, 0x009F, gcbControl},
+	{0x00AD, 0x00AD, gcbControl}, {0x0300, 0x036F, gcbExtend}, {0x0483, 0x0489, gcbExtend},
+	{0x0591, 0x05BD, gcbExtend}, {0x05BF, 0x05BF, gcbExtend}, {0x05C1, 0x05C2, gcbExtend},
+	{0x05C4, 0x05C5, gcbExtend}, {0x05C7, 0x05C7, gcbExtend}, {0x0600, 0x0605, gcbPrepend},
+	{0x0610, 0x061A, gcbExtend}, {0x061C, 0x061C, gcbControl}, {0x064B, 0x065F, gcbExtend},
+	{0x0670, 0x0670, gcbExtend}, {0x06D6, 0x06DC, gcbExtend}, {0x06DD, 0x06DD, gcbPrepend},
+	{0x06DF, 0x06E4, gcbExtend}, {0x06E7, 0x06E8, gcbExtend}, {0x06EA, 0x06ED, gcbExtend},
+	{0x070F, 0x070F, gcbPrepend}, {0x0711, 0x0711, gcbExtend}, {0x0730, 0x074A, gcbExtend},
+	{0x07A6, 0x07B0, gcbExtend}, {0x07EB, 0x07F3, gcbExtend}, {0x07FD, 0x07FD, gcbExtend},
+	{0x0816, 0x0819, gcbExtend}, {0x081B, 0x0823, gcbExtend}, {0x0825, 0x0827, gcbExtend},
+	{0x0829, 0x082D, gcbExtend}, {0x0859, 0x085B, gcbExtend}, {0x0890, 0x0891, gcbPrepend},
+	{0x0897, 0x089F, gcbExtend}, {0x08CA, 0x08E1, gcbExtend}, {0x08E2, 0x08E2, gcbPrepend},
+	{0x08E3, 0x0902, gcbExtend}, {0x0903, 0x0903, gcbSpacingMark}, {0x093A, 0x093A, gcbExtend},
+	{0x093B, 0x093B, gcbSpacingMark}, {0x093C, 0x093C, gcbExtend}, {0x093E, 0x0940, gcbSpacingMark},
+	{0x0941, 0x0948, gcbExtend}, {0x0949, 0x094C, gcbSpacingMark}, {0x094D, 0x094D, gcbExtend},
+	{0x094E, 0x094F, gcbSpacingMark}, {0x0951, 0x0957, gcbExtend}, {0x0962, 0x0963, gcbExtend},
+	{0x0981, 0x0981, gcbExtend}, {0x0982, 0x0983, gcbSpacingMark}, {0x09BC, 0x09BC, gcbExtend},
+	{0x09BE, 0x09BE, gcbExtend}, {0x09BF, 0x09C0, gcbSpacingMark}, {0x09C1, 0x09C4, gcbExtend},
+	{0x09C7, 0x09C8, gcbSpacingMark}, {0x09CB, 0x09CC, gcbSpacingMark}, {0x09CD, 0x09CD, gcbExtend},
+	{0x09D7, 0x09D7, gcbExtend}, {0x09E2, 0x09E3, gcbExtend}, {0x09FE, 0x09FE, gcbExtend},
+	{0x0A01, 0x0A02, gcbExtend}, {0x0A03, 0x0A03, gcbSpacingMark}, {0x0A3C, 0x0A3C, gcbExtend},
+	{0x0A3E, 0x0A40, gcbSpacingMark}, {0x0A41, 0x0A42, gcbExtend}, {0x0A47, 0x0A48, gcbExtend},
+	{0x0A4B, 0x0A4D, gcbExtend}, {0x0A51, 0x0A51, gcbExtend}, {0x0A70, 0x0A71, gcbExtend},
+	{0x0A75, 0x0A75, gcbExtend}, {0x0A81, 0x0A82, gcbExtend}, {0x0A83, 0x0A83, gcbSpacingMark},
+	{0x0ABC, 0x0ABC, gcbExtend}, {0x0ABE, 0x0AC0, gcbSpacingMark}, {0x0AC1, 0x0AC5, gcbExtend},
+	{0x0AC7, 0x0AC8, gcbExtend}, {0x0AC9, 0x0AC9, gcbSpacingMark}, {0x0ACB, 0x0ACC, gcbSpacingMark},
+	{0x0ACD, 0x0ACD, gcbExtend}, {0x0AE2, 0x0AE3, gcbExtend}, {0x0AFA, 0x0AFF, gcbExtend},
+	{0x0B01, 0x0B01, gcbExtend}, {0x0B02, 0x0B03, gcbSpacingMark}, {0x0B3C, 0x0B3C, gcbExtend},
+	{0x0B3E, 0x0B3F, gcbExtend}, {0x0B40, 0x0B40, gcbSpacingMark}, {0x0B41, 0x0B44, gcbExtend},
+	{0x0B47, 0x0B48, gcbSpacingMark}, {0x0B4B, 0x0B4C, gcbSpacingMark}, {0x0B4D, 0x0B4D, gcbExtend},
+	{0x0B55, 0x0B57, gcbExtend}, {0x0B62, 0x0B63, gcbExtend}, {0x0B82, 0x0B82, gcbExtend},
+	{0x0BBE, 0x0BBE, gcbExtend}, {0x0BBF, 0x0BBF, gcbSpacingMark}, {0x0BC0, 0x0BC0, gcbExtend},
+	{0x0BC1, 0x0BC2, gcbSpacingMark}, {0x0BC6, 0x0BC8, gcbSpacingMark}, {0x0BCA, 0x0BCC, gcbSpacingMark},
+	{0x0BCD, 0x0BCD, gcbExtend}, {0x0BD7, 0x0BD7, gcbExtend}, {0x0C00, 0x0C00, gcbExtend},
+	{0x0C01, 0x0C03, gcbSpacingMark}, {0x0C04, 0x0C04, gcbExtend}, {0x0C3C, 0x0C3C, gcbExtend},
+	{0x0C3E, 0x0C40, gcbExtend}, {0x0C41, 0x0C44, gcbSpacingMark}, {0x0C46, 0x0C48, gcbExtend},
+	{0x0C4A, 0x0C4D, gcbExtend}, {0x0C55, 0x0C56, gcbExtend}, {0x0C62, 0x0C63, gcbExtend},
+	{0x0C81, 0x0C81, gcbExtend}, {0x0C82, 0x0C83, gcbSpacingMark}, {0x0CBC, 0x0CBC, gcbExtend},
+	{0x0CBE, 0x0CBE, gcbSpacingMark}, {0x0CBF, 0x0CC0, gcbExtend}, {0x0CC1, 0x0CC1, gcbSpacingMark},
+	{0x0CC2, 0x0CC2, gcbExtend}, {0x0CC3, 0x0CC4, gcbSpacingMark}, {0x0CC6, 0x0CC8, gcbExtend},
+	{0x0CCA, 0x0CCD, gcbExtend}, {0x0CD5, 0x0CD6, gcbExtend}, {0x0CE2, 0x0CE3, gcbExtend},
+	{0x0CF3, 0x0CF3, gcbSpacingMark}, {0x0D00, 0x0D01, gcbExtend}, {0x0D02, 0x0D03, gcbSpacingMark},
+	{0x0D3B, 0x0D3C, gcbExtend}, {0x0D3E, 0x0D3E, gcbExtend}, {0x0D3F, 0x0D40, gcbSpacingMark},
+	{0x0D41, 0x0D44, gcbExtend}, {0x0D46, 0x0D48, gcbSpacingMark}, {0x0D4A, 0x0D4C, gcbSpacingMark},
+	{0x0D4D, 0x0D4D, gcbExtend}, {0x0D4E, 0x0D4E, gcbPrepend}, {0x0D57, 0x0D57, gcbExtend},
+	{0x0D62, 0x0D63, gcbExtend}, {0x0D81, 0x0D81, gcbExtend}, {0x0D82, 0x0D83, gcbSpacingMark},
+	{0x0DCA, 0x0DCA, gcbExtend}, {0x0DCF, 0x0DCF, gcbExtend}, {0x0DD0, 0x0DD1, gcbSpacingMark},
+	{0x0DD2, 0x0DD4, gcbExtend}, {0x0DD6, 0x0DD6, gcbExtend}, {0x0DD8, 0x0DDE, gcbSpacingMark},
+	{0x0DDF, 0x0DDF, gcbExtend}, {0x0DF2, 0x0DF3, gcbSpacingMark}, {0x0E31, 0x0E31, gcbExtend},
+	{0x0E33, 0x0E33, gcbSpacingMark}, {0x0E34, 0x0E3A, gcbExtend}, {0x0E47, 0x0E4E, gcbExtend},
+	{0x0EB1, 0x0EB1, gcbExtend}, {0x0EB3, 0x0EB3, gcbSpacingMark}, {0x0EB4, 0x0EBC, gcbExtend},
+	{0x0EC8, 0x0ECE, gcbExtend}, {0x0F18, 0x0F19, gcbExtend}, {0x0F35, 0x0F35, gcbExtend},
+	{0x0F37, 0x0F37, gcbExtend}, {0x0F39, 0x0F39, gcbExtend}, {0x0F3E, 0x0F3F, gcbSpacingMark},
+	{0x0F71, 0x0F7E, gcbExtend}, {0x0F7F, 0x0F7F, gcbSpacingMark}, {0x0F80, 0x0F84, gcbExtend},
+	{0x0F86, 0x0F87, gcbExtend}, {0x0F8D, 0x0F97, gcbExtend}, {0x0F99, 0x0FBC, gcbExtend},
+	{0x0FC6, 0x0FC6, gcbExtend}, {0x102D, 0x1030, gcbExtend}, {0x1031, 0x1031, gcbSpacingMark},
+	{0x1032, 0x1037, gcbExtend}, {0x1039, 0x103A, gcbExtend}, {0x103B, 0x103C, gcbSpacingMark},
+	{0x103D, 0x103E, gcbExtend}, {0x1056, 0x1057, gcbSpacingMark}, {0x1058, 0x1059, gcbExtend},
+	{0x105E, 0x1060, gcbExtend}, {0x1071, 0x1074, gcbExtend}, {0x1082, 0x1082, gcbExtend},
+	{0x1084, 0x1084, gcbSpacingMark}, {0x1085, 0x1086, gcbExtend}, {0x108D, 0x108D, gcbExtend},
+	{0x109D, 0x109D, gcbExtend}, {0x1100, 0x115F, gcbL}, {0x1160, 0x11A7, gcbV},
+	{0x11A8, 0x11FF, gcbT}, {0x135D, 0x135F, gcbExtend}, {0x1712, 0x1715, gcbExtend},
+	{0x1732, 0x1734, gcbExtend}, {0x1752, 0x1753, gcbExtend}, {0x1772, 0x1773, gcbExtend},
+	{0x17B4, 0x17B5, gcbExtend}, {0x17B6, 0x17B6, gcbSpacingMark}, {0x17B7, 0x17BD, gcbExtend},
+	{0x17BE, 0x17C5, gcbSpacingMark}, {0x17C6, 0x17C6, gcbExtend}, {0x17C7, 0x17C8, gcbSpacingMark},
+	{0x17C9, 0x17D3, gcbExtend}, {0x17DD, 0x17DD, gcbExtend}, {0x180B, 0x180D, gcbExtend},
+	{0x180E, 0x180E, gcbControl}, {0x180F, 0x180F, gcbExtend}, {0x1885, 0x1886, gcbExtend},
+	{0x18A9, 0x18A9, gcbExtend}, {0x1920, 0x1922, gcbExtend}, {0x1923, 0x1926, gcbSpacingMark},
+	{0x1927, 0x1928, gcbExtend}, {0x1929, 0x192B, gcbSpacingMark}, {0x1930, 0x1931, gcbSpacingMark},
+	{0x1932, 0x1932, gcbExtend}, {0x1933, 0x1938, gcbSpacingMark}, {0x1939, 0x193B, gcbExtend},
+	{0x1A17, 0x1A18, gcbExtend}, {0x1A19, 0x1A1A, gcbSpacingMark}, {0x1A1B, 0x1A1B, gcbExtend},
+	{0x1A55, 0x1A55, gcbSpacingMark}, {0x1A56, 0x1A56, gcbExtend}, {0x1A57, 0x1A57, gcbSpacingMark},
+	{0x1A58, 0x1A5E, gcbExtend}, {0x1A60, 0x1A60, gcbExtend}, {0x1A62, 0x1A62, gcbExtend},
+	{0x1A65, 0x1A6C, gcbExtend}, {0x1A6D, 0x1A72, gcbSpacingMark}, {0x1A73, 0x1A7C, gcbExtend},
+	{0x1A7F, 0x1A7F, gcbExtend}, {0x1AB0, 0x1ADD, gcbExtend}, {0x1AE0, 0x1AEB, gcbExtend},
+	{0x1B00, 0x1B03, gcbExtend}, {0x1B04, 0x1B04, gcbSpacingMark}, {0x1B34, 0x1B3D, gcbExtend},
+	{0x1B3E, 0x1B41, gcbSpacingMark}, {0x1B42, 0x1B44, gcbExtend}, {0x1B6B, 0x1B73, gcbExtend},
+	{0x1B80, 0x1B81, gcbExtend}, {0x1B82, 0x1B82, gcbSpacingMark}, {0x1BA1, 0x1BA1, gcbSpacingMark},
+	{0x1BA2, 0x1BA5, gcbExtend}, {0x1BA6, 0x1BA7, gcbSpacingMark}, {0x1BA8, 0x1BAD, gcbExtend},
+	{0x1BE6, 0x1BE6, gcbExtend}, {0x1BE7, 0x1BE7, gcbSpacingMark}, {0x1BE8, 0x1BE9, gcbExtend},
+	{0x1BEA, 0x1BEC, gcbSpacingMark}, {0x1BED, 0x1BED, gcbExtend}, {0x1BEE, 0x1BEE, gcbSpacingMark},
+	{0x1BEF, 0x1BF3, gcbExtend}, {0x1C24, 0x1C2B, gcbSpacingMark}, {0x1C2C, 0x1C33, gcbExtend},
+	{0x1C34, 0x1C35, gcbSpacingMark}, {0x1C36, 0x1C37, gcbExtend}, {0x1CD0, 0x1CD2, gcbExtend},
+	{0x1CD4, 0x1CE0, gcbExtend}, {0x1CE1, 0x1CE1, gcbSpacingMark}, {0x1CE2, 0x1CE8, gcbExtend},
+	{0x1CED, 0x1CED, gcbExtend}, {0x1CF4, 0x1CF4, gcbExtend}, {0x1CF7, 0x1CF7, gcbSpacingMark},
+	{0x1CF8, 0x1CF9, gcbExtend}, {0x1DC0, 0x1DFF, gcbExtend}, {0x200B, 0x200B, gcbControl},
+	{0x200C, 0x200C, gcbExtend}, {0x200D, 0x200D, gcbZWJ}, {0x200E, 0x200F, gcbControl},
+	{0x2028, 0x202E, gcbControl}, {0x2060, 0x2064, gcbControl}, {0x2066, 0x206F, gcbControl},
+	{0x20D0, 0x20F0, gcbExtend}, {0x2CEF, 0x2CF1, gcbExtend}, {0x2D7F, 0x2D7F, gcbExtend},
+	{0x2DE0, 0x2DFF, gcbExtend}, {0x302A, 0x302F, gcbExtend}, {0x3099, 0x309A, gcbExtend},
+	{0xA66F, 0xA672, gcbExtend}, {0xA674, 0xA67D, gcbExtend}, {0xA69E, 0xA69F, gcbExtend},
+	{0xA6F0, 0xA6F1, gcbExtend}, {0xA802, 0xA802, gcbExtend}, {0xA806, 0xA806, gcbExtend},
+	{0xA80B, 0xA80B, gcbExtend}, {0xA823, 0xA824, gcbSpacingMark}, {0xA825, 0xA826, gcbExtend},
+	{0xA827, 0xA827, gcbSpacingMark}, {0xA82C, 0xA82C, gcbExtend}, {0xA880, 0xA881, gcbSpacingMark},
+	{0xA8B4, 0xA8C3, gcbSpacingMark}, {0xA8C4, 0xA8C5, gcbExtend}, {0xA8E0, 0xA8F1, gcbExtend},
+	{0xA8FF, 0xA8FF, gcbExtend}, {0xA926, 0xA92D, gcbExtend}, {0xA947, 0xA951, gcbExtend},
+	{0xA952, 0xA952, gcbSpacingMark}, {0xA953, 0xA953, gcbExtend}, {0xA960, 0xA97C, gcbL},
+	{0xA980, 0xA982, gcbExtend}, {0xA983, 0xA983, gcbSpacingMark}, {0xA9B3, 0xA9B3, gcbExtend},
+	{0xA9B4, 0xA9B5, gcbSpacingMark}, {0xA9B6, 0xA9B9, gcbExtend}, {0xA9BA, 0xA9BB, gcbSpacingMark},
+	{0xA9BC, 0xA9BD, gcbExtend}, {0xA9BE, 0xA9BF, gcbSpacingMark}, {0xA9C0, 0xA9C0, gcbExtend},
+	{0xA9E5, 0xA9E5, gcbExtend}, {0xAA29, 0xAA2E, gcbExtend}, {0xAA2F, 0xAA30, gcbSpacingMark},
+	{0xAA31, 0xAA32, gcbExtend}, {0xAA33, 0xAA34, gcbSpacingMark}, {0xAA35, 0xAA36, gcbExtend},
+	{0xAA43, 0xAA43, gcbExtend}, {0xAA4C, 0xAA4C, gcbExtend}, {0xAA4D, 0xAA4D, gcbSpacingMark},
+	{0xAA7C, 0xAA7C, gcbExtend}, {0xAAB0, 0xAAB0, gcbExtend}, {0xAAB2, 0xAAB4, gcbExtend},
+	{0xAAB7, 0xAAB8, gcbExtend}, {0xAABE, 0xAABF, gcbExtend}, {0xAAC1, 0xAAC1, gcbExtend},
+	{0xAAEB, 0xAAEB, gcbSpacingMark}, {0xAAEC, 0xAAED, gcbExtend}, {0xAAEE, 0xAAEF, gcbSpacingMark},
+	{0xAAF5, 0xAAF5, gcbSpacingMark}, {0xAAF6, 0xAAF6, gcbExtend}, {0xABE3, 0xABE4, gcbSpacingMark},
+	{0xABE5, 0xABE5, gcbExtend}, {0xABE6, 0xABE7, gcbSpacingMark}, {0xABE8, 0xABE8, gcbExtend},
+	{0xABE9, 0xABEA, gcbSpacingMark}, {0xABEC, 0xABEC, gcbSpacingMark}, {0xABED, 0xABED, gcbExtend},
+	{0xAC00, 0xAC00, gcbLV}, {0xAC01, 0xAC1B, gcbLVT}, {0xAC1C, 0xAC1C, gcbLV},
+	{0xAC1D, 0xAC37, gcbLVT}, {0xAC38, 0xAC38, gcbLV}, {0xAC39, 0xAC53, gcbLVT},
+	{0xAC54, 0xAC54, gcbLV}, {0xAC55, 0xAC6F, gcbLVT}, {0xAC70, 0xAC70, gcbLV},
+	{0xAC71, 0xAC8B, gcbLVT}, {0xAC8C, 0xAC8C, gcbLV}, {0xAC8D, 0xACA7, gcbLVT},
+	{0xACA8, 0xACA8, gcbLV}, {0xACA9, 0xACC3, gcbLVT}, {0xACC4, 0xACC4, gcbLV},
+	{0xACC5, 0xACDF, gcbLVT}, {0xACE0, 0xACE0, gcbLV}, {0xACE1, 0xACFB, gcbLVT},
+	{0xACFC, 0xACFC, gcbLV}, {0xACFD, 0xAD17, gcbLVT}, {0xAD18, 0xAD18, gcbLV},
+	{0xAD19, 0xAD33, gcbLVT}, {0xAD34, 0xAD34, gcbLV}, {0xAD35, 0xAD4F, gcbLVT},
+	{0xAD50, 0xAD50, gcbLV}, {0xAD51, 0xAD6B, gcbLVT}, {0xAD6C, 0xAD6C, gcbLV},
+	{0xAD6D, 0xAD87, gcbLVT}, {0xAD88, 0xAD88, gcbLV}, {0xAD89, 0xADA3, gcbLVT},
+	{0xADA4, 0xADA4, gcbLV}, {0xADA5, 0xADBF, gcbLVT}, {0xADC0, 0xADC0, gcbLV},
+	{0xADC1, 0xADDB, gcbLVT}, {0xADDC, 0xADDC, gcbLV}, {0xADDD, 0xADF7, gcbLVT},
+	{0xADF8, 0xADF8, gcbLV}, {0xADF9, 0xAE13, gcbLVT}, {0xAE14, 0xAE14, gcbLV},
+	{0xAE15, 0xAE2F, gcbLVT}, {0xAE30, 0xAE30, gcbLV}, {0xAE31, 0xAE4B, gcbLVT},
+	{0xAE4C, 0xAE4C, gcbLV}, {0xAE4D, 0xAE67, gcbLVT}, {0xAE68, 0xAE68, gcbLV},
+	{0xAE69, 0xAE83, gcbLVT}, {0xAE84, 0xAE84, gcbLV}, {0xAE85, 0xAE9F, gcbLVT},
+	{0xAEA0, 0xAEA0, gcbLV}, {0xAEA1, 0xAEBB, gcbLVT}, {0xAEBC, 0xAEBC, gcbLV},
+	{0xAEBD, 0xAED7, gcbLVT}, {0xAED8, 0xAED8, gcbLV}, {0xAED9, 0xAEF3, gcbLVT},
+	{0xAEF4, 0xAEF4, gcbLV}, {0xAEF5, 0xAF0F, gcbLVT}, {0xAF10, 0xAF10, gcbLV},
+	{0xAF11, 0xAF2B, gcbLVT}, {0xAF2C, 0xAF2C, gcbLV}, {0xAF2D, 0xAF47, gcbLVT},
+	{0xAF48, 0xAF48, gcbLV}, {0xAF49, 0xAF63, gcbLVT}, {0xAF64, 0xAF64, gcbLV},
+	{0xAF65, 0xAF7F, gcbLVT}, {0xAF80, 0xAF80, gcbLV}, {0xAF81, 0xAF9B, gcbLVT},
+	{0xAF9C, 0xAF9C, gcbLV}, {0xAF9D, 0xAFB7, gcbLVT}, {0xAFB8, 0xAFB8, gcbLV},
+	{0xAFB9, 0xAFD3, gcbLVT}, {0xAFD4, 0xAFD4, gcbLV}, {0xAFD5, 0xAFEF, gcbLVT},
+	{0xAFF0, 0xAFF0, gcbLV}, {0xAFF1, 0xB00B, gcbLVT}, {0xB00C, 0xB00C, gcbLV},
+	{0xB00D, 0xB027, gcbLVT}, {0xB028, 0xB028, gcbLV}, {0xB029, 0xB043, gcbLVT},
+	{0xB044, 0xB044, gcbLV}, {0xB045, 0xB05F, gcbLVT}, {0xB060, 0xB060, gcbLV},
+	{0xB061, 0xB07B, gcbLVT}, {0xB07C, 0xB07C, gcbLV}, {0xB07D, 0xB097, gcbLVT},
+	{0xB098, 0xB098, gcbLV}, {0xB099, 0xB0B3, gcbLVT}, {0xB0B4, 0xB0B4, gcbLV},
+	{0xB0B5, 0xB0CF, gcbLVT}, {0xB0D0, 0xB0D0, gcbLV}, {0xB0D1, 0xB0EB, gcbLVT},
+	{0xB0EC, 0xB0EC, gcbLV}, {0xB0ED, 0xB107, gcbLVT}, {0xB108, 0xB108, gcbLV},
+	{0xB109, 0xB123, gcbLVT}, {0xB124, 0xB124, gcbLV}, {0xB125, 0xB13F, gcbLVT},
+	{0xB140, 0xB140, gcbLV}, {0xB141, 0xB15B, gcbLVT}, {0xB15C, 0xB15C, gcbLV},
+	{0xB15D, 0xB177, gcbLVT}, {0xB178, 0xB178, gcbLV}, {0xB179, 0xB193, gcbLVT},
+	{0xB194, 0xB194, gcbLV}, {0xB195, 0xB1AF, gcbLVT}, {0xB1B0, 0xB1B0, gcbLV},
+	{0xB1B1, 0xB1CB, gcbLVT}, {0xB1CC, 0xB1CC, gcbLV}, {0xB1CD, 0xB1E7, gcbLVT},
+	{0xB1E8, 0xB1E8, gcbLV}, {0xB1E9, 0xB203, gcbLVT}, {0xB204, 0xB204, gcbLV},
+	{0xB205, 0xB21F, gcbLVT}, {0xB220, 0xB220, gcbLV}, {0xB221, 0xB23B, gcbLVT},
+	{0xB23C, 0xB23C, gcbLV}, {0xB23D, 0xB257, gcbLVT}, {0xB258, 0xB258, gcbLV},
+	{0xB259, 0xB273, gcbLVT}, {0xB274, 0xB274, gcbLV}, {0xB275, 0xB28F, gcbLVT},
+	{0xB290, 0xB290, gcbLV}, {0xB291, 0xB2AB, gcbLVT}, {0xB2AC, 0xB2AC, gcbLV},
+	{0xB2AD, 0xB2C7, gcbLVT}, {0xB2C8, 0xB2C8, gcbLV}, {0xB2C9, 0xB2E3, gcbLVT},
+	{0xB2E4, 0xB2E4, gcbLV}, {0xB2E5, 0xB2FF, gcbLVT}, {0xB300, 0xB300, gcbLV},
+	{0xB301, 0xB31B, gcbLVT}, {0xB31C, 0xB31C, gcbLV}, {0xB31D, 0xB337, gcbLVT},
+	{0xB338, 0xB338, gcbLV}, {0xB339, 0xB353, gcbLVT}, {0xB354, 0xB354, gcbLV},
+	{0xB355, 0xB36F, gcbLVT}, {0xB370, 0xB370, gcbLV}, {0xB371, 0xB38B, gcbLVT},
+	{0xB38C, 0xB38C, gcbLV}, {0xB38D, 0xB3A7, gcbLVT}, {0xB3A8, 0xB3A8, gcbLV},
+	{0xB3A9, 0xB3C3, gcbLVT}, {0xB3C4, 0xB3C4, gcbLV}, {0xB3C5, 0xB3DF, gcbLVT},
+	{0xB3E0, 0xB3E0, gcbLV}, {0xB3E1, 0xB3FB, gcbLVT}, {0xB3FC, 0xB3FC, gcbLV},
+	{0xB3FD, 0xB417, gcbLVT}, {0xB418, 0xB418, gcbLV}, {0xB419, 0xB433, gcbLVT},
+	{0xB434, 0xB434, gcbLV}, {0xB435, 0xB44F, gcbLVT}, {0xB450, 0xB450, gcbLV},
+	{0xB451, 0xB46B, gcbLVT}, {0xB46C, 0xB46C, gcbLV}, {0xB46D, 0xB487, gcbLVT},
+	{0xB488, 0xB488, gcbLV}, {0xB489, 0xB4A3, gcbLVT}, {0xB4A4, 0xB4A4, gcbLV},
+	{0xB4A5, 0xB4BF, gcbLVT}, {0xB4C0, 0xB4C0, gcbLV}, {0xB4C1, 0xB4DB, gcbLVT},
+	{0xB4DC, 0xB4DC, gcbLV}, {0xB4DD, 0xB4F7, gcbLVT}, {0xB4F8, 0xB4F8, gcbLV},
+	{0xB4F9, 0xB513, gcbLVT}, {0xB514, 0xB514, gcbLV}, {0xB515, 0xB52F, gcbLVT},
+	{0xB530, 0xB530, gcbLV}, {0xB531, 0xB54B, gcbLVT}, {0xB54C, 0xB54C, gcbLV},
+	{0xB54D, 0xB567, gcbLVT}, {0xB568, 0xB568, gcbLV}, {0xB569, 0xB583, gcbLVT},
+	{0xB584, 0xB584, gcbLV}, {0xB585, 0xB59F, gcbLVT}, {0xB5A0, 0xB5A0, gcbLV},
+	{0xB5A1, 0xB5BB, gcbLVT}, {0xB5BC, 0xB5BC, gcbLV}, {0xB5BD, 0xB5D7, gcbLVT},
+	{0xB5D8, 0xB5D8, gcbLV}, {0xB5D9, 0xB5F3, gcbLVT}, {0xB5F4, 0xB5F4, gcbLV},
+	{0xB5F5, 0xB60F, gcbLVT}, {0xB610, 0xB610, gcbLV}, {0xB611, 0xB62B, gcbLVT},
+	{0xB62C, 0xB62C, gcbLV}, {0xB62D, 0xB647, gcbLVT}, {0xB648, 0xB648, gcbLV},
+	{0xB649, 0xB663, gcbLVT}, {0xB664, 0xB664, gcbLV}, {0xB665, 0xB67F, gcbLVT},
+	{0xB680, 0xB680, gcbLV}, {0xB681, 0xB69B, gcbLVT}, {0xB69C, 0xB69C, gcbLV},
+	{0xB69D, 0xB6B7, gcbLVT}, {0xB6B8, 0xB6B8, gcbLV}, {0xB6B9, 0xB6D3, gcbLVT},
+	{0xB6D4, 0xB6D4, gcbLV}, {0xB6D5, 0xB6EF, gcbLVT}, {0xB6F0, 0xB6F0, gcbLV},
+	{0xB6F1, 0xB70B, gcbLVT}, {0xB70C, 0xB70C, gcbLV}, {0xB70D, 0xB727, gcbLVT},
+	{0xB728, 0xB728, gcbLV}, {0xB729, 0xB743, gcbLVT}, {0xB744, 0xB744, gcbLV},
+	{0xB745, 0xB75F, gcbLVT}, {0xB760, 0xB760, gcbLV}, {0xB761, 0xB77B, gcbLVT},
+	{0xB77C, 0xB77C, gcbLV}, {0xB77D, 0xB797, gcbLVT}, {0xB798, 0xB798, gcbLV},
+	{0xB799, 0xB7B3, gcbLVT}, {0xB7B4, 0xB7B4, gcbLV}, {0xB7B5, 0xB7CF, gcbLVT},
+	{0xB7D0, 0xB7D0, gcbLV}, {0xB7D1, 0xB7EB, gcbLVT}, {0xB7EC, 0xB7EC, gcbLV},
+	{0xB7ED, 0xB807, gcbLVT}, {0xB808, 0xB808, gcbLV}, {0xB809, 0xB823, gcbLVT},
+	{0xB824, 0xB824, gcbLV}, {0xB825, 0xB83F, gcbLVT}, {0xB840, 0xB840, gcbLV},
+	{0xB841, 0xB85B, gcbLVT}, {0xB85C, 0xB85C, gcbLV}, {0xB85D, 0xB877, gcbLVT},
+	{0xB878, 0xB878, gcbLV}, {0xB879, 0xB893, gcbLVT}, {0xB894, 0xB894, gcbLV},
+	{0xB895, 0xB8AF, gcbLVT}, {0xB8B0, 0xB8B0, gcbLV}, {0xB8B1, 0xB8CB, gcbLVT},
+	{0xB8CC, 0xB8CC, gcbLV}, {0xB8CD, 0xB8E7, gcbLVT}, {0xB8E8, 0xB8E8, gcbLV},
+	{0xB8E9, 0xB903, gcbLVT}, {0xB904, 0xB904, gcbLV}, {0xB905, 0xB91F, gcbLVT},
+	{0xB920, 0xB920, gcbLV}, {0xB921, 0xB93B, gcbLVT}, {0xB93C, 0xB93C, gcbLV},
+	{0xB93D, 0xB957, gcbLVT}, {0xB958, 0xB958, gcbLV}, {0xB959, 0xB973, gcbLVT},
+	{0xB974, 0xB974, gcbLV}, {0xB975, 0xB98F, gcbLVT}, {0xB990, 0xB990, gcbLV},
+	{0xB991, 0xB9AB, gcbLVT}, {0xB9AC, 0xB9AC, gcbLV}, {0xB9AD, 0xB9C7, gcbLVT},
+	{0xB9C8, 0xB9C8, gcbLV}, {0xB9C9, 0xB9E3, gcbLVT}, {0xB9E4, 0xB9E4, gcbLV},
+	{0xB9E5, 0xB9FF, gcbLVT}, {0xBA00, 0xBA00, gcbLV}, {0xBA01, 0xBA1B, gcbLVT},
+	{0xBA1C, 0xBA1C, gcbLV}, {0xBA1D, 0xBA37, gcbLVT}, {0xBA38, 0xBA38, gcbLV},
+	{0xBA39, 0xBA53, gcbLVT}, {0xBA54, 0xBA54, gcbLV}, {0xBA55, 0xBA6F, gcbLVT},
+	{0xBA70, 0xBA70, gcbLV}, {0xBA71, 0xBA8B, gcbLVT}, {0xBA8C, 0xBA8C, gcbLV},
+	{0xBA8D, 0xBAA7, gcbLVT}, {0xBAA8, 0xBAA8, gcbLV}, {0xBAA9, 0xBAC3, gcbLVT},
+	{0xBAC4, 0xBAC4, gcbLV}, {0xBAC5, 0xBADF, gcbLVT}, {0xBAE0, 0xBAE0, gcbLV},
+	{0xBAE1, 0xBAFB, gcbLVT}, {0xBAFC, 0xBAFC, gcbLV}, {0xBAFD, 0xBB17, gcbLVT},
+	{0xBB18, 0xBB18, gcbLV}, {0xBB19, 0xBB33, gcbLVT}, {0xBB34, 0xBB34, gcbLV},
+	{0xBB35, 0xBB4F, gcbLVT}, {0xBB50, 0xBB50, gcbLV}, {0xBB51, 0xBB6B, gcbLVT},
+	{0xBB6C, 0xBB6C, gcbLV}, {0xBB6D, 0xBB87, gcbLVT}, {0xBB88, 0xBB88, gcbLV},
+	{0xBB89, 0xBBA3, gcbLVT}, {0xBBA4, 0xBBA4, gcbLV}, {0xBBA5, 0xBBBF, gcbLVT},
+	{0xBBC0, 0xBBC0, gcbLV}, {0xBBC1, 0xBBDB, gcbLVT}, {0xBBDC, 0xBBDC, gcbLV},
+	{0xBBDD, 0xBBF7, gcbLVT}, {0xBBF8, 0xBBF8, gcbLV}, {0xBBF9, 0xBC13, gcbLVT},
+	{0xBC14, 0xBC14, gcbLV}, {0xBC15, 0xBC2F, gcbLVT}, {0xBC30, 0xBC30, gcbLV},
+	{0xBC31, 0xBC4B, gcbLVT}, {0xBC4C, 0xBC4C, gcbLV}, {0xBC4D, 0xBC67, gcbLVT},
+	{0xBC68, 0xBC68, gcbLV}, {0xBC69, 0xBC83, gcbLVT}, {0xBC84, 0xBC84, gcbLV},
+	{0xBC85, 0xBC9F, gcbLVT}, {0xBCA0, 0xBCA0, gcbLV}, {0xBCA1, 0xBCBB, gcbLVT},
+	{0xBCBC, 0xBCBC, gcbLV}, {0xBCBD, 0xBCD7, gcbLVT}, {0xBCD8, 0xBCD8, gcbLV},
+	{0xBCD9, 0xBCF3, gcbLVT}, {0xBCF4, 0xBCF4, gcbLV}, {0xBCF5, 0xBD0F, gcbLVT},
+	{0xBD10, 0xBD10, gcbLV}, {0xBD11, 0xBD2B, gcbLVT}, {0xBD2C, 0xBD2C, gcbLV},
+	{0xBD2D, 0xBD47, gcbLVT}, {0xBD48, 0xBD48, gcbLV}, {0xBD49, 0xBD63, gcbLVT},
+	{0xBD64, 0xBD64, gcbLV}, {0xBD65, 0xBD7F, gcbLVT}, {0xBD80, 0xBD80, gcbLV},
+	{0xBD81, 0xBD9B, gcbLVT}, {0xBD9C, 0xBD9C, gcbLV}, {0xBD9D, 0xBDB7, gcbLVT},
+	{0xBDB8, 0xBDB8, gcbLV}, {0xBDB9, 0xBDD3, gcbLVT}, {0xBDD4, 0xBDD4, gcbLV},
+	{0xBDD5, 0xBDEF, gcbLVT}, {0xBDF0, 0xBDF0, gcbLV}, {0xBDF1, 0xBE0B, gcbLVT},
+	{0xBE0C, 0xBE0C, gcbLV}, {0xBE0D, 0xBE27, gcbLVT}, {0xBE28, 0xBE28, gcbLV},
+	{0xBE29, 0xBE43, gcbLVT}, {0xBE44, 0xBE44, gcbLV}, {0xBE45, 0xBE5F, gcbLVT},
+	{0xBE60, 0xBE60, gcbLV}, {0xBE61, 0xBE7B, gcbLVT}, {0xBE7C, 0xBE7C, gcbLV},
+	{0xBE7D, 0xBE97, gcbLVT}, {0xBE98, 0xBE98, gcbLV}, {0xBE99, 0xBEB3, gcbLVT},
+	{0xBEB4, 0xBEB4, gcbLV}, {0xBEB5, 0xBECF, gcbLVT}, {0xBED0, 0xBED0, gcbLV},
+	{0xBED1, 0xBEEB, gcbLVT}, {0xBEEC, 0xBEEC, gcbLV}, {0xBEED, 0xBF07, gcbLVT},
+	{0xBF08, 0xBF08, gcbLV}, {0xBF09, 0xBF23, gcbLVT}, {0xBF24, 0xBF24, gcbLV},
+	{0xBF25, 0xBF3F, gcbLVT}, {0xBF40, 0xBF40, gcbLV}, {0xBF41, 0xBF5B, gcbLVT},
+	{0xBF5C, 0xBF5C, gcbLV}, {0xBF5D, 0xBF77, gcbLVT}, {0xBF78, 0xBF78, gcbLV},
+	{0xBF79, 0xBF93, gcbLVT}, {0xBF94, 0xBF94, gcbLV}, {0xBF95, 0xBFAF, gcbLVT},
+	{0xBFB0, 0xBFB0, gcbLV}, {0xBFB1, 0xBFCB, gcbLVT}, {0xBFCC, 0xBFCC, gcbLV},
+	{0xBFCD, 0xBFE7, gcbLVT}, {0xBFE8, 0xBFE8, gcbLV}, {0xBFE9, 0xC003, gcbLVT},
+	{0xC004, 0xC004, gcbLV}, {0xC005, 0xC01F, gcbLVT}, {0xC020, 0xC020, gcbLV},
+	{0xC021, 0xC03B, gcbLVT}, {0xC03C, 0xC03C, gcbLV}, {0xC03D, 0xC057, gcbLVT},
+	{0xC058, 0xC058, gcbLV}, {0xC059, 0xC073, gcbLVT}, {0xC074, 0xC074, gcbLV},
+	{0xC075, 0xC08F, gcbLVT}, {0xC090, 0xC090, gcbLV}, {0xC091, 0xC0AB, gcbLVT},
+	{0xC0AC, 0xC0AC, gcbLV}, {0xC0AD, 0xC0C7, gcbLVT}, {0xC0C8, 0xC0C8, gcbLV},
+	{0xC0C9, 0xC0E3, gcbLVT}, {0xC0E4, 0xC0E4, gcbLV}, {0xC0E5, 0xC0FF, gcbLVT},
+	{0xC100, 0xC100, gcbLV}, {0xC101, 0xC11B, gcbLVT}, {0xC11C, 0xC11C, gcbLV},
+	{0xC11D, 0xC137, gcbLVT}, {0xC138, 0xC138, gcbLV}, {0xC139, 0xC153, gcbLVT},
+	{0xC154, 0xC154, gcbLV}, {0xC155, 0xC16F, gcbLVT}, {0xC170, 0xC170, gcbLV},
+	{0xC171, 0xC18B, gcbLVT}, {0xC18C, 0xC18C, gcbLV}, {0xC18D, 0xC1A7, gcbLVT},
+	{0xC1A8, 0xC1A8, gcbLV}, {0xC1A9, 0xC1C3, gcbLVT}, {0xC1C4, 0xC1C4, gcbLV},
+	{0xC1C5, 0xC1DF, gcbLVT}, {0xC1E0, 0xC1E0, gcbLV}, {0xC1E1, 0xC1FB, gcbLVT},
+	{0xC1FC, 0xC1FC, gcbLV}, {0xC1FD, 0xC217, gcbLVT}, {0xC218, 0xC218, gcbLV},
+	{0xC219, 0xC233, gcbLVT}, {0xC234, 0xC234, gcbLV}, {0xC235, 0xC24F, gcbLVT},
+	{0xC250, 0xC250, gcbLV}, {0xC251, 0xC26B, gcbLVT}, {0xC26C, 0xC26C, gcbLV},
+	{0xC26D, 0xC287, gcbLVT}, {0xC288, 0xC288, gcbLV}, {0xC289, 0xC2A3, gcbLVT},
+	{0xC2A4, 0xC2A4, gcbLV}, {0xC2A5, 0xC2BF, gcbLVT}, {0xC2C0, 0xC2C0, gcbLV},
+	{0xC2C1, 0xC2DB, gcbLVT}, {0xC2DC, 0xC2DC, gcbLV}, {0xC2DD, 0xC2F7, gcbLVT},
+	{0xC2F8, 0xC2F8, gcbLV}, {0xC2F9, 0xC313, gcbLVT}, {0xC314, 0xC314, gcbLV},
+	{0xC315, 0xC32F, gcbLVT}, {0xC330, 0xC330, gcbLV}, {0xC331, 0xC34B, gcbLVT},
+	{0xC34C, 0xC34C, gcbLV}, {0xC34D, 0xC367, gcbLVT}, {0xC368, 0xC368, gcbLV},
+	{0xC369, 0xC383, gcbLVT}, {0xC384, 0xC384, gcbLV}, {0xC385, 0xC39F, gcbLVT},
+	{0xC3A0, 0xC3A0, gcbLV}, {0xC3A1, 0xC3BB, gcbLVT}, {0xC3BC, 0xC3BC, gcbLV},
+	{0xC3BD, 0xC3D7, gcbLVT}, {0xC3D8, 0xC3D8, gcbLV}, {0xC3D9, 0xC3F3, gcbLVT},
+	{0xC3F4, 0xC3F4, gcbLV}, {0xC3F5, 0xC40F, gcbLVT}, {0xC410, 0xC410, gcbLV},
+	{0xC411, 0xC42B, gcbLVT}, {0xC42C, 0xC42C, gcbLV}, {0xC42D, 0xC447, gcbLVT},
+	{0xC448, 0xC448, gcbLV}, {0xC449, 0xC463, gcbLVT}, {0xC464, 0xC464, gcbLV},
+	{0xC465, 0xC47F, gcbLVT}, {0xC480, 0xC480, gcbLV}, {0xC481, 0xC49B, gcbLVT},
+	{0xC49C, 0xC49C, gcbLV}, {0xC49D, 0xC4B7, gcbLVT}, {0xC4B8, 0xC4B8, gcbLV},
+	{0xC4B9, 0xC4D3, gcbLVT}, {0xC4D4, 0xC4D4, gcbLV}, {0xC4D5, 0xC4EF, gcbLVT},
+	{0xC4F0, 0xC4F0, gcbLV}, {0xC4F1, 0xC50B, gcbLVT}, {0xC50C, 0xC50C, gcbLV},
+	{0xC50D, 0xC527, gcbLVT}, {0xC528, 0xC528, gcbLV}, {0xC529, 0xC543, gcbLVT},
+	{0xC544, 0xC544, gcbLV}, {0xC545, 0xC55F, gcbLVT}, {0xC560, 0xC560, gcbLV},
+	{0xC561, 0xC57B, gcbLVT}, {0xC57C, 0xC57C, gcbLV}, {0xC57D, 0xC597, gcbLVT},
+	{0xC598, 0xC598, gcbLV}, {0xC599, 0xC5B3, gcbLVT}, {0xC5B4, 0xC5B4, gcbLV},
+	{0xC5B5, 0xC5CF, gcbLVT}, {0xC5D0, 0xC5D0, gcbLV}, {0xC5D1, 0xC5EB, gcbLVT},
+	{0xC5EC, 0xC5EC, gcbLV}, {0xC5ED, 0xC607, gcbLVT}, {0xC608, 0xC608, gcbLV},
+	{0xC609, 0xC623, gcbLVT}, {0xC624, 0xC624, gcbLV}, {0xC625, 0xC63F, gcbLVT},
+	{0xC640, 0xC640, gcbLV}, {0xC641, 0xC65B, gcbLVT}, {0xC65C, 0xC65C, gcbLV},
+	{0xC65D, 0xC677, gcbLVT}, {0xC678, 0xC678, gcbLV}, {0xC679, 0xC693, gcbLVT},
+	{0xC694, 0xC694, gcbLV}, {0xC695, 0xC6AF, gcbLVT}, {0xC6B0, 0xC6B0, gcbLV},
+	{0xC6B1, 0xC6CB, gcbLVT}, {0xC6CC, 0xC6CC, gcbLV}, {0xC6CD, 0xC6E7, gcbLVT},
+	{0xC6E8, 0xC6E8, gcbLV}, {0xC6E9, 0xC703, gcbLVT}, {0xC704, 0xC704, gcbLV},
+	{0xC705, 0xC71F, gcbLVT}, {0xC720, 0xC720, gcbLV}, {0xC721, 0xC73B, gcbLVT},
+	{0xC73C, 0xC73C, gcbLV}, {0xC73D, 0xC757, gcbLVT}, {0xC758, 0xC758, gcbLV},
+	{0xC759, 0xC773, gcbLVT}, {0xC774, 0xC774, gcbLV}, {0xC775, 0xC78F, gcbLVT},
+	{0xC790, 0xC790, gcbLV}, {0xC791, 0xC7AB, gcbLVT}, {0xC7AC, 0xC7AC, gcbLV},
+	{0xC7AD, 0xC7C7, gcbLVT}, {0xC7C8, 0xC7C8, gcbLV}, {0xC7C9, 0xC7E3, gcbLVT},
+	{0xC7E4, 0xC7E4, gcbLV}, {0xC7E5, 0xC7FF, gcbLVT}, {0xC800, 0xC800, gcbLV},
+	{0xC801, 0xC81B, gcbLVT}, {0xC81C, 0xC81C, gcbLV}, {0xC81D, 0xC837, gcbLVT},
+	{0xC838, 0xC838, gcbLV}, {0xC839, 0xC853, gcbLVT}, {0xC854, 0xC854, gcbLV},
+	{0xC855, 0xC86F, gcbLVT}, {0xC870, 0xC870, gcbLV}, {0xC871, 0xC88B, gcbLVT},
+	{0xC88C, 0xC88C, gcbLV}, {0xC88D, 0xC8A7, gcbLVT}, {0xC8A8, 0xC8A8, gcbLV},
+	{0xC8A9, 0xC8C3, gcbLVT}, {0xC8C4, 0xC8C4, gcbLV}, {0xC8C5, 0xC8DF, gcbLVT},
+	{0xC8E0, 0xC8E0, gcbLV}, {0xC8E1, 0xC8FB, gcbLVT}, {0xC8FC, 0xC8FC, gcbLV},
+	{0xC8FD, 0xC917, gcbLVT}, {0xC918, 0xC918, gcbLV}, {0xC919, 0xC933, gcbLVT},
+	{0xC934, 0xC934, gcbLV}, {0xC935, 0xC94F, gcbLVT}, {0xC950, 0xC950, gcbLV},
+	{0xC951, 0xC96B, gcbLVT}, {0xC96C, 0xC96C, gcbLV}, {0xC96D, 0xC987, gcbLVT},
+	{0xC988, 0xC988, gcbLV}, {0xC989, 0xC9A3, gcbLVT}, {0xC9A4, 0xC9A4, gcbLV},
+	{0xC9A5, 0xC9BF, gcbLVT}, {0xC9C0, 0xC9C0, gcbLV}, {0xC9C1, 0xC9DB, gcbLVT},
+	{0xC9DC, 0xC9DC, gcbLV}, {0xC9DD, 0xC9F7, gcbLVT}, {0xC9F8, 0xC9F8, gcbLV},
+	{0xC9F9, 0xCA13, gcbLVT}, {0xCA14, 0xCA14, gcbLV}, {0xCA15, 0xCA2F, gcbLVT},
+	{0xCA30, 0xCA30, gcbLV}, {0xCA31, 0xCA4B, gcbLVT}, {0xCA4C, 0xCA4C, gcbLV},
+	{0xCA4D, 0xCA67, gcbLVT}, {0xCA68, 0xCA68, gcbLV}, {0xCA69, 0xCA83, gcbLVT},
+	{0xCA84, 0xCA84, gcbLV}, {0xCA85, 0xCA9F, gcbLVT}, {0xCAA0, 0xCAA0, gcbLV},
+	{0xCAA1, 0xCABB, gcbLVT}, {0xCABC, 0xCABC, gcbLV}, {0xCABD, 0xCAD7, gcbLVT},
+	{0xCAD8, 0xCAD8, gcbLV}, {0xCAD9, 0xCAF3, gcbLVT}, {0xCAF4, 0xCAF4, gcbLV},
+	{0xCAF5, 0xCB0F, gcbLVT}, {0xCB10, 0xCB10, gcbLV}, {0xCB11, 0xCB2B, gcbLVT},
+	{0xCB2C, 0xCB2C, gcbLV}, {0xCB2D, 0xCB47, gcbLVT}, {0xCB48, 0xCB48, gcbLV},
+	{0xCB49, 0xCB63, gcbLVT}, {0xCB64, 0xCB64, gcbLV}, {0xCB65, 0xCB7F, gcbLVT},
+	{0xCB80, 0xCB80, gcbLV}, {0xCB81, 0xCB9B, gcbLVT}, {0xCB9C, 0xCB9C, gcbLV},
+	{0xCB9D, 0xCBB7, gcbLVT}, {0xCBB8, 0xCBB8, gcbLV}, {0xCBB9, 0xCBD3, gcbLVT},
+	{0xCBD4, 0xCBD4, gcbLV}, {0xCBD5, 0xCBEF, gcbLVT}, {0xCBF0, 0xCBF0, gcbLV},
+	{0xCBF1, 0xCC0B, gcbLVT}, {0xCC0C, 0xCC0C, gcbLV}, {0xCC0D, 0xCC27, gcbLVT},
+	{0xCC28, 0xCC28, gcbLV}, {0xCC29, 0xCC43, gcbLVT}, {0xCC44, 0xCC44, gcbLV},
+	{0xCC45, 0xCC5F, gcbLVT}, {0xCC60, 0xCC60, gcbLV}, {0xCC61, 0xCC7B, gcbLVT},
+	{0xCC7C, 0xCC7C, gcbLV}, {0xCC7D, 0xCC97, gcbLVT}, {0xCC98, 0xCC98, gcbLV},
+	{0xCC99, 0xCCB3, gcbLVT}, {0xCCB4, 0xCCB4, gcbLV}, {0xCCB5, 0xCCCF, gcbLVT},
+	{0xCCD0, 0xCCD0, gcbLV}, {0xCCD1, 0xCCEB, gcbLVT}, {0xCCEC, 0xCCEC, gcbLV},
+	{0xCCED, 0xCD07, gcbLVT}, {0xCD08, 0xCD08, gcbLV}, {0xCD09, 0xCD23, gcbLVT},
+	{0xCD24, 0xCD24, gcbLV}, {0xCD25, 0xCD3F, gcbLVT}, {0xCD40, 0xCD40, gcbLV},
+	{0xCD41, 0xCD5B, gcbLVT}, {0xCD5C, 0xCD5C, gcbLV}, {0xCD5D, 0xCD77, gcbLVT},
+	{0xCD78, 0xCD78, gcbLV}, {0xCD79, 0xCD93, gcbLVT}, {0xCD94, 0xCD94, gcbLV},
+	{0xCD95, 0xCDAF, gcbLVT}, {0xCDB0, 0xCDB0, gcbLV}, {0xCDB1, 0xCDCB, gcbLVT},
+	{0xCDCC, 0xCDCC, gcbLV}, {0xCDCD, 0xCDE7, gcbLVT}, {0xCDE8, 0xCDE8, gcbLV},
+	{0xCDE9, 0xCE03, gcbLVT}, {0xCE04, 0xCE04, gcbLV}, {0xCE05, 0xCE1F, gcbLVT},
+	{0xCE20, 0xCE20, gcbLV}, {0xCE21, 0xCE3B, gcbLVT}, {0xCE3C, 0xCE3C, gcbLV},
+	{0xCE3D, 0xCE57, gcbLVT}, {0xCE58, 0xCE58, gcbLV}, {0xCE59, 0xCE73, gcbLVT},
+	{0xCE74, 0xCE74, gcbLV}, {0xCE75, 0xCE8F, gcbLVT}, {0xCE90, 0xCE90, gcbLV},
+	{0xCE91, 0xCEAB, gcbLVT}, {0xCEAC, 0xCEAC, gcbLV}, {0xCEAD, 0xCEC7, gcbLVT},
+	{0xCEC8, 0xCEC8, gcbLV}, {0xCEC9, 0xCEE3, gcbLVT}, {0xCEE4, 0xCEE4, gcbLV},
+	{0xCEE5, 0xCEFF, gcbLVT}, {0xCF00, 0xCF00, gcbLV}, {0xCF01, 0xCF1B, gcbLVT},
+	{0xCF1C, 0xCF1C, gcbLV}, {0xCF1D, 0xCF37, gcbLVT}, {0xCF38, 0xCF38, gcbLV},
+	{0xCF39, 0xCF53, gcbLVT}, {0xCF54, 0xCF54, gcbLV}, {0xCF55, 0xCF6F, gcbLVT},
+	{0xCF70, 0xCF70, gcbLV}, {0xCF71, 0xCF8B, gcbLVT}, {0xCF8C, 0xCF8C, gcbLV},
+	{0xCF8D, 0xCFA7, gcbLVT}, {0xCFA8, 0xCFA8, gcbLV}, {0xCFA9, 0xCFC3, gcbLVT},
+	{0xCFC4, 0xCFC4, gcbLV}, {0xCFC5, 0xCFDF, gcbLVT}, {0xCFE0, 0xCFE0, gcbLV},
+	{0xCFE1, 0xCFFB, gcbLVT}, {0xCFFC, 0xCFFC, gcbLV}, {0xCFFD, 0xD017, gcbLVT},
+	{0xD018, 0xD018, gcbLV}, {0xD019, 0xD033, gcbLVT}, {0xD034, 0xD034, gcbLV},
+	{0xD035, 0xD04F, gcbLVT}, {0xD050, 0xD050, gcbLV}, {0xD051, 0xD06B, gcbLVT},
+	{0xD06C, 0xD06C, gcbLV}, {0xD06D, 0xD087, gcbLVT}, {0xD088, 0xD088, gcbLV},
+	{0xD089, 0xD0A3, gcbLVT}, {0xD0A4, 0xD0A4, gcbLV}, {0xD0A5, 0xD0BF, gcbLVT},
+	{0xD0C0, 0xD0C0, gcbLV}, {0xD0C1, 0xD0DB, gcbLVT}, {0xD0DC, 0xD0DC, gcbLV},
+	{0xD0DD, 0xD0F7, gcbLVT}, {0xD0F8, 0xD0F8, gcbLV}, {0xD0F9, 0xD113, gcbLVT},
+	{0xD114, 0xD114, gcbLV}, {0xD115, 0xD12F, gcbLVT}, {0xD130, 0xD130, gcbLV},
+	{0xD131, 0xD14B, gcbLVT}, {0xD14C, 0xD14C, gcbLV}, {0xD14D, 0xD167, gcbLVT},
+	{0xD168, 0xD168, gcbLV}, {0xD169, 0xD183, gcbLVT}, {0xD184, 0xD184, gcbLV},
+	{0xD185, 0xD19F, gcbLVT}, {0xD1A0, 0xD1A0, gcbLV}, {0xD1A1, 0xD1BB, gcbLVT},
+	{0xD1BC, 0xD1BC, gcbLV}, {0xD1BD, 0xD1D7, gcbLVT}, {0xD1D8, 0xD1D8, gcbLV},
+	{0xD1D9, 0xD1F3, gcbLVT}, {0xD1F4, 0xD1F4, gcbLV}, {0xD1F5, 0xD20F, gcbLVT},
+	{0xD210, 0xD210, gcbLV}, {0xD211, 0xD22B, gcbLVT}, {0xD22C, 0xD22C, gcbLV},
+	{0xD22D, 0xD247, gcbLVT}, {0xD248, 0xD248, gcbLV}, {0xD249, 0xD263, gcbLVT},
+	{0xD264, 0xD264, gcbLV}, {0xD265, 0xD27F, gcbLVT}, {0xD280, 0xD280, gcbLV},
+	{0xD281, 0xD29B, gcbLVT}, {0xD29C, 0xD29C, gcbLV}, {0xD29D, 0xD2B7, gcbLVT},
+	{0xD2B8, 0xD2B8, gcbLV}, {0xD2B9, 0xD2D3, gcbLVT}, {0xD2D4, 0xD2D4, gcbLV},
+	{0xD2D5, 0xD2EF, gcbLVT}, {0xD2F0, 0xD2F0, gcbLV}, {0xD2F1, 0xD30B, gcbLVT},
+	{0xD30C, 0xD30C, gcbLV}, {0xD30D, 0xD327, gcbLVT}, {0xD328, 0xD328, gcbLV},
+	{0xD329, 0xD343, gcbLVT}, {0xD344, 0xD344, gcbLV}, {0xD345, 0xD35F, gcbLVT},
+	{0xD360, 0xD360, gcbLV}, {0xD361, 0xD37B, gcbLVT}, {0xD37C, 0xD37C, gcbLV},
+	{0xD37D, 0xD397, gcbLVT}, {0xD398, 0xD398, gcbLV}, {0xD399, 0xD3B3, gcbLVT},
+	{0xD3B4, 0xD3B4, gcbLV}, {0xD3B5, 0xD3CF, gcbLVT}, {0xD3D0, 0xD3D0, gcbLV},
+	{0xD3D1, 0xD3EB, gcbLVT}, {0xD3EC, 0xD3EC, gcbLV}, {0xD3ED, 0xD407, gcbLVT},
+	{0xD408, 0xD408, gcbLV}, {0xD409, 0xD423, gcbLVT}, {0xD424, 0xD424, gcbLV},
+	{0xD425, 0xD43F, gcbLVT}, {0xD440, 0xD440, gcbLV}, {0xD441, 0xD45B, gcbLVT},
+	{0xD45C, 0xD45C, gcbLV}, {0xD45D, 0xD477, gcbLVT}, {0xD478, 0xD478, gcbLV},
+	{0xD479, 0xD493, gcbLVT}, {0xD494, 0xD494, gcbLV}, {0xD495, 0xD4AF, gcbLVT},
+	{0xD4B0, 0xD4B0, gcbLV}, {0xD4B1, 0xD4CB, gcbLVT}, {0xD4CC, 0xD4CC, gcbLV},
+	{0xD4CD, 0xD4E7, gcbLVT}, {0xD4E8, 0xD4E8, gcbLV}, {0xD4E9, 0xD503, gcbLVT},
+	{0xD504, 0xD504, gcbLV}, {0xD505, 0xD51F, gcbLVT}, {0xD520, 0xD520, gcbLV},
+	{0xD521, 0xD53B, gcbLVT}, {0xD53C, 0xD53C, gcbLV}, {0xD53D, 0xD557, gcbLVT},
+	{0xD558, 0xD558, gcbLV}, {0xD559, 0xD573, gcbLVT}, {0xD574, 0xD574, gcbLV},
+	{0xD575, 0xD58F, gcbLVT}, {0xD590, 0xD590, gcbLV}, {0xD591, 0xD5AB, gcbLVT},
+	{0xD5AC, 0xD5AC, gcbLV}, {0xD5AD, 0xD5C7, gcbLVT}, {0xD5C8, 0xD5C8, gcbLV},
+	{0xD5C9, 0xD5E3, gcbLVT}, {0xD5E4, 0xD5E4, gcbLV}, {0xD5E5, 0xD5FF, gcbLVT},
+	{0xD600, 0xD600, gcbLV}, {0xD601, 0xD61B, gcbLVT}, {0xD61C, 0xD61C, gcbLV},
+	{0xD61D, 0xD637, gcbLVT}, {0xD638, 0xD638, gcbLV}, {0xD639, 0xD653, gcbLVT},
+	{0xD654, 0xD654, gcbLV}, {0xD655, 0xD66F, gcbLVT}, {0xD670, 0xD670, gcbLV},
+	{0xD671, 0xD68B, gcbLVT}, {0xD68C, 0xD68C, gcbLV}, {0xD68D, 0xD6A7, gcbLVT},
+	{0xD6A8, 0xD6A8, gcbLV}, {0xD6A9, 0xD6C3, gcbLVT}, {0xD6C4, 0xD6C4, gcbLV},
+	{0xD6C5, 0xD6DF, gcbLVT}, {0xD6E0, 0xD6E0, gcbLV}, {0xD6E1, 0xD6FB, gcbLVT},
+	{0xD6FC, 0xD6FC, gcbLV}, {0xD6FD, 0xD717, gcbLVT}, {0xD718, 0xD718, gcbLV},
+	{0xD719, 0xD733, gcbLVT}, {0xD734, 0xD734, gcbLV}, {0xD735, 0xD74F, gcbLVT},
+	{0xD750, 0xD750, gcbLV}, {0xD751, 0xD76B, gcbLVT}, {0xD76C, 0xD76C, gcbLV},
+	{0xD76D, 0xD787, gcbLVT}, {0xD788, 0xD788, gcbLV}, {0xD789, 0xD7A3, gcbLVT},
+	{0xD7B0, 0xD7C6, gcbV}, {0xD7CB, 0xD7FB, gcbT}, {0xFB1E, 0xFB1E, gcbExtend},
+	{0xFE00, 0xFE0F, gcbExtend}, {0xFE20, 0xFE2F, gcbExtend}, {0xFEFF, 0xFEFF, gcbControl},
+	{0xFF9E, 0xFF9F, gcbExtend}, {0xFFF9, 0xFFFB, gcbControl}, {0x101FD, 0x101FD, gcbExtend},
+	{0x102E0, 0x102E0, gcbExtend}, {0x10376, 0x1037A, gcbExtend}, {0x10A01, 0x10A03, gcbExtend},
+	{0x10A05, 0x10A06, gcbExtend}, {0x10A0C, 0x10A0F, gcbExtend}, {0x10A38, 0x10A3A, gcbExtend},
+	{0x10A3F, 0x10A3F, gcbExtend}, {0x10AE5, 0x10AE6, gcbExtend}, {0x10D24, 0x10D27, gcbExtend},
+	{0x10D69, 0x10D6D, gcbExtend}, {0x10EAB, 0x10EAC, gcbExtend}, {0x10EFA, 0x10EFF, gcbExtend},
+	{0x10F46, 0x10F50, gcbExtend}, {0x10F82, 0x10F85, gcbExtend}, {0x11000, 0x11000, gcbSpacingMark},
+	{0x11001, 0x11001, gcbExtend}, {0x11002, 0x11002, gcbSpacingMark}, {0x11038, 0x11046, gcbExtend},
+	{0x11070, 0x11070, gcbExtend}, {0x11073, 0x11074, gcbExtend}, {0x1107F, 0x11081, gcbExtend},
+	{0x11082, 0x11082, gcbSpacingMark}, {0x110B0, 0x110B2, gcbSpacingMark}, {0x110B3, 0x110B6, gcbExtend},
+	{0x110B7, 0x110B8, gcbSpacingMark}, {0x110B9, 0x110BA, gcbExtend}, {0x110BD, 0x110BD, gcbPrepend},
+	{0x110C2, 0x110C2, gcbExtend}, {0x110CD, 0x110CD, gcbPrepend}, {0x11100, 0x11102, gcbExtend},
+	{0x11127, 0x1112B, gcbExtend}, {0x1112C, 0x1112C, gcbSpacingMark}, {0x1112D, 0x11134, gcbExtend},
+	{0x11145, 0x11146, gcbSpacingMark}, {0x11173, 0x11173, gcbExtend}, {0x11180, 0x11181, gcbExtend},
+	{0x11182, 0x11182, gcbSpacingMark}, {0x111B3, 0x111B5, gcbSpacingMark}, {0x111B6, 0x111BE, gcbExtend},
+	{0x111BF, 0x111BF, gcbSpacingMark}, {0x111C0, 0x111C0, gcbExtend}, {0x111C2, 0x111C3, gcbPrepend},
+	{0x111C9, 0x111CC, gcbExtend}, {0x111CE, 0x111CE, gcbSpacingMark}, {0x111CF, 0x111CF, gcbExtend},
+	{0x1122C, 0x1122E, gcbSpacingMark}, {0x1122F, 0x11231, gcbExtend}, {0x11232, 0x11233, gcbSpacingMark},
+	{0x11234, 0x11237, gcbExtend}, {0x1123E, 0x1123E, gcbExtend}, {0x11241, 0x11241, gcbExtend},
+	{0x112DF, 0x112DF, gcbExtend}, {0x112E0, 0x112E2, gcbSpacingMark}, {0x112E3, 0x112EA, gcbExtend},
+	{0x11300, 0x11301, gcbExtend}, {0x11302, 0x11303, gcbSpacingMark}, {0x1133B, 0x1133C, gcbExtend},
+	{0x1133E, 0x1133E, gcbExtend}, {0x1133F, 0x1133F, gcbSpacingMark}, {0x11340, 0x11340, gcbExtend},
+	{0x11341, 0x11344, gcbSpacingMark}, {0x11347, 0x11348, gcbSpacingMark}, {0x1134B, 0x1134C, gcbSpacingMark},
+	{0x1134D, 0x1134D, gcbExtend}, {0x11357, 0x11357, gcbExtend}, {0x11362, 0x11363, gcbSpacingMark},
+	{0x11366, 0x1136C, gcbExtend}, {0x11370, 0x11374, gcbExtend}, {0x113B8, 0x113B8, gcbExtend},
+	{0x113B9, 0x113BA, gcbSpacingMark}, {0x113BB, 0x113C0, gcbExtend}, {0x113C2, 0x113C2, gcbExtend},
+	{0x113C5, 0x113C5, gcbExtend}, {0x113C7, 0x113C9, gcbExtend}, {0x113CA, 0x113CA, gcbSpacingMark},
+	{0x113CC, 0x113CD, gcbSpacingMark}, {0x113CE, 0x113D0, gcbExtend}, {0x113D1, 0x113D1, gcbPrepend},
+	{0x113D2, 0x113D2, gcbExtend}, {0x113E1, 0x113E2, gcbExtend}, {0x11435, 0x11437, gcbSpacingMark},
+	{0x11438, 0x1143F, gcbExtend}, {0x11440, 0x11441, gcbSpacingMark}, {0x11442, 0x11444, gcbExtend},
+	{0x11445, 0x11445, gcbSpacingMark}, {0x11446, 0x11446, gcbExtend}, {0x1145E, 0x1145E, gcbExtend},
+	{0x114B0, 0x114B0, gcbExtend}, {0x114B1, 0x114B2, gcbSpacingMark}, {0x114B3, 0x114B8, gcbExtend},
+	{0x114B9, 0x114B9, gcbSpacingMark}, {0x114BA, 0x114BA, gcbExtend}, {0x114BB, 0x114BC, gcbSpacingMark},
+	{0x114BD, 0x114BD, gcbExtend}, {0x114BE, 0x114BE, gcbSpacingMark}, {0x114BF, 0x114C0, gcbExtend},
+	{0x114C1, 0x114C1, gcbSpacingMark}, {0x114C2, 0x114C3, gcbExtend}, {0x115AF, 0x115AF, gcbExtend},
+	{0x115B0, 0x115B1, gcbSpacingMark}, {0x115B2, 0x115B5, gcbExtend}, {0x115B8, 0x115BB, gcbSpacingMark},
+	{0x115BC, 0x115BD, gcbExtend}, {0x115BE, 0x115BE, gcbSpacingMark}, {0x115BF, 0x115C0, gcbExtend},
+	{0x115DC, 0x115DD, gcbExtend}, {0x11630, 0x11632, gcbSpacingMark}, {0x11633, 0x1163A, gcbExtend},
+	{0x1163B, 0x1163C, gcbSpacingMark}, {0x1163D, 0x1163D, gcbExtend}, {0x1163E, 0x1163E, gcbSpacingMark},
+	{0x1163F, 0x11640, gcbExtend}, {0x116AB, 0x116AB, gcbExtend}, {0x116AC, 0x116AC, gcbSpacingMark},
+	{0x116AD, 0x116AD, gcbExtend}, {0x116AE, 0x116AF, gcbSpacingMark}, {0x116B0, 0x116B7, gcbExtend},
+	{0x1171D, 0x1171D, gcbExtend}, {0x1171E, 0x1171E, gcbSpacingMark}, {0x1171F, 0x1171F, gcbExtend},
+	{0x11722, 0x11725, gcbExtend}, {0x11726, 0x11726, gcbSpacingMark}, {0x11727, 0x1172B, gcbExtend},
+	{0x1182C, 0x1182E, gcbSpacingMark}, {0x1182F, 0x11837, gcbExtend}, {0x11838, 0x11838, gcbSpacingMark},
+	{0x11839, 0x1183A, gcbExtend}, {0x11930, 0x11930, gcbExtend}, {0x11931, 0x11935, gcbSpacingMark},
+	{0x11937, 0x11938, gcbSpacingMark}, {0x1193B, 0x1193E, gcbExtend}, {0x1193F, 0x1193F, gcbPrepend},
+	{0x11940, 0x11940, gcbSpacingMark}, {0x11941, 0x11941, gcbPrepend}, {0x11942, 0x11942, gcbSpacingMark},
+	{0x11943, 0x11943, gcbExtend}, {0x119D1, 0x119D3, gcbSpacingMark}, {0x119D4, 0x119D7, gcbExtend},
+	{0x119DA, 0x119DB, gcbExtend}, {0x119DC, 0x119DF, gcbSpacingMark}, {0x119E0, 0x119E0, gcbExtend},
+	{0x119E4, 0x119E4, gcbSpacingMark}, {0x11A01, 0x11A0A, gcbExtend}, {0x11A33, 0x11A38, gcbExtend},
+	{0x11A39, 0x11A39, gcbSpacingMark}, {0x11A3A, 0x11A3A, gcbPrepend}, {0x11A3B, 0x11A3E, gcbExtend},
+	{0x11A47, 0x11A47, gcbExtend}, {0x11A51, 0x11A56, gcbExtend}, {0x11A57, 0x11A58, gcbSpacingMark},
+	{0x11A59, 0x11A5B, gcbExtend}, {0x11A84, 0x11A89, gcbPrepend}, {0x11A8A, 0x11A96, gcbExtend},
+	{0x11A97, 0x11A97, gcbSpacingMark}, {0x11A98, 0x11A99, gcbExtend}, {0x11B60, 0x11B60, gcbExtend},
+	{0x11B61, 0x11B61, gcbSpacingMark}, {0x11B62, 0x11B64, gcbExtend}, {0x11B65, 0x11B65, gcbSpacingMark},
+	{0x11B66, 0x11B66, gcbExtend}, {0x11B67, 0x11B67, gcbSpacingMark}, {0x11C2F, 0x11C2F, gcbSpacingMark},
+	{0x11C30, 0x11C36, gcbExtend}, {0x11C38, 0x11C3D, gcbExtend}, {0x11C3E, 0x11C3E, gcbSpacingMark},
+	{0x11C3F, 0x11C3F, gcbExtend}, {0x11C92, 0x11CA7, gcbExtend}, {0x11CA9, 0x11CA9, gcbSpacingMark},
+	{0x11CAA, 0x11CB0, gcbExtend}, {0x11CB1, 0x11CB1, gcbSpacingMark}, {0x11CB2, 0x11CB3, gcbExtend},
+	{0x11CB4, 0x11CB4, gcbSpacingMark}, {0x11CB5, 0x11CB6, gcbExtend}, {0x11D31, 0x11D36, gcbExtend},
+	{0x11D3A, 0x11D3A, gcbExtend}, {0x11D3C, 0x11D3D, gcbExtend}, {0x11D3F, 0x11D45, gcbExtend},
+	{0x11D46, 0x11D46, gcbPrepend}, {0x11D47, 0x11D47, gcbExtend}, {0x11D8A, 0x11D8E, gcbSpacingMark},
+	{0x11D90, 0x11D91, gcbExtend}, {0x11D93, 0x11D94, gcbSpacingMark}, {0x11D95, 0x11D95, gcbExtend},
+	{0x11D96, 0x11D96, gcbSpacingMark}, {0x11D97, 0x11D97, gcbExtend}, {0x11EF3, 0x11EF4, gcbExtend},
+	{0x11EF5, 0x11EF6, gcbSpacingMark}, {0x11F00, 0x11F01, gcbExtend}, {0x11F02, 0x11F02, gcbPrepend},
+	{0x11F03, 0x11F03, gcbSpacingMark}, {0x11F34, 0x11F35, gcbSpacingMark}, {0x11F36, 0x11F3A, gcbExtend},
+	{0x11F3E, 0x11F3F, gcbSpacingMark}, {0x11F40, 0x11F42, gcbExtend}, {0x11F5A, 0x11F5A, gcbExtend},
+	{0x13430, 0x1343F, gcbControl}, {0x13440, 0x13440, gcbExtend}, {0x13447, 0x13455, gcbExtend},
+	{0x1611E, 0x16129, gcbExtend}, {0x1612A, 0x1612C, gcbSpacingMark}, {0x1612D, 0x1612F, gcbExtend},
+	{0x16AF0, 0x16AF4, gcbExtend}, {0x16B30, 0x16B36, gcbExtend}, {0x16F4F, 0x16F4F, gcbExtend},
+	{0x16F51, 0x16F87, gcbSpacingMark}, {0x16F8F, 0x16F92, gcbExtend}, {0x16FE4, 0x16FE4, gcbExtend},
+	{0x16FF0, 0x16FF1, gcbExtend}, {0x1BC9D, 0x1BC9E, gcbExtend}, {0x1BCA0, 0x1BCA3, gcbControl},
+	{0x1CF00, 0x1CF2D, gcbExtend}, {0x1CF30, 0x1CF46, gcbExtend}, {0x1D165, 0x1D169, gcbExtend},
+	{0x1D16D, 0x1D172, gcbExtend}, {0x1D173, 0x1D17A, gcbControl}, {0x1D17B, 0x1D182, gcbExtend},
+	{0x1D185, 0x1D18B, gcbExtend}, {0x1D1AA, 0x1D1AD, gcbExtend}, {0x1D242, 0x1D244, gcbExtend},
+	{0x1DA00, 0x1DA36, gcbExtend}, {0x1DA3B, 0x1DA6C, gcbExtend}, {0x1DA75, 0x1DA75, gcbExtend},
+	{0x1DA84, 0x1DA84, gcbExtend}, {0x1DA9B, 0x1DA9F, gcbExtend}, {0x1DAA1, 0x1DAAF, gcbExtend},
+	{0x1E000, 0x1E006, gcbExtend}, {0x1E008, 0x1E018, gcbExtend}, {0x1E01B, 0x1E021, gcbExtend},
+	{0x1E023, 0x1E024, gcbExtend}, {0x1E026, 0x1E02A, gcbExtend}, {0x1E08F, 0x1E08F, gcbExtend},
+	{0x1E130, 0x1E136, gcbExtend}, {0x1E2AE, 0x1E2AE, gcbExtend}, {0x1E2EC, 0x1E2EF, gcbExtend},
+	{0x1E4EC, 0x1E4EF, gcbExtend}, {0x1E5EE, 0x1E5EF, gcbExtend}, {0x1E6E3, 0x1E6E3, gcbExtend},
+	{0x1E6E6, 0x1E6E6, gcbExtend}, {0x1E6EE, 0x1E6EF, gcbExtend}, {0x1E6F5, 0x1E6F5, gcbExtend},
+	{0x1E8D0, 0x1E8D6, gcbExtend}, {0x1E944, 0x1E94A, gcbExtend}, {0x1F1E6, 0x1F1FF, gcbRegionalIndicator},
+	{0x1F3FB, 0x1F3FF, gcbExtend}, {0xE0001, 0xE0001, gcbControl}, {0xE0020, 0xE007F, gcbExtend},
+	{0xE0100, 0xE01EF, gcbExtend},
+}
+
+// pictographicRanges are the Extended_Pictographic runes, as first and
+// last rune, sorted
+var pictographicRanges = [][2]rune{
+	{0x00A9, 0x00A9}, {0x00AE, 0x00AE}, {0x203C, 0x203C}, {0x2049, 0x2049},
+	{0x2122, 0x2122}, {0x2139, 0x2139}, {0x2194, 0x2199}, {0x21A9, 0x21AA},
+	{0x231A, 0x231B}, {0x2328, 0x2328}, {0x2388, 0x2388}, {0x23CF, 0x23CF},
+	{0x23E9, 0x23F3}, {0x23F8, 0x23FA}, {0x24C2, 0x24C2}, {0x25AA, 0x25AB},
+	{0x25B6, 0x25B6}, {0x25C0, 0x25C0}, {0x25FB, 0x25FE}, {0x2600, 0x2605},
+	{0x2607, 0x2612}, {0x2614, 0x2685}, {0x2690, 0x2705}, {0x2708, 0x2712},
+	{0x2714, 0x2714}, {0x2716, 0x2716}, {0x271D, 0x271D}, {0x2721, 0x2721},
+	{0x2728, 0x2728}, {0x2733, 0x2734}, {0x2744, 0x2744}, {0x2747, 0x2747},
+	{0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755}, {0x2757, 0x2757},
+	{0x2763, 0x2767}, {0x2795, 0x2797}, {0x27A1, 0x27A1}, {0x27B0, 0x27B0},
+	{0x27BF, 0x27BF}, {0x2934, 0x2935}, {0x2B05, 0x2B07}, {0x2B1B, 0x2B1C},
+	{0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x3030, 0x3030}, {0x303D, 0x303D},
+	{0x3297, 0x3297}, {0x3299, 0x3299}, {0x1F000, 0x1F0FF}, {0x1F10D, 0x1F10F},
+	{0x1F12F, 0x1F12F}, {0x1F16C, 0x1F171}, {0x1F17E, 0x1F17F}, {0x1F18E, 0x1F18E},
+	{0x1F191, 0x1F19A}, {0x1F1AD, 0x1F1E5}, {0x1F201, 0x1F20F}, {0x1F21A, 0x1F21A},
+	{0x1F22F, 0x1F22F}, {0x1F232, 0x1F23A}, {0x1F23C, 0x1F23F}, {0x1F249, 0x1F3FA},
+	{0x1F400, 0x1F53D}, {0x1F546, 0x1F64F}, {0x1F680, 0x1F6FF}, {0x1F774, 0x1F77F},
+	{0x1F7D5, 0x1F7FF}, {0x1F80C, 0x1F80F}, {0x1F848, 0x1F84F}, {0x1F85A, 0x1F85F},
+	{0x1F888, 0x1F88F}, {0x1F8AE, 0x1F8FF}, {0x1F90C, 0x1F93A}, {0x1F93C, 0x1F945},
+	{0x1F947, 0x1FAFF}, {0x1FC00, 0x1FFFD},
+}
+
+// conjunctClasses are the Indic_Conjunct_Break Consonant and Linker runes,
+// as first rune, last rune and value, sorted. Extend is every other rune
+// of class gcbExtend or gcbZWJ.
+var conjunctClasses = []conjunctRange{
+	{0x0915, 0x0939, incbConsonant}, {0x094D, 0x094D, incbLinker}, {0x0958, 0x095F, incbConsonant},
+	{0x0978, 0x097F, incbConsonant}, {0x0995, 0x09A8, incbConsonant}, {0x09AA, 0x09B0, incbConsonant},
+	{0x09B2, 0x09B2, incbConsonant}, {0x09B6, 0x09B9, incbConsonant}, {0x09CD, 0x09CD, incbLinker},
+	{0x09DC, 0x09DD, incbConsonant}, {0x09DF, 0x09DF, incbConsonant}, {0x09F0, 0x09F1, incbConsonant},
+	{0x0A95, 0x0AA8, incbConsonant}, {0x0AAA, 0x0AB0, incbConsonant}, {0x0AB2, 0x0AB3, incbConsonant},
+	{0x0AB5, 0x0AB9, incbConsonant}, {0x0ACD, 0x0ACD, incbLinker}, {0x0AF9, 0x0AF9, incbConsonant},
+	{0x0B15, 0x0B28, incbConsonant}, {0x0B2A, 0x0B30, incbConsonant}, {0x0B32, 0x0B33, incbConsonant},
+	{0x0B35, 0x0B39, incbConsonant}, {0x0B4D, 0x0B4D, incbLinker}, {0x0B5C, 0x0B5D, incbConsonant},
+	{0x0B5F, 0x0B5F, incbConsonant}, {0x0B71, 0x0B71, incbConsonant}, {0x0C15, 0x0C28, incbConsonant},
+	{0x0C2A, 0x0C39, incbConsonant}, {0x0C4D, 0x0C4D, incbLinker}, {0x0C58, 0x0C5A, incbConsonant},
+	{0x0D15, 0x0D3A, incbConsonant}, {0x0D4D, 0x0D4D, incbLinker},
+}
@@ -0,0 +1,112 @@
+package buffer
+
+import "sort"
+
+// LineGuide carries what indent guides and sticky headers need for one line
+type LineGuide struct {
+	Line   int // Zero-based line number
+	Start  int // Position of the first byte of the line
+	Depth  int // Bracket nesting depth at the start of the line
+	Indent int // Width of the leading whitespace in columns
+}
+
+// guideCache remembers line starts and bracket depths for a prefix of the
+// buffer. Edits only truncate it from the edited line on, so queries never
+// rescan text before the first changed line.
+type guideCache struct {
+	starts []int // start position of each cached line
+	depths []int // bracket depth at the start of each cached line
+}
+
+// LineGuides returns the guides of lines [firstLine, lastLine), clipped to
+// the number of lines in the buffer. Tabs advance to the next multiple of tabWidth.
+func (gb *GapBuffer) LineGuides(firstLine int, lastLine int, tabWidth int) (guides []LineGuide, err error) {
+	defer guard("LineGuides", firstLine, gb.length, &err)
+
+	if firstLine < 0 || lastLine < firstLine {
+		return nil, ErrInvalidRange
+	}
+	if tabWidth <= 0 {
+		tabWidth = 1
+	}
+
+	gb.extendGuides(lastLine)
+	cache := &gb.guides
+	if firstLine > len(cache.starts) || (firstLine == len(cache.starts) && firstLine < lastLine) {
+		return nil, ErrPositionOutOfRange
+	}
+	if lastLine > len(cache.starts) {
+		lastLine = len(cache.starts)
+	}
+
+	for line := firstLine; line < lastLine; line++ {
+		guides = append(guides, LineGuide{
+			Line:   line,
+			Start:  cache.starts[line],
+			Depth:  cache.depths[line],
+			Indent: gb.indentWidth(cache.starts[line], tabWidth),
+		})
+	}
+	return guides, nil
+}
+
+// extendGuides scans forward from the last cached line until at least
+// lines lines are cached or the end of the buffer is reached
+func (gb *GapBuffer) extendGuides(lines int) {
+	cache := &gb.guides
+	if len(cache.starts) == 0 {
+		cache.starts = append(cache.starts, 0)
+		cache.depths = append(cache.depths, 0)
+	}
+	if len(cache.starts) >= lines {
+		return
+	}
+
+	last := len(cache.starts) - 1
+	depth := cache.depths[last]
+	gb.forEachChunk(cache.starts[last], gb.length, func(pos int, text string) bool {
+		for i := 0; i < len(text); i++ {
+			switch text[i] {
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				if depth > 0 {
+					depth--
+				}
+			case '\n':
+				cache.starts = append(cache.starts, pos+i+1)
+				cache.depths = append(cache.depths, depth)
+			}
+		}
+		return len(cache.starts) < lines
+	})
+}
+
+// indentWidth measures the leading whitespace of the line starting at pos
+func (gb *GapBuffer) indentWidth(pos int, tabWidth int) int {
+	width := 0
+	gb.forEachChunk(pos, gb.length, func(_ int, text string) bool {
+		for i := 0; i < len(text); i++ {
+			switch text[i] {
+			case ' ':
+				width++
+			case '\t':
+				width += tabWidth - width%tabWidth
+			default:
+				return false
+			}
+		}
+		return true
+	})
+	return width
+}
+
+// invalidateGuides drops the cached lines starting after the edit position
+func (gb *GapBuffer) invalidateGuides(op Operation) {
+	cache := &gb.guides
+	keep := sort.Search(len(cache.starts), func(i int) bool {
+		return cache.starts[i] > op.Pos
+	})
+	cache.starts = cache.starts[:keep]
+	cache.depths = cache.depths[:keep]
+}
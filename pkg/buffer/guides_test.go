@@ -0,0 +1,65 @@
+package buffer_test
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestLineGuides checks depths and indents, clipping and errors, and that
+// guides cached across edits match guides computed afresh
+func TestLineGuides(t *testing.T) {
+	gb := newBuffer(t, "func f() {\n\tif x {\n\t  y(a,\n\t    b)\n\t}\n}\n")
+	got, err := gb.LineGuides(0, 100, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []buffer.LineGuide{
+		{Line: 0, Start: 0, Depth: 0, Indent: 0},
+		{Line: 1, Start: 11, Depth: 1, Indent: 4},
+		{Line: 2, Start: 19, Depth: 2, Indent: 6},
+		{Line: 3, Start: 27, Depth: 3, Indent: 8},
+		{Line: 4, Start: 35, Depth: 2, Indent: 4},
+		{Line: 5, Start: 38, Depth: 1, Indent: 0},
+		{Line: 6, Start: 40, Depth: 0, Indent: 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if _, err := gb.LineGuides(8, 9, 4); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("past the last line: got %v, want ErrPositionOutOfRange", err)
+	}
+	if _, err := gb.LineGuides(3, 2, 4); !errors.Is(err, buffer.ErrInvalidRange) {
+		t.Errorf("reversed lines: got %v, want ErrInvalidRange", err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	pieces := []string{"{", "}", "(\n", "\n\t", "  x", ")"}
+	for i := 0; i < 500; i++ {
+		start := rng.Intn(gb.Length() + 1)
+		end := start + rng.Intn(min(4, gb.Length()-start)+1)
+		gb.Replace(start, end, pieces[rng.Intn(len(pieces))])
+
+		first := rng.Intn(gb.LineCount())
+		got, err := gb.LineGuides(first, first+5, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := newBuffer(t, gb.GetText()).LineGuides(first, first+5, 4)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("edit %d: got %+v, want %+v", i, got, want)
+		}
+	}
+
+	gb.ScheduleGuideBackfill()
+	if err := gb.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := gb.LineGuides(0, gb.LineCount(), 4); len(got) != gb.LineCount() {
+		t.Errorf("after backfill: got %d guides for %d lines", len(got), gb.LineCount())
+	}
+}
@@ -0,0 +1,206 @@
+package buffer
+
+import "errors"
+
+// DEFAULT_HISTORY_DEPTH is the default number of undo steps kept
+const DEFAULT_HISTORY_DEPTH = 1000
+
+// Errors returned by Undo and Redo
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// historyStep is a group of operations undone and redone together, oldest first
+type historyStep []Operation
+
+// history records applied edits as undo steps
+type history struct {
+	depth     int // 0 uses DEFAULT_HISTORY_DEPTH, < 0 disables history
+	undo      []historyStep
+	redo      []historyStep
+	group     historyStep // operations of the open group
+	nesting   int         // number of BeginGroup calls not yet ended
+	replaying bool        // edits come from Undo or Redo
+}
+
+// SetHistoryDepth sets how many undo steps are kept. n <= 0 disables the
+// history and drops the recorded steps.
+func (gb *GapBuffer) SetHistoryDepth(n int) {
+	h := &gb.history
+	if n <= 0 {
+		*h = history{depth: -1}
+		gb.collectSpill()
+		return
+	}
+	h.depth = n
+	gb.releaseSteps(h.trim())
+}
+
+// BeginGroup starts a group of edits undone as one step, such as a paste or
+// an edit at many cursors. Groups nest: the step ends with the outermost
+// EndGroup.
+func (gb *GapBuffer) BeginGroup() {
+	gb.history.nesting++
+}
+
+// EndGroup ends the group started by the matching BeginGroup
+func (gb *GapBuffer) EndGroup() {
+	h := &gb.history
+	if h.nesting == 0 {
+		return
+	}
+	if h.nesting--; h.nesting == 0 {
+		gb.releaseSteps(h.closeGroup())
+	}
+}
+
+// CanUndo reports whether Undo has a step to revert
+func (gb *GapBuffer) CanUndo() bool {
+	return len(gb.history.undo) > 0 || len(gb.history.group) > 0
+}
+
+// CanRedo reports whether Redo has a step to reapply
+func (gb *GapBuffer) CanRedo() bool {
+	return len(gb.history.redo) > 0
+}
+
+// Undo reverts the most recent step. It ends any open group first and
+// is not available during a transaction.
+func (gb *GapBuffer) Undo() (err error) {
+	defer guard("Undo", noPos, gb.length, &err)
+
+	if gb.InTransaction() {
+		return ErrTransactionActive
+	}
+	h := &gb.history
+	h.nesting = 0
+	gb.releaseSteps(h.closeGroup())
+	if len(h.undo) == 0 {
+		return ErrNothingToUndo
+	}
+	step := h.undo[len(h.undo)-1]
+
+	inverse := make([]Edit, len(step))
+	for i, op := range step {
+		op, err := gb.loadOperation(op)
+		if err != nil {
+			return err
+		}
+		inverse[len(step)-1-i] = Edit{Start: op.Pos, End: op.Pos + op.Inserted, Text: op.DeletedText}
+	}
+	if err := gb.replay(inverse); err != nil {
+		return err
+	}
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, step)
+	return nil
+}
+
+// Redo reapplies the most recently undone step
+func (gb *GapBuffer) Redo() (err error) {
+	defer guard("Redo", noPos, gb.length, &err)
+
+	if gb.InTransaction() {
+		return ErrTransactionActive
+	}
+	h := &gb.history
+	if len(h.redo) == 0 {
+		return ErrNothingToRedo
+	}
+	step := h.redo[len(h.redo)-1]
+
+	edits := make([]Edit, len(step))
+	for i, op := range step {
+		edits[i] = Edit{Start: op.Pos, End: op.Pos + op.Deleted, Text: op.InsertedText}
+	}
+	if err := gb.replay(edits); err != nil {
+		return err
+	}
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, step)
+	return nil
+}
+
+// replay applies edits one after the other, each in the coordinates left by
+// the previous one, without recording them as a new step. They restore text
+// the buffer held before, so limits and hooks are not checked and the quota
+// is charged for the whole set up front; the edits then cannot fail halfway.
+func (gb *GapBuffer) replay(edits []Edit) error {
+	if gb.views > 0 {
+		return ErrViewActive
+	}
+	delta := 0
+	for _, e := range edits {
+		delta += len(e.Text) - (e.End - e.Start)
+	}
+	if err := gb.chargeQuota(delta); err != nil {
+		return err
+	}
+
+	h := &gb.history
+	limits, quota, suggesting, hooks := gb.limits, gb.quota, gb.suggesting, gb.changeHooks
+	gb.limits, gb.quota, gb.suggesting, gb.changeHooks = Limits{}, nil, false, nil
+	h.replaying = true
+	defer func() {
+		gb.limits, gb.quota, gb.suggesting, gb.changeHooks = limits, quota, suggesting, hooks
+		h.replaying = false
+	}()
+
+	for _, e := range edits {
+		if err := gb.replace(e.Start, e.End, e.Text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordHistory adds an applied operation to the open group or as a step of
+// its own. New edits make the undone steps unreachable.
+func (gb *GapBuffer) recordHistory(op Operation) {
+	h := &gb.history
+	if h.depth < 0 || h.replaying {
+		return
+	}
+	dropped := h.redo
+	h.redo = nil
+	h.group = append(h.group, op)
+	if h.nesting == 0 {
+		dropped = append(dropped, h.closeGroup()...)
+	}
+	gb.releaseSteps(dropped)
+}
+
+// closeGroup turns the operations of the open group into an undo step and
+// returns the steps dropped to make room for it
+func (h *history) closeGroup() []historyStep {
+	if len(h.group) == 0 {
+		return nil
+	}
+	h.undo = append(h.undo, h.group)
+	h.group = nil
+	return h.trim()
+}
+
+// releaseSteps frees the spilled content of steps dropped from the history
+func (gb *GapBuffer) releaseSteps(steps []historyStep) {
+	for _, step := range steps {
+		gb.releaseOperations(step)
+	}
+}
+
+// trim drops the oldest steps beyond the depth and returns them
+func (h *history) trim() []historyStep {
+	depth := h.depth
+	if depth == 0 {
+		depth = DEFAULT_HISTORY_DEPTH
+	}
+	extra := len(h.undo) - depth
+	if extra <= 0 {
+		return nil
+	}
+	dropped := append([]historyStep(nil), h.undo[:extra]...)
+	clear(h.undo[:extra])
+	h.undo = h.undo[extra:]
+	return dropped
+}
@@ -0,0 +1,96 @@
+package buffer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestUndoRedo checks undoing and redoing single edits and nested groups,
+// and that a new edit drops the undone steps
+func TestUndoRedo(t *testing.T) {
+	gb := buffer.New()
+	gb.InsertAt(0, "a")
+	gb.BeginGroup()
+	gb.InsertAt(1, "b")
+	gb.BeginGroup()
+	gb.Replace(0, 1, "A")
+	gb.EndGroup()
+	gb.DeleteAt(1, 1)
+	gb.EndGroup()
+	gb.EndGroup() // unmatched, ignored
+	gb.InsertAt(1, "c")
+
+	steps := []string{"Ac", "A", "a", ""}
+	for _, want := range steps[1:] {
+		if err := gb.Undo(); err != nil || gb.GetText() != want {
+			t.Fatalf("undo: got %q, %v, want %q", gb.GetText(), err, want)
+		}
+	}
+	if err := gb.Undo(); !errors.Is(err, buffer.ErrNothingToUndo) || gb.CanUndo() {
+		t.Errorf("undo past the first step: %v", err)
+	}
+	for i := len(steps) - 2; i >= 0; i-- {
+		if err := gb.Redo(); err != nil || gb.GetText() != steps[i] {
+			t.Fatalf("redo: got %q, %v, want %q", gb.GetText(), err, steps[i])
+		}
+	}
+	if err := gb.Redo(); !errors.Is(err, buffer.ErrNothingToRedo) || gb.CanRedo() {
+		t.Errorf("redo past the last step: %v", err)
+	}
+
+	gb.Undo()
+	gb.InsertAt(0, "x")
+	if gb.CanRedo() {
+		t.Error("redo kept after a new edit")
+	}
+	if err := gb.Undo(); err != nil || gb.GetText() != "A" {
+		t.Errorf("got %q, %v", gb.GetText(), err)
+	}
+}
+
+// TestUndoOpenGroup checks that Undo ends the open group and reverts it
+func TestUndoOpenGroup(t *testing.T) {
+	gb := newBuffer(t, "text")
+	gb.BeginGroup()
+	gb.InsertAt(4, " more")
+	gb.InsertAt(0, ">")
+	if !gb.CanUndo() {
+		t.Fatal("open group not undoable")
+	}
+	if err := gb.Undo(); err != nil || gb.GetText() != "text" {
+		t.Fatalf("got %q, %v", gb.GetText(), err)
+	}
+	// The group is closed: the next edit is a step of its own
+	gb.InsertAt(0, "a")
+	gb.InsertAt(0, "b")
+	gb.Undo()
+	if gb.GetText() != "atext" {
+		t.Errorf("got %q", gb.GetText())
+	}
+}
+
+// TestHistoryDepth checks that only the last steps are kept and that a
+// depth of zero disables the history
+func TestHistoryDepth(t *testing.T) {
+	gb := buffer.New()
+	gb.SetHistoryDepth(2)
+	for _, s := range []string{"a", "b", "c"} {
+		gb.InsertAt(gb.Length(), s)
+	}
+	gb.Undo()
+	gb.Undo()
+	if err := gb.Undo(); !errors.Is(err, buffer.ErrNothingToUndo) || gb.GetText() != "a" {
+		t.Errorf("got %q, %v", gb.GetText(), err)
+	}
+
+	gb.SetHistoryDepth(0)
+	if gb.CanRedo() {
+		t.Error("steps kept with the history disabled")
+	}
+	gb.InsertAt(0, "x")
+	if gb.CanUndo() {
+		t.Error("edit recorded with the history disabled")
+	}
+}
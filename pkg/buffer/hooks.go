@@ -0,0 +1,40 @@
+package buffer
+
+// ChangeHook inspects an edit about to replace [start, end) with text and
+// returns an error to reject it, such as for a read-only region
+type ChangeHook func(start int, end int, text string) error
+
+// changeHook is a registered hook; its address identifies it
+type changeHook struct {
+	hook ChangeHook
+}
+
+// BeforeChange calls hook before every edit is applied, in registration
+// order; the first error cancels the edit and is returned to the caller.
+// Edit sets are checked entirely before any of them is applied. Undo, Redo
+// and Rollback restore earlier text and are not checked. The returned
+// function removes the hook.
+func (gb *GapBuffer) BeforeChange(hook ChangeHook) (remove func()) {
+	h := &changeHook{hook: hook}
+	gb.changeHooks = append(gb.changeHooks, h)
+	return func() {
+		for i, registered := range gb.changeHooks {
+			if registered == h {
+				gb.changeHooks = append(gb.changeHooks[:i:i], gb.changeHooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// checkHooks runs the hooks over every edit
+func (gb *GapBuffer) checkHooks(edits []Edit) error {
+	for _, e := range edits {
+		for _, h := range gb.changeHooks {
+			if err := h.hook(e.Start, e.End, e.Text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
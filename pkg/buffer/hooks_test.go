@@ -0,0 +1,53 @@
+package buffer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestBeforeChange checks that a hook sees the exact edit, can veto it,
+// also within an edit set, and is skipped by undo
+func TestBeforeChange(t *testing.T) {
+	errReadOnly := errors.New("read-only")
+	gb := newBuffer(t, "header\nbody")
+	var seen []buffer.Edit
+	gb.BeforeChange(func(start, end int, text string) error {
+		seen = append(seen, buffer.Edit{Start: start, End: end, Text: text})
+		return nil
+	})
+	remove := gb.BeforeChange(func(start, end int, text string) error {
+		if start < 7 {
+			return errReadOnly
+		}
+		return nil
+	})
+
+	if err := gb.Replace(7, 11, "new body"); err != nil {
+		t.Fatal(err)
+	}
+	if err := gb.DeleteAt(0, 1); !errors.Is(err, errReadOnly) || gb.GetText() != "header\nnew body" {
+		t.Errorf("got %q, %v", gb.GetText(), err)
+	}
+	edits := []buffer.Edit{{Start: 8, End: 8, Text: "x"}, {Start: 6, End: 7, Text: ""}}
+	if _, err := gb.ApplyEdits(edits, buffer.OverlapError); !errors.Is(err, errReadOnly) || gb.GetText() != "header\nnew body" {
+		t.Errorf("edit set: got %q, %v", gb.GetText(), err)
+	}
+	want := []buffer.Edit{{Start: 7, End: 11, Text: "new body"}, {Start: 0, End: 1, Text: ""}}
+	if len(seen) < 2 || seen[0] != want[0] || seen[1] != want[1] {
+		t.Errorf("hook saw %+v", seen)
+	}
+
+	if err := gb.Undo(); err != nil || gb.GetText() != "header\nbody" {
+		t.Errorf("undo: got %q, %v", gb.GetText(), err)
+	}
+	// Undoing the initial insert touches the read-only region
+	if err := gb.Undo(); err != nil || gb.GetText() != "" {
+		t.Errorf("undo: got %q, %v", gb.GetText(), err)
+	}
+	remove()
+	if err := gb.InsertAt(0, "# "); err != nil {
+		t.Errorf("removed hook still vetoes: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package buffer
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// contentHandler serves the content of a buffer over HTTP
+type contentHandler struct {
+	gb          *GapBuffer
+	contentType string
+}
+
+// NewHTTPHandler returns an http.Handler serving the buffer content with a
+// Content-Length, an ETag derived from the buffer and its version, see
+// ETag, and support for Range and conditional requests. The buffer is read
+// while the response is written, so edits from other goroutines must be
+// serialized by the caller.
+func NewHTTPHandler(gb *GapBuffer, contentType string) http.Handler {
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+	return &contentHandler{gb: gb, contentType: contentType}
+}
+
+// NewSnapshotHTTPHandler returns an http.Handler serving the text of a
+// snapshot like NewHTTPHandler. A snapshot never changes, so any number of
+// requests can be served at once while the buffer keeps being edited.
+func NewSnapshotHTTPHandler(s *Snapshot, contentType string) http.Handler {
+	return NewHTTPHandler(s.gb, contentType)
+}
+
+func (h *contentHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", h.contentType)
+	w.Header().Set("ETag", h.gb.ETag())
+	http.ServeContent(w, req, "", time.Time{}, h.gb.SizedReader())
+}
+
+// ETag returns an HTTP entity tag identifying the current content. It
+// combines the version with a random identity the buffer draws on first
+// use, so buffers loaded separately, even from the same file or by
+// another process, never share tags while their versions do. Snapshots
+// share the identity of their buffer; clones draw their own.
+func (gb *GapBuffer) ETag() string {
+	return `"` + strconv.FormatUint(gb.contentIdentity(), 36) + "-" + strconv.Itoa(gb.version) + `"`
+}
+
+// contentIdentity returns the identity of the buffer, drawing it on first
+// use
+func (gb *GapBuffer) contentIdentity() uint64 {
+	for gb.identity == 0 {
+		gb.identity = rand.Uint64()
+	}
+	return gb.identity
+}
+
+// ETag returns the entity tag of the buffer at the version of the
+// snapshot, see GapBuffer.ETag
+func (s *Snapshot) ETag() string {
+	return s.gb.ETag()
+}
@@ -0,0 +1,98 @@
+package buffer_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// serve sends a GET with the given headers to h and returns the response
+func serve(t *testing.T, h http.Handler, header map[string]string) (*http.Response, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/doc", nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	res := rec.Result()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res, string(body)
+}
+
+// TestHTTPHandler checks full, ranged and conditional requests, and that
+// the ETag follows edits
+func TestHTTPHandler(t *testing.T) {
+	gb := newBuffer(t, "0123456789abcdef")
+	h := buffer.NewHTTPHandler(gb, "")
+
+	res, body := serve(t, h, nil)
+	if res.StatusCode != http.StatusOK || body != "0123456789abcdef" || res.ContentLength != 16 {
+		t.Fatalf("full: %d, %q, length %d", res.StatusCode, body, res.ContentLength)
+	}
+	if ct := res.Header.Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("content type %q", ct)
+	}
+	etag := res.Header.Get("ETag")
+	if etag != gb.ETag() {
+		t.Errorf("got ETag %s, want %s", etag, gb.ETag())
+	}
+
+	res, body = serve(t, h, map[string]string{"Range": "bytes=4-7"})
+	if res.StatusCode != http.StatusPartialContent || body != "4567" {
+		t.Errorf("range: %d, %q", res.StatusCode, body)
+	}
+	if cr := res.Header.Get("Content-Range"); cr != "bytes 4-7/16" {
+		t.Errorf("content range %q", cr)
+	}
+	res, _ = serve(t, h, map[string]string{"Range": "bytes=20-30"})
+	if res.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("range past the end: %d", res.StatusCode)
+	}
+	res, _ = serve(t, h, map[string]string{"If-None-Match": etag})
+	if res.StatusCode != http.StatusNotModified {
+		t.Errorf("unchanged: %d", res.StatusCode)
+	}
+
+	gb.InsertAt(0, ">")
+	if gb.ETag() == etag {
+		t.Fatal("ETag unchanged by an edit")
+	}
+	res, body = serve(t, h, map[string]string{"If-None-Match": etag})
+	if res.StatusCode != http.StatusOK || body != ">0123456789abcdef" {
+		t.Errorf("changed: %d, %q", res.StatusCode, body)
+	}
+	res, body = serve(t, h, map[string]string{"Range": "bytes=0-3", "If-Range": etag})
+	if res.StatusCode != http.StatusOK || len(body) != 17 {
+		t.Errorf("stale If-Range: %d, %q", res.StatusCode, body)
+	}
+}
+
+// TestETag checks that snapshots share the tag of their buffer at their
+// version while clones and other buffers get their own
+func TestETag(t *testing.T) {
+	gb := newBuffer(t, "text")
+	s := gb.Snapshot()
+	if s.ETag() != gb.ETag() {
+		t.Errorf("snapshot %s, buffer %s", s.ETag(), gb.ETag())
+	}
+	if gb.Clone().ETag() == gb.ETag() || newBuffer(t, "text").ETag() == gb.ETag() {
+		t.Error("another buffer at the same version shares the tag")
+	}
+
+	etag := gb.ETag()
+	gb.InsertAt(0, "more ")
+	if s.ETag() != etag {
+		t.Errorf("snapshot tag changed to %s", s.ETag())
+	}
+	res, body := serve(t, buffer.NewSnapshotHTTPHandler(s, "text/markdown"), nil)
+	if body != "text" || res.Header.Get("ETag") != etag || res.Header.Get("Content-Type") != "text/markdown" {
+		t.Errorf("snapshot handler: %q, %s, %s", body, res.Header.Get("ETag"), res.Header.Get("Content-Type"))
+	}
+}
@@ -0,0 +1,158 @@
+package buffer
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// DEFAULT_IDLE_BUDGET is the default time an idle task may run per slice
+const DEFAULT_IDLE_BUDGET = 5 * time.Millisecond
+
+// IdleTask does a slice of deferred work, such as precomputing folds. It
+// runs on the goroutine editing the buffer, must return as soon as yield
+// reports true, and returns true once it has nothing left to do.
+type IdleTask func(yield func() bool) (done bool)
+
+// IdleHandle identifies a task scheduled with OnIdle
+type IdleHandle struct {
+	sched  *idleScheduler
+	task   IdleTask
+	budget time.Duration
+	done   bool
+}
+
+// idleScheduler runs idle tasks in slices between edits
+type idleScheduler struct {
+	tasks []*IdleHandle
+	next  int         // task to run first in the next round
+	input atomic.Bool // input arrived, running tasks must yield
+}
+
+// OnIdle schedules task to run in slices of at most budget whenever
+// RunIdle is called, until it reports it is done or is cancelled.
+// A budget <= 0 uses DEFAULT_IDLE_BUDGET.
+func (gb *GapBuffer) OnIdle(task IdleTask, budget time.Duration) *IdleHandle {
+	if budget <= 0 {
+		budget = DEFAULT_IDLE_BUDGET
+	}
+	h := &IdleHandle{sched: &gb.idle, task: task, budget: budget}
+	gb.idle.tasks = append(gb.idle.tasks, h)
+	return h
+}
+
+// Cancel removes the task from the scheduler
+func (h *IdleHandle) Cancel() {
+	h.done = true
+	h.sched.remove(h)
+}
+
+// Done reports whether the task finished or was cancelled
+func (h *IdleHandle) Done() bool {
+	return h.done
+}
+
+// NotifyInput tells running idle tasks to yield because user input is
+// waiting. It is safe to call from any goroutine. Edits notify it as well.
+func (gb *GapBuffer) NotifyInput() {
+	gb.idle.input.Store(true)
+}
+
+// RunIdle gives every scheduled task one slice, stopping early when input
+// is notified, and reports whether tasks remain. Call it from the event
+// loop when there is nothing else to do.
+func (gb *GapBuffer) RunIdle() bool {
+	s := &gb.idle
+	s.input.Store(false)
+
+	for n := len(s.tasks); n > 0 && len(s.tasks) > 0 && !s.input.Load(); n-- {
+		if s.next >= len(s.tasks) {
+			s.next = 0
+		}
+		h := s.tasks[s.next]
+		s.next++
+
+		deadline := time.Now().Add(h.budget)
+		yield := func() bool {
+			return s.input.Load() || h.done || !time.Now().Before(deadline)
+		}
+		if h.task(yield) && !h.done {
+			h.done = true
+			s.remove(h)
+		}
+	}
+	return len(s.tasks) > 0
+}
+
+// remove drops h from the scheduled tasks
+func (s *idleScheduler) remove(h *IdleHandle) {
+	for i, t := range s.tasks {
+		if t == h {
+			s.tasks = append(s.tasks[:i], s.tasks[i+1:]...)
+			if s.next > i {
+				s.next--
+			}
+			return
+		}
+	}
+}
+
+// ScheduleCompaction schedules an idle task merging adjacent chunks that
+// fit in one chunk together, undoing the fragmentation left by many small
+// edits. Edits made meanwhile are fine; the task resumes where it stopped.
+func (gb *GapBuffer) ScheduleCompaction() *IdleHandle {
+	pos := 0
+	return gb.OnIdle(func(yield func() bool) bool {
+		for !yield() {
+			if pos >= gb.length {
+				return true
+			}
+			pos = gb.compactAt(pos)
+		}
+		return false
+	}, 0)
+}
+
+// compactAt merges the chunk containing pos with its successor when they are
+// contiguous and fit in one chunk, and returns where to continue
+func (gb *GapBuffer) compactAt(pos int) int {
+	key, chunk, ok := gb.tree.floor(gb.physical(pos))
+	if !ok {
+		return gb.length
+	}
+	text := chunk.Text
+	nextKey, nextText, found := 0, "", false
+	gb.tree.ascend(key+1, math.MaxInt, func(k int, next *payload, _ measures) bool {
+		nextKey, nextText, found = k, next.Text, true
+		return false
+	})
+	if !found {
+		return gb.length
+	}
+
+	end := gb.logical(key) + len(text)
+	if nextKey != key+len(text) || len(text)+len(nextText) > gb.chunkSize {
+		// Separated by the gap or too large together
+		return end
+	}
+	gb.ownTree()
+	gb.tree.Delete(nextKey)
+	gb.tree.set(key, payload{Text: text + nextText})
+	return gb.logical(key)
+}
+
+// ScheduleGuideBackfill schedules an idle task computing the line guides of
+// the whole buffer ahead of LineGuides calls, a few thousand lines per step
+func (gb *GapBuffer) ScheduleGuideBackfill() *IdleHandle {
+	const step = 4096
+	return gb.OnIdle(func(yield func() bool) bool {
+		for !yield() {
+			want := len(gb.guides.starts) + step
+			gb.extendGuides(want)
+			if len(gb.guides.starts) < want {
+				return true
+			}
+		}
+		return false
+	}, 0)
+}
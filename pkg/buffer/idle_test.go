@@ -0,0 +1,110 @@
+package buffer_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestRunIdle checks that tasks get a slice each round until they are
+// done or cancelled
+func TestRunIdle(t *testing.T) {
+	gb := buffer.New()
+	var order []string
+	counter := func(name string, slices int) buffer.IdleTask {
+		return func(yield func() bool) bool {
+			order = append(order, name)
+			slices--
+			return slices == 0
+		}
+	}
+	a := gb.OnIdle(counter("a", 2), 0)
+	b := gb.OnIdle(counter("b", 5), 0)
+	c := gb.OnIdle(counter("c", 5), 0)
+
+	if !gb.RunIdle() || !gb.RunIdle() {
+		t.Fatal("no tasks left")
+	}
+	if !a.Done() || b.Done() {
+		t.Errorf("done: a %v, b %v", a.Done(), b.Done())
+	}
+	c.Cancel()
+	gb.RunIdle()
+	if got := strings.Join(order, ""); got != "abcabcb" {
+		t.Errorf("got order %q", got)
+	}
+	for gb.RunIdle() {
+	}
+	if !b.Done() || len(order) != 9 {
+		t.Errorf("b done %v after %d slices", b.Done(), len(order))
+	}
+}
+
+// TestRunIdleYield checks that a task yields once its budget is spent and
+// that input ends the round
+func TestRunIdleYield(t *testing.T) {
+	gb := buffer.New()
+	var slow int
+	gb.OnIdle(func(yield func() bool) bool {
+		slow++
+		start := time.Now()
+		for !yield() {
+			if time.Since(start) > time.Second {
+				t.Error("budget not enforced")
+				return true
+			}
+		}
+		return false
+	}, time.Millisecond)
+	ran := false
+	gb.OnIdle(func(yield func() bool) bool {
+		ran = true
+		return true
+	}, 0)
+	gb.OnIdle(func(yield func() bool) bool {
+		gb.NotifyInput()
+		if !yield() {
+			t.Error("no yield after input")
+		}
+		return false
+	}, 0)
+
+	// The third task notifies input, and the edit of the fourth one does
+	// too, so each round is cut short before the first task runs again
+	gb.RunIdle()
+	typed := gb.OnIdle(func(yield func() bool) bool {
+		gb.InsertAt(0, "typed")
+		return yield()
+	}, 0)
+	gb.RunIdle()
+	if !typed.Done() {
+		t.Error("edit did not make the task yield")
+	}
+	if slow != 1 || !ran {
+		t.Errorf("slow task ran %d times, quick task ran %v", slow, ran)
+	}
+}
+
+// TestScheduleCompaction checks that compaction merges chunks fragmented
+// by small edits and keeps the text
+func TestScheduleCompaction(t *testing.T) {
+	gb := newBuffer(t, strings.Repeat("0123456789\n", 5000))
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		gb.InsertAt(rng.Intn(gb.Length()+1), "x")
+	}
+	text, before := gb.GetText(), len(gb.Chunks())
+
+	h := gb.ScheduleCompaction()
+	for gb.RunIdle() {
+	}
+	if !h.Done() || gb.GetText() != text {
+		t.Fatal("compaction did not finish or changed the text")
+	}
+	if after := len(gb.Chunks()); after >= before {
+		t.Errorf("got %d chunks, was %d", after, before)
+	}
+}
@@ -0,0 +1,127 @@
+package buffer
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Chunks returns copies of the stored chunks in position order, with Pos
+// set to their logical position. The layout is deterministic: it depends
+// only on the chunk size and the sequence of operations applied, never on
+// timing or map order, so it can be used in golden tests.
+func (gb *GapBuffer) Chunks() []Chunk {
+	var chunks []Chunk
+	gb.forEachChunk(0, gb.length, func(pos int, text string) bool {
+		chunks = append(chunks, Chunk{Text: text, Pos: pos})
+		return true
+	})
+	return chunks
+}
+
+// RandomizeLayout re-splits the text into chunks of pseudo-random sizes up
+// to the chunk size and moves the gap to a random chunk boundary. The same
+// seed always produces the same layout. The text, version and history are
+// unchanged; it is meant for tests checking that results do not depend on
+// how the text happens to be chunked.
+func (gb *GapBuffer) RandomizeLayout(seed int64) {
+	r := rand.New(rand.NewSource(seed))
+	text := gb.rawText(0, gb.length)
+
+	// Split at rune boundaries where possible, like inserts do
+	var bounds []int
+	for i := 0; i < len(text); {
+		end := chunkEnd(text, i, 1+r.Intn(gb.chunkSize))
+		bounds = append(bounds, i)
+		i = end
+	}
+
+	gap := gb.gapEnd - gb.gapStart
+	gb.gapStart = len(text)
+	if len(bounds) > 0 {
+		gb.gapStart = bounds[r.Intn(len(bounds))]
+	}
+	gb.gapEnd = gb.gapStart + gap
+
+	gb.tree = newChunkTree(gb.TreeLayout())
+	gb.treeShared = false
+	for i, start := range bounds {
+		end := len(text)
+		if i+1 < len(bounds) {
+			end = bounds[i+1]
+		}
+		key := gb.physical(start)
+		gb.tree.add(key, payload{Text: text[start:end]})
+	}
+}
+
+// FROZEN_CHUNK_SIZE is the size of the chunks Freeze compacts the text into
+const FROZEN_CHUNK_SIZE = 1024 * 1024
+
+// Freeze compacts the text into large contiguous chunks and closes the gap,
+// which makes reads cheaper and the tree much smaller. It suits buffers that
+// are rarely edited, such as viewer panes or the right-hand side of a diff.
+// The first edit afterwards thaws the buffer back into regular chunks.
+func (gb *GapBuffer) Freeze() {
+	gb.rechunk(FROZEN_CHUNK_SIZE, 0)
+	gb.frozen = true
+}
+
+// Frozen reports whether the buffer was frozen and not edited since
+func (gb *GapBuffer) Frozen() bool {
+	return gb.frozen
+}
+
+// thaw splits a frozen buffer into regular chunks before it is edited
+func (gb *GapBuffer) thaw() {
+	gb.rechunk(gb.chunkSize, DEFAULT_GAP_SIZE)
+	gb.frozen = false
+}
+
+// rechunk rebuilds the tree with the text split into chunks of size bytes,
+// followed by a gap of gap bytes. The chunks share one copy of the text.
+func (gb *GapBuffer) rechunk(size int, gap int) {
+	text := gb.rawText(0, gb.length)
+	gb.gapStart = len(text)
+	gb.gapEnd = gb.gapStart + gap
+
+	gb.tree = newChunkTree(gb.TreeLayout())
+	gb.treeShared = false
+	for i := 0; i < len(text); {
+		end := chunkEnd(text, i, size)
+		gb.tree.add(i, payload{Text: text[i:end]})
+		i = end
+	}
+}
+
+// TreeDepth returns the depth of the tree holding the chunks. A red-black
+// tree of n nodes is never deeper than 2·log2(n+1); a deeper tree means the
+// structure was corrupted.
+func (gb *GapBuffer) TreeDepth() int {
+	return gb.tree.Depth()
+}
+
+// HealTree rebuilds the tree holding the chunks if it is deeper than a
+// red-black tree can be, keeping the chunks and the gap as they are, and
+// reports whether it did. Long-lived buffers can call it periodically.
+func (gb *GapBuffer) HealTree() bool {
+	if float64(gb.tree.Depth()) <= 2*math.Log2(float64(gb.tree.count()+1)) {
+		return false
+	}
+	gb.tree = gb.tree.rebuilt()
+	gb.treeShared = false
+	return true
+}
+
+// SetTreeChecks makes every edit check the depth of the tree and heal it,
+// see HealTree. The check walks the whole tree, so it is meant for stress
+// tests and debugging rather than production use.
+func (gb *GapBuffer) SetTreeChecks(on bool) {
+	gb.treeChecks = on
+}
+
+// checkTree heals the tree after an edit when tree checks are on
+func (gb *GapBuffer) checkTree() {
+	if gb.treeChecks {
+		gb.HealTree()
+	}
+}
@@ -0,0 +1,138 @@
+package buffer_test
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// edited returns a buffer with small chunks after a fixed series of edits
+func edited() *buffer.GapBuffer {
+	gb := buffer.NewWithChunkSize(16)
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		pos := rng.Intn(gb.Length() + 1)
+		gb.Replace(pos, pos+rng.Intn(min(8, gb.Length()-pos)+1), strings.Repeat("é", rng.Intn(6)))
+	}
+	return gb
+}
+
+// checkChunks fails unless the chunks make up the text at their positions
+func checkChunks(t *testing.T, gb *buffer.GapBuffer) []buffer.Chunk {
+	t.Helper()
+	chunks := gb.Chunks()
+	var sb strings.Builder
+	for _, c := range chunks {
+		if c.Pos != sb.Len() {
+			t.Fatalf("chunk at %d, want %d", c.Pos, sb.Len())
+		}
+		sb.WriteString(c.Text)
+	}
+	if sb.String() != string(gb.Bytes()) {
+		t.Fatal("chunks do not make up the text")
+	}
+	return chunks
+}
+
+// TestChunksDeterministic checks that the same edits give the same chunks
+// and that RandomizeLayout depends only on its seed and changes nothing
+// but the layout
+func TestChunksDeterministic(t *testing.T) {
+	a, b := edited(), edited()
+	if !reflect.DeepEqual(checkChunks(t, a), checkChunks(t, b)) {
+		t.Fatal("same edits, different chunks")
+	}
+
+	text, version := a.GetText(), a.Version()
+	a.RandomizeLayout(7)
+	b.RandomizeLayout(7)
+	chunks := checkChunks(t, a)
+	if !reflect.DeepEqual(chunks, checkChunks(t, b)) {
+		t.Error("same seed, different chunks")
+	}
+	b.RandomizeLayout(8)
+	if reflect.DeepEqual(chunks, checkChunks(t, b)) {
+		t.Error("different seeds, same chunks")
+	}
+	if a.GetText() != text || a.Version() != version || !a.CanUndo() {
+		t.Error("randomizing changed the text, version or history")
+	}
+
+	// Edits go on the same on any layout
+	for _, gb := range []*buffer.GapBuffer{a, b} {
+		gb.InsertAt(gb.Length()/2, "middle")
+		gb.Undo()
+		gb.Undo()
+	}
+	if a.GetText() != b.GetText() {
+		t.Error("the layout changed the result of edits")
+	}
+	checkChunks(t, a)
+}
+
+// TestFreeze checks that freezing compacts the chunks without changing the
+// text and that the first edit thaws the buffer
+func TestFreeze(t *testing.T) {
+	gb := edited()
+	text, lines := string(gb.Bytes()), gb.LineCount()
+	gb.Freeze()
+	if !gb.Frozen() || string(gb.Bytes()) != text || gb.LineCount() != lines {
+		t.Fatal("freezing changed the text")
+	}
+	if chunks := checkChunks(t, gb); len(chunks) != 1 {
+		t.Errorf("got %d chunks, want 1", len(chunks))
+	}
+
+	if err := gb.InsertAt(3, "thawed"); err != nil {
+		t.Fatal(err)
+	}
+	if gb.Frozen() || string(gb.Bytes()) != text[:3]+"thawed"+text[3:] {
+		t.Error("edit did not thaw the buffer")
+	}
+	if chunks := checkChunks(t, gb); len(chunks) < 2 {
+		t.Errorf("got %d chunks after thawing", len(chunks))
+	}
+	gb.Undo()
+	if string(gb.Bytes()) != text {
+		t.Error("undo after thawing")
+	}
+}
+
+// TestSetTreeLayout switches layouts back and forth between edits and
+// checks that the chunks, lines and history carry over
+func TestSetTreeLayout(t *testing.T) {
+	gb, want := edited(), edited()
+	if gb.TreeLayout() != buffer.LayoutPointer {
+		t.Errorf("new buffers use layout %v", gb.TreeLayout())
+	}
+	chunks := checkChunks(t, gb)
+	gb.SetTreeLayout(buffer.LayoutCompact)
+	if gb.TreeLayout() != buffer.LayoutCompact {
+		t.Fatalf("got layout %v", gb.TreeLayout())
+	}
+	if !reflect.DeepEqual(checkChunks(t, gb), chunks) || gb.LineCount() != want.LineCount() || gb.RuneLength() != want.RuneLength() {
+		t.Error("switching changed the chunks or the measures")
+	}
+
+	rng := rand.New(rand.NewSource(2))
+	layouts := []buffer.TreeLayout{buffer.LayoutPointer, buffer.LayoutCompact}
+	for i := 0; i < 200; i++ {
+		if i%50 == 0 {
+			gb.SetTreeLayout(layouts[i/50%2])
+		}
+		pos := rng.Intn(gb.Length() + 1)
+		text := strings.Repeat("x\n", rng.Intn(3))
+		gb.InsertAt(pos, text)
+		want.InsertAt(pos, text)
+	}
+	for gb.CanUndo() {
+		gb.Undo()
+		want.Undo()
+	}
+	if !reflect.DeepEqual(checkChunks(t, gb), checkChunks(t, want)) {
+		t.Error("edits differ between layouts")
+	}
+}
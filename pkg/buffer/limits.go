@@ -0,0 +1,158 @@
+package buffer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrLimitExceeded is wrapped by every *LimitError
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// LimitKind names one of the hard limits of a buffer
+type LimitKind int
+
+const (
+	LimitSize       LimitKind = iota // Length of the whole text
+	LimitInsert                      // Length of the text inserted by one edit
+	LimitLineLength                  // Length of a line, without its newline
+)
+
+func (k LimitKind) String() string {
+	switch k {
+	case LimitSize:
+		return "size"
+	case LimitInsert:
+		return "insert"
+	case LimitLineLength:
+		return "line length"
+	}
+	return fmt.Sprintf("LimitKind(%d)", int(k))
+}
+
+// LimitError reports an edit rejected because it would exceed a hard limit.
+// It wraps ErrLimitExceeded.
+type LimitError struct {
+	Kind  LimitKind
+	Max   int // Configured limit
+	Value int // Value the edit would have produced
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s limit exceeded: %d > %d", e.Kind, e.Value, e.Max)
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// Limits are hard limits enforced on every edit, in bytes. A zero field
+// means no limit. They protect services accepting untrusted documents from
+// memory exhaustion.
+type Limits struct {
+	MaxSize       int // Maximum length of the text
+	MaxInsert     int // Maximum length of the text inserted by a single edit
+	MaxLineLength int // Maximum length of a line
+}
+
+// SetLimits sets the hard limits checked before every edit and suggestion.
+// Existing text is not checked: an edit is only rejected when it grows the
+// text past MaxSize or makes a line it touches longer than MaxLineLength
+// and longer than it was.
+func (gb *GapBuffer) SetLimits(limits Limits) {
+	gb.limits = limits
+}
+
+// Limits returns the hard limits set with SetLimits
+func (gb *GapBuffer) Limits() Limits {
+	return gb.limits
+}
+
+// checkLimits returns a *LimitError if applying the edits would exceed a
+// limit. The edits must be sorted and must not overlap.
+func (gb *GapBuffer) checkLimits(edits []Edit) error {
+	limits := gb.limits
+	if limits == (Limits{}) {
+		return nil
+	}
+
+	delta := 0
+	for _, e := range edits {
+		if limits.MaxInsert > 0 && len(e.Text) > limits.MaxInsert {
+			return &LimitError{Kind: LimitInsert, Max: limits.MaxInsert, Value: len(e.Text)}
+		}
+		delta += len(e.Text) - (e.End - e.Start)
+	}
+	if limits.MaxSize > 0 && delta > 0 && gb.length+delta > limits.MaxSize {
+		return &LimitError{Kind: LimitSize, Max: limits.MaxSize, Value: gb.length + delta}
+	}
+
+	if limits.MaxLineLength > 0 {
+		for i := 0; i < len(edits); {
+			// Edits touching the same lines are checked together
+			first, last := gb.lineAt(edits[i].Start), gb.lineAt(edits[i].End)
+			j := i + 1
+			for j < len(edits) && gb.lineAt(edits[j].Start) <= last {
+				last = max(last, gb.lineAt(edits[j].End))
+				j++
+			}
+
+			longest := gb.editedLongestLine(first, edits[i:j])
+			if longest > limits.MaxLineLength && gb.longestLineIn(first, last, longest) < longest {
+				return &LimitError{Kind: LimitLineLength, Max: limits.MaxLineLength, Value: longest}
+			}
+			i = j
+		}
+	}
+	return nil
+}
+
+// editedLongestLine returns the length of the longest line the edits would
+// leave where they touch the text, from line first on. The kept text is
+// measured from line offsets and only the inserted text is scanned.
+func (gb *GapBuffer) editedLongestLine(first int, edits []Edit) int {
+	longest := 0
+	run := edits[0].Start - gb.lineStartOf(first) // length of the line so far
+	for k, e := range edits {
+		text := e.Text
+		for {
+			i := strings.IndexByte(text, '\n')
+			if i < 0 {
+				break
+			}
+			longest = max(longest, run+i)
+			run, text = 0, text[i+1:]
+		}
+		run += len(text)
+
+		// The kept text up to the next edit, or to the end of the line
+		next := gb.lineEnd(e.End)
+		if k+1 < len(edits) {
+			next = edits[k+1].Start
+		}
+		line := gb.lineAt(e.End)
+		if nextLine := gb.lineAt(next); nextLine == line {
+			run += next - e.End
+		} else {
+			longest = max(longest, run+gb.lineStartOf(line+1)-1-e.End)
+			run = next - gb.lineStartOf(nextLine)
+		}
+	}
+	return max(longest, run)
+}
+
+// longestLineIn returns the length of the longest of the lines first to
+// last, stopping early once one is at least atLeast long
+func (gb *GapBuffer) longestLineIn(first int, last int, atLeast int) int {
+	longest := 0
+	start := gb.lineStartOf(first)
+	for line := first; line <= last && longest < atLeast; line++ {
+		end := gb.length
+		if line+1 < gb.lineCount() {
+			end = gb.lineStartOf(line+1) - 1
+		}
+		longest = max(longest, end-start)
+		start = end + 1
+	}
+	return longest
+}
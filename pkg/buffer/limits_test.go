@@ -0,0 +1,111 @@
+package buffer_test
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestLimits checks each limit, and that a line already too long may be
+// edited as long as the edit does not make it longer
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		limits buffer.Limits
+		edits  []buffer.Edit
+		kind   buffer.LimitKind
+		value  int // 0 when the edits are accepted
+	}{
+		{"size", "abc", buffer.Limits{MaxSize: 5}, []buffer.Edit{{Start: 3, End: 3, Text: "def"}}, buffer.LimitSize, 6},
+		{"size shrinking", "abcdef", buffer.Limits{MaxSize: 5}, []buffer.Edit{{Start: 0, End: 2, Text: "x"}}, 0, 0},
+		{"insert", "", buffer.Limits{MaxInsert: 2}, []buffer.Edit{{Start: 0, End: 0, Text: "abc"}}, buffer.LimitInsert, 3},
+		{"line", "short\nline\n", buffer.Limits{MaxLineLength: 10}, []buffer.Edit{{Start: 6, End: 6, Text: "1234567"}}, buffer.LimitLineLength, 11},
+		{"line split", "abcdefghij", buffer.Limits{MaxLineLength: 10}, []buffer.Edit{{Start: 5, End: 5, Text: "12345\n67890"}}, 0, 0},
+		{"line split too long", "abcdefghij", buffer.Limits{MaxLineLength: 10}, []buffer.Edit{{Start: 5, End: 5, Text: "123456\n\n67890"}}, buffer.LimitLineLength, 11},
+		{"lines joined", "aaaa\nbbbb\ncccc", buffer.Limits{MaxLineLength: 10}, []buffer.Edit{{Start: 4, End: 5}, {Start: 9, End: 10}}, buffer.LimitLineLength, 12},
+		{"one line joined", "aaaa\nbbbb\ncccc", buffer.Limits{MaxLineLength: 10}, []buffer.Edit{{Start: 4, End: 5}}, 0, 0},
+		{"long line shortened", "0123456789abcdef\n", buffer.Limits{MaxLineLength: 10}, []buffer.Edit{{Start: 0, End: 2, Text: "x"}}, 0, 0},
+		{"long line grown", "0123456789abcdef\n", buffer.Limits{MaxLineLength: 10}, []buffer.Edit{{Start: 0, End: 0, Text: "x"}}, buffer.LimitLineLength, 17},
+		{"long line split", "0123456789abcdef\n", buffer.Limits{MaxLineLength: 10}, []buffer.Edit{{Start: 8, End: 8, Text: "\n"}}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gb := newBuffer(t, tt.text)
+			gb.SetLimits(tt.limits)
+			_, err := gb.ApplyEdits(tt.edits, buffer.OverlapError)
+			if tt.value == 0 {
+				if err != nil {
+					t.Fatalf("got %v, want the edits accepted", err)
+				}
+				return
+			}
+			var le *buffer.LimitError
+			if !errors.As(err, &le) || !errors.Is(err, buffer.ErrLimitExceeded) {
+				t.Fatalf("got %v, want a *LimitError", err)
+			}
+			if le.Kind != tt.kind || le.Value != tt.value {
+				t.Errorf("got %v of %d, want %v of %d", le.Kind, le.Value, tt.kind, tt.value)
+			}
+			if gb.GetText() != tt.text {
+				t.Errorf("text changed to %q", gb.GetText())
+			}
+		})
+	}
+}
+
+// TestLineLengthLimit compares random edits against the lines of the text
+// before and after them
+func TestLineLengthLimit(t *testing.T) {
+	const maxLine = 12
+	rng := rand.New(rand.NewSource(1))
+	pieces := []string{"a", "bc", "defg", "\n", "\r\n", "hijklmnop"}
+	random := func(n int) string {
+		var sb strings.Builder
+		for range n {
+			sb.WriteString(pieces[rng.Intn(len(pieces))])
+		}
+		return sb.String()
+	}
+
+	gb := newBuffer(t, random(200))
+	gb.SetLimits(buffer.Limits{MaxLineLength: maxLine})
+	for i := 0; i < 2000; i++ {
+		text := gb.GetText()
+		start := rng.Intn(len(text) + 1)
+		end := start + rng.Intn(min(8, len(text)-start)+1)
+		inserted := random(rng.Intn(4))
+
+		// The touched lines as they are and as the edit leaves them
+		from := strings.LastIndexByte(text[:start], '\n') + 1
+		to := len(text)
+		if j := strings.IndexByte(text[end:], '\n'); j >= 0 {
+			to = end + j
+		}
+		before := longest(text[from:to])
+		after := longest(text[from:start] + inserted + text[end:to])
+
+		err := gb.Replace(start, end, inserted)
+		var le *buffer.LimitError
+		switch {
+		case after > maxLine && after > before:
+			if !errors.As(err, &le) || le.Value != after {
+				t.Fatalf("edit %d: got %v, want a line length of %d rejected", i, err, after)
+			}
+		case err != nil:
+			t.Fatalf("edit %d: got %v, want the edit accepted", i, err)
+		}
+	}
+}
+
+// longest returns the length of the longest line of text
+func longest(text string) int {
+	n := 0
+	for _, line := range strings.Split(text, "\n") {
+		n = max(n, len(line))
+	}
+	return n
+}
@@ -0,0 +1,191 @@
+package buffer
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidLineEnding is returned for a line ending style that does not exist
+var ErrInvalidLineEnding = errors.New("invalid line ending")
+
+// LineEnding is a line terminator style
+type LineEnding int
+
+const (
+	EOLNone LineEnding = iota // No line terminators
+	EOLLF                     // \n, as on Unix
+	EOLCRLF                   // \r\n, as on Windows
+	EOLCR                     // \r alone, as on classic Mac OS
+)
+
+// String returns the usual name of the style
+func (e LineEnding) String() string {
+	switch e {
+	case EOLLF:
+		return "LF"
+	case EOLCRLF:
+		return "CRLF"
+	case EOLCR:
+		return "CR"
+	}
+	return "none"
+}
+
+// text returns the terminator of the style
+func (e LineEnding) text() string {
+	switch e {
+	case EOLCRLF:
+		return "\r\n"
+	case EOLCR:
+		return "\r"
+	}
+	return "\n"
+}
+
+// LineEndingCounts holds the number of line terminators of each style
+type LineEndingCounts struct {
+	LF   int
+	CRLF int
+	CR   int
+}
+
+// Dominant returns the most frequent style, preferring LF, then CRLF, on a
+// tie, or EOLNone if there are no terminators
+func (c LineEndingCounts) Dominant() LineEnding {
+	switch {
+	case c.LF == 0 && c.CRLF == 0 && c.CR == 0:
+		return EOLNone
+	case c.LF >= c.CRLF && c.LF >= c.CR:
+		return EOLLF
+	case c.CRLF >= c.CR:
+		return EOLCRLF
+	}
+	return EOLCR
+}
+
+// Mixed reports whether more than one style occurs
+func (c LineEndingCounts) Mixed() bool {
+	styles := 0
+	for _, n := range []int{c.LF, c.CRLF, c.CR} {
+		if n > 0 {
+			styles++
+		}
+	}
+	return styles > 1
+}
+
+// CountLineEndings counts the line terminators of the buffer by style
+func (gb *GapBuffer) CountLineEndings() LineEndingCounts {
+	var c LineEndingCounts
+	gb.eachLineEnding(0, gb.length, func(_ int, eol LineEnding) {
+		switch eol {
+		case EOLLF:
+			c.LF++
+		case EOLCRLF:
+			c.CRLF++
+		case EOLCR:
+			c.CR++
+		}
+	})
+	return c
+}
+
+// DetectLineEnding returns the dominant line ending style of the buffer and
+// whether other styles occur as well
+func (gb *GapBuffer) DetectLineEnding() (dominant LineEnding, mixed bool) {
+	c := gb.CountLineEndings()
+	return c.Dominant(), c.Mixed()
+}
+
+// ConvertLineEndings converts every line terminator of the buffer to eol,
+// see ConvertLineEndingsRange
+func (gb *GapBuffer) ConvertLineEndings(eol LineEnding) (err error) {
+	defer guard("ConvertLineEndings", noPos, gb.length, &err)
+	return gb.convertLineEndings(0, gb.length, eol)
+}
+
+// ConvertLineEndingsRange converts the line terminators in [start, end) to
+// eol, as one undo step. A \r\n the range boundary splits is converted as a
+// whole. Only the bytes that differ are edited, so markers and the line
+// index follow along as with any edit. The line index breaks lines at \n
+// only: converting to EOLCR joins the lines for it, as for text loaded with
+// CR endings.
+func (gb *GapBuffer) ConvertLineEndingsRange(start int, end int, eol LineEnding) (err error) {
+	defer guard("ConvertLineEndingsRange", start, gb.length, &err)
+
+	if start < 0 || end > gb.length || start > end {
+		return ErrInvalidRange
+	}
+	return gb.convertLineEndings(start, end, eol)
+}
+
+func (gb *GapBuffer) convertLineEndings(start int, end int, eol LineEnding) error {
+	if eol != EOLLF && eol != EOLCRLF && eol != EOLCR {
+		return ErrInvalidLineEnding
+	}
+
+	to := eol.text()
+	var edits []Edit
+	gb.eachLineEnding(start, end, func(pos int, found LineEnding) {
+		if found == eol {
+			return
+		}
+		// Keep the byte the terminators share, so \n and \r\n differ by an
+		// inserted or deleted \r
+		from := found.text()
+		switch {
+		case strings.HasSuffix(to, from):
+			edits = append(edits, Edit{Start: pos, End: pos, Text: to[:len(to)-len(from)]})
+		case strings.HasSuffix(from, to):
+			edits = append(edits, Edit{Start: pos, End: pos + len(from) - len(to)})
+		case strings.HasPrefix(to, from):
+			edits = append(edits, Edit{Start: pos + len(from), End: pos + len(from), Text: to[len(from):]})
+		case strings.HasPrefix(from, to):
+			edits = append(edits, Edit{Start: pos + len(to), End: pos + len(from)})
+		default:
+			edits = append(edits, Edit{Start: pos, End: pos + len(from), Text: to})
+		}
+	})
+	if len(edits) == 0 {
+		return nil
+	}
+	_, err := gb.ApplyEdits(edits, OverlapError)
+	return err
+}
+
+// eachLineEnding calls fn with the position and style of every line
+// terminator in [start, end), widening the range so it does not split a
+// \r\n
+func (gb *GapBuffer) eachLineEnding(start int, end int, fn func(pos int, eol LineEnding)) {
+	start, end = gb.snapEOL(start, false), gb.snapEOL(end, true)
+
+	cr := -1 // Position of a \r not yet known to start a \r\n
+	gb.forEachChunk(start, end, func(pos int, text string) bool {
+		for i := 0; i < len(text); i++ {
+			j := strings.IndexAny(text[i:], "\r\n")
+			if j < 0 {
+				break
+			}
+			i += j
+			p := pos + i
+			if cr >= 0 && p == cr+1 && text[i] == '\n' {
+				fn(cr, EOLCRLF)
+				cr = -1
+				continue
+			}
+			if cr >= 0 {
+				fn(cr, EOLCR)
+				cr = -1
+			}
+			if text[i] == '\r' {
+				cr = p
+			} else {
+				fn(p, EOLLF)
+			}
+		}
+		return true
+	})
+	if cr >= 0 {
+		fn(cr, EOLCR)
+	}
+}
@@ -0,0 +1,97 @@
+package buffer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestDetectLineEnding checks counting, including a \r\n split between
+// chunks, and the dominant style
+func TestDetectLineEnding(t *testing.T) {
+	tests := []struct {
+		text     string
+		counts   buffer.LineEndingCounts
+		dominant buffer.LineEnding
+		mixed    bool
+	}{
+		{"", buffer.LineEndingCounts{}, buffer.EOLNone, false},
+		{"a\nb\n", buffer.LineEndingCounts{LF: 2}, buffer.EOLLF, false},
+		{"a\r\nb\r\nc\n", buffer.LineEndingCounts{LF: 1, CRLF: 2}, buffer.EOLCRLF, true},
+		{"a\rb\r\r\n", buffer.LineEndingCounts{CRLF: 1, CR: 2}, buffer.EOLCR, true},
+		{"a\r\nb\n", buffer.LineEndingCounts{LF: 1, CRLF: 1}, buffer.EOLLF, true},
+	}
+	for _, tt := range tests {
+		gb := buffer.NewWithChunkSize(2)
+		gb.InsertAt(0, tt.text)
+		gb.RandomizeLayout(1)
+		if got := gb.CountLineEndings(); got != tt.counts {
+			t.Errorf("%q: got %+v, want %+v", tt.text, got, tt.counts)
+		}
+		if dominant, mixed := gb.DetectLineEnding(); dominant != tt.dominant || mixed != tt.mixed {
+			t.Errorf("%q: got %v, %v, want %v, %v", tt.text, dominant, mixed, tt.dominant, tt.mixed)
+		}
+	}
+}
+
+// TestConvertLineEndings checks conversion between styles as one undo step
+// that only edits the bytes that differ
+func TestConvertLineEndings(t *testing.T) {
+	const text = "a\nb\r\nc\rd"
+	tests := []struct {
+		eol  buffer.LineEnding
+		want string
+	}{
+		{buffer.EOLLF, "a\nb\nc\nd"},
+		{buffer.EOLCRLF, "a\r\nb\r\nc\r\nd"},
+		{buffer.EOLCR, "a\rb\rc\rd"},
+	}
+	for _, tt := range tests {
+		gb := newBuffer(t, text)
+		m, _ := gb.CreateMarker(3, buffer.GravityRight) // on the \r of \r\n
+		if err := gb.ConvertLineEndings(tt.eol); err != nil {
+			t.Fatal(err)
+		}
+		if got := gb.GetText(); got != tt.want {
+			t.Errorf("%v: got %q, want %q", tt.eol, got, tt.want)
+		}
+		if tt.eol == buffer.EOLCRLF && m.Pos() != 4 {
+			t.Errorf("%v: the marker moved to %d", tt.eol, m.Pos())
+		}
+		gb.Undo()
+		if got := gb.GetText(); got != text {
+			t.Errorf("%v: undo gave %q", tt.eol, got)
+		}
+	}
+
+	gb := newBuffer(t, text)
+	version := gb.Version()
+	if err := gb.ConvertLineEndings(buffer.EOLNone); !errors.Is(err, buffer.ErrInvalidLineEnding) {
+		t.Errorf("EOLNone: got %v", err)
+	}
+	if gb.Version() != version {
+		t.Errorf("a failed conversion edited the buffer")
+	}
+}
+
+// TestConvertLineEndingsRange checks that only the terminators in range
+// are converted and that a \r\n split by the range is taken whole
+func TestConvertLineEndingsRange(t *testing.T) {
+	gb := newBuffer(t, "a\r\nb\r\nc\r\n")
+	if err := gb.ConvertLineEndingsRange(2, 4, buffer.EOLLF); err != nil {
+		t.Fatal(err)
+	}
+	if got := gb.GetText(); got != "a\nb\r\nc\r\n" {
+		t.Errorf("got %q", got)
+	}
+	if err := gb.ConvertLineEndingsRange(7, 8, buffer.EOLCR); err != nil {
+		t.Fatal(err)
+	}
+	if got := gb.GetText(); got != "a\nb\r\nc\r" {
+		t.Errorf("got %q", got)
+	}
+	if err := gb.ConvertLineEndingsRange(3, 2, buffer.EOLLF); !errors.Is(err, buffer.ErrInvalidRange) {
+		t.Errorf("reversed range: got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package buffer
+
+import "errors"
+
+// ErrUnknownLineID is returned for a line ID that was never handed out or
+// whose line was deleted
+var ErrUnknownLineID = errors.New("unknown line id")
+
+// LineID identifies a line through edits: inserting or deleting other lines
+// does not change it, only deleting the line itself does. When an edit
+// splits a line, the part before the edit keeps the ID, or the part after
+// it if the edit starts at the beginning of the line, and the other lines
+// get new IDs. When lines are joined, the first one keeps its ID unless
+// the join starts at its beginning, in which case the last one does.
+type LineID uint64
+
+// lineTable maps line numbers to IDs and IDs to attached data. It is only
+// kept once line IDs are used.
+type lineTable struct {
+	ids  []LineID
+	next LineID
+	data map[LineID]interface{}
+}
+
+// newID returns an ID that was never handed out before
+func (t *lineTable) newID() LineID {
+	t.next++
+	return t.next
+}
+
+// LineID returns the ID of the zero-based line
+func (gb *GapBuffer) LineID(line int) (id LineID, err error) {
+	defer guard("LineID", line, gb.length, &err)
+
+	t := gb.ensureLineIDs()
+	if line < 0 || line >= len(t.ids) {
+		return 0, ErrLineOutOfRange
+	}
+	return t.ids[line], nil
+}
+
+// LineOfID returns the current zero-based line of id. It reports false if
+// the line no longer exists.
+func (gb *GapBuffer) LineOfID(id LineID) (int, bool) {
+	for line, lineID := range gb.ensureLineIDs().ids {
+		if lineID == id {
+			return line, true
+		}
+	}
+	return 0, false
+}
+
+// SetLineData attaches value to the line with id, replacing what was
+// attached before, such as a breakpoint or coverage count. The value moves
+// with the line and is dropped when the line is deleted. When a line is
+// joined to the end of another, the other keeps its own value, or takes
+// the one of the joined line if it had none.
+func (gb *GapBuffer) SetLineData(id LineID, value interface{}) (err error) {
+	defer guard("SetLineData", noPos, gb.length, &err)
+
+	if _, ok := gb.LineOfID(id); !ok {
+		return ErrUnknownLineID
+	}
+	gb.ensureLineIDs().data[id] = value
+	return nil
+}
+
+// LineData returns the value attached to the line with id
+func (gb *GapBuffer) LineData(id LineID) (interface{}, bool) {
+	value, ok := gb.ensureLineIDs().data[id]
+	return value, ok
+}
+
+// DeleteLineData removes the value attached to the line with id
+func (gb *GapBuffer) DeleteLineData(id LineID) {
+	delete(gb.ensureLineIDs().data, id)
+}
+
+// EachLineData calls fn for every line with data attached, in line order,
+// until fn returns false
+func (gb *GapBuffer) EachLineData(fn func(line int, id LineID, value interface{}) bool) {
+	t := gb.ensureLineIDs()
+	for line, id := range t.ids {
+		if value, ok := t.data[id]; ok && !fn(line, id, value) {
+			return
+		}
+	}
+}
+
+// ensureLineIDs returns the line table, numbering the lines on first use
+func (gb *GapBuffer) ensureLineIDs() *lineTable {
+	if gb.lineIDs == nil {
+		t := &lineTable{data: make(map[LineID]interface{})}
+		t.ids = make([]LineID, gb.lineCount())
+		for i := range t.ids {
+			t.ids[i] = t.newID()
+		}
+		gb.lineIDs = t
+	}
+	return gb.lineIDs
+}
+
+// updateLineIDs replaces the IDs of the lines op touched, keeping the
+// identity of the lines whose text partly survived
+func (gb *GapBuffer) updateLineIDs(op Operation) {
+	t := gb.lineIDs
+	if t == nil {
+		return
+	}
+
+	first := gb.lineAt(op.Pos)
+	added := gb.lineAt(op.Pos+op.Inserted) - first
+	removed := added + len(t.ids) - gb.lineCount()
+	old := t.ids[first : first+removed+1]
+
+	ids := make([]LineID, added+1)
+	for i := range ids {
+		ids[i] = t.newID()
+	}
+	last := old[len(old)-1]
+	headKept := op.Pos > gb.lineStartOf(first)
+	if headKept {
+		ids[0] = old[0]
+	}
+	if !headKept || (added > 0 && last != old[0]) {
+		ids[added] = last
+	}
+
+	// Drop the data of the lines that were deleted, except that a line
+	// joined to the end of another is merged into it
+	if headKept && added == 0 && removed > 0 {
+		if _, taken := t.data[old[0]]; !taken {
+			if value, ok := t.data[last]; ok {
+				t.data[old[0]] = value
+			}
+		}
+	}
+	for _, id := range old {
+		if id != ids[0] && id != ids[added] {
+			delete(t.data, id)
+		}
+	}
+
+	tail := t.ids[first+removed+1:]
+	t.ids = append(append(t.ids[:first:first], ids...), tail...)
+}
@@ -0,0 +1,113 @@
+package buffer_test
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// lineIDs returns the IDs of all lines
+func lineIDs(t *testing.T, gb *buffer.GapBuffer) []buffer.LineID {
+	t.Helper()
+	ids := make([]buffer.LineID, gb.LineCount())
+	for i := range ids {
+		id, err := gb.LineID(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = id
+	}
+	return ids
+}
+
+// TestLineIDs checks which lines keep their IDs and data through inserts,
+// splits, joins and deletions
+func TestLineIDs(t *testing.T) {
+	gb := newBuffer(t, "zero\none\ntwo\nthree")
+	ids := lineIDs(t, gb)
+	gb.SetLineData(ids[1], "breakpoint")
+	gb.SetLineData(ids[3], "bookmark")
+
+	// Lines inserted above move the others down
+	gb.InsertAt(0, "new\nlines\n")
+	if line, ok := gb.LineOfID(ids[1]); !ok || line != 3 {
+		t.Errorf("line one at %d, %v", line, ok)
+	}
+
+	// A split keeps the ID on the part before the edit, or after it at
+	// the start of a line
+	gb.InsertAt(gb.Find("ne\n", 0), "\n")   // "o" + "ne"
+	gb.InsertAt(gb.Find("two", 0), "1.5\n") // before "two"
+	got := lineIDs(t, gb)
+	if got[3] != ids[1] || got[6] != ids[2] || got[4] == ids[1] || got[5] == ids[2] {
+		t.Errorf("after splits: %v from %v", got, ids)
+	}
+
+	// Deleting a whole line drops its ID and data
+	start := gb.Find("three", 0)
+	gb.DeleteAt(start-4, 4) // "two\n"
+	if _, ok := gb.LineOfID(ids[2]); ok {
+		t.Error("deleted line still known")
+	}
+	if value, ok := gb.LineData(ids[3]); !ok || value != "bookmark" {
+		t.Errorf("data of the next line: %v, %v", value, ok)
+	}
+
+	// Joining keeps the first line, which takes the data of the joined one
+	// when it has none
+	gb.DeleteAt(gb.Find("\nthree", 0), 1)
+	line, _ := gb.LineOfID(ids[3])
+	var lines []int
+	gb.EachLineData(func(line int, id buffer.LineID, value interface{}) bool {
+		lines = append(lines, line)
+		return true
+	})
+	if _, ok := gb.LineOfID(ids[3]); ok || len(lines) != 2 {
+		t.Errorf("joined line still known at %d, data on lines %v", line, lines)
+	}
+	if value, _ := gb.LineData(got[5]); value != "bookmark" {
+		t.Errorf("joined data: got %v", value)
+	}
+	gb.DeleteLineData(got[5])
+	if _, ok := gb.LineData(got[5]); ok {
+		t.Error("data kept after DeleteLineData")
+	}
+
+	if _, err := gb.LineID(gb.LineCount()); !errors.Is(err, buffer.ErrLineOutOfRange) {
+		t.Errorf("line past the end: %v", err)
+	}
+	if err := gb.SetLineData(ids[2], 1); !errors.Is(err, buffer.ErrUnknownLineID) {
+		t.Errorf("data on a deleted line: %v", err)
+	}
+}
+
+// TestLineIDsEdits checks that every line has a distinct ID through random
+// edits and that untouched lines keep theirs
+func TestLineIDsEdits(t *testing.T) {
+	gb := newBuffer(t, "a\nb\nc\nd\n")
+	rng := rand.New(rand.NewSource(1))
+	pieces := []string{"\n", "x", "y\nz", "\n\n", ""}
+	for i := 0; i < 500; i++ {
+		before := lineIDs(t, gb)
+		pos := rng.Intn(gb.Length() + 1)
+		end := pos + rng.Intn(min(4, gb.Length()-pos)+1)
+		firstLine, _, _ := gb.OffsetToLineCol(pos)
+		gb.Replace(pos, end, pieces[rng.Intn(len(pieces))])
+
+		after := lineIDs(t, gb)
+		seen := map[buffer.LineID]bool{}
+		for _, id := range after {
+			if seen[id] {
+				t.Fatalf("edit %d: ID %d given twice in %v", i, id, after)
+			}
+			seen[id] = true
+		}
+		for line := 0; line < firstLine; line++ {
+			if after[line] != before[line] {
+				t.Fatalf("edit %d: line %d above the edit changed ID", i, line)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package buffer
+
+import "strings"
+
+// LineCount returns the number of lines in the buffer. A text ending with
+// a newline has an empty last line after it, and so does a text given a
+// virtual final newline by SetFinalNewline.
+func (gb *GapBuffer) LineCount() int {
+	if gb.hasVirtualNewline() {
+		return gb.lineCount() + 1
+	}
+	return gb.lineCount()
+}
+
+// lineCount returns the number of lines of the stored text
+func (gb *GapBuffer) lineCount() int {
+	return gb.tree.total().lines + 1
+}
+
+// OffsetToLineCol returns the zero-based line containing pos and the byte
+// offset of pos within that line. A \r\n is one line terminator: a
+// position between its \r and \n is taken to be before the \r. The end
+// of the buffer is on the line a virtual final newline ends.
+func (gb *GapBuffer) OffsetToLineCol(pos int) (line int, col int, err error) {
+	defer guard("OffsetToLineCol", pos, gb.length, &err)
+
+	if pos < 0 || pos > gb.length {
+		return 0, 0, ErrPositionOutOfRange
+	}
+	pos = gb.snapEOL(pos, false)
+	line = gb.lineAt(pos)
+	return line, pos - gb.lineStartOf(line), nil
+}
+
+// LineColToOffset returns the position of the byte at offset col in the
+// zero-based line. col may point at the end of the line, but not past it.
+// The empty line after a virtual final newline is at the end of the buffer.
+func (gb *GapBuffer) LineColToOffset(line int, col int) (pos int, err error) {
+	defer guard("LineColToOffset", line, gb.length, &err)
+
+	r, err := gb.shownLineRange(line)
+	if err != nil {
+		return 0, err
+	}
+	if col < 0 || col > r.Len() {
+		return 0, ErrPositionOutOfRange
+	}
+	return r.Start + col, nil
+}
+
+// LineRange returns the range of the zero-based line, excluding its line
+// terminator, \n or \r\n. The empty line after a virtual final newline
+// is an empty range at the end of the buffer.
+func (gb *GapBuffer) LineRange(line int) (r Range, err error) {
+	defer guard("LineRange", line, gb.length, &err)
+	return gb.shownLineRange(line)
+}
+
+// shownLineRange is lineRange counting the empty line after a virtual
+// final newline
+func (gb *GapBuffer) shownLineRange(line int) (Range, error) {
+	if line == gb.lineCount() && gb.hasVirtualNewline() {
+		return Range{Start: gb.length, End: gb.length}, nil
+	}
+	return gb.lineRange(line)
+}
+
+func (gb *GapBuffer) lineRange(line int) (Range, error) {
+	if line < 0 || line >= gb.lineCount() {
+		return Range{}, ErrLineOutOfRange
+	}
+	start := gb.lineStartOf(line)
+	end := gb.length
+	if line+1 < gb.lineCount() {
+		end = gb.lineStartOf(line+1) - 1
+		if end > start && gb.rawText(end-1, end) == "\r" {
+			end--
+		}
+	}
+	return Range{Start: start, End: end}, nil
+}
+
+// snapEOL moves a position between the \r and \n of a \r\n before the
+// \r, or after the \n if forward is set, and returns other positions as
+// they are
+func (gb *GapBuffer) snapEOL(pos int, forward bool) int {
+	if pos <= 0 || pos >= gb.length || gb.rawText(pos-1, pos+1) != "\r\n" {
+		return pos
+	}
+	if forward {
+		return pos + 1
+	}
+	return pos - 1
+}
+
+// lineAt returns the number of newlines before pos, using the newline
+// counts the tree keeps per subtree
+func (gb *GapBuffer) lineAt(pos int) int {
+	chunk, before, _, ok, _ := gb.tree.seek(func(before measures, m measures) bool {
+		return pos < before.size+m.size
+	})
+	if !ok {
+		return before.lines
+	}
+	return before.lines + strings.Count(chunk.Text[:pos-before.size], "\n")
+}
+
+// lineStartOf returns the position of the first byte of the zero-based line,
+// which must exist
+func (gb *GapBuffer) lineStartOf(line int) int {
+	if line == 0 {
+		return 0
+	}
+
+	// Find the line-th newline
+	chunk, before, _, ok, _ := gb.tree.seek(func(before measures, m measures) bool {
+		return line <= before.lines+m.lines
+	})
+	if !ok {
+		return gb.length
+	}
+	text := chunk.Text
+	i := -1
+	for line -= before.lines; line > 0; line-- {
+		i += 1 + strings.IndexByte(text[i+1:], '\n')
+	}
+	return before.size + i + 1
+}
+
+// lineStart returns the position of the first byte of the line containing pos
+func (gb *GapBuffer) lineStart(pos int) int {
+	return gb.lineStartOf(gb.lineAt(pos))
+}
+
+// lineEnd returns the position of the newline ending the line containing
+// pos, or the buffer length for the last line
+func (gb *GapBuffer) lineEnd(pos int) int {
+	line := gb.lineAt(pos)
+	if line+1 >= gb.lineCount() {
+		return gb.length
+	}
+	return gb.lineStartOf(line+1) - 1
+}
+
+// inkBefore returns the number of non-whitespace bytes before pos, using the
+// counts the tree keeps per subtree
+func (gb *GapBuffer) inkBefore(pos int) int {
+	chunk, before, _, ok, _ := gb.tree.seek(func(before measures, m measures) bool {
+		return pos < before.size+m.size
+	})
+	if !ok {
+		return before.ink
+	}
+	prefix := chunk.Text[:pos-before.size]
+	return before.ink + textInk(prefix, strings.Count(prefix, "\n"))
+}
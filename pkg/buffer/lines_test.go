@@ -0,0 +1,123 @@
+package buffer_test
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestLineIndex checks the conversions on a text mixing \n and \r\n, and
+// their errors
+func TestLineIndex(t *testing.T) {
+	gb := newBuffer(t, "ab\r\ncd\n\nef")
+	if gb.LineCount() != 4 {
+		t.Errorf("got %d lines, want 4", gb.LineCount())
+	}
+
+	ranges := []buffer.Range{{Start: 0, End: 2}, {Start: 4, End: 6}, {Start: 7, End: 7}, {Start: 8, End: 10}}
+	for line, want := range ranges {
+		if got, err := gb.LineRange(line); err != nil || got != want {
+			t.Errorf("line %d: got %+v, %v, want %+v", line, got, err, want)
+		}
+	}
+
+	tests := []struct{ pos, line, col int }{
+		{0, 0, 0}, {2, 0, 2}, {3, 0, 2}, {4, 1, 0}, {6, 1, 2}, {7, 2, 0}, {10, 3, 2},
+	}
+	for _, tt := range tests {
+		line, col, err := gb.OffsetToLineCol(tt.pos)
+		if err != nil || line != tt.line || col != tt.col {
+			t.Errorf("pos %d: got %d:%d, %v, want %d:%d", tt.pos, line, col, err, tt.line, tt.col)
+		}
+	}
+	if pos, err := gb.LineColToOffset(1, 2); err != nil || pos != 6 {
+		t.Errorf("1:2: got %d, %v", pos, err)
+	}
+
+	if _, _, err := gb.OffsetToLineCol(11); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("offset past the end: %v", err)
+	}
+	if _, err := gb.LineColToOffset(0, 3); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("column past the end of the line: %v", err)
+	}
+	if _, err := gb.LineRange(4); !errors.Is(err, buffer.ErrLineOutOfRange) {
+		t.Errorf("line past the end: %v", err)
+	}
+	if _, err := gb.LineColToOffset(-1, 0); !errors.Is(err, buffer.ErrLineOutOfRange) {
+		t.Errorf("negative line: %v", err)
+	}
+}
+
+// TestLineIndexEdits checks the index against splitting the text afresh
+// through random edits
+func TestLineIndexEdits(t *testing.T) {
+	gb := newBuffer(t, "")
+	rng := rand.New(rand.NewSource(1))
+	pieces := []string{"\n", "x", "line\n", "\r\n", "a\nb\nc", strings.Repeat("y", 300)}
+	for i := 0; i < 500; i++ {
+		if gb.Length() > 0 && rng.Intn(3) == 0 {
+			pos := rng.Intn(gb.Length())
+			if err := gb.DeleteAt(pos, rng.Intn(min(50, gb.Length()-pos))+1); err != nil {
+				t.Fatal(err)
+			}
+		} else if err := gb.InsertAt(rng.Intn(gb.Length()+1), pieces[rng.Intn(len(pieces))]); err != nil {
+			t.Fatal(err)
+		}
+
+		text := string(gb.Bytes())
+		lines := strings.Split(text, "\n")
+		if gb.LineCount() != len(lines) {
+			t.Fatalf("edit %d: got %d lines, want %d", i, gb.LineCount(), len(lines))
+		}
+		start := 0
+		for line, s := range lines {
+			want := buffer.Range{Start: start, End: start + len(strings.TrimSuffix(s, "\r"))}
+			if line == len(lines)-1 {
+				want.End = start + len(s)
+			}
+			if got, err := gb.LineRange(line); err != nil || got != want {
+				t.Fatalf("edit %d, line %d: got %+v, %v, want %+v", i, line, got, err, want)
+			}
+			if l, col, err := gb.OffsetToLineCol(start + len(s)/2); err != nil || l != line || start+col > want.End {
+				t.Fatalf("edit %d, line %d: got %d:%d, %v", i, line, l, col, err)
+			}
+			start += len(s) + 1
+		}
+	}
+}
+
+// TestCRLF checks that line and cursor APIs treat \r\n as one terminator
+// while raw edits stay byte-exact
+func TestCRLF(t *testing.T) {
+	gb := newBuffer(t, "a\r")
+	if gb.LineCount() != 1 {
+		t.Errorf("a lone \\r ends a line")
+	}
+	// A \r\n made by two edits is one terminator too
+	gb.InsertAt(2, "\nb")
+	if r, _ := gb.LineRange(0); r != (buffer.Range{Start: 0, End: 1}) || gb.LineCount() != 2 {
+		t.Errorf("got line %+v of %d", r, gb.LineCount())
+	}
+	if _, err := gb.LineColToOffset(0, 2); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("column on the \\r: %v", err)
+	}
+
+	c, _ := gb.NewCursor(2)
+	if c.Pos() != 1 {
+		t.Errorf("cursor inside the \\r\\n at %d", c.Pos())
+	}
+	if c.MoveForward(buffer.UnitByte, 1) != 3 || c.MoveBackward(buffer.UnitByte, 1) != 1 {
+		t.Errorf("byte moves did not step over the \\r\\n")
+	}
+	if err := c.DeleteForward(1); err != nil || gb.GetText() != "ab" {
+		t.Errorf("cursor delete: got %q, %v", gb.GetText(), err)
+	}
+
+	gb = newBuffer(t, "a\r\nb")
+	if err := gb.DeleteAt(2, 1); err != nil || gb.GetText() != "a\rb" || gb.LineCount() != 1 {
+		t.Errorf("raw delete: got %q, %v", gb.GetText(), err)
+	}
+}
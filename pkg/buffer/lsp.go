@@ -0,0 +1,119 @@
+package buffer
+
+import "fmt"
+
+// LSPPosition is a position as the Language Server Protocol sends it: a
+// zero-based line and an offset in UTF-16 code units within that line
+type LSPPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// LSPRange is a range of LSP positions, end exclusive
+type LSPRange struct {
+	Start LSPPosition `json:"start"`
+	End   LSPPosition `json:"end"`
+}
+
+// ContentChange is an LSP TextDocumentContentChangeEvent. A nil Range
+// replaces the whole document with Text.
+type ContentChange struct {
+	Range *LSPRange `json:"range,omitempty"`
+	Text  string    `json:"text"`
+}
+
+// ApplyContentChanges applies the changes of a textDocument/didChange
+// notification in order, each in the coordinates of the text left by the
+// previous ones. Either all changes are applied, as one undo step, or none
+// of them and the error says which one failed.
+func (gb *GapBuffer) ApplyContentChanges(changes []ContentChange) (err error) {
+	defer guard("ApplyContentChanges", noPos, gb.length, &err)
+
+	return gb.Transaction(func() error {
+		for i, c := range changes {
+			r := Range{Start: 0, End: gb.length}
+			if c.Range != nil {
+				var err error
+				if r, err = gb.lspRange(*c.Range); err != nil {
+					return fmt.Errorf("content change %d: %w", i, err)
+				}
+			}
+			if err := gb.replace(r.Start, r.End, c.Text); err != nil {
+				return fmt.Errorf("content change %d: %w", i, err)
+			}
+		}
+		return nil
+	})
+}
+
+// LSPPositionToOffset returns the byte position of p. As the protocol
+// requires, a character past the end of the line means the end of the
+// line; one in the middle of a surrogate pair means the start of its rune.
+func (gb *GapBuffer) LSPPositionToOffset(p LSPPosition) (pos int, err error) {
+	defer guard("LSPPositionToOffset", p.Line, gb.length, &err)
+	return gb.lspOffset(p)
+}
+
+// OffsetToLSPPosition returns the LSP position of pos, for publishing
+// diagnostics or answering requests
+func (gb *GapBuffer) OffsetToLSPPosition(pos int) (p LSPPosition, err error) {
+	defer guard("OffsetToLSPPosition", pos, gb.length, &err)
+
+	if pos < 0 || pos > gb.length {
+		return LSPPosition{}, ErrPositionOutOfRange
+	}
+	line := gb.lineAt(pos)
+	return LSPPosition{Line: line, Character: gb.unitsBefore(pos) - gb.unitsBefore(gb.lineStartOf(line))}, nil
+}
+
+// RangeToLSP returns the LSP range of r
+func (gb *GapBuffer) RangeToLSP(r Range) (lr LSPRange, err error) {
+	defer guard("RangeToLSP", r.Start, gb.length, &err)
+
+	if r.Start > r.End {
+		return LSPRange{}, ErrInvalidRange
+	}
+	start, err := gb.OffsetToLSPPosition(r.Start)
+	if err != nil {
+		return LSPRange{}, err
+	}
+	end, err := gb.OffsetToLSPPosition(r.End)
+	if err != nil {
+		return LSPRange{}, err
+	}
+	return LSPRange{Start: start, End: end}, nil
+}
+
+// lspRange converts an LSP range to byte positions
+func (gb *GapBuffer) lspRange(r LSPRange) (Range, error) {
+	start, err := gb.lspOffset(r.Start)
+	if err != nil {
+		return Range{}, err
+	}
+	end, err := gb.lspOffset(r.End)
+	if err != nil {
+		return Range{}, err
+	}
+	if start > end {
+		return Range{}, ErrInvalidRange
+	}
+	return Range{Start: start, End: end}, nil
+}
+
+// lspOffset converts an LSP position to a byte position. The end of a line
+// is before its terminator, \r\n included.
+func (gb *GapBuffer) lspOffset(p LSPPosition) (int, error) {
+	if p.Character < 0 {
+		return 0, ErrPositionOutOfRange
+	}
+	line, err := gb.lineRange(p.Line)
+	if err != nil {
+		return 0, err
+	}
+
+	units := gb.unitsBefore(line.Start) + p.Character
+	if units >= gb.unitsBefore(line.End) {
+		return line.End, nil
+	}
+	return gb.unitsPos(units), nil
+}
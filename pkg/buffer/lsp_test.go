@@ -0,0 +1,100 @@
+package buffer_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// lspText has a rune outside the BMP, two UTF-16 units and four bytes
+// long, a two-byte rune and a \r\n
+const lspText = "a\U0001F600b\r\nçd\n"
+
+// TestLSPPositions checks converting between byte positions and LSP
+// positions both ways, and the errors
+func TestLSPPositions(t *testing.T) {
+	gb := newBuffer(t, lspText)
+	toOffset := []struct {
+		line, char, want int
+	}{
+		{0, 0, 0}, {0, 1, 1},
+		{0, 2, 1}, // inside the surrogate pair
+		{0, 3, 5}, {0, 4, 6},
+		{0, 99, 6}, // past the end of the line, before the \r\n
+		{1, 1, 10}, {1, 2, 11}, {2, 0, 12},
+	}
+	for _, tt := range toOffset {
+		got, err := gb.LSPPositionToOffset(buffer.LSPPosition{Line: tt.line, Character: tt.char})
+		if err != nil || got != tt.want {
+			t.Errorf("%d:%d: got %d, %v, want %d", tt.line, tt.char, got, err, tt.want)
+		}
+	}
+
+	toLSP := []struct {
+		pos, line, char int
+	}{
+		{0, 0, 0}, {1, 0, 1}, {5, 0, 3}, {6, 0, 4}, {8, 1, 0}, {10, 1, 1}, {12, 2, 0},
+	}
+	for _, tt := range toLSP {
+		got, err := gb.OffsetToLSPPosition(tt.pos)
+		if err != nil || got != (buffer.LSPPosition{Line: tt.line, Character: tt.char}) {
+			t.Errorf("%d: got %+v, %v, want %d:%d", tt.pos, got, err, tt.line, tt.char)
+		}
+	}
+	r, err := gb.RangeToLSP(buffer.Range{Start: 1, End: 10})
+	if want := (buffer.LSPRange{Start: buffer.LSPPosition{Line: 0, Character: 1}, End: buffer.LSPPosition{Line: 1, Character: 1}}); err != nil || r != want {
+		t.Errorf("range: got %+v, %v", r, err)
+	}
+
+	if _, err := gb.LSPPositionToOffset(buffer.LSPPosition{Line: 3}); !errors.Is(err, buffer.ErrLineOutOfRange) {
+		t.Errorf("line past the end: %v", err)
+	}
+	if _, err := gb.LSPPositionToOffset(buffer.LSPPosition{Character: -1}); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("negative character: %v", err)
+	}
+	if _, err := gb.OffsetToLSPPosition(13); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("offset past the end: %v", err)
+	}
+	if _, err := gb.RangeToLSP(buffer.Range{Start: 5, End: 1}); !errors.Is(err, buffer.ErrInvalidRange) {
+		t.Errorf("reversed range: %v", err)
+	}
+}
+
+// TestApplyContentChanges checks that changes apply in order as one undo
+// step, and that a failing change leaves the text untouched
+func TestApplyContentChanges(t *testing.T) {
+	at := func(line, char int) buffer.LSPPosition { return buffer.LSPPosition{Line: line, Character: char} }
+	gb := newBuffer(t, lspText)
+
+	changes := []buffer.ContentChange{
+		{Range: &buffer.LSPRange{Start: at(0, 1), End: at(0, 3)}, Text: "X"},
+		{Range: &buffer.LSPRange{Start: at(0, 2), End: at(0, 2)}, Text: "\U0001F600"},
+		{Range: &buffer.LSPRange{Start: at(1, 2), End: at(2, 0)}, Text: "!"},
+	}
+	if err := gb.ApplyContentChanges(changes); err != nil || gb.GetText() != "aX\U0001F600b\r\nçd!" {
+		t.Fatalf("got %q, %v", gb.GetText(), err)
+	}
+	gb.Undo()
+	if gb.GetText() != lspText {
+		t.Errorf("undo: got %q", gb.GetText())
+	}
+
+	changes = []buffer.ContentChange{
+		{Range: &buffer.LSPRange{Start: at(0, 0), End: at(0, 0)}, Text: "ok"},
+		{Range: &buffer.LSPRange{Start: at(5, 0), End: at(5, 0)}, Text: "bad"},
+	}
+	err := gb.ApplyContentChanges(changes)
+	if !errors.Is(err, buffer.ErrLineOutOfRange) || !strings.Contains(err.Error(), "content change 1") || gb.GetText() != lspText {
+		t.Errorf("got %q, %v", gb.GetText(), err)
+	}
+	changes = []buffer.ContentChange{{Range: &buffer.LSPRange{Start: at(1, 1), End: at(0, 0)}}}
+	if err := gb.ApplyContentChanges(changes); !errors.Is(err, buffer.ErrInvalidRange) {
+		t.Errorf("reversed range: %v", err)
+	}
+
+	if err := gb.ApplyContentChanges([]buffer.ContentChange{{Text: "whole"}}); err != nil || gb.GetText() != "whole" {
+		t.Errorf("full sync: got %q, %v", gb.GetText(), err)
+	}
+}
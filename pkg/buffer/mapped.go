@@ -0,0 +1,137 @@
+package buffer
+
+import (
+	"errors"
+	"math"
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ErrFileTooLarge is returned by NewFromFile for a file that does not fit
+// in the address space
+var ErrFileTooLarge = errors.New("file too large")
+
+// NewFromFile opens the file at path by mapping it read-only into memory.
+// The chunks of the buffer point into the mapping instead of holding copies,
+// and edits only add chunks for the text they insert, like a piece table
+// over the original file, so memory use follows the edits rather than the
+// file size. Opening still reads the file once to count its lines and
+// runes. The file must not be changed by anyone while the buffer uses it;
+// save with an atomic rename rather than by rewriting it in place. Close
+// copies what is still needed and unmaps the file; text returned by Chunks
+// must not be used after it, though snapshots keep the mapping until they
+// are no longer referenced. Where mapping is not supported the file is
+// read into memory. The file is kept open until Close, so SaveFile can
+// copy the text still coming from it, see SaveFile.
+func NewFromFile(path string) (gb *GapBuffer, err error) {
+	defer guard("NewFromFile", noPos, noPos, &err)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if gb == nil || gb.mappedFile != f {
+			f.Close()
+		}
+	}()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > math.MaxInt {
+		return nil, ErrFileTooLarge
+	}
+
+	gb = New()
+	if info.Size() == 0 {
+		return gb, nil
+	}
+	data, err := mapFile(f, int(info.Size()))
+	if err != nil {
+		return nil, err
+	}
+	gb.mapping = data
+	gb.mappingPins = new(atomic.Int32)
+	gb.mappingPins.Store(1)
+	gb.mappedFile = f
+	gb.mappedTime = info.ModTime()
+
+	// Chunks are as large as frozen ones, so even huge files make small trees
+	text := unsafe.String(&data[0], len(data))
+	for i := 0; i < len(text); {
+		end := chunkEnd(text, i, FROZEN_CHUNK_SIZE)
+		gb.tree.add(i, payload{Text: text[i:end]})
+		i = end
+	}
+	gb.length = len(text)
+	gb.gapStart = gb.length
+	gb.gapEnd = gb.length + DEFAULT_GAP_SIZE
+	return gb, nil
+}
+
+// unmap copies the text of the chunks pointing into the mapped file into
+// memory and releases the buffer's pin on the mapping
+func (gb *GapBuffer) unmap() error {
+	if gb.mapping == nil {
+		return nil
+	}
+	// Chunks can be shared with snapshots, so they are replaced rather than
+	// written to
+	gb.ownTree()
+	var keys []int
+	gb.tree.ascend(math.MinInt, math.MaxInt, func(key int, chunk *payload, _ measures) bool {
+		if gb.inMapping(chunk.Text) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	for _, key := range keys {
+		_, chunk, _ := gb.tree.floor(key)
+		gb.tree.set(key, payload{Text: strings.Clone(chunk.Text)})
+	}
+
+	err := errors.Join(unpinMapping(pinnedMapping{gb.mapping, gb.mappingPins}), gb.mappedFile.Close())
+	gb.mapping = nil
+	gb.mappingPins = nil
+	gb.mappedFile = nil
+	return err
+}
+
+// pinnedMapping is a file mapping with the number of its holders
+type pinnedMapping struct {
+	data []byte
+	pins *atomic.Int32
+}
+
+// pinMapping keeps the mapping of the buffer until s is garbage
+// collected, since the snapshot shares chunks pointing into it
+func (gb *GapBuffer) pinMapping(s *GapBuffer) {
+	if gb.mapping == nil {
+		return
+	}
+	gb.mappingPins.Add(1)
+	runtime.AddCleanup(s, func(m pinnedMapping) { unpinMapping(m) }, pinnedMapping{gb.mapping, gb.mappingPins})
+}
+
+// unpinMapping drops a holder of the mapping and unmaps it after the last
+func unpinMapping(m pinnedMapping) error {
+	if m.pins.Add(-1) > 0 {
+		return nil
+	}
+	return unmapFile(m.data)
+}
+
+// inMapping reports whether text points into the file mapped by
+// NewFromFile
+func (gb *GapBuffer) inMapping(text string) bool {
+	if gb.mapping == nil || len(text) == 0 {
+		return false
+	}
+	start := uintptr(unsafe.Pointer(&gb.mapping[0]))
+	p := uintptr(unsafe.Pointer(unsafe.StringData(text)))
+	return p >= start && p < start+uintptr(len(gb.mapping))
+}
@@ -0,0 +1,63 @@
+package buffer_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestNewFromFile opens a file, edits it and checks that the text survives
+// Close and the file being removed
+func TestNewFromFile(t *testing.T) {
+	text := strings.Repeat("mapped \u00E9 line\n", 20000)
+	path := filepath.Join(t.TempDir(), "mapped.txt")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gb, err := buffer.NewFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gb.Length() != len(text) || gb.LineCount() != 20001 || gb.RuneLength() != 14*20000 || gb.CanUndo() {
+		t.Errorf("opened %d bytes, %d lines, %d runes", gb.Length(), gb.LineCount(), gb.RuneLength())
+	}
+
+	want := text[:50000] + "inserted" + text[50010:]
+	gb.Replace(50000, 50010, "inserted")
+	if err := gb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("overwritten"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(path)
+	if gb.GetText() != want {
+		t.Errorf("text changed after Close")
+	}
+}
+
+// TestNewFromFileErrors checks empty and missing files
+func TestNewFromFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "empty.txt")
+	os.WriteFile(path, nil, 0o644)
+	gb, err := buffer.NewFromFile(path)
+	if err != nil || gb.Length() != 0 {
+		t.Fatalf("empty file: %v", err)
+	}
+	gb.InsertAt(0, "x")
+	if err := gb.Close(); err != nil || gb.GetText() != "x" {
+		t.Errorf("empty file: got %q, %v", gb.GetText(), err)
+	}
+
+	_, err = buffer.NewFromFile(filepath.Join(dir, "missing.txt"))
+	var opErr *buffer.OpError
+	if !errors.Is(err, fs.ErrNotExist) || !errors.As(err, &opErr) || opErr.Op != "NewFromFile" {
+		t.Errorf("missing file: got %v", err)
+	}
+}
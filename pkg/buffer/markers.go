@@ -0,0 +1,382 @@
+package buffer
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"sort"
+	"unicode/utf8"
+)
+
+// ErrTagNotComparable is returned by CreateMarkers for a tag that cannot be
+// compared with ==
+var ErrTagNotComparable = errors.New("marker tag not comparable")
+
+// Gravity decides on which side of text inserted exactly at a marker the
+// marker ends up
+type Gravity int
+
+const (
+	GravityLeft  Gravity = iota // Stay before inserted text
+	GravityRight                // Move after inserted text, like a cursor
+)
+
+// Collapse decides where a marker strictly inside replaced text ends up in
+// the replacement
+type Collapse int
+
+const (
+	CollapseGravity      Collapse = iota // Edge the gravity points to: the end for GravityRight
+	CollapseStart                        // Start of the replacement
+	CollapseEnd                          // End of the replacement
+	CollapseProportional                 // Same relative offset in the replacement, on a rune start
+)
+
+// Marker is a position that follows the text around it through edits, for
+// cursors, diagnostics or breakpoints. A marker strictly inside replaced
+// text moves into the replacement as its collapse mode says, which keeps
+// cursors in place through a reformat of the whole buffer with
+// CollapseProportional.
+type Marker struct {
+	host     markerHost
+	pos      int  // position, or its distance to the end of the text if fromEnd
+	fromEnd  bool // the marker lies after the split of the markerIndex
+	gravity  Gravity
+	collapse Collapse
+	tag      any
+	removed  bool
+}
+
+// markerHost is the buffer keeping markers up to date: the GapBuffer that
+// created them, or the MigratedBuffer they moved to with MigrateBackend
+type markerHost interface {
+	Length() int
+	trackedMarkers() *markerIndex
+}
+
+// MarkerSpec describes a marker to create with CreateMarkers
+type MarkerSpec struct {
+	Pos      int
+	Gravity  Gravity
+	Collapse Collapse
+	Tag      any // Comparable value identifying the markers of one provider, see RemoveMarkersInRange
+}
+
+// CreateMarker returns a marker at pos that the buffer keeps up to date
+// until it is removed
+func (gb *GapBuffer) CreateMarker(pos int, gravity Gravity) (m *Marker, err error) {
+	defer guard("CreateMarker", pos, gb.length, &err)
+
+	if pos < 0 || pos > gb.length {
+		return nil, ErrPositionOutOfRange
+	}
+	m = &Marker{host: gb, pos: pos, gravity: gravity}
+	gb.markers.add(m, gb.length)
+	return m, nil
+}
+
+// CreateMarkers creates a marker for every spec, in order, for providers
+// of diagnostics or semantic tokens that place thousands at a time. The
+// markers are allocated together and merged into the index in one pass.
+// If a position is out of range, no marker is created.
+func (gb *GapBuffer) CreateMarkers(specs []MarkerSpec) (markers []*Marker, err error) {
+	defer guard("CreateMarkers", noPos, gb.length, &err)
+
+	for _, spec := range specs {
+		if spec.Pos < 0 || spec.Pos > gb.length {
+			return nil, &OpError{Op: "CreateMarkers", Pos: spec.Pos, Length: gb.length, Err: ErrPositionOutOfRange}
+		}
+		if !comparableTag(spec.Tag) {
+			return nil, &OpError{Op: "CreateMarkers", Pos: spec.Pos, Length: gb.length, Err: ErrTagNotComparable}
+		}
+	}
+	block := make([]Marker, len(specs))
+	markers = make([]*Marker, len(specs))
+	for i, spec := range specs {
+		block[i] = Marker{host: gb, pos: spec.Pos, gravity: spec.Gravity, collapse: spec.Collapse, tag: spec.Tag}
+		markers[i] = &block[i]
+	}
+	gb.markers.addAll(markers, gb.length)
+	return markers, nil
+}
+
+// RemoveMarkers removes all the markers in one pass over the markers of
+// the buffer
+func (gb *GapBuffer) RemoveMarkers(markers []*Marker) {
+	removed := false
+	for _, m := range markers {
+		switch {
+		case m.removed:
+		case m.host != markerHost(gb):
+			m.Remove()
+		default:
+			m.removed = true
+			removed = true
+		}
+	}
+	if removed {
+		gb.dropRemovedMarkers()
+	}
+}
+
+// RemoveMarkersInRange removes the markers tagged tag with a position in
+// r, including its end, and returns how many it removed. Only the markers
+// in r are looked at. Tags are compared
+// with ==, so a provider can use a value of an unexported type of its own,
+// as with context keys, and leave the markers of others alone. Markers
+// without a tag, such as those of CreateMarker and cursors, are never
+// removed this way, and neither is anything for a tag that cannot be
+// compared.
+func (gb *GapBuffer) RemoveMarkersInRange(r Range, tag any) int {
+	if tag == nil || !comparableTag(tag) {
+		return 0
+	}
+	n := 0
+	x := &gb.markers
+	for i := x.search(r.Start, gb.length); i < len(x.markers) && x.at(i, gb.length) <= r.End; i++ {
+		if m := x.markers[i]; m.tag == tag {
+			m.removed = true
+			n++
+		}
+	}
+	if n > 0 {
+		gb.dropRemovedMarkers()
+	}
+	return n
+}
+
+// comparableTag reports whether tag can be compared with == without
+// panicking, which rules out slices, maps and functions, also inside
+// structs, arrays and interfaces
+func comparableTag(tag any) bool {
+	return tag == nil || reflect.ValueOf(tag).Comparable()
+}
+
+// dropRemovedMarkers stops tracking the markers flagged as removed
+func (gb *GapBuffer) dropRemovedMarkers() {
+	gb.markers.dropRemoved(gb.length)
+}
+
+// trackedMarkers returns the markers the buffer keeps up to date
+func (gb *GapBuffer) trackedMarkers() *markerIndex {
+	return &gb.markers
+}
+
+// Pos returns the current position of the marker
+func (m *Marker) Pos() int {
+	if m.fromEnd {
+		return m.host.Length() + m.pos
+	}
+	return m.pos
+}
+
+// Gravity returns the gravity of the marker
+func (m *Marker) Gravity() Gravity {
+	return m.gravity
+}
+
+// Tag returns the tag the marker was created with, nil for none
+func (m *Marker) Tag() any {
+	return m.tag
+}
+
+// Collapse returns the collapse mode of the marker
+func (m *Marker) Collapse() Collapse {
+	return m.collapse
+}
+
+// SetCollapse changes where the marker goes when the text around it is
+// replaced
+func (m *Marker) SetCollapse(c Collapse) {
+	m.collapse = c
+}
+
+// SetPos moves the marker to pos
+func (m *Marker) SetPos(pos int) error {
+	if pos < 0 || pos > m.host.Length() {
+		return ErrPositionOutOfRange
+	}
+	m.moveTo(pos)
+	return nil
+}
+
+// moveTo moves the marker to pos, which must be in range, keeping the
+// index sorted
+func (m *Marker) moveTo(pos int) {
+	if m.removed {
+		m.pos = pos
+		return
+	}
+	x, length := m.host.trackedMarkers(), m.host.Length()
+	x.remove(m, length)
+	m.pos = pos
+	x.add(m, length)
+}
+
+// Remove stops updating the marker. Its position is frozen afterwards.
+func (m *Marker) Remove() {
+	if m.removed {
+		return
+	}
+	m.host.trackedMarkers().remove(m, m.host.Length())
+	m.removed = true
+}
+
+// Removed reports whether Remove was called
+func (m *Marker) Removed() bool {
+	return m.removed
+}
+
+// shiftMarkers moves all markers through op
+func (gb *GapBuffer) shiftMarkers(op Operation) {
+	gb.markers.shift(op, gb.length)
+}
+
+// shifted returns where the marker at pos goes through op
+func (m *Marker) shifted(pos int, op Operation) int {
+	if pos > op.Pos && pos < op.Pos+op.Deleted && m.collapse != CollapseGravity {
+		return collapse(pos, m.collapse, op)
+	}
+	if m.gravity == GravityRight {
+		return shiftStart(pos, op)
+	}
+	return shiftEnd(pos, op)
+}
+
+// collapse maps pos, strictly inside the text op deleted, into the text it
+// inserted
+func collapse(pos int, c Collapse, op Operation) int {
+	switch c {
+	case CollapseStart:
+		return op.Pos
+	case CollapseEnd:
+		return op.Pos + op.Inserted
+	}
+	p := op.Pos + (pos-op.Pos)*op.Inserted/op.Deleted
+	for p > op.Pos && !utf8.RuneStart(op.InsertedText[p-op.Pos]) {
+		p--
+	}
+	return p
+}
+
+// markerIndex keeps the markers of a buffer sorted by position, split the
+// way the buffer splits its text at the gap: the markers before the split
+// store their position, those after it their distance to the end of the
+// text. An edit leaves those distances valid, so it only moves the split
+// to its position, crossing the markers in between, and maps the markers
+// in the range it replaced. Typing among thousands of markers touches few
+// of them, and lookups by position are binary searches.
+type markerIndex struct {
+	markers []*Marker
+	split   int
+}
+
+// at returns the position of the i-th marker in a text of length bytes
+func (x *markerIndex) at(i int, length int) int {
+	if i >= x.split {
+		return length + x.markers[i].pos
+	}
+	return x.markers[i].pos
+}
+
+// search returns the index of the first marker at pos or after it
+func (x *markerIndex) search(pos int, length int) int {
+	return sort.Search(len(x.markers), func(i int) bool { return x.at(i, length) >= pos })
+}
+
+// moveSplit moves the split to index i, converting the markers it crosses
+func (x *markerIndex) moveSplit(i int, length int) {
+	for ; x.split < i; x.split++ {
+		m := x.markers[x.split]
+		m.pos, m.fromEnd = m.pos+length, false
+	}
+	for x.split > i {
+		x.split--
+		m := x.markers[x.split]
+		m.pos, m.fromEnd = m.pos-length, true
+	}
+}
+
+// add tracks m, whose position is set, after the markers at the same
+// position
+func (x *markerIndex) add(m *Marker, length int) {
+	i := x.search(m.pos+1, length)
+	if i <= x.split {
+		x.split++
+	} else {
+		m.pos, m.fromEnd = m.pos-length, true
+	}
+	x.markers = slices.Insert(x.markers, i, m)
+}
+
+// addAll tracks markers, whose positions are set, in one merge
+func (x *markerIndex) addAll(markers []*Marker, length int) {
+	x.moveSplit(len(x.markers), length)
+	added := slices.Clone(markers)
+	slices.SortStableFunc(added, func(a, b *Marker) int { return a.pos - b.pos })
+
+	merged := make([]*Marker, 0, len(x.markers)+len(added))
+	i := 0
+	for _, m := range x.markers {
+		for ; i < len(added) && added[i].pos < m.pos; i++ {
+			merged = append(merged, added[i])
+		}
+		merged = append(merged, m)
+	}
+	x.markers = append(merged, added[i:]...)
+	x.split = len(x.markers)
+}
+
+// remove stops tracking m, leaving it at its current position
+func (x *markerIndex) remove(m *Marker, length int) {
+	pos := m.Pos()
+	for i := x.search(pos, length); i < len(x.markers) && x.at(i, length) == pos; i++ {
+		if x.markers[i] == m {
+			if i < x.split {
+				x.split--
+			}
+			x.markers = slices.Delete(x.markers, i, i+1)
+			m.pos, m.fromEnd = pos, false
+			return
+		}
+	}
+}
+
+// dropRemoved stops tracking the markers flagged as removed in one pass,
+// leaving them at their current positions
+func (x *markerIndex) dropRemoved(length int) {
+	kept, split := x.markers[:0], 0
+	for i, m := range x.markers {
+		if m.removed {
+			m.pos, m.fromEnd = x.at(i, length), false
+			continue
+		}
+		if i < x.split {
+			split++
+		}
+		kept = append(kept, m)
+	}
+	clear(x.markers[len(kept):])
+	x.markers, x.split = kept, split
+}
+
+// shift moves the markers through op, applied to a text that is now length
+// bytes long. Only the markers from op.Pos to the end of the deleted text
+// are mapped; they end up in the inserted text, so the order is kept.
+func (x *markerIndex) shift(op Operation, length int) {
+	old := length - op.Inserted + op.Deleted
+	x.moveSplit(x.search(op.Pos, old), old)
+
+	end := x.split
+	for ; end < len(x.markers); end++ {
+		pos := x.at(end, old)
+		if pos != op.Pos && pos >= op.Pos+op.Deleted {
+			break
+		}
+		m := x.markers[end]
+		m.pos, m.fromEnd = m.shifted(pos, op), false
+	}
+	window := x.markers[x.split:end]
+	slices.SortStableFunc(window, func(a, b *Marker) int { return a.pos - b.pos })
+	x.split = end
+}
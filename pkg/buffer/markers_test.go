@@ -0,0 +1,317 @@
+package buffer_test
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestMarkers checks how markers of either gravity follow inserts and
+// deletes before, at and around them
+func TestMarkers(t *testing.T) {
+	tests := []struct {
+		name        string
+		edit        func(gb *buffer.GapBuffer) error
+		left, right int
+	}{
+		{"insert before", func(gb *buffer.GapBuffer) error { return gb.InsertAt(1, "xx") }, 6, 6},
+		{"insert at", func(gb *buffer.GapBuffer) error { return gb.InsertAt(4, "xx") }, 4, 6},
+		{"insert after", func(gb *buffer.GapBuffer) error { return gb.InsertAt(5, "xx") }, 4, 4},
+		{"delete before", func(gb *buffer.GapBuffer) error { return gb.DeleteAt(0, 2) }, 2, 2},
+		{"delete around", func(gb *buffer.GapBuffer) error { return gb.DeleteAt(2, 4) }, 2, 2},
+		{"delete after", func(gb *buffer.GapBuffer) error { return gb.DeleteAt(4, 2) }, 4, 4},
+		{"replace around", func(gb *buffer.GapBuffer) error { return gb.Replace(3, 6, "abcde") }, 3, 8},
+	}
+	for _, tt := range tests {
+		gb := newBuffer(t, "0123456789")
+		left, _ := gb.CreateMarker(4, buffer.GravityLeft)
+		right, _ := gb.CreateMarker(4, buffer.GravityRight)
+		if err := tt.edit(gb); err != nil {
+			t.Fatal(err)
+		}
+		if left.Pos() != tt.left || right.Pos() != tt.right {
+			t.Errorf("%s: got %d and %d, want %d and %d", tt.name, left.Pos(), right.Pos(), tt.left, tt.right)
+		}
+	}
+}
+
+// TestMarkerLifetime checks moving, undoing around and removing a marker,
+// and the errors for positions out of range
+func TestMarkerLifetime(t *testing.T) {
+	gb := newBuffer(t, "hello")
+	if _, err := gb.CreateMarker(6, buffer.GravityLeft); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("marker past the end: %v", err)
+	}
+	m, err := gb.CreateMarker(5, buffer.GravityRight)
+	if err != nil || m.Gravity() != buffer.GravityRight {
+		t.Fatal(err)
+	}
+	if err := m.SetPos(6); !errors.Is(err, buffer.ErrPositionOutOfRange) || m.Pos() != 5 {
+		t.Errorf("SetPos past the end: %v, at %d", err, m.Pos())
+	}
+	m.SetPos(2)
+
+	gb.InsertAt(0, ">> ")
+	gb.Undo()
+	if m.Pos() != 2 {
+		t.Errorf("after undo: got %d, want 2", m.Pos())
+	}
+
+	m.Remove()
+	m.Remove()
+	gb.InsertAt(0, "x")
+	if !m.Removed() || m.Pos() != 2 {
+		t.Errorf("removed marker moved to %d", m.Pos())
+	}
+}
+
+// TestMarkerCollapse checks where each collapse mode puts a marker inside
+// a replaced range, and that markers on its edges follow their gravity
+func TestMarkerCollapse(t *testing.T) {
+	tests := []struct {
+		collapse buffer.Collapse
+		gravity  buffer.Gravity
+		want     int
+	}{
+		{buffer.CollapseGravity, buffer.GravityLeft, 2},
+		{buffer.CollapseGravity, buffer.GravityRight, 8},
+		{buffer.CollapseStart, buffer.GravityRight, 2},
+		{buffer.CollapseEnd, buffer.GravityLeft, 8},
+		// Halfway is byte 3 of "ééé", inside a rune, so back to 2
+		{buffer.CollapseProportional, buffer.GravityLeft, 4},
+	}
+	for _, tt := range tests {
+		gb := newBuffer(t, "ab012345cd")
+		markers, err := gb.CreateMarkers([]buffer.MarkerSpec{
+			{Pos: 5, Gravity: tt.gravity, Collapse: tt.collapse},
+			{Pos: 2, Gravity: buffer.GravityLeft, Collapse: tt.collapse},
+			{Pos: 8, Gravity: buffer.GravityRight, Collapse: tt.collapse},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		gb.Replace(2, 8, "ééé")
+		if got := markers[0].Pos(); got != tt.want {
+			t.Errorf("collapse %d, gravity %d: got %d, want %d", tt.collapse, tt.gravity, got, tt.want)
+		}
+		if markers[1].Pos() != 2 || markers[2].Pos() != 8 {
+			t.Errorf("collapse %d: edges moved to %d and %d", tt.collapse, markers[1].Pos(), markers[2].Pos())
+		}
+	}
+}
+
+// TestCursorProportional checks that a cursor keeps its place on a rune
+// start through a reformat of the whole buffer
+func TestCursorProportional(t *testing.T) {
+	gb := newBuffer(t, "x=1;y=2;")
+	c, _ := gb.NewCursor(4)
+	c.SetCollapse(buffer.CollapseProportional)
+	gb.Replace(0, gb.Length(), "x = 1;\ny = 2;\n")
+	if c.Pos() != 7 {
+		t.Errorf("got %d, want 7", c.Pos())
+	}
+	gb.Replace(0, gb.Length(), "ä ö ü")
+	if pos := c.Pos(); pos != 3 {
+		t.Errorf("got %d, want 3", pos)
+	}
+}
+
+// markerTag tags the markers of one provider in the tests
+type markerTag string
+
+// TestCreateMarkers checks that CreateMarkers creates all the markers or,
+// on a bad spec, none
+func TestCreateMarkers(t *testing.T) {
+	gb := newBuffer(t, "0123456789")
+	markers, err := gb.CreateMarkers([]buffer.MarkerSpec{
+		{Pos: 0},
+		{Pos: 4, Gravity: buffer.GravityRight, Tag: markerTag("lint")},
+		{Pos: 10, Collapse: buffer.CollapseEnd},
+	})
+	if err != nil || len(markers) != 3 {
+		t.Fatalf("got %d markers, %v", len(markers), err)
+	}
+	if m := markers[1]; m.Pos() != 4 || m.Gravity() != buffer.GravityRight || m.Tag() != markerTag("lint") {
+		t.Errorf("got marker at %d, gravity %v, tag %v", m.Pos(), m.Gravity(), m.Tag())
+	}
+	if markers[2].Collapse() != buffer.CollapseEnd {
+		t.Errorf("got collapse %v", markers[2].Collapse())
+	}
+	gb.InsertAt(4, "xx")
+	if markers[0].Pos() != 0 || markers[1].Pos() != 6 || markers[2].Pos() != 12 {
+		t.Errorf("after an insert: at %d, %d and %d", markers[0].Pos(), markers[1].Pos(), markers[2].Pos())
+	}
+
+	tests := []struct {
+		specs []buffer.MarkerSpec
+		pos   int
+		want  error
+	}{
+		{[]buffer.MarkerSpec{{Pos: 1}, {Pos: 13}}, 13, buffer.ErrPositionOutOfRange},
+		{[]buffer.MarkerSpec{{Pos: 1}, {Pos: 2, Tag: []int{1}}}, 2, buffer.ErrTagNotComparable},
+	}
+	for _, tt := range tests {
+		markers, err := gb.CreateMarkers(tt.specs)
+		var opErr *buffer.OpError
+		if markers != nil || !errors.Is(err, tt.want) || !errors.As(err, &opErr) || opErr.Pos != tt.pos {
+			t.Errorf("got %d markers, %v, want %v at %d", len(markers), err, tt.want, tt.pos)
+		}
+	}
+	if removed := gb.RemoveMarkersInRange(buffer.Range{Start: 0, End: 12}, markerTag("lint")); removed != 1 {
+		t.Errorf("a failed CreateMarkers left markers behind: removed %d", removed)
+	}
+}
+
+// TestRemoveMarkers checks batch removal and removal by tag and range
+func TestRemoveMarkers(t *testing.T) {
+	gb := newBuffer(t, "0123456789")
+	var specs []buffer.MarkerSpec
+	for pos := 0; pos <= 10; pos++ {
+		tag := markerTag("even")
+		if pos%2 == 1 {
+			tag = "odd"
+		}
+		specs = append(specs, buffer.MarkerSpec{Pos: pos, Tag: tag})
+	}
+	markers, _ := gb.CreateMarkers(specs)
+	plain, _ := gb.CreateMarker(5, buffer.GravityLeft)
+
+	if n := gb.RemoveMarkersInRange(buffer.Range{Start: 2, End: 6}, markerTag("even")); n != 3 {
+		t.Errorf("removed %d even markers in [2, 6], want 3", n)
+	}
+	for pos, m := range markers {
+		want := pos%2 == 0 && pos >= 2 && pos <= 6
+		if m.Removed() != want {
+			t.Errorf("marker at %d: removed %v", pos, m.Removed())
+		}
+	}
+	if n := gb.RemoveMarkersInRange(buffer.Range{Start: 0, End: 10}, nil); n != 0 || plain.Removed() {
+		t.Errorf("removed %d untagged markers", n)
+	}
+	if n := gb.RemoveMarkersInRange(buffer.Range{Start: 0, End: 10}, []int{}); n != 0 {
+		t.Errorf("removed %d markers for a tag that cannot be compared", n)
+	}
+
+	gb.RemoveMarkers(append(markers[7:], plain, markers[7]))
+	gb.InsertAt(0, "x")
+	for pos, m := range markers {
+		if m.Removed() != (pos >= 7 || pos%2 == 0 && pos >= 2 && pos <= 6) {
+			t.Errorf("marker at %d: removed %v", pos, m.Removed())
+		}
+		want := pos + 1
+		if pos == 0 {
+			want = 0 // left gravity keeps it before the insert
+		}
+		if !m.Removed() && m.Pos() != want {
+			t.Errorf("marker at %d moved to %d", pos, m.Pos())
+		}
+	}
+	if !plain.Removed() || plain.Pos() != 5 {
+		t.Errorf("removed marker moved to %d", plain.Pos())
+	}
+}
+
+// modelMarker is a marker of the plain model TestMarkerIndex checks the
+// buffer against
+type modelMarker struct {
+	pos      int
+	gravity  buffer.Gravity
+	collapse buffer.Collapse
+	tag      markerTag
+}
+
+// shift moves the model marker through op the way markers are documented
+// to move
+func (m *modelMarker) shift(op buffer.Operation) {
+	end := op.Pos + op.Deleted
+	switch {
+	case m.pos > op.Pos && m.pos < end && m.collapse == buffer.CollapseStart:
+		m.pos = op.Pos
+	case m.pos > op.Pos && m.pos < end && m.collapse == buffer.CollapseEnd:
+		m.pos = op.Pos + op.Inserted
+	case m.pos < op.Pos || m.pos == op.Pos && m.gravity == buffer.GravityLeft:
+	case m.pos >= end:
+		m.pos += op.Inserted - op.Deleted
+	case m.gravity == buffer.GravityRight:
+		m.pos = op.Pos + op.Inserted
+	default:
+		m.pos = op.Pos
+	}
+}
+
+// TestMarkerIndex runs random edits, moves and removals over many markers,
+// with edits far apart and close together, and checks every position
+// against a plain model
+func TestMarkerIndex(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	gb := newBuffer(t, strings.Repeat("0123456789", 20))
+	var markers []*buffer.Marker
+	var model []*modelMarker
+	create := func(n int) {
+		specs := make([]buffer.MarkerSpec, n)
+		for i := range specs {
+			specs[i] = buffer.MarkerSpec{
+				Pos:      rng.Intn(gb.Length() + 1),
+				Gravity:  buffer.Gravity(rng.Intn(2)),
+				Collapse: buffer.Collapse(rng.Intn(3)),
+				Tag:      markerTag([]string{"a", "b"}[rng.Intn(2)]),
+			}
+			model = append(model, &modelMarker{specs[i].Pos, specs[i].Gravity, specs[i].Collapse, specs[i].Tag.(markerTag)})
+		}
+		created, err := gb.CreateMarkers(specs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		markers = append(markers, created...)
+	}
+	create(300)
+
+	for step := 0; step < 2000; step++ {
+		version := gb.Version()
+		pos := rng.Intn(gb.Length() + 1)
+		if step%4 != 0 {
+			// Stay near the previous edit, as typing does
+			pos = min(pos%16+gb.Length()/2, gb.Length())
+		}
+		switch rng.Intn(10) {
+		case 0:
+			create(1 + rng.Intn(5))
+		case 1:
+			i := rng.Intn(len(markers))
+			markers[i].SetPos(pos)
+			model[i].pos = markers[i].Pos()
+		case 2:
+			i := rng.Intn(len(markers))
+			markers[i].Remove()
+		case 3:
+			r := buffer.Range{Start: pos, End: min(pos+rng.Intn(8), gb.Length())}
+			want := 0
+			for i, m := range model {
+				if !markers[i].Removed() && m.tag == "a" && r.Start <= m.pos && m.pos <= r.End {
+					want++
+				}
+			}
+			if n := gb.RemoveMarkersInRange(r, markerTag("a")); n != want {
+				t.Fatalf("step %d: removed %d markers in %v, want %d", step, n, r, want)
+			}
+		default:
+			end := min(pos+rng.Intn(6), gb.Length())
+			gb.Replace(pos, end, strings.Repeat("x", rng.Intn(6)))
+		}
+		ops, _ := gb.Operations(version)
+		for i, m := range model {
+			// Removed markers stay where they were
+			for _, op := range ops {
+				if !markers[i].Removed() {
+					m.shift(op)
+				}
+			}
+			if got := markers[i].Pos(); got != m.pos {
+				t.Fatalf("step %d: marker %d at %d, want %d", step, i, got, m.pos)
+			}
+		}
+	}
+}
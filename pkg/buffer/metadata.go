@@ -0,0 +1,30 @@
+package buffer
+
+// Metadata is arbitrary information attached to edits, such as the command
+// that caused them ("paste", "format") or a request id. It is shared by all
+// operations it is attached to and must not be modified afterwards.
+type Metadata map[string]interface{}
+
+// WithMetadata calls fn and attaches md to every operation the edits made
+// by fn produce. The metadata is recorded in the operation log, retained
+// versions and change feeds. Nested calls see the keys of the outer calls
+// too, inner values winning.
+func (gb *GapBuffer) WithMetadata(md Metadata, fn func() error) error {
+	prev := gb.metadata
+	if len(prev) > 0 && len(md) > 0 {
+		merged := make(Metadata, len(prev)+len(md))
+		for k, v := range prev {
+			merged[k] = v
+		}
+		for k, v := range md {
+			merged[k] = v
+		}
+		md = merged
+	} else if len(md) == 0 {
+		md = prev
+	}
+
+	gb.metadata = md
+	defer func() { gb.metadata = prev }()
+	return fn()
+}
@@ -0,0 +1,63 @@
+package buffer_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestWithMetadata checks that metadata reaches the operation log, change
+// events and retained versions, merged through nested calls and only for
+// the edits made within them
+func TestWithMetadata(t *testing.T) {
+	gb := newBuffer(t, "")
+	gb.SetVersionRetention(10)
+	base := gb.Version()
+	var events []buffer.Metadata
+	gb.Subscribe(func(e buffer.ChangeEvent) { events = append(events, e.Metadata) })
+
+	paste := buffer.Metadata{"command": "paste", "id": 1}
+	err := gb.WithMetadata(paste, func() error {
+		if err := gb.InsertAt(0, "a"); err != nil {
+			return err
+		}
+		return gb.WithMetadata(buffer.Metadata{"id": 2}, func() error {
+			return gb.InsertAt(1, "b")
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gb.InsertAt(2, "c"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []buffer.Metadata{paste, {"command": "paste", "id": 2}, nil}
+	ops, err := gb.Operations(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, op := range ops {
+		if !reflect.DeepEqual(op.Metadata, want[i]) {
+			t.Errorf("operation %d: got %v, want %v", i, op.Metadata, want[i])
+		}
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events: got %v, want %v", events, want)
+	}
+	for _, v := range gb.Versions() {
+		if i := v.Version - base - 1; i >= 0 && !reflect.DeepEqual(v.Metadata, want[i]) {
+			t.Errorf("version %d: got %v, want %v", v.Version, v.Metadata, want[i])
+		}
+	}
+	if !reflect.DeepEqual(paste, buffer.Metadata{"command": "paste", "id": 1}) {
+		t.Errorf("outer metadata modified: %v", paste)
+	}
+
+	failed := errors.New("failed")
+	if err := gb.WithMetadata(paste, func() error { return failed }); err != failed {
+		t.Errorf("got %v, want the error of fn", err)
+	}
+}
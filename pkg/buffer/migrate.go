@@ -0,0 +1,219 @@
+package buffer
+
+import (
+	"errors"
+	"strings"
+)
+
+// BackendKind names one of the text storage backends implementing Buffer
+type BackendKind int
+
+const (
+	BackendChunkTree  BackendKind = iota // *GapBuffer, chunks in a balanced tree
+	BackendRope                          // *Rope, a persistent rope
+	BackendFlat                          // *FlatBuffer, one slice with a gap
+	BackendPieceTable                    // *PieceTable, pieces of the original and the added text
+)
+
+// ErrUnknownBackend is returned by MigrateBackend for a kind it does not know
+var ErrUnknownBackend = errors.New("unknown backend")
+
+// ErrBackendState is returned by GapBuffer.MigrateBackend when the buffer
+// holds state a MigratedBuffer cannot keep
+var ErrBackendState = errors.New("backend cannot keep the buffer state")
+
+// String returns the name of the backend
+func (k BackendKind) String() string {
+	switch k {
+	case BackendChunkTree:
+		return "chunk tree"
+	case BackendRope:
+		return "rope"
+	case BackendFlat:
+		return "flat"
+	case BackendPieceTable:
+		return "piece table"
+	}
+	return "unknown"
+}
+
+// MigrateBackend returns the buffer as one of the given kind, so that a long
+// session can move to the backend suiting its workload. The buffer itself
+// is returned for BackendChunkTree. For another kind the text is copied to
+// the new backend, see the MigrateBackend function, and a MigratedBuffer
+// around it carries on the version, the operation log and the undo and
+// redo steps, with spilled text read back into memory. The markers and
+// range sets move to it and stop following gb, which otherwise keeps its
+// text, version and history. Pending suggestions and open transactions
+// have no counterpart there, so ErrBackendState is returned while the
+// buffer has any.
+func (gb *GapBuffer) MigrateBackend(kind BackendKind) (_ Buffer, err error) {
+	defer guard("MigrateBackend", noPos, gb.length, &err)
+
+	if kind == BackendChunkTree {
+		return gb, nil
+	}
+	if gb.InTransaction() || len(gb.suggestions.items) > 0 {
+		return nil, ErrBackendState
+	}
+
+	ops, err := gb.loadOperations(gb.oplog.ops)
+	if err != nil {
+		return nil, err
+	}
+	h := history{depth: gb.history.depth, nesting: gb.history.nesting}
+	if h.group, err = gb.loadOperations(gb.history.group); err != nil {
+		return nil, err
+	}
+	if h.undo, err = gb.loadSteps(gb.history.undo); err != nil {
+		return nil, err
+	}
+	if h.redo, err = gb.loadSteps(gb.history.redo); err != nil {
+		return nil, err
+	}
+	b, err := MigrateBackend(gb, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &MigratedBuffer{
+		backend:   b,
+		kind:      kind,
+		version:   gb.version,
+		oplog:     opLog{ops: ops, limit: gb.oplog.limit},
+		history:   h,
+		markers:   gb.markers,
+		rangeSets: gb.rangeSets,
+	}
+	for _, marker := range m.markers.markers {
+		marker.host = m
+	}
+	gb.markers, gb.rangeSets = markerIndex{}, nil
+	return m, nil
+}
+
+// loadOperations returns a copy of ops with any spilled content read back
+// into memory
+func (gb *GapBuffer) loadOperations(ops []Operation) ([]Operation, error) {
+	if ops == nil {
+		return nil, nil
+	}
+	loaded := make([]Operation, len(ops))
+	for i, op := range ops {
+		var err error
+		if loaded[i], err = gb.loadOperation(op); err != nil {
+			return nil, err
+		}
+	}
+	return loaded, nil
+}
+
+// loadSteps returns a copy of steps with any spilled content read back
+// into memory
+func (gb *GapBuffer) loadSteps(steps []historyStep) ([]historyStep, error) {
+	loaded := make([]historyStep, len(steps))
+	for i, step := range steps {
+		var err error
+		if loaded[i], err = gb.loadOperations(step); err != nil {
+			return nil, err
+		}
+	}
+	return loaded, nil
+}
+
+// MigrateBackend returns a buffer of the given kind holding the text of b,
+// such as a FlatBuffer moving to the chunk tree once a file has grown. A
+// buffer already of that kind is returned as it is. Otherwise the text is
+// copied byte for byte in blocks and b is left as it was; only the text
+// moves, since a Rope or FlatBuffer keeps no version or history and a
+// GapBuffer made from one starts afresh. Text mapped from a file is copied,
+// so the result stays valid after b is closed.
+func MigrateBackend(b Buffer, kind BackendKind) (_ Buffer, err error) {
+	defer guard("MigrateBackend", noPos, noPos, &err)
+
+	switch kind {
+	case BackendChunkTree:
+		if gb, ok := b.(*GapBuffer); ok {
+			return gb, nil
+		}
+		gb := New()
+		eachBlock(b, func(text string) {
+			for len(text) > 0 {
+				n := min(len(text), gb.chunkSize)
+				gb.rawInsert(gb.length, text[:n])
+				text = text[n:]
+			}
+		})
+		return gb, nil
+	case BackendRope:
+		if r, ok := b.(*Rope); ok {
+			return r, nil
+		}
+		r := NewRope()
+		eachBlock(b, func(text string) {
+			r.root = ropeJoin(r.root, ropeFromString(text))
+		})
+		return r, nil
+	case BackendFlat:
+		if f, ok := b.(*FlatBuffer); ok {
+			return f, nil
+		}
+		data := make([]byte, 0, b.Length()+DEFAULT_FLAT_GAP_SIZE)
+		eachBlock(b, func(text string) {
+			data = append(data, text...)
+		})
+		return &FlatBuffer{data: data[:cap(data)], gapStart: len(data), gapEnd: cap(data)}, nil
+	case BackendPieceTable:
+		if p, ok := b.(*PieceTable); ok {
+			return p, nil
+		}
+		data := make([]byte, 0, b.Length())
+		eachBlock(b, func(text string) {
+			data = append(data, text...)
+		})
+		return NewPieceTableFromString(string(data)), nil
+	}
+	return nil, ErrUnknownBackend
+}
+
+// eachBlock calls fn with the text of b in order, in blocks exactly as
+// stored, so invalid UTF-8 and runes split across blocks survive. Blocks
+// of a file mapping are copied.
+func eachBlock(b Buffer, fn func(text string)) {
+	switch b := b.(type) {
+	case *GapBuffer:
+		b.forEachChunk(0, b.length, func(_ int, text string) bool {
+			if b.inMapping(text) {
+				text = strings.Clone(text)
+			}
+			fn(text)
+			return true
+		})
+	case *Rope:
+		b.root.each(0, b.Length(), fn)
+	case *FlatBuffer:
+		fn(string(b.data[:b.gapStart]))
+		fn(string(b.data[b.gapEnd:]))
+	case *PieceTable:
+		b.each(fn)
+	case *MigratedBuffer:
+		eachBlock(b.backend, fn)
+	default:
+		fn(b.GetText())
+	}
+}
+
+// backendText returns the bytes of b in [start, end), which must be a valid
+// range, as stored, without the UTF-8 coercion of GetTextRange
+func backendText(b Buffer, start int, end int) string {
+	switch b := b.(type) {
+	case *Rope:
+		return b.text(start, end)
+	case *FlatBuffer:
+		return b.text(start, end)
+	case *PieceTable:
+		return b.text(start, end)
+	}
+	text, _ := b.GetTextRange(start, end)
+	return text
+}
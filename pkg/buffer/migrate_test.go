@@ -0,0 +1,133 @@
+package buffer_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestMigrateMappedBuffer migrates a buffer mapped from a file to every
+// backend and reads the result after the source buffer is closed, which
+// unmaps the file
+func TestMigrateMappedBuffer(t *testing.T) {
+	text := strings.Repeat("line of mapped text\n", 4096)
+	path := filepath.Join(t.TempDir(), "mapped.txt")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kind := range []buffer.BackendKind{buffer.BackendRope, buffer.BackendFlat, buffer.BackendPieceTable} {
+		t.Run(kind.String(), func(t *testing.T) {
+			gb, err := buffer.NewFromFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := buffer.MigrateBackend(gb, kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := gb.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.GetText(); got != text {
+				t.Fatalf("text after Close differs: %d bytes, want %d", len(got), len(text))
+			}
+		})
+	}
+}
+
+// TestMigrateBackendState migrates an edited buffer to every backend and
+// back, and checks that the version, log, history, markers and range sets
+// come along
+func TestMigrateBackendState(t *testing.T) {
+	for _, kind := range []buffer.BackendKind{buffer.BackendRope, buffer.BackendFlat, buffer.BackendPieceTable} {
+		t.Run(kind.String(), func(t *testing.T) {
+			gb := buffer.New()
+			gb.InsertAt(0, "hello world")
+			gb.DeleteAt(0, 6)
+			marker, _ := gb.CreateMarker(5, buffer.GravityRight)
+			set := buffer.NewRangeSet(buffer.Range{Start: 0, End: 5})
+			gb.TrackRangeSet(set)
+
+			b, err := gb.MigrateBackend(kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, ok := b.(*buffer.MigratedBuffer)
+			if !ok || m.Kind() != kind {
+				t.Fatalf("got %T, want a MigratedBuffer of kind %v", b, kind)
+			}
+			if m.Version() != 2 {
+				t.Fatalf("version %d, want 2", m.Version())
+			}
+			if ops, err := m.Operations(0); err != nil || len(ops) != 2 || ops[1].DeletedText != "hello " {
+				t.Fatalf("operations: got %+v, %v", ops, err)
+			}
+
+			if err := m.InsertAt(0, ">> "); err != nil {
+				t.Fatal(err)
+			}
+			if marker.Pos() != 8 || set.Ranges()[0] != (buffer.Range{Start: 3, End: 8}) {
+				t.Fatalf("marker at %d, set %v, want 8 and [3, 8)", marker.Pos(), set.Ranges())
+			}
+			if gb.GetText() != "world" {
+				t.Fatalf("source changed to %q", gb.GetText())
+			}
+
+			// Undo the edit made after the migration and the one before
+			for _, want := range []string{"world", "hello world"} {
+				if err := m.Undo(); err != nil {
+					t.Fatal(err)
+				}
+				if got := m.GetText(); got != want {
+					t.Fatalf("after undo got %q, want %q", got, want)
+				}
+			}
+
+			back, err := m.MigrateBackend(buffer.BackendChunkTree)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gb2 := back.(*buffer.GapBuffer)
+			if gb2.Version() != 5 || !gb2.CanUndo() || !gb2.CanRedo() {
+				t.Fatalf("back on the chunk tree: version %d, undo %v, redo %v", gb2.Version(), gb2.CanUndo(), gb2.CanRedo())
+			}
+			if err := gb2.Redo(); err != nil || gb2.GetText() != "world" {
+				t.Fatalf("redo: got %q, %v", gb2.GetText(), err)
+			}
+			if err := marker.SetPos(gb2.Length()); err != nil {
+				t.Fatal(err)
+			}
+			gb2.InsertAt(0, "a")
+			if marker.Pos() != gb2.Length() {
+				t.Fatalf("marker at %d, want %d", marker.Pos(), gb2.Length())
+			}
+		})
+	}
+}
+
+// TestMigrateBackendRefused checks that pending suggestions and open
+// transactions keep a buffer on the chunk tree
+func TestMigrateBackendRefused(t *testing.T) {
+	gb := buffer.New()
+	gb.InsertAt(0, "hello")
+	if b, err := gb.MigrateBackend(buffer.BackendChunkTree); err != nil || b != buffer.Buffer(gb) {
+		t.Fatalf("migrating to the chunk tree: got %v, %v, want the buffer itself", b, err)
+	}
+
+	if _, err := gb.Suggest(0, 0, "x"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gb.MigrateBackend(buffer.BackendRope); !errors.Is(err, buffer.ErrBackendState) {
+		t.Fatalf("migrating with a suggestion: got %v, want ErrBackendState", err)
+	}
+
+	var nilBuffer buffer.Buffer
+	if _, err := buffer.MigrateBackend(nilBuffer, buffer.BackendRope); err == nil {
+		t.Fatal("migrating a nil buffer: got no error")
+	}
+}
@@ -0,0 +1,296 @@
+package buffer
+
+import "time"
+
+// MigratedBuffer is a buffer moved by GapBuffer.MigrateBackend to a backend
+// other than the chunk tree. The backend only stores the text, so the
+// migrated buffer keeps what the GapBuffer kept around it: the version and
+// operation log, the undo and redo steps, and the markers and range sets,
+// which go on following the text through edits. It migrates on, back to
+// the chunk tree included, with MigrateBackend.
+type MigratedBuffer struct {
+	backend Buffer
+	kind    BackendKind
+
+	version   int
+	oplog     opLog
+	history   history
+	markers   markerIndex
+	rangeSets []*RangeSet
+}
+
+var _ Buffer = (*MigratedBuffer)(nil)
+
+// Backend returns the backend holding the text. Editing it directly
+// bypasses the version, history and markers.
+func (m *MigratedBuffer) Backend() Buffer {
+	return m.backend
+}
+
+// Kind returns the kind of the backend
+func (m *MigratedBuffer) Kind() BackendKind {
+	return m.kind
+}
+
+// Length returns the length of the text in bytes
+func (m *MigratedBuffer) Length() int {
+	return m.backend.Length()
+}
+
+// GetText returns the text in the buffer
+func (m *MigratedBuffer) GetText() string {
+	return m.backend.GetText()
+}
+
+// GetTextRange returns the text in the specified range
+func (m *MigratedBuffer) GetTextRange(start int, end int) (string, error) {
+	return m.backend.GetTextRange(start, end)
+}
+
+// InsertAt inserts text at the specified position
+func (m *MigratedBuffer) InsertAt(pos int, text string) (err error) {
+	defer guard("InsertAt", pos, m.Length(), &err)
+
+	if pos < 0 || pos > m.Length() {
+		return ErrPositionOutOfRange
+	}
+	return m.replace(pos, pos, text)
+}
+
+// DeleteAt deletes text at the specified position
+func (m *MigratedBuffer) DeleteAt(pos int, count int) (err error) {
+	defer guard("DeleteAt", pos, m.Length(), &err)
+
+	if pos == m.Length() && count > 0 {
+		return ErrDeleteAtEnd
+	}
+	if pos < 0 || count < 0 || pos > m.Length() || count > m.Length()-pos {
+		return ErrInvalidRange
+	}
+	return m.replace(pos, pos+count, "")
+}
+
+// Replace replaces the text in the specified range
+func (m *MigratedBuffer) Replace(start int, end int, text string) (err error) {
+	defer guard("Replace", start, m.Length(), &err)
+
+	if start < 0 || end > m.Length() || start > end {
+		return ErrInvalidRange
+	}
+	return m.replace(start, end, text)
+}
+
+// replace applies an edit to the backend and records it as GapBuffer.replace
+// does, in the log, the history, the range sets and the markers
+func (m *MigratedBuffer) replace(start int, end int, text string) error {
+	if start == end && text == "" {
+		return nil
+	}
+	deleted := backendText(m.backend, start, end)
+	if err := m.backend.Replace(start, end, text); err != nil {
+		return err
+	}
+
+	m.version++
+	op := Operation{
+		Version:      m.version,
+		Pos:          start,
+		Deleted:      len(deleted),
+		Inserted:     len(text),
+		Time:         time.Now(),
+		DeletedText:  deleted,
+		InsertedText: text,
+	}
+	m.oplog.ops = append(m.oplog.ops, op)
+	m.oplog.trim()
+	for _, s := range m.rangeSets {
+		s.Shift(op)
+	}
+	m.markers.shift(op, m.Length())
+
+	h := &m.history
+	if h.depth < 0 || h.replaying {
+		return nil
+	}
+	h.redo = nil
+	h.group = append(h.group, op)
+	if h.nesting == 0 {
+		h.closeGroup()
+	}
+	return nil
+}
+
+// Version returns the current version, continuing from the version of the
+// migrated GapBuffer
+func (m *MigratedBuffer) Version() int {
+	return m.version
+}
+
+// Operations returns the operations applied after the given version,
+// oldest first, those of the migrated GapBuffer included
+func (m *MigratedBuffer) Operations(since int) (result []Operation, err error) {
+	defer guard("Operations", noPos, m.Length(), &err)
+
+	ops, err := m.oplog.since(since, m.version)
+	if err != nil {
+		return nil, err
+	}
+	return append([]Operation(nil), ops...), nil
+}
+
+// BeginGroup starts a group of edits undone as one step, see
+// GapBuffer.BeginGroup
+func (m *MigratedBuffer) BeginGroup() {
+	m.history.nesting++
+}
+
+// EndGroup ends the group started by the matching BeginGroup
+func (m *MigratedBuffer) EndGroup() {
+	h := &m.history
+	if h.nesting == 0 {
+		return
+	}
+	if h.nesting--; h.nesting == 0 {
+		h.closeGroup()
+	}
+}
+
+// CanUndo reports whether Undo has a step to revert
+func (m *MigratedBuffer) CanUndo() bool {
+	return len(m.history.undo) > 0 || len(m.history.group) > 0
+}
+
+// CanRedo reports whether Redo has a step to reapply
+func (m *MigratedBuffer) CanRedo() bool {
+	return len(m.history.redo) > 0
+}
+
+// Undo reverts the most recent step, which may have been made before the
+// migration. It ends any open group first.
+func (m *MigratedBuffer) Undo() (err error) {
+	defer guard("Undo", noPos, m.Length(), &err)
+
+	h := &m.history
+	h.nesting = 0
+	h.closeGroup()
+	if len(h.undo) == 0 {
+		return ErrNothingToUndo
+	}
+	step := h.undo[len(h.undo)-1]
+
+	inverse := make([]Edit, len(step))
+	for i, op := range step {
+		inverse[len(step)-1-i] = Edit{Start: op.Pos, End: op.Pos + op.Inserted, Text: op.DeletedText}
+	}
+	if err := m.replay(inverse); err != nil {
+		return err
+	}
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, step)
+	return nil
+}
+
+// Redo reapplies the most recently undone step
+func (m *MigratedBuffer) Redo() (err error) {
+	defer guard("Redo", noPos, m.Length(), &err)
+
+	h := &m.history
+	if len(h.redo) == 0 {
+		return ErrNothingToRedo
+	}
+	step := h.redo[len(h.redo)-1]
+
+	edits := make([]Edit, len(step))
+	for i, op := range step {
+		edits[i] = Edit{Start: op.Pos, End: op.Pos + op.Deleted, Text: op.InsertedText}
+	}
+	if err := m.replay(edits); err != nil {
+		return err
+	}
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, step)
+	return nil
+}
+
+// replay applies edits one after the other without recording them as a
+// new step, see GapBuffer.replay
+func (m *MigratedBuffer) replay(edits []Edit) error {
+	m.history.replaying = true
+	defer func() { m.history.replaying = false }()
+
+	for _, e := range edits {
+		if err := m.replace(e.Start, e.End, e.Text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateMarker returns a marker at pos that the buffer keeps up to date
+// until it is removed
+func (m *MigratedBuffer) CreateMarker(pos int, gravity Gravity) (marker *Marker, err error) {
+	defer guard("CreateMarker", pos, m.Length(), &err)
+
+	if pos < 0 || pos > m.Length() {
+		return nil, ErrPositionOutOfRange
+	}
+	marker = &Marker{host: m, pos: pos, gravity: gravity}
+	m.markers.add(marker, m.Length())
+	return marker, nil
+}
+
+// trackedMarkers returns the markers the buffer keeps up to date
+func (m *MigratedBuffer) trackedMarkers() *markerIndex {
+	return &m.markers
+}
+
+// TrackRangeSet makes the buffer shift s through every edit it applies. A
+// nil set is ignored.
+func (m *MigratedBuffer) TrackRangeSet(s *RangeSet) {
+	if s == nil {
+		return
+	}
+	m.rangeSets = append(m.rangeSets, s)
+}
+
+// UntrackRangeSet stops shifting s through edits
+func (m *MigratedBuffer) UntrackRangeSet(s *RangeSet) {
+	for i, tracked := range m.rangeSets {
+		if tracked == s {
+			m.rangeSets = append(m.rangeSets[:i], m.rangeSets[i+1:]...)
+			return
+		}
+	}
+}
+
+// MigrateBackend moves the buffer to a backend of the given kind. For
+// BackendChunkTree it returns a GapBuffer taking over the version,
+// operation log, history, markers and range sets; m is left with its text
+// only. For another kind m itself moves to the new backend and is
+// returned.
+func (m *MigratedBuffer) MigrateBackend(kind BackendKind) (_ Buffer, err error) {
+	defer guard("MigrateBackend", noPos, m.Length(), &err)
+
+	b, err := MigrateBackend(m.backend, kind)
+	if err != nil {
+		return nil, err
+	}
+	if kind != BackendChunkTree {
+		m.backend, m.kind = b, kind
+		return m, nil
+	}
+
+	gb := b.(*GapBuffer)
+	gb.version = m.version
+	gb.oplog = m.oplog
+	gb.history = m.history
+	gb.rangeSets = m.rangeSets
+	gb.markers = m.markers
+	for _, marker := range gb.markers.markers {
+		marker.host = gb
+	}
+	m.oplog = opLog{limit: m.oplog.limit}
+	m.history = history{depth: m.history.depth}
+	m.markers, m.rangeSets = markerIndex{}, nil
+	return gb, nil
+}
@@ -0,0 +1,22 @@
+//go:build !unix
+
+package buffer
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads the first size bytes of f where mapping is not supported
+func mapFile(f *os.File, size int) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// unmapFile has nothing to release for data read by mapFile
+func unmapFile(data []byte) error {
+	return nil
+}
@@ -0,0 +1,24 @@
+package buffer
+
+// SetRecordNoOps sets whether edits that leave the text unchanged are
+// applied like any other edit. By default they are dropped: empty inserts,
+// zero-length deletes and replacements with identical text succeed without
+// bumping the version, recording history or notifying change consumers, so
+// incremental systems downstream are not woken up for nothing. Turn it on
+// for consumers that count every call.
+func (gb *GapBuffer) SetRecordNoOps(on bool) {
+	gb.recordNoOps = on
+}
+
+// isNoOp reports whether replacing [start, end) with text changes nothing
+func (gb *GapBuffer) isNoOp(start int, end int, text string) bool {
+	if end-start != len(text) {
+		return false
+	}
+	same := true
+	gb.forEachChunk(start, end, func(pos int, chunk string) bool {
+		same = chunk == text[pos-start:pos-start+len(chunk)]
+		return same
+	})
+	return same
+}
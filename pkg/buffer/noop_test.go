@@ -0,0 +1,50 @@
+package buffer_test
+
+import (
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestNoOps checks that edits leaving the text unchanged are dropped by
+// default and applied with SetRecordNoOps
+func TestNoOps(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(gb *buffer.GapBuffer) error
+	}{
+		{"empty insert", func(gb *buffer.GapBuffer) error { return gb.InsertAt(2, "") }},
+		{"zero-length delete", func(gb *buffer.GapBuffer) error { return gb.DeleteAt(2, 0) }},
+		{"empty replace", func(gb *buffer.GapBuffer) error { return gb.Replace(3, 3, "") }},
+		{"identical replace", func(gb *buffer.GapBuffer) error { return gb.Replace(1, 4, "ell") }},
+	}
+	for _, record := range []bool{false, true} {
+		for _, tt := range tests {
+			gb := newBuffer(t, "hello")
+			gb.SetHistoryDepth(0) // drops the step of the initial insert
+			gb.SetHistoryDepth(10)
+			gb.SetRecordNoOps(record)
+			events := 0
+			gb.Subscribe(func(buffer.ChangeEvent) { events++ })
+			version := gb.Version()
+
+			if err := tt.edit(gb); err != nil || gb.GetText() != "hello" {
+				t.Fatalf("%s: got %q, %v", tt.name, gb.GetText(), err)
+			}
+			bumped := gb.Version() != version
+			if bumped != record || gb.CanUndo() != record || (events > 0) != record {
+				t.Errorf("%s, recording %v: version bumped %v, undoable %v, %d events",
+					tt.name, record, bumped, gb.CanUndo(), events)
+			}
+		}
+	}
+}
+
+// TestNoOpChange checks that a replacement differing in one byte is applied
+func TestNoOpChange(t *testing.T) {
+	gb := newBuffer(t, "hello")
+	version := gb.Version()
+	if err := gb.Replace(1, 4, "elL"); err != nil || gb.GetText() != "helLo" || gb.Version() == version {
+		t.Errorf("got %q, %v, version %d", gb.GetText(), err, gb.Version())
+	}
+}
@@ -0,0 +1,358 @@
+package buffer
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:generate go run gennorm.go
+
+// ErrInvalidNormForm is returned for a normalization form that does not exist
+var ErrInvalidNormForm = errors.New("invalid normalization form")
+
+// NormForm is a Unicode normalization form
+type NormForm int
+
+const (
+	NormNone NormForm = iota // Text is left as it is
+	NFC                      // Canonical decomposition followed by canonical composition
+	NFD                      // Canonical decomposition
+)
+
+// Normalize converts the contents of the buffer to form, as one undo step.
+// Only the lines that change are edited, and within them only the runes
+// that differ, so markers on text already in form stay where they are.
+// Invalid UTF-8 is kept as it is.
+func (gb *GapBuffer) Normalize(form NormForm) (err error) {
+	defer guard("Normalize", noPos, gb.length, &err)
+
+	if form != NFC && form != NFD {
+		if form == NormNone {
+			return nil
+		}
+		return ErrInvalidNormForm
+	}
+
+	// Normalization never looks across a newline, so lines are converted on
+	// their own
+	var edits []Edit
+	for line, n := 0, gb.lineCount(); line < n; line++ {
+		start, end := gb.lineStartOf(line), gb.length
+		if line+1 < n {
+			end = gb.lineStartOf(line + 1)
+		}
+		text := gb.rawText(start, end)
+		if normalized := normalizeString(form, text); normalized != text {
+			p := runePrefix(text, normalized)
+			q := runeSuffix(text[p:], normalized[p:])
+			edits = append(edits, Edit{Start: start + p, End: end - q, Text: normalized[p : len(normalized)-q]})
+		}
+	}
+	if len(edits) == 0 {
+		return nil
+	}
+
+	inputNorm := gb.inputNorm
+	gb.inputNorm = NormNone
+	defer func() { gb.inputNorm = inputNorm }()
+	_, err = gb.ApplyEdits(edits, OverlapError)
+	return err
+}
+
+// SetInputNormalization makes every insertion convert its text to form,
+// NormNone to store text as given. The runes around the insertion that
+// combine with it are normalized along, so inserting a combining accent
+// after a letter under NFC replaces the letter with its accented form.
+// Undo and redo restore text as it was, and ApplyEdits normalizes each
+// inserted text on its own. Text already in the buffer is not converted,
+// see Normalize.
+func (gb *GapBuffer) SetInputNormalization(form NormForm) (err error) {
+	defer guard("SetInputNormalization", noPos, gb.length, &err)
+
+	if form != NormNone && form != NFC && form != NFD {
+		return ErrInvalidNormForm
+	}
+	gb.inputNorm = form
+	return nil
+}
+
+// InputNormalization returns the form inserted text is converted to
+func (gb *GapBuffer) InputNormalization() NormForm {
+	return gb.inputNorm
+}
+
+// normalizeInput returns the edit that replaces [start, end) with text
+// under the input normalization, widened to the runes around it that
+// combine with text and trimmed to what actually changes
+func (gb *GapBuffer) normalizeInput(start int, end int, text string) (int, int, string) {
+	if text == "" {
+		return start, end, text
+	}
+
+	before := ""
+	if r, _ := utf8.DecodeRuneInString(text); combinesBackward(r) {
+		before = gb.rawText(gb.graphemeScanStart(start), start)
+		before = before[segmentStart(before):]
+	}
+	after := gb.rawText(end, min(end+graphemeContext, gb.length))
+	after = after[:segmentEnd(after)]
+
+	normalized := normalizeString(gb.inputNorm, before+text+after)
+	p := runePrefix(before, normalized)
+	q := runeSuffix(after, normalized[p:])
+	return start - len(before) + p, end + len(after) - q, normalized[p : len(normalized)-q]
+}
+
+// runePrefix returns the length of the longest common prefix of a and b
+// that ends at a rune boundary
+func runePrefix(a string, b string) int {
+	n := commonPrefix(a, b)
+	for n > 0 && (n < len(a) && !utf8.RuneStart(a[n]) || n < len(b) && !utf8.RuneStart(b[n])) {
+		n--
+	}
+	return n
+}
+
+// runeSuffix returns the length of the longest common suffix of a and b
+// that starts at a rune boundary
+func runeSuffix(a string, b string) int {
+	n := commonSuffix(a, b)
+	for n > 0 && !utf8.RuneStart(a[len(a)-n]) {
+		n--
+	}
+	return n
+}
+
+// segmentStart returns where the last rune of s that nothing before it
+// combines with starts, or 0
+func segmentStart(s string) int {
+	for i := len(s); i > 0; {
+		r, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+		if !combinesBackward(r) {
+			return i
+		}
+	}
+	return 0
+}
+
+// segmentEnd returns where the first rune of s that does not combine with
+// the runes before it starts, or len(s)
+func segmentEnd(s string) int {
+	for i, r := range s {
+		if !combinesBackward(r) {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// combinesBackward reports whether r may be reordered or composed with the
+// rune before it
+func combinesBackward(r rune) bool {
+	switch {
+	case r < 0x300:
+		return false
+	case r >= hangulVBase && r < hangulVBase+hangulVCount, r > hangulTBase && r < hangulTBase+hangulTCount:
+		return true
+	}
+	var buf [4]rune
+	d := decomposeRune(buf[:0], r) // e.g. U+0F73 decomposes to marks
+	return combiningClass(d[0]) != 0 || searchRunes(composingStarters, d[0])
+}
+
+// normalizeString returns s in form, copying invalid bytes as they are
+func normalizeString(form NormForm, s string) string {
+	i := 0
+	for i < len(s) && s[i] < utf8.RuneSelf {
+		i++
+	}
+	if i == len(s) || form == NormNone {
+		return s
+	}
+	if i > 0 {
+		i-- // the marks that follow may combine with it
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	b.WriteString(s[:i])
+	var runes []rune
+	for i < len(s) {
+		// Invalid bytes end the valid text before them, which is normalized
+		// on its own
+		j := i
+		for j < len(s) {
+			r, size := utf8.DecodeRuneInString(s[j:])
+			if r == utf8.RuneError && size == 1 {
+				break
+			}
+			j += size
+		}
+		runes = normalizeRunes(form, s[i:j], runes[:0])
+		for _, r := range runes {
+			b.WriteRune(r)
+		}
+		if j < len(s) {
+			b.WriteByte(s[j])
+			j++
+		}
+		i = j
+	}
+	if b.Len() == len(s) && b.String() == s {
+		return s
+	}
+	return b.String()
+}
+
+// normalizeRunes appends the runes of the valid UTF-8 text s in form to buf
+func normalizeRunes(form NormForm, s string, buf []rune) []rune {
+	for _, r := range s {
+		buf = decomposeRune(buf, r)
+	}
+
+	// Canonical ordering: marks between two starters sorted by class
+	for i := 1; i < len(buf); i++ {
+		c := combiningClass(buf[i])
+		if c == 0 {
+			continue
+		}
+		for j := i; j > 0 && combiningClass(buf[j-1]) > c; j-- {
+			buf[j], buf[j-1] = buf[j-1], buf[j]
+		}
+	}
+	if form == NFC {
+		buf = composeRunes(buf)
+	}
+	return buf
+}
+
+// composeRunes applies canonical composition to decomposed and ordered
+// runes in place
+func composeRunes(runes []rune) []rune {
+	out := runes[:0]
+	starter := -1   // index in out of the last starter
+	lastClass := -1 // class of the last rune after it, -1 if there is none
+	for _, r := range runes {
+		c := int(combiningClass(r))
+		if starter >= 0 && lastClass < c {
+			if composed, ok := composePair(out[starter], r); ok {
+				out[starter] = composed
+				continue
+			}
+		}
+		if c == 0 {
+			starter, lastClass = len(out), -1
+		} else {
+			lastClass = c
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// Hangul syllable arithmetic, see section 3.12 of the Unicode standard
+const (
+	hangulSBase  = 0xAC00
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11A7
+	hangulLCount = 19
+	hangulVCount = 21
+	hangulTCount = 28
+	hangulNCount = hangulVCount * hangulTCount
+	hangulSCount = hangulLCount * hangulNCount
+)
+
+// decomposeRune appends the full canonical decomposition of r to buf
+func decomposeRune(buf []rune, r rune) []rune {
+	if r < 0xC0 {
+		return append(buf, r)
+	}
+	if s := r - hangulSBase; s >= 0 && s < hangulSCount {
+		buf = append(buf, hangulLBase+s/hangulNCount, hangulVBase+s%hangulNCount/hangulTCount)
+		if t := s % hangulTCount; t != 0 {
+			buf = append(buf, hangulTBase+t)
+		}
+		return buf
+	}
+
+	lo, hi := 0, len(decompositions)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch d := decompositions[mid]; {
+		case r < d.r:
+			hi = mid
+		case r > d.r:
+			lo = mid + 1
+		default:
+			for _, x := range d.d {
+				buf = append(buf, x)
+			}
+			return buf
+		}
+	}
+	return append(buf, r)
+}
+
+// composePair returns the primary composite of a followed by b
+func composePair(a rune, b rune) (rune, bool) {
+	if l, v := a-hangulLBase, b-hangulVBase; l >= 0 && l < hangulLCount && v >= 0 && v < hangulVCount {
+		return hangulSBase + (l*hangulVCount+v)*hangulTCount, true
+	}
+	if s, t := a-hangulSBase, b-hangulTBase; s >= 0 && s < hangulSCount && s%hangulTCount == 0 && t > 0 && t < hangulTCount {
+		return a + t, true
+	}
+
+	lo, hi := 0, len(compositions)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch c := compositions[mid]; {
+		case a < c[0] || a == c[0] && b < c[1]:
+			hi = mid
+		case a > c[0] || b > c[1]:
+			lo = mid + 1
+		default:
+			return c[2], true
+		}
+	}
+	return 0, false
+}
+
+// combiningClass returns the canonical combining class of r
+func combiningClass(r rune) uint8 {
+	if r < 0x300 {
+		return 0
+	}
+	lo, hi := 0, len(cccRanges)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch c := cccRanges[mid]; {
+		case r < c[0]:
+			hi = mid
+		case r > c[1]:
+			lo = mid + 1
+		default:
+			return uint8(c[2])
+		}
+	}
+	return 0
+}
+
+// searchRunes reports whether the sorted runes contain r
+func searchRunes(runes []rune, r rune) bool {
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		switch {
+		case r < runes[mid]:
+			hi = mid
+		case r > runes[mid]:
+			lo = mid + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package buffer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestNormalize checks NFC and NFD conversion of the whole buffer
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		form buffer.NormForm
+		want string
+	}{
+		{"compose", "e\u0301 x\nA\u030A", buffer.NFC, "\u00E9 x\n\u00C5"},
+		{"decompose", "\u00E9 x\n\u00C5", buffer.NFD, "e\u0301 x\nA\u030A"},
+		{"reorder", "a\u0301\u0323", buffer.NFD, "a\u0323\u0301"},
+		{"compose reordered", "a\u0301\u0323", buffer.NFC, "\u1EA1\u0301"},
+		{"hangul", "\uAC00", buffer.NFD, "\u1100\u1161"},
+		{"hangul compose", "\u1100\u1161", buffer.NFC, "\uAC00"},
+		{"none", "e\u0301", buffer.NormNone, "e\u0301"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gb := newBuffer(t, tt.text)
+			if err := gb.Normalize(tt.form); err != nil {
+				t.Fatal(err)
+			}
+			if got := gb.GetText(); got != tt.want {
+				t.Errorf("got %+q, want %+q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNormalizeUndo checks that normalizing is one undo step that leaves
+// markers on unchanged text in place
+func TestNormalizeUndo(t *testing.T) {
+	text := "ab\ne\u0301\nc\u0327d"
+	gb := newBuffer(t, text)
+	m, _ := gb.CreateMarker(1, buffer.GravityRight)
+
+	if err := gb.Normalize(buffer.NFC); err != nil {
+		t.Fatal(err)
+	}
+	if got := gb.GetText(); got != "ab\n\u00E9\n\u00E7d" {
+		t.Errorf("got %+q", got)
+	}
+	if m.Pos() != 1 {
+		t.Errorf("marker moved to %d", m.Pos())
+	}
+	if err := gb.Undo(); err != nil || gb.GetText() != text {
+		t.Errorf("undo: got %+q, %v", gb.GetText(), err)
+	}
+	if err := gb.Normalize(buffer.NormForm(9)); !errors.Is(err, buffer.ErrInvalidNormForm) {
+		t.Errorf("unknown form: %v", err)
+	}
+}
+
+// TestInputNormalization checks that insertions combine with the text
+// around them and that undo restores the text verbatim
+func TestInputNormalization(t *testing.T) {
+	gb := newBuffer(t, "xe")
+	if err := gb.SetInputNormalization(buffer.NFC); err != nil {
+		t.Fatal(err)
+	}
+	if gb.InputNormalization() != buffer.NFC {
+		t.Errorf("got form %v", gb.InputNormalization())
+	}
+
+	gb.InsertAt(2, "\u0301y")
+	if got := gb.GetText(); got != "x\u00E9y" {
+		t.Errorf("got %+q", got)
+	}
+	gb.InsertAt(0, "A\u030A")
+	if got := gb.GetText(); got != "\u00C5x\u00E9y" {
+		t.Errorf("got %+q", got)
+	}
+	gb.Undo()
+	gb.Undo()
+	if got := gb.GetText(); got != "xe" {
+		t.Errorf("undo: got %+q", got)
+	}
+
+	gb.SetInputNormalization(buffer.NFD)
+	gb.InsertAt(2, "\u00E9")
+	if got := gb.GetText(); got != "xee\u0301" {
+		t.Errorf("NFD: got %+q", got)
+	}
+	if err := gb.SetInputNormalization(buffer.NormForm(-1)); !errors.Is(err, buffer.ErrInvalidNormForm) {
+		t.Errorf("unknown form: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package buffer
+
+import (
+	"errors"
+	"time"
+)
+
+// DEFAULT_OPLOG_LIMIT is the minimum number of operations kept in the operation log
+const DEFAULT_OPLOG_LIMIT = 10000
+
+// ErrVersionUnavailable is returned when a version is newer than the buffer
+// or older than the oldest operation still kept in the operation log
+var ErrVersionUnavailable = errors.New("version unavailable")
+
+// Operation describes one edit applied to the buffer
+type Operation struct {
+	Version  int       // Version of the buffer after the edit
+	Pos      int       // Position of the edit
+	Deleted  int       // Number of bytes removed at Pos
+	Inserted int       // Number of bytes inserted at Pos
+	Time     time.Time // When the edit was applied
+	Actor    string    // Who made the edit, see WithActor
+	Metadata Metadata  // Attached with WithMetadata, nil if none
+
+	DeletedText  string // Bytes removed at Pos
+	InsertedText string // Bytes inserted at Pos
+
+	deletedSpill *spillRef // DeletedText moved to the spill file
+}
+
+// opLog keeps the most recent operations applied to the buffer
+type opLog struct {
+	ops   []Operation
+	limit int
+}
+
+// Version returns the current version of the buffer. It starts at 0 and
+// increases by one with every applied edit.
+func (gb *GapBuffer) Version() int {
+	return gb.version
+}
+
+// SetOperationLogLimit sets how many operations are at least kept in the
+// operation log. A limit <= 0 restores DEFAULT_OPLOG_LIMIT.
+func (gb *GapBuffer) SetOperationLogLimit(limit int) {
+	if limit <= 0 {
+		limit = DEFAULT_OPLOG_LIMIT
+	}
+	gb.oplog.limit = limit
+	gb.releaseOperations(gb.oplog.trim())
+}
+
+// Operations returns the operations applied after the given version, oldest first
+func (gb *GapBuffer) Operations(since int) (result []Operation, err error) {
+	defer guard("Operations", noPos, gb.length, &err)
+
+	ops, err := gb.oplog.since(since, gb.version)
+	if err != nil {
+		return nil, err
+	}
+	result = make([]Operation, len(ops))
+	for i, op := range ops {
+		if result[i], err = gb.loadOperation(op); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// recordOperation bumps the version and appends the edit to the operation
+// log. It returns the operation and the copy kept in the log, whose large
+// deleted text may have been spilled.
+func (gb *GapBuffer) recordOperation(pos int, deleted string, inserted string) (Operation, Operation) {
+	gb.version++
+	op := Operation{
+		Version:      gb.version,
+		Pos:          pos,
+		Deleted:      len(deleted),
+		Inserted:     len(inserted),
+		Time:         time.Now(),
+		Actor:        gb.actor,
+		Metadata:     gb.metadata,
+		DeletedText:  deleted,
+		InsertedText: inserted,
+	}
+	logged := op
+	gb.spillOperation(&logged)
+	gb.oplog.ops = append(gb.oplog.ops, logged)
+	gb.releaseOperations(gb.oplog.trim())
+	return op, logged
+}
+
+// since returns the logged operations applied after version, sharing the log's storage
+func (l *opLog) since(version int, current int) ([]Operation, error) {
+	if version < 0 || version > current {
+		return nil, ErrVersionUnavailable
+	}
+	if version == current {
+		return nil, nil
+	}
+
+	// The operation that produced version+1 must still be in the log
+	first := current - len(l.ops) + 1
+	if version+1 < first {
+		return nil, ErrVersionUnavailable
+	}
+	return l.ops[version+1-first:], nil
+}
+
+// trim drops the oldest operations once the log holds twice the limit,
+// so the copy is amortized over many edits. It returns the dropped operations.
+func (l *opLog) trim() []Operation {
+	limit := l.limit
+	if limit <= 0 {
+		limit = DEFAULT_OPLOG_LIMIT
+	}
+	if len(l.ops) <= 2*limit {
+		return nil
+	}
+	dropped := l.ops[:len(l.ops)-limit]
+	l.ops = append(l.ops[:0:0], l.ops[len(l.ops)-limit:]...)
+	return dropped
+}
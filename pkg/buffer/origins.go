@@ -0,0 +1,290 @@
+package buffer
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"sort"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+)
+
+// ErrNoOriginalOffsets is returned by OriginalOffset for a buffer that was
+// not read with NewFromReaderEncoding
+var ErrNoOriginalOffsets = errors.New("no original offsets")
+
+// maxOriginSpan bounds the text a segment of an originTable covers while
+// decoding, and so the text scanned to look up an offset
+const maxOriginSpan = 4096
+
+// noStep marks a rune length no rune of a segment has had yet
+const noStep = 0xff
+
+// originSegment maps the buffer bytes from pos up to the next segment to
+// the input, starting at orig: each rune of n bytes was decoded from
+// steps[n-1] bytes of input. Most encodings take the same number of bytes
+// for all runes of one UTF-8 length, so a segment covers text mixing
+// scripts until it reaches maxOriginSpan. Text inserted by edits has steps
+// of 0, so all of it maps to orig.
+type originSegment struct {
+	pos   int
+	orig  int64
+	steps [utf8.UTFMax]uint8
+}
+
+// originTable maps positions of a decoded buffer to offsets in its input
+type originTable struct {
+	segs []originSegment
+
+	// While decoding: the end of the text and of the input so far,
+	// whether the next rune must start a segment and whether ASCII input
+	// decodes to itself
+	end   int
+	orig  int64
+	fresh bool
+	ascii bool
+}
+
+// OriginalOffset returns the offset in the input read by
+// NewFromReaderEncoding of the byte at pos, so that messages about the
+// file on disk point to the right place after transcoding. A byte inside
+// a character maps to the start of its encoding, and the end of the buffer
+// to the end of the input. Positions keep their offsets through edits;
+// text inserted by edits, including text brought back by undo, maps to
+// the offset of the text it was inserted at.
+func (gb *GapBuffer) OriginalOffset(pos int) (off int64, err error) {
+	defer guard("OriginalOffset", pos, gb.length, &err)
+
+	if gb.origins == nil {
+		return 0, ErrNoOriginalOffsets
+	}
+	if pos < 0 || pos > gb.length {
+		return 0, ErrPositionOutOfRange
+	}
+	s := gb.origins.segs[gb.origins.segment(pos)]
+	return s.offset(pos, func() string { return gb.rawText(s.pos, pos) }), nil
+}
+
+// newOriginTable returns a table for text about to be decoded from enc
+func newOriginTable(enc encoding.Encoding) *originTable {
+	ascii := make([]byte, utf8.RuneSelf)
+	for i := range ascii {
+		ascii[i] = byte(i)
+	}
+	decoded, err := enc.NewDecoder().Bytes(ascii)
+	return &originTable{fresh: true, ascii: err == nil && bytes.Equal(decoded, ascii)}
+}
+
+// offset returns the input offset of pos in the segment, given the text
+// from the start of the segment to pos. A rune cut short by pos does not
+// count.
+func (s *originSegment) offset(pos int, text func() string) int64 {
+	if s.steps == [utf8.UTFMax]uint8{} || pos == s.pos {
+		return s.orig
+	}
+	off := s.orig
+	t := text()
+	for i := 0; i < len(t); i++ {
+		if n := runeSize(t[i]); n > 0 && i+n <= len(t) && s.steps[n-1] != noStep {
+			off += int64(s.steps[n-1])
+		}
+	}
+	return off
+}
+
+// runeSize returns the length of the rune starting with b, or 0 if b
+// cannot start one
+func runeSize(b byte) int {
+	switch {
+	case b < utf8.RuneSelf:
+		return 1
+	case b < 0xc0:
+		return 0
+	case b < 0xe0:
+		return 2
+	case b < 0xf0:
+		return 3
+	}
+	return 4
+}
+
+// add appends n runes of size bytes of text, each decoded from step bytes
+// of input
+func (t *originTable) add(n int, size int, step int) {
+	for n > 0 {
+		last := len(t.segs) - 1
+		if t.fresh || t.end-t.segs[last].pos >= maxOriginSpan ||
+			t.segs[last].steps[size-1] != noStep && t.segs[last].steps[size-1] != uint8(step) {
+			s := originSegment{pos: t.end, orig: t.orig}
+			for i := range s.steps {
+				s.steps[i] = noStep
+			}
+			t.segs = append(t.segs, s)
+			t.fresh = false
+			last++
+		}
+		t.segs[last].steps[size-1] = uint8(step)
+
+		k := min(n, (maxOriginSpan-(t.end-t.segs[last].pos)+size-1)/size)
+		t.end += k * size
+		t.orig += int64(k * step)
+		n -= k
+	}
+}
+
+// addUnit appends text decoded from step bytes of input as one piece.
+// Text decoded from nothing, such as a byte order mark or an escape
+// sequence, only moves the offset of the text after it. Text of several
+// runes, which a decoder produces for some single characters, maps to the
+// start of its input.
+func (t *originTable) addUnit(text string, step int) {
+	if text == "" {
+		t.orig += int64(step)
+		t.fresh = true
+		return
+	}
+	if _, size := utf8.DecodeRuneInString(text); size == len(text) && step < noStep {
+		t.add(1, size, step)
+		return
+	}
+	t.segs = append(t.segs, originSegment{pos: t.end, orig: t.orig})
+	t.fresh = true
+	t.end += len(text)
+	t.orig += int64(step)
+}
+
+// finish ends the table once all input is decoded, so that the end of the
+// text maps to the end of the input
+func (t *originTable) finish() {
+	if t.fresh {
+		t.segs = append(t.segs, originSegment{pos: t.end, orig: t.orig})
+	}
+}
+
+// addDecoded adds text, decoded by dec from src. ASCII decoding to itself
+// is taken as it is; other characters are decoded again one at a time,
+// from the smallest prefix dec accepts, to learn how much input each took.
+func (t *originTable) addDecoded(dec *encoding.Decoder, text string, src []byte, atEOF bool) {
+	var dst [4 * utf8.UTFMax]byte
+	for i := 0; i < len(src); {
+		if t.ascii {
+			n := 0
+			for n < len(text) && i+n < len(src) && src[i+n] < utf8.RuneSelf && text[n] == src[i+n] {
+				n++
+			}
+			if n > 0 {
+				t.add(n, 1, 1)
+				text = text[n:]
+				i += n
+				continue
+			}
+		}
+
+		nDst, nSrc := 0, 0
+		for k := 1; k <= len(src)-i && nSrc == 0; k++ {
+			var err error
+			nDst, nSrc, err = dec.Transform(dst[:], src[i:i+k], atEOF && i+k == len(src))
+			if err != nil && !errors.Is(err, transform.ErrShortSrc) {
+				break
+			}
+		}
+		if nSrc == 0 {
+			// Cannot happen for complete characters
+			t.addUnit(text, len(src)-i)
+			return
+		}
+		nDst = min(nDst, len(text))
+		t.addUnit(text[:nDst], nSrc)
+		text = text[nDst:]
+		i += nSrc
+	}
+	if text != "" {
+		// Flushed by the decoder at the end of the input
+		t.addUnit(text, 0)
+	}
+}
+
+// segment returns the index of the segment holding pos
+func (t *originTable) segment(pos int) int {
+	return max(sort.Search(len(t.segs), func(i int) bool { return t.segs[i].pos > pos })-1, 0)
+}
+
+// split makes a segment start at pos, given the text from start, the
+// start of the segment holding pos, to pos. The rest of a rune cut by pos
+// maps to the start of its encoding, and the runes after it go on from
+// its end.
+func (t *originTable) split(pos int, text func(start int) string) {
+	i := t.segment(pos)
+	s := t.segs[i]
+	if s.pos == pos {
+		return
+	}
+	next := s
+	next.pos = pos
+	if s.steps == [utf8.UTFMax]uint8{} {
+		t.segs = slices.Insert(t.segs, i+1, next)
+		return
+	}
+	before := text(s.pos)
+	next.orig = s.offset(pos, func() string { return before })
+
+	for j := len(before) - 1; j >= max(len(before)-utf8.UTFMax, 0); j-- {
+		n := runeSize(before[j])
+		if n == 0 {
+			continue
+		}
+		if j+n > len(before) {
+			cut := originSegment{pos: pos, orig: next.orig}
+			next.pos = s.pos + j + n
+			if step := s.steps[n-1]; step != noStep {
+				next.orig += int64(step)
+			}
+			if i+1 < len(t.segs) && next.pos >= t.segs[i+1].pos {
+				t.segs = slices.Insert(t.segs, i+1, cut)
+				return
+			}
+			t.segs = slices.Insert(t.segs, i+1, cut, next)
+			return
+		}
+		break
+	}
+	t.segs = slices.Insert(t.segs, i+1, next)
+}
+
+// updateOrigins moves the original offsets through op: the segments of the
+// deleted text go, the ones after it shift, and the inserted text maps to
+// the offset of the text it replaced
+func (gb *GapBuffer) updateOrigins(op Operation) {
+	t := gb.origins
+	if t == nil || op.Deleted == 0 && op.Inserted == 0 {
+		return
+	}
+
+	// The table still describes the text before op, which is the same up
+	// to op.Pos. Once a segment starts there, the one holding the end of
+	// the deleted text starts within the deleted text.
+	t.split(op.Pos, func(start int) string {
+		return gb.rawText(start, op.Pos)
+	})
+	t.split(op.Pos+op.Deleted, func(start int) string {
+		return op.DeletedText[start-op.Pos:]
+	})
+
+	from := t.segment(op.Pos)
+	orig := t.segs[from].orig
+	to := from
+	for to < len(t.segs) && t.segs[to].pos < op.Pos+op.Deleted {
+		to++
+	}
+	delta := op.Inserted - op.Deleted
+	for i := to; i < len(t.segs); i++ {
+		t.segs[i].pos += delta
+	}
+	if op.Inserted > 0 {
+		t.segs = slices.Replace(t.segs, from, to, originSegment{pos: op.Pos, orig: orig})
+	} else {
+		t.segs = slices.Delete(t.segs, from, to)
+	}
+}
@@ -0,0 +1,95 @@
+package buffer_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// encodedOffsets encodes text rune by rune and returns the input offset of
+// every byte of text, plus the offset of its end
+func encodedOffsets(t *testing.T, text string, enc encoding.Encoding) ([]byte, []int64) {
+	t.Helper()
+	var input []byte
+	var offsets []int64
+	for _, r := range text {
+		b, err := enc.NewEncoder().String(string(r))
+		if err != nil {
+			t.Fatalf("encoding %q: %v", r, err)
+		}
+		for range len(string(r)) {
+			offsets = append(offsets, int64(len(input)))
+		}
+		input = append(input, b...)
+	}
+	return input, append(offsets, int64(len(input)))
+}
+
+// TestOriginalOffset checks that positions map back to the input they were
+// decoded from, before and after edits
+func TestOriginalOffset(t *testing.T) {
+	tests := []struct {
+		name string
+		enc  encoding.Encoding
+		text string
+	}{
+		{"latin-1", charmap.ISO8859_1, "café naïve\n" + strings.Repeat("àb", 3000)},
+		{"shift-jis", japanese.ShiftJIS, "abc 日本語\nx" + strings.Repeat("かなab", 2000)},
+		{"utf-16", unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), "héllo \U0001f600 wörld"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, offsets := encodedOffsets(t, tt.text, tt.enc)
+			gb, err := buffer.NewFromReaderEncoding(bytes.NewReader(input), tt.enc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gb.GetText() != tt.text {
+				t.Fatalf("decoded %q", gb.GetText())
+			}
+			for pos, want := range offsets {
+				if got, err := gb.OriginalOffset(pos); err != nil || got != want {
+					t.Fatalf("position %d: got %d, %v, want %d", pos, got, err, want)
+				}
+			}
+
+			// Text after an edit keeps its offsets; the inserted text maps
+			// to the offset of the text it replaced
+			start := strings.IndexByte(tt.text, ' ')
+			end := start + 3
+			if err := gb.Replace(start, end, "«»"); err != nil {
+				t.Fatal(err)
+			}
+			delta := len("«»") - (end - start)
+			for pos := 0; pos <= gb.Length(); pos++ {
+				var want int64
+				switch {
+				case pos < start:
+					want = offsets[pos]
+				case pos < start+len("«»"):
+					want = offsets[start]
+				default:
+					want = offsets[pos-delta]
+				}
+				if got, err := gb.OriginalOffset(pos); err != nil || got != want {
+					t.Fatalf("after edit, position %d: got %d, %v, want %d", pos, got, err, want)
+				}
+			}
+			if _, err := gb.OriginalOffset(gb.Length() + 1); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+				t.Errorf("past the end: got %v, want ErrPositionOutOfRange", err)
+			}
+		})
+	}
+
+	if _, err := newBuffer(t, "text").OriginalOffset(0); !errors.Is(err, buffer.ErrNoOriginalOffsets) {
+		t.Errorf("buffer not decoded: got %v, want ErrNoOriginalOffsets", err)
+	}
+}
@@ -0,0 +1,91 @@
+package buffer
+
+import "sort"
+
+// LineClassifier inspects one line, without its newline, and reports whether
+// it is an outline item and under which label (a heading, a function, ...)
+type LineClassifier func(line string) (label string, ok bool)
+
+// OutlineItem is a line recognized by the outline classifier. End excludes the newline.
+type OutlineItem struct {
+	Start int
+	End   int
+	Label string
+}
+
+// outlineIndex keeps the outline items of a buffer sorted by position
+type outlineIndex struct {
+	classify LineClassifier
+	items    []OutlineItem
+}
+
+// SetOutlineClassifier installs the classifier used to maintain the outline.
+// The whole buffer is classified once; afterwards only lines touched by an
+// edit are classified again. A nil classifier disables the outline.
+func (gb *GapBuffer) SetOutlineClassifier(classify LineClassifier) {
+	if classify == nil {
+		gb.outline = nil
+		return
+	}
+	gb.outline = &outlineIndex{classify: classify}
+	gb.outline.items = gb.classifyLines(classify, 0, gb.length)
+}
+
+// Outline returns the current outline items ordered by position
+func (gb *GapBuffer) Outline() []OutlineItem {
+	if gb.outline == nil {
+		return nil
+	}
+	return append([]OutlineItem(nil), gb.outline.items...)
+}
+
+// classifyLines runs classify over every line overlapping [start, end]
+func (gb *GapBuffer) classifyLines(classify LineClassifier, start int, end int) []OutlineItem {
+	var items []OutlineItem
+	for pos := gb.lineStart(start); pos <= end; {
+		lineEnd := gb.lineEnd(pos)
+		if label, ok := classify(EnsureValidUTF8(gb.rawText(pos, lineEnd))); ok {
+			items = append(items, OutlineItem{Start: pos, End: lineEnd, Label: label})
+		}
+		if lineEnd >= gb.length {
+			break
+		}
+		pos = lineEnd + 1
+	}
+	return items
+}
+
+// updateOutline reclassifies the lines touched by op and shifts the items after it
+func (gb *GapBuffer) updateOutline(op Operation) {
+	if gb.outline == nil {
+		return
+	}
+
+	// Lines covering the edited text in the new coordinates
+	regionStart := gb.lineStart(op.Pos)
+	regionEnd := gb.lineEnd(op.Pos + op.Inserted)
+
+	delta := op.Inserted - op.Deleted
+	var before, after []OutlineItem
+	for _, item := range gb.outline.items {
+		switch {
+		case item.End < op.Pos:
+			if item.Start < regionStart {
+				before = append(before, item)
+			}
+		case item.Start > op.Pos+op.Deleted:
+			item.Start += delta
+			item.End += delta
+			if item.Start > regionEnd {
+				after = append(after, item)
+			}
+		}
+	}
+
+	items := append(before, gb.classifyLines(gb.outline.classify, regionStart, regionEnd)...)
+	items = append(items, after...)
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Start < items[j].Start
+	})
+	gb.outline.items = items
+}
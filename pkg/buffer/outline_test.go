@@ -0,0 +1,50 @@
+package buffer_test
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// headings classifies lines starting with # as outline items
+func headings(line string) (string, bool) {
+	if !strings.HasPrefix(line, "#") {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimLeft(line, "#")), true
+}
+
+// TestOutline checks the items found initially and that the outline kept
+// up to date through random edits matches classifying the text afresh
+func TestOutline(t *testing.T) {
+	gb := newBuffer(t, "# One\ntext\n## Two\n")
+	gb.SetOutlineClassifier(headings)
+	want := []buffer.OutlineItem{{Start: 0, End: 5, Label: "One"}, {Start: 11, End: 17, Label: "Two"}}
+	if got := gb.Outline(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	pieces := []string{"#", "# h", "\n", "text", "\n#", ""}
+	for i := 0; i < 1000; i++ {
+		start := rng.Intn(gb.Length() + 1)
+		end := start + rng.Intn(min(6, gb.Length()-start)+1)
+		if err := gb.Replace(start, end, pieces[rng.Intn(len(pieces))]); err != nil {
+			t.Fatal(err)
+		}
+
+		fresh := newBuffer(t, gb.GetText())
+		fresh.SetOutlineClassifier(headings)
+		if got, want := gb.Outline(), fresh.Outline(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("edit %d on %q: got %+v, want %+v", i, gb.GetText(), got, want)
+		}
+	}
+
+	gb.SetOutlineClassifier(nil)
+	if gb.Outline() != nil {
+		t.Error("outline kept without a classifier")
+	}
+}
@@ -0,0 +1,58 @@
+package buffer
+
+// PatchOptions controls the output of ExportChangesAsPatch
+type PatchOptions struct {
+	Name    string // File name used in the headers, "a/" and "b/" are prepended
+	Context int    // Number of context lines around changes, 3 if <= 0
+}
+
+// TextAt reconstructs the text of an earlier version by undoing the logged
+// operations applied since then
+func (gb *GapBuffer) TextAt(version int) (text string, err error) {
+	defer guard("TextAt", noPos, gb.length, &err)
+
+	ops, err := gb.oplog.since(version, gb.version)
+	if err != nil {
+		return "", err
+	}
+
+	rope := NewRopeFromString(gb.rawText(0, gb.length))
+	for i := len(ops) - 1; i >= 0; i-- {
+		op, err := gb.loadOperation(ops[i])
+		if err != nil {
+			return "", err
+		}
+		if err := rope.Replace(op.Pos, op.Pos+op.Inserted, op.DeletedText); err != nil {
+			return "", err
+		}
+	}
+
+	result := make([]byte, 0, rope.Length())
+	rope.root.each(0, rope.Length(), func(text string) {
+		result = append(result, text...)
+	})
+	return string(result), nil
+}
+
+// ExportChangesAsPatch returns a unified diff of the edits made since the
+// given version, with the text of that version as the old file. It is empty
+// when the text is unchanged.
+func (gb *GapBuffer) ExportChangesAsPatch(since int, opts PatchOptions) (patch string, err error) {
+	defer guard("ExportChangesAsPatch", noPos, gb.length, &err)
+
+	base, err := gb.TextAt(since)
+	if err != nil {
+		return "", err
+	}
+	if opts.Context <= 0 {
+		opts.Context = 3
+	}
+	name := opts.Name
+	if name == "" {
+		name = "buffer"
+	}
+
+	oldLines := splitLines(base)
+	newLines := splitLines(gb.rawText(0, gb.length))
+	return unifiedDiff(oldLines, newLines, "a/"+name, "b/"+name, opts.Context), nil
+}
@@ -0,0 +1,58 @@
+package buffer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestTextAt checks that every retained version can be rebuilt
+func TestTextAt(t *testing.T) {
+	gb := buffer.New()
+	texts := []string{""}
+	for _, e := range []buffer.Edit{
+		{Start: 0, End: 0, Text: "hello world"},
+		{Start: 0, End: 5, Text: "goodbye"},
+		{Start: 7, End: 13},
+		{Start: 7, End: 7, Text: ", moon"},
+	} {
+		gb.Replace(e.Start, e.End, e.Text)
+		texts = append(texts, gb.GetText())
+	}
+	for v, want := range texts {
+		if got, err := gb.TextAt(v); err != nil || got != want {
+			t.Errorf("version %d: got %q, %v, want %q", v, got, err, want)
+		}
+	}
+	if _, err := gb.TextAt(len(texts)); !errors.Is(err, buffer.ErrVersionUnavailable) {
+		t.Errorf("future version: got %v, want ErrVersionUnavailable", err)
+	}
+}
+
+// TestExportChangesAsPatch checks the hunks of a unified diff and that no
+// net change gives an empty patch
+func TestExportChangesAsPatch(t *testing.T) {
+	gb := newBuffer(t, "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\n")
+	since := gb.Version()
+	gb.Replace(4, 7, "TWO")
+	gb.InsertAt(gb.Length(), "ten")
+
+	patch, err := gb.ExportChangesAsPatch(since, buffer.PatchOptions{Name: "f.txt", Context: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--- a/f.txt\n+++ b/f.txt\n" +
+		"@@ -1,3 +1,3 @@\n one\n-two\n+TWO\n three\n" +
+		"@@ -9,1 +9,2 @@\n nine\n+ten\n\\ No newline at end of file\n"
+	if patch != want {
+		t.Errorf("got\n%s\nwant\n%s", patch, want)
+	}
+
+	since = gb.Version()
+	gb.InsertAt(0, "x")
+	gb.DeleteAt(0, 1)
+	if patch, err := gb.ExportChangesAsPatch(since, buffer.PatchOptions{}); err != nil || patch != "" {
+		t.Errorf("no net change: got %q, %v", patch, err)
+	}
+}
@@ -0,0 +1,162 @@
+package buffer
+
+import "slices"
+
+// piece is a run of text of a piece table, taken from the original text or
+// from the buffer of added text
+type piece struct {
+	added  bool
+	start  int
+	length int
+}
+
+// PieceTable keeps the text it was created with untouched and appends all
+// inserted text to a second buffer; the document is a list of pieces of
+// either. Edits only split and splice the list, so they never copy text
+// already in the table, which suits large files loaded once and edited
+// sparsely. Lookups walk the list, so it grows slower with many edits.
+type PieceTable struct {
+	original string
+	add      []byte // inserted text, only ever appended to
+	pieces   []piece
+	length   int
+}
+
+// NewPieceTable creates an empty piece table
+func NewPieceTable() *PieceTable {
+	return &PieceTable{}
+}
+
+// NewPieceTableFromString creates a piece table whose original text is
+// text, which is kept as it is and not copied
+func NewPieceTableFromString(text string) *PieceTable {
+	p := &PieceTable{original: text, length: len(text)}
+	if len(text) > 0 {
+		p.pieces = []piece{{start: 0, length: len(text)}}
+	}
+	return p
+}
+
+// Length returns the length of the text in bytes
+func (p *PieceTable) Length() int {
+	return p.length
+}
+
+// InsertAt inserts text at the specified position
+func (p *PieceTable) InsertAt(pos int, text string) (err error) {
+	defer guard("InsertAt", pos, p.length, &err)
+
+	if pos < 0 || pos > p.length {
+		return ErrPositionOutOfRange
+	}
+	return p.Replace(pos, pos, text)
+}
+
+// DeleteAt deletes text at the specified position
+func (p *PieceTable) DeleteAt(pos int, count int) (err error) {
+	defer guard("DeleteAt", pos, p.length, &err)
+
+	if pos == p.length && count > 0 {
+		return ErrDeleteAtEnd
+	}
+	if pos < 0 || count < 0 || pos > p.length || count > p.length-pos {
+		return ErrInvalidRange
+	}
+	return p.Replace(pos, pos+count, "")
+}
+
+// Replace replaces the text in the specified range
+func (p *PieceTable) Replace(start int, end int, text string) (err error) {
+	defer guard("Replace", start, p.length, &err)
+
+	if start < 0 || end > p.length || start > end {
+		return ErrInvalidRange
+	}
+	i := p.split(start)
+	j := p.split(end)
+	var inserted []piece
+	if len(text) > 0 {
+		// Typing appends to the piece just added instead of making a new one
+		if i > 0 && i == j {
+			if prev := &p.pieces[i-1]; prev.added && prev.start+prev.length == len(p.add) {
+				p.add = append(p.add, text...)
+				prev.length += len(text)
+				p.length += len(text)
+				return nil
+			}
+		}
+		inserted = []piece{{added: true, start: len(p.add), length: len(text)}}
+		p.add = append(p.add, text...)
+	}
+	p.pieces = slices.Replace(p.pieces, i, j, inserted...)
+	p.length += len(text) - (end - start)
+	return nil
+}
+
+// GetText returns the text in the piece table
+func (p *PieceTable) GetText() string {
+	return EnsureValidUTF8(p.text(0, p.length))
+}
+
+// GetTextRange returns the text in the specified range
+func (p *PieceTable) GetTextRange(start int, end int) (text string, err error) {
+	defer guard("GetTextRange", start, p.length, &err)
+
+	if start < 0 || end > p.length || start > end {
+		return "", ErrInvalidRange
+	}
+	return EnsureValidUTF8(p.text(start, end)), nil
+}
+
+// text returns the bytes in [start, end) as they are stored
+func (p *PieceTable) text(start int, end int) string {
+	result := make([]byte, 0, end-start)
+	pos := 0
+	for _, pc := range p.pieces {
+		if pos >= end {
+			break
+		}
+		if pos+pc.length > start {
+			from, to := pc.start+max(start-pos, 0), pc.start+min(end-pos, pc.length)
+			if pc.added {
+				result = append(result, p.add[from:to]...)
+			} else {
+				result = append(result, p.original[from:to]...)
+			}
+		}
+		pos += pc.length
+	}
+	return string(result)
+}
+
+// each calls fn with the text of the pieces in order. Pieces of the
+// original text are passed as they are, added ones are copied.
+func (p *PieceTable) each(fn func(text string)) {
+	for _, pc := range p.pieces {
+		if pc.added {
+			fn(string(p.add[pc.start : pc.start+pc.length]))
+		} else {
+			fn(p.original[pc.start : pc.start+pc.length])
+		}
+	}
+}
+
+// split makes pos fall between two pieces and returns the index of the
+// piece starting there, or the number of pieces at the end
+func (p *PieceTable) split(pos int) int {
+	at := 0
+	for i, pc := range p.pieces {
+		switch {
+		case pos == at:
+			return i
+		case pos < at+pc.length:
+			off := pos - at
+			head := piece{added: pc.added, start: pc.start, length: off}
+			tail := piece{added: pc.added, start: pc.start + off, length: pc.length - off}
+			p.pieces = slices.Replace(p.pieces, i, i+1, head, tail)
+			return i + 1
+		}
+		at += pc.length
+	}
+	return len(p.pieces)
+}
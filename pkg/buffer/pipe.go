@@ -0,0 +1,65 @@
+package buffer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// PipeThroughCommand streams the text in r, or the whole buffer if r is nil,
+// to the standard input of the command and replaces it with the output,
+// like an external formatter. The text is read from a snapshot chunk by
+// chunk, and the output is compared with it line by line as it arrives,
+// so neither is held in full. Only the lines that changed are edited, as
+// one edit set applied atomically, so markers elsewhere stay put and the
+// change is undone as one step. If the command fails, including when ctx
+// is done, the buffer is left untouched and the error carries its standard
+// error output.
+func (gb *GapBuffer) PipeThroughCommand(ctx context.Context, name string, args []string, r *Range) (applied []AppliedEdit, err error) {
+	defer guard("PipeThroughCommand", noPos, gb.length, &err)
+
+	target := Range{Start: 0, End: gb.length}
+	if r != nil {
+		target = *r
+	}
+	if target.Start < 0 || target.End > gb.length || target.Start > target.End {
+		return nil, ErrInvalidRange
+	}
+
+	// The command and the diff read the text on goroutines of their own
+	snap := gb.Snapshot().gb
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = &SizedReader{gb: snap, start: target.Start, pos: target.Start, end: target.End, version: snap.version}
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	old := &SizedReader{gb: snap, start: target.Start, pos: target.Start, end: target.End, version: snap.version}
+	edits, diffErr := streamLineEdits(old, stdout, target.Start)
+	if diffErr != nil {
+		// Wait must not be left waiting for output nobody reads
+		io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if diffErr != nil {
+		return nil, diffErr
+	}
+	if len(edits) == 0 {
+		return nil, nil
+	}
+	return gb.ApplyEdits(edits, OverlapError)
+}
@@ -0,0 +1,85 @@
+package buffer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestPipeThroughCommand formats a buffer with sed and checks that only
+// the changed lines are edited, wherever they are and however many lines
+// come and go around them
+func TestPipeThroughCommand(t *testing.T) {
+	if _, err := exec.LookPath("sed"); err != nil {
+		t.Skip("sed not available")
+	}
+	var sb strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&sb, "line %d\n", i)
+	}
+	text := sb.String()
+
+	tests := []struct {
+		name   string
+		script string
+		edits  int
+	}{
+		{"unchanged", "s/^nothing//", 0},
+		{"one line", "s/^line 1000$/LINE 1000/", 1},
+		{"scattered", "s/^line \\(.*\\)00$/LINE \\100/", 19},
+		{"deleted", "/^line 5..$/d", 1},
+		{"added", "/^line 1999$/a\\\nextra", 1},
+		{"last line", "$s/$/!/", 1},
+		{"every line", "s/line/LINE/", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gb := buffer.New()
+			gb.InsertAt(0, text)
+			cmd := exec.Command("sed", tt.script)
+			cmd.Stdin = strings.NewReader(text)
+			want, err := cmd.Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			applied, err := gb.PipeThroughCommand(context.Background(), "sed", []string{tt.script}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gb.GetText() != string(want) {
+				t.Fatal("text differs from the output of sed")
+			}
+			if len(applied) != tt.edits {
+				t.Fatalf("%d edits, want %d", len(applied), tt.edits)
+			}
+		})
+	}
+}
+
+// TestPipeThroughCommandFails checks that a failing command leaves the
+// buffer untouched and reports its standard error
+func TestPipeThroughCommandFails(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	gb := buffer.New()
+	gb.InsertAt(0, "keep\n")
+	_, err := gb.PipeThroughCommand(context.Background(), "sh", []string{"-c", "echo partial; echo broken >&2; exit 3"}, nil)
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("got %v, want the exit error with the standard error", err)
+	}
+	if gb.GetText() != "keep\n" || gb.Version() != 1 {
+		t.Fatalf("buffer changed to %q", gb.GetText())
+	}
+
+	if _, err := gb.PipeThroughCommand(context.Background(), "cat", nil, &buffer.Range{Start: 2, End: 9}); !errors.Is(err, buffer.ErrInvalidRange) {
+		t.Fatalf("range past the end: got %v, want ErrInvalidRange", err)
+	}
+}
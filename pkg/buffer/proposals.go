@@ -0,0 +1,210 @@
+package buffer
+
+import "errors"
+
+// Errors returned by ProposalQueue
+var (
+	ErrProposalNotFound = errors.New("proposal not found")
+	ErrProposalConflict = errors.New("proposal conflicts with later edits")
+	ErrProposalDecided  = errors.New("proposal already accepted or rejected")
+)
+
+// ProposalState describes where a proposal is in its lifecycle
+type ProposalState int
+
+const (
+	ProposalPending    ProposalState = iota // Shown as suggestions, waiting for a decision
+	ProposalConflicted                      // Overlaps edits made after its base version
+	ProposalAccepted                        // Applied to the buffer
+	ProposalRejected                        // Discarded
+)
+
+// Proposal is a set of edits submitted by an external tool against a base version
+type Proposal struct {
+	ID          int
+	BaseVersion int
+	Edits       []Edit // Edits in base version coordinates
+	State       ProposalState
+
+	suggestions []int // ids of the suggestions showing the edits
+}
+
+// ProposalQueue collects edit proposals from external tools (formatters,
+// codemods, AI assistants). Proposals made against an older version are
+// rebased onto the current text using the operation log and shown as
+// suggestions; proposals that overlap later edits are flagged as conflicted.
+type ProposalQueue struct {
+	gb        *GapBuffer
+	proposals []*Proposal
+	nextID    int
+}
+
+// NewProposalQueue creates a proposal queue for the buffer
+func NewProposalQueue(gb *GapBuffer) *ProposalQueue {
+	return &ProposalQueue{gb: gb}
+}
+
+// Submit adds a proposal made against baseVersion. The edits must be sorted,
+// non-overlapping and expressed in the coordinates of baseVersion.
+// A proposal that conflicts with later edits is kept with ProposalConflicted.
+func (q *ProposalQueue) Submit(baseVersion int, edits []Edit) (id int, err error) {
+	defer guard("Submit", noPos, q.gb.length, &err)
+
+	ops, err := q.gb.oplog.since(baseVersion, q.gb.version)
+	if err != nil {
+		return 0, err
+	}
+
+	// Length of the text at the base version
+	baseLength := q.gb.length
+	for _, op := range ops {
+		baseLength -= op.Inserted - op.Deleted
+	}
+	if err := validateEdits(edits, baseLength); err != nil {
+		return 0, err
+	}
+
+	p := &Proposal{
+		BaseVersion: baseVersion,
+		Edits:       append([]Edit(nil), edits...),
+		State:       ProposalPending,
+	}
+
+	// Rebase every edit onto the current version
+	rebased := make([]Edit, len(edits))
+	for i, e := range edits {
+		for _, op := range ops {
+			var ok bool
+			if e, ok = rebaseEdit(e, op); !ok {
+				p.State = ProposalConflicted
+				return q.add(p), nil
+			}
+		}
+		rebased[i] = e
+	}
+
+	// The proposal is only queued once all its suggestions are shown
+	for _, e := range rebased {
+		sid, err := q.gb.Suggest(e.Start, e.End, e.Text)
+		if err != nil {
+			q.dropSuggestions(p)
+			return 0, err
+		}
+		p.suggestions = append(p.suggestions, sid)
+	}
+	return q.add(p), nil
+}
+
+// add queues a proposal under a new id and returns the id
+func (q *ProposalQueue) add(p *Proposal) int {
+	q.nextID++
+	p.ID = q.nextID
+	q.proposals = append(q.proposals, p)
+	return p.ID
+}
+
+// Proposals returns all proposals in submission order with up-to-date states
+func (q *ProposalQueue) Proposals() []Proposal {
+	result := make([]Proposal, 0, len(q.proposals))
+	for _, p := range q.proposals {
+		q.refresh(p)
+		result = append(result, *p)
+	}
+	return result
+}
+
+// Conflicts returns the proposals that could not be rebased onto the current version
+func (q *ProposalQueue) Conflicts() []Proposal {
+	var result []Proposal
+	for _, p := range q.Proposals() {
+		if p.State == ProposalConflicted {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Accept applies all edits of a pending proposal as one unit
+func (q *ProposalQueue) Accept(id int) (err error) {
+	defer guard("Accept", noPos, q.gb.length, &err)
+
+	p := q.find(id)
+	if p == nil {
+		return ErrProposalNotFound
+	}
+	q.refresh(p)
+	if p.State == ProposalConflicted {
+		return ErrProposalConflict
+	}
+	if p.State != ProposalPending {
+		return ErrProposalDecided
+	}
+
+	// Edits are non-overlapping, so accepting one only shifts the others.
+	// The transaction makes them one undo step and reverts those already
+	// applied, with their suggestions, if one is refused.
+	err = q.gb.Transaction(func() error {
+		for _, sid := range p.suggestions {
+			if err := q.gb.AcceptSuggestion(sid); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	p.suggestions = nil
+	p.State = ProposalAccepted
+	return nil
+}
+
+// Reject discards a pending or conflicted proposal and its suggestions. An
+// accepted proposal is part of the text and can only be undone.
+func (q *ProposalQueue) Reject(id int) (err error) {
+	defer guard("Reject", noPos, q.gb.length, &err)
+
+	p := q.find(id)
+	if p == nil {
+		return ErrProposalNotFound
+	}
+	if p.State != ProposalPending && p.State != ProposalConflicted {
+		return ErrProposalDecided
+	}
+	q.dropSuggestions(p)
+	p.State = ProposalRejected
+	return nil
+}
+
+// find returns the proposal with the given id
+func (q *ProposalQueue) find(id int) *Proposal {
+	for _, p := range q.proposals {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// refresh flags a pending proposal as conflicted once any of its
+// suggestions was discarded by an overlapping edit
+func (q *ProposalQueue) refresh(p *Proposal) {
+	if p.State != ProposalPending {
+		return
+	}
+	for _, sid := range p.suggestions {
+		if !q.gb.hasSuggestion(sid) {
+			q.dropSuggestions(p)
+			p.State = ProposalConflicted
+			return
+		}
+	}
+}
+
+// dropSuggestions rejects the remaining suggestions of a proposal
+func (q *ProposalQueue) dropSuggestions(p *Proposal) {
+	for _, sid := range p.suggestions {
+		q.gb.takeSuggestion(sid)
+	}
+	p.suggestions = nil
+}
@@ -0,0 +1,116 @@
+package buffer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestProposalRebase submits proposals against an older version and checks
+// that the one clear of later edits is rebased and accepted, and the one
+// overlapping them is flagged
+func TestProposalRebase(t *testing.T) {
+	gb := buffer.New()
+	gb.InsertAt(0, "one two three")
+	base := gb.Version()
+	gb.InsertAt(0, ">> ")
+	gb.Replace(7, 10, "TWO")
+
+	q := buffer.NewProposalQueue(gb)
+	clean, err := q.Submit(base, []buffer.Edit{{Start: 8, End: 13, Text: "3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conflicted, err := q.Submit(base, []buffer.Edit{{Start: 4, End: 7, Text: "2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := q.Conflicts(); len(c) != 1 || c[0].ID != conflicted {
+		t.Fatalf("conflicts: got %+v, want proposal %d", c, conflicted)
+	}
+	if err := q.Accept(conflicted); !errors.Is(err, buffer.ErrProposalConflict) {
+		t.Fatalf("accepting a conflicted proposal: got %v, want ErrProposalConflict", err)
+	}
+
+	if err := q.Accept(clean); err != nil {
+		t.Fatal(err)
+	}
+	if got := gb.GetText(); got != ">> one TWO 3" {
+		t.Fatalf("got %q, want %q", got, ">> one TWO 3")
+	}
+}
+
+// TestProposalSubmitFails checks that a proposal whose suggestions cannot
+// be shown is not queued, and that the queue's errors carry their context
+func TestProposalSubmitFails(t *testing.T) {
+	gb := buffer.New()
+	gb.InsertAt(0, "text")
+	gb.SetLimits(buffer.Limits{MaxInsert: 2})
+
+	q := buffer.NewProposalQueue(gb)
+	id, err := q.Submit(gb.Version(), []buffer.Edit{{Start: 0, End: 0, Text: "too long"}})
+	if !errors.Is(err, buffer.ErrLimitExceeded) || id != 0 {
+		t.Fatalf("submitting past a limit: got %d, %v, want ErrLimitExceeded", id, err)
+	}
+	if p := q.Proposals(); len(p) != 0 {
+		t.Fatalf("failed proposal queued: %+v", p)
+	}
+	if s := gb.Suggestions(); len(s) != 0 {
+		t.Fatalf("failed proposal left suggestions: %+v", s)
+	}
+
+	id, err = q.Submit(gb.Version(), []buffer.Edit{{Start: 0, End: 0, Text: "ok"}})
+	if err != nil || id != 1 {
+		t.Fatalf("next proposal: got %d, %v, want id 1", id, err)
+	}
+	if err := q.Reject(id); err != nil {
+		t.Fatal(err)
+	}
+	var opErr *buffer.OpError
+	if err := q.Reject(42); !errors.Is(err, buffer.ErrProposalNotFound) || !errors.As(err, &opErr) || opErr.Op != "Reject" {
+		t.Fatalf("rejecting an unknown proposal: got %v, want an OpError wrapping ErrProposalNotFound", err)
+	}
+}
+
+// TestProposalDecided checks that a proposal accepted or rejected once
+// cannot be decided again, and keeps its state
+func TestProposalDecided(t *testing.T) {
+	gb := buffer.New()
+	gb.InsertAt(0, "text")
+	q := buffer.NewProposalQueue(gb)
+	accepted, _ := q.Submit(gb.Version(), []buffer.Edit{{Start: 0, End: 0, Text: "new "}})
+	rejected, _ := q.Submit(gb.Version(), []buffer.Edit{{Start: 4, End: 4, Text: "!"}})
+	if err := q.Accept(accepted); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Reject(rejected); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		decide func(id int) error
+		id     int
+	}{
+		{"reject accepted", q.Reject, accepted},
+		{"accept accepted", q.Accept, accepted},
+		{"reject rejected", q.Reject, rejected},
+		{"accept rejected", q.Accept, rejected},
+	}
+	for _, tt := range tests {
+		if err := tt.decide(tt.id); !errors.Is(err, buffer.ErrProposalDecided) {
+			t.Errorf("%s: got %v, want ErrProposalDecided", tt.name, err)
+		}
+	}
+	if got := gb.GetText(); got != "new text" {
+		t.Errorf("got %q, want %q", got, "new text")
+	}
+	states := map[int]buffer.ProposalState{}
+	for _, p := range q.Proposals() {
+		states[p.ID] = p.State
+	}
+	if states[accepted] != buffer.ProposalAccepted || states[rejected] != buffer.ProposalRejected {
+		t.Errorf("states changed: %v", states)
+	}
+}
@@ -0,0 +1,284 @@
+package buffer
+
+import "strings"
+
+// ProseKind is the kind of a block found by the prose structure scanner
+type ProseKind int
+
+const (
+	ProseParagraph ProseKind = iota // Run of non-blank lines
+	ProseHeading                    // ATX ("## Title") or setext (underlined) heading
+	ProseCode                       // Fenced code block, fences included
+)
+
+// ProseBlock is a block of a prose document. It spans whole lines; End
+// excludes the newline of its last line. Level is the heading level, 1 to 6.
+type ProseBlock struct {
+	Kind  ProseKind
+	Start int
+	End   int
+	Level int
+}
+
+// ProseSection is a heading and the text it governs, up to the next heading
+// of the same or a higher level. End excludes the newline before that heading.
+type ProseSection struct {
+	Heading ProseBlock
+	End     int
+}
+
+// proseIndex keeps the prose blocks of a buffer sorted by position
+type proseIndex struct {
+	blocks []ProseBlock
+}
+
+// SetProseStructure turns the prose structure scanner on or off. It finds
+// headings, paragraphs and fenced code blocks with Markdown conventions,
+// without a full Markdown parse, to drive outlines and folding in note-taking
+// apps. The whole buffer is scanned once; afterwards an edit only rescans the
+// blocks around it.
+func (gb *GapBuffer) SetProseStructure(on bool) {
+	if !on {
+		gb.prose = nil
+		return
+	}
+	blocks, _ := gb.scanProse(0, -1, nil)
+	gb.prose = &proseIndex{blocks: blocks}
+}
+
+// ProseBlocks returns the current prose blocks ordered by position
+func (gb *GapBuffer) ProseBlocks() []ProseBlock {
+	if gb.prose == nil {
+		return nil
+	}
+	return append([]ProseBlock(nil), gb.prose.blocks...)
+}
+
+// ProseSections returns the sections of the document, one per heading, in order
+func (gb *GapBuffer) ProseSections() []ProseSection {
+	if gb.prose == nil {
+		return nil
+	}
+
+	var sections []ProseSection
+	var open []int // indexes of sections whose end is not known yet
+	for _, b := range gb.prose.blocks {
+		if b.Kind != ProseHeading {
+			continue
+		}
+		for len(open) > 0 && sections[open[len(open)-1]].Heading.Level >= b.Level {
+			sections[open[len(open)-1]].End = max(b.Start-1, 0)
+			open = open[:len(open)-1]
+		}
+		open = append(open, len(sections))
+		sections = append(sections, ProseSection{Heading: b})
+	}
+	for _, i := range open {
+		sections[i].End = gb.length
+	}
+	return sections
+}
+
+// scanProse finds the blocks from the line starting at start. Once past
+// limit (never if limit < 0) it stops at the first blank line outside a
+// block that keep does not cover, and returns the position of that line.
+// Otherwise it scans to the end of the buffer and returns its length.
+func (gb *GapBuffer) scanProse(start int, limit int, keep []ProseBlock) ([]ProseBlock, int) {
+	var blocks []ProseBlock
+	para := -1   // start of the open paragraph
+	paraEnd := 0 // end of its last line
+	fence := ""  // marker of the open code block
+	fenceStart := 0
+
+	closePara := func() {
+		if para >= 0 {
+			blocks = append(blocks, ProseBlock{Kind: ProseParagraph, Start: para, End: paraEnd})
+			para = -1
+		}
+	}
+
+	for pos := start; ; {
+		end := gb.lineEnd(pos)
+		line := strings.TrimSuffix(EnsureValidUTF8(gb.rawText(pos, end)), "\r")
+
+		switch {
+		case fence != "":
+			if closesFence(line, fence) {
+				blocks = append(blocks, ProseBlock{Kind: ProseCode, Start: fenceStart, End: end})
+				fence = ""
+			}
+		case strings.TrimSpace(line) == "":
+			closePara()
+			if limit >= 0 && pos > limit && !proseCovers(keep, pos) {
+				return blocks, pos
+			}
+		case openFence(line) != "":
+			closePara()
+			fence, fenceStart = openFence(line), pos
+		case atxLevel(line) > 0:
+			closePara()
+			blocks = append(blocks, ProseBlock{Kind: ProseHeading, Start: pos, End: end, Level: atxLevel(line)})
+		case para >= 0 && setextLevel(line) > 0:
+			blocks = append(blocks, ProseBlock{Kind: ProseHeading, Start: para, End: end, Level: setextLevel(line)})
+			para = -1
+		case para < 0 && isThematicBreak(line):
+			// A horizontal rule is not a block worth folding
+		default:
+			if para < 0 {
+				para = pos
+			}
+			paraEnd = end
+		}
+
+		if end >= gb.length {
+			break
+		}
+		pos = end + 1
+	}
+
+	closePara()
+	if fence != "" {
+		// An unclosed fence runs to the end of the document
+		blocks = append(blocks, ProseBlock{Kind: ProseCode, Start: fenceStart, End: gb.length})
+	}
+	return blocks, gb.length
+}
+
+// proseCovers reports whether pos lies within one of the blocks
+func proseCovers(blocks []ProseBlock, pos int) bool {
+	for _, b := range blocks {
+		if b.Start <= pos && pos <= b.End {
+			return true
+		}
+	}
+	return false
+}
+
+// updateProse rescans the blocks around op and shifts the blocks after it.
+// Blocks never start inside a code block, so scanning can restart at the
+// start of the block around the edit and stop at a blank line after it
+// where the old blocks agree.
+func (gb *GapBuffer) updateProse(op Operation) {
+	if gb.prose == nil {
+		return
+	}
+
+	// Restart at the block containing the edited line or the one right
+	// above it, which a setext underline or a paragraph line could join
+	restart := gb.lineStart(op.Pos)
+	// Scanning must at least pass the edit and the end of every block it rescans
+	limit := gb.lineEnd(op.Pos + op.Inserted)
+	delta := op.Inserted - op.Deleted
+	var before, after []ProseBlock
+	for _, b := range gb.prose.blocks {
+		switch {
+		case b.Start < restart && b.End+1 < restart:
+			before = append(before, b)
+		case b.Start > op.Pos+op.Deleted:
+			b.Start += delta
+			b.End += delta
+			after = append(after, b)
+		default:
+			restart = min(restart, b.Start)
+			if b.End > op.Pos+op.Deleted {
+				limit = max(limit, b.End+delta)
+			}
+		}
+	}
+	// Blocks kept before restart may now touch it
+	for len(before) > 0 && before[len(before)-1].End+1 >= restart {
+		restart = min(restart, before[len(before)-1].Start)
+		before = before[:len(before)-1]
+	}
+
+	blocks, stop := gb.scanProse(restart, limit, after)
+	for len(after) > 0 && after[0].Start <= stop {
+		after = after[1:]
+	}
+
+	result := append(before, blocks...)
+	gb.prose.blocks = append(result, after...)
+}
+
+// atxLevel returns the level of an ATX heading line, or 0
+func atxLevel(line string) int {
+	line, ok := trimIndent(line)
+	if !ok {
+		return 0
+	}
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0
+	}
+	if level < len(line) && line[level] != ' ' && line[level] != '\t' {
+		return 0
+	}
+	return level
+}
+
+// setextLevel returns 1 or 2 for a line of only '=' or '-' underlining a heading, or 0
+func setextLevel(line string) int {
+	line, ok := trimIndent(line)
+	line = strings.TrimRight(line, " \t")
+	if !ok || line == "" {
+		return 0
+	}
+	switch {
+	case strings.Trim(line, "=") == "":
+		return 1
+	case strings.Trim(line, "-") == "":
+		return 2
+	}
+	return 0
+}
+
+// isThematicBreak reports whether line is a horizontal rule such as "***" or "- - -"
+func isThematicBreak(line string) bool {
+	line, ok := trimIndent(line)
+	if !ok {
+		return false
+	}
+	line = strings.ReplaceAll(strings.ReplaceAll(line, " ", ""), "\t", "")
+	if len(line) < 3 {
+		return false
+	}
+	c := line[0]
+	return (c == '*' || c == '-' || c == '_') && strings.Trim(line, string(c)) == ""
+}
+
+// openFence returns the marker of a line opening a fenced code block, or ""
+func openFence(line string) string {
+	line, ok := trimIndent(line)
+	if !ok || len(line) < 3 || (line[0] != '`' && line[0] != '~') {
+		return ""
+	}
+	n := 0
+	for n < len(line) && line[n] == line[0] {
+		n++
+	}
+	if n < 3 || (line[0] == '`' && strings.ContainsRune(line[n:], '`')) {
+		return ""
+	}
+	return line[:n]
+}
+
+// closesFence reports whether line closes the code block opened with marker
+func closesFence(line string, marker string) bool {
+	line, ok := trimIndent(line)
+	if !ok || !strings.HasPrefix(line, marker) {
+		return false
+	}
+	return strings.TrimSpace(strings.TrimLeft(line, marker[:1])) == ""
+}
+
+// trimIndent removes up to three spaces of indentation. More makes the line
+// an indented code line, which never starts a block.
+func trimIndent(line string) (string, bool) {
+	for i := 0; i < 3 && strings.HasPrefix(line, " "); i++ {
+		line = line[1:]
+	}
+	return line, !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t")
+}
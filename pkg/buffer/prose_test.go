@@ -0,0 +1,68 @@
+package buffer_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestProseStructure checks the blocks and sections of a small document
+func TestProseStructure(t *testing.T) {
+	text := "# Title\n\nIntro line\nmore\n\nSub\n---\n```\n# not a heading\n```\n\n# Next\n"
+	gb := newBuffer(t, text)
+	if gb.ProseBlocks() != nil {
+		t.Error("blocks found with the scanner off")
+	}
+	gb.SetProseStructure(true)
+
+	want := []buffer.ProseBlock{
+		{Kind: buffer.ProseHeading, Start: 0, End: 7, Level: 1},
+		{Kind: buffer.ProseParagraph, Start: 9, End: 24},
+		{Kind: buffer.ProseHeading, Start: 26, End: 33, Level: 2},
+		{Kind: buffer.ProseCode, Start: 34, End: 57},
+		{Kind: buffer.ProseHeading, Start: 59, End: 65, Level: 1},
+	}
+	if got := gb.ProseBlocks(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	sections := gb.ProseSections()
+	wantEnds := []int{58, 58, len(text)}
+	if len(sections) != len(wantEnds) {
+		t.Fatalf("got %+v", sections)
+	}
+	for i, s := range sections {
+		if s.End != wantEnds[i] {
+			t.Errorf("section %d: got end %d, want %d", i, s.End, wantEnds[i])
+		}
+	}
+
+	gb.SetProseStructure(false)
+	if gb.ProseBlocks() != nil || gb.ProseSections() != nil {
+		t.Error("blocks kept with the scanner off")
+	}
+}
+
+// TestProseStructureEdits checks that the blocks kept up to date through
+// random edits match scanning the text afresh
+func TestProseStructureEdits(t *testing.T) {
+	gb := newBuffer(t, "# A\n\ntext\n")
+	gb.SetProseStructure(true)
+
+	rng := rand.New(rand.NewSource(1))
+	pieces := []string{"#", "# h", "\n", "\n\n", "text", "===", "---", "```", "~~~", "    ", ""}
+	for i := 0; i < 2000; i++ {
+		start := rng.Intn(gb.Length() + 1)
+		end := start + rng.Intn(min(8, gb.Length()-start)+1)
+		if err := gb.Replace(start, end, pieces[rng.Intn(len(pieces))]); err != nil {
+			t.Fatal(err)
+		}
+
+		fresh := newBuffer(t, gb.GetText())
+		fresh.SetProseStructure(true)
+		if got, want := gb.ProseBlocks(), fresh.ProseBlocks(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("edit %d on %q: got %+v, want %+v", i, gb.GetText(), got, want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package buffer
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrQuotaExceeded is wrapped by every *QuotaError
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaError reports an edit rejected because its owner is out of quota
+type QuotaError struct {
+	Owner     string
+	Limit     int // Quota of the owner in bytes
+	Usage     int // Bytes used by the owner before the edit
+	Requested int // Bytes the edit would add
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("quota exceeded for %q: %d + %d > %d", e.Owner, e.Usage, e.Requested, e.Limit)
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// QuotaHook decides what happens when an edit would exceed its owner's
+// quota. Returning nil lets the edit through, e.g. for a soft quota that is
+// only logged; returning an error rejects the edit with that error.
+type QuotaHook func(e *QuotaError) error
+
+// QuotaManager accounts the text bytes of buffers per logical owner, such
+// as a tenant, and enforces a byte quota across all buffers of an owner.
+// It is safe for concurrent use by buffers edited on different goroutines.
+type QuotaManager struct {
+	mu           sync.Mutex
+	defaultLimit int
+	limits       map[string]int
+	usage        map[string]int
+	hook         QuotaHook
+}
+
+// NewQuotaManager creates a quota manager giving every owner defaultLimit
+// bytes. A limit <= 0 means no limit.
+func NewQuotaManager(defaultLimit int) *QuotaManager {
+	return &QuotaManager{
+		defaultLimit: defaultLimit,
+		limits:       make(map[string]int),
+		usage:        make(map[string]int),
+	}
+}
+
+// SetLimit sets the quota of owner, overriding the default. Usage above
+// a lowered limit is kept, but only edits shrinking it are accepted.
+func (q *QuotaManager) SetLimit(owner string, limit int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.limits[owner] = limit
+}
+
+// Limit returns the quota of owner in bytes, <= 0 if unlimited
+func (q *QuotaManager) Limit(owner string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.limit(owner)
+}
+
+func (q *QuotaManager) limit(owner string) int {
+	if limit, ok := q.limits[owner]; ok {
+		return limit
+	}
+	return q.defaultLimit
+}
+
+// Usage returns the bytes currently held by the buffers of owner
+func (q *QuotaManager) Usage(owner string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.usage[owner]
+}
+
+// SetExceededHook installs a hook called instead of rejecting edits that
+// exceed a quota. A nil hook restores the default of rejecting them with a
+// *QuotaError.
+func (q *QuotaManager) SetExceededHook(hook QuotaHook) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.hook = hook
+}
+
+// charge adds delta bytes to the usage of owner. Growth past the quota is
+// refused unless the hook allows it; shrinking always succeeds.
+func (q *QuotaManager) charge(owner string, delta int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	usage := q.usage[owner]
+	if limit := q.limit(owner); delta > 0 && limit > 0 && usage+delta > limit {
+		qe := &QuotaError{Owner: owner, Limit: limit, Usage: usage, Requested: delta}
+		if q.hook == nil {
+			return qe
+		}
+		if err := q.hook(qe); err != nil {
+			return err
+		}
+	}
+
+	if usage += delta; usage == 0 {
+		delete(q.usage, owner)
+	} else {
+		q.usage[owner] = usage
+	}
+	return nil
+}
+
+// SetQuota charges the buffer's text to owner in q from now on, moving its
+// current length away from the previous owner, if any. It fails when the
+// text does not fit in the owner's quota. A nil q detaches the buffer.
+func (gb *GapBuffer) SetQuota(q *QuotaManager, owner string) (err error) {
+	defer guard("SetQuota", noPos, gb.length, &err)
+
+	if q != nil {
+		if err := q.charge(owner, gb.length); err != nil {
+			return err
+		}
+	}
+	gb.releaseQuota()
+	gb.quota, gb.quotaOwner = q, owner
+	return nil
+}
+
+// chargeQuota accounts an edit changing the length by delta to the buffer's owner
+func (gb *GapBuffer) chargeQuota(delta int) error {
+	if gb.quota == nil || delta == 0 {
+		return nil
+	}
+	return gb.quota.charge(gb.quotaOwner, delta)
+}
+
+// releaseQuota gives the buffer's bytes back to its owner and detaches it
+func (gb *GapBuffer) releaseQuota() {
+	if gb.quota != nil {
+		gb.quota.charge(gb.quotaOwner, -gb.length)
+	}
+	gb.quota, gb.quotaOwner = nil, ""
+}
@@ -0,0 +1,95 @@
+package buffer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestQuota checks that buffers of one owner share its quota, that edits
+// past it are rejected untouched, and that bytes are given back by
+// shrinking edits, by moving to another owner and by Close
+func TestQuota(t *testing.T) {
+	q := buffer.NewQuotaManager(10)
+	q.SetLimit("big", 100)
+	a, b := newBuffer(t, "12345"), newBuffer(t, "123")
+	if err := a.SetQuota(q, "tenant"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetQuota(q, "tenant"); err != nil {
+		t.Fatal(err)
+	}
+	if q.Usage("tenant") != 8 {
+		t.Fatalf("usage %d, want 8", q.Usage("tenant"))
+	}
+
+	err := b.InsertAt(0, "abc")
+	var qe *buffer.QuotaError
+	if !errors.As(err, &qe) || !errors.Is(err, buffer.ErrQuotaExceeded) {
+		t.Fatalf("past the quota: got %v", err)
+	}
+	if *qe != (buffer.QuotaError{Owner: "tenant", Limit: 10, Usage: 8, Requested: 3}) {
+		t.Errorf("got %+v", *qe)
+	}
+	if b.GetText() != "123" || q.Usage("tenant") != 8 {
+		t.Errorf("rejected edit left %q with usage %d", b.GetText(), q.Usage("tenant"))
+	}
+	if _, err := b.ApplyEdits([]buffer.Edit{{Start: 0, End: 0, Text: "ab"}, {Start: 3, End: 3, Text: "c"}}, buffer.OverlapError); !errors.Is(err, buffer.ErrQuotaExceeded) {
+		t.Errorf("edit set past the quota: got %v", err)
+	}
+
+	// Replacing within the quota and shrinking are fine
+	if err := a.Replace(0, 5, "1234567"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Undo(); err != nil || q.Usage("tenant") != 8 {
+		t.Errorf("undo: usage %d, %v", q.Usage("tenant"), err)
+	}
+
+	if err := b.SetQuota(q, "big"); err != nil {
+		t.Fatal(err)
+	}
+	if q.Usage("tenant") != 5 || q.Usage("big") != 3 {
+		t.Errorf("moved: usage %d and %d", q.Usage("tenant"), q.Usage("big"))
+	}
+	if err := a.Close(); err != nil || q.Usage("tenant") != 0 {
+		t.Errorf("closed: usage %d, %v", q.Usage("tenant"), err)
+	}
+
+	if err := newBuffer(t, "far too long").SetQuota(q, "tenant"); !errors.Is(err, buffer.ErrQuotaExceeded) {
+		t.Errorf("text past the quota: got %v", err)
+	}
+	if q.Usage("tenant") != 0 {
+		t.Errorf("refused buffer charged %d", q.Usage("tenant"))
+	}
+}
+
+// TestQuotaHook checks that a hook can let edits through or replace the
+// error
+func TestQuotaHook(t *testing.T) {
+	q := buffer.NewQuotaManager(4)
+	gb := buffer.New()
+	gb.SetQuota(q, "soft")
+
+	var exceeded []buffer.QuotaError
+	q.SetExceededHook(func(e *buffer.QuotaError) error {
+		exceeded = append(exceeded, *e)
+		return nil
+	})
+	if err := gb.InsertAt(0, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if len(exceeded) != 1 || q.Usage("soft") != 5 {
+		t.Errorf("soft quota: %+v, usage %d", exceeded, q.Usage("soft"))
+	}
+
+	refused := errors.New("refused")
+	q.SetExceededHook(func(e *buffer.QuotaError) error { return refused })
+	if err := gb.InsertAt(0, "!"); !errors.Is(err, refused) {
+		t.Errorf("hook error: got %v", err)
+	}
+	if err := gb.DeleteAt(0, 2); err != nil {
+		t.Errorf("shrinking over the quota: %v", err)
+	}
+}
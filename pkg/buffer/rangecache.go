@@ -0,0 +1,72 @@
+package buffer
+
+// DEFAULT_RANGE_CACHE_SIZE is the number of GetTextRange results a buffer keeps
+const DEFAULT_RANGE_CACHE_SIZE = 8
+
+// rangeCacheMaxLen bounds the length of a cached range, so the cache holds
+// the lines widgets show rather than pinning large copies of the text
+const rangeCacheMaxLen = 16 << 10
+
+// rangeCacheEntry is a cached GetTextRange result
+type rangeCacheEntry struct {
+	start int
+	end   int
+	text  string
+}
+
+// rangeCache keeps the most recent GetTextRange results of one version,
+// most recently used first. Several widgets showing the same line then
+// share one flattening of it.
+type rangeCache struct {
+	size    int // maximum number of entries, 0 to disable
+	version int // version the entries belong to
+	entries []rangeCacheEntry
+}
+
+// SetRangeCacheSize sets how many recent GetTextRange results are kept for
+// repeated reads of the same range, 0 to disable the cache. Results are
+// dropped as soon as the buffer is edited. Ranges longer than 16KB are not
+// cached.
+func (gb *GapBuffer) SetRangeCacheSize(n int) {
+	gb.rangeCache = rangeCache{size: max(n, 0)}
+}
+
+// RangeCacheSize returns the number of results set with SetRangeCacheSize
+func (gb *GapBuffer) RangeCacheSize() int {
+	return gb.rangeCache.size
+}
+
+// get returns the cached text of [start, end) at version, moving it to the
+// front
+func (c *rangeCache) get(version int, start int, end int) (string, bool) {
+	if c.version != version {
+		c.entries = c.entries[:0]
+		c.version = version
+		return "", false
+	}
+	for i, e := range c.entries {
+		if e.start == start && e.end == end {
+			copy(c.entries[1:i+1], c.entries[:i])
+			c.entries[0] = e
+			return e.text, true
+		}
+	}
+	return "", false
+}
+
+// put records the text of [start, end) at version, evicting the least
+// recently used entry when full
+func (c *rangeCache) put(version int, start int, end int, text string) {
+	if c.size == 0 || end-start > rangeCacheMaxLen {
+		return
+	}
+	if c.version != version {
+		c.entries = c.entries[:0]
+		c.version = version
+	}
+	if len(c.entries) < c.size {
+		c.entries = append(c.entries, rangeCacheEntry{})
+	}
+	copy(c.entries[1:], c.entries)
+	c.entries[0] = rangeCacheEntry{start: start, end: end, text: text}
+}
@@ -0,0 +1,65 @@
+package buffer_test
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestRangeCache checks that repeated reads of a range return the cached
+// string, that eviction and edits drop it, and that large ranges and a
+// disabled cache are not served from it
+func TestRangeCache(t *testing.T) {
+	gb := buffer.NewWithChunkSize(16)
+	gb.InsertAt(0, strings.Repeat("cached line\n", 4000))
+	if gb.RangeCacheSize() != buffer.DEFAULT_RANGE_CACHE_SIZE {
+		t.Errorf("got cache size %d", gb.RangeCacheSize())
+	}
+	// cached reports whether reading [start, end) twice gives the same
+	// string rather than a new flattening of the chunks
+	cached := func(first string, start, end int) bool {
+		text, _ := gb.GetTextRange(start, end)
+		return text == first && unsafe.StringData(text) == unsafe.StringData(first)
+	}
+	read := func(start, end int) string {
+		text, _ := gb.GetTextRange(start, end)
+		return text
+	}
+
+	line := read(12, 60)
+	if !cached(line, 12, 60) {
+		t.Errorf("a repeated read was not cached")
+	}
+	if large := read(0, 20<<10); cached(large, 0, 20<<10) {
+		t.Errorf("a range over 16KB was cached")
+	}
+
+	// Of nine ranges, the least recently used is evicted
+	first := read(100, 150)
+	for i := 1; i <= buffer.DEFAULT_RANGE_CACHE_SIZE; i++ {
+		read(100+i, 150+i)
+	}
+	if cached(first, 100, 150) {
+		t.Errorf("the least recently used range was kept")
+	}
+	if !cached(read(108, 158), 108, 158) {
+		t.Errorf("the most recently used range was evicted")
+	}
+
+	line = read(12, 24)
+	gb.Replace(12, 18, "edited")
+	if text := read(12, 24); text != "edited line\n" {
+		t.Errorf("read %q after an edit", text)
+	}
+	gb.Undo()
+	if text := read(12, 24); text != "cached line\n" || cached(line, 12, 24) {
+		t.Errorf("read %q after undo", text)
+	}
+
+	gb.SetRangeCacheSize(0)
+	if cached(read(12, 60), 12, 60) {
+		t.Errorf("a disabled cache served a range")
+	}
+}
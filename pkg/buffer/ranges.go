@@ -0,0 +1,222 @@
+package buffer
+
+import "sort"
+
+// Range is the half-open range of positions [Start, End)
+type Range struct {
+	Start int
+	End   int
+}
+
+// Len returns the number of positions in the range
+func (r Range) Len() int {
+	if r.End < r.Start {
+		return 0
+	}
+	return r.End - r.Start
+}
+
+// Empty reports whether the range contains no position
+func (r Range) Empty() bool {
+	return r.End <= r.Start
+}
+
+// Contains reports whether pos lies in the range
+func (r Range) Contains(pos int) bool {
+	return r.Start <= pos && pos < r.End
+}
+
+// Overlaps reports whether both ranges share at least one position
+func (r Range) Overlaps(o Range) bool {
+	return r.Start < o.End && o.Start < r.End
+}
+
+// Shift maps the range through an edit. Text inserted strictly inside the
+// range becomes part of it, text inserted at its boundaries does not, and
+// deleted positions are removed from it.
+func (r Range) Shift(op Operation) Range {
+	return Range{Start: shiftStart(r.Start, op), End: shiftEnd(r.End, op)}
+}
+
+// shiftStart maps the start of a range through an edit
+func shiftStart(p int, op Operation) int {
+	switch {
+	case p < op.Pos:
+		return p
+	case p >= op.Pos+op.Deleted:
+		return p + op.Inserted - op.Deleted
+	default:
+		return op.Pos + op.Inserted
+	}
+}
+
+// shiftEnd maps the end of a range through an edit
+func shiftEnd(p int, op Operation) int {
+	switch {
+	case p <= op.Pos:
+		return p
+	case p >= op.Pos+op.Deleted:
+		return p + op.Inserted - op.Deleted
+	default:
+		return op.Pos
+	}
+}
+
+// RangeSet is a set of positions stored as sorted, non-overlapping,
+// non-adjacent ranges. It backs selections, highlights and damage tracking.
+// The zero value is an empty set.
+type RangeSet struct {
+	ranges []Range
+}
+
+// NewRangeSet returns the set covering the given ranges
+func NewRangeSet(ranges ...Range) *RangeSet {
+	s := &RangeSet{ranges: append([]Range(nil), ranges...)}
+	s.normalize()
+	return s
+}
+
+// normalize sorts the ranges, drops empty ones and merges overlapping or adjacent ones
+func (s *RangeSet) normalize() {
+	sort.Slice(s.ranges, func(i, j int) bool {
+		return s.ranges[i].Start < s.ranges[j].Start
+	})
+
+	merged := s.ranges[:0]
+	for _, r := range s.ranges {
+		if r.Empty() {
+			continue
+		}
+		if n := len(merged); n > 0 && r.Start <= merged[n-1].End {
+			if r.End > merged[n-1].End {
+				merged[n-1].End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	s.ranges = merged
+}
+
+// Ranges returns the ranges of the set in order
+func (s *RangeSet) Ranges() []Range {
+	return append([]Range(nil), s.ranges...)
+}
+
+// Len returns the number of positions in the set
+func (s *RangeSet) Len() int {
+	n := 0
+	for _, r := range s.ranges {
+		n += r.Len()
+	}
+	return n
+}
+
+// Empty reports whether the set contains no position
+func (s *RangeSet) Empty() bool {
+	return len(s.ranges) == 0
+}
+
+// Contains reports whether pos is in the set
+func (s *RangeSet) Contains(pos int) bool {
+	i := sort.Search(len(s.ranges), func(i int) bool {
+		return s.ranges[i].End > pos
+	})
+	return i < len(s.ranges) && s.ranges[i].Contains(pos)
+}
+
+// Add adds the positions of r to the set
+func (s *RangeSet) Add(r Range) {
+	s.ranges = append(s.ranges, r)
+	s.normalize()
+}
+
+// Remove removes the positions of r from the set
+func (s *RangeSet) Remove(r Range) {
+	*s = *s.Subtract(NewRangeSet(r))
+}
+
+// Clone returns a copy of the set
+func (s *RangeSet) Clone() *RangeSet {
+	return &RangeSet{ranges: s.Ranges()}
+}
+
+// Union returns the positions in s or o
+func (s *RangeSet) Union(o *RangeSet) *RangeSet {
+	return NewRangeSet(append(s.Ranges(), o.ranges...)...)
+}
+
+// Intersect returns the positions in both s and o
+func (s *RangeSet) Intersect(o *RangeSet) *RangeSet {
+	result := &RangeSet{}
+	i, j := 0, 0
+	for i < len(s.ranges) && j < len(o.ranges) {
+		a, b := s.ranges[i], o.ranges[j]
+		start, end := max(a.Start, b.Start), min(a.End, b.End)
+		if start < end {
+			result.ranges = append(result.ranges, Range{Start: start, End: end})
+		}
+		if a.End < b.End {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
+
+// Subtract returns the positions in s that are not in o
+func (s *RangeSet) Subtract(o *RangeSet) *RangeSet {
+	result := &RangeSet{}
+	j := 0
+	for _, r := range s.ranges {
+		start := r.Start
+		for j < len(o.ranges) && o.ranges[j].End <= start {
+			j++
+		}
+		for k := j; k < len(o.ranges) && o.ranges[k].Start < r.End; k++ {
+			if o.ranges[k].Start > start {
+				result.ranges = append(result.ranges, Range{Start: start, End: o.ranges[k].Start})
+			}
+			start = max(start, o.ranges[k].End)
+		}
+		if start < r.End {
+			result.ranges = append(result.ranges, Range{Start: start, End: r.End})
+		}
+	}
+	return result
+}
+
+// Shift maps every range of the set through an edit, see Range.Shift
+func (s *RangeSet) Shift(op Operation) {
+	for i, r := range s.ranges {
+		s.ranges[i] = r.Shift(op)
+	}
+	s.normalize()
+}
+
+// TrackRangeSet makes the buffer shift s through every edit it applies. A
+// nil set is ignored.
+func (gb *GapBuffer) TrackRangeSet(s *RangeSet) {
+	if s == nil {
+		return
+	}
+	gb.rangeSets = append(gb.rangeSets, s)
+}
+
+// UntrackRangeSet stops shifting s through edits
+func (gb *GapBuffer) UntrackRangeSet(s *RangeSet) {
+	for i, tracked := range gb.rangeSets {
+		if tracked == s {
+			gb.rangeSets = append(gb.rangeSets[:i], gb.rangeSets[i+1:]...)
+			return
+		}
+	}
+}
+
+// shiftRangeSets shifts all tracked range sets through op
+func (gb *GapBuffer) shiftRangeSets(op Operation) {
+	for _, s := range gb.rangeSets {
+		s.Shift(op)
+	}
+}
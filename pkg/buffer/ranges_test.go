@@ -0,0 +1,105 @@
+package buffer_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// positions returns the positions below n in s, as a set model
+func positions(s *buffer.RangeSet, n int) []bool {
+	in := make([]bool, n)
+	for pos := range in {
+		in[pos] = s.Contains(pos)
+	}
+	return in
+}
+
+// randomRangeSet returns a set of a few random ranges below n
+func randomRangeSet(rng *rand.Rand, n int) *buffer.RangeSet {
+	var ranges []buffer.Range
+	for range rng.Intn(5) {
+		start := rng.Intn(n)
+		ranges = append(ranges, buffer.Range{Start: start, End: start + rng.Intn(n-start+1)})
+	}
+	return buffer.NewRangeSet(ranges...)
+}
+
+// TestRangeSetAlgebra checks union, intersection and difference position
+// by position, and that results stay normalized
+func TestRangeSetAlgebra(t *testing.T) {
+	const n = 30
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		a, b := randomRangeSet(rng, n), randomRangeSet(rng, n)
+		pa, pb := positions(a, n), positions(b, n)
+		for name, got := range map[string]*buffer.RangeSet{
+			"union":     a.Union(b),
+			"intersect": a.Intersect(b),
+			"subtract":  a.Subtract(b),
+		} {
+			ranges := got.Ranges()
+			for j, r := range ranges {
+				if r.Empty() || j > 0 && r.Start <= ranges[j-1].End {
+					t.Fatalf("%s: not normalized: %v", name, ranges)
+				}
+			}
+			count := 0
+			for pos, in := range positions(got, n) {
+				var want bool
+				switch name {
+				case "union":
+					want = pa[pos] || pb[pos]
+				case "intersect":
+					want = pa[pos] && pb[pos]
+				case "subtract":
+					want = pa[pos] && !pb[pos]
+				}
+				if in != want {
+					t.Fatalf("%s of %v and %v: position %d in %v", name, a.Ranges(), b.Ranges(), pos, in)
+				}
+				if in {
+					count++
+				}
+			}
+			if got.Len() != count {
+				t.Fatalf("%s: Len %d, want %d", name, got.Len(), count)
+			}
+		}
+
+		c := a.Clone()
+		c.Remove(buffer.Range{Start: 10, End: 20})
+		c.Add(buffer.Range{Start: 25, End: 28})
+		for pos, in := range positions(c, n) {
+			want := pa[pos] && (pos < 10 || pos >= 20) || pos >= 25 && pos < 28
+			if in != want {
+				t.Fatalf("remove and add on %v: position %d in %v", a.Ranges(), pos, in)
+			}
+		}
+	}
+}
+
+// TestTrackRangeSet checks that tracked sets follow edits until untracked
+func TestTrackRangeSet(t *testing.T) {
+	gb := newBuffer(t, "0123456789")
+	s := buffer.NewRangeSet(buffer.Range{Start: 2, End: 4}, buffer.Range{Start: 6, End: 8})
+	gb.TrackRangeSet(s)
+	gb.TrackRangeSet(nil)
+
+	gb.InsertAt(0, "ab")  // both shift
+	gb.InsertAt(5, "x")   // inside the first, which grows
+	gb.InsertAt(11, "yy") // at the end of the second, which does not
+	gb.DeleteAt(6, 4)     // from inside the first to the start of the second: they merge
+	want := []buffer.Range{{Start: 4, End: 7}}
+	if got := s.Ranges(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	gb.UntrackRangeSet(s)
+	gb.InsertAt(0, "zz")
+	if got := s.Ranges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("untracked set moved to %v", got)
+	}
+}
@@ -1,5 +1,7 @@
 package buffer
 
+import "math"
+
 // Color represents the color of a node in the red-black tree
 type Color bool
 
@@ -10,12 +12,16 @@ const (
 
 // Node represents a node in the red-black tree
 type Node struct {
-	Key    int         // Position in the text
-	Value  interface{} // Data stored at this position
+	Key    int     // Position in the text
+	value  payload // Text stored at this position
 	Color  Color
 	Left   *Node
 	Right  *Node
 	Parent *Node
+
+	// Measure of value and totals of the subtree rooted here
+	own measures
+	sum measures
 }
 
 // RBTree represents a red-black tree
@@ -55,18 +61,45 @@ func (t *RBTree) searchTreeHelper(node *Node, key int) *Node {
 	return t.searchTreeHelper(node.Right, key)
 }
 
-// Insert adds a new node with the given key and value to the tree
-func (t *RBTree) Insert(key int, value interface{}) {
+// Insert adds a new node with the given key and the text of chunk to the
+// tree. The key is the position; chunk.Pos is not used.
+func (t *RBTree) Insert(key int, chunk Chunk) {
+	t.add(key, payload{Text: chunk.Text})
+}
+
+// add inserts a new node with the given key and value, measuring the value
+func (t *RBTree) add(key int, value payload) {
 	// Create new node
 	newNode := &Node{
 		Key:    key,
-		Value:  value,
+		value:  value,
 		Color:  Red,
 		Left:   t.Nil,
 		Right:  t.Nil,
 		Parent: t.Nil,
 	}
+	newNode.measure()
+	t.insert(newNode)
+}
 
+// insertMeasured is like add but takes the measure of value instead of
+// computing it, for values whose measure is already known
+func (t *RBTree) insertMeasured(key int, value payload, m measures) {
+	newNode := &Node{
+		Key:    key,
+		value:  value,
+		Color:  Red,
+		Left:   t.Nil,
+		Right:  t.Nil,
+		Parent: t.Nil,
+		own:    m,
+		sum:    m,
+	}
+	t.insert(newNode)
+}
+
+// insert links a measured node into the tree and rebalances it
+func (t *RBTree) insert(newNode *Node) {
 	var y *Node = t.Nil
 	var x *Node = t.Root
 
@@ -90,6 +123,7 @@ func (t *RBTree) Insert(key int, value interface{}) {
 	} else {
 		y.Right = newNode
 	}
+	t.fixup(y)
 
 	// If new node is root, color it black and return
 	if newNode.Parent == t.Nil {
@@ -123,6 +157,8 @@ func (t *RBTree) leftRotate(x *Node) {
 	}
 	y.Left = x
 	x.Parent = y
+	t.pull(x)
+	t.pull(y)
 }
 
 // RightRotate performs a right rotation on the given node
@@ -142,6 +178,8 @@ func (t *RBTree) rightRotate(x *Node) {
 	}
 	y.Right = x
 	x.Parent = y
+	t.pull(x)
+	t.pull(y)
 }
 
 // fixInsert fixes the red-black tree properties after insertion
@@ -244,6 +282,9 @@ func (t *RBTree) deleteNodeHelper(node *Node, key int) {
 		y.Color = z.Color
 	}
 
+	// Every subtree that changed lies on the path from x up
+	t.fixup(x.Parent)
+
 	if originalColor == Black {
 		t.fixDelete(x)
 	}
@@ -331,25 +372,250 @@ func (t *RBTree) fixDelete(x *Node) {
 }
 
 // InOrderTraversal performs an in-order traversal of the tree and applies the given function to each node
-func (t *RBTree) InOrderTraversal(fn func(key int, value interface{})) {
+func (t *RBTree) InOrderTraversal(fn func(key int, chunk Chunk)) {
 	t.inOrderHelper(t.Root, fn)
 }
 
 // inOrderHelper is a helper function for InOrderTraversal
-func (t *RBTree) inOrderHelper(node *Node, fn func(key int, value interface{})) {
+func (t *RBTree) inOrderHelper(node *Node, fn func(key int, chunk Chunk)) {
 	if node != t.Nil {
 		t.inOrderHelper(node.Left, fn)
-		fn(node.Key, node.Value)
+		fn(node.Key, node.Chunk())
 		t.inOrderHelper(node.Right, fn)
 	}
 }
 
-// Update updates the value of a node with the given key
-func (t *RBTree) Update(key int, value interface{}) bool {
+// Update replaces the text of the node with the given key with the text of
+// chunk
+func (t *RBTree) Update(key int, chunk Chunk) bool {
 	node := t.Search(key)
 	if node == nil {
 		return false
 	}
-	node.Value = value
+	t.setValue(node, payload{Text: chunk.Text})
 	return true
 }
+
+// Chunk returns the text the node holds, with its key as position
+func (n *Node) Chunk() Chunk {
+	return Chunk{Text: n.value.Text, Pos: n.Key}
+}
+
+// setValue replaces the value of node and updates the totals above it
+func (t *RBTree) setValue(node *Node, value payload) {
+	node.value = value
+	node.measure()
+	t.fixup(node)
+}
+
+// measure records the measure of the node's value
+func (n *Node) measure() {
+	n.own = n.value.measure()
+	n.sum = n.own
+}
+
+// pull recomputes the totals of node from its children
+func (t *RBTree) pull(node *Node) {
+	node.sum = node.Left.sum.plus(node.own).plus(node.Right.sum)
+}
+
+// fixup recomputes the totals from node up to the root
+func (t *RBTree) fixup(node *Node) {
+	for ; node != t.Nil; node = node.Parent {
+		t.pull(node)
+	}
+}
+
+// Floor returns the node with the largest key less than or equal to key, or nil
+func (t *RBTree) Floor(key int) *Node {
+	var result *Node
+	node := t.Root
+	for node != t.Nil {
+		if node.Key <= key {
+			result = node
+			node = node.Right
+		} else {
+			node = node.Left
+		}
+	}
+	return result
+}
+
+// Ceiling returns the node with the smallest key greater than or equal to key, or nil
+func (t *RBTree) Ceiling(key int) *Node {
+	var result *Node
+	node := t.Root
+	for node != t.Nil {
+		if node.Key >= key {
+			result = node
+			node = node.Left
+		} else {
+			node = node.Right
+		}
+	}
+	return result
+}
+
+// Successor returns the node following node in key order, or nil
+func (t *RBTree) Successor(node *Node) *Node {
+	if node.Right != t.Nil {
+		return t.minimum(node.Right)
+	}
+	parent := node.Parent
+	for parent != t.Nil && node == parent.Right {
+		node = parent
+		parent = parent.Parent
+	}
+	if parent == t.Nil {
+		return nil
+	}
+	return parent
+}
+
+// Predecessor returns the node preceding node in key order, or nil
+func (t *RBTree) Predecessor(node *Node) *Node {
+	if node.Left != t.Nil {
+		node = node.Left
+		for node.Right != t.Nil {
+			node = node.Right
+		}
+		return node
+	}
+	parent := node.Parent
+	for parent != t.Nil && node == parent.Left {
+		node = parent
+		parent = parent.Parent
+	}
+	if parent == t.Nil {
+		return nil
+	}
+	return parent
+}
+
+// Ascend calls fn for every node with a key in [from, to) in key order until fn returns false
+func (t *RBTree) Ascend(from, to int, fn func(node *Node) bool) {
+	for node := t.Ceiling(from); node != nil && node.Key < to; node = t.Successor(node) {
+		if !fn(node) {
+			return
+		}
+	}
+}
+
+// nodesInRange collects the nodes with keys in [from, to) in key order
+func (t *RBTree) nodesInRange(from, to int) []*Node {
+	var nodes []*Node
+	t.Ascend(from, to, func(node *Node) bool {
+		nodes = append(nodes, node)
+		return true
+	})
+	return nodes
+}
+
+// Depth returns the number of nodes on the longest path from the root
+func (t *RBTree) Depth() int {
+	var depth func(node *Node) int
+	depth = func(node *Node) int {
+		if node == t.Nil {
+			return 0
+		}
+		return 1 + max(depth(node.Left), depth(node.Right))
+	}
+	return depth(t.Root)
+}
+
+// rebuilt returns a balanced tree holding the same keys and values
+func (t *RBTree) rebuilt() chunkTree {
+	fresh := NewRBTree()
+	t.Ascend(math.MinInt, math.MaxInt, func(node *Node) bool {
+		fresh.insertMeasured(node.Key, node.value, node.own)
+		return true
+	})
+	return fresh
+}
+
+// forked returns a compact tree holding the same keys and values. Nodes
+// linked to their parents cannot be shared, so the first fork moves the
+// chunks to the layout whose nodes can be, and later forks copy only the
+// nodes an edit changes.
+func (t *RBTree) forked() chunkTree {
+	fresh := newCompactTree()
+	t.Ascend(math.MinInt, math.MaxInt, func(node *Node) bool {
+		fresh.insertMeasured(node.Key, node.value, node.own)
+		return true
+	})
+	return fresh
+}
+
+// countNodes returns the number of nodes in the subtree rooted at n
+func (n *Node) countNodes(nilNode *Node) int {
+	if n == nilNode {
+		return 0
+	}
+	return 1 + n.Left.countNodes(nilNode) + n.Right.countNodes(nilNode)
+}
+
+// count returns the number of nodes
+func (t *RBTree) count() int {
+	return t.Root.countNodes(t.Nil)
+}
+
+// set replaces the value of the node at key, which must exist
+func (t *RBTree) set(key int, value payload) {
+	t.setValue(t.Search(key), value)
+}
+
+// floor returns the key and value of the node Floor finds
+func (t *RBTree) floor(key int) (int, *payload, bool) {
+	node := t.Floor(key)
+	if node == nil {
+		return 0, nil, false
+	}
+	return node.Key, &node.value, true
+}
+
+// ascend calls fn with the nodes with a key in [from, to) in key order
+func (t *RBTree) ascend(from int, to int, fn func(key int, value *payload, own measures) bool) {
+	t.Ascend(from, to, func(node *Node) bool {
+		return fn(node.Key, &node.value, node.own)
+	})
+}
+
+// descend calls fn with the nodes with a key at most from in reverse key
+// order
+func (t *RBTree) descend(from int, fn func(key int, value *payload) bool) {
+	for node := t.Floor(from); node != nil; node = t.Predecessor(node) {
+		if !fn(node.Key, &node.value) {
+			return
+		}
+	}
+}
+
+// shift moves the nodes with a key in [from, to) by delta
+func (t *RBTree) shift(from int, to int, delta int) {
+	for _, node := range t.nodesInRange(from, to) {
+		node.Key += delta
+	}
+}
+
+// total returns the measures of the whole tree
+func (t *RBTree) total() measures {
+	return t.Root.sum
+}
+
+// seek descends from the root to the node in which in first holds
+func (t *RBTree) seek(in func(before measures, m measures) bool) (*payload, measures, measures, bool, bool) {
+	var before measures
+	for node := t.Root; node != t.Nil; {
+		if in(before, node.Left.sum) {
+			node = node.Left
+			continue
+		}
+		before = before.plus(node.Left.sum)
+		if in(before, node.own) {
+			return &node.value, before, node.own, true, true
+		}
+		before = before.plus(node.own)
+		node = node.Right
+	}
+	return nil, before, measures{}, false, false
+}
@@ -0,0 +1,196 @@
+package buffer
+
+import (
+	"errors"
+	"io"
+	"math"
+	"unicode/utf8"
+)
+
+// ErrBufferModified is returned by readers when the buffer was edited while reading
+var ErrBufferModified = errors.New("buffer modified during read")
+
+// SizedReader reads buffer content chunk by chunk without flattening it and
+// knows its size up front, for APIs that need a Content-Length. Editing the
+// buffer invalidates the reader.
+type SizedReader struct {
+	gb      *GapBuffer
+	start   int
+	pos     int
+	end     int
+	version int
+}
+
+// SizedReader returns a reader over the whole buffer content
+func (gb *GapBuffer) SizedReader() *SizedReader {
+	return &SizedReader{gb: gb, end: gb.length, version: gb.version}
+}
+
+// Reader returns a reader over the whole buffer content that walks the
+// chunks as it is read, for saving or hashing large buffers without the
+// copy GetText makes. It is a *SizedReader. Editing the buffer invalidates
+// it.
+func (gb *GapBuffer) Reader() io.Reader {
+	return gb.SizedReader()
+}
+
+// RangeReader returns a reader over [start, end), like Reader
+func (gb *GapBuffer) RangeReader(start int, end int) (r io.Reader, err error) {
+	defer guard("RangeReader", start, gb.length, &err)
+
+	if start < 0 || end > gb.length || start > end {
+		return nil, ErrInvalidRange
+	}
+	return &SizedReader{gb: gb, start: start, pos: start, end: end, version: gb.version}, nil
+}
+
+// ReadAt implements io.ReaderAt, reading the stored bytes from off straight
+// out of the chunks, so code written for random-access files, such as
+// binary parsers or archive/zip with Length as the size, works on the
+// buffer unchanged. A read ending at the end of the buffer returns io.EOF
+// with the bytes read.
+func (gb *GapBuffer) ReadAt(p []byte, off int64) (n int, err error) {
+	defer guard("ReadAt", int(off), gb.length, &err)
+
+	if off < 0 {
+		return 0, ErrPositionOutOfRange
+	}
+	if off >= int64(gb.length) {
+		return 0, io.EOF
+	}
+
+	start := int(off)
+	gb.forEachChunk(start, min(gb.length, start+len(p)), func(_ int, text string) bool {
+		n += copy(p[n:], text)
+		return true
+	})
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// readBlockSize is the size of the blocks ReadFrom appends at a time
+const readBlockSize = 64 << 10
+
+// NewFromReader creates a buffer holding everything r produces. The input
+// is stored chunk by chunk as it is read, so a large file is never held in
+// one string. Loading is not an edit: there is nothing to undo.
+func NewFromReader(r io.Reader) (gb *GapBuffer, err error) {
+	defer guard("NewFromReader", noPos, noPos, &err)
+
+	gb = New()
+	err = readBlocks(r, gb.chunkSize, func(text string) error {
+		gb.rawInsert(gb.length, text)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return gb, nil
+}
+
+// ReadFrom implements io.ReaderFrom: everything r produces is appended to
+// the buffer as one undo step, in blocks edited in as they are read rather
+// than as one giant string. If r fails or an edit is refused, by limits for
+// instance, nothing is appended. It returns the number of bytes read.
+func (gb *GapBuffer) ReadFrom(r io.Reader) (n int64, err error) {
+	defer guard("ReadFrom", noPos, gb.length, &err)
+
+	err = gb.Transaction(func() error {
+		return readBlocks(r, readBlockSize, func(text string) error {
+			n += int64(len(text))
+			return gb.replace(gb.length, gb.length, text)
+		})
+	})
+	return n, err
+}
+
+// readBlocks reads r until io.EOF and calls fn with blocks of up to size
+// bytes. A rune split by a read is held back for the next block, so blocks
+// only end inside a rune at the end of the input.
+func readBlocks(r io.Reader, size int, fn func(string) error) error {
+	buf := make([]byte, max(size, 2*utf8.UTFMax))
+	carry := 0
+	for {
+		n, err := r.Read(buf[carry:])
+		n += carry
+		end := n
+		if err == nil {
+			for start := n - 1; start >= max(n-utf8.UTFMax, 0); start-- {
+				if utf8.RuneStart(buf[start]) {
+					if !utf8.FullRune(buf[start:n]) {
+						end = start
+					}
+					break
+				}
+			}
+		}
+		if end > 0 {
+			if err := fn(string(buf[:end])); err != nil {
+				return err
+			}
+		}
+		carry = copy(buf, buf[end:n])
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Read implements io.Reader
+func (r *SizedReader) Read(p []byte) (n int, err error) {
+	defer guard("Read", r.pos, r.gb.length, &err)
+
+	if r.gb.version != r.version {
+		return 0, ErrBufferModified
+	}
+	if r.pos >= r.end {
+		return 0, io.EOF
+	}
+
+	r.gb.forEachChunk(r.pos, min(r.end, r.pos+len(p)), func(_ int, text string) bool {
+		n += copy(p[n:], text)
+		return true
+	})
+	r.pos += n
+	return n, nil
+}
+
+// Len returns the number of bytes not yet read
+func (r *SizedReader) Len() int {
+	return max(r.end-r.pos, 0)
+}
+
+// Size returns the total number of bytes the reader produces
+func (r *SizedReader) Size() int64 {
+	return int64(r.end - r.start)
+}
+
+// Seek implements io.Seeker
+func (r *SizedReader) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = int64(r.start) + offset
+	case io.SeekCurrent:
+		abs = int64(r.pos) + offset
+	case io.SeekEnd:
+		abs = int64(r.end) + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if abs < int64(r.start) {
+		return 0, errors.New("negative position")
+	}
+	if abs > math.MaxInt {
+		return 0, errors.New("position out of range")
+	}
+
+	// Seeking past the end is allowed; reads there return io.EOF
+	r.pos = int(abs)
+	return abs - int64(r.start), nil
+}
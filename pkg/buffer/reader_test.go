@@ -0,0 +1,192 @@
+package buffer_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestSizedReader writes a buffer into a tar archive, which needs the size
+// up front, and checks Len, Seek and that an edit invalidates the reader
+func TestSizedReader(t *testing.T) {
+	text := strings.Repeat("sized reader ", 1000)
+	gb := buffer.NewWithChunkSize(64)
+	gb.InsertAt(0, text)
+
+	r := gb.SizedReader()
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	if err := tw.WriteHeader(&tar.Header{Name: "doc.txt", Mode: 0o644, Size: r.Size()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(tw, r); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(&archive)
+	if _, err := tr.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := io.ReadAll(tr); string(got) != text {
+		t.Errorf("archived %d bytes, want %d", len(got), len(text))
+	}
+
+	rr, err := gb.RangeReader(13, 13+26)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = rr.(*buffer.SizedReader)
+	p := make([]byte, 5)
+	io.ReadFull(r, p)
+	if string(p) != "sized" || r.Len() != 21 || r.Size() != 26 {
+		t.Errorf("range: read %q, %d left of %d", p, r.Len(), r.Size())
+	}
+	if pos, err := r.Seek(-4, io.SeekEnd); err != nil || pos != 22 {
+		t.Errorf("seek from the end: got %d, %v", pos, err)
+	}
+	if rest, _ := io.ReadAll(r); string(rest) != "der " {
+		t.Errorf("after seeking: read %q", rest)
+	}
+	if _, err := r.Seek(-1, io.SeekStart); err == nil {
+		t.Error("seek before the start: got no error")
+	}
+	if _, err := gb.RangeReader(5, gb.Length()+1); !errors.Is(err, buffer.ErrInvalidRange) {
+		t.Errorf("range past the end: got %v, want ErrInvalidRange", err)
+	}
+
+	r.Seek(0, io.SeekStart)
+	gb.InsertAt(0, "x")
+	if _, err := r.Read(p); !errors.Is(err, buffer.ErrBufferModified) {
+		t.Errorf("read after an edit: got %v, want ErrBufferModified", err)
+	}
+}
+
+// TestReader checks that Reader and RangeReader stream the stored bytes,
+// invalid UTF-8 included, and behave as io.Readers and io.Seekers
+func TestReader(t *testing.T) {
+	text := strings.Repeat("chunk\xff by chunk\n", 50)
+	gb := buffer.NewWithChunkSize(16)
+	gb.InsertAt(0, text)
+	gb.RandomizeLayout(7)
+
+	if err := iotest.TestReader(gb.Reader(), []byte(text)); err != nil {
+		t.Error(err)
+	}
+	rr, err := gb.RangeReader(6, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := iotest.TestReader(rr, []byte(text[6:300])); err != nil {
+		t.Errorf("range: %v", err)
+	}
+	if rr, err := gb.RangeReader(9, 9); err != nil {
+		t.Error(err)
+	} else if got, err := io.ReadAll(rr); err != nil || len(got) != 0 {
+		t.Errorf("empty range: read %q, %v", got, err)
+	}
+	if _, err := gb.RangeReader(4, 3); !errors.Is(err, buffer.ErrInvalidRange) {
+		t.Errorf("reversed range: got %v, want ErrInvalidRange", err)
+	}
+}
+
+// TestNewFromReader checks that runes split between reads are stored whole
+// and that loading leaves nothing to undo
+func TestNewFromReader(t *testing.T) {
+	text := strings.Repeat("r\u00E9ad \u4E2D\U0001F600\n", 300)
+	gb, err := buffer.NewFromReader(iotest.HalfReader(strings.NewReader(text)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gb.GetText() != text || gb.CanUndo() {
+		t.Errorf("loaded %d bytes, undo %v", gb.Length(), gb.CanUndo())
+	}
+	if n := gb.RuneLength(); n != 8*300 {
+		t.Errorf("got %d runes", n)
+	}
+
+	errRead := errors.New("read failed")
+	r := io.MultiReader(strings.NewReader("head"), iotest.ErrReader(errRead))
+	if gb, err := buffer.NewFromReader(r); gb != nil || !errors.Is(err, errRead) {
+		t.Errorf("failing reader: got %v, %v", gb, err)
+	}
+}
+
+// TestReadFrom checks that ReadFrom appends as one undo step and appends
+// nothing when the reader fails or a limit is hit
+func TestReadFrom(t *testing.T) {
+	gb := newBuffer(t, "head\n")
+	text := strings.Repeat("appended line\n", 10000)
+	n, err := gb.ReadFrom(iotest.OneByteReader(strings.NewReader(text[:1000])))
+	if err != nil || n != 1000 {
+		t.Fatalf("read %d bytes, %v", n, err)
+	}
+	if n, err := gb.ReadFrom(strings.NewReader(text[1000:])); err != nil || n != int64(len(text)-1000) {
+		t.Fatalf("read %d bytes, %v", n, err)
+	}
+	if gb.GetText() != "head\n"+text {
+		t.Errorf("got %d bytes", gb.Length())
+	}
+	gb.Undo()
+	gb.Undo()
+	if gb.GetText() != "head\n" {
+		t.Errorf("undo: got %d bytes", gb.Length())
+	}
+
+	errRead := errors.New("read failed")
+	r := io.MultiReader(strings.NewReader(text), iotest.ErrReader(errRead))
+	if _, err := gb.ReadFrom(r); !errors.Is(err, errRead) || gb.GetText() != "head\n" {
+		t.Errorf("failing reader: got %d bytes, %v", gb.Length(), err)
+	}
+	gb.SetLimits(buffer.Limits{MaxSize: 1000})
+	if _, err := gb.ReadFrom(strings.NewReader(text)); !errors.Is(err, buffer.ErrLimitExceeded) || gb.GetText() != "head\n" {
+		t.Errorf("limit: got %d bytes, %v", gb.Length(), err)
+	}
+}
+
+// TestReadAt reads a zip archive held in a buffer through ReadAt and
+// checks reads at and past the end
+func TestReadAt(t *testing.T) {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	w, _ := zw.Create("inner.txt")
+	io.WriteString(w, strings.Repeat("zipped text\n", 100))
+	zw.Close()
+
+	gb := buffer.NewWithChunkSize(64)
+	gb.InsertAt(0, archive.String())
+	gb.RandomizeLayout(4)
+	zr, err := zip.NewReader(gb, int64(gb.Length()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := zr.Open("inner.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := io.ReadAll(f); err != nil || string(got) != strings.Repeat("zipped text\n", 100) {
+		t.Errorf("read %d bytes, %v", len(got), err)
+	}
+
+	if err := iotest.TestReader(io.NewSectionReader(gb, 0, int64(gb.Length())), archive.Bytes()); err != nil {
+		t.Error(err)
+	}
+	p := make([]byte, 8)
+	if n, err := gb.ReadAt(p, int64(gb.Length()-3)); n != 3 || err != io.EOF {
+		t.Errorf("read ending at the end: got %d, %v", n, err)
+	}
+	if n, err := gb.ReadAt(p, int64(gb.Length())); n != 0 || err != io.EOF {
+		t.Errorf("read at the end: got %d, %v", n, err)
+	}
+	if _, err := gb.ReadAt(p, -1); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("negative offset: got %v", err)
+	}
+}
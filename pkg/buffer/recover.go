@@ -0,0 +1,226 @@
+package buffer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSwap is returned when a swap file cannot be recovered
+var ErrInvalidSwap = errors.New("invalid swap file")
+
+// VimSwapName returns the name Vim gives the swap file of path when it is
+// kept next to the file, e.g. ".notes.txt.swp"
+func VimSwapName(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".swp")
+}
+
+// EmacsAutosaveName returns the name Emacs gives the auto-save file of
+// path, e.g. "#notes.txt#"
+func EmacsAutosaveName(path string) string {
+	return filepath.Join(filepath.Dir(path), "#"+filepath.Base(path)+"#")
+}
+
+// RecoverEmacsAutosave loads the text of an Emacs auto-save file, which is
+// a plain copy of the buffer content
+func RecoverEmacsAutosave(r io.Reader) (gb *GapBuffer, err error) {
+	defer guard("RecoverEmacsAutosave", noPos, noPos, &err)
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	gb = New()
+	gb.rawInsert(0, string(data))
+	return gb, nil
+}
+
+// VimSwapInfo describes the editing session that left a Vim swap file
+type VimSwapInfo struct {
+	Version  string    // Vim version that wrote the file, e.g. "VIM 9.0"
+	FileName string    // Name of the edited file, possibly starting with "~/"
+	User     string    // User running Vim
+	Host     string    // Host Vim ran on
+	PID      int       // Process id of Vim
+	ModTime  time.Time // Modification time of the edited file when it was loaded
+	Modified bool      // Whether the session had unsaved changes
+}
+
+// Layout of Vim's block 0 (struct block0 in memline.c)
+const (
+	vimPageSizeOff = 12
+	vimMTimeOff    = 16
+	vimPIDOff      = 24
+	vimUserOff     = 28
+	vimHostOff     = 68
+	vimNameOff     = 108
+	vimNameSize    = 900
+	vimMagicOff    = vimNameOff + vimNameSize
+
+	vimMagicLong = 0x30313233
+	vimMagicInt  = 0x20212223
+	vimDirty     = 0x55 // last byte of the name field when modified
+	vimFFMask    = 3    // file format + 1 in the flags byte before it
+	vimDBMarked  = 0x80000000
+)
+
+// vimSwap decodes the blocks of a swap file. Blocks other than block 0 are
+// written in the native layout of the machine running Vim, detected from
+// the magic numbers of block 0.
+type vimSwap struct {
+	data     []byte
+	pageSize int
+	order    binary.ByteOrder
+	longSize int // 4 or 8
+	lines    []string
+}
+
+// RecoverVimSwap recovers the text of a Vim swap file, the way "vim -r"
+// does, along with information about the session that wrote it. Lines are
+// joined with the line endings of the file format Vim was using, and the
+// last line always gets one. Encrypted swap files are not supported.
+func RecoverVimSwap(r io.Reader) (gb *GapBuffer, info VimSwapInfo, err error) {
+	defer guard("RecoverVimSwap", noPos, noPos, &err)
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, VimSwapInfo{}, err
+	}
+	if len(data) < vimMagicOff+16 || data[0] != 'b' {
+		return nil, VimSwapInfo{}, ErrInvalidSwap
+	}
+	if data[1] != '0' {
+		return nil, VimSwapInfo{}, fmt.Errorf("%w: encrypted", ErrInvalidSwap)
+	}
+
+	s := &vimSwap{data: data, pageSize: int(binary.LittleEndian.Uint32(data[vimPageSizeOff:]))}
+	switch {
+	case binary.LittleEndian.Uint32(data[vimMagicOff:]) == vimMagicLong:
+		s.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(data[vimMagicOff:]) == vimMagicLong:
+		s.order = binary.BigEndian
+	case binary.BigEndian.Uint32(data[vimMagicOff+4:]) == vimMagicLong:
+		s.order, s.longSize = binary.BigEndian, 8
+	default:
+		return nil, VimSwapInfo{}, fmt.Errorf("%w: bad magic", ErrInvalidSwap)
+	}
+	if s.longSize == 0 {
+		s.longSize = 4
+		if s.order.Uint32(data[vimMagicOff+8:]) == vimMagicInt {
+			s.longSize = 8
+		}
+	}
+	if s.pageSize < 512 || len(data) < 2*s.pageSize {
+		return nil, VimSwapInfo{}, fmt.Errorf("%w: bad page size", ErrInvalidSwap)
+	}
+
+	name := data[vimNameOff : vimNameOff+vimNameSize]
+	info = VimSwapInfo{
+		Version:  cString(data[2:vimPageSizeOff]),
+		FileName: cString(name),
+		User:     cString(data[vimUserOff:vimHostOff]),
+		Host:     cString(data[vimHostOff:vimNameOff]),
+		PID:      int(binary.LittleEndian.Uint32(data[vimPIDOff:])),
+		ModTime:  time.Unix(int64(binary.LittleEndian.Uint32(data[vimMTimeOff:])), 0),
+		Modified: name[vimNameSize-1] == vimDirty,
+	}
+
+	// Block 1 is the root of the tree of pointer and data blocks
+	if err := s.readBlock(1, 1, 0); err != nil {
+		return nil, info, err
+	}
+
+	eol := "\n"
+	switch int(name[vimNameSize-2]&vimFFMask) - 1 {
+	case 1:
+		eol = "\r\n"
+	case 2:
+		eol = "\r"
+	}
+
+	gb = New()
+	var sb strings.Builder
+	for _, line := range s.lines {
+		sb.WriteString(line)
+		sb.WriteString(eol)
+	}
+	gb.rawInsert(0, sb.String())
+	return gb, info, nil
+}
+
+// cString returns the NUL-terminated string at the start of b
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// long reads a C long at off
+func (s *vimSwap) long(b []byte, off int) int {
+	if s.longSize == 8 {
+		return int(s.order.Uint64(b[off:]))
+	}
+	return int(int32(s.order.Uint32(b[off:])))
+}
+
+// readBlock appends the lines of the block at bnum, spanning pages pages,
+// and of the blocks below it
+func (s *vimSwap) readBlock(bnum int, pages int, depth int) error {
+	if depth > 100 || bnum <= 0 || pages <= 0 || bnum > len(s.data)/s.pageSize ||
+		pages > len(s.data)/s.pageSize-bnum {
+		return fmt.Errorf("%w: bad block %d", ErrInvalidSwap, bnum)
+	}
+	block := s.data[bnum*s.pageSize : (bnum+pages)*s.pageSize]
+
+	switch string(block[:2]) {
+	case "tp", "pt":
+		// Pointer block: pb_id, pb_count, pb_count_max, then entries of
+		// pe_bnum, pe_line_count, pe_old_lnum and pe_page_count
+		count := int(s.order.Uint16(block[2:]))
+		entry := 3*s.longSize + 4
+		entry = (entry + s.longSize - 1) / s.longSize * s.longSize
+		off := 8
+		if off+count*entry > len(block) {
+			return fmt.Errorf("%w: bad pointer block %d", ErrInvalidSwap, bnum)
+		}
+		for i := 0; i < count; i++ {
+			e := off + i*entry
+			child := s.long(block, e)
+			childPages := int(int32(s.order.Uint32(block[e+3*s.longSize:])))
+			if err := s.readBlock(child, childPages, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "ad", "da":
+		// Data block: db_id, db_free, db_txt_start, db_txt_end,
+		// db_line_count, then the start of every line. Lines are stored
+		// backwards from the end of the block, NUL-terminated.
+		txtEnd := int(s.order.Uint32(block[12:]))
+		count := s.long(block, 16)
+		index := 16 + s.longSize
+		if txtEnd > len(block) || count < 0 || index+4*count > txtEnd {
+			return fmt.Errorf("%w: bad data block %d", ErrInvalidSwap, bnum)
+		}
+		end := txtEnd
+		for i := 0; i < count; i++ {
+			start := int(s.order.Uint32(block[index+4*i:]) &^ vimDBMarked)
+			if start < index+4*count || start > end {
+				return fmt.Errorf("%w: bad data block %d", ErrInvalidSwap, bnum)
+			}
+			line := cString(block[start:end])
+			// Vim keeps NUL characters as newlines in memory
+			s.lines = append(s.lines, strings.ReplaceAll(line, "\n", "\x00"))
+			end = start
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unknown block %d", ErrInvalidSwap, bnum)
+}
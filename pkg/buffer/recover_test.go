@@ -0,0 +1,119 @@
+package buffer_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// vimSwap builds the swap file Vim on a little-endian 64-bit machine
+// writes for lines in file format ff (0 unix, 1 dos, 2 mac): block 0,
+// a pointer block and one data block, of 4096 bytes each
+func vimSwap(lines []string, ff byte) []byte {
+	const page = 4096
+	le := binary.LittleEndian
+	data := make([]byte, 3*page)
+
+	b0 := data[:page]
+	copy(b0, "b0VIM 9.0")
+	le.PutUint32(b0[12:], page)
+	le.PutUint32(b0[16:], 1700000000)
+	le.PutUint32(b0[24:], 4242)
+	copy(b0[28:], "alice")
+	copy(b0[68:], "devbox")
+	copy(b0[108:], "~/notes.txt")
+	b0[108+898] = ff + 1
+	b0[108+899] = 0x55
+	le.PutUint64(b0[1008:], 0x30313233)
+	le.PutUint32(b0[1016:], 0x20212223)
+
+	pb := data[page : 2*page]
+	copy(pb, "tp")
+	le.PutUint16(pb[2:], 1)
+	le.PutUint64(pb[8:], 2)
+	le.PutUint64(pb[16:], uint64(len(lines)))
+	le.PutUint32(pb[32:], 1)
+
+	db := data[2*page:]
+	copy(db, "da")
+	le.PutUint32(db[12:], page)
+	le.PutUint64(db[16:], uint64(len(lines)))
+	end := page
+	for i, line := range lines {
+		start := end - len(line) - 1
+		copy(db[start:], line)
+		le.PutUint32(db[24+4*i:], uint32(start))
+		end = start
+	}
+	return data
+}
+
+// TestRecoverVimSwap checks the text and session recovered from a swap
+// file, in each file format
+func TestRecoverVimSwap(t *testing.T) {
+	lines := []string{"first line", "", "nul\nin line"}
+	for ff, eol := range []string{"\n", "\r\n", "\r"} {
+		gb, info, err := buffer.RecoverVimSwap(bytes.NewReader(vimSwap(lines, byte(ff))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "first line" + eol + eol + "nul\x00in line" + eol
+		if gb.GetText() != want {
+			t.Errorf("format %d: got %q, want %q", ff, gb.GetText(), want)
+		}
+		wantInfo := buffer.VimSwapInfo{
+			Version:  "VIM 9.0",
+			FileName: "~/notes.txt",
+			User:     "alice",
+			Host:     "devbox",
+			PID:      4242,
+			ModTime:  time.Unix(1700000000, 0),
+			Modified: true,
+		}
+		if info != wantInfo {
+			t.Errorf("format %d: got %+v, want %+v", ff, info, wantInfo)
+		}
+	}
+}
+
+// TestRecoverVimSwapInvalid checks that damaged swap files are refused
+// with ErrInvalidSwap
+func TestRecoverVimSwapInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		damage func(data []byte) []byte
+	}{
+		{"truncated", func(data []byte) []byte { return data[:1000] }},
+		{"encrypted", func(data []byte) []byte { data[1] = 'c'; return data }},
+		{"bad magic", func(data []byte) []byte { data[1008] = 0; return data }},
+		{"missing block", func(data []byte) []byte { data[4096+8] = 9; return data }},
+		{"unknown block", func(data []byte) []byte { copy(data[2*4096:], "xx"); return data }},
+		{"bad line start", func(data []byte) []byte { data[2*4096+24+1] = 0xff; return data }},
+	}
+	for _, tt := range tests {
+		data := tt.damage(vimSwap([]string{"text"}, 0))
+		if _, _, err := buffer.RecoverVimSwap(bytes.NewReader(data)); !errors.Is(err, buffer.ErrInvalidSwap) {
+			t.Errorf("%s: got %v, want ErrInvalidSwap", tt.name, err)
+		}
+	}
+}
+
+// TestRecoverEmacsAutosave checks the file names and that an auto-save
+// file is taken as it is
+func TestRecoverEmacsAutosave(t *testing.T) {
+	if got := buffer.EmacsAutosaveName("/home/a/notes.txt"); got != "/home/a/#notes.txt#" {
+		t.Errorf("auto-save name %q", got)
+	}
+	if got := buffer.VimSwapName("/home/a/notes.txt"); got != "/home/a/.notes.txt.swp" {
+		t.Errorf("swap name %q", got)
+	}
+	gb, err := buffer.RecoverEmacsAutosave(strings.NewReader("draft\r\nline"))
+	if err != nil || gb.GetText() != "draft\r\nline" || gb.CanUndo() {
+		t.Errorf("got %q, %v", gb.GetText(), err)
+	}
+}
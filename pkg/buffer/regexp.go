@@ -0,0 +1,204 @@
+package buffer
+
+import (
+	"io"
+	"reflect"
+	"regexp"
+	"unicode/utf8"
+)
+
+// FindRegexp returns the leftmost match of re starting at or after start.
+// The text is fed to re chunk by chunk, so it is never flattened. The rune
+// before start still counts for ^, \b and other assertions, as if re was
+// run over the whole text.
+func (gb *GapBuffer) FindRegexp(re *regexp.Regexp, start int) (match Range, found bool, err error) {
+	defer guard("FindRegexp", start, gb.length, &err)
+
+	if start < 0 || start > gb.length {
+		return Range{}, false, ErrPositionOutOfRange
+	}
+	loc := gb.findRegexp(re, gb.contextRegexp(re), start)
+	if loc == nil {
+		return Range{}, false, nil
+	}
+	return Range{Start: loc[0], End: loc[1]}, true, nil
+}
+
+// FindAllRegexp returns the successive non-overlapping matches of re
+// starting at or after start, at most n of them if n >= 0. Like regexp's
+// FindAll, an empty match right after the previous match is ignored.
+func (gb *GapBuffer) FindAllRegexp(re *regexp.Regexp, start int, n int) (matches []Range, err error) {
+	defer guard("FindAllRegexp", start, gb.length, &err)
+
+	if start < 0 || start > gb.length {
+		return nil, ErrPositionOutOfRange
+	}
+
+	gb.eachRegexpMatch(re, start, func(loc []int) bool {
+		matches = append(matches, Range{Start: loc[0], End: loc[1]})
+		return n < 0 || len(matches) < n
+	})
+	return matches, nil
+}
+
+// ReplaceAllRegexp replaces every match of re with template, in which $1 or
+// ${name} stand for the text of the submatches as in regexp.Expand. All
+// replacements are applied as one edit set, so markers follow them and
+// they are undone as one step. It returns the number of replacements.
+func (gb *GapBuffer) ReplaceAllRegexp(re *regexp.Regexp, template string) (count int, err error) {
+	defer guard("ReplaceAllRegexp", noPos, gb.length, &err)
+
+	var edits []Edit
+	gb.eachRegexpMatch(re, 0, func(loc []int) bool {
+		// Expand against the text of the match only, with offsets into it
+		src := gb.rawText(loc[0], loc[1])
+		rel := make([]int, len(loc))
+		for i, p := range loc {
+			rel[i] = -1
+			if p >= 0 {
+				rel[i] = p - loc[0]
+			}
+		}
+		text := re.ExpandString(nil, template, src, rel)
+		edits = append(edits, Edit{Start: loc[0], End: loc[1], Text: string(text)})
+		return true
+	})
+	if len(edits) == 0 {
+		return 0, nil
+	}
+	if _, err := gb.ApplyEdits(edits, OverlapError); err != nil {
+		return 0, err
+	}
+	return len(edits), nil
+}
+
+// eachRegexpMatch calls fn with the submatch positions of the successive
+// non-overlapping matches of re from start on until fn returns false.
+// Like regexp's FindAll, an empty match right after the previous match is
+// ignored.
+func (gb *GapBuffer) eachRegexpMatch(re *regexp.Regexp, start int, fn func(loc []int) bool) {
+	withContext := gb.contextRegexp(re)
+	prevEnd := -1
+	for pos := start; pos <= gb.length; {
+		loc := gb.findRegexp(re, withContext, pos)
+		if loc == nil {
+			return
+		}
+		if loc[0] == loc[1] && loc[0] == prevEnd {
+			// Step over the rune after an empty match the previous one ends at
+			if loc[0] == gb.length {
+				return
+			}
+			_, size := gb.runeAfter(loc[0])
+			pos = loc[0] + size
+			continue
+		}
+		if !fn(loc) {
+			return
+		}
+		prevEnd = loc[1]
+		pos = loc[1]
+		if loc[0] == loc[1] {
+			if pos == gb.length {
+				return
+			}
+			_, size := gb.runeAfter(pos)
+			pos += size
+		}
+	}
+}
+
+// regexpCache keeps the context regexp of the last regexp searched for, so
+// that repeated searches compile it once
+type regexpCache struct {
+	re          *regexp.Regexp
+	withContext *regexp.Regexp
+}
+
+// contextRegexp returns a regexp matching any rune followed by a match of
+// re, captured as group 1. Run from the rune before the search start, it
+// finds the matches of re at or after the start with that rune as context.
+// It prefers the longest match if re does, as after CompilePOSIX.
+func (gb *GapBuffer) contextRegexp(re *regexp.Regexp) *regexp.Regexp {
+	if gb.regexps.re != re {
+		withContext := regexp.MustCompile(`(?s:.)(` + re.String() + `)`)
+		if longestRegexp(re) {
+			withContext.Longest()
+		}
+		gb.regexps = regexpCache{re: re, withContext: withContext}
+	}
+	return gb.regexps.withContext
+}
+
+// longestRegexp reports whether re prefers the longest match. The regexp
+// package does not export the setting, so it is read from the struct.
+func longestRegexp(re *regexp.Regexp) bool {
+	f := reflect.ValueOf(re).Elem().FieldByName("longest")
+	return f.IsValid() && f.Kind() == reflect.Bool && f.Bool()
+}
+
+// findRegexp returns the submatch positions of the first match of re from
+// start on, or nil. It runs re itself from the start of the text, or
+// withContext from the rune before start.
+func (gb *GapBuffer) findRegexp(re *regexp.Regexp, withContext *regexp.Regexp, start int) []int {
+	if start == 0 {
+		return re.FindReaderSubmatchIndex(&runeReader{gb: gb, end: gb.length})
+	}
+
+	_, size := gb.runeBefore(start)
+	from := start - size
+	loc := withContext.FindReaderSubmatchIndex(&runeReader{gb: gb, pos: from, end: gb.length})
+	if loc == nil {
+		return nil
+	}
+	loc = loc[2:]
+	for i, p := range loc {
+		if p >= 0 {
+			loc[i] = from + p
+		}
+	}
+	return loc
+}
+
+// runeReader decodes the text in [pos, end), one chunk piece at a time.
+// Invalid bytes are read as utf8.RuneError of size 1, as regexp does for
+// strings, so match offsets are the same.
+type runeReader struct {
+	gb   *GapBuffer
+	pos  int
+	end  int
+	text string // rest of the current chunk piece, starting at pos
+}
+
+// ReadRune implements io.RuneReader
+func (r *runeReader) ReadRune() (rune, int, error) {
+	if len(r.text) == 0 {
+		if r.pos >= r.end {
+			return 0, 0, io.EOF
+		}
+		r.gb.forEachChunk(r.pos, r.end, func(_ int, text string) bool {
+			r.text = text
+			return false
+		})
+	}
+
+	if !utf8.FullRuneInString(r.text) && r.pos+len(r.text) < r.end {
+		// The rune continues in the next chunk
+		c, size := utf8.DecodeRuneInString(r.gb.rawText(r.pos, min(r.pos+utf8.UTFMax, r.end)))
+		r.advance(size)
+		return c, size, nil
+	}
+	c, size := utf8.DecodeRuneInString(r.text)
+	r.advance(size)
+	return c, size, nil
+}
+
+// advance moves past size bytes, which may reach into the next chunk piece
+func (r *runeReader) advance(size int) {
+	r.pos += size
+	if size <= len(r.text) {
+		r.text = r.text[size:]
+	} else {
+		r.text = ""
+	}
+}
@@ -0,0 +1,102 @@
+package buffer_test
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestFindAllRegexp checks that matching chunk by chunk finds what regexp
+// finds in the whole text, on several layouts
+func TestFindAllRegexp(t *testing.T) {
+	text := "line one\nÉtés été\xff été\nlast line x\n\nxx"
+	patterns := []string{`\bl\w*`, `é+`, `(?m)^l.*$`, `x*`, `[^\n]+`, `é\x{fffd} `, `(?s).`}
+
+	gb := buffer.NewWithChunkSize(4)
+	gb.InsertAt(0, text)
+	for seed := int64(0); seed < 5; seed++ {
+		gb.RandomizeLayout(seed)
+		for _, p := range patterns {
+			re := regexp.MustCompile(p)
+			var want []buffer.Range
+			for _, loc := range re.FindAllStringIndex(text, -1) {
+				want = append(want, buffer.Range{Start: loc[0], End: loc[1]})
+			}
+			got, err := gb.FindAllRegexp(re, 0, -1)
+			if err != nil || !reflect.DeepEqual(got, want) {
+				t.Fatalf("layout %d, %q: got %v, %v, want %v", seed, p, got, err, want)
+			}
+			if got, _ := gb.FindAllRegexp(re, 0, 2); len(want) > 2 && !reflect.DeepEqual(got, want[:2]) {
+				t.Errorf("layout %d, %q: got %v for 2 matches", seed, p, got)
+			}
+		}
+	}
+}
+
+// TestFindRegexp checks that the text before the start counts for
+// assertions, that POSIX regexps keep the longest match, and the errors
+func TestFindRegexp(t *testing.T) {
+	gb := newBuffer(t, "xfoo foo\nbar")
+	tests := []struct {
+		re    *regexp.Regexp
+		start int
+		want  buffer.Range
+		found bool
+	}{
+		{regexp.MustCompile(`\bfoo`), 1, buffer.Range{Start: 5, End: 8}, true},
+		{regexp.MustCompile(`foo`), 1, buffer.Range{Start: 1, End: 4}, true},
+		{regexp.MustCompile(`(?m)^\w+`), 2, buffer.Range{Start: 9, End: 12}, true},
+		{regexp.MustCompile(`^bar`), 9, buffer.Range{}, false},
+		{regexp.MustCompile(`fo|foo`), 5, buffer.Range{Start: 5, End: 7}, true},
+		{regexp.MustCompilePOSIX(`fo|foo`), 5, buffer.Range{Start: 5, End: 8}, true},
+		{regexp.MustCompile(`$`), 12, buffer.Range{Start: 12, End: 12}, true},
+	}
+	for _, tt := range tests {
+		got, found, err := gb.FindRegexp(tt.re, tt.start)
+		if err != nil || found != tt.found || got != tt.want {
+			t.Errorf("%q from %d: got %v, %v, %v", tt.re, tt.start, got, found, err)
+		}
+	}
+
+	if _, _, err := gb.FindRegexp(regexp.MustCompile(`x`), 13); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("start past the end: %v", err)
+	}
+	if _, err := gb.FindAllRegexp(regexp.MustCompile(`x`), -1, -1); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("negative start: %v", err)
+	}
+}
+
+// TestReplaceAllRegexp checks templates with numbered and named groups,
+// empty matches, markers and that the replacements are undone as one step
+func TestReplaceAllRegexp(t *testing.T) {
+	tests := []struct {
+		text, pattern, template, want string
+		count                         int
+	}{
+		{"a=1, b=22", `(\w)=(\d+)`, "$2:$1", "1:a, 22:b", 2},
+		{"key: value", `(?P<k>\w+): (?P<v>\w+)`, "${v}-${k}", "value-key", 1},
+		{"abc", `x*`, "-", "-a-b-c-", 4},
+		{"line\nline", `(?m)^`, "> ", "> line\n> line", 2},
+		{"none", `x+`, "y", "none", 0},
+	}
+	for _, tt := range tests {
+		gb := newBuffer(t, tt.text)
+		count, err := gb.ReplaceAllRegexp(regexp.MustCompile(tt.pattern), tt.template)
+		if err != nil || count != tt.count || gb.GetText() != tt.want {
+			t.Errorf("%q in %q: got %q, %d, %v, want %q, %d", tt.pattern, tt.text, gb.GetText(), count, err, tt.want, tt.count)
+		}
+	}
+
+	gb := newBuffer(t, "foo bar foo")
+	m, _ := gb.CreateMarker(8, buffer.GravityLeft)
+	gb.ReplaceAllRegexp(regexp.MustCompile(`foo`), "quux")
+	if gb.GetText() != "quux bar quux" || m.Pos() != 9 {
+		t.Errorf("got %q, marker at %d", gb.GetText(), m.Pos())
+	}
+	if err := gb.Undo(); err != nil || gb.GetText() != "foo bar foo" {
+		t.Errorf("undo: got %q, %v", gb.GetText(), err)
+	}
+}
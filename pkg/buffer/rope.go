@@ -0,0 +1,256 @@
+package buffer
+
+// DEFAULT_ROPE_LEAF_SIZE is the largest amount of text stored in one rope leaf
+const DEFAULT_ROPE_LEAF_SIZE = 1024
+
+// ropeNode is an immutable node of a rope: a leaf holding text, or an inner
+// node concatenating two subtrees. Nodes are never modified once built, so
+// any number of ropes can share them.
+type ropeNode struct {
+	left   *ropeNode
+	right  *ropeNode
+	text   string // only set on leaves
+	length int
+	height int
+}
+
+// Rope is a persistent, height-balanced rope. Edits build new nodes along
+// one path and share everything else, so Clone is O(1) and every clone is
+// an independent snapshot. It suits workloads that keep many versions.
+type Rope struct {
+	root *ropeNode
+}
+
+// NewRope creates an empty rope
+func NewRope() *Rope {
+	return &Rope{}
+}
+
+// NewRopeFromString creates a rope holding text
+func NewRopeFromString(text string) *Rope {
+	return &Rope{root: ropeFromString(text)}
+}
+
+// Clone returns an independent copy of the rope in constant time
+func (r *Rope) Clone() *Rope {
+	return &Rope{root: r.root}
+}
+
+// Length returns the length of the text in bytes
+func (r *Rope) Length() int {
+	return r.root.len()
+}
+
+// InsertAt inserts text at the specified position
+func (r *Rope) InsertAt(pos int, text string) (err error) {
+	defer guard("InsertAt", pos, r.Length(), &err)
+
+	if pos < 0 || pos > r.Length() {
+		return ErrPositionOutOfRange
+	}
+	left, right := ropeSplit(r.root, pos)
+	r.root = ropeJoin(ropeJoin(left, ropeFromString(text)), right)
+	return nil
+}
+
+// DeleteAt deletes text at the specified position
+func (r *Rope) DeleteAt(pos int, count int) (err error) {
+	defer guard("DeleteAt", pos, r.Length(), &err)
+
+	if pos == r.Length() && count > 0 {
+		return ErrDeleteAtEnd
+	}
+	if pos < 0 || count < 0 || pos > r.Length() || count > r.Length()-pos {
+		return ErrInvalidRange
+	}
+	return r.Replace(pos, pos+count, "")
+}
+
+// Replace replaces the text in the specified range
+func (r *Rope) Replace(start int, end int, text string) (err error) {
+	defer guard("Replace", start, r.Length(), &err)
+
+	if start < 0 || end > r.Length() || start > end {
+		return ErrInvalidRange
+	}
+	left, rest := ropeSplit(r.root, start)
+	_, right := ropeSplit(rest, end-start)
+	r.root = ropeJoin(ropeJoin(left, ropeFromString(text)), right)
+	return nil
+}
+
+// GetText returns the text in the rope
+func (r *Rope) GetText() string {
+	result := make([]byte, 0, r.Length())
+	r.root.each(0, r.Length(), func(text string) {
+		result = append(result, text...)
+	})
+	return EnsureValidUTF8(string(result))
+}
+
+// GetTextRange returns the text in the specified range
+func (r *Rope) GetTextRange(start int, end int) (text string, err error) {
+	defer guard("GetTextRange", start, r.Length(), &err)
+
+	if start < 0 || end > r.Length() || start > end {
+		return "", ErrInvalidRange
+	}
+	return EnsureValidUTF8(r.text(start, end)), nil
+}
+
+// text returns the bytes in [start, end) as they are stored
+func (r *Rope) text(start int, end int) string {
+	result := make([]byte, 0, end-start)
+	r.root.each(start, end, func(text string) {
+		result = append(result, text...)
+	})
+	return string(result)
+}
+
+// len returns the length of a possibly nil node
+func (n *ropeNode) len() int {
+	if n == nil {
+		return 0
+	}
+	return n.length
+}
+
+// ht returns the height of a possibly nil node
+func (n *ropeNode) ht() int {
+	if n == nil {
+		return -1
+	}
+	return n.height
+}
+
+// isLeaf reports whether the node holds text directly
+func (n *ropeNode) isLeaf() bool {
+	return n.left == nil && n.right == nil
+}
+
+// each calls fn with the pieces of text overlapping [start, end) in order
+func (n *ropeNode) each(start int, end int, fn func(text string)) {
+	if n == nil || start >= end {
+		return
+	}
+	if n.isLeaf() {
+		fn(n.text[start:end])
+		return
+	}
+
+	leftLen := n.left.len()
+	if start < leftLen {
+		n.left.each(start, min(end, leftLen), fn)
+	}
+	if end > leftLen {
+		n.right.each(max(start-leftLen, 0), end-leftLen, fn)
+	}
+}
+
+// ropeLeaf creates a leaf node
+func ropeLeaf(text string) *ropeNode {
+	if text == "" {
+		return nil
+	}
+	return &ropeNode{text: text, length: len(text)}
+}
+
+// ropeConcat creates an inner node without rebalancing
+func ropeConcat(left, right *ropeNode) *ropeNode {
+	return &ropeNode{
+		left:   left,
+		right:  right,
+		length: left.len() + right.len(),
+		height: max(left.ht(), right.ht()) + 1,
+	}
+}
+
+// ropeFromString builds a balanced rope from text split into leaves
+func ropeFromString(text string) *ropeNode {
+	if len(text) <= DEFAULT_ROPE_LEAF_SIZE {
+		return ropeLeaf(text)
+	}
+
+	// Split in the middle without breaking a UTF-8 sequence when possible
+	mid := chunkEnd(text, 0, len(text)/2)
+	return ropeConcat(ropeFromString(text[:mid]), ropeFromString(text[mid:]))
+}
+
+// ropeBalance restores the height invariant of a node whose children differ by at most two
+func ropeBalance(n *ropeNode) *ropeNode {
+	switch {
+	case n.left.ht() > n.right.ht()+1:
+		left := n.left
+		if left.right.ht() > left.left.ht() {
+			left = ropeRotateLeft(left)
+		}
+		return ropeRotateRight(ropeConcat(left, n.right))
+	case n.right.ht() > n.left.ht()+1:
+		right := n.right
+		if right.left.ht() > right.right.ht() {
+			right = ropeRotateRight(right)
+		}
+		return ropeRotateLeft(ropeConcat(n.left, right))
+	default:
+		return n
+	}
+}
+
+// ropeRotateLeft returns a copy of n rotated to the left
+func ropeRotateLeft(n *ropeNode) *ropeNode {
+	r := n.right
+	return ropeConcat(ropeConcat(n.left, r.left), r.right)
+}
+
+// ropeRotateRight returns a copy of n rotated to the right
+func ropeRotateRight(n *ropeNode) *ropeNode {
+	l := n.left
+	return ropeConcat(l.left, ropeConcat(l.right, n.right))
+}
+
+// ropeJoin concatenates two ropes, keeping the result balanced.
+// Small adjacent leaves are merged so that edits do not fragment the rope.
+func ropeJoin(a, b *ropeNode) *ropeNode {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if a.isLeaf() && b.isLeaf() && a.length+b.length <= DEFAULT_ROPE_LEAF_SIZE {
+		return ropeLeaf(a.text + b.text)
+	}
+
+	switch {
+	case a.ht() > b.ht()+1:
+		return ropeBalance(ropeConcat(a.left, ropeJoin(a.right, b)))
+	case b.ht() > a.ht()+1:
+		return ropeBalance(ropeConcat(ropeJoin(a, b.left), b.right))
+	default:
+		return ropeConcat(a, b)
+	}
+}
+
+// ropeSplit splits a rope into the text before pos and the text from pos on
+func ropeSplit(n *ropeNode, pos int) (*ropeNode, *ropeNode) {
+	if n == nil {
+		return nil, nil
+	}
+	if pos <= 0 {
+		return nil, n
+	}
+	if pos >= n.length {
+		return n, nil
+	}
+	if n.isLeaf() {
+		return ropeLeaf(n.text[:pos]), ropeLeaf(n.text[pos:])
+	}
+
+	leftLen := n.left.len()
+	if pos <= leftLen {
+		l, r := ropeSplit(n.left, pos)
+		return l, ropeJoin(r, n.right)
+	}
+	l, r := ropeSplit(n.right, pos-leftLen)
+	return ropeJoin(n.left, l), r
+}
@@ -0,0 +1,40 @@
+package buffer_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestRopeClone checks that a rope and its clones never see each other's
+// edits, however long they are and however they are edited
+func TestRopeClone(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	r := buffer.NewRopeFromString(strings.Repeat("rope text ", 2000))
+	var clones []*buffer.Rope
+	var texts []string
+	for i := 0; i < 500; i++ {
+		if i%50 == 0 {
+			clones = append(clones, r.Clone())
+			texts = append(texts, r.GetText())
+		}
+		start := rng.Intn(r.Length() + 1)
+		end := start + rng.Intn(min(300, r.Length()-start)+1)
+		if err := r.Replace(start, end, strings.Repeat("x", rng.Intn(200))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, c := range clones {
+		if c.GetText() != texts[i] {
+			t.Fatalf("clone %d changed", i)
+		}
+		if err := c.InsertAt(0, "clone"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if strings.HasPrefix(r.GetText(), "clone") {
+		t.Error("editing a clone changed the rope")
+	}
+}
@@ -0,0 +1,173 @@
+package buffer
+
+import (
+	"bufio"
+	"io"
+	"unicode/utf8"
+)
+
+// RuneIterator walks the runes of a buffer in both directions, decoding
+// them straight from the chunks. It implements io.RuneScanner, so regexp
+// and parsers can read the buffer without copying it. Invalid bytes are
+// read as utf8.RuneError of size 1, as when decoding a string. Editing the
+// buffer invalidates the iterator.
+type RuneIterator struct {
+	gb      *GapBuffer
+	pos     int // byte position
+	runePos int // runes before pos, as ByteToRune counts them
+	version int
+
+	chunk      string // chunk last decoded from
+	chunkStart int    // position of the chunk, valid if chunk is not empty
+	unread     int    // size of the rune ReadRune returned last, 0 if none
+}
+
+var _ io.RuneScanner = (*RuneIterator)(nil)
+
+// RuneIterator returns an iterator at the byte position start
+func (gb *GapBuffer) RuneIterator(start int) (it *RuneIterator, err error) {
+	defer guard("RuneIterator", start, gb.length, &err)
+
+	if start < 0 || start > gb.length {
+		return nil, ErrPositionOutOfRange
+	}
+	return &RuneIterator{gb: gb, pos: start, runePos: gb.runesBefore(start), version: gb.version}, nil
+}
+
+// Pos returns the byte position of the iterator
+func (it *RuneIterator) Pos() int {
+	return it.pos
+}
+
+// RunePos returns the number of runes before the iterator, as ByteToRune
+// counts them
+func (it *RuneIterator) RunePos() int {
+	return it.runePos
+}
+
+// SetPos moves the iterator to the byte position pos
+func (it *RuneIterator) SetPos(pos int) (err error) {
+	defer guard("SetPos", pos, it.gb.length, &err)
+
+	if it.gb.version != it.version {
+		return ErrBufferModified
+	}
+	if pos < 0 || pos > it.gb.length {
+		return ErrPositionOutOfRange
+	}
+	it.pos, it.runePos, it.unread = pos, it.gb.runesBefore(pos), 0
+	return nil
+}
+
+// SetRunePos moves the iterator to the rune with index runePos, or the end
+// of the buffer for the number of runes
+func (it *RuneIterator) SetRunePos(runePos int) (err error) {
+	defer guard("SetRunePos", runePos, it.gb.length, &err)
+
+	if it.gb.version != it.version {
+		return ErrBufferModified
+	}
+	if runePos < 0 || runePos > it.gb.tree.total().runes {
+		return ErrRunePositionOutOfRange
+	}
+	it.pos, it.runePos, it.unread = it.gb.runeOffset(runePos), runePos, 0
+	return nil
+}
+
+// Next returns the rune after the iterator and its size in bytes and moves
+// past it. At the end of the buffer it returns io.EOF.
+func (it *RuneIterator) Next() (r rune, size int, err error) {
+	defer guard("Next", it.pos, it.gb.length, &err)
+
+	if it.gb.version != it.version {
+		return 0, 0, ErrBufferModified
+	}
+	it.unread = 0
+	if it.pos >= it.gb.length {
+		return 0, 0, io.EOF
+	}
+
+	text := it.chunkFrom(it.pos)
+	if !utf8.FullRuneInString(text) && it.pos+len(text) < it.gb.length {
+		// The rune continues in the next chunk
+		text = it.gb.rawText(it.pos, min(it.pos+utf8.UTFMax, it.gb.length))
+	}
+	r, size = utf8.DecodeRuneInString(text)
+	it.pos += size
+	it.runePos += textRunes(text[:size])
+	return r, size, nil
+}
+
+// Prev returns the rune before the iterator and its size in bytes and
+// moves before it. At the start of the buffer it returns io.EOF.
+func (it *RuneIterator) Prev() (r rune, size int, err error) {
+	defer guard("Prev", it.pos, it.gb.length, &err)
+
+	if it.gb.version != it.version {
+		return 0, 0, ErrBufferModified
+	}
+	it.unread = 0
+	if it.pos <= 0 {
+		return 0, 0, io.EOF
+	}
+
+	text := it.chunkTo(it.pos)
+	if len(text) < utf8.UTFMax && it.pos > len(text) {
+		// The rune may begin in the previous chunk
+		text = it.gb.rawText(max(it.pos-utf8.UTFMax, 0), it.pos)
+	}
+	r, size = utf8.DecodeLastRuneInString(text)
+	it.pos -= size
+	it.runePos -= textRunes(text[len(text)-size:])
+	return r, size, nil
+}
+
+// ReadRune implements io.RuneReader, like Next
+func (it *RuneIterator) ReadRune() (r rune, size int, err error) {
+	r, size, err = it.Next()
+	it.unread = size
+	return r, size, err
+}
+
+// UnreadRune implements io.RuneScanner: it steps back over the rune the
+// last call returned, which must be ReadRune
+func (it *RuneIterator) UnreadRune() (err error) {
+	defer guard("UnreadRune", it.pos, it.gb.length, &err)
+
+	if it.gb.version != it.version {
+		return ErrBufferModified
+	}
+	if it.unread == 0 {
+		return bufio.ErrInvalidUnreadRune
+	}
+	it.runePos -= textRunes(it.gb.rawText(it.pos-it.unread, it.pos))
+	it.pos -= it.unread
+	it.unread = 0
+	return nil
+}
+
+// chunkFrom returns the text from pos, which is before the end, to the end
+// of its chunk
+func (it *RuneIterator) chunkFrom(pos int) string {
+	if it.chunk == "" || pos < it.chunkStart || pos >= it.chunkStart+len(it.chunk) {
+		it.load(pos)
+	}
+	return it.chunk[pos-it.chunkStart:]
+}
+
+// chunkTo returns the text from the start of the chunk holding the byte
+// before pos, which is after the start, to pos
+func (it *RuneIterator) chunkTo(pos int) string {
+	if it.chunk == "" || pos <= it.chunkStart || pos > it.chunkStart+len(it.chunk) {
+		it.load(pos - 1)
+	}
+	return it.chunk[:pos-it.chunkStart]
+}
+
+// load makes the chunk holding the byte at pos the current one
+func (it *RuneIterator) load(pos int) {
+	chunk, before, _, _, _ := it.gb.tree.seek(func(before measures, m measures) bool {
+		return pos < before.size+m.size
+	})
+	it.chunk, it.chunkStart = chunk.Text, before.size
+}
@@ -0,0 +1,103 @@
+package buffer_test
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestRuneIterator walks the runes both ways on varied layouts and checks
+// them and the positions against decoding the text
+func TestRuneIterator(t *testing.T) {
+	gb := buffer.NewWithChunkSize(4)
+	gb.InsertAt(0, "aé中\U0001F600z\xffx\xe2\x82")
+	gb.InsertAt(2, "\xe2\x82")
+	gb.InsertAt(4, "\xac")
+	text := string(gb.Bytes())
+
+	for seed := int64(1); seed <= 5; seed++ {
+		gb.RandomizeLayout(seed)
+		it, err := gb.RuneIterator(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for pos := 0; pos < len(text); {
+			want, wantSize := utf8.DecodeRuneInString(text[pos:])
+			r, size, err := it.Next()
+			if err != nil || r != want || size != wantSize {
+				t.Fatalf("seed %d: Next at %d = %q, %d, %v, want %q, %d", seed, pos, r, size, err, want, wantSize)
+			}
+			pos += size
+			if runePos, _ := gb.ByteToRune(pos); it.Pos() != pos || it.RunePos() != runePos {
+				t.Fatalf("seed %d: at %d, rune %d, want %d, rune %d", seed, it.Pos(), it.RunePos(), pos, runePos)
+			}
+		}
+		if _, _, err := it.Next(); err != io.EOF {
+			t.Errorf("seed %d: Next at the end: got %v", seed, err)
+		}
+
+		for pos := len(text); pos > 0; {
+			want, wantSize := utf8.DecodeLastRuneInString(text[:pos])
+			r, size, err := it.Prev()
+			if err != nil || r != want || size != wantSize {
+				t.Fatalf("seed %d: Prev at %d = %q, %d, %v, want %q, %d", seed, pos, r, size, err, want, wantSize)
+			}
+			pos -= size
+			if it.Pos() != pos {
+				t.Fatalf("seed %d: at %d, want %d", seed, it.Pos(), pos)
+			}
+		}
+		if _, _, err := it.Prev(); err != io.EOF || it.RunePos() != 0 {
+			t.Errorf("seed %d: Prev at the start: got %v, rune %d", seed, err, it.RunePos())
+		}
+	}
+}
+
+// TestRuneIteratorScanner checks the io.RuneScanner methods, the moves and
+// the errors
+func TestRuneIteratorScanner(t *testing.T) {
+	gb := buffer.NewWithChunkSize(4)
+	gb.InsertAt(0, "key = värde; other = 42")
+	it, _ := gb.RuneIterator(0)
+	if loc := regexp.MustCompile(`\d+`).FindReaderIndex(it); loc == nil || loc[0] != 22 {
+		t.Errorf("regexp found %v", loc)
+	}
+
+	it.SetRunePos(7)
+	if r, _, _ := it.ReadRune(); r != 'ä' || it.Pos() != 9 {
+		t.Errorf("read %q, at %d", r, it.Pos())
+	}
+	if err := it.UnreadRune(); err != nil || it.Pos() != 7 || it.RunePos() != 7 {
+		t.Errorf("unread: at %d, rune %d, %v", it.Pos(), it.RunePos(), err)
+	}
+	if err := it.UnreadRune(); !errors.Is(err, bufio.ErrInvalidUnreadRune) {
+		t.Errorf("second unread: got %v", err)
+	}
+	it.Next()
+	if err := it.UnreadRune(); !errors.Is(err, bufio.ErrInvalidUnreadRune) {
+		t.Errorf("unread after Next: got %v", err)
+	}
+
+	if err := it.SetPos(gb.Length() + 1); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("SetPos past the end: got %v", err)
+	}
+	if err := it.SetRunePos(gb.RuneLength() + 1); !errors.Is(err, buffer.ErrRunePositionOutOfRange) {
+		t.Errorf("SetRunePos past the end: got %v", err)
+	}
+	if _, err := gb.RuneIterator(-1); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("iterator before the start: got %v", err)
+	}
+
+	gb.InsertAt(0, "x")
+	if _, _, err := it.Next(); !errors.Is(err, buffer.ErrBufferModified) {
+		t.Errorf("Next after an edit: got %v", err)
+	}
+	if err := it.SetPos(0); !errors.Is(err, buffer.ErrBufferModified) {
+		t.Errorf("SetPos after an edit: got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package buffer
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// runeMarkInterval is the number of runes between the byte offsets a chunk
+// of multi-byte text remembers, so that finding a rune in it decodes at
+// most this many runes instead of the whole chunk
+const runeMarkInterval = 64
+
+// ByteToRune returns the number of runes before the byte position pos. A
+// position inside a rune counts the whole rune.
+func (gb *GapBuffer) ByteToRune(pos int) (runePos int, err error) {
+	defer guard("ByteToRune", pos, gb.length, &err)
+
+	if pos < 0 || pos > gb.length {
+		return 0, ErrPositionOutOfRange
+	}
+	return gb.runesBefore(pos), nil
+}
+
+// RuneToByte returns the byte position of the rune with index runePos, or
+// the buffer length for the number of runes
+func (gb *GapBuffer) RuneToByte(runePos int) (pos int, err error) {
+	defer guard("RuneToByte", runePos, gb.length, &err)
+
+	if runePos < 0 || runePos > gb.tree.total().runes {
+		return 0, ErrRunePositionOutOfRange
+	}
+	return gb.runeOffset(runePos), nil
+}
+
+// ByteAt returns the byte at pos, found through the tree in O(log n)
+func (gb *GapBuffer) ByteAt(pos int) (b byte, err error) {
+	defer guard("ByteAt", pos, gb.length, &err)
+
+	if pos < 0 || pos >= gb.length {
+		return 0, ErrPositionOutOfRange
+	}
+	chunk, before, _, _, _ := gb.tree.seek(func(before measures, m measures) bool {
+		return pos < before.size+m.size
+	})
+	return chunk.Text[pos-before.size], nil
+}
+
+// RuneAt returns the rune with index runePos, found through the tree in
+// O(log n). Invalid bytes are returned as utf8.RuneError.
+func (gb *GapBuffer) RuneAt(runePos int) (r rune, err error) {
+	defer guard("RuneAt", runePos, gb.length, &err)
+
+	if runePos < 0 || runePos >= gb.tree.total().runes {
+		return 0, ErrRunePositionOutOfRange
+	}
+	chunk, before, own, _, owned := gb.tree.seek(func(before measures, m measures) bool {
+		return runePos < before.runes+m.runes
+	})
+	off := runePos - before.runes
+	if own.runes != own.size {
+		off = chunk.runeOffset(off, owned && !gb.treeShared)
+	}
+	if text := chunk.Text[off:]; utf8.FullRuneInString(text) {
+		r, _ = utf8.DecodeRuneInString(text)
+		return r, nil
+	}
+
+	// The rune continues in the next chunk
+	r, _ = gb.runeAfter(before.size + off)
+	return r, nil
+}
+
+// runeRange converts a range of rune indexes to byte positions, clamping
+// its end to the number of runes
+func (gb *GapBuffer) runeRange(runeStart int, runeEnd int) (int, int, error) {
+	runes := gb.tree.total().runes
+	if runeStart < 0 || runeStart > runeEnd || runeStart > runes {
+		return 0, 0, ErrInvalidRuneRange
+	}
+	return gb.runeOffset(runeStart), gb.runeOffset(min(runeEnd, runes)), nil
+}
+
+// runesBefore returns the number of runes before pos, using the counts the
+// tree keeps per subtree and the marks of the chunk containing pos
+func (gb *GapBuffer) runesBefore(pos int) int {
+	chunk, before, own, ok, owned := gb.tree.seek(func(before measures, m measures) bool {
+		return pos < before.size+m.size
+	})
+	switch {
+	case !ok:
+		return before.runes
+	case own.runes == own.size:
+		return before.runes + pos - before.size
+	}
+	return before.runes + chunk.runesBefore(pos-before.size, owned && !gb.treeShared)
+}
+
+// runeOffset returns the position of the rune with index n, or the buffer
+// length if there are only n runes
+func (gb *GapBuffer) runeOffset(n int) int {
+	chunk, before, own, ok, owned := gb.tree.seek(func(before measures, m measures) bool {
+		return n < before.runes+m.runes
+	})
+	switch {
+	case !ok:
+		return gb.length
+	case own.runes == own.size:
+		return before.size + n - before.runes
+	}
+	return before.size + chunk.runeOffset(n-before.runes, owned && !gb.treeShared)
+}
+
+// runeOffset returns the offset in the chunk of its rune with index n,
+// which must exist, keeping the marks it computes if cache is set
+func (c *payload) runeOffset(n int, cache bool) int {
+	marks := c.marks(cache)
+	off := marks[n/runeMarkInterval]
+	for left := n % runeMarkInterval; left > 0; left-- {
+		off++
+		for off < len(c.Text) && c.Text[off]&0xC0 == 0x80 {
+			off++
+		}
+	}
+	return off
+}
+
+// runesBefore returns the number of runes starting before offset off of
+// the chunk, keeping the marks it computes if cache is set
+func (c *payload) runesBefore(off int, cache bool) int {
+	marks := c.marks(cache)
+	i := sort.SearchInts(marks, off) - 1
+	if i < 0 {
+		return 0
+	}
+	return i*runeMarkInterval + textRunes(c.Text[marks[i]:off])
+}
+
+// marks returns the offsets of the runes of the chunk whose index is a
+// multiple of runeMarkInterval, computing them on first use. They are kept
+// for later calls if cache is set; chunks shared with snapshots must not
+// be written to.
+func (c *payload) marks(cache bool) []int {
+	if c.runeMarks != nil {
+		return c.runeMarks
+	}
+	marks := make([]int, 0, len(c.Text)/runeMarkInterval+1)
+	n := 0
+	for i := 0; i < len(c.Text); i++ {
+		if c.Text[i]&0xC0 == 0x80 {
+			continue
+		}
+		if n%runeMarkInterval == 0 {
+			marks = append(marks, i)
+		}
+		n++
+	}
+	if cache {
+		c.runeMarks = marks
+	}
+	return marks
+}
+
+// textRunes returns the number of runes in text, counted by their lead
+// bytes so that counts of pieces split anywhere add up. Stray continuation
+// bytes of invalid text count for nothing.
+func textRunes(text string) int {
+	runes := len(text)
+	for i := 0; i < len(text); i++ {
+		if text[i]&0xC0 == 0x80 {
+			runes--
+		}
+	}
+	return runes
+}
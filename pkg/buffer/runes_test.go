@@ -0,0 +1,98 @@
+package buffer_test
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestRuneOffsets checks ByteToRune and RuneToByte against decoding the
+// text, through edits of mostly multi-byte text in chunks long enough to
+// hold many rune marks
+func TestRuneOffsets(t *testing.T) {
+	gb := buffer.NewWithChunkSize(1024)
+	gb.InsertAt(0, strings.Repeat("日本語のテキストabc", 300))
+	rng := rand.New(rand.NewSource(1))
+	pieces := []string{"漢字", "x", "€uro", "\U0001F600", ""}
+
+	for i := 0; i < 200; i++ {
+		text := gb.GetText()
+		runePos := rng.Intn(utf8.RuneCountInString(text) + 1)
+		pos := len(string([]rune(text)[:runePos]))
+		if got, err := gb.RuneToByte(runePos); err != nil || got != pos {
+			t.Fatalf("edit %d: rune %d at byte %d, %v, want %d", i, runePos, got, err, pos)
+		}
+		if got, err := gb.ByteToRune(pos); err != nil || got != runePos {
+			t.Fatalf("edit %d: byte %d at rune %d, %v, want %d", i, pos, got, err, runePos)
+		}
+		if gb.RuneLength() != utf8.RuneCountInString(text) {
+			t.Fatalf("edit %d: got %d runes", i, gb.RuneLength())
+		}
+
+		end := pos
+		for n := rng.Intn(4); n > 0 && end < len(text); n-- {
+			_, size := utf8.DecodeRuneInString(text[end:])
+			end += size
+		}
+		gb.Replace(pos, end, pieces[rng.Intn(len(pieces))])
+	}
+}
+
+// TestRuneOffsetsSplit checks a byte position inside a rune and the errors
+func TestRuneOffsetsSplit(t *testing.T) {
+	gb := newBuffer(t, "a日b")
+	if n, _ := gb.ByteToRune(2); n != 2 {
+		t.Errorf("inside 日: got %d, want 2", n)
+	}
+	if pos, _ := gb.RuneToByte(3); pos != 5 {
+		t.Errorf("rune count: got %d, want 5", pos)
+	}
+	if _, err := gb.ByteToRune(6); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("byte past the end: %v", err)
+	}
+	if _, err := gb.RuneToByte(4); !errors.Is(err, buffer.ErrRunePositionOutOfRange) {
+		t.Errorf("rune past the end: %v", err)
+	}
+}
+
+// TestByteAtRuneAt checks the point accessors against the text on varied
+// layouts, with a rune split between chunks and invalid bytes
+func TestByteAtRuneAt(t *testing.T) {
+	gb := buffer.NewWithChunkSize(4)
+	gb.InsertAt(0, "aé中\U0001F600z\xffend")
+	gb.InsertAt(1, "\xe2\x82")
+	gb.InsertAt(3, "\xac") // completes a € split over two inserts
+	text := string(gb.Bytes())
+	want := []rune(text)
+
+	for seed := int64(1); seed <= 5; seed++ {
+		gb.RandomizeLayout(seed)
+		for pos := 0; pos < len(text); pos++ {
+			if b, err := gb.ByteAt(pos); err != nil || b != text[pos] {
+				t.Fatalf("seed %d: ByteAt(%d) = %q, %v, want %q", seed, pos, b, err, text[pos])
+			}
+		}
+		for i, r := range want {
+			if got, err := gb.RuneAt(i); err != nil || got != r {
+				t.Fatalf("seed %d: RuneAt(%d) = %q, %v, want %q", seed, i, got, err, r)
+			}
+		}
+	}
+	if want[1] != '€' || want[6] != utf8.RuneError {
+		t.Errorf("got runes %q", want)
+	}
+
+	if _, err := gb.ByteAt(len(text)); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("ByteAt at the end: got %v", err)
+	}
+	if _, err := gb.RuneAt(len(want)); !errors.Is(err, buffer.ErrRunePositionOutOfRange) {
+		t.Errorf("RuneAt at the end: got %v", err)
+	}
+	if _, err := gb.RuneAt(-1); !errors.Is(err, buffer.ErrRunePositionOutOfRange) {
+		t.Errorf("RuneAt before the start: got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package buffer
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// SampledLine is a line picked by SampleLines. End excludes the line
+// terminator, \n or \r\n.
+type SampledLine struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// SampleLines returns up to n distinct lines picked at random, ordered by
+// position. Lines are weighted by their length in bytes: a random offset is
+// drawn and the line around it is read, so only the sampled lines are
+// scanned. The empty line after a final newline has no byte to draw and is
+// never sampled; when n covers every other line, all of them are returned.
+// The same seed on the same text yields the same sample.
+func (gb *GapBuffer) SampleLines(n int, seed int64) []SampledLine {
+	if n <= 0 || gb.length == 0 {
+		return nil
+	}
+
+	lines := gb.lineCount()
+	if gb.rawText(gb.length-1, gb.length) == "\n" {
+		lines--
+	}
+	if n >= lines {
+		result := make([]SampledLine, lines)
+		for line := range result {
+			result[line] = gb.sampledLine(line)
+		}
+		return result
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	seen := make(map[int]bool)
+	var result []SampledLine
+
+	// Give up after a bounded number of draws when a few long lines take
+	// up most of the buffer
+	for attempts := 0; len(result) < n && attempts < 4*n+16; attempts++ {
+		line := gb.lineAt(rng.Intn(gb.length))
+		if seen[line] {
+			continue
+		}
+		seen[line] = true
+		result = append(result, gb.sampledLine(line))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Start < result[j].Start
+	})
+	return result
+}
+
+// sampledLine returns the line as SampleLines reports it
+func (gb *GapBuffer) sampledLine(line int) SampledLine {
+	r, _ := gb.lineRange(line)
+	return SampledLine{
+		Start: r.Start,
+		End:   r.End,
+		Text:  EnsureValidUTF8(gb.rawText(r.Start, r.End)),
+	}
+}
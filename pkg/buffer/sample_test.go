@@ -0,0 +1,60 @@
+package buffer_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestSampleLines checks that sampled lines exclude their \r\n and that
+// asking for every line returns all lines that hold a byte, leaving out
+// the empty line after a final newline
+func TestSampleLines(t *testing.T) {
+	gb := newBuffer(t, "one\r\ntwo\n\r\nfour\r\n")
+	want := []buffer.SampledLine{
+		{Start: 0, End: 3, Text: "one"},
+		{Start: 5, End: 8, Text: "two"},
+		{Start: 9, End: 9, Text: ""},
+		{Start: 11, End: 15, Text: "four"},
+	}
+	for _, n := range []int{4, 5, 100} {
+		if got := gb.SampleLines(n, 1); !reflect.DeepEqual(got, want) {
+			t.Errorf("SampleLines(%d): got %+v, want %+v", n, got, want)
+		}
+	}
+}
+
+// TestSampleLinesSubset checks that a smaller sample has distinct lines in
+// order, each read with its \r\n excluded, and is the same for a seed
+func TestSampleLinesSubset(t *testing.T) {
+	text := strings.Repeat("line of text\r\n", 50)
+	gb := newBuffer(t, text)
+
+	got := gb.SampleLines(10, 7)
+	if len(got) != 10 {
+		t.Fatalf("got %d lines, want 10", len(got))
+	}
+	for i, line := range got {
+		if line.Text != "line of text" || line.End-line.Start != len(line.Text) {
+			t.Errorf("line %d: got %+v", i, line)
+		}
+		if i > 0 && line.Start <= got[i-1].Start {
+			t.Errorf("line %d: start %d after %d", i, line.Start, got[i-1].Start)
+		}
+	}
+	if again := gb.SampleLines(10, 7); !reflect.DeepEqual(again, got) {
+		t.Errorf("same seed: got %+v, want %+v", again, got)
+	}
+}
+
+// newBuffer returns a GapBuffer holding text
+func newBuffer(t *testing.T, text string) *buffer.GapBuffer {
+	t.Helper()
+	gb := buffer.New()
+	if err := gb.InsertAt(0, text); err != nil {
+		t.Fatal(err)
+	}
+	return gb
+}
@@ -0,0 +1,142 @@
+package buffer
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/text/encoding"
+)
+
+// SaveOptions configures SaveFile
+type SaveOptions struct {
+	Backup       bool        // Keep the previous content of the file next to it
+	BackupSuffix string      // Appended to the path to name the backup, "~" if empty
+	Perm         fs.FileMode // Permissions of a new file, 0644 if zero; an existing file keeps its own
+	Compressor   Compressor  // Compresses the content, CompressorFor(path) if nil
+
+	// Encoding is the character set the file is written in, UTF-8 if nil.
+	// Text it cannot represent fails the save with an *EncodeError.
+	Encoding encoding.Encoding
+}
+
+// SaveFile writes the buffer to the file at path so that the file holds
+// either its old or its new content even if the process or the machine
+// crashes midway: the content goes to a temporary file in the same
+// directory, which is synced and then renamed over path, and the directory
+// is synced after the rename. A symlink at path is followed, so the file it
+// points to is replaced. The chunks are written as they are, through the
+// compressor registered for the extension of path unless opts names one.
+// Replacing the file leaves a file mapped by NewFromFile intact. The text
+// of such a buffer that still comes from the file is copied file to file,
+// without passing through memory where the system supports it, unless
+// the content is compressed or encoded.
+func (gb *GapBuffer) SaveFile(path string, opts SaveOptions) (err error) {
+	defer guard("SaveFile", noPos, gb.length, &err)
+
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	perm := opts.Perm
+	if perm == 0 {
+		perm = 0o644
+	}
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		perm = info.Mode().Perm()
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+name+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	c := opts.Compressor
+	if c == nil {
+		c = CompressorFor(path)
+	}
+	write := gb.writeContent
+	if opts.Encoding != nil {
+		write = func(w io.Writer) error { return gb.writeEncoded(w, opts.Encoding) }
+	}
+	if c == nil && opts.Encoding == nil && gb.mapping != nil {
+		err = gb.writeBack(tmp)
+	} else {
+		_, err = writeCompressed(tmp, c, write)
+	}
+	if err != nil {
+		return err
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	if opts.Backup && info != nil {
+		suffix := opts.BackupSuffix
+		if suffix == "" {
+			suffix = "~"
+		}
+		if err = backupFile(path, path+suffix); err != nil {
+			return err
+		}
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// backupFile makes backup hold the current content of path, as a hard link
+// where possible and as a synced copy otherwise
+func backupFile(path string, backup string) error {
+	if err := os.Remove(backup); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if os.Link(path, backup) == nil {
+		return nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
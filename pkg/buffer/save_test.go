@@ -0,0 +1,181 @@
+package buffer_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// readFile returns the content of the file at path
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// checkNoTemp fails unless dir holds want files, so no temporary file of
+// SaveFile is left behind
+func checkNoTemp(t *testing.T, dir string, want int) {
+	t.Helper()
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != want {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("got files %v, want %d", names, want)
+	}
+}
+
+// TestSaveFile checks that saving replaces the file as a whole: a reader
+// holding the old file keeps reading the old content, the new one has it
+// all, and no temporary file is left
+func TestSaveFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "doc.txt")
+	gb := newBuffer(t, "first\n")
+	if err := gb.SaveFile(path, buffer.SaveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	old, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+
+	gb.InsertAt(gb.Length(), "second\n")
+	if err := gb.SaveFile(path, buffer.SaveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path); got != "first\nsecond\n" {
+		t.Errorf("saved %q", got)
+	}
+	if got, _ := io.ReadAll(old); string(got) != "first\n" {
+		t.Errorf("the old file was rewritten in place: %q", got)
+	}
+	checkNoTemp(t, dir, 1)
+}
+
+// TestSaveFileFailure checks that a failing save leaves the file and the
+// directory as they were
+func TestSaveFileFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "doc.txt")
+	os.WriteFile(path, []byte("kept"), 0o644)
+	gb := newBuffer(t, "lost")
+
+	failing := func(io.Writer) (io.WriteCloser, error) { return nil, errWriteFailed }
+	err := gb.SaveFile(path, buffer.SaveOptions{Backup: true, Compressor: failing})
+	var opErr *buffer.OpError
+	if !errors.Is(err, errWriteFailed) || !errors.As(err, &opErr) || opErr.Op != "SaveFile" {
+		t.Errorf("got %v", err)
+	}
+	if got := readFile(t, path); got != "kept" {
+		t.Errorf("the file changed to %q", got)
+	}
+	checkNoTemp(t, dir, 1)
+
+	if err := gb.SaveFile(filepath.Join(dir, "missing", "doc.txt"), buffer.SaveOptions{}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing directory: got %v", err)
+	}
+}
+
+// TestSaveFileBackup checks that the previous content is kept under the
+// backup suffix, and that nothing is backed up for a new file
+func TestSaveFileBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "doc.txt")
+	gb := newBuffer(t, "one")
+	if err := gb.SaveFile(path, buffer.SaveOptions{Backup: true}); err != nil {
+		t.Fatal(err)
+	}
+	checkNoTemp(t, dir, 1)
+
+	tests := []struct {
+		text   string
+		suffix string
+		backup string
+	}{
+		{"two", "", path + "~"},
+		{"three", "", path + "~"},
+		{"four", ".bak", path + ".bak"},
+	}
+	prev := "one"
+	for _, tt := range tests {
+		gb.Replace(0, gb.Length(), tt.text)
+		if err := gb.SaveFile(path, buffer.SaveOptions{Backup: true, BackupSuffix: tt.suffix}); err != nil {
+			t.Fatal(err)
+		}
+		if got := readFile(t, path); got != tt.text {
+			t.Errorf("saved %q, want %q", got, tt.text)
+		}
+		if got := readFile(t, tt.backup); got != prev {
+			t.Errorf("backup %s holds %q, want %q", filepath.Base(tt.backup), got, prev)
+		}
+		prev = tt.text
+	}
+	checkNoTemp(t, dir, 3)
+}
+
+// TestSaveFilePermissions checks that new files get opts.Perm and existing
+// ones keep their permissions
+func TestSaveFilePermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix permissions")
+	}
+	dir := t.TempDir()
+	gb := newBuffer(t, "text")
+
+	path := filepath.Join(dir, "default.txt")
+	gb.SaveFile(path, buffer.SaveOptions{})
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o644 {
+		t.Errorf("default permissions: got %v, %v", info.Mode(), err)
+	}
+
+	path = filepath.Join(dir, "private.txt")
+	gb.SaveFile(path, buffer.SaveOptions{Perm: 0o600})
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("new file: got %v, %v", info.Mode(), err)
+	}
+
+	os.Chmod(path, 0o640)
+	gb.SaveFile(path, buffer.SaveOptions{Perm: 0o600})
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o640 {
+		t.Errorf("existing file: got %v, %v", info.Mode(), err)
+	}
+}
+
+// TestSaveFileSymlink checks that saving through a symlink replaces the
+// file it points to and leaves the link in place
+func TestSaveFileSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.txt")
+	link := filepath.Join(dir, "link.txt")
+	os.WriteFile(target, []byte("old"), 0o644)
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	gb := newBuffer(t, "new")
+	if err := gb.SaveFile(link, buffer.SaveOptions{Backup: true}); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		t.Errorf("the link was replaced: %v, %v", info.Mode(), err)
+	}
+	if got := readFile(t, target); got != "new" {
+		t.Errorf("target holds %q", got)
+	}
+	if got := readFile(t, target+"~"); got != "old" {
+		t.Errorf("backup holds %q", got)
+	}
+}
@@ -0,0 +1,114 @@
+package buffer
+
+import "sort"
+
+// ScrollBucket is the summary of a run of lines for a minimap or the
+// annotations along a scrollbar
+type ScrollBucket struct {
+	FirstLine   int // Zero-based first line of the bucket
+	Lines       int // Number of lines in the bucket
+	Ink         int // Bytes that are not spaces, tabs, carriage returns or newlines
+	Diagnostics int // Diagnostics on the lines of the bucket
+	SearchHits  int // Search hits starting on the lines of the bucket
+}
+
+// ScrollMap summarizes the document in equal vertical buckets. The ink
+// counts are kept by the buffer through edits and the diagnostics and
+// search hits are markers, so buckets are cheap to recompute after any edit.
+type ScrollMap struct {
+	gb          *GapBuffer
+	diagnostics []*Marker
+	hits        []*Marker
+}
+
+// NewScrollMap returns a scroll map of the buffer without diagnostics or
+// search hits. Close it when it is no longer needed.
+func (gb *GapBuffer) NewScrollMap() *ScrollMap {
+	return &ScrollMap{gb: gb}
+}
+
+// SetDiagnostics replaces the diagnostics with ones at the given positions
+func (s *ScrollMap) SetDiagnostics(positions []int) error {
+	markers, err := s.createMarkers(positions)
+	if err != nil {
+		return err
+	}
+	s.gb.RemoveMarkers(s.diagnostics)
+	s.diagnostics = markers
+	return nil
+}
+
+// SetSearchHits replaces the search hits, which are counted where they start
+func (s *ScrollMap) SetSearchHits(hits []Range) error {
+	positions := make([]int, len(hits))
+	for i, h := range hits {
+		positions[i] = h.Start
+	}
+	markers, err := s.createMarkers(positions)
+	if err != nil {
+		return err
+	}
+	s.gb.RemoveMarkers(s.hits)
+	s.hits = markers
+	return nil
+}
+
+// Close removes the diagnostics and search hits from the buffer
+func (s *ScrollMap) Close() {
+	s.gb.RemoveMarkers(s.diagnostics)
+	s.gb.RemoveMarkers(s.hits)
+	s.diagnostics, s.hits = nil, nil
+}
+
+// Buckets splits the lines of the document into n buckets of equal height,
+// the first ones one line shorter when they do not divide evenly, and
+// returns their summaries. A document of fewer than n lines gets a bucket
+// per line.
+func (s *ScrollMap) Buckets(n int) (buckets []ScrollBucket, err error) {
+	gb := s.gb
+	defer guard("Buckets", noPos, gb.length, &err)
+
+	if n <= 0 {
+		return nil, nil
+	}
+	lines := gb.lineCount()
+	n = min(n, lines)
+
+	buckets = make([]ScrollBucket, n)
+	ink := 0
+	for b := range buckets {
+		first, next := b*lines/n, (b+1)*lines/n
+		end := gb.length
+		if next < lines {
+			end = gb.lineStartOf(next)
+		}
+		inkEnd := gb.inkBefore(end)
+		buckets[b] = ScrollBucket{FirstLine: first, Lines: next - first, Ink: inkEnd - ink}
+		ink = inkEnd
+	}
+
+	// bucketOf returns the bucket holding the line of pos
+	bucketOf := func(pos int) int {
+		line := gb.lineAt(pos)
+		return sort.Search(n, func(b int) bool {
+			return buckets[b].FirstLine+buckets[b].Lines > line
+		})
+	}
+	for _, m := range s.diagnostics {
+		buckets[bucketOf(m.Pos())].Diagnostics++
+	}
+	for _, m := range s.hits {
+		buckets[bucketOf(m.Pos())].SearchHits++
+	}
+	return buckets, nil
+}
+
+// createMarkers returns left-gravity markers at positions, or none if one
+// is out of range
+func (s *ScrollMap) createMarkers(positions []int) ([]*Marker, error) {
+	specs := make([]MarkerSpec, len(positions))
+	for i, pos := range positions {
+		specs[i] = MarkerSpec{Pos: pos, Gravity: GravityLeft}
+	}
+	return s.gb.CreateMarkers(specs)
+}
@@ -0,0 +1,86 @@
+package buffer_test
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestScrollMap checks the buckets of a small document, with diagnostics
+// and search hits following an edit
+func TestScrollMap(t *testing.T) {
+	gb := newBuffer(t, "ab\n\t\ncd e\r\n  \nfgh")
+	s := gb.NewScrollMap()
+	defer s.Close()
+	if err := s.SetDiagnostics([]int{0, 5, 14}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetSearchHits([]buffer.Range{{Start: 6, End: 8}, {Start: 15, End: 17}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []buffer.ScrollBucket{
+		{FirstLine: 0, Lines: 2, Ink: 2, Diagnostics: 1},
+		{FirstLine: 2, Lines: 3, Ink: 6, Diagnostics: 2, SearchHits: 2},
+	}
+	if got, err := s.Buckets(2); err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, %v, want %+v", got, err, want)
+	}
+	if got, _ := s.Buckets(10); len(got) != 5 {
+		t.Errorf("got %d buckets for 5 lines", len(got))
+	}
+
+	// Markers follow the text onto the lines it moves to
+	gb.InsertAt(0, "new\n\n\n")
+	want = []buffer.ScrollBucket{
+		{FirstLine: 0, Lines: 4, Ink: 5, Diagnostics: 1},
+		{FirstLine: 4, Lines: 4, Ink: 6, Diagnostics: 2, SearchHits: 2},
+	}
+	if got, _ := s.Buckets(2); !reflect.DeepEqual(got, want) {
+		t.Errorf("after an edit: got %+v, want %+v", got, want)
+	}
+
+	if err := s.SetDiagnostics([]int{gb.Length() + 1}); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("diagnostic past the end: %v", err)
+	}
+	if got, _ := s.Buckets(1); got[0].Diagnostics != 3 {
+		t.Errorf("failed update dropped diagnostics: %+v", got)
+	}
+	if got, _ := s.Buckets(0); got != nil {
+		t.Errorf("got %+v for no buckets", got)
+	}
+}
+
+// TestScrollMapInk checks the ink of the buckets against counting it in
+// the text through random edits
+func TestScrollMapInk(t *testing.T) {
+	gb := buffer.NewWithChunkSize(8)
+	s := gb.NewScrollMap()
+	rng := rand.New(rand.NewSource(1))
+	pieces := []string{"word", " ", "\t", "\n", "\r\n", "x y\nz"}
+	for i := 0; i < 500; i++ {
+		pos := rng.Intn(gb.Length() + 1)
+		gb.Replace(pos, pos+rng.Intn(min(5, gb.Length()-pos)+1), pieces[rng.Intn(len(pieces))])
+
+		lines := strings.Split(gb.GetText(), "\n")
+		buckets, err := s.Buckets(1 + rng.Intn(7))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range buckets {
+			want := 0
+			for _, line := range lines[b.FirstLine : b.FirstLine+b.Lines] {
+				want += len(strings.Join(strings.FieldsFunc(line, func(r rune) bool {
+					return r == ' ' || r == '\t' || r == '\r'
+				}), ""))
+			}
+			if b.Ink != want {
+				t.Fatalf("edit %d, bucket %+v: want ink %d", i, b, want)
+			}
+		}
+	}
+}
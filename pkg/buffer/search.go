@@ -0,0 +1,209 @@
+package buffer
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// SearchQuery describes a search run over many buffers. Matches never span
+// lines.
+type SearchQuery struct {
+	Pattern    string
+	Regexp     bool // Pattern is a regular expression instead of literal text
+	IgnoreCase bool
+	MaxMatches int // Maximum number of matches per source, 0 for no limit
+}
+
+// compile turns the query into a regular expression
+func (q SearchQuery) compile() (*regexp.Regexp, error) {
+	pattern := q.Pattern
+	if !q.Regexp {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if q.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// SearchSource is a buffer to search, or a way to load one when its turn
+// comes, such as a file on disk that is not open
+type SearchSource struct {
+	Name   string
+	Buffer *GapBuffer
+	Load   func() (*GapBuffer, error)
+}
+
+// FileSource returns a source loading the file at path only when it is searched
+func FileSource(path string) SearchSource {
+	return SearchSource{
+		Name: path,
+		Load: func() (*GapBuffer, error) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			gb := New()
+			gb.rawInsert(0, string(data))
+			return gb, nil
+		},
+	}
+}
+
+// SearchMatch is one match with the position of its line, for previews
+type SearchMatch struct {
+	Start    int
+	End      int
+	Line     int    // Zero-based line of the match
+	Col      int    // Byte offset of the match in its line
+	LineText string // The line, without its newline
+}
+
+// SearchResult groups the matches found in one source, or the error that
+// prevented searching it
+type SearchResult struct {
+	Name    string
+	Matches []SearchMatch
+	Err     error
+}
+
+// SearchBuffers searches the sources with up to workers goroutines
+// (runtime.NumCPU() if workers <= 0) and streams one result per source
+// with matches or an error, in completion order. The channel is closed
+// once every source is searched or ctx is done.
+//
+// Open buffers are snapshotted before SearchBuffers returns, which only
+// copies their chunk list, so it must be called from the goroutine editing
+// them; they can be edited again as soon as it returns.
+func SearchBuffers(ctx context.Context, sources []SearchSource, q SearchQuery, workers int) (<-chan SearchResult, error) {
+	re, err := q.compile()
+	if err != nil {
+		return nil, err
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	type job struct {
+		source SearchSource
+		chunks []Chunk
+	}
+	jobs := make([]job, len(sources))
+	for i, s := range sources {
+		jobs[i].source = s
+		if s.Buffer != nil {
+			jobs[i].chunks = s.Buffer.Chunks()
+		}
+	}
+
+	results := make(chan SearchResult)
+	next := make(chan job)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range next {
+				result := SearchResult{Name: j.source.Name}
+				if j.source.Buffer == nil && j.source.Load != nil {
+					gb, err := j.source.Load()
+					if err != nil {
+						result.Err = err
+					} else {
+						j.chunks = gb.Chunks()
+					}
+				}
+				if result.Err == nil {
+					result.Matches = searchChunks(ctx, j.chunks, re, q.MaxMatches)
+				}
+				if result.Err == nil && len(result.Matches) == 0 {
+					continue
+				}
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(results)
+		defer wg.Wait()
+		defer close(next)
+		for _, j := range jobs {
+			select {
+			case next <- j:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return results, nil
+}
+
+// searchChunks finds the matches of re line by line in a chunk snapshot.
+// Lines within one chunk are matched without copying.
+func searchChunks(ctx context.Context, chunks []Chunk, re *regexp.Regexp, maxMatches int) []SearchMatch {
+	var matches []SearchMatch
+	line := 0
+	lineStart := 0
+	var pending strings.Builder // start of a line continued in the next chunk
+
+	// match searches one complete line and reports whether to go on
+	match := func(text string) bool {
+		lineText := ""
+		for i, m := range re.FindAllStringIndex(text, -1) {
+			if i == 0 {
+				// The chunk text can point into a file mapping, which the
+				// matches must outlive
+				lineText = strings.Clone(EnsureValidUTF8(text))
+			}
+			matches = append(matches, SearchMatch{
+				Start:    lineStart + m[0],
+				End:      lineStart + m[1],
+				Line:     line,
+				Col:      m[0],
+				LineText: lineText,
+			})
+			if maxMatches > 0 && len(matches) >= maxMatches {
+				matches = matches[:maxMatches]
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, c := range chunks {
+		if ctx.Err() != nil {
+			return matches
+		}
+		text := c.Text
+		for {
+			i := strings.IndexByte(text, '\n')
+			if i < 0 {
+				pending.WriteString(text)
+				break
+			}
+			current := text[:i]
+			if pending.Len() > 0 {
+				pending.WriteString(current)
+				current = pending.String()
+				pending.Reset()
+			}
+			if !match(current) {
+				return matches
+			}
+			lineStart += len(current) + 1
+			line++
+			text = text[i+1:]
+		}
+	}
+	match(pending.String())
+	return matches
+}
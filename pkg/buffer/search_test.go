@@ -0,0 +1,106 @@
+package buffer_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// search runs q over the sources and returns the results by name
+func search(t *testing.T, sources []buffer.SearchSource, q buffer.SearchQuery) map[string]buffer.SearchResult {
+	t.Helper()
+	results, err := buffer.SearchBuffers(context.Background(), sources, q, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]buffer.SearchResult{}
+	for r := range results {
+		byName[r.Name] = r
+	}
+	return byName
+}
+
+// TestSearchBuffers checks the matches found in open buffers and files,
+// and that sources without any are left out
+func TestSearchBuffers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "disk.txt")
+	if err := os.WriteFile(path, []byte("nothing\nTODO on disk\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A long buffer has lines spanning chunks
+	long := strings.Repeat("x", 100000) + " todo\n" + strings.Repeat("y", 100000) + "\ntodo"
+	sources := []buffer.SearchSource{
+		{Name: "open", Buffer: newBuffer(t, "a todo\nb\ntodo todo\n")},
+		{Name: "long", Buffer: newBuffer(t, long)},
+		{Name: "none", Buffer: newBuffer(t, "clean\n")},
+		buffer.FileSource(path),
+		buffer.FileSource(filepath.Join(dir, "missing.txt")),
+	}
+	results := search(t, sources, buffer.SearchQuery{Pattern: "todo", IgnoreCase: true})
+
+	var names []string
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	wantNames := []string{filepath.Join(dir, "disk.txt"), filepath.Join(dir, "missing.txt"), "long", "open"}
+	sort.Strings(wantNames)
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("got results for %v, want %v", names, wantNames)
+	}
+
+	want := []buffer.SearchMatch{
+		{Start: 2, End: 6, Line: 0, Col: 2, LineText: "a todo"},
+		{Start: 9, End: 13, Line: 2, Col: 0, LineText: "todo todo"},
+		{Start: 14, End: 18, Line: 2, Col: 5, LineText: "todo todo"},
+	}
+	if got := results["open"].Matches; !reflect.DeepEqual(got, want) {
+		t.Errorf("open: got %+v, want %+v", got, want)
+	}
+	want = []buffer.SearchMatch{{Start: 8, End: 12, Line: 1, Col: 0, LineText: "TODO on disk"}}
+	if got := results[path].Matches; !reflect.DeepEqual(got, want) {
+		t.Errorf("file: got %+v, want %+v", got, want)
+	}
+	if r := results[filepath.Join(dir, "missing.txt")]; r.Err == nil || r.Matches != nil {
+		t.Errorf("missing file: got %+v", r)
+	}
+
+	matches := results["long"].Matches
+	if len(matches) != 2 || matches[0].Start != 100001 || matches[0].Line != 0 ||
+		matches[1].Start != len(long)-4 || matches[1].Line != 2 || matches[1].LineText != "todo" {
+		t.Errorf("long: got %d matches", len(matches))
+	}
+}
+
+// TestSearchBuffersQuery checks regular expressions, case and the match
+// limit, and that an invalid pattern is refused
+func TestSearchBuffersQuery(t *testing.T) {
+	sources := []buffer.SearchSource{{Name: "b", Buffer: newBuffer(t, "Foo foo f00\nfoo\n")}}
+
+	tests := []struct {
+		q    buffer.SearchQuery
+		want int
+	}{
+		{buffer.SearchQuery{Pattern: "foo"}, 2},
+		{buffer.SearchQuery{Pattern: "foo", IgnoreCase: true}, 3},
+		{buffer.SearchQuery{Pattern: "f[o0]+", Regexp: true}, 3},
+		{buffer.SearchQuery{Pattern: "f[o0]+"}, 0},
+		{buffer.SearchQuery{Pattern: "foo", IgnoreCase: true, MaxMatches: 2}, 2},
+	}
+	for _, tt := range tests {
+		if got := len(search(t, sources, tt.q)["b"].Matches); got != tt.want {
+			t.Errorf("%+v: got %d matches, want %d", tt.q, got, tt.want)
+		}
+	}
+
+	if _, err := buffer.SearchBuffers(context.Background(), sources, buffer.SearchQuery{Pattern: "(", Regexp: true}, 0); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}
@@ -0,0 +1,186 @@
+package buffer
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// SearchSession steps through the occurrences of a literal query from a
+// position, as an incremental search UI does. The current match is held by
+// markers, so the session stays valid across edits and continues from where
+// the match moved to. Searches wrap around the ends of the buffer. Close
+// the session when it is no longer needed.
+type SearchSession struct {
+	gb            *GapBuffer
+	query         string
+	caseSensitive bool
+	wholeWord     bool
+	re            *regexp.Regexp
+
+	start   *Marker // current match, or the position searches start from
+	end     *Marker
+	matched bool
+}
+
+// NewSearchSession returns a case-sensitive session for query starting at pos
+func (gb *GapBuffer) NewSearchSession(query string, pos int) (s *SearchSession, err error) {
+	defer guard("NewSearchSession", pos, gb.length, &err)
+
+	start, err := gb.CreateMarker(pos, GravityRight)
+	if err != nil {
+		return nil, err
+	}
+	end, _ := gb.CreateMarker(pos, GravityLeft)
+	s = &SearchSession{gb: gb, query: query, caseSensitive: true, start: start, end: end}
+	s.compile()
+	return s, nil
+}
+
+// Close removes the markers of the session from the buffer
+func (s *SearchSession) Close() {
+	s.start.Remove()
+	s.end.Remove()
+}
+
+// SetQuery changes the query; the next search starts at the current match
+func (s *SearchSession) SetQuery(query string) {
+	s.query = query
+	s.compile()
+	s.rewind()
+}
+
+// SetCaseSensitive toggles whether letter case must match
+func (s *SearchSession) SetCaseSensitive(on bool) {
+	s.caseSensitive = on
+	s.compile()
+	s.rewind()
+}
+
+// SetWholeWord toggles whether matches must not be part of a longer word
+func (s *SearchSession) SetWholeWord(on bool) {
+	s.wholeWord = on
+	s.rewind()
+}
+
+// Current returns the current match. It reports false if there is none, or
+// if an edit changed the text so that it no longer matches.
+func (s *SearchSession) Current() (Range, bool) {
+	r := Range{Start: s.start.Pos(), End: s.end.Pos()}
+	if !s.matched || r.Empty() {
+		return Range{}, false
+	}
+	m, found := s.find(r.Start, r.End)
+	if !found || m != r {
+		return Range{}, false
+	}
+	return r, true
+}
+
+// NextMatch moves to the first match after the current one, or at or after
+// the start position if there is no current match
+func (s *SearchSession) NextMatch() (Range, bool) {
+	from := s.end.Pos()
+	if !s.matched {
+		from = s.start.Pos()
+	}
+	m, found := s.find(from, s.gb.length)
+	if !found {
+		m, found = s.find(0, s.gb.length)
+	}
+	if found {
+		s.setMatch(m)
+	}
+	return m, found
+}
+
+// PrevMatch moves to the last match starting before the current one
+func (s *SearchSession) PrevMatch() (Range, bool) {
+	m, found := s.findBefore(s.start.Pos())
+	if !found {
+		m, found = s.findBefore(s.gb.length + 1)
+	}
+	if found {
+		s.setMatch(m)
+	}
+	return m, found
+}
+
+// compile builds the regexp for the query and case setting
+func (s *SearchSession) compile() {
+	s.re, _ = SearchQuery{Pattern: s.query, IgnoreCase: !s.caseSensitive}.compile()
+}
+
+// rewind makes the next search start at the current match again
+func (s *SearchSession) rewind() {
+	s.end.SetPos(s.start.Pos())
+	s.matched = false
+}
+
+// setMatch makes m the current match
+func (s *SearchSession) setMatch(m Range) {
+	s.start.SetPos(m.Start)
+	s.end.SetPos(m.End)
+	s.matched = true
+}
+
+// find returns the first match in [from, to)
+func (s *SearchSession) find(from int, to int) (Range, bool) {
+	if s.query == "" {
+		return Range{}, false
+	}
+	for from < to {
+		loc := s.re.FindReaderIndex(&runeReader{gb: s.gb, pos: from, end: to})
+		if loc == nil {
+			return Range{}, false
+		}
+		m := Range{Start: from + loc[0], End: from + loc[1]}
+		if !s.wholeWord || s.isWholeWord(m) {
+			return m, true
+		}
+		_, size := s.gb.runeAfter(m.Start)
+		from = m.Start + size
+	}
+	return Range{}, false
+}
+
+// findBefore returns the last match starting before pos. It searches
+// windows of growing size backwards from pos, so it only reads the text up
+// to the match. Case folding keeps the number of runes, so a match starting
+// in a window ends within a few bytes per rune of the query past it.
+func (s *SearchSession) findBefore(pos int) (Range, bool) {
+	reach := utf8.UTFMax * utf8.RuneCountInString(s.query)
+	for window, to := 4096, pos; to > 0; window *= 2 {
+		from := max(to-window, 0)
+		var last Range
+		found := false
+		for at := from; ; {
+			m, ok := s.find(at, min(to+reach, s.gb.length))
+			if !ok || m.Start >= to {
+				break
+			}
+			last, found = m, true
+			_, size := s.gb.runeAfter(m.Start)
+			at = m.Start + size
+		}
+		if found {
+			return last, true
+		}
+		to = from
+	}
+	return Range{}, false
+}
+
+// isWholeWord reports whether m is not preceded or followed by a word rune
+func (s *SearchSession) isWholeWord(m Range) bool {
+	if m.Start > 0 {
+		if r, _ := s.gb.runeBefore(m.Start); isWordRune(r) {
+			return false
+		}
+	}
+	if m.End < s.gb.length {
+		if r, _ := s.gb.runeAfter(m.End); isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package buffer_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestSearchSession checks stepping forwards and backwards with wrapping,
+// and the case and whole word toggles
+func TestSearchSession(t *testing.T) {
+	gb := newBuffer(t, "Foo foo food foo")
+	s, err := gb.NewSearchSession("foo", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	steps := []struct {
+		next  bool
+		start int
+	}{
+		{true, 4}, {true, 8}, {true, 13}, {true, 4}, {false, 13}, {false, 8},
+	}
+	for i, step := range steps {
+		var m buffer.Range
+		var found bool
+		if step.next {
+			m, found = s.NextMatch()
+		} else {
+			m, found = s.PrevMatch()
+		}
+		if !found || m.Start != step.start || m.End != step.start+3 {
+			t.Fatalf("step %d: got %v, %v, want a match at %d", i, m, found, step.start)
+		}
+	}
+
+	s.SetWholeWord(true)
+	if m, _ := s.NextMatch(); m.Start != 13 {
+		t.Errorf("whole word: got %v", m)
+	}
+	// Toggles search again from the current match, then wrap around
+	s.SetCaseSensitive(false)
+	if m, _ := s.NextMatch(); m.Start != 13 {
+		t.Errorf("ignoring case: got %v", m)
+	}
+	if m, _ := s.NextMatch(); m.Start != 0 {
+		t.Errorf("ignoring case: got %v", m)
+	}
+	s.SetQuery("bar")
+	if _, found := s.NextMatch(); found {
+		t.Error("found a query that does not occur")
+	}
+	if _, err := gb.NewSearchSession("x", 17); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("session past the end: %v", err)
+	}
+}
+
+// TestSearchSessionEdits checks that the current match follows edits and
+// is dropped once it no longer matches
+func TestSearchSessionEdits(t *testing.T) {
+	gb := newBuffer(t, "one two one two")
+	s, _ := gb.NewSearchSession("two", 0)
+	defer s.Close()
+	s.NextMatch()
+
+	gb.InsertAt(0, "zero ")
+	if m, ok := s.Current(); !ok || m != (buffer.Range{Start: 9, End: 12}) {
+		t.Fatalf("after an insert before: got %v, %v", m, ok)
+	}
+	if m, _ := s.NextMatch(); m.Start != 17 {
+		t.Errorf("next after the edit: got %v", m)
+	}
+
+	gb.Replace(17, 18, "T")
+	if _, ok := s.Current(); ok {
+		t.Error("edited match still current")
+	}
+	if m, _ := s.NextMatch(); m.Start != 9 {
+		t.Errorf("next after the match was edited: got %v", m)
+	}
+}
+
+// TestSearchSessionPrevFar checks PrevMatch finding a match far before the
+// current position
+func TestSearchSessionPrevFar(t *testing.T) {
+	gb := newBuffer(t, "needle"+strings.Repeat(" ", 100000))
+	s, _ := gb.NewSearchSession("needle", gb.Length())
+	defer s.Close()
+	if m, found := s.PrevMatch(); !found || m.Start != 0 {
+		t.Errorf("got %v, %v", m, found)
+	}
+}
@@ -0,0 +1,141 @@
+package buffer
+
+import (
+	"math"
+	"unicode/utf8"
+)
+
+// EditDistanceTo returns the Levenshtein distance in runes between the text
+// of the buffer and s. With maxCost >= 0 it gives up as soon as the distance
+// is known to exceed maxCost and returns maxCost+1 and false; only the band
+// of maxCost diagonals around the main one is computed. The buffer is
+// streamed chunk by chunk and the common prefix and suffix are skipped
+// before comparing, so near-identical texts are cheap.
+func (gb *GapBuffer) EditDistanceTo(s string, maxCost int) (dist int, ok bool) {
+	start, end, s := gb.trimCommon(s)
+
+	limit := math.MaxInt / 2
+	if maxCost >= 0 {
+		limit = maxCost + 1
+	}
+
+	want := []rune(s)
+	m := len(want)
+	if d := gb.runeCountIn(start, end) - m; d >= limit || -d >= limit {
+		return limit, false
+	}
+
+	// prev and cur are rows of the distance matrix, capped at limit. Cells
+	// right of the band of the current row still hold limit from the start.
+	prev := make([]int, m+1)
+	cur := make([]int, m+1)
+	for j := range prev {
+		prev[j] = min(j, limit)
+		cur[j] = limit
+	}
+
+	i := 0
+	exceeded := false
+	gb.scanRunes(start, end, func(_ int, c rune, _ int) bool {
+		i++
+		lo, hi := 1, m
+		if maxCost >= 0 {
+			lo, hi = max(1, i-maxCost), min(m, i+maxCost)
+		}
+
+		rowMin := limit
+		cur[0] = min(i, limit)
+		if lo > 1 {
+			cur[lo-1] = limit
+		} else {
+			rowMin = cur[0]
+		}
+		for j := lo; j <= hi; j++ {
+			cost := prev[j-1]
+			if want[j-1] != c {
+				cost = min(prev[j-1], prev[j], cur[j-1]) + 1
+			}
+			cur[j] = min(cost, limit)
+			rowMin = min(rowMin, cur[j])
+		}
+
+		prev, cur = cur, prev
+		exceeded = rowMin >= limit
+		return !exceeded
+	})
+	if exceeded || prev[m] >= limit {
+		return limit, false
+	}
+	return prev[m], true
+}
+
+// Similarity returns how similar the text of the buffer is to s, from 0
+// for nothing in common to 1 for identical texts: one minus the edit
+// distance divided by the length of the longer text, in runes.
+func (gb *GapBuffer) Similarity(s string) float64 {
+	dist, _ := gb.EditDistanceTo(s, -1)
+	longest := max(gb.runeCountIn(0, gb.length), utf8.RuneCountInString(s))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(dist)/float64(longest)
+}
+
+// trimCommon skips the prefix and suffix the text of the buffer shares with
+// s, cut where both sides start a rune, and returns the buffer range and the part of s left
+func (gb *GapBuffer) trimCommon(s string) (start int, end int, rest string) {
+	gb.forEachChunk(0, gb.length, func(_ int, text string) bool {
+		n := commonPrefix(text, s[start:])
+		start += n
+		return n == len(text)
+	})
+	for start > 0 && ((start < len(s) && !utf8.RuneStart(s[start])) || !gb.runeStart(start)) {
+		start--
+	}
+
+	suffix := 0
+	limit := min(gb.length, len(s)) - start
+	gb.forEachChunkReverse(start, gb.length, func(_ int, text string) bool {
+		n := commonSuffix(text, s[start:len(s)-suffix])
+		suffix += n
+		return n == len(text) && suffix < limit
+	})
+	suffix = min(suffix, limit)
+	for suffix > 0 && (!utf8.RuneStart(s[len(s)-suffix]) || !gb.runeStart(gb.length-suffix)) {
+		suffix--
+	}
+	return start, gb.length - suffix, s[start : len(s)-suffix]
+}
+
+// commonPrefix returns the length of the longest common prefix of a and b
+func commonPrefix(a, b string) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// commonSuffix returns the length of the longest common suffix of a and b
+func commonSuffix(a, b string) int {
+	n := min(len(a), len(b))
+	for i := 1; i <= n; i++ {
+		if a[len(a)-i] != b[len(b)-i] {
+			return i - 1
+		}
+	}
+	return n
+}
+
+// runeCountIn returns the number of runes in [start, end), counting invalid
+// bytes as one rune each
+func (gb *GapBuffer) runeCountIn(start int, end int) int {
+	n := 0
+	gb.scanRunes(start, end, func(int, rune, int) bool {
+		n++
+		return true
+	})
+	return n
+}
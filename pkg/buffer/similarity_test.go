@@ -0,0 +1,88 @@
+package buffer_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// levenshtein is the textbook edit distance in runes
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// TestEditDistanceTo checks the distance against the textbook algorithm on
+// random texts, with and without a maximum cost
+func TestEditDistanceTo(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	alphabet := []string{"a", "b", "é", "\n", "日"}
+	random := func(n int) string {
+		s := ""
+		for i := 0; i < n; i++ {
+			s += alphabet[rng.Intn(len(alphabet))]
+		}
+		return s
+	}
+
+	for i := 0; i < 300; i++ {
+		text := random(rng.Intn(30))
+		other := random(rng.Intn(30))
+		if rng.Intn(2) == 0 {
+			// Mostly shared text exercises the trimmed prefix and suffix
+			other = text[:len(text)/2] + other + text[len(text)/2:]
+		}
+		gb := buffer.NewWithChunkSize(4)
+		gb.InsertAt(0, text)
+		gb.RandomizeLayout(int64(i))
+
+		want := levenshtein(text, other)
+		if got, ok := gb.EditDistanceTo(other, -1); got != want || !ok {
+			t.Fatalf("%q to %q: got %d, %v, want %d", text, other, got, ok, want)
+		}
+		maxCost := rng.Intn(10)
+		got, ok := gb.EditDistanceTo(other, maxCost)
+		if want <= maxCost && (got != want || !ok) || want > maxCost && (got != maxCost+1 || ok) {
+			t.Fatalf("%q to %q within %d: got %d, %v, want %d", text, other, maxCost, got, ok, want)
+		}
+	}
+}
+
+// TestSimilarity checks the score at its bounds and in between
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		text, other string
+		want        float64
+	}{
+		{"", "", 1},
+		{"same", "same", 1},
+		{"abcd", "wxyz", 0},
+		{"kitten", "sitting", 1 - 3.0/7},
+		{"été", "ete", 1 - 2.0/3},
+	}
+	for _, tt := range tests {
+		gb := buffer.New()
+		gb.InsertAt(0, tt.text)
+		if got := gb.Similarity(tt.other); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%q and %q: got %v, want %v", tt.text, tt.other, got, tt.want)
+		}
+	}
+}
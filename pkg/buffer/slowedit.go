@@ -0,0 +1,81 @@
+package buffer
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoSlowEditReport is returned by SyncBuffer.SetSlowEditPolicy for a
+// policy with a threshold but no Report callback
+var ErrNoSlowEditReport = errors.New("slow edit policy without Report")
+
+// SlowEdit describes a write call that held the lock of a SyncBuffer
+// longer than the slow edit threshold
+type SlowEdit struct {
+	Op       string        // Method called, such as "InsertAt" or "ApplyEdits"
+	Start    int           // Start of the range written, -1 if the call takes none
+	End      int           // End of the range written, -1 if the call takes none
+	Inserted int           // Length of the inserted text, -1 if not known up front
+	Duration time.Duration // Time the lock has been held so far, or in all once Done
+	Done     bool          // Whether the call has returned
+}
+
+// SlowEditPolicy reports write calls that hold the lock of a SyncBuffer
+// longer than Threshold, to help find accidental O(n) work on a UI thread:
+// a costly change hook or listener, a huge paste, a rebuild the edit
+// triggers. Report is called from a timer as soon as a call passes the
+// threshold, while it still holds the lock, so that a call that never
+// returns is reported too, and once more with Done set when the call
+// returns. It runs on a goroutine of its own, possibly concurrently with
+// another report, and must not wait for the buffer.
+type SlowEditPolicy struct {
+	Threshold time.Duration  // Duration above which calls are reported, 0 for no check
+	Report    func(SlowEdit) // Called with the slow call; required with a threshold
+}
+
+// SetSlowEditPolicy sets the policy checked on every write call. A
+// threshold without Report is refused with ErrNoSlowEditReport.
+func (s *SyncBuffer) SetSlowEditPolicy(p SlowEditPolicy) error {
+	if p.Threshold > 0 && p.Report == nil {
+		return ErrNoSlowEditReport
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.slowEdit = p
+	return nil
+}
+
+// SlowEditPolicy returns the policy set with SetSlowEditPolicy
+func (s *SyncBuffer) SlowEditPolicy() SlowEditPolicy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.slowEdit
+}
+
+// watch starts timing a write call that has just taken the lock and
+// returns the function to call once the lock is released. The timer
+// reports the call as soon as it passes the threshold; the returned
+// function stops it and reports the whole call if it was slow.
+func (s *SyncBuffer) watch(op string, start int, end int, inserted int) func() {
+	p := s.slowEdit
+	if p.Threshold <= 0 {
+		return func() {}
+	}
+	e := SlowEdit{Op: op, Start: start, End: end, Inserted: inserted}
+	began := time.Now()
+	timer := time.AfterFunc(p.Threshold, func() {
+		running := e
+		running.Duration = time.Since(began)
+		p.Report(running)
+	})
+	return func() {
+		d := time.Since(began)
+		timer.Stop()
+		if d > p.Threshold {
+			e.Duration, e.Done = d, true
+			p.Report(e)
+		}
+	}
+}
@@ -0,0 +1,542 @@
+package buffer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+	"strings"
+	"unsafe"
+)
+
+// ErrInvalidSnapshot is returned when a snapshot cannot be loaded
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+// SNAPSHOT_VERSION is the version of the snapshot format written by SaveTo.
+// It only changes with the framing; new content goes into new sections.
+const SNAPSHOT_VERSION = 2
+
+// snapshotMagic starts every snapshot
+const snapshotMagic = "GBSNAP"
+
+// maxSnapshotChunkSize bounds the chunk size accepted from a snapshot, so a
+// corrupted header cannot make LoadFrom allocate without limit
+const maxSnapshotChunkSize = 1 << 30
+
+// Snapshot sections. A reader skips the sections it does not know unless
+// the lowest bit of their ID marks them as required.
+const (
+	sectionEnd      = 0 // ends the list of sections
+	sectionRequired = 1 // bit set in the IDs of sections a reader must understand
+
+	sectionText      = 1 // chunk size and the text of every chunk, required
+	sectionIndex     = 2 // newline and rune count of every chunk, recounted if missing
+	sectionChunkSums = 4 // size and CRC-32 of every chunk, read by RecoverSnapshot
+)
+
+// SaveTo writes the text of the buffer to w in the snapshot format read by
+// LoadFrom. Next to the text it stores the derived counts the indexes are
+// built from: newlines, which make up the line index, and runes per chunk.
+// Reopening a large snapshot then skips the scan that rebuilding them
+// would take. It returns the number of bytes written.
+//
+// The format is the magic "GBSNAP" and the format version as a uvarint,
+// then a list of sections, each its ID and payload length as uvarints
+// followed by the payload, ended by ID 0, and finally the CRC-32 (IEEE) of
+// everything before it, big-endian. A reader skips sections it does not
+// know unless the lowest bit of their ID is set, so later releases can add
+// optional sections, such as history or bookmarks, that older ones still
+// load around. The chunk checksum section comes first and holds the number
+// of chunks, the length and CRC-32 of every chunk and the CRC-32 of the
+// section itself, so RecoverSnapshot can tell which chunks survived damage.
+// The text section holds the chunk size, the number of chunks and the
+// length and text of every chunk; the index section the number of chunks
+// and the newline and rune count of every chunk.
+func (gb *GapBuffer) SaveTo(w io.Writer) (n int64, err error) {
+	defer guard("SaveTo", noPos, gb.length, &err)
+
+	cw := &countingWriter{w: w}
+	crc := crc32.NewIEEE()
+	bw := bufio.NewWriter(io.MultiWriter(cw, crc))
+
+	var scratch [binary.MaxVarintLen64]byte
+	putUvarint := func(v int) {
+		bw.Write(scratch[:binary.PutUvarint(scratch[:], uint64(v))])
+	}
+	eachChunk := func(fn func(chunk *payload, own measures)) {
+		gb.tree.ascend(math.MinInt, math.MaxInt, func(_ int, chunk *payload, own measures) bool {
+			fn(chunk, own)
+			return true
+		})
+	}
+
+	bw.WriteString(snapshotMagic)
+	putUvarint(SNAPSHOT_VERSION)
+
+	count := gb.tree.count()
+	sums := crc32.NewIEEE()
+	size := uvarintLen(count) + crc32.Size
+	eachChunk(func(chunk *payload, _ measures) {
+		size += uvarintLen(len(chunk.Text)) + crc32.Size
+	})
+	putUvarint(sectionChunkSums)
+	putUvarint(size)
+	sw := io.MultiWriter(bw, sums)
+	sw.Write(scratch[:binary.PutUvarint(scratch[:], uint64(count))])
+	eachChunk(func(chunk *payload, _ measures) {
+		sw.Write(scratch[:binary.PutUvarint(scratch[:], uint64(len(chunk.Text)))])
+		sw.Write(binary.BigEndian.AppendUint32(scratch[:0], chunkCRC(chunk.Text)))
+	})
+	bw.Write(sums.Sum(nil))
+
+	size = uvarintLen(gb.chunkSize) + uvarintLen(count)
+	eachChunk(func(chunk *payload, _ measures) {
+		size += uvarintLen(len(chunk.Text)) + len(chunk.Text)
+	})
+	putUvarint(sectionText)
+	putUvarint(size)
+	putUvarint(gb.chunkSize)
+	putUvarint(count)
+	eachChunk(func(chunk *payload, _ measures) {
+		putUvarint(len(chunk.Text))
+		bw.WriteString(chunk.Text)
+	})
+
+	size = uvarintLen(count)
+	eachChunk(func(_ *payload, own measures) {
+		size += uvarintLen(own.lines) + uvarintLen(own.runes)
+	})
+	putUvarint(sectionIndex)
+	putUvarint(size)
+	putUvarint(count)
+	eachChunk(func(_ *payload, own measures) {
+		putUvarint(own.lines)
+		putUvarint(own.runes)
+	})
+
+	putUvarint(sectionEnd)
+	if err := bw.Flush(); err != nil {
+		return cw.n, err
+	}
+
+	_, err = w.Write(crc.Sum(nil))
+	return cw.n + crc32.Size, err
+}
+
+// chunkCRC returns the CRC-32 of the text of a chunk
+func chunkCRC(text string) uint32 {
+	return crc32.ChecksumIEEE(unsafe.Slice(unsafe.StringData(text), len(text)))
+}
+
+// uvarintLen returns the number of bytes v takes as a uvarint
+func uvarintLen(v int) int {
+	var scratch [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(scratch[:], uint64(v))
+}
+
+// snapshotChunk is a chunk read from a snapshot with its stored counts,
+// -1 where they are unknown
+type snapshotChunk struct {
+	text  string
+	lines int
+	runes int
+}
+
+// chunkSum is the stored size and CRC-32 of a chunk
+type chunkSum struct {
+	size int
+	sum  uint32
+}
+
+// snapshotContent is the text read from a snapshot, as far as it could be
+// read
+type snapshotContent struct {
+	chunkSize int
+	chunks    []snapshotChunk
+	complete  bool       // all chunks were read
+	sums      []chunkSum // checksums of all chunks, nil if missing or damaged
+}
+
+// LoadFrom reads a buffer written by SaveTo, in the current format or the
+// one of version 1. The chunks are restored as they were saved, with the
+// stored counts instead of rescanning the text; the checksum guards them
+// against corruption. Unknown optional sections are skipped, and a missing
+// index is rebuilt from the text.
+func LoadFrom(r io.Reader) (gb *GapBuffer, err error) {
+	defer guard("LoadFrom", noPos, noPos, &err)
+
+	crc := crc32.NewIEEE()
+	br := &snapshotReader{r: bufio.NewReader(r), crc: crc}
+
+	magic := make([]byte, len(snapshotMagic))
+	br.read(magic)
+	if br.err == nil && string(magic) != snapshotMagic {
+		return nil, ErrInvalidSnapshot
+	}
+	content, err := br.content()
+	if err != nil {
+		return nil, err
+	}
+	if br.err != nil {
+		return nil, br.err
+	}
+
+	sum := crc.Sum32()
+	var stored [crc32.Size]byte
+	if _, err := io.ReadFull(br.r, stored[:]); err != nil {
+		return nil, ErrInvalidSnapshot
+	}
+	if binary.BigEndian.Uint32(stored[:]) != sum {
+		return nil, ErrInvalidSnapshot
+	}
+
+	return content.buffer(), nil
+}
+
+// RecoverSnapshot loads as much as it can of a snapshot that LoadFrom
+// rejects as damaged, for getting most of a document back rather than
+// nothing. Only a snapshot whose header is unreadable fails; otherwise
+// the chunks that survived are joined in order, rescanned rather than
+// trusting the stored counts, and damage describes what is missing. An
+// intact snapshot loads as with LoadFrom, with no damage.
+//
+// Chunks are checked against the checksum section SaveTo writes in front
+// of the text, which also records the length of chunks that are lost, so
+// damage.Lost covers them exactly. Snapshots without the section, such
+// as those of version 1, keep every chunk that could be read, with
+// damage.Unverified set if the file checksum fails.
+func RecoverSnapshot(r io.Reader) (gb *GapBuffer, damage SnapshotDamage, err error) {
+	defer guard("RecoverSnapshot", noPos, noPos, &err)
+
+	crc := crc32.NewIEEE()
+	br := &snapshotReader{r: bufio.NewReader(r), crc: crc}
+	magic := make([]byte, len(snapshotMagic))
+	br.read(magic)
+	if br.err == nil && string(magic) != snapshotMagic {
+		return nil, damage, ErrInvalidSnapshot
+	}
+	content, err := br.content()
+	if err != nil {
+		return nil, damage, err
+	}
+
+	if br.err == nil {
+		sum := crc.Sum32()
+		var stored [crc32.Size]byte
+		if _, err := io.ReadFull(br.r, stored[:]); err == nil && binary.BigEndian.Uint32(stored[:]) == sum {
+			return content.buffer(), damage, nil
+		}
+	}
+
+	// Stored counts may be as damaged as anything else
+	for i := range content.chunks {
+		content.chunks[i].lines, content.chunks[i].runes = -1, -1
+	}
+	if content.chunkSize <= 0 || content.chunkSize > maxSnapshotChunkSize {
+		content.chunkSize = DEFAULT_CHUNK_SIZE
+	}
+	damage.Truncated = !content.complete
+
+	sums := content.sums
+	if len(sums) < len(content.chunks) || (content.complete && len(sums) != len(content.chunks)) {
+		damage.Unverified = true
+		return content.buffer(), damage, nil
+	}
+	kept := content.chunks[:0]
+	pos := 0
+	for i, cs := range sums {
+		if i < len(content.chunks) {
+			if c := content.chunks[i]; len(c.text) == cs.size && chunkCRC(c.text) == cs.sum {
+				kept = append(kept, c)
+				pos += cs.size
+				continue
+			}
+		}
+		if n := len(damage.Lost); n > 0 && damage.Lost[n-1].End == pos {
+			damage.Lost[n-1].End += cs.size
+		} else {
+			damage.Lost = append(damage.Lost, Range{Start: pos, End: pos + cs.size})
+		}
+		pos += cs.size
+	}
+	content.chunks = kept
+	return content.buffer(), damage, nil
+}
+
+// SnapshotDamage describes what RecoverSnapshot could not restore
+type SnapshotDamage struct {
+	// Lost holds the ranges of the saved text that were dropped, in the
+	// positions of the saved buffer, in order. The recovered buffer holds
+	// the rest of the text, so a lost range starts there at its Start
+	// minus the length of the ranges before it.
+	Lost []Range
+	// Truncated reports that the text ended early, cut off or with its
+	// framing broken. Without chunk checksums, how much is missing after
+	// the recovered text is unknown.
+	Truncated bool
+	// Unverified reports that the snapshot is damaged but no chunk
+	// checksums were available, so the recovered text may be wrong
+	Unverified bool
+}
+
+// Damaged reports whether anything was lost or may be wrong
+func (d SnapshotDamage) Damaged() bool {
+	return len(d.Lost) > 0 || d.Truncated || d.Unverified
+}
+
+// content reads the version and the text of a snapshot after the magic.
+// Damage found along the way is left in s.err with the content read up to
+// it; only a missing or unknown version is returned as an error.
+func (s *snapshotReader) content() (snapshotContent, error) {
+	switch version := s.uvarint(); {
+	case s.err != nil:
+		return snapshotContent{}, s.err
+	case version == 1:
+		return s.chunksV1(), nil
+	case version == SNAPSHOT_VERSION:
+		return s.sections(), nil
+	}
+	return snapshotContent{}, ErrInvalidSnapshot
+}
+
+// buffer returns a buffer holding the chunks, using the stored counts that
+// are known
+func (c snapshotContent) buffer() *GapBuffer {
+	gb := NewWithChunkSize(c.chunkSize)
+	pos := 0
+	for _, c := range c.chunks {
+		m := measures{size: len(c.text), lines: c.lines, units: textUnits(c.text), runes: c.runes}
+		if m.lines < 0 {
+			m.lines = strings.Count(c.text, "\n")
+		}
+		if m.runes < 0 {
+			m.runes = textRunes(c.text)
+		}
+		m.ink = textInk(c.text, m.lines)
+		gb.tree.insertMeasured(pos, payload{Text: c.text}, m)
+		pos += m.size
+	}
+	gb.length = pos
+	gb.gapStart = pos
+	gb.gapEnd = pos + DEFAULT_GAP_SIZE
+	return gb
+}
+
+// chunksV1 reads the chunk size and chunks of a version 1 snapshot, which
+// are stored one after the other with their counts
+func (s *snapshotReader) chunksV1() (c snapshotContent) {
+	c.chunkSize = s.chunkSize()
+	count := s.uvarint()
+	for i := 0; i < count && s.err == nil; i++ {
+		size := s.uvarint()
+		lines := s.uvarint()
+		s.uvarint() // rune count, recounted since version 1 writers counted split runes differently
+		text := s.chunkText(c.chunkSize, size)
+		if s.err == nil && lines > size {
+			s.err = ErrInvalidSnapshot
+		}
+		if s.err == nil {
+			c.chunks = append(c.chunks, snapshotChunk{text: text, lines: lines, runes: -1})
+		}
+	}
+	c.complete = s.err == nil
+	return c
+}
+
+// sections reads the sections of a snapshot up to the end marker and
+// returns the text they hold
+func (s *snapshotReader) sections() (c snapshotContent) {
+	haveText := false
+	var counts [][2]int
+	for s.err == nil {
+		id := s.uvarint()
+		if id == sectionEnd || s.err != nil {
+			break
+		}
+		size := s.uvarint()
+		start := s.n
+		switch id {
+		case sectionText:
+			haveText = true
+			c.chunkSize = s.chunkSize()
+			count := s.uvarint()
+			for i := 0; i < count && s.err == nil; i++ {
+				text := s.chunkText(c.chunkSize, s.uvarint())
+				if s.err == nil {
+					c.chunks = append(c.chunks, snapshotChunk{text: text, lines: -1, runes: -1})
+				}
+			}
+			c.complete = s.err == nil
+		case sectionChunkSums:
+			var payload bytes.Buffer
+			s.copyTo(&payload, size)
+			c.sums = parseChunkSums(payload.Bytes())
+		case sectionIndex:
+			count := s.uvarint()
+			for i := 0; i < count && s.err == nil; i++ {
+				counts = append(counts, [2]int{s.uvarint(), s.uvarint()})
+			}
+		default:
+			if id&sectionRequired != 0 {
+				s.fail(fmt.Errorf("%w: unknown required section %d", ErrInvalidSnapshot, id))
+				break
+			}
+			s.skip(size)
+		}
+		if s.err == nil && s.n-start != size {
+			s.err = ErrInvalidSnapshot
+		}
+	}
+	if s.err == nil && !haveText {
+		s.err = ErrInvalidSnapshot
+	}
+	if s.err != nil || len(counts) != len(c.chunks) {
+		return c
+	}
+	for i, n := range counts {
+		if n[0] > len(c.chunks[i].text) || n[1] > len(c.chunks[i].text) {
+			s.err = ErrInvalidSnapshot
+			break
+		}
+		c.chunks[i].lines, c.chunks[i].runes = n[0], n[1]
+	}
+	return c
+}
+
+// parseChunkSums decodes the payload of a chunk checksum section,
+// returning nil if it is damaged
+func parseChunkSums(b []byte) []chunkSum {
+	if len(b) < crc32.Size {
+		return nil
+	}
+	b, own := b[:len(b)-crc32.Size], b[len(b)-crc32.Size:]
+	if crc32.ChecksumIEEE(b) != binary.BigEndian.Uint32(own) {
+		return nil
+	}
+	count, n := binary.Uvarint(b)
+	if n <= 0 {
+		return nil
+	}
+	b = b[n:]
+	var sums []chunkSum
+	for ; count > 0; count-- {
+		size, n := binary.Uvarint(b)
+		if n <= 0 || len(b) < n+crc32.Size || size > maxSnapshotChunkSize {
+			return nil
+		}
+		sums = append(sums, chunkSum{size: int(size), sum: binary.BigEndian.Uint32(b[n:])})
+		b = b[n+crc32.Size:]
+	}
+	if len(b) != 0 {
+		return nil
+	}
+	return sums
+}
+
+// snapshotReader reads snapshot fields while checksumming and counting
+// them. The first error sticks; later reads do nothing.
+type snapshotReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+	n   int // bytes read
+	err error
+}
+
+// read fills p, turning a truncated stream into ErrInvalidSnapshot
+func (s *snapshotReader) read(p []byte) {
+	if s.err != nil {
+		return
+	}
+	if _, err := io.ReadFull(s.r, p); err != nil {
+		s.fail(err)
+		return
+	}
+	s.crc.Write(p)
+	s.n += len(p)
+}
+
+// skip reads past n bytes
+func (s *snapshotReader) skip(n int) {
+	s.copyTo(io.Discard, n)
+}
+
+// copyTo copies the next n bytes to w
+func (s *snapshotReader) copyTo(w io.Writer, n int) {
+	if s.err != nil {
+		return
+	}
+	copied, err := io.CopyN(io.MultiWriter(s.crc, w), s.r, int64(n))
+	s.n += int(copied)
+	if err != nil {
+		s.fail(err)
+	}
+}
+
+// chunkSize reads a chunk size, which must be in the range LoadFrom accepts
+func (s *snapshotReader) chunkSize() int {
+	size := s.uvarint()
+	if s.err == nil && (size <= 0 || size > maxSnapshotChunkSize) {
+		s.err = ErrInvalidSnapshot
+	}
+	return size
+}
+
+// chunkText reads the text of a chunk of size bytes in a snapshot with the
+// given chunk size
+func (s *snapshotReader) chunkText(chunkSize int, size int) string {
+	if s.err == nil && (size <= 0 || size > max(chunkSize, FROZEN_CHUNK_SIZE)) {
+		s.err = ErrInvalidSnapshot
+	}
+	if s.err != nil {
+		return ""
+	}
+	buf := make([]byte, size)
+	s.read(buf)
+	return string(buf)
+}
+
+// uvarint reads a uvarint that must fit in an int
+func (s *snapshotReader) uvarint() int {
+	if s.err != nil {
+		return 0
+	}
+	var buf [binary.MaxVarintLen64]byte
+	n := 0
+	for {
+		b, err := s.r.ReadByte()
+		if err != nil {
+			s.fail(err)
+			return 0
+		}
+		buf[n] = b
+		n++
+		if b < 0x80 {
+			break
+		}
+		if n == len(buf) {
+			s.err = ErrInvalidSnapshot
+			return 0
+		}
+	}
+	s.crc.Write(buf[:n])
+	s.n += n
+	v, _ := binary.Uvarint(buf[:n])
+	if v > math.MaxInt {
+		s.err = ErrInvalidSnapshot
+		return 0
+	}
+	return int(v)
+}
+
+// fail records err, reporting a stream ending early as an invalid snapshot
+func (s *snapshotReader) fail(err error) {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = ErrInvalidSnapshot
+	}
+	s.err = err
+}
@@ -0,0 +1,201 @@
+package buffer_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// uvarints encodes values as consecutive uvarints
+func uvarints(values ...int) []byte {
+	var b []byte
+	for _, v := range values {
+		b = binary.AppendUvarint(b, uint64(v))
+	}
+	return b
+}
+
+// section encodes a snapshot section with its ID and payload length
+func section(id int, payload []byte) []byte {
+	return append(uvarints(id, len(payload)), payload...)
+}
+
+// textSection encodes the text section of chunks saved with chunkSize
+func textSection(chunkSize int, chunks ...string) []byte {
+	payload := uvarints(chunkSize, len(chunks))
+	for _, c := range chunks {
+		payload = append(append(payload, uvarints(len(c))...), c...)
+	}
+	return section(1, payload)
+}
+
+// snapshotFile frames sections as a snapshot of the given format version
+func snapshotFile(version int, sections ...[]byte) []byte {
+	b := append([]byte("GBSNAP"), uvarints(version)...)
+	for _, s := range sections {
+		b = append(b, s...)
+	}
+	b = append(b, 0)
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b))
+}
+
+// TestSnapshotRoundTrip checks that an edited buffer loads back with the
+// same text, chunks and counts
+func TestSnapshotRoundTrip(t *testing.T) {
+	gb := buffer.NewWithChunkSize(64)
+	gb.InsertAt(0, strings.Repeat("héllo wörld\r\n", 40))
+	gb.InsertAt(100, "\n\n\xff")
+	gb.DeleteAt(30, 50)
+
+	var buf bytes.Buffer
+	n, err := gb.SaveTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatalf("wrote %d of %d bytes, %v", n, buf.Len(), err)
+	}
+	loaded, err := buffer.LoadFrom(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(loaded.Bytes(), gb.Bytes()) {
+		t.Fatal("text differs")
+	}
+	if loaded.LineCount() != gb.LineCount() || loaded.RuneLength() != gb.RuneLength() {
+		t.Errorf("got %d lines and %d runes, want %d and %d",
+			loaded.LineCount(), loaded.RuneLength(), gb.LineCount(), gb.RuneLength())
+	}
+	if len(loaded.Chunks()) != len(gb.Chunks()) {
+		t.Errorf("got %d chunks, want %d", len(loaded.Chunks()), len(gb.Chunks()))
+	}
+}
+
+// TestSnapshotIndex checks that stored counts are taken as they are, that
+// a missing index is rebuilt and that impossible counts are refused
+func TestSnapshotIndex(t *testing.T) {
+	chunks := []string{"a\nb\n", "cé\n"}
+	text := textSection(16, chunks...)
+
+	gb, err := buffer.LoadFrom(bytes.NewReader(snapshotFile(2, text)))
+	if err != nil || gb.GetText() != "a\nb\ncé\n" || gb.LineCount() != 4 || gb.RuneLength() != 7 {
+		t.Fatalf("without an index: got %q, %d lines, %v", gb.GetText(), gb.LineCount(), err)
+	}
+
+	// Counts off by one show that the text was not rescanned
+	index := section(2, uvarints(2, 2, 4, 2, 3))
+	gb, err = buffer.LoadFrom(bytes.NewReader(snapshotFile(2, text, index)))
+	if err != nil || gb.LineCount() != 5 || gb.RuneLength() != 7 {
+		t.Fatalf("with an index: got %d lines, %d runes, %v", gb.LineCount(), gb.RuneLength(), err)
+	}
+
+	index = section(2, uvarints(2, 5, 4, 1, 3))
+	if _, err := buffer.LoadFrom(bytes.NewReader(snapshotFile(2, text, index))); !errors.Is(err, buffer.ErrInvalidSnapshot) {
+		t.Errorf("more newlines than bytes: %v", err)
+	}
+}
+
+// TestSnapshotVersions checks that version 1 snapshots and unknown
+// optional sections load, and that unknown required sections, unknown
+// versions and corrupted streams are refused
+func TestSnapshotVersions(t *testing.T) {
+	var buf bytes.Buffer
+	newBuffer(t, "saved").SaveTo(&buf)
+	if want := append([]byte("GBSNAP"), uvarints(buffer.SNAPSHOT_VERSION)...); !bytes.HasPrefix(buf.Bytes(), want) {
+		t.Errorf("SaveTo wrote header %q", buf.Bytes()[:len(want)])
+	}
+
+	// Version 1 stores each chunk with its counts, without sections
+	v1 := append([]byte("GBSNAP"), uvarints(1, 16, 2, 4, 2, 4)...)
+	v1 = append(append(append(v1, "a\nb\n"...), uvarints(4, 1, 3)...), "cé\n"...)
+	v1 = binary.BigEndian.AppendUint32(v1, crc32.ChecksumIEEE(v1))
+	if gb, err := buffer.LoadFrom(bytes.NewReader(v1)); err != nil || gb.GetText() != "a\nb\ncé\n" || gb.LineCount() != 4 {
+		t.Errorf("version 1: got %v, %v", gb, err)
+	}
+
+	text := textSection(16, "new\n", "text")
+	future := section(6, []byte("bookmarks of a newer release"))
+	if gb, err := buffer.LoadFrom(bytes.NewReader(snapshotFile(2, future, text, future))); err != nil || gb.GetText() != "new\ntext" {
+		t.Errorf("unknown optional section: got %v, %v", gb, err)
+	}
+
+	corrupted := snapshotFile(2, text)
+	corrupted[12] ^= 1
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"unknown required section", snapshotFile(2, text, section(7, []byte("x")))},
+		{"unknown version", snapshotFile(3, text)},
+		{"no text", snapshotFile(2)},
+		{"corrupted", corrupted},
+		{"truncated", snapshotFile(2, text)[:20]},
+	}
+	for _, tt := range tests {
+		if _, err := buffer.LoadFrom(bytes.NewReader(tt.data)); !errors.Is(err, buffer.ErrInvalidSnapshot) {
+			t.Errorf("%s: got %v", tt.name, err)
+		}
+	}
+}
+
+// TestRecoverSnapshot damages a saved snapshot in a chunk and by
+// truncation and checks what is recovered and reported lost
+func TestRecoverSnapshot(t *testing.T) {
+	gb := buffer.NewWithChunkSize(16)
+	for i := 0; i < 40; i++ {
+		gb.InsertAt(gb.Length(), fmt.Sprintf("line %02d\n", i))
+	}
+	var buf bytes.Buffer
+	gb.SaveTo(&buf)
+	saved, text, chunks := buf.Bytes(), gb.GetText(), gb.Chunks()
+
+	recovered, damage, err := buffer.RecoverSnapshot(bytes.NewReader(saved))
+	if err != nil || recovered.GetText() != text || damage.Damaged() {
+		t.Fatalf("intact: got %d bytes, %+v, %v", recovered.Length(), damage, err)
+	}
+
+	// A flipped byte in a chunk loses that chunk only
+	lost := chunks[3]
+	damaged := bytes.Clone(saved)
+	damaged[bytes.Index(damaged, []byte(lost.Text))] ^= 0x20
+	if _, err := buffer.LoadFrom(bytes.NewReader(damaged)); !errors.Is(err, buffer.ErrInvalidSnapshot) {
+		t.Errorf("LoadFrom took a damaged snapshot: %v", err)
+	}
+	recovered, damage, err = buffer.RecoverSnapshot(bytes.NewReader(damaged))
+	want := buffer.SnapshotDamage{Lost: []buffer.Range{{Start: lost.Pos, End: lost.Pos + len(lost.Text)}}}
+	if err != nil || !reflect.DeepEqual(damage, want) {
+		t.Fatalf("damaged chunk: got %+v, %v, want %+v", damage, err, want)
+	}
+	if got := recovered.GetText(); got != text[:lost.Pos]+text[lost.Pos+len(lost.Text):] || recovered.LineCount() != strings.Count(got, "\n")+1 {
+		t.Errorf("damaged chunk: recovered %q", got)
+	}
+
+	// Truncated in a chunk, the rest of the text is known to be lost
+	cut := chunks[10]
+	truncated := saved[:bytes.Index(saved, []byte(cut.Text))+5]
+	recovered, damage, err = buffer.RecoverSnapshot(bytes.NewReader(truncated))
+	want = buffer.SnapshotDamage{Lost: []buffer.Range{{Start: cut.Pos, End: len(text)}}, Truncated: true}
+	if err != nil || !reflect.DeepEqual(damage, want) || recovered.GetText() != text[:cut.Pos] {
+		t.Errorf("truncated: got %d bytes, %+v, %v, want %+v", recovered.Length(), damage, err, want)
+	}
+
+	if _, _, err := buffer.RecoverSnapshot(strings.NewReader("NOTSNAP")); !errors.Is(err, buffer.ErrInvalidSnapshot) {
+		t.Errorf("bad magic: got %v", err)
+	}
+}
+
+// TestRecoverSnapshotUnverified checks that a damaged snapshot without
+// chunk checksums keeps what it can read and says it is unverified
+func TestRecoverSnapshotUnverified(t *testing.T) {
+	data := snapshotFile(2, textSection(16, "first\n", "second\n"))
+	data[len(data)-1] ^= 1
+	gb, damage, err := buffer.RecoverSnapshot(bytes.NewReader(data))
+	want := buffer.SnapshotDamage{Unverified: true}
+	if err != nil || !reflect.DeepEqual(damage, want) || gb.GetText() != "first\nsecond\n" {
+		t.Errorf("got %q, %+v, %v", gb.GetText(), damage, err)
+	}
+}
@@ -0,0 +1,143 @@
+package buffer
+
+import "io"
+
+// Snapshot is an immutable view of the buffer at one version. It shares
+// the chunk tree with the buffer instead of copying it, so taking one
+// costs the same for any size. Before its next change the buffer forks the
+// tree: the two go on sharing the nodes and an edit copies only the nodes
+// on its path, in O(log n), plus those of the chunks the gap moves across.
+// Nodes of LayoutPointer link to their parents and cannot be shared, so
+// the first fork of such a buffer moves its chunks, though not the text,
+// to LayoutCompact, once. A snapshot stays valid and unchanged whatever
+// happens to the buffer afterwards. The file mapped by NewFromFile stays
+// mapped after Close as long as a snapshot of it is referenced.
+//
+// A snapshot can be read from other goroutines while the buffer is being
+// edited, by any number of them at once, which suits background tasks
+// needing a consistent text such as linters, formatters or indexers.
+type Snapshot struct {
+	gb *GapBuffer
+}
+
+var _ io.ReaderAt = (*Snapshot)(nil)
+
+// Snapshot returns a copy-on-write snapshot of the buffer in constant time
+func (gb *GapBuffer) Snapshot() *Snapshot {
+	gb.treeShared = true
+	s := &Snapshot{gb: &GapBuffer{
+		tree:         gb.tree,
+		gapStart:     gb.gapStart,
+		gapEnd:       gb.gapEnd,
+		length:       gb.length,
+		chunkSize:    gb.chunkSize,
+		version:      gb.version,
+		finalNewline: gb.finalNewline,
+		binary:       gb.binary,
+		treeShared:   true,
+		identity:     gb.contentIdentity(),
+		// No results are cached, so reads never write to the snapshot
+		rangeCache: rangeCache{version: gb.version},
+	}}
+	gb.pinMapping(s.gb)
+	return s
+}
+
+// ownTree gives the buffer a tree it can change before it changes, if it
+// shares the current one with snapshots
+func (gb *GapBuffer) ownTree() {
+	if gb.treeShared {
+		gb.tree = gb.tree.forked()
+		gb.treeShared = false
+	}
+}
+
+// Version returns the version of the buffer the snapshot was taken at
+func (s *Snapshot) Version() int {
+	return s.gb.Version()
+}
+
+// Length returns the length of the text in bytes
+func (s *Snapshot) Length() int {
+	return s.gb.Length()
+}
+
+// RuneLength returns the number of runes of the text
+func (s *Snapshot) RuneLength() int {
+	return s.gb.RuneLength()
+}
+
+// LineCount returns the number of lines, as GapBuffer.LineCount counts them
+func (s *Snapshot) LineCount() int {
+	return s.gb.LineCount()
+}
+
+// GetText returns the text, as GapBuffer.GetText does
+func (s *Snapshot) GetText() string {
+	return s.gb.text()
+}
+
+// GetTextRange returns the text in [start, end)
+func (s *Snapshot) GetTextRange(start int, end int) (string, error) {
+	return s.gb.GetTextRange(start, end)
+}
+
+// GetRuneTextRange returns the text between two rune indexes
+func (s *Snapshot) GetRuneTextRange(runeStart int, runeEnd int) (string, error) {
+	return s.gb.GetRuneTextRange(runeStart, runeEnd)
+}
+
+// Bytes returns a copy of the text exactly as stored
+func (s *Snapshot) Bytes() []byte {
+	return s.gb.Bytes()
+}
+
+// GetBytesRange returns a copy of the bytes in [start, end) exactly as
+// stored
+func (s *Snapshot) GetBytesRange(start int, end int) ([]byte, error) {
+	return s.gb.GetBytesRange(start, end)
+}
+
+// ReadAt implements io.ReaderAt, see GapBuffer.ReadAt
+func (s *Snapshot) ReadAt(p []byte, off int64) (int, error) {
+	return s.gb.ReadAt(p, off)
+}
+
+// Reader returns a reader over the text that walks the chunks as it is
+// read. It is a *SizedReader.
+func (s *Snapshot) Reader() io.Reader {
+	return s.gb.Reader()
+}
+
+// WriteTo implements io.WriterTo, see GapBuffer.WriteTo
+func (s *Snapshot) WriteTo(w io.Writer) (int64, error) {
+	return s.gb.WriteTo(w)
+}
+
+// ByteToRune returns the number of runes before the byte position pos
+func (s *Snapshot) ByteToRune(pos int) (int, error) {
+	return s.gb.ByteToRune(pos)
+}
+
+// RuneToByte returns the byte position of the rune with index runePos
+func (s *Snapshot) RuneToByte(runePos int) (int, error) {
+	return s.gb.RuneToByte(runePos)
+}
+
+// OffsetToLineCol returns the zero-based line containing pos and the byte
+// offset of pos within it
+func (s *Snapshot) OffsetToLineCol(pos int) (line int, col int, err error) {
+	return s.gb.OffsetToLineCol(pos)
+}
+
+// LineColToOffset returns the position of the byte at offset col in the
+// zero-based line
+func (s *Snapshot) LineColToOffset(line int, col int) (int, error) {
+	return s.gb.LineColToOffset(line, col)
+}
+
+// LineRange returns the range of the zero-based line, excluding its line
+// terminator
+func (s *Snapshot) LineRange(line int) (Range, error) {
+	return s.gb.LineRange(line)
+}
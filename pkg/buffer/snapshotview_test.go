@@ -0,0 +1,82 @@
+package buffer_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestSnapshotUnchanged edits a buffer in both tree layouts after taking
+// snapshots and checks that each keeps the text of its version
+func TestSnapshotUnchanged(t *testing.T) {
+	for _, layout := range []buffer.TreeLayout{buffer.LayoutPointer, buffer.LayoutCompact} {
+		t.Run(layoutName(layout), func(t *testing.T) {
+			gb := buffer.NewWithChunkSize(8)
+			gb.SetTreeLayout(layout)
+			var snaps []*buffer.Snapshot
+			var texts []string
+			for i := 0; i < 50; i++ {
+				gb.InsertAt(gb.Length()/2, strings.Repeat(string(rune('a'+i%26)), i%7+1))
+				if i%3 == 0 {
+					gb.DeleteAt(0, 1)
+				}
+				snaps = append(snaps, gb.Snapshot())
+				texts = append(texts, gb.GetText())
+			}
+			for i, s := range snaps {
+				if got := s.GetText(); got != texts[i] {
+					t.Fatalf("snapshot %d: got %q, want %q", i, got, texts[i])
+				}
+			}
+		})
+	}
+}
+
+// TestSnapshotOutlivesClose reads a snapshot of a mapped buffer after the
+// buffer is closed, which must not unmap the text the snapshot shares
+func TestSnapshotOutlivesClose(t *testing.T) {
+	text := strings.Repeat("line of mapped text\n", 4096)
+	path := filepath.Join(t.TempDir(), "mapped.txt")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gb, err := buffer.NewFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := gb.Snapshot()
+	gb.InsertAt(0, "edited ")
+	if err := gb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	runtime.GC()
+
+	if got := s.GetText(); got != text {
+		t.Fatalf("snapshot after Close differs: %d bytes, want %d", len(got), len(text))
+	}
+	if got := gb.GetText(); got != "edited "+text {
+		t.Fatalf("buffer after Close differs: %d bytes, want %d", len(got), len(text)+7)
+	}
+}
+
+// TestSnapshotForkLayout checks that the first edit after a snapshot moves
+// a buffer of pointer layout to the compact one, whose nodes it shares
+func TestSnapshotForkLayout(t *testing.T) {
+	gb := buffer.NewWithChunkSize(8)
+	gb.InsertAt(0, strings.Repeat("chunked text ", 20))
+	s := gb.Snapshot()
+	if gb.TreeLayout() != buffer.LayoutPointer {
+		t.Fatalf("snapshot changed the layout to %v", gb.TreeLayout())
+	}
+	gb.InsertAt(5, "!")
+	if gb.TreeLayout() != buffer.LayoutCompact {
+		t.Errorf("forked tree has layout %v", gb.TreeLayout())
+	}
+	if got, want := s.GetText(), strings.Repeat("chunked text ", 20); got != want {
+		t.Errorf("snapshot changed: %q", got)
+	}
+}
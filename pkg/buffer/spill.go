@@ -0,0 +1,291 @@
+package buffer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DEFAULT_SPILL_THRESHOLD is the size above which deleted text kept in the
+// operation log is moved out of memory into a temporary spill file
+const DEFAULT_SPILL_THRESHOLD = 1024 * 1024 // 1MB
+
+// spillRef locates text stored in the spill file, or in a stream of a
+// storage if storage is set. A stream is put in the background: until
+// written is closed, the text stays in memory, and it stays there for good
+// if putting it failed.
+type spillRef struct {
+	off int64
+	n   int
+
+	storage Storage
+	name    string
+	written chan struct{}
+	text    string // the text until it is written, owned by the writer
+	err     error  // error of the write, set before written is closed
+}
+
+// spillFile is an append-only temporary file holding large history content.
+// Released regions are punched out of the file where the OS supports it, so
+// disk usage stays proportional to the content still referenced.
+type spillFile struct {
+	f    *os.File
+	size int64 // end of the written data
+	live int64 // bytes still referenced
+
+	storage Storage          // holds spilled text instead of the file, see SetSpillStorage
+	prefix  string           // start of the names of the streams in storage
+	next    int              // number of the next stream
+	bg      *backgroundTasks // runs the puts and deletes of streams
+
+	refs map[*spillRef]bool // text written and not yet released
+}
+
+// ErrSpillReleased is returned by calls needing the deleted text of an
+// operation that was spilled out of memory and released by Close
+var ErrSpillReleased = errors.New("spilled text released")
+
+// write appends text to the spill file, creating it on first use, or
+// starts putting it into a stream of its own in the storage
+func (s *spillFile) write(text string) (*spillRef, error) {
+	if s.storage != nil {
+		ref := &spillRef{
+			n:       len(text),
+			storage: s.storage,
+			name:    s.prefix + strconv.Itoa(s.next),
+			written: make(chan struct{}),
+			text:    text,
+		}
+		s.next++
+		s.bg.run(func() {
+			defer close(ref.written)
+			ref.err = ref.storage.Put(context.Background(), ref.name, strings.NewReader(ref.text))
+			if ref.err == nil {
+				ref.text = ""
+			}
+		})
+		return s.add(ref), nil
+	}
+	if s.f == nil {
+		f, err := os.CreateTemp("", "gapbuffer-spill-*")
+		if err != nil {
+			return nil, err
+		}
+		s.f = f
+	}
+
+	if _, err := s.f.WriteAt([]byte(text), s.size); err != nil {
+		return nil, err
+	}
+	ref := &spillRef{off: s.size, n: len(text)}
+	s.size += int64(len(text))
+	s.live += int64(len(text))
+	return s.add(ref), nil
+}
+
+// add records ref as written
+func (s *spillFile) add(ref *spillRef) *spillRef {
+	if s.refs == nil {
+		s.refs = make(map[*spillRef]bool)
+	}
+	s.refs[ref] = true
+	return ref
+}
+
+// release gives back the space used by ref. Once nothing is referenced the
+// file is truncated; otherwise the region becomes a hole in a sparse file.
+// Streams in a storage are deleted.
+func (s *spillFile) release(ref *spillRef) {
+	if !s.refs[ref] {
+		return
+	}
+	delete(s.refs, ref)
+	if ref.storage != nil {
+		s.bg.run(func() {
+			<-ref.written
+			ref.storage.Delete(context.Background(), ref.name)
+		})
+		return
+	}
+	if s.f == nil {
+		return
+	}
+
+	s.live -= int64(ref.n)
+	if s.live <= 0 {
+		if s.f.Truncate(0) == nil {
+			s.size = 0
+			s.live = 0
+		}
+		return
+	}
+	punchHole(s.f, ref.off, int64(ref.n))
+}
+
+// read loads text previously written to the spill file. Text still being
+// put into a stream is waited for.
+func (s *spillFile) read(ref *spillRef) (string, error) {
+	if !s.refs[ref] {
+		return "", ErrSpillReleased
+	}
+	if ref.storage != nil {
+		if <-ref.written; ref.err != nil {
+			return ref.text, nil
+		}
+		r, err := ref.storage.Get(context.Background(), ref.name)
+		if err != nil {
+			return "", err
+		}
+		defer r.Close()
+		buf, err := io.ReadAll(r)
+		return string(buf), err
+	}
+	buf := make([]byte, ref.n)
+	if _, err := s.f.ReadAt(buf, ref.off); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// close removes the spill file
+func (s *spillFile) close() error {
+	if s.f == nil {
+		return nil
+	}
+	name := s.f.Name()
+	err := s.f.Close()
+	if rmErr := os.Remove(name); err == nil {
+		err = rmErr
+	}
+	s.f = nil
+	s.size = 0
+	s.live = 0
+	return err
+}
+
+// SetSpillThreshold sets the size above which deleted text kept in the
+// operation log is written to a temporary file instead of being held in
+// memory. A threshold <= 0 restores DEFAULT_SPILL_THRESHOLD.
+func (gb *GapBuffer) SetSpillThreshold(threshold int) {
+	if threshold <= 0 {
+		threshold = DEFAULT_SPILL_THRESHOLD
+	}
+	gb.spillThreshold = threshold
+}
+
+// SetSpillStorage makes spilled history text go to st instead of a
+// temporary file, each text in a stream of its own named prefix followed
+// by a sequence number, which is deleted when the text is dropped from
+// the log or the buffer is closed. The prefix must not be used by other
+// buffers sharing st. Streams are put and deleted in the background, so
+// that a slow storage does not hold up editing; Flush and Close wait for
+// them. Text that cannot be put stays in memory. Text spilled before keeps
+// its place. A nil st restores the temporary file.
+func (gb *GapBuffer) SetSpillStorage(st Storage, prefix string) {
+	gb.spill.storage = st
+	gb.spill.prefix = prefix
+	gb.spill.bg = &gb.bg
+}
+
+// spillOperation moves the deleted text of a logged operation to the spill
+// file when it is large. If spilling fails the text simply stays in memory.
+func (gb *GapBuffer) spillOperation(op *Operation) {
+	threshold := gb.spillThreshold
+	if threshold <= 0 {
+		threshold = DEFAULT_SPILL_THRESHOLD
+	}
+	if len(op.DeletedText) <= threshold {
+		return
+	}
+
+	ref, err := gb.spill.write(op.DeletedText)
+	if err != nil {
+		return
+	}
+	op.DeletedText = ""
+	op.deletedSpill = ref
+}
+
+// releaseOperations frees the spilled content of operations dropped from
+// the log or the history, unless the other one still refers to it
+func (gb *GapBuffer) releaseOperations(ops []Operation) {
+	for _, op := range ops {
+		if op.deletedSpill != nil {
+			gb.collectSpill()
+			return
+		}
+	}
+}
+
+// collectSpill releases the spilled text no operation of the log or the
+// history refers to any more. Undo steps share the spilled text of the
+// logged operations, and either may outlive the other.
+func (gb *GapBuffer) collectSpill() {
+	if len(gb.spill.refs) == 0 {
+		return
+	}
+	used := make(map[*spillRef]bool)
+	mark := func(ops []Operation) {
+		for _, op := range ops {
+			if op.deletedSpill != nil {
+				used[op.deletedSpill] = true
+			}
+		}
+	}
+	mark(gb.oplog.ops)
+	h := &gb.history
+	mark(h.group)
+	for _, steps := range [][]historyStep{h.undo, h.redo} {
+		for _, step := range steps {
+			mark(step)
+		}
+	}
+	// Rollback brings back the redo steps of a savepoint
+	for _, save := range gb.txn.saves {
+		for _, step := range save.redo {
+			mark(step)
+		}
+	}
+
+	for ref := range gb.spill.refs {
+		if !used[ref] {
+			gb.spill.release(ref)
+		}
+	}
+}
+
+// loadOperation returns op with any spilled content read back into memory
+func (gb *GapBuffer) loadOperation(op Operation) (Operation, error) {
+	if op.deletedSpill == nil {
+		return op, nil
+	}
+	text, err := gb.spill.read(op.deletedSpill)
+	if err != nil {
+		return op, err
+	}
+	op.DeletedText = text
+	op.deletedSpill = nil
+	return op, nil
+}
+
+// Close releases resources held by the buffer, such as the spill file or
+// the streams of SetSpillStorage, whose background writes and deletes it
+// waits for, and gives its bytes back to its quota owner. The buffer's text remains usable, but spilled history content is
+// lost: undoing a step that needs it, and reading its operation with
+// Operations, TextAt, ExportAuditLog or ExportChangesAsPatch, fails with
+// ErrSpillReleased. The text of a buffer opened with NewFromFile that
+// still comes from the file is copied into memory before the file is
+// unmapped.
+func (gb *GapBuffer) Close() (err error) {
+	defer guard("Close", noPos, gb.length, &err)
+
+	gb.releaseQuota()
+	for ref := range gb.spill.refs {
+		gb.spill.release(ref)
+	}
+	gb.bg.wait(context.Background())
+	return errors.Join(gb.unmap(), gb.spill.close())
+}
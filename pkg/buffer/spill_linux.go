@@ -0,0 +1,17 @@
+package buffer
+
+import (
+	"os"
+	"syscall"
+)
+
+const (
+	fallocKeepSize  = 0x1 // FALLOC_FL_KEEP_SIZE
+	fallocPunchHole = 0x2 // FALLOC_FL_PUNCH_HOLE
+)
+
+// punchHole deallocates [off, off+n) of f, keeping the file size.
+// Errors are ignored: the region just keeps using disk space.
+func punchHole(f *os.File, off int64, n int64) {
+	syscall.Fallocate(int(f.Fd()), fallocKeepSize|fallocPunchHole, off, n)
+}
@@ -0,0 +1,97 @@
+package buffer_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestSpill checks that deleted text beyond the threshold is read back for
+// undo and the log, and that after Close every reader needing it fails
+// instead of returning operations without their deleted text
+func TestSpill(t *testing.T) {
+	gb := buffer.New()
+	gb.SetSpillThreshold(16)
+	text := strings.Repeat("spilled text ", 10)
+	gb.InsertAt(0, text)
+	if err := gb.DeleteAt(0, len(text)); err != nil {
+		t.Fatal(err)
+	}
+
+	ops, err := gb.Operations(1)
+	if err != nil || len(ops) != 1 || ops[0].DeletedText != text {
+		t.Fatalf("operations before Close: got %+v, %v", ops, err)
+	}
+	if err := gb.Undo(); err != nil || gb.GetText() != text {
+		t.Fatalf("undo: got %q, %v", gb.GetText(), err)
+	}
+	if err := gb.Redo(); err != nil || gb.GetText() != "" {
+		t.Fatalf("redo: got %q, %v", gb.GetText(), err)
+	}
+
+	if err := gb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gb.Operations(1); !errors.Is(err, buffer.ErrSpillReleased) {
+		t.Errorf("Operations after Close: got %v, want ErrSpillReleased", err)
+	}
+	if _, err := gb.TextAt(1); !errors.Is(err, buffer.ErrSpillReleased) {
+		t.Errorf("TextAt after Close: got %v, want ErrSpillReleased", err)
+	}
+	if err := gb.ExportAuditLog(io.Discard, 1, nil); !errors.Is(err, buffer.ErrSpillReleased) {
+		t.Errorf("ExportAuditLog after Close: got %v, want ErrSpillReleased", err)
+	}
+	if _, err := gb.ExportChangesAsPatch(1, buffer.PatchOptions{}); !errors.Is(err, buffer.ErrSpillReleased) {
+		t.Errorf("ExportChangesAsPatch after Close: got %v, want ErrSpillReleased", err)
+	}
+	if err := gb.Undo(); !errors.Is(err, buffer.ErrSpillReleased) {
+		t.Errorf("Undo after Close: got %v, want ErrSpillReleased", err)
+	}
+
+	// Operations that kept their text in memory still read back
+	if err := gb.InsertAt(0, "more"); err != nil {
+		t.Fatal(err)
+	}
+	if ops, err := gb.Operations(4); err != nil || len(ops) != 1 || ops[0].InsertedText != "more" {
+		t.Errorf("operations after new edit: got %+v, %v", ops, err)
+	}
+}
+
+// TestSpillReleased checks that releasing spilled text dropped from the log
+// and the history, which punches holes into the spill file, keeps the text
+// still referenced readable
+func TestSpillReleased(t *testing.T) {
+	gb := buffer.New()
+	defer gb.Close()
+	gb.SetSpillThreshold(16)
+	gb.SetHistoryDepth(3)
+	gb.SetOperationLogLimit(2)
+
+	var texts []string
+	for i := 0; i < 10; i++ {
+		text := strings.Repeat(string(rune('a'+i)), 32+i)
+		texts = append(texts, text)
+		gb.InsertAt(0, text)
+	}
+	var states []string
+	for i := len(texts) - 1; i >= 0; i-- {
+		states = append(states, gb.GetText())
+		if err := gb.DeleteAt(0, len(texts[i])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := len(states) - 1; i >= len(states)-3; i-- {
+		if err := gb.Undo(); err != nil {
+			t.Fatalf("undo to state %d: %v", i, err)
+		}
+		if gb.GetText() != states[i] {
+			t.Fatalf("undo to state %d: got %d bytes, want %d", i, gb.Length(), len(states[i]))
+		}
+	}
+	if gb.CanUndo() {
+		t.Error("history deeper than its depth")
+	}
+}
@@ -0,0 +1,274 @@
+package buffer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Storage keeps named streams of bytes for the parts of the package that
+// persist data: snapshots, see SaveSnapshot, and spilled history, see
+// SetSpillStorage. Implementing it lets them target object stores or
+// sandboxed app storage; DirStorage and MemStorage cover the file system
+// and memory. Names are slash-separated paths as accepted by fs.ValidPath.
+// Implementations must be safe for concurrent use.
+type Storage interface {
+	// Put stores everything r produces under name, replacing any stream
+	// of that name. Readers see either the old or the new stream, never
+	// part of it.
+	Put(ctx context.Context, name string, r io.Reader) error
+	// Get opens the stream stored under name. A missing stream is an
+	// error matching fs.ErrNotExist.
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// List returns the names of the streams starting with prefix, sorted
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Delete removes the stream stored under name. Deleting a missing
+	// stream is not an error.
+	Delete(ctx context.Context, name string) error
+}
+
+// checkStorageName returns an error for a name Storage does not accept
+func checkStorageName(op string, name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return nil
+}
+
+// SaveSnapshot writes the buffer with SaveTo into the stream name of st.
+// The snapshot is streamed, never held in memory as a whole.
+func (gb *GapBuffer) SaveSnapshot(ctx context.Context, st Storage, name string) (err error) {
+	defer guard("SaveSnapshot", noPos, gb.length, &err)
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	gb.goBackground(func() {
+		defer close(done)
+		_, err := gb.SaveTo(pw)
+		pw.CloseWithError(err)
+	})
+	err = st.Put(ctx, name, pr)
+
+	// Stop the writer if Put gave up early, and wait for it, so the
+	// buffer is not read once SaveSnapshot returns
+	pr.CloseWithError(errors.New("storage stopped reading"))
+	<-done
+	return err
+}
+
+// LoadSnapshot reads a buffer saved by SaveSnapshot from the stream name
+// of st, see LoadFrom
+func LoadSnapshot(ctx context.Context, st Storage, name string) (gb *GapBuffer, err error) {
+	defer guard("LoadSnapshot", noPos, noPos, &err)
+
+	r, err := st.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return LoadFrom(r)
+}
+
+// DirStorage is a Storage keeping every stream in a file below a
+// directory, with slashes in names separating subdirectories. Put writes
+// a temporary file next to the stream and renames it over the stream, as
+// SaveFile does.
+type DirStorage struct {
+	dir string
+}
+
+// NewDirStorage returns a storage keeping its streams below dir, which is
+// created when the first stream is put
+func NewDirStorage(dir string) *DirStorage {
+	return &DirStorage{dir: dir}
+}
+
+// path returns the file holding the stream name
+func (s *DirStorage) path(name string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(name))
+}
+
+// Put implements Storage
+func (s *DirStorage) Put(ctx context.Context, name string, r io.Reader) (err error) {
+	if err := checkStorageName("put", name); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	path := s.path(name)
+	dir, base := filepath.Split(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = io.Copy(tmp, r); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// Get implements Storage
+func (s *DirStorage) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	if err := checkStorageName("get", name); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return os.Open(s.path(name))
+}
+
+// List implements Storage. Temporary files of unfinished puts are left out.
+func (s *DirStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == s.dir {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() || (strings.HasPrefix(d.Name(), ".") && strings.Contains(d.Name(), ".tmp-")) {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+		if name := filepath.ToSlash(rel); strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return names, err
+}
+
+// Delete implements Storage
+func (s *DirStorage) Delete(ctx context.Context, name string) error {
+	if err := checkStorageName("delete", name); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// MemStorage is a Storage keeping its streams in memory, for tests and
+// for platforms without a usable file system
+type MemStorage struct {
+	mu      sync.Mutex
+	streams map[string][]byte
+}
+
+// NewMemStorage returns an empty in-memory storage
+func NewMemStorage() *MemStorage {
+	return &MemStorage{streams: make(map[string][]byte)}
+}
+
+// Put implements Storage
+func (s *MemStorage) Put(ctx context.Context, name string, r io.Reader) error {
+	if err := checkStorageName("put", name); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streams[name] = data
+	return nil
+}
+
+// Get implements Storage
+func (s *MemStorage) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	if err := checkStorageName("get", name); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.streams[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "get", Path: name, Err: fs.ErrNotExist}
+	}
+	// Streams are replaced, never modified, so readers can share them
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// List implements Storage
+func (s *MemStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for name := range s.streams {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Delete implements Storage
+func (s *MemStorage) Delete(ctx context.Context, name string) error {
+	if err := checkStorageName("delete", name); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.streams, name)
+	return nil
+}
@@ -0,0 +1,167 @@
+package buffer_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// storages returns a fresh storage of each implementation
+func storages(t *testing.T) map[string]buffer.Storage {
+	return map[string]buffer.Storage{
+		"Dir": buffer.NewDirStorage(filepath.Join(t.TempDir(), "store")),
+		"Mem": buffer.NewMemStorage(),
+	}
+}
+
+// get returns the content of the stream name of st
+func get(t *testing.T, st buffer.Storage, name string) string {
+	t.Helper()
+	r, err := st.Get(context.Background(), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// TestStorage checks the Storage contract on both implementations
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+	for name, st := range storages(t) {
+		t.Run(name, func(t *testing.T) {
+			if names, err := st.List(ctx, ""); err != nil || len(names) != 0 {
+				t.Errorf("empty storage lists %v, %v", names, err)
+			}
+			for _, stream := range []string{"b/two", "a", "b/one", "c"} {
+				if err := st.Put(ctx, stream, strings.NewReader("content of "+stream)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			st.Put(ctx, "a", strings.NewReader("replaced"))
+			if got := get(t, st, "a"); got != "replaced" {
+				t.Errorf("got %q", got)
+			}
+			if names, _ := st.List(ctx, "b/"); !slices.Equal(names, []string{"b/one", "b/two"}) {
+				t.Errorf("listed %v", names)
+			}
+
+			if err := st.Delete(ctx, "b/one"); err != nil {
+				t.Fatal(err)
+			}
+			if err := st.Delete(ctx, "b/one"); err != nil {
+				t.Errorf("deleting a missing stream: %v", err)
+			}
+			if _, err := st.Get(ctx, "b/one"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("deleted stream: got %v", err)
+			}
+			if names, _ := st.List(ctx, ""); !slices.Equal(names, []string{"a", "b/two", "c"}) {
+				t.Errorf("listed %v", names)
+			}
+
+			for _, bad := range []string{"../escape", "/root", "a//b", "."} {
+				if err := st.Put(ctx, bad, strings.NewReader("x")); !errors.Is(err, fs.ErrInvalid) {
+					t.Errorf("Put(%q): got %v", bad, err)
+				}
+			}
+			cancelled, cancel := context.WithCancel(ctx)
+			cancel()
+			if _, err := st.Get(cancelled, "a"); !errors.Is(err, context.Canceled) {
+				t.Errorf("cancelled Get: got %v", err)
+			}
+		})
+	}
+}
+
+// failingStorage is a MemStorage whose Put fails after reading a little
+type failingStorage struct {
+	*buffer.MemStorage
+}
+
+// Put implements buffer.Storage
+func (s failingStorage) Put(ctx context.Context, name string, r io.Reader) error {
+	r.Read(make([]byte, 8))
+	return errWriteFailed
+}
+
+// TestSaveSnapshot checks that snapshots round-trip through both storages
+// and that a failing Put fails the save without hanging
+func TestSaveSnapshot(t *testing.T) {
+	ctx := context.Background()
+	gb := edited()
+	for name, st := range storages(t) {
+		if err := gb.SaveSnapshot(ctx, st, "snapshots/doc"); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		loaded, err := buffer.LoadSnapshot(ctx, st, "snapshots/doc")
+		if err != nil || !slices.Equal(loaded.Bytes(), gb.Bytes()) {
+			t.Errorf("%s: loaded %d bytes, %v", name, loaded.Length(), err)
+		}
+		if _, err := buffer.LoadSnapshot(ctx, st, "snapshots/missing"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s: missing snapshot: got %v", name, err)
+		}
+	}
+
+	if err := gb.SaveSnapshot(ctx, failingStorage{buffer.NewMemStorage()}, "doc"); !errors.Is(err, errWriteFailed) {
+		t.Errorf("failing storage: got %v", err)
+	}
+}
+
+// TestSpillStorage checks that spilled text goes to the storage once
+// flushed, reads back for undo and is deleted by Close
+func TestSpillStorage(t *testing.T) {
+	ctx := context.Background()
+	st := buffer.NewMemStorage()
+	gb := buffer.New()
+	gb.SetSpillThreshold(16)
+	gb.SetSpillStorage(st, "spill/doc-")
+
+	text := strings.Repeat("spilled to storage ", 10)
+	gb.InsertAt(0, text)
+	gb.DeleteAt(0, len(text))
+	if err := gb.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	names, _ := st.List(ctx, "spill/doc-")
+	if len(names) != 1 || get(t, st, names[0]) != text {
+		t.Fatalf("spilled streams %v", names)
+	}
+	if err := gb.Undo(); err != nil || gb.GetText() != text {
+		t.Errorf("undo: got %d bytes, %v", gb.Length(), err)
+	}
+
+	if err := gb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := st.List(ctx, ""); len(names) != 0 {
+		t.Errorf("Close left streams %v", names)
+	}
+}
+
+// TestSpillStorageFails checks that text the storage refuses stays in
+// memory for undo
+func TestSpillStorageFails(t *testing.T) {
+	gb := buffer.New()
+	gb.SetSpillThreshold(16)
+	gb.SetSpillStorage(failingStorage{buffer.NewMemStorage()}, "spill/")
+	text := strings.Repeat("kept in memory ", 10)
+	gb.InsertAt(0, text)
+	gb.DeleteAt(0, len(text))
+	if err := gb.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := gb.Undo(); err != nil || gb.GetText() != text {
+		t.Errorf("undo: got %d bytes, %v", gb.Length(), err)
+	}
+}
@@ -0,0 +1,116 @@
+package buffer_test
+
+import (
+	"bytes"
+	"flag"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+var stress = flag.Int("stress", 1, "scale of the stress tests, in multiples of the default number of edits")
+
+// TestStress makes many random edits of every size on small chunks, so the
+// tree splits, merges and rebalances constantly, and checks that every edit
+// completes, the text stays right, the tree never gets deeper than a
+// red-black tree can be, and the whole history undoes and redoes. Run it at
+// scale with -stress=N.
+func TestStress(t *testing.T) {
+	edits := 20000 * *stress
+	if testing.Short() {
+		edits = 2000
+	}
+	for _, layout := range []buffer.TreeLayout{buffer.LayoutPointer, buffer.LayoutCompact} {
+		t.Run(layoutName(layout), func(t *testing.T) {
+			stressEdits(t, layout, edits)
+		})
+	}
+}
+
+func layoutName(layout buffer.TreeLayout) string {
+	if layout == buffer.LayoutCompact {
+		return "Compact"
+	}
+	return "Pointer"
+}
+
+func stressEdits(t *testing.T, layout buffer.TreeLayout, edits int) {
+	gb := buffer.NewWithChunkSize(64)
+	gb.SetTreeLayout(layout)
+	gb.SetHistoryDepth(edits + 1)
+	rng := rand.New(rand.NewSource(int64(edits)))
+	var model []byte
+
+	text := func() string {
+		// Mostly keystrokes, sometimes pastes spanning many chunks
+		n := 1 + rng.Intn(4)
+		if rng.Intn(20) == 0 {
+			n = rng.Intn(2000)
+		}
+		return strings.Repeat(string(rune('a'+rng.Intn(26))), n) + "\n"[:rng.Intn(2)]
+	}
+
+	for i := 0; i < edits; i++ {
+		var err error
+		switch pos := rng.Intn(len(model) + 1); {
+		case len(model) > 1<<16 || rng.Intn(3) == 0 && len(model) > 0:
+			// Delete, more often a lot once the text is large
+			end := min(len(model), pos+1+rng.Intn(1+len(model)/8))
+			if pos == len(model) {
+				pos--
+			}
+			err = gb.DeleteAt(pos, end-pos)
+			model = append(model[:pos:pos], model[end:]...)
+		case rng.Intn(4) == 0 && len(model) > 0:
+			end := min(len(model), pos+rng.Intn(64))
+			s := text()
+			err = gb.Replace(pos, end, s)
+			model = append(model[:pos:pos], append([]byte(s), model[end:]...)...)
+		default:
+			s := text()
+			err = gb.InsertAt(pos, s)
+			model = append(model[:pos:pos], append([]byte(s), model[pos:]...)...)
+		}
+		if err != nil {
+			t.Fatalf("edit %d: %v", i, err)
+		}
+
+		if i%500 == 0 {
+			gb.ScheduleCompaction()
+			for gb.RunIdle() {
+			}
+		}
+		if i%97 == 0 {
+			checkStressed(t, gb, model, i)
+		}
+	}
+	checkStressed(t, gb, model, edits)
+
+	final := append([]byte(nil), model...)
+	for gb.Undo() == nil {
+	}
+	if gb.Length() != 0 {
+		t.Fatalf("undoing everything left %d bytes", gb.Length())
+	}
+	for gb.Redo() == nil {
+	}
+	checkStressed(t, gb, final, edits)
+}
+
+// checkStressed checks the text and the depth of the tree after edit i
+func checkStressed(t *testing.T, gb *buffer.GapBuffer, model []byte, i int) {
+	t.Helper()
+	if got := gb.Bytes(); !bytes.Equal(got, model) {
+		t.Fatalf("after edit %d: text differs from the model (%d bytes, want %d)", i, len(got), len(model))
+	}
+	chunks := len(gb.Chunks())
+	if limit := 2 * math.Log2(float64(chunks+1)); float64(gb.TreeDepth()) > limit {
+		t.Fatalf("after edit %d: tree of %d chunks has depth %d", i, chunks, gb.TreeDepth())
+	}
+	if gb.HealTree() {
+		t.Fatalf("after edit %d: the tree needed healing", i)
+	}
+}
@@ -0,0 +1,149 @@
+package buffer
+
+import "time"
+
+// ChangeEvent describes one applied edit to a listener
+type ChangeEvent struct {
+	Offset       int    // Position of the edit
+	DeletedLen   int    // Number of bytes removed at Offset
+	InsertedText string // Text inserted at Offset
+	Revision     int    // Version of the buffer after the edit
+	Actor        string
+	Metadata     Metadata
+}
+
+// ChangeListener is called with every edit applied to a buffer
+type ChangeListener func(ChangeEvent)
+
+// CoalesceWindow bounds how many edits a coalescing listener gets as one
+// event. Zero fields impose no bound of their own.
+type CoalesceWindow struct {
+	Delay  time.Duration // Deliver once an edit comes this long after the first held one
+	MaxOps int           // Deliver once this many edits are held
+}
+
+// subscription is a registered listener; its address identifies it
+type subscription struct {
+	listener ChangeListener
+	window   *CoalesceWindow // nil delivers every edit on its own
+
+	pending *ChangeEvent // edits held for a coalescing listener
+	end     int          // end of the text pending inserted, in current positions
+	ops     int          // number of edits in pending
+	since   time.Time    // when the first of them was applied
+	idle    *IdleHandle  // delivers pending when the buffer goes idle
+}
+
+// Subscribe calls listener synchronously after every applied edit, once
+// everything tracking positions has caught up with it, in subscription
+// order. Listeners must not edit the buffer. The returned function removes
+// the listener; it may be called from within a listener.
+func (gb *GapBuffer) Subscribe(listener ChangeListener) (unsubscribe func()) {
+	return gb.subscribe(&subscription{listener: listener})
+}
+
+// SubscribeCoalesced is like Subscribe, but holds back runs of edits and
+// delivers each run as one event replacing the union of the ranges they
+// touched, so that consumers like highlighters reparse once per burst of
+// typing. A run is delivered when it reaches the bounds of window, when
+// RunIdle is called, or on FlushChanges. Edits by different actors or with
+// metadata are never merged. Held edits are dropped on unsubscribe.
+func (gb *GapBuffer) SubscribeCoalesced(listener ChangeListener, window CoalesceWindow) (unsubscribe func()) {
+	return gb.subscribe(&subscription{listener: listener, window: &window})
+}
+
+// FlushChanges delivers the edits held by coalescing listeners
+func (gb *GapBuffer) FlushChanges() {
+	for _, s := range gb.subscriptions {
+		s.flush()
+	}
+}
+
+// subscribe registers sub and returns the function removing it
+func (gb *GapBuffer) subscribe(sub *subscription) (unsubscribe func()) {
+	gb.subscriptions = append(gb.subscriptions, sub)
+	return func() {
+		for i, s := range gb.subscriptions {
+			if s == sub {
+				gb.subscriptions = append(gb.subscriptions[:i:i], gb.subscriptions[i+1:]...)
+				sub.pending = nil
+				if sub.idle != nil {
+					sub.idle.Cancel()
+				}
+				return
+			}
+		}
+	}
+}
+
+// notifySubscribers calls the listeners with op
+func (gb *GapBuffer) notifySubscribers(op Operation) {
+	if len(gb.subscriptions) == 0 {
+		return
+	}
+	event := ChangeEvent{
+		Offset:       op.Pos,
+		DeletedLen:   op.Deleted,
+		InsertedText: op.InsertedText,
+		Revision:     op.Version,
+		Actor:        op.Actor,
+		Metadata:     op.Metadata,
+	}
+	// Listeners unsubscribing copy the slice, so this one stays intact
+	for _, s := range gb.subscriptions {
+		if s.window == nil {
+			s.listener(event)
+		} else {
+			gb.hold(s, event, op)
+		}
+	}
+}
+
+// hold adds the edit described by event to the run held for s, delivering
+// the run first if the edit may not join it and afterwards if it is full
+func (gb *GapBuffer) hold(s *subscription, event ChangeEvent, op Operation) {
+	now := time.Now()
+	if p := s.pending; p != nil && (p.Actor != event.Actor || len(p.Metadata) > 0 || len(event.Metadata) > 0 ||
+		(s.window.Delay > 0 && now.Sub(s.since) >= s.window.Delay)) {
+		s.flush()
+	}
+
+	opEnd := op.Pos + op.Inserted
+	if p := s.pending; p == nil {
+		s.pending = &event
+		s.end = opEnd
+		s.since = now
+	} else {
+		// Union of both ranges, before and after the edit
+		start := min(p.Offset, op.Pos)
+		end := max(s.end, op.Pos+op.Deleted)
+		p.DeletedLen += end - start - (s.end - p.Offset)
+		p.Offset = start
+		p.Revision = event.Revision
+		s.end = end + op.Inserted - op.Deleted
+		p.InsertedText = gb.rawText(start, s.end)
+	}
+	s.ops++
+
+	if s.window.MaxOps > 0 && s.ops >= s.window.MaxOps {
+		s.flush()
+		return
+	}
+	if s.idle == nil || s.idle.Done() {
+		s.idle = gb.OnIdle(func(func() bool) bool {
+			s.flush()
+			return true
+		}, 0)
+	}
+}
+
+// flush delivers the run of edits held for s, if any
+func (s *subscription) flush() {
+	if s.pending == nil {
+		return
+	}
+	event := *s.pending
+	s.pending = nil
+	s.ops = 0
+	s.listener(event)
+}
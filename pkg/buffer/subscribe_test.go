@@ -0,0 +1,139 @@
+package buffer_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// apply applies a change event to a copy of the text
+func apply(text string, e buffer.ChangeEvent) string {
+	return text[:e.Offset] + e.InsertedText + text[e.Offset+e.DeletedLen:]
+}
+
+// TestSubscribe checks that replaying the events rebuilds the text, with
+// the revision of every edit, undo and redo included
+func TestSubscribe(t *testing.T) {
+	gb := newBuffer(t, "start")
+	model := gb.GetText()
+	revision := gb.Version()
+	gb.Subscribe(func(e buffer.ChangeEvent) {
+		model = apply(model, e)
+		if e.Revision != revision+1 || e.Revision != gb.Version() {
+			t.Fatalf("got revision %d after %d", e.Revision, revision)
+		}
+		revision = e.Revision
+	})
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		switch pos := rng.Intn(gb.Length() + 1); rng.Intn(5) {
+		case 0:
+			gb.Undo()
+		case 1:
+			gb.Redo()
+		default:
+			gb.Replace(pos, pos+rng.Intn(min(4, gb.Length()-pos)+1), "ab"[:rng.Intn(3)])
+		}
+		if model != gb.GetText() {
+			t.Fatalf("edit %d: events give %q, want %q", i, model, gb.GetText())
+		}
+	}
+}
+
+// TestSubscribeOrder checks the order of listeners, that positions are up
+// to date when they run and unsubscribing from within a listener
+func TestSubscribeOrder(t *testing.T) {
+	gb := newBuffer(t, "text")
+	m, _ := gb.CreateMarker(4, buffer.GravityLeft)
+	var calls []string
+	var unsubscribe func()
+	gb.Subscribe(func(buffer.ChangeEvent) { calls = append(calls, "first") })
+	unsubscribe = gb.Subscribe(func(buffer.ChangeEvent) {
+		calls = append(calls, "second")
+		if m.Pos() != 6 {
+			t.Errorf("marker at %d in the listener", m.Pos())
+		}
+		unsubscribe()
+	})
+	gb.Subscribe(func(buffer.ChangeEvent) { calls = append(calls, "third") })
+
+	gb.InsertAt(0, ">>")
+	gb.InsertAt(0, ">>")
+	want := []string{"first", "second", "third", "first", "third"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("got %v, want %v", calls, want)
+	}
+}
+
+// TestSubscribeCoalesced checks that runs of edits arrive as one event
+// that brings the text up to date, bounded by the window and delivered on
+// flush or idle
+func TestSubscribeCoalesced(t *testing.T) {
+	gb := newBuffer(t, "hello world")
+	model := gb.GetText()
+	var events []buffer.ChangeEvent
+	gb.SubscribeCoalesced(func(e buffer.ChangeEvent) {
+		model = apply(model, e)
+		events = append(events, e)
+	}, buffer.CoalesceWindow{MaxOps: 3})
+
+	check := func(what string, wantEvents int) {
+		t.Helper()
+		if len(events) != wantEvents || model != gb.GetText() {
+			t.Fatalf("%s: got %d events giving %q, want %d giving %q", what, len(events), model, wantEvents, gb.GetText())
+		}
+	}
+
+	gb.InsertAt(5, ",")
+	gb.DeleteAt(0, 1)
+	if len(events) != 0 {
+		t.Fatalf("got %d events for held edits", len(events))
+	}
+	gb.Replace(0, 0, "H")
+	check("window full", 1)
+	if e := events[0]; e.Offset != 0 || e.DeletedLen != 5 || e.InsertedText != "Hello," || e.Revision != gb.Version() {
+		t.Errorf("merged event %+v", e)
+	}
+
+	gb.InsertAt(gb.Length(), "!")
+	gb.FlushChanges()
+	check("flush", 2)
+	gb.InsertAt(0, ">")
+	gb.RunIdle()
+	check("idle", 3)
+
+	// Edits of another actor or with metadata are never merged
+	gb.InsertAt(0, "a")
+	gb.WithActor("peer").InsertAt(0, "b")
+	gb.WithMetadata(buffer.Metadata{"command": "paste"}, func() error { return gb.InsertAt(0, "c") })
+	gb.FlushChanges()
+	check("actors and metadata", 6)
+	if events[4].Actor != "peer" || events[5].Metadata["command"] != "paste" {
+		t.Errorf("got %+v", events[4:])
+	}
+}
+
+// TestSubscribeCoalescedDelay checks that an edit coming after the delay
+// delivers the run held before it, and that unsubscribing drops held edits
+func TestSubscribeCoalescedDelay(t *testing.T) {
+	gb := buffer.New()
+	events := 0
+	unsubscribe := gb.SubscribeCoalesced(func(buffer.ChangeEvent) { events++ }, buffer.CoalesceWindow{Delay: time.Millisecond})
+	gb.InsertAt(0, "a")
+	gb.InsertAt(1, "b")
+	time.Sleep(5 * time.Millisecond)
+	gb.InsertAt(2, "c")
+	if events != 1 {
+		t.Errorf("got %d events after the delay, want 1", events)
+	}
+	unsubscribe()
+	gb.FlushChanges()
+	gb.RunIdle()
+	if events != 1 {
+		t.Errorf("held edits delivered after unsubscribing")
+	}
+}
@@ -0,0 +1,195 @@
+package buffer
+
+import (
+	"errors"
+	"slices"
+	"sort"
+)
+
+// ErrSuggestionNotFound is returned when a suggestion id is unknown
+var ErrSuggestionNotFound = errors.New("suggestion not found")
+
+// Suggestion is a pending edit recorded as an overlay instead of being applied.
+// It proposes replacing [Start, End) with Text: an insertion has Start == End,
+// a deletion has an empty Text.
+type Suggestion struct {
+	ID    int
+	Start int
+	End   int
+	Text  string
+}
+
+// suggestionSet holds the pending suggestions of a buffer
+type suggestionSet struct {
+	items  []*Suggestion
+	nextID int
+}
+
+// SetSuggestionMode turns suggested-edits mode on or off. While it is on,
+// InsertAt, DeleteAt, Replace and their rune variants record suggestions
+// instead of modifying the text.
+func (gb *GapBuffer) SetSuggestionMode(on bool) {
+	gb.suggesting = on
+}
+
+// SuggestionMode reports whether suggested-edits mode is on
+func (gb *GapBuffer) SuggestionMode() bool {
+	return gb.suggesting
+}
+
+// Suggest records a suggestion to replace [start, end) with text and returns its id
+func (gb *GapBuffer) Suggest(start int, end int, text string) (id int, err error) {
+	defer guard("Suggest", start, gb.length, &err)
+
+	if gb.views > 0 {
+		return 0, ErrViewActive
+	}
+	if start < 0 || end > gb.length || start > end {
+		return 0, ErrInvalidRange
+	}
+	if err := gb.checkLimits([]Edit{{Start: start, End: end, Text: text}}); err != nil {
+		return 0, err
+	}
+	return gb.addSuggestion(start, end, text)
+}
+
+// addSuggestion records a validated suggestion
+func (gb *GapBuffer) addSuggestion(start int, end int, text string) (int, error) {
+	gb.suggestions.nextID++
+	gb.suggestions.items = append(gb.suggestions.items, &Suggestion{
+		ID:    gb.suggestions.nextID,
+		Start: start,
+		End:   end,
+		Text:  text,
+	})
+	return gb.suggestions.nextID, nil
+}
+
+// Suggestions returns the pending suggestions ordered by position
+func (gb *GapBuffer) Suggestions() []Suggestion {
+	result := make([]Suggestion, 0, len(gb.suggestions.items))
+	for _, s := range gb.suggestions.items {
+		result = append(result, *s)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Start < result[j].Start
+	})
+	return result
+}
+
+// AcceptSuggestion applies the suggestion to the text and removes it.
+// The remaining suggestions are adjusted like for any other edit.
+func (gb *GapBuffer) AcceptSuggestion(id int) (err error) {
+	defer guard("AcceptSuggestion", noPos, gb.length, &err)
+
+	if gb.views > 0 {
+		return ErrViewActive
+	}
+	s := gb.findSuggestion(id)
+	if s == nil {
+		return ErrSuggestionNotFound
+	}
+	// Keep the suggestion when the edit is rejected
+	edit := []Edit{{Start: s.Start, End: s.End, Text: s.Text}}
+	if err := gb.checkLimits(edit); err != nil {
+		return err
+	}
+	if err := gb.checkHooks(edit); err != nil {
+		return err
+	}
+	// The suggestion is taken out before the edit, which would discard it as
+	// overlapping, and put back if the edit fails
+	i := slices.Index(gb.suggestions.items, s)
+	gb.takeSuggestion(id)
+
+	// Accepting always edits the text, even in suggested-edits mode
+	suggesting, hooks := gb.suggesting, gb.changeHooks
+	gb.suggesting, gb.changeHooks = false, nil
+	defer func() { gb.suggesting, gb.changeHooks = suggesting, hooks }()
+
+	if err := gb.replace(s.Start, s.End, s.Text); err != nil {
+		i = min(i, len(gb.suggestions.items))
+		gb.suggestions.items = slices.Insert(gb.suggestions.items, i, s)
+		return err
+	}
+	return nil
+}
+
+// RejectSuggestion discards the suggestion without touching the text
+func (gb *GapBuffer) RejectSuggestion(id int) (err error) {
+	defer guard("RejectSuggestion", noPos, gb.length, &err)
+
+	if gb.views > 0 {
+		return ErrViewActive
+	}
+	if gb.takeSuggestion(id) == nil {
+		return ErrSuggestionNotFound
+	}
+	return nil
+}
+
+// takeSuggestion removes the suggestion with the given id from the set
+func (gb *GapBuffer) takeSuggestion(id int) *Suggestion {
+	for i, s := range gb.suggestions.items {
+		if s.ID == id {
+			gb.suggestions.items = append(gb.suggestions.items[:i], gb.suggestions.items[i+1:]...)
+			return s
+		}
+	}
+	return nil
+}
+
+// findSuggestion returns the pending suggestion with the given id, or nil
+func (gb *GapBuffer) findSuggestion(id int) *Suggestion {
+	for _, s := range gb.suggestions.items {
+		if s.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+// hasSuggestion reports whether the suggestion with the given id is still pending
+func (gb *GapBuffer) hasSuggestion(id int) bool {
+	return gb.findSuggestion(id) != nil
+}
+
+// SuggestedText returns the text as it would read with every pending
+// suggestion accepted. Suggestions overlapping an earlier one are ignored.
+func (gb *GapBuffer) SuggestedText() string {
+	result := make([]byte, 0, gb.length)
+	pos := 0
+	for _, s := range gb.Suggestions() {
+		if s.Start < pos {
+			continue
+		}
+		gb.forEachChunk(pos, s.Start, func(_ int, text string) bool {
+			result = append(result, text...)
+			return true
+		})
+		result = append(result, s.Text...)
+		pos = s.End
+	}
+	gb.forEachChunk(pos, gb.length, func(_ int, text string) bool {
+		result = append(result, text...)
+		return true
+	})
+
+	return EnsureValidUTF8(string(result))
+}
+
+// adjustSuggestions shifts pending suggestions after an applied edit.
+// Suggestions whose range overlaps the edited text are discarded because
+// they no longer refer to the text they were made against.
+func (gb *GapBuffer) adjustSuggestions(op Operation) {
+	items := gb.suggestions.items[:0]
+	for _, s := range gb.suggestions.items {
+		e, ok := rebaseEdit(Edit{Start: s.Start, End: s.End}, op)
+		if !ok {
+			continue
+		}
+		s.Start, s.End = e.Start, e.End
+		items = append(items, s)
+	}
+	gb.suggestions.items = items
+}
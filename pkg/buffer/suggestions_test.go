@@ -0,0 +1,74 @@
+package buffer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestSuggestions checks that edits in suggested-edits mode leave the text
+// alone, that accepting one shifts the others and drops those it overlaps,
+// and that rejecting one only forgets it
+func TestSuggestions(t *testing.T) {
+	gb := newBuffer(t, "the quick brown fox")
+	gb.SetSuggestionMode(true)
+	if err := gb.Replace(4, 9, "slow"); err != nil {
+		t.Fatal(err)
+	}
+	if err := gb.DeleteAt(9, 6); err != nil {
+		t.Fatal(err)
+	}
+	if err := gb.InsertAt(19, "!"); err != nil {
+		t.Fatal(err)
+	}
+	if gb.GetText() != "the quick brown fox" {
+		t.Fatalf("suggestions changed the text to %q", gb.GetText())
+	}
+	if got, want := gb.SuggestedText(), "the slow fox!"; got != want {
+		t.Errorf("suggested text: got %q, want %q", got, want)
+	}
+
+	s := gb.Suggestions()
+	if len(s) != 3 {
+		t.Fatalf("got %+v, want 3 suggestions", s)
+	}
+	if err := gb.AcceptSuggestion(s[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if gb.GetText() != "the slow brown fox" {
+		t.Fatalf("accepted: got %q", gb.GetText())
+	}
+	got := gb.Suggestions()
+	want := []buffer.Suggestion{
+		{ID: s[1].ID, Start: 8, End: 14},
+		{ID: s[2].ID, Start: 18, End: 18, Text: "!"},
+	}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("after accepting: got %+v, want %+v", got, want)
+	}
+
+	// An edit over the deletion drops it
+	gb.SetSuggestionMode(false)
+	if err := gb.Replace(9, 14, "green"); err != nil {
+		t.Fatal(err)
+	}
+	if got := gb.Suggestions(); len(got) != 1 || got[0].ID != s[2].ID {
+		t.Fatalf("after an overlapping edit: got %+v", got)
+	}
+
+	if err := gb.RejectSuggestion(s[2].ID); err != nil {
+		t.Fatal(err)
+	}
+	if gb.GetText() != "the slow green fox" || len(gb.Suggestions()) != 0 {
+		t.Errorf("rejected: got %q with %+v", gb.GetText(), gb.Suggestions())
+	}
+	if _, err := gb.Suggest(5, 100, "x"); !errors.Is(err, buffer.ErrInvalidRange) {
+		t.Errorf("suggestion past the end: got %v, want ErrInvalidRange", err)
+	}
+	for _, err := range []error{gb.AcceptSuggestion(s[2].ID), gb.RejectSuggestion(s[2].ID)} {
+		if !errors.Is(err, buffer.ErrSuggestionNotFound) {
+			t.Errorf("unknown id: got %v, want ErrSuggestionNotFound", err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package buffer
+
+import (
+	"sort"
+	"unicode"
+)
+
+// SuspiciousKind tells why a rune was flagged
+type SuspiciousKind int
+
+const (
+	SuspiciousBidi       SuspiciousKind = iota // Bidirectional control character, see Trojan Source
+	SuspiciousZeroWidth                        // Zero-width joiner outside an emoji sequence
+	SuspiciousConfusable                       // Character that looks like an ASCII one
+)
+
+// SuspiciousRune is a rune that may make the text read differently than it
+// is interpreted. Lookalike is the ASCII character a confusable resembles.
+type SuspiciousRune struct {
+	Pos       int
+	Size      int
+	Rune      rune
+	Kind      SuspiciousKind
+	Lookalike rune
+}
+
+// confusables maps homoglyphs of ASCII letters and digits to what they look
+// like. Fullwidth forms are handled separately.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'е': 'e', 'о': 'o', 'р': 'p', 'с': 'c', 'у': 'y', 'х': 'x',
+	'і': 'i', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w', 'һ': 'h', 'ӏ': 'l',
+	'А': 'A', 'В': 'B', 'Е': 'E', 'К': 'K', 'М': 'M', 'Н': 'H', 'О': 'O', 'Р': 'P',
+	'С': 'C', 'Т': 'T', 'Х': 'X', 'І': 'I', 'Ј': 'J', 'Ѕ': 'S', 'Ԛ': 'Q', 'Ԝ': 'W',
+	// Greek
+	'Α': 'A', 'Β': 'B', 'Ε': 'E', 'Ζ': 'Z', 'Η': 'H', 'Ι': 'I', 'Κ': 'K', 'Μ': 'M',
+	'Ν': 'N', 'Ο': 'O', 'Ρ': 'P', 'Τ': 'T', 'Υ': 'Y', 'Χ': 'X', 'ο': 'o', 'ν': 'v',
+	// Others
+	'ı': 'i', 'ℓ': 'l',
+}
+
+// SuspiciousRunes flags the runes in [start, end) that are commonly used to
+// make code read differently than it compiles: bidirectional controls,
+// zero-width joiners that do not join two emoji, and homoglyphs of ASCII
+// characters. To keep ordinary non-Latin text quiet, a confusable is only
+// flagged when its word also contains ASCII letters or consists entirely of
+// confusables, e.g. a Cyrillic "сор" posing as "cop".
+func (gb *GapBuffer) SuspiciousRunes(start int, end int) (runes []SuspiciousRune, err error) {
+	defer guard("SuspiciousRunes", start, gb.length, &err)
+
+	if start < 0 || end > gb.length || start > end {
+		return nil, ErrInvalidRange
+	}
+
+	// Confusables of the current word, flagged when the word ends
+	var word []SuspiciousRune
+	wordASCII, wordOther := false, false
+	endWord := func() {
+		if wordASCII || !wordOther {
+			runes = append(runes, word...)
+		}
+		word = word[:0]
+		wordASCII, wordOther = false, false
+	}
+
+	// A zero-width joiner is only known to be harmless once the next rune is seen
+	var prev rune
+	var zwj *SuspiciousRune
+
+	gb.scanRunes(start, end, func(pos int, r rune, size int) bool {
+		if zwj != nil {
+			if !isEmoji(prev) || !isEmoji(r) {
+				runes = append(runes, *zwj)
+			}
+			zwj = nil
+		}
+
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			endWord()
+		}
+
+		switch {
+		case isBidiControl(r):
+			runes = append(runes, SuspiciousRune{Pos: pos, Size: size, Rune: r, Kind: SuspiciousBidi})
+		case r == '\u200d':
+			zwj = &SuspiciousRune{Pos: pos, Size: size, Rune: r, Kind: SuspiciousZeroWidth}
+			// Keep the rune before the joiner to test it against the one after
+			return true
+		case r < unicode.MaxASCII:
+			if unicode.IsLetter(r) {
+				wordASCII = true
+			}
+		default:
+			if like, ok := confusableOf(r); ok {
+				word = append(word, SuspiciousRune{Pos: pos, Size: size, Rune: r, Kind: SuspiciousConfusable, Lookalike: like})
+			} else if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				wordOther = true
+			}
+		}
+		prev = r
+		return true
+	})
+
+	if zwj != nil {
+		runes = append(runes, *zwj)
+	}
+	endWord()
+
+	// Words are flagged when they end, so restore position order
+	sort.Slice(runes, func(i, j int) bool {
+		return runes[i].Pos < runes[j].Pos
+	})
+	return runes, nil
+}
+
+// confusableOf returns the ASCII character r is easily mistaken for
+func confusableOf(r rune) (rune, bool) {
+	if (r >= '０' && r <= '９') || (r >= 'Ａ' && r <= 'Ｚ') || (r >= 'ａ' && r <= 'ｚ') {
+		// Fullwidth letters and digits
+		return r - 0xfee0, true
+	}
+	like, ok := confusables[r]
+	return like, ok
+}
+
+// isBidiControl reports whether r changes the direction of the text around it
+func isBidiControl(r rune) bool {
+	switch {
+	case r == '\u061c', r == '\u200e', r == '\u200f':
+		return true
+	case r >= '\u202a' && r <= '\u202e':
+		return true
+	case r >= '\u2066' && r <= '\u2069':
+		return true
+	}
+	return false
+}
+
+// isEmoji reports whether r can take part in an emoji ZWJ sequence. It is
+// an approximation of the Unicode Extended_Pictographic property, which the
+// standard library does not provide.
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1f000 && r <= 0x1faff:
+		return true
+	case r >= 0x2300 && r <= 0x23ff, r >= 0x2600 && r <= 0x27bf, r >= 0x2b00 && r <= 0x2bff:
+		return true
+	case r == '\ufe0f' || r == '\u20e3' || r == '\u00a9' || r == '\u00ae' || r == '\u203c' || r == '\u2049':
+		return true
+	case r >= 0xe0020 && r <= 0xe007f:
+		// Tag characters of subdivision flags
+		return true
+	}
+	return false
+}
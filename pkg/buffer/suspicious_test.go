@@ -0,0 +1,57 @@
+package buffer_test
+
+import (
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestSuspiciousRunes checks what is flagged, and that emoji sequences and
+// ordinary non-Latin words are not
+func TestSuspiciousRunes(t *testing.T) {
+	type flag struct {
+		rune      rune
+		kind      buffer.SuspiciousKind
+		lookalike rune
+	}
+	tests := []struct {
+		name string
+		text string
+		want []flag
+	}{
+		{"plain", "if x == 1 { return }", nil},
+		{"trojan source", "/*\u202e } \u2066if (admin)\u2069 \u2066 begin*/", []flag{
+			{'\u202e', buffer.SuspiciousBidi, 0}, {'\u2066', buffer.SuspiciousBidi, 0},
+			{'\u2069', buffer.SuspiciousBidi, 0}, {'\u2066', buffer.SuspiciousBidi, 0},
+		}},
+		{"mixed word", "p\u0430ypal", []flag{{'\u0430', buffer.SuspiciousConfusable, 'a'}}},
+		{"all confusables", "\u0441\u043e\u0440 = 1", []flag{
+			{'\u0441', buffer.SuspiciousConfusable, 'c'}, {'\u043e', buffer.SuspiciousConfusable, 'o'},
+			{'\u0440', buffer.SuspiciousConfusable, 'p'},
+		}},
+		{"fullwidth", "ｘ1", []flag{{'ｘ', buffer.SuspiciousConfusable, 'x'}}},
+		{"russian", "привет мир", nil},
+		{"greek", "καλημέρα", nil},
+		{"emoji sequence", "👨\u200d👩\u200d👧", nil},
+		{"joiner in a word", "ad\u200dmin", []flag{{'\u200d', buffer.SuspiciousZeroWidth, 0}}},
+		{"joiner at the end", "x\u200d", []flag{{'\u200d', buffer.SuspiciousZeroWidth, 0}}},
+	}
+	for _, tt := range tests {
+		gb := newBuffer(t, tt.text)
+		got, err := gb.SuspiciousRunes(0, gb.Length())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+			continue
+		}
+		for i, r := range got {
+			w := tt.want[i]
+			text, _ := gb.GetTextRange(r.Pos, r.Pos+r.Size)
+			if r.Rune != w.rune || r.Kind != w.kind || r.Lookalike != w.lookalike || text != string(w.rune) {
+				t.Errorf("%s: flag %d: got %+v, want %+v", tt.name, i, r, w)
+			}
+		}
+	}
+}
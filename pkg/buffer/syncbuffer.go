@@ -0,0 +1,158 @@
+package buffer
+
+import (
+	"io"
+	"sync"
+)
+
+// SyncBuffer guards a GapBuffer with a mutex so that it can be shared
+// between goroutines, such as a UI thread and a language server client.
+// Every call takes the lock, reads included, since reads fill caches of
+// the buffer; readers that must not wait for edits read a Snapshot
+// instead. Write calls can be timed with SetSlowEditPolicy.
+type SyncBuffer struct {
+	mu       sync.Mutex
+	gb       *GapBuffer
+	slowEdit SlowEditPolicy // reports write calls holding the lock too long
+}
+
+// NewSyncBuffer returns a SyncBuffer guarding gb, which must not be used
+// directly afterwards
+func NewSyncBuffer(gb *GapBuffer) *SyncBuffer {
+	return &SyncBuffer{gb: gb}
+}
+
+// write runs fn, a write call named op on the range [start, end) inserting
+// inserted bytes, under the lock and timed by the slow edit policy
+func (s *SyncBuffer) write(op string, start int, end int, inserted int, fn func() error) error {
+	s.mu.Lock()
+	stop := s.watch(op, start, end, inserted)
+	defer func() {
+		s.mu.Unlock()
+		stop()
+	}()
+	return fn()
+}
+
+// InsertAt inserts text at the specified position
+func (s *SyncBuffer) InsertAt(pos int, text string) error {
+	return s.write("InsertAt", pos, pos, len(text), func() error {
+		return s.gb.InsertAt(pos, text)
+	})
+}
+
+// DeleteAt deletes text at the specified position
+func (s *SyncBuffer) DeleteAt(pos int, count int) error {
+	return s.write("DeleteAt", pos, pos+count, 0, func() error {
+		return s.gb.DeleteAt(pos, count)
+	})
+}
+
+// Replace replaces the text in the specified range
+func (s *SyncBuffer) Replace(start int, end int, text string) error {
+	return s.write("Replace", start, end, len(text), func() error {
+		return s.gb.Replace(start, end, text)
+	})
+}
+
+// ApplyEdits applies a set of edits atomically, see GapBuffer.ApplyEdits.
+// A slow call is reported with the range spanning all the edits.
+func (s *SyncBuffer) ApplyEdits(edits []Edit, policy OverlapPolicy) (applied []AppliedEdit, err error) {
+	start, end, inserted := noPos, noPos, 0
+	for i, e := range edits {
+		if i == 0 || e.Start < start {
+			start = e.Start
+		}
+		end = max(end, e.End)
+		inserted += len(e.Text)
+	}
+	err = s.write("ApplyEdits", start, end, inserted, func() error {
+		applied, err = s.gb.ApplyEdits(edits, policy)
+		return err
+	})
+	return applied, err
+}
+
+// Undo reverts the most recent step
+func (s *SyncBuffer) Undo() error {
+	return s.write("Undo", noPos, noPos, noPos, s.gb.Undo)
+}
+
+// Redo reapplies the most recently undone step
+func (s *SyncBuffer) Redo() error {
+	return s.write("Redo", noPos, noPos, noPos, s.gb.Redo)
+}
+
+// ReadFrom appends everything r produces as one undo step, see
+// GapBuffer.ReadFrom. The lock is held while r is read.
+func (s *SyncBuffer) ReadFrom(r io.Reader) (n int64, err error) {
+	err = s.write("ReadFrom", noPos, noPos, noPos, func() error {
+		n, err = s.gb.ReadFrom(r)
+		return err
+	})
+	return n, err
+}
+
+// Transaction runs fn in a transaction on the buffer under the lock, see
+// GapBuffer.Transaction. It is timed as one write call.
+func (s *SyncBuffer) Transaction(fn func(gb *GapBuffer) error) error {
+	return s.write("Transaction", noPos, noPos, noPos, func() error {
+		return s.gb.Transaction(func() error { return fn(s.gb) })
+	})
+}
+
+// Update runs fn with the buffer under the lock, for the calls SyncBuffer
+// has no method for. It is timed as one write call named op.
+func (s *SyncBuffer) Update(op string, fn func(gb *GapBuffer) error) error {
+	return s.write(op, noPos, noPos, noPos, func() error { return fn(s.gb) })
+}
+
+// View runs fn with the buffer under the lock, for reads SyncBuffer has
+// no method for. fn must not edit the buffer.
+func (s *SyncBuffer) View(fn func(gb *GapBuffer)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fn(s.gb)
+}
+
+// Snapshot returns a snapshot of the buffer, which can be read without
+// the lock
+func (s *SyncBuffer) Snapshot() *Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.gb.Snapshot()
+}
+
+// Length returns the length of the text in bytes
+func (s *SyncBuffer) Length() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.gb.Length()
+}
+
+// Version returns the current version of the buffer
+func (s *SyncBuffer) Version() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.gb.Version()
+}
+
+// GetText returns the text in the buffer
+func (s *SyncBuffer) GetText() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.gb.GetText()
+}
+
+// GetTextRange returns the text in the specified range
+func (s *SyncBuffer) GetTextRange(start int, end int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.gb.GetTextRange(start, end)
+}
@@ -0,0 +1,63 @@
+package buffer_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestSlowEditWatchdog blocks a write call in a change hook and checks that
+// the watchdog reports it while it still holds the lock, and again with
+// its whole duration once it returns
+func TestSlowEditWatchdog(t *testing.T) {
+	gb := buffer.New()
+	release := make(chan struct{})
+	gb.BeforeChange(func(start int, end int, text string) error {
+		<-release
+		return nil
+	})
+	s := buffer.NewSyncBuffer(gb)
+
+	if err := s.SetSlowEditPolicy(buffer.SlowEditPolicy{Threshold: time.Millisecond}); !errors.Is(err, buffer.ErrNoSlowEditReport) {
+		t.Fatalf("policy without Report: got %v, want ErrNoSlowEditReport", err)
+	}
+	reports := make(chan buffer.SlowEdit, 2)
+	err := s.SetSlowEditPolicy(buffer.SlowEditPolicy{
+		Threshold: 10 * time.Millisecond,
+		Report:    func(e buffer.SlowEdit) { reports <- e },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := s.ApplyEdits([]buffer.Edit{{Start: 0, End: 0, Text: "abc"}}, buffer.OverlapError)
+		done <- err
+	}()
+
+	running := <-reports
+	if running.Done || running.Op != "ApplyEdits" || running.Start != 0 || running.End != 0 || running.Inserted != 3 {
+		t.Fatalf("report while running: got %+v", running)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	finished := <-reports
+	if !finished.Done || finished.Duration < running.Duration {
+		t.Fatalf("report once returned: got %+v after %+v", finished, running)
+	}
+
+	// Fast calls are not reported
+	if err := s.InsertAt(0, "x"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-reports:
+		t.Fatalf("fast call reported: %+v", e)
+	case <-time.After(30 * time.Millisecond):
+	}
+}
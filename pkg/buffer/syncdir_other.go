@@ -0,0 +1,7 @@
+//go:build !unix
+
+package buffer
+
+// syncDir is a no-op where directories cannot be synced; renames are
+// durable once the file system commits them
+func syncDir(dir string) error { return nil }
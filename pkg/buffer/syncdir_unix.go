@@ -0,0 +1,16 @@
+//go:build unix
+
+package buffer
+
+import "os"
+
+// syncDir flushes the directory entries of dir to disk, so a rename in it
+// survives a crash
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,134 @@
+package buffer
+
+import "errors"
+
+// Errors returned by transactions
+var (
+	ErrNoTransaction     = errors.New("no transaction in progress")
+	ErrTransactionActive = errors.New("transaction in progress")
+)
+
+// savepoint is the state a transaction returns to on Rollback
+type savepoint struct {
+	ops         int           // number of operations applied before it
+	redo        []historyStep // redo steps, dropped by the first edit
+	suggestions []Suggestion  // pending suggestions
+}
+
+// transaction collects the operations applied since the outermost Begin
+type transaction struct {
+	ops   []Operation
+	saves []savepoint
+}
+
+// Begin starts a transaction: the edits until the matching Commit are
+// applied as usual, but Rollback reverts all of them. Transactions nest;
+// an inner Rollback only reverts the edits since the inner Begin. The
+// edits of a transaction form one undo step.
+func (gb *GapBuffer) Begin() {
+	gb.txn.saves = append(gb.txn.saves, savepoint{
+		ops:         len(gb.txn.ops),
+		redo:        gb.history.redo,
+		suggestions: gb.Suggestions(),
+	})
+	gb.BeginGroup()
+}
+
+// InTransaction reports whether a transaction is in progress
+func (gb *GapBuffer) InTransaction() bool {
+	return len(gb.txn.saves) > 0
+}
+
+// Commit ends the innermost transaction, keeping its edits
+func (gb *GapBuffer) Commit() (err error) {
+	defer guard("Commit", noPos, gb.length, &err)
+
+	if len(gb.txn.saves) == 0 {
+		return ErrNoTransaction
+	}
+	gb.commit()
+	return nil
+}
+
+// commit ends the innermost transaction, keeping its edits
+func (gb *GapBuffer) commit() {
+	save := gb.txn.saves[len(gb.txn.saves)-1]
+	gb.txn.saves = gb.txn.saves[:len(gb.txn.saves)-1]
+	if len(gb.txn.saves) == 0 {
+		gb.txn.ops = nil
+	}
+	gb.EndGroup()
+	// The redo steps the transaction dropped can no longer come back
+	gb.releaseSteps(save.redo)
+}
+
+// Rollback ends the innermost transaction and restores the text and the
+// pending suggestions to what they were at its Begin. The reverting edits
+// are regular operations with new versions, so change consumers follow
+// along, but they are not recorded as an undo step. If the edits cannot be
+// reverted, such as while a view is open, the transaction still ends, as
+// if committed, and the error is returned.
+func (gb *GapBuffer) Rollback() (err error) {
+	defer guard("Rollback", noPos, gb.length, &err)
+
+	if len(gb.txn.saves) == 0 {
+		return ErrNoTransaction
+	}
+	save := gb.txn.saves[len(gb.txn.saves)-1]
+
+	ops := gb.txn.ops[save.ops:]
+	inverse := make([]Edit, len(ops))
+	for i, op := range ops {
+		inverse[len(ops)-1-i] = Edit{Start: op.Pos, End: op.Pos + op.Inserted, Text: op.DeletedText}
+	}
+	if err := gb.replay(inverse); err != nil {
+		gb.commit()
+		return err
+	}
+
+	// Forget the reverted edits as if they never happened
+	h := &gb.history
+	if n := len(h.group) - len(ops); n >= 0 {
+		h.group = h.group[:n]
+	}
+	h.redo = save.redo
+	gb.suggestions.items = gb.suggestions.items[:0]
+	for _, s := range save.suggestions {
+		gb.suggestions.items = append(gb.suggestions.items, &s)
+	}
+
+	gb.txn.ops = gb.txn.ops[:save.ops]
+	gb.txn.saves = gb.txn.saves[:len(gb.txn.saves)-1]
+	gb.EndGroup()
+	return nil
+}
+
+// Transaction runs fn in a transaction, committing it if fn returns nil and
+// rolling it back otherwise. It returns the error of fn. If fn panics, the
+// transaction is rolled back before the panic goes on.
+func (gb *GapBuffer) Transaction(fn func() error) error {
+	gb.Begin()
+	returned := false
+	defer func() {
+		if !returned {
+			gb.Rollback()
+		}
+	}()
+	err := fn()
+	returned = true
+	if err != nil {
+		if rbErr := gb.Rollback(); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+	return gb.Commit()
+}
+
+// recordTransaction remembers an applied operation for Rollback
+func (gb *GapBuffer) recordTransaction(op Operation) {
+	if len(gb.txn.saves) == 0 || gb.history.replaying {
+		return
+	}
+	gb.txn.ops = append(gb.txn.ops, op)
+}
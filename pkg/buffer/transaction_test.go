@@ -0,0 +1,114 @@
+package buffer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestTransaction checks that a failed edit rolls the whole transaction
+// back and that a committed one is undone as one step
+func TestTransaction(t *testing.T) {
+	gb := newBuffer(t, "hello")
+	err := gb.Transaction(func() error {
+		if err := gb.InsertAt(5, " world"); err != nil {
+			return err
+		}
+		if err := gb.DeleteAt(0, 1); err != nil {
+			return err
+		}
+		return gb.Replace(5, 100, "")
+	})
+	if !errors.Is(err, buffer.ErrInvalidRange) || gb.GetText() != "hello" || gb.InTransaction() {
+		t.Fatalf("got %q, %v", gb.GetText(), err)
+	}
+	// The reverted edits are no undo step
+	gb.Undo()
+	if gb.GetText() != "" {
+		t.Errorf("undo after rollback: got %q", gb.GetText())
+	}
+	gb.Redo()
+
+	if err := gb.Transaction(func() error {
+		gb.InsertAt(5, "!")
+		return gb.Replace(0, 1, "H")
+	}); err != nil || gb.GetText() != "Hello!" {
+		t.Fatalf("got %q, %v", gb.GetText(), err)
+	}
+	if err := gb.Undo(); err != nil || gb.GetText() != "hello" {
+		t.Errorf("undo of the transaction: got %q, %v", gb.GetText(), err)
+	}
+}
+
+// TestTransactionNested checks that an inner rollback only reverts the
+// inner edits, and the errors outside of a transaction
+func TestTransactionNested(t *testing.T) {
+	gb := newBuffer(t, "a")
+	gb.Begin()
+	gb.InsertAt(1, "b")
+	gb.Begin()
+	gb.InsertAt(2, "c")
+	if err := gb.Undo(); !errors.Is(err, buffer.ErrTransactionActive) {
+		t.Errorf("undo in a transaction: %v", err)
+	}
+	if err := gb.Rollback(); err != nil || gb.GetText() != "ab" {
+		t.Fatalf("inner rollback: got %q, %v", gb.GetText(), err)
+	}
+	gb.Begin()
+	gb.InsertAt(2, "d")
+	if err := gb.Commit(); err != nil || !gb.InTransaction() {
+		t.Fatalf("inner commit: %v", err)
+	}
+	if err := gb.Rollback(); err != nil || gb.GetText() != "a" {
+		t.Fatalf("outer rollback: got %q, %v", gb.GetText(), err)
+	}
+
+	if err := gb.Commit(); !errors.Is(err, buffer.ErrNoTransaction) {
+		t.Errorf("commit without a transaction: %v", err)
+	}
+	if err := gb.Rollback(); !errors.Is(err, buffer.ErrNoTransaction) {
+		t.Errorf("rollback without a transaction: %v", err)
+	}
+}
+
+// TestTransactionPanic checks that a panicking transaction is rolled back
+func TestTransactionPanic(t *testing.T) {
+	gb := newBuffer(t, "a")
+	func() {
+		defer func() { recover() }()
+		gb.Transaction(func() error {
+			gb.InsertAt(1, "b")
+			panic("boom")
+		})
+	}()
+	if gb.GetText() != "a" || gb.InTransaction() {
+		t.Errorf("got %q, in transaction %v", gb.GetText(), gb.InTransaction())
+	}
+}
+
+// TestRollbackFails checks that a rollback refused while a view is open
+// still ends the transaction, so that undo works again afterwards
+func TestRollbackFails(t *testing.T) {
+	gb := newBuffer(t, "hello")
+	gb.Begin()
+	gb.InsertAt(5, " world")
+	err := gb.View(func(buffer.ReadView) error {
+		return gb.Rollback()
+	})
+	if !errors.Is(err, buffer.ErrViewActive) {
+		t.Fatalf("got %v, want ErrViewActive", err)
+	}
+	if gb.InTransaction() {
+		t.Fatal("failed rollback left the transaction open")
+	}
+	if err := gb.Rollback(); !errors.Is(err, buffer.ErrNoTransaction) {
+		t.Errorf("second rollback: got %v, want ErrNoTransaction", err)
+	}
+	if gb.GetText() != "hello world" {
+		t.Errorf("got %q", gb.GetText())
+	}
+	if err := gb.Undo(); err != nil || gb.GetText() != "hello" {
+		t.Errorf("undo: got %q, %v", gb.GetText(), err)
+	}
+}
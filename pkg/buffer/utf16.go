@@ -0,0 +1,88 @@
+package buffer
+
+// UTF16Length returns the length of the text in UTF-16 code units, as
+// JavaScript, Windows and the Language Server Protocol count it. The tree
+// keeps the count per chunk, so this takes constant time.
+func (gb *GapBuffer) UTF16Length() int {
+	return gb.tree.total().units
+}
+
+// ByteToUTF16 returns the number of UTF-16 code units before the byte
+// position pos. A position inside a rune counts the whole rune.
+func (gb *GapBuffer) ByteToUTF16(pos int) (units int, err error) {
+	defer guard("ByteToUTF16", pos, gb.length, &err)
+
+	if pos < 0 || pos > gb.length {
+		return 0, ErrPositionOutOfRange
+	}
+	return gb.unitsBefore(pos), nil
+}
+
+// UTF16ToByte returns the byte position after the first units UTF-16 code
+// units. An offset between the two halves of a surrogate pair maps to the
+// start of its rune.
+func (gb *GapBuffer) UTF16ToByte(units int) (pos int, err error) {
+	defer guard("UTF16ToByte", units, gb.length, &err)
+
+	if units < 0 || units > gb.UTF16Length() {
+		return 0, ErrPositionOutOfRange
+	}
+	return gb.unitsPos(units), nil
+}
+
+// unitsBefore returns the number of UTF-16 code units before pos, using the
+// counts the tree keeps per subtree
+func (gb *GapBuffer) unitsBefore(pos int) int {
+	chunk, before, _, ok, _ := gb.tree.seek(func(before measures, m measures) bool {
+		return pos < before.size+m.size
+	})
+	if !ok {
+		return before.units
+	}
+	return before.units + textUnits(chunk.Text[:pos-before.size])
+}
+
+// unitsPos returns the start of the rune containing the UTF-16 code unit at
+// offset units, or the buffer length for the offset of its end
+func (gb *GapBuffer) unitsPos(units int) int {
+	chunk, before, _, ok, _ := gb.tree.seek(func(before measures, m measures) bool {
+		return units < before.units+m.units
+	})
+	if !ok {
+		return gb.length
+	}
+	units -= before.units
+	text := chunk.Text
+	for i := 0; i < len(text); i++ {
+		n := byteUnits(text[i])
+		if units < n {
+			return before.size + i
+		}
+		units -= n
+	}
+	return before.size + len(text)
+}
+
+// textUnits returns the length of text in UTF-16 code units. It counts the
+// lead bytes of runes, so counts of pieces split anywhere add up; stray
+// continuation bytes of invalid text count for nothing.
+func textUnits(text string) int {
+	units := 0
+	for i := 0; i < len(text); i++ {
+		units += byteUnits(text[i])
+	}
+	return units
+}
+
+// byteUnits returns the number of UTF-16 code units of the rune b starts:
+// two for the lead byte of a four-byte sequence, none for a continuation
+// byte and one otherwise
+func byteUnits(b byte) int {
+	switch {
+	case b&0xC0 == 0x80:
+		return 0
+	case b >= 0xF0 && b <= 0xF4:
+		return 2
+	}
+	return 1
+}
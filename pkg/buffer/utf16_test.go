@@ -0,0 +1,64 @@
+package buffer_test
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestUTF16 checks the conversions against encoding the text as UTF-16,
+// on texts mixing runes of every length split across small chunks
+func TestUTF16(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	runes := []string{"a", "é", "€", "\U0001F600", "\n"}
+	gb := buffer.NewWithChunkSize(5)
+	for i := 0; i < 200; i++ {
+		pos := rng.Intn(gb.Length() + 1)
+		for pos > 0 && pos < gb.Length() && !utf8.RuneStart(gb.Bytes()[pos]) {
+			pos--
+		}
+		gb.InsertAt(pos, runes[rng.Intn(len(runes))])
+	}
+	gb.RandomizeLayout(1)
+
+	text := gb.GetText()
+	if want := len(utf16.Encode([]rune(text))); gb.UTF16Length() != want {
+		t.Fatalf("got length %d, want %d", gb.UTF16Length(), want)
+	}
+	for pos := range text + "." {
+		pos = min(pos, len(text))
+		want := len(utf16.Encode([]rune(text[:pos])))
+		units, err := gb.ByteToUTF16(pos)
+		if err != nil || units != want {
+			t.Fatalf("byte %d: got %d, %v, want %d", pos, units, err, want)
+		}
+		if back, err := gb.UTF16ToByte(units); err != nil || back != pos {
+			t.Fatalf("units %d: got %d, %v, want %d", units, back, err, pos)
+		}
+	}
+}
+
+// TestUTF16Split checks positions inside runes and surrogate pairs, and
+// the errors
+func TestUTF16Split(t *testing.T) {
+	gb := newBuffer(t, "é\U0001F600")
+	if units, _ := gb.ByteToUTF16(1); units != 1 {
+		t.Errorf("inside é: got %d, want 1", units)
+	}
+	if units, _ := gb.ByteToUTF16(3); units != 3 {
+		t.Errorf("inside the emoji: got %d, want 3", units)
+	}
+	if pos, _ := gb.UTF16ToByte(2); pos != 2 {
+		t.Errorf("inside the surrogate pair: got %d, want 2", pos)
+	}
+	if _, err := gb.ByteToUTF16(7); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("byte past the end: %v", err)
+	}
+	if _, err := gb.UTF16ToByte(4); !errors.Is(err, buffer.ErrPositionOutOfRange) {
+		t.Errorf("units past the end: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package buffer
+
+import "time"
+
+// VersionSnapshot is a retained version of the buffer text
+type VersionSnapshot struct {
+	Version  int
+	Time     time.Time
+	Metadata Metadata // Metadata of the edit that produced the version
+	Text     *Rope    // Immutable text of the version; clone it before editing
+}
+
+// versionChain keeps the last versions of the buffer as ropes sharing structure
+type versionChain struct {
+	limit    int
+	current  *Rope
+	versions []VersionSnapshot // oldest first, consecutive version numbers
+}
+
+// SetVersionRetention keeps the last n versions of the text alive. Versions
+// are persistent ropes that share unchanged structure, so each edit costs
+// O(log n) extra work and memory. Enabling retention copies the current text
+// once; n <= 0 disables it.
+func (gb *GapBuffer) SetVersionRetention(n int) {
+	if n <= 0 {
+		gb.versions = nil
+		return
+	}
+	if gb.versions == nil {
+		rope := NewRopeFromString(gb.rawText(0, gb.length))
+		gb.versions = &versionChain{current: rope}
+		gb.versions.add(VersionSnapshot{Version: gb.version, Time: time.Now(), Text: rope.Clone()})
+	}
+	gb.versions.limit = n
+	gb.versions.trim()
+}
+
+// Versions returns the retained versions, oldest first
+func (gb *GapBuffer) Versions() []VersionSnapshot {
+	if gb.versions == nil {
+		return nil
+	}
+	return append([]VersionSnapshot(nil), gb.versions.versions...)
+}
+
+// VersionAt returns the retained text of a version in constant time
+func (gb *GapBuffer) VersionAt(version int) (r *Rope, err error) {
+	defer guard("VersionAt", noPos, gb.length, &err)
+
+	if gb.versions == nil || len(gb.versions.versions) == 0 {
+		return nil, ErrVersionUnavailable
+	}
+	first := gb.versions.versions[0].Version
+	i := version - first
+	if i < 0 || i >= len(gb.versions.versions) {
+		return nil, ErrVersionUnavailable
+	}
+	return gb.versions.versions[i].Text.Clone(), nil
+}
+
+// recordVersion applies op to the rope mirror and retains the new version
+func (gb *GapBuffer) recordVersion(op Operation) {
+	chain := gb.versions
+	if chain == nil {
+		return
+	}
+	if err := chain.current.Replace(op.Pos, op.Pos+op.Deleted, op.InsertedText); err != nil {
+		// The mirror is out of sync, rebuild it from the buffer
+		chain.current = NewRopeFromString(gb.rawText(0, gb.length))
+		chain.versions = nil
+	}
+	chain.add(VersionSnapshot{Version: op.Version, Time: op.Time, Metadata: op.Metadata, Text: chain.current.Clone()})
+}
+
+// add appends a version and drops the oldest ones beyond the limit
+func (c *versionChain) add(v VersionSnapshot) {
+	c.versions = append(c.versions, v)
+	c.trim()
+}
+
+// trim drops the oldest versions beyond the limit
+func (c *versionChain) trim() {
+	for len(c.versions) > c.limit {
+		// Clear the slot so the dropped rope can be collected
+		c.versions[0] = VersionSnapshot{}
+		c.versions = c.versions[1:]
+	}
+}
@@ -0,0 +1,52 @@
+package buffer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestVersionRetention checks that the last versions are kept with their
+// text and metadata, that older ones are dropped, and that the ropes
+// handed out are copies
+func TestVersionRetention(t *testing.T) {
+	gb := newBuffer(t, "v1")
+	gb.SetVersionRetention(3)
+	texts := map[int]string{1: "v1"}
+	for i, s := range []string{"a", "b", "c"} {
+		gb.WithMetadata(buffer.Metadata{"edit": s}, func() error {
+			return gb.InsertAt(gb.Length(), s)
+		})
+		texts[i+2] = gb.GetText()
+	}
+
+	versions := gb.Versions()
+	if len(versions) != 3 || versions[0].Version != 2 || versions[2].Version != 4 {
+		t.Fatalf("got %+v, want versions 2 to 4", versions)
+	}
+	for _, v := range versions {
+		if v.Text.GetText() != texts[v.Version] || v.Metadata["edit"] == nil {
+			t.Errorf("version %d: got %q with %v", v.Version, v.Text.GetText(), v.Metadata)
+		}
+	}
+
+	r, err := gb.VersionAt(3)
+	if err != nil || r.GetText() != "v1ab" {
+		t.Fatalf("version 3: got %v, %v", r, err)
+	}
+	r.InsertAt(0, "edited ")
+	if r, _ := gb.VersionAt(3); r.GetText() != "v1ab" {
+		t.Errorf("editing a returned rope changed the version to %q", r.GetText())
+	}
+	for _, v := range []int{1, 5} {
+		if _, err := gb.VersionAt(v); !errors.Is(err, buffer.ErrVersionUnavailable) {
+			t.Errorf("version %d: got %v, want ErrVersionUnavailable", v, err)
+		}
+	}
+
+	gb.SetVersionRetention(0)
+	if gb.Versions() != nil {
+		t.Error("versions kept with retention disabled")
+	}
+}
@@ -0,0 +1,61 @@
+package buffer
+
+import "errors"
+
+// ErrViewActive is returned by edits attempted while a read view is open
+var ErrViewActive = errors.New("buffer is frozen by an open view")
+
+// ReadView offers the read APIs of a buffer against a single frozen version
+type ReadView interface {
+	Version() int
+	Length() int
+	RuneLength() int
+	GetText() string
+	GetTextRange(start int, end int) (string, error)
+	GetRuneTextRange(runeStart int, runeEnd int) (string, error)
+	Suggestions() []Suggestion
+	SuggestedText() string
+	Operations(since int) ([]Operation, error)
+	BlameRange(start int, end int) ([]BlameSpan, error)
+}
+
+// readView exposes only the read APIs of the buffer, so fn cannot reach the
+// edit methods through a type assertion
+type readView struct {
+	gb *GapBuffer
+}
+
+func (v readView) Version() int    { return v.gb.Version() }
+func (v readView) Length() int     { return v.gb.Length() }
+func (v readView) RuneLength() int { return v.gb.RuneLength() }
+func (v readView) GetText() string { return v.gb.GetText() }
+
+func (v readView) GetTextRange(start int, end int) (string, error) {
+	return v.gb.GetTextRange(start, end)
+}
+
+func (v readView) GetRuneTextRange(runeStart int, runeEnd int) (string, error) {
+	return v.gb.GetRuneTextRange(runeStart, runeEnd)
+}
+
+func (v readView) Suggestions() []Suggestion { return v.gb.Suggestions() }
+func (v readView) SuggestedText() string     { return v.gb.SuggestedText() }
+
+func (v readView) Operations(since int) ([]Operation, error) {
+	return v.gb.Operations(since)
+}
+
+func (v readView) BlameRange(start int, end int) ([]BlameSpan, error) {
+	return v.gb.BlameRange(start, end)
+}
+
+// View calls fn with a read view of the buffer. The buffer is frozen until fn
+// returns: every edit attempted meanwhile, for example from a callback, fails
+// with ErrViewActive, so all queries made through v see the same version.
+// Views may be nested. The buffer itself is not safe for concurrent use;
+// goroutines sharing it still need their own locking.
+func (gb *GapBuffer) View(fn func(v ReadView) error) error {
+	gb.views++
+	defer func() { gb.views-- }()
+	return fn(readView{gb: gb})
+}
@@ -0,0 +1,50 @@
+package buffer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestView checks that edits fail while a view is open, nested or not,
+// and succeed again once it is closed
+func TestView(t *testing.T) {
+	gb := newBuffer(t, "frozen")
+	sentinel := errors.New("from fn")
+
+	err := gb.View(func(v buffer.ReadView) error {
+		if v.Version() != 1 || v.GetText() != "frozen" {
+			t.Errorf("view: version %d, text %q", v.Version(), v.GetText())
+		}
+		edits := map[string]error{
+			"InsertAt": gb.InsertAt(0, "x"),
+			"Replace":  gb.Replace(0, 1, "x"),
+			"ApplyEdits": func() error {
+				_, err := gb.ApplyEdits([]buffer.Edit{{Start: 0, End: 0, Text: "x"}}, buffer.OverlapError)
+				return err
+			}(),
+			"Undo": gb.Undo(),
+		}
+		for name, err := range edits {
+			if !errors.Is(err, buffer.ErrViewActive) {
+				t.Errorf("%s in a view: got %v, want ErrViewActive", name, err)
+			}
+		}
+		return gb.View(func(inner buffer.ReadView) error {
+			if err := gb.DeleteAt(0, 1); !errors.Is(err, buffer.ErrViewActive) {
+				t.Errorf("DeleteAt in a nested view: got %v, want ErrViewActive", err)
+			}
+			return sentinel
+		})
+	})
+	if err != sentinel {
+		t.Errorf("View returned %v, want the error of fn", err)
+	}
+	if gb.Version() != 1 || gb.GetText() != "frozen" {
+		t.Fatalf("after the view: version %d, text %q", gb.Version(), gb.GetText())
+	}
+	if err := gb.InsertAt(0, "un"); err != nil {
+		t.Errorf("after the view: %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package buffer
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// WhitespaceKind classifies a whitespace mark
+type WhitespaceKind int
+
+const (
+	WhitespaceTab       WhitespaceKind = iota // Tabs inside a line
+	WhitespaceTrailing                        // Spaces and tabs at the end of a line
+	WhitespaceNBSP                            // Non-breaking spaces
+	WhitespaceOther                           // Other Unicode spaces, e.g. em space or ideographic space
+	WhitespaceInvisible                       // Zero-width, format and control characters
+)
+
+// WhitespaceMark is a run of characters of the same kind in [Start, End)
+type WhitespaceMark struct {
+	Start int
+	End   int
+	Kind  WhitespaceKind
+}
+
+// WhitespaceMap returns the positions of the characters a "render whitespace"
+// feature draws in [start, end): tabs, trailing spaces and tabs, non-breaking
+// spaces, other Unicode spaces and invisible characters. Plain spaces inside a
+// line and line endings are not reported. Adjacent characters of the same kind
+// are reported as one mark. Only the range is decoded, chunk by chunk, plus
+// the rest of its last line when it ends in spaces.
+func (gb *GapBuffer) WhitespaceMap(start int, end int) (marks []WhitespaceMark, err error) {
+	defer guard("WhitespaceMap", start, gb.length, &err)
+
+	if start < 0 || end > gb.length || start > end {
+		return nil, ErrInvalidRange
+	}
+
+	add := func(m WhitespaceMark) {
+		if n := len(marks); n > 0 && marks[n-1].Kind == m.Kind && marks[n-1].End == m.Start {
+			marks[n-1].End = m.End
+			return
+		}
+		marks = append(marks, m)
+	}
+
+	// Whether a run of spaces and tabs is trailing is only known at the next
+	// character, so its tabs are held back until then
+	runStart, runEnd := -1, -1
+	var runTabs []int
+	endRun := func(trailing bool) {
+		if runStart < 0 {
+			return
+		}
+		if trailing {
+			add(WhitespaceMark{Start: runStart, End: runEnd, Kind: WhitespaceTrailing})
+		} else {
+			for _, pos := range runTabs {
+				add(WhitespaceMark{Start: pos, End: pos + 1, Kind: WhitespaceTab})
+			}
+		}
+		runStart = -1
+		runTabs = runTabs[:0]
+	}
+
+	gb.scanRunes(start, end, func(pos int, r rune, size int) bool {
+		switch r {
+		case ' ', '\t':
+			if runStart < 0 {
+				runStart = pos
+			}
+			if r == '\t' {
+				runTabs = append(runTabs, pos)
+			}
+			runEnd = pos + size
+		case '\n':
+			endRun(true)
+		case '\r':
+			// Part of a CRLF line ending
+		default:
+			endRun(false)
+			if kind, ok := whitespaceKind(r, size); ok {
+				add(WhitespaceMark{Start: pos, End: pos + size, Kind: kind})
+			}
+		}
+		return true
+	})
+
+	if runStart >= 0 {
+		// The run is trailing if nothing but spaces follows it on its line
+		trailing := true
+		gb.scanRunes(end, gb.length, func(_ int, r rune, _ int) bool {
+			switch r {
+			case ' ', '\t', '\r':
+				return true
+			case '\n':
+				return false
+			}
+			trailing = false
+			return false
+		})
+		endRun(trailing)
+	}
+	return marks, nil
+}
+
+// whitespaceKind classifies a character that is not a space, tab or line ending
+func whitespaceKind(r rune, size int) (WhitespaceKind, bool) {
+	switch {
+	case r == '\u00a0' || r == '\u2007' || r == '\u202f':
+		return WhitespaceNBSP, true
+	case unicode.IsSpace(r):
+		return WhitespaceOther, true
+	case r == utf8.RuneError && size == 1:
+		// Invalid byte, drawn as a replacement character rather than as whitespace
+		return 0, false
+	case unicode.IsControl(r) || unicode.Is(unicode.Cf, r) || r == '\u115f' || r == '\u1160' || r == '\u3164':
+		return WhitespaceInvisible, true
+	}
+	return 0, false
+}
+
+// scanRunes decodes the runes in [start, end) chunk by chunk and calls fn
+// with each rune and its position until fn returns false. Runes split across
+// chunks are reassembled; invalid bytes are reported as utf8.RuneError of size 1.
+func (gb *GapBuffer) scanRunes(start int, end int, fn func(pos int, r rune, size int) bool) {
+	var partial []byte // start of a rune continued in the next chunk
+	partialPos := 0
+	stopped := false
+
+	gb.forEachChunk(start, end, func(pos int, text string) bool {
+		i := 0
+		if len(partial) > 0 {
+			// Decode the runes starting in the bytes left over from the
+			// previous chunk, borrowing what they need from this one
+			buf := append(partial, text[:min(len(text), utf8.UTFMax)]...)
+			done := 0
+			for done < len(partial) {
+				if !utf8.FullRune(buf[done:]) && len(text) < utf8.UTFMax {
+					partial = append([]byte(nil), buf[done:]...)
+					partialPos += done
+					return true
+				}
+				r, size := utf8.DecodeRune(buf[done:])
+				if !fn(partialPos+done, r, size) {
+					stopped = true
+					return false
+				}
+				done += size
+			}
+			i = done - len(partial)
+			partial = nil
+		}
+
+		for i < len(text) {
+			if !utf8.FullRuneInString(text[i:]) {
+				partial = append(partial, text[i:]...)
+				partialPos = pos + i
+				return true
+			}
+			r, size := utf8.DecodeRuneInString(text[i:])
+			if !fn(pos+i, r, size) {
+				stopped = true
+				return false
+			}
+			i += size
+		}
+		return true
+	})
+
+	// An incomplete rune at the end of the range
+	for len(partial) > 0 && !stopped {
+		r, size := utf8.DecodeRune(partial)
+		stopped = !fn(partialPos, r, size)
+		partial = partial[size:]
+		partialPos += size
+	}
+}
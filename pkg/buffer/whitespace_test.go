@@ -0,0 +1,46 @@
+package buffer_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestWhitespaceMap checks each kind of mark, trailing runs seen past the
+// end of the range, and runes split between chunks
+func TestWhitespaceMap(t *testing.T) {
+	const text = "a\tb  \nc\u00a0d\u3000e\u200bf \t\r\ng  h"
+	tab, trailing, nbsp, other, invisible := buffer.WhitespaceTab, buffer.WhitespaceTrailing,
+		buffer.WhitespaceNBSP, buffer.WhitespaceOther, buffer.WhitespaceInvisible
+	tests := []struct {
+		start, end int
+		want       []buffer.WhitespaceMark
+	}{
+		{0, len(text), []buffer.WhitespaceMark{
+			{Start: 1, End: 2, Kind: tab},
+			{Start: 3, End: 5, Kind: trailing},
+			{Start: 7, End: 9, Kind: nbsp},
+			{Start: 10, End: 13, Kind: other},
+			{Start: 14, End: 17, Kind: invisible},
+			{Start: 18, End: 20, Kind: trailing},
+		}},
+		// A run cut by the end of the range is trailing if its line is
+		{3, 4, []buffer.WhitespaceMark{{Start: 3, End: 4, Kind: trailing}}},
+		{17, 19, []buffer.WhitespaceMark{{Start: 18, End: 19, Kind: trailing}}},
+		{0, 2, []buffer.WhitespaceMark{{Start: 1, End: 2, Kind: tab}}},
+	}
+	for _, chunkSize := range []int{1, 3, 64} {
+		gb := buffer.NewWithChunkSize(chunkSize)
+		gb.InsertAt(0, text)
+		for _, tt := range tests {
+			got, err := gb.WhitespaceMap(tt.start, tt.end)
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chunks of %d, [%d, %d): got %+v, %v, want %+v", chunkSize, tt.start, tt.end, got, err, tt.want)
+			}
+		}
+		if _, err := gb.WhitespaceMap(5, 3); err == nil {
+			t.Error("reversed range: got no error")
+		}
+	}
+}
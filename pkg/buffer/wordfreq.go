@@ -0,0 +1,288 @@
+package buffer
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// WordFrequency is a word of the buffer and the number of times it occurs
+type WordFrequency struct {
+	Word  string
+	Count int
+}
+
+// WordMatch is a word of the buffer whose start approximately matches a
+// query with Errors rune insertions, deletions or substitutions
+type WordMatch struct {
+	Word   string
+	Count  int
+	Errors int
+}
+
+// wordIndex counts the words of a buffer, runs of letters, digits and
+// underscores, keeps them sorted for prefix queries and records where they
+// occur
+type wordIndex struct {
+	counts      map[string]int
+	sorted      []string
+	occurrences []wordOccurrence // sorted by position
+}
+
+// wordOccurrence is a word at a position. The word shares its storage with
+// the entry of the sorted words.
+type wordOccurrence struct {
+	pos  int
+	word string
+}
+
+// SetWordIndex turns the word index on or off. The whole buffer is scanned
+// once; afterwards an edit only recounts the words it touches and shifts
+// the positions of those after it, so completion can query the index on
+// every keystroke.
+func (gb *GapBuffer) SetWordIndex(on bool) {
+	if !on {
+		gb.words = nil
+		return
+	}
+	gb.words = &wordIndex{counts: make(map[string]int)}
+	var word strings.Builder
+	start := 0
+	gb.scanRunes(0, gb.length, func(pos int, r rune, _ int) bool {
+		switch {
+		case isWordRune(r):
+			if word.Len() == 0 {
+				start = pos
+			}
+			word.WriteRune(r)
+		case word.Len() > 0:
+			gb.words.occurrences = append(gb.words.occurrences, gb.words.add(start, word.String()))
+			word.Reset()
+		}
+		return true
+	})
+	if word.Len() > 0 {
+		gb.words.occurrences = append(gb.words.occurrences, gb.words.add(start, word.String()))
+	}
+}
+
+// WordCompletions returns up to n words starting with prefix, prefix itself
+// excluded, the most frequent first and then alphabetically, or all of them
+// if n <= 0. It returns nil if the word index is off.
+func (gb *GapBuffer) WordCompletions(prefix string, n int) []WordFrequency {
+	if gb.words == nil {
+		return nil
+	}
+	sorted := gb.words.sorted
+	i := sort.SearchStrings(sorted, prefix)
+	var matches []WordFrequency
+	for ; i < len(sorted) && strings.HasPrefix(sorted[i], prefix); i++ {
+		if sorted[i] != prefix {
+			matches = append(matches, WordFrequency{Word: sorted[i], Count: gb.words.counts[sorted[i]]})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Count > matches[j].Count
+	})
+	if n > 0 && len(matches) > n {
+		matches = matches[:n]
+	}
+	return matches
+}
+
+// FuzzyWordCompletions returns up to n words starting with text that
+// differs from query by at most maxErrors rune edits, query itself
+// excluded, the fewest errors first, then the most frequent, then
+// alphabetically, or all of them if n <= 0. The sorted words are walked as
+// a trie: the edit distance rows of the runes a word shares with the
+// previous one are reused, and a word is left as soon as its start can no
+// longer match. It returns nil if the word index is off.
+func (gb *GapBuffer) FuzzyWordCompletions(query string, maxErrors int, n int) []WordMatch {
+	if gb.words == nil || maxErrors < 0 {
+		return nil
+	}
+	pattern := []rune(query)
+	m := len(pattern)
+
+	// rows[d][j] is the distance between the first d runes of the current
+	// word and the first j runes of the pattern; best[d] is the smallest
+	// rows[e][m] for e <= d, the errors of the best matching start
+	rows := [][]int{make([]int, m+1)}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	best := []int{m}
+
+	var matches []WordMatch
+	var prev []rune
+	for _, word := range gb.words.sorted {
+		runes := []rune(word)
+		depth := 0
+		for depth < len(rows)-1 && depth < len(runes) && prev[depth] == runes[depth] {
+			depth++
+		}
+		rows, best = rows[:depth+1], best[:depth+1]
+		for depth < len(runes) && minInts(rows[depth]) <= maxErrors {
+			row := make([]int, m+1)
+			row[0] = depth + 1
+			for j := 1; j <= m; j++ {
+				row[j] = rows[depth][j-1]
+				if pattern[j-1] != runes[depth] {
+					row[j] = min(rows[depth][j-1], rows[depth][j], row[j-1]) + 1
+				}
+			}
+			rows = append(rows, row)
+			best = append(best, min(best[depth], row[m]))
+			depth++
+		}
+		prev = runes
+
+		if errors := best[depth]; errors <= maxErrors && word != query {
+			matches = append(matches, WordMatch{Word: word, Count: gb.words.counts[word], Errors: errors})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Errors != matches[j].Errors {
+			return matches[i].Errors < matches[j].Errors
+		}
+		return matches[i].Count > matches[j].Count
+	})
+	if n > 0 && len(matches) > n {
+		matches = matches[:n]
+	}
+	return matches
+}
+
+// WordOccurrences returns the number of times word occurs in the buffer as
+// a whole word, or 0 if the word index is off
+func (gb *GapBuffer) WordOccurrences(word string) int {
+	if gb.words == nil {
+		return 0
+	}
+	return gb.words.counts[word]
+}
+
+// WordPositions returns the start of every occurrence of word as a whole
+// word, in order, or nil if the word index is off
+func (gb *GapBuffer) WordPositions(word string) []int {
+	if gb.words == nil {
+		return nil
+	}
+	var positions []int
+	for _, o := range gb.words.occurrences {
+		if o.word == word {
+			positions = append(positions, o.pos)
+		}
+	}
+	return positions
+}
+
+// updateWords recounts the words touching op: the occurrences from the
+// start of the word around the edit to the end of the word after it are
+// replaced with the words found there now, and the ones after are shifted
+func (gb *GapBuffer) updateWords(op Operation) {
+	if gb.words == nil {
+		return
+	}
+	w := gb.words
+
+	// A byte edit can complete or break a rune across either end, before
+	// or after it, so the region is widened over a rune begun just before
+	// the edit and over the continuation bytes after it
+	start := op.Pos
+	for k := op.Pos - 1; k >= max(op.Pos-utf8.UTFMax+1, 0); k-- {
+		if !gb.runeStart(k) {
+			continue
+		}
+		if gb.rawText(k, k+1)[0] >= utf8.RuneSelf {
+			start = k
+		}
+		break
+	}
+	for start > 0 {
+		r, size := gb.runeBefore(start)
+		if !isWordRune(r) {
+			break
+		}
+		start -= size
+	}
+	end := op.Pos + op.Inserted
+	for end < gb.length && !gb.runeStart(end) {
+		end++
+	}
+	for end < gb.length {
+		r, size := gb.runeAfter(end)
+		if !isWordRune(r) {
+			break
+		}
+		end += size
+	}
+	delta := op.Inserted - op.Deleted
+
+	// Occurrences starting in the region, in the coordinates before the edit
+	i := sort.Search(len(w.occurrences), func(k int) bool { return w.occurrences[k].pos >= start })
+	j := sort.Search(len(w.occurrences), func(k int) bool { return w.occurrences[k].pos >= end-delta })
+	for _, o := range w.occurrences[i:j] {
+		w.remove(o.word)
+	}
+
+	var found []wordOccurrence
+	text := gb.rawText(start, end)
+	wordStart := -1
+	for k, r := range text {
+		switch {
+		case isWordRune(r):
+			if wordStart < 0 {
+				wordStart = k
+			}
+		case wordStart >= 0:
+			found = append(found, w.add(start+wordStart, text[wordStart:k]))
+			wordStart = -1
+		}
+	}
+	if wordStart >= 0 {
+		found = append(found, w.add(start+wordStart, text[wordStart:]))
+	}
+
+	rest := w.occurrences[j:]
+	for k := range rest {
+		rest[k].pos += delta
+	}
+	w.occurrences = append(w.occurrences[:i], append(found, rest...)...)
+}
+
+// add counts an occurrence of word at pos and returns it, sharing the
+// storage of the sorted entry of the word
+func (w *wordIndex) add(pos int, word string) wordOccurrence {
+	i := sort.SearchStrings(w.sorted, word)
+	if i == len(w.sorted) || w.sorted[i] != word {
+		w.sorted = append(w.sorted, "")
+		copy(w.sorted[i+1:], w.sorted[i:])
+		w.sorted[i] = strings.Clone(word)
+	}
+	w.counts[w.sorted[i]]++
+	return wordOccurrence{pos: pos, word: w.sorted[i]}
+}
+
+// remove uncounts an occurrence of word, dropping it from the sorted words
+// with its last occurrence
+func (w *wordIndex) remove(word string) {
+	if w.counts[word] > 1 {
+		w.counts[word]--
+		return
+	}
+	delete(w.counts, word)
+	if i := sort.SearchStrings(w.sorted, word); i < len(w.sorted) && w.sorted[i] == word {
+		w.sorted = append(w.sorted[:i], w.sorted[i+1:]...)
+	}
+}
+
+// minInts returns the smallest of values, which must not be empty
+func minInts(values []int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		m = min(m, v)
+	}
+	return m
+}
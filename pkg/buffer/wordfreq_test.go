@@ -0,0 +1,138 @@
+package buffer_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestWordCompletions checks the ordering and limits of completions
+func TestWordCompletions(t *testing.T) {
+	gb := newBuffer(t, "foo food foo, fob_1 bar\nfoo")
+	if gb.WordCompletions("fo", 0) != nil || gb.WordOccurrences("foo") != 0 {
+		t.Errorf("the word index is on before SetWordIndex")
+	}
+	gb.SetWordIndex(true)
+
+	tests := []struct {
+		prefix string
+		n      int
+		want   []buffer.WordFrequency
+	}{
+		{"fo", 0, []buffer.WordFrequency{{Word: "foo", Count: 3}, {Word: "fob_1", Count: 1}, {Word: "food", Count: 1}}},
+		{"fo", 2, []buffer.WordFrequency{{Word: "foo", Count: 3}, {Word: "fob_1", Count: 1}}},
+		{"foo", 0, []buffer.WordFrequency{{Word: "food", Count: 1}}},
+		{"x", 0, nil},
+	}
+	for _, tt := range tests {
+		if got := gb.WordCompletions(tt.prefix, tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("WordCompletions(%q, %d) = %v, want %v", tt.prefix, tt.n, got, tt.want)
+		}
+	}
+	if got := gb.WordOccurrences("foo"); got != 3 {
+		t.Errorf("got %d occurrences of foo", got)
+	}
+
+	gb.SetWordIndex(false)
+	if gb.WordOccurrences("foo") != 0 {
+		t.Errorf("the word index is still on")
+	}
+}
+
+// TestWordIndexEdits checks the incrementally updated index against one
+// built from scratch after random edits
+func TestWordIndexEdits(t *testing.T) {
+	gb := newBuffer(t, "alpha beta gamma\nbeta_2 alpha\n")
+	gb.SetWordIndex(true)
+	rng := rand.New(rand.NewSource(1))
+	inserts := []string{"a", " ", "beta", "x y", "\n", "_", "\u00E9", ".,"}
+
+	for i := 0; i < 300; i++ {
+		pos := rng.Intn(gb.Length() + 1)
+		if rng.Intn(3) == 0 && pos < gb.Length() {
+			gb.DeleteAt(pos, min(rng.Intn(4)+1, gb.Length()-pos))
+		} else {
+			gb.InsertAt(pos, inserts[rng.Intn(len(inserts))])
+		}
+
+		fresh := newBuffer(t, string(gb.Bytes()))
+		fresh.SetWordIndex(true)
+		if got, want := gb.WordCompletions("", 0), fresh.WordCompletions("", 0); !reflect.DeepEqual(got, want) {
+			t.Fatalf("edit %d: got %v, want %v", i, got, want)
+		}
+		for _, word := range []string{"a", "beta", "alpha"} {
+			if got, want := gb.WordPositions(word), fresh.WordPositions(word); !reflect.DeepEqual(got, want) {
+				t.Fatalf("edit %d: %s at %v, want %v", i, word, got, want)
+			}
+		}
+	}
+}
+
+// TestFuzzyWordCompletions checks that words whose start is within the
+// allowed errors of the query are found, the closest first
+func TestFuzzyWordCompletions(t *testing.T) {
+	gb := newBuffer(t, "function funnel fun functor fnuction banana function")
+	if gb.FuzzyWordCompletions("fun", 1, 0) != nil {
+		t.Errorf("the word index is on before SetWordIndex")
+	}
+	gb.SetWordIndex(true)
+
+	tests := []struct {
+		query     string
+		maxErrors int
+		n         int
+		want      []buffer.WordMatch
+	}{
+		{"fun", 0, 0, []buffer.WordMatch{
+			{Word: "function", Count: 2}, {Word: "functor", Count: 1}, {Word: "funnel", Count: 1},
+		}},
+		{"fumc", 1, 0, []buffer.WordMatch{
+			{Word: "function", Count: 2, Errors: 1}, {Word: "functor", Count: 1, Errors: 1},
+		}},
+		{"fumc", 2, 0, []buffer.WordMatch{
+			{Word: "function", Count: 2, Errors: 1}, {Word: "functor", Count: 1, Errors: 1},
+			{Word: "fnuction", Count: 1, Errors: 2}, {Word: "fun", Count: 1, Errors: 2}, {Word: "funnel", Count: 1, Errors: 2},
+		}},
+		{"fumc", 2, 3, []buffer.WordMatch{
+			{Word: "function", Count: 2, Errors: 1}, {Word: "functor", Count: 1, Errors: 1}, {Word: "fnuction", Count: 1, Errors: 2},
+		}},
+		{"xyz", 1, 0, nil},
+	}
+	for _, tt := range tests {
+		if got := gb.FuzzyWordCompletions(tt.query, tt.maxErrors, tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FuzzyWordCompletions(%q, %d, %d) = %v, want %v", tt.query, tt.maxErrors, tt.n, got, tt.want)
+		}
+	}
+}
+
+// TestWordPositions checks that word positions follow edits
+func TestWordPositions(t *testing.T) {
+	gb := newBuffer(t, "ab cd ab\nab")
+	if gb.WordPositions("ab") != nil {
+		t.Errorf("the word index is on before SetWordIndex")
+	}
+	gb.SetWordIndex(true)
+	if got := gb.WordPositions("ab"); !reflect.DeepEqual(got, []int{0, 6, 9}) {
+		t.Errorf("got %v", got)
+	}
+
+	gb.InsertAt(0, "xy ")    // "xy ab cd ab\nab"
+	gb.DeleteAt(5, 1)        // "xy abcd ab\nab"
+	gb.Replace(8, 10, "ab ") // "xy abcd ab \nab"
+	tests := []struct {
+		word string
+		want []int
+	}{
+		{"ab", []int{8, 12}},
+		{"abcd", []int{3}},
+		{"cd", nil},
+		{"xy", []int{0}},
+	}
+	for _, tt := range tests {
+		if got := gb.WordPositions(tt.word); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("WordPositions(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package buffer
+
+import "unicode"
+
+// WordBoundaryAfter returns the end of the first word ending after pos, or
+// the buffer length, as ctrl-right moves. Words are found with the Unicode
+// word segmentation of UAX #29, so "can't" and "3.14" are one word each and
+// every Han ideograph is a word of its own.
+func (gb *GapBuffer) WordBoundaryAfter(pos int) int {
+	pos = min(max(pos, 0), gb.length)
+	for pos < gb.length {
+		start, text, bounds := gb.wordWindow(pos)
+		for i := 1; i < len(bounds); i++ {
+			if start+bounds[i] > pos && isWordSegment(text[bounds[i-1]:bounds[i]]) {
+				return start + bounds[i]
+			}
+		}
+		pos = start + len(text)
+	}
+	return gb.length
+}
+
+// WordBoundaryBefore returns the start of the last word starting before
+// pos, or 0, as ctrl-left moves
+func (gb *GapBuffer) WordBoundaryBefore(pos int) int {
+	pos = min(max(pos, 0), gb.length)
+	for pos > 0 {
+		start, text, bounds := gb.wordWindow(pos - 1)
+		for i := len(bounds) - 1; i > 0; i-- {
+			if start+bounds[i-1] < pos && isWordSegment(text[bounds[i-1]:bounds[i]]) {
+				return start + bounds[i-1]
+			}
+		}
+		pos = start
+	}
+	return 0
+}
+
+// WordAt returns the segment of the word segmentation containing the rune
+// at pos, or the one before the end of the buffer, as double-click selects,
+// and reports whether it is a word rather than spaces or punctuation
+func (gb *GapBuffer) WordAt(pos int) (r Range, isWord bool) {
+	pos = min(max(pos, 0), gb.length)
+	if pos == gb.length {
+		if pos == 0 {
+			return Range{}, false
+		}
+		pos--
+	}
+	start, text, bounds := gb.wordWindow(pos)
+	for i := 1; i < len(bounds); i++ {
+		if start+bounds[i] > pos {
+			segment := text[bounds[i-1]:bounds[i]]
+			return Range{Start: start + bounds[i-1], End: start + bounds[i]}, isWordSegment(segment)
+		}
+	}
+	return Range{Start: pos, End: pos}, false
+}
+
+// wordWindow returns the text around pos that word segmentation looks at,
+// where it starts and its segment boundaries: the line of pos with its
+// newline, which always ends a segment, or at most graphemeContext bytes
+// each way on long lines
+func (gb *GapBuffer) wordWindow(pos int) (start int, text string, bounds []int) {
+	start = gb.graphemeScanStart(pos)
+	end := gb.lineEnd(pos)
+	if end < gb.length {
+		end++
+	}
+	if end-pos > graphemeContext {
+		end = pos + graphemeContext
+		for end < gb.length && !gb.runeStart(end) {
+			end++
+		}
+	}
+	text = gb.rawText(start, end)
+	return start, text, wordBreaks(text)
+}
+
+// isWordSegment reports whether a segment holds letters, digits or
+// ideographs
+func isWordSegment(segment string) bool {
+	for _, r := range segment {
+		if isWordRune(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordClass is the Word_Break property of a rune
+type wordClass uint8
+
+const (
+	wbOther wordClass = iota
+	wbCR
+	wbLF
+	wbNewline
+	wbExtend
+	wbZWJ
+	wbRegionalIndicator
+	wbFormat
+	wbKatakana
+	wbHebrewLetter
+	wbALetter
+	wbSingleQuote
+	wbDoubleQuote
+	wbMidNumLet
+	wbMidLetter
+	wbMidNum
+	wbNumeric
+	wbExtendNumLet
+	wbWSegSpace
+)
+
+// wordUnit is a rune with the extending and format runes following it,
+// which the rules skip over (WB4)
+type wordUnit struct {
+	pos   int
+	class wordClass
+	first rune
+	last  rune
+}
+
+// wordBreaks returns the offsets of the segment boundaries of text under
+// the UAX #29 word rules, 0 and len(text) included
+func wordBreaks(text string) []int {
+	var units []wordUnit
+	for i, r := range text {
+		c := wordClassOf(r)
+		if n := len(units); n > 0 && (c == wbExtend || c == wbFormat || c == wbZWJ) {
+			if p := units[n-1].class; p != wbCR && p != wbLF && p != wbNewline {
+				units[n-1].last = r
+				continue
+			}
+		}
+		units = append(units, wordUnit{pos: i, class: c, first: r, last: r})
+	}
+
+	bounds := []int{0}
+	ri := 0 // regional indicators right before the current unit
+	for i := 1; i < len(units); i++ {
+		if units[i-1].class == wbRegionalIndicator {
+			ri++
+		} else {
+			ri = 0
+		}
+		if wordBreakBefore(units, i, ri) {
+			bounds = append(bounds, units[i].pos)
+		}
+	}
+	if len(text) > 0 {
+		bounds = append(bounds, len(text))
+	}
+	return bounds
+}
+
+// wordBreakBefore reports whether the rules put a boundary before units[i]
+func wordBreakBefore(units []wordUnit, i int, ri int) bool {
+	a, b := units[i-1].class, units[i].class
+	before, after := wbOther, wbOther // classes two units back and one ahead
+	if i >= 2 {
+		before = units[i-2].class
+	}
+	if i+1 < len(units) {
+		after = units[i+1].class
+	}
+
+	switch {
+	case a == wbCR && b == wbLF: // WB3
+		return false
+	case a == wbCR || a == wbLF || a == wbNewline: // WB3a
+		return true
+	case b == wbCR || b == wbLF || b == wbNewline: // WB3b
+		return true
+	case units[i-1].last == 0x200D && isPictographic(units[i].first): // WB3c
+		return false
+	case a == wbWSegSpace && b == wbWSegSpace: // WB3d
+		return false
+	case isAHLetter(a) && isAHLetter(b): // WB5
+		return false
+	case isAHLetter(a) && isMidLetterQ(b) && isAHLetter(after): // WB6
+		return false
+	case isAHLetter(before) && isMidLetterQ(a) && isAHLetter(b): // WB7
+		return false
+	case a == wbHebrewLetter && b == wbSingleQuote: // WB7a
+		return false
+	case a == wbHebrewLetter && b == wbDoubleQuote && after == wbHebrewLetter: // WB7b
+		return false
+	case before == wbHebrewLetter && a == wbDoubleQuote && b == wbHebrewLetter: // WB7c
+		return false
+	case (a == wbNumeric || isAHLetter(a)) && b == wbNumeric: // WB8, WB9
+		return false
+	case a == wbNumeric && isAHLetter(b): // WB10
+		return false
+	case before == wbNumeric && isMidNumQ(a) && b == wbNumeric: // WB11
+		return false
+	case a == wbNumeric && isMidNumQ(b) && after == wbNumeric: // WB12
+		return false
+	case a == wbKatakana && b == wbKatakana: // WB13
+		return false
+	case (isAHLetter(a) || a == wbNumeric || a == wbKatakana || a == wbExtendNumLet) && b == wbExtendNumLet: // WB13a
+		return false
+	case a == wbExtendNumLet && (isAHLetter(b) || b == wbNumeric || b == wbKatakana): // WB13b
+		return false
+	case a == wbRegionalIndicator && b == wbRegionalIndicator: // WB15, WB16
+		return ri%2 == 0
+	}
+	return true
+}
+
+// isAHLetter reports whether c is a letter class of the rules
+func isAHLetter(c wordClass) bool {
+	return c == wbALetter || c == wbHebrewLetter
+}
+
+// isMidLetterQ reports whether c may join letters
+func isMidLetterQ(c wordClass) bool {
+	return c == wbMidLetter || c == wbMidNumLet || c == wbSingleQuote
+}
+
+// isMidNumQ reports whether c may join digits
+func isMidNumQ(c wordClass) bool {
+	return c == wbMidNum || c == wbMidNumLet || c == wbSingleQuote
+}
+
+// wordClassOf returns the Word_Break property of r, derived from the
+// categories and scripts the unicode package provides
+func wordClassOf(r rune) wordClass {
+	switch r {
+	case '\r':
+		return wbCR
+	case '\n':
+		return wbLF
+	case 0x0B, 0x0C, 0x85, 0x2028, 0x2029:
+		return wbNewline
+	case 0x200D:
+		return wbZWJ
+	case 0x200C:
+		return wbExtend
+	case 0x200B:
+		return wbOther
+	case '\'':
+		return wbSingleQuote
+	case '"':
+		return wbDoubleQuote
+	case '.', 0x2018, 0x2019, 0x2024, 0xFE52, 0xFF07, 0xFF0E:
+		return wbMidNumLet
+	case ':', 0xB7, 0x387, 0x55F, 0x5F4, 0x2027, 0xFE13, 0xFE55, 0xFF1A:
+		return wbMidLetter
+	case ',', ';', 0x37E, 0x589, 0x60C, 0x60D, 0x66C, 0x7F8, 0x2044, 0xFE10, 0xFE14, 0xFE50, 0xFE54, 0xFF0C, 0xFF1B:
+		return wbMidNum
+	case 0x202F:
+		return wbExtendNumLet
+	case 0x30FC, 0x309B, 0x309C, 0xFF70:
+		return wbKatakana
+	}
+
+	switch {
+	case r >= 0x1F1E6 && r <= 0x1F1FF:
+		return wbRegionalIndicator
+	case r >= 0x1F3FB && r <= 0x1F3FF,
+		unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc, unicode.Other_Grapheme_Extend):
+		return wbExtend
+	case unicode.Is(unicode.Cf, r):
+		return wbFormat
+	case unicode.Is(unicode.Katakana, r):
+		return wbKatakana
+	case unicode.Is(unicode.Hebrew, r) && unicode.IsLetter(r):
+		return wbHebrewLetter
+	case unicode.Is(unicode.Nd, r) && !(r >= 0xFF10 && r <= 0xFF19):
+		return wbNumeric
+	case unicode.Is(unicode.Pc, r):
+		return wbExtendNumLet
+	case unicode.Is(unicode.Zs, r) && r != 0xA0 && r != 0x2007:
+		return wbWSegSpace
+	case (unicode.IsLetter(r) || unicode.Is(unicode.Nl, r)) &&
+		!unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Thai, unicode.Lao, unicode.Myanmar, unicode.Khmer):
+		return wbALetter
+	}
+	return wbOther
+}
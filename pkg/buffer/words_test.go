@@ -0,0 +1,65 @@
+package buffer_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// wordsText has a contraction, a decimal number, ideographs, an
+// underscore and a newline
+const wordsText = "can't stop 3.14 日本語 foo_bar\nnext"
+
+// TestWordBoundaries checks ctrl-arrow moves across the whole text both ways
+func TestWordBoundaries(t *testing.T) {
+	gb := newBuffer(t, wordsText)
+
+	var ends []int
+	for pos := 0; pos < gb.Length(); {
+		pos = gb.WordBoundaryAfter(pos)
+		ends = append(ends, pos)
+	}
+	if want := []int{5, 10, 15, 19, 22, 25, 33, 38}; !reflect.DeepEqual(ends, want) {
+		t.Errorf("moving right: got %v, want %v", ends, want)
+	}
+
+	var starts []int
+	for pos := gb.Length(); pos > 0; {
+		pos = gb.WordBoundaryBefore(pos)
+		starts = append(starts, pos)
+	}
+	if want := []int{34, 26, 22, 19, 16, 11, 6, 0}; !reflect.DeepEqual(starts, want) {
+		t.Errorf("moving left: got %v, want %v", starts, want)
+	}
+
+	if gb.WordBoundaryAfter(100) != gb.Length() || gb.WordBoundaryBefore(-1) != 0 {
+		t.Error("positions out of range not clamped")
+	}
+}
+
+// TestWordAt checks the segments double-click selects
+func TestWordAt(t *testing.T) {
+	gb := newBuffer(t, wordsText)
+	tests := []struct {
+		pos    int
+		want   buffer.Range
+		isWord bool
+	}{
+		{0, buffer.Range{Start: 0, End: 5}, true},
+		{3, buffer.Range{Start: 0, End: 5}, true},
+		{5, buffer.Range{Start: 5, End: 6}, false},
+		{13, buffer.Range{Start: 11, End: 15}, true},
+		{20, buffer.Range{Start: 19, End: 22}, true},
+		{33, buffer.Range{Start: 33, End: 34}, false},
+		{38, buffer.Range{Start: 34, End: 38}, true},
+	}
+	for _, tt := range tests {
+		if got, isWord := gb.WordAt(tt.pos); got != tt.want || isWord != tt.isWord {
+			t.Errorf("%d: got %v, %v, want %v, %v", tt.pos, got, isWord, tt.want, tt.isWord)
+		}
+	}
+	if got, isWord := buffer.New().WordAt(0); got != (buffer.Range{}) || isWord {
+		t.Errorf("empty buffer: got %v, %v", got, isWord)
+	}
+}
@@ -0,0 +1,77 @@
+package buffer
+
+import (
+	"errors"
+	"io"
+	"os"
+	"unsafe"
+)
+
+// errMappedFileChanged is returned when the file mapped by NewFromFile no
+// longer holds the text copied from it
+var errMappedFileChanged = errors.New("mapped file changed")
+
+// writeBack writes the content to f like writeContent, but copies the runs
+// of text still coming from the file mapped by NewFromFile straight from
+// that file, with copy_file_range where the system has it, so saving a few
+// edits to a huge file moves little data through memory. The mapped file
+// is checked to still have the size and modification time it was opened
+// with; otherwise the content is written from memory as usual.
+func (gb *GapBuffer) writeBack(f *os.File) error {
+	src := gb.mappedFile
+	if src == nil || !gb.mappingUnchanged() {
+		return gb.writeContent(f)
+	}
+
+	start := uintptr(unsafe.Pointer(&gb.mapping[0]))
+	var off, n int64 // run of the mapped file waiting to be copied
+	copyRun := func() error {
+		if n == 0 {
+			return nil
+		}
+		if _, err := src.Seek(off, io.SeekStart); err != nil {
+			return err
+		}
+		copied, err := f.ReadFrom(&io.LimitedReader{R: src, N: n})
+		if err == nil && copied != n {
+			err = errMappedFileChanged
+		}
+		n = 0
+		return err
+	}
+
+	var err error
+	gb.forEachChunk(0, gb.length, func(_ int, text string) bool {
+		if gb.inMapping(text) {
+			at := int64(uintptr(unsafe.Pointer(unsafe.StringData(text))) - start)
+			if n > 0 && off+n == at {
+				n += int64(len(text))
+				return true
+			}
+			if err = copyRun(); err != nil {
+				return false
+			}
+			off, n = at, int64(len(text))
+			return true
+		}
+		if err = copyRun(); err != nil {
+			return false
+		}
+		_, err = io.WriteString(f, text)
+		return err == nil
+	})
+	if err == nil {
+		err = copyRun()
+	}
+	if err == nil && gb.hasVirtualNewline() {
+		_, err = io.WriteString(f, "\n")
+	}
+	return err
+}
+
+// mappingUnchanged reports whether the file mapped by NewFromFile still has
+// the size of the mapping and the modification time it had when opened
+func (gb *GapBuffer) mappingUnchanged() bool {
+	info, err := gb.mappedFile.Stat()
+	return err == nil && info.Size() == int64(len(gb.mapping)) && info.ModTime().Equal(gb.mappedTime)
+}
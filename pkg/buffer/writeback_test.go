@@ -0,0 +1,61 @@
+package buffer_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kebaren/gapbuffer/pkg/buffer"
+)
+
+// TestSaveFileMapped checks that saving a few edits to a mapped file,
+// whose unchanged runs are copied file to file, writes the edited text,
+// also when the file is saved over itself and edited again
+func TestSaveFileMapped(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&sb, "line %d of the mapped file\n", i)
+	}
+	text := sb.String()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.txt")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gb, err := buffer.NewFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gb.Close()
+
+	edit := func(start, end int, s string) {
+		t.Helper()
+		if err := gb.Replace(start, end, s); err != nil {
+			t.Fatal(err)
+		}
+		text = text[:start] + s + text[end:]
+	}
+	edit(10, 14, "LINE")
+	edit(len(text)/2, len(text)/2+100, "")
+	edit(len(text), len(text), "appended\n")
+
+	for i, target := range []string{filepath.Join(dir, "copy.txt"), path, path} {
+		if err := gb.SaveFile(target, buffer.SaveOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != text {
+			t.Fatalf("save %d: got %d bytes, want %d", i, len(data), len(text))
+		}
+		edit(100, 101, "#")
+	}
+	if gb.GetText() != text {
+		t.Error("buffer changed by saving over its file")
+	}
+}